@@ -7,21 +7,55 @@ import (
 	"io"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/openshift-online/ocm-sdk-go/logging"
 
 	"github.com/tzvatot/openshift-hive-simulator/pkg"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/api"
 	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
 )
 
 var (
-	configPath = flag.String("config", "", "Path to configuration file (YAML)")
-	apiPort    = flag.Int("api-port", 8080, "Port for configuration API")
-	logLevel   = flag.String("log-level", "info", "Log level (debug, info, warn, error)")
+	configPath  = flag.String("config", "", "Path to configuration file (YAML)")
+	apiPort     = flag.Int("api-port", 8080, "Port for configuration API")
+	metricsPort = flag.Int("metrics-port", 0, "Port for the Prometheus metrics endpoint (0 disables)")
+	logLevel    = flag.String("log-level", "info", "Log level (debug, info, warn, error)")
+
+	maxRuntime               = flag.Duration("max-runtime", 0, "Shut down gracefully after this duration, e.g. 30m (0 runs until signalled)")
+	cacheSyncTimeout         = flag.Duration("cache-sync-timeout", 2*time.Minute, "How long to wait for the controller caches to sync at startup before failing")
+	clientLatencyMs          = flag.Int("client-latency-ms", 0, "Delay in milliseconds injected into every controller client operation (0 disables)")
+	requireStatusSubresource = flag.Bool("require-status-subresource", false, "Fail startup if a simulated CRD is installed without the status subresource")
+	runID                    = flag.String("run-id", "", "Run ID stamped as the hive-sim/run-id label on every resource the simulator creates")
+	extraCRDDirs             = flag.String("extra-crd-dirs", "", "Comma-separated list of additional CRD directories to install at startup")
+	kubeconfigPath           = flag.String("kubeconfig-path", "", "Path to write the kubeconfig to (default: hive-simulator-kubeconfig.yaml in the temp directory)")
+	keepKubeconfig           = flag.Bool("keep-kubeconfig", false, "Keep the kubeconfig file when the simulator stops")
+	replayRequests           = flag.String("replay-requests", "", "Path to a JSONL file of recorded API requests replayed once the API server is up")
+	randomSeed               = flag.Int64("random-seed", 0, "Seed for probabilistic failure rolls, for reproducible runs (overrides randomSeed in the config file)")
+	speedFactor              = flag.Float64("speed-factor", 1, "Factor every transition delay is divided by, e.g. 10 runs lifecycles ten times faster (overrides speedFactor in the config file)")
+	apiResponseHeaders       = flag.String("api-response-headers", "", "Comma-separated list of Name=Value headers added to every configuration API response")
+	apiToken                 = flag.String("api-token", "", "Bearer token required on mutating configuration API requests (default $HIVESIM_API_TOKEN, the API is open when unset)")
+	apiTokenForReads         = flag.Bool("api-token-for-reads", false, "Require the --api-token on read-only configuration API requests too, except for the health probes")
+	apiTLSCert               = flag.String("api-tls-cert", "", "Path to the TLS certificate the configuration API is served with over HTTPS (requires --api-tls-key)")
+	apiTLSKey                = flag.String("api-tls-key", "", "Path to the TLS private key the configuration API is served with over HTTPS (requires --api-tls-cert)")
+	apiTLSSelfSigned         = flag.Bool("api-tls-selfsigned", false, "Serve the configuration API over HTTPS with a self-signed certificate written next to the kubeconfig")
+	dryRun                   = flag.Bool("dry-run", false, "Only log the state transitions the reconcilers would apply, without writing to the API server")
+	enableControllers        = flag.String("enable-controllers", "", "Comma-separated list of controllers to run: clusterdeployment, accountclaim, projectclaim (default all)")
 )
 
+// CRD sources, each flag can be repeated
+var (
+	crdDirs listFlag
+	crdURLs listFlag
+)
+
+func init() {
+	flag.Var(&crdDirs, "crd-dir", "Directory of the simulator's CRDs, can be repeated (default: auto-detected); a missing directory fails startup")
+	flag.Var(&crdURLs, "crd-url", "URL of CRD YAML downloaded and installed at startup, can be repeated")
+}
+
 func main() {
 	flag.Parse()
 
@@ -36,14 +70,101 @@ func main() {
 	logger.Info(ctx, "Hive Simulator starting...")
 	logger.Info(ctx, "  Config file: %s", getConfigPath(*configPath))
 	logger.Info(ctx, "  API port: %d", *apiPort)
+	if *metricsPort > 0 {
+		logger.Info(ctx, "  Metrics port: %d", *metricsPort)
+	}
 	logger.Info(ctx, "  Log level: %s", *logLevel)
+	if *clientLatencyMs > 0 {
+		logger.Info(ctx, "  Client latency: %dms", *clientLatencyMs)
+	}
+	if *maxRuntime > 0 {
+		logger.Info(ctx, "  Max runtime: %v", *maxRuntime)
+	}
 
 	// Load configuration
-	cfg, err := config.LoadFromFile(*configPath)
+	cfg, warnings, err := config.LoadFromFile(*configPath)
 	if err != nil {
 		logger.Error(ctx, "Failed to load configuration: %v", err)
 		os.Exit(1)
 	}
+	logConfigWarnings(ctx, logger, warnings)
+
+	if *runID != "" {
+		if err := cfg.SetRunID(*runID); err != nil {
+			logger.Error(ctx, "Invalid --run-id: %v", err)
+			os.Exit(1)
+		}
+		logger.Info(ctx, "  Run ID: %s", cfg.RunID)
+	}
+
+	if isFlagSet("random-seed") {
+		cfg.RandomSeed = randomSeed
+	}
+	if cfg.RandomSeed != nil {
+		logger.Info(ctx, "  Random seed: %d", *cfg.RandomSeed)
+	}
+
+	if isFlagSet("speed-factor") {
+		if *speedFactor <= 0 {
+			logger.Error(ctx, "Invalid --speed-factor: must be > 0, got %v", *speedFactor)
+			os.Exit(1)
+		}
+		cfg.SpeedFactor = *speedFactor
+	}
+	if cfg.GetSpeedFactor() != 1 {
+		logger.Info(ctx, "  Speed factor: %v", cfg.GetSpeedFactor())
+	}
+
+	if *apiResponseHeaders != "" {
+		if err := cfg.SetAPIResponseHeaders(splitList(*apiResponseHeaders)); err != nil {
+			logger.Error(ctx, "Invalid --api-response-headers: %v", err)
+			os.Exit(1)
+		}
+	}
+
+	controllers := splitList(*enableControllers)
+	if err := hive_simulator.ValidateControllers(controllers); err != nil {
+		logger.Error(ctx, "Invalid --enable-controllers: %v", err)
+		os.Exit(1)
+	}
+	if len(controllers) > 0 {
+		logger.Info(ctx, "  Enabled controllers: %s", strings.Join(controllers, ", "))
+	}
+
+	token := *apiToken
+	if token == "" {
+		token = os.Getenv(apiTokenEnvVar)
+	}
+	if token != "" {
+		logger.Info(ctx, "  API authentication: bearer token required")
+	} else if *apiTokenForReads {
+		logger.Error(ctx, "Invalid --api-token-for-reads: requires --api-token or %s", apiTokenEnvVar)
+		os.Exit(1)
+	}
+
+	if err := hive_simulator.ValidateAPITLS(*apiTLSCert, *apiTLSKey, *apiTLSSelfSigned); err != nil {
+		logger.Error(ctx, "Invalid API TLS flags: %v", err)
+		os.Exit(1)
+	}
+	if *apiTLSCert != "" {
+		logger.Info(ctx, "  API TLS certificate: %s", *apiTLSCert)
+	} else if *apiTLSSelfSigned {
+		logger.Info(ctx, "  API TLS certificate: self-signed")
+	}
+
+	if *dryRun {
+		logger.Info(ctx, "  Dry run: transitions are logged, not applied")
+	}
+
+	var recordedRequests []api.RecordedRequest
+	if *replayRequests != "" {
+		recordedRequests, err = api.LoadRecordedRequests(*replayRequests)
+		if err != nil {
+			logger.Error(ctx, "Failed to load recorded API requests: %v", err)
+			os.Exit(1)
+		}
+		logger.Info(ctx, "  Replay requests: %d from %s", len(recordedRequests), *replayRequests)
+	}
 
 	logger.Info(ctx, "Configuration loaded successfully")
 	logger.Debug(ctx, "  ClusterDeployment delay: %ds", cfg.ClusterDeployment.DefaultDelaySeconds)
@@ -52,7 +173,26 @@ func main() {
 	logger.Debug(ctx, "  ClusterImageSets: %d", len(cfg.ClusterImageSets))
 
 	// Create server
-	server := hive_simulator.NewServer(logger, cfg, *apiPort)
+	server := hive_simulator.NewServer(logger, cfg, hive_simulator.ServerOptions{
+		APIPort:                  *apiPort,
+		ClientLatency:            time.Duration(*clientLatencyMs) * time.Millisecond,
+		CacheSyncTimeout:         *cacheSyncTimeout,
+		RequireStatusSubresource: *requireStatusSubresource,
+		CRDDirs:                  crdDirs,
+		CRDURLs:                  crdURLs,
+		ExtraCRDDirs:             splitList(*extraCRDDirs),
+		KubeconfigPath:           *kubeconfigPath,
+		KeepKubeconfig:           *keepKubeconfig,
+		ReplayRequests:           recordedRequests,
+		MetricsPort:              *metricsPort,
+		EnabledControllers:       controllers,
+		APIToken:                 token,
+		APITokenForReads:         *apiTokenForReads,
+		APITLSCertFile:           *apiTLSCert,
+		APITLSKeyFile:            *apiTLSKey,
+		APITLSSelfSigned:         *apiTLSSelfSigned,
+		DryRun:                   *dryRun,
+	})
 
 	// Setup signal handling for graceful shutdown
 	ctx, cancel := context.WithCancel(ctx)
@@ -67,6 +207,26 @@ func main() {
 		cancel()
 	}()
 
+	// Reload the configuration file on SIGHUP, keeping per-resource overrides
+	reloadChan := make(chan os.Signal, 1)
+	signal.Notify(reloadChan, syscall.SIGHUP)
+
+	go func() {
+		for {
+			select {
+			case <-reloadChan:
+				reloadConfig(ctx, logger, server, *configPath)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// Enforce maximum runtime so a hung run can't go on forever
+	if *maxRuntime > 0 {
+		enforceMaxRuntime(ctx, logger, *maxRuntime, cancel)
+	}
+
 	// Start server
 	if err := server.Start(ctx); err != nil {
 		logger.Error(ctx, "Server failed: %v", err)
@@ -76,6 +236,51 @@ func main() {
 	logger.Info(ctx, "Hive Simulator exited cleanly")
 }
 
+// apiTokenEnvVar is the environment variable the API token is read from when --api-token is unset
+const apiTokenEnvVar = "HIVESIM_API_TOKEN"
+
+// enforceMaxRuntime calls cancel once the given runtime elapses, unless ctx is done first.
+// The returned channel is closed once it has stopped waiting.
+func enforceMaxRuntime(ctx context.Context, logger logging.Logger, maxRuntime time.Duration, cancel context.CancelFunc) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		timer := time.NewTimer(maxRuntime)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+			logger.Warn(ctx, "Maximum runtime of %v reached, shutting down...", maxRuntime)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return done
+}
+
+// reloadConfig loads the configuration file again and applies it to the running server.
+// An invalid file is logged and the current configuration is kept.
+func reloadConfig(ctx context.Context, logger logging.Logger, server *hive_simulator.Server, path string) {
+	logger.Info(ctx, "Reloading configuration from %s", getConfigPath(path))
+
+	cfg, warnings, err := config.LoadFromFile(path)
+	if err != nil {
+		logger.Error(ctx, "Failed to reload configuration, keeping the current one: %v", err)
+		return
+	}
+	logConfigWarnings(ctx, logger, warnings)
+
+	server.ReloadConfig(ctx, cfg)
+	logger.Info(ctx, "Configuration reloaded")
+}
+
+// logConfigWarnings logs the settings of the configuration that are valid but have no effect
+func logConfigWarnings(ctx context.Context, logger logging.Logger, warnings []string) {
+	for _, warning := range warnings {
+		logger.Warn(ctx, "Configuration warning: %s", warning)
+	}
+}
+
 // timestampWriter wraps an io.Writer and adds timestamps to each line
 type timestampWriter struct {
 	writer io.Writer
@@ -123,3 +328,39 @@ func getConfigPath(path string) string {
 	}
 	return path
 }
+
+// isFlagSet returns true if the flag with the given name was passed on the command line
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// listFlag is a flag that collects the values of every time it is given
+type listFlag []string
+
+// String returns the collected values, comma-separated
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set adds a value
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+)
+
+func TestEnforceMaxRuntime_CancelsContext(t *testing.T) {
+	logger, err := setupLogger("error")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	enforceMaxRuntime(ctx, logger, 10*time.Millisecond, cancel)
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("context was not cancelled after max runtime")
+	}
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+}
+
+func TestEnforceMaxRuntime_StopsOnEarlierShutdown(t *testing.T) {
+	logger, err := setupLogger("error")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var stops atomic.Int32
+	done := enforceMaxRuntime(ctx, logger, time.Hour, func() { stops.Add(1) })
+
+	// Shutting down before the max runtime ends the wait without stopping again
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("max runtime kept waiting after shutdown")
+	}
+	assert.Zero(t, stops.Load())
+}
+
+func TestReloadConfig(t *testing.T) {
+	logger, err := setupLogger("error")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	cfg := config.DefaultConfig()
+	server := hive_simulator.NewServer(logger, cfg, hive_simulator.ServerOptions{})
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	// An invalid file keeps the current configuration
+	require.NoError(t, os.WriteFile(path, []byte("clusterDeployment:\n  defaultDelaySeconds: -1\n"), 0o600))
+	reloadConfig(ctx, logger, server, path)
+	assert.Equal(t, 5, cfg.ClusterDeployment.DefaultDelaySeconds)
+
+	require.NoError(t, os.WriteFile(path, []byte("clusterDeployment:\n  defaultDelaySeconds: 42\n"), 0o600))
+	reloadConfig(ctx, logger, server, path)
+	assert.Equal(t, 42, cfg.ClusterDeployment.DefaultDelaySeconds)
+}
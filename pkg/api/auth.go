@@ -0,0 +1,58 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// unauthenticatedPaths are never gated by the API token, so that liveness and readiness
+// probes keep working
+var unauthenticatedPaths = map[string]bool{
+	"/api/v1/healthz": true,
+	"/api/v1/readyz":  true,
+}
+
+// SetAPIToken sets the bearer token required on mutating API requests, no token is required
+// when empty
+func (h *Handlers) SetAPIToken(token string) {
+	h.apiToken = token
+}
+
+// SetRequireTokenForReads requires the API token on read-only requests too, except for the
+// health probes
+func (h *Handlers) SetRequireTokenForReads(require bool) {
+	h.requireTokenForReads = require
+}
+
+// requireAPIToken is a middleware rejecting requests that need the API token and lack it with 401
+func (h *Handlers) requireAPIToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.apiToken == "" || !h.needsAPIToken(r) || h.hasAPIToken(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		h.logger.Debug(r.Context(), "Rejecting %s %s without a valid API token", r.Method, r.URL.Path)
+		w.Header().Set("WWW-Authenticate", `Bearer realm="hive-simulator"`)
+		h.writeError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+	})
+}
+
+// needsAPIToken returns true if the request has to carry the API token: mutating requests
+// always do, read-only ones only when reads are gated
+func (h *Handlers) needsAPIToken(r *http.Request) bool {
+	if unauthenticatedPaths[r.URL.Path] {
+		return false
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return h.requireTokenForReads
+	}
+	return true
+}
+
+// hasAPIToken returns true if the request carries the API token as a bearer token
+func (h *Handlers) hasAPIToken(r *http.Request) bool {
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return found && subtle.ConstantTimeCompare([]byte(token), []byte(h.apiToken)) == 1
+}
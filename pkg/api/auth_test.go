@@ -0,0 +1,65 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func doRequestWithToken(handlers *Handlers, method, path, token string) *httptest.ResponseRecorder {
+	router := SetupRoutes(handlers)
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAPIToken_NotConfigured(t *testing.T) {
+	handlers := createTestHandlers(t)
+
+	rec := doRequest(handlers, http.MethodPost, "/api/v1/pause")
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestAPIToken_MutatingRequests(t *testing.T) {
+	handlers := createTestHandlers(t)
+	handlers.SetAPIToken("s3cret")
+
+	rec := doRequest(handlers, http.MethodPost, "/api/v1/pause")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Header().Get("WWW-Authenticate"), "Bearer")
+	assert.False(t, handlers.behaviorEngine.IsReconcilePaused())
+
+	rec = doRequestWithToken(handlers, http.MethodPost, "/api/v1/pause", "wrong")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.False(t, handlers.behaviorEngine.IsReconcilePaused())
+
+	rec = doRequestWithToken(handlers, http.MethodPost, "/api/v1/pause", "s3cret")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, handlers.behaviorEngine.IsReconcilePaused())
+
+	// Reads stay open
+	rec = doRequest(handlers, http.MethodGet, "/api/v1/version")
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestAPIToken_Reads(t *testing.T) {
+	handlers := createTestHandlers(t)
+	handlers.SetAPIToken("s3cret")
+	handlers.SetRequireTokenForReads(true)
+
+	rec := doRequest(handlers, http.MethodGet, "/api/v1/version")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = doRequestWithToken(handlers, http.MethodGet, "/api/v1/version", "s3cret")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// The health probes never need the token
+	rec = doRequest(handlers, http.MethodGet, "/api/v1/healthz")
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
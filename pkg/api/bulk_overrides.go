@@ -0,0 +1,123 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	errors "github.com/zgalor/weberr"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/behavior"
+)
+
+// Statuses of the items of a bulk override request
+const (
+	bulkStatusSet      = "set"
+	bulkStatusCleared  = "cleared"
+	bulkStatusNotFound = "not found"
+	bulkStatusInvalid  = "invalid"
+)
+
+// BulkOverrideResult is the outcome of one item of a bulk override request
+type BulkOverrideResult struct {
+	Key    string `json:"key"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// SetOverridesBulk sets the overrides of many resources in one request. Valid items are all
+// applied at once, invalid ones are reported in the per-item results and skipped.
+func (h *Handlers) SetOverridesBulk(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.logger.Debug(ctx, "POST /api/v1/overrides/bulk")
+
+	var entries []behavior.ResourceOverrideEntry
+	if err := json.NewDecoder(r.Body).Decode(&entries); err != nil {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+
+	results := make([]BulkOverrideResult, 0, len(entries))
+	valid := make([]behavior.ResourceOverrideEntry, 0, len(entries))
+	for _, entry := range entries {
+		key := fmt.Sprintf("%s/%s/%s", entry.ResourceType, entry.Namespace, entry.Name)
+		if err := validateOverrideEntry(&entry); err != nil {
+			results = append(results, BulkOverrideResult{Key: key, Status: bulkStatusInvalid, Error: err.Error()})
+			continue
+		}
+		valid = append(valid, entry)
+		key = fmt.Sprintf("%s/%s/%s", entry.ResourceType, entry.Namespace, entry.Name)
+		results = append(results, BulkOverrideResult{Key: key, Status: bulkStatusSet})
+	}
+
+	h.behaviorEngine.SetResourceOverrides(ctx, valid)
+	h.writeJSON(w, http.StatusOK, results)
+}
+
+// validateOverrideEntry checks an item of a bulk override request, normalizing its resource
+// type to the kind and filling in the resource name of the override
+func validateOverrideEntry(entry *behavior.ResourceOverrideEntry) error {
+	kind, ok := resourceKinds[strings.ToLower(entry.ResourceType)]
+	if !ok {
+		return errors.Errorf("unknown resource type: %s", entry.ResourceType)
+	}
+	if entry.Namespace == "" || entry.Name == "" {
+		return errors.Errorf("namespace and name are required")
+	}
+	if entry.Override == nil {
+		return errors.Errorf("override is required")
+	}
+	if entry.Override.DelaySeconds != nil && *entry.Override.DelaySeconds < 0 {
+		return errors.Errorf("delaySeconds must be >= 0")
+	}
+
+	entry.ResourceType = kind
+	if entry.Override.ResourceName == "" {
+		entry.Override.ResourceName = entry.Name
+	}
+	return nil
+}
+
+// ClearOverridesBulk clears the overrides of a list of type/namespace/name keys in one request
+func (h *Handlers) ClearOverridesBulk(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.logger.Debug(ctx, "DELETE /api/v1/overrides/bulk")
+
+	var keys []string
+	if err := json.NewDecoder(r.Body).Decode(&keys); err != nil {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+
+	results := make([]BulkOverrideResult, len(keys))
+	var valid []string
+	var validIndexes []int
+	for i, key := range keys {
+		results[i].Key = key
+		segments := strings.Split(key, "/")
+		if len(segments) != 3 || segments[1] == "" || segments[2] == "" {
+			results[i].Status = bulkStatusInvalid
+			results[i].Error = fmt.Sprintf("key %q must have the form type/namespace/name", key)
+			continue
+		}
+		kind, ok := resourceKinds[strings.ToLower(segments[0])]
+		if !ok {
+			results[i].Status = bulkStatusInvalid
+			results[i].Error = fmt.Sprintf("unknown resource type: %s", segments[0])
+			continue
+		}
+		valid = append(valid, fmt.Sprintf("%s/%s/%s", kind, segments[1], segments[2]))
+		validIndexes = append(validIndexes, i)
+	}
+
+	cleared := h.behaviorEngine.ClearResourceOverrides(ctx, valid)
+	for i, index := range validIndexes {
+		results[index].Key = valid[i]
+		results[index].Status = bulkStatusNotFound
+		if cleared[i] {
+			results[index].Status = bulkStatusCleared
+		}
+	}
+	h.writeJSON(w, http.StatusOK, results)
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlers_SetOverridesBulk(t *testing.T) {
+	handlers := createTestHandlers(t)
+
+	rec := doRequestWithBody(handlers, http.MethodPost, "/api/v1/overrides/bulk", `[
+		{"resourceType": "clusterdeployment", "namespace": "default", "name": "cd-1", "override": {"delaySeconds": 30}},
+		{"resourceType": "machinepool", "namespace": "default", "name": "mp-1", "override": {"delaySeconds": 30}},
+		{"resourceType": "accountclaim", "namespace": "default", "name": "ac-1"},
+		{"resourceType": "AccountClaim", "namespace": "default", "name": "ac-2", "override": {"forceSuccess": true}}
+	]`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var results []BulkOverrideResult
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &results))
+	require.Len(t, results, 4)
+	assert.Equal(t, BulkOverrideResult{Key: "ClusterDeployment/default/cd-1", Status: "set"}, results[0])
+	assert.Equal(t, "invalid", results[1].Status)
+	assert.Contains(t, results[1].Error, "unknown resource type")
+	assert.Equal(t, "invalid", results[2].Status)
+	assert.Contains(t, results[2].Error, "override is required")
+	assert.Equal(t, BulkOverrideResult{Key: "AccountClaim/default/ac-2", Status: "set"}, results[3])
+
+	overrides := handlers.behaviorEngine.ListOverrides()
+	require.Len(t, overrides, 2)
+	assert.Equal(t, "cd-1", overrides["ClusterDeployment/default/cd-1"].ResourceName)
+	assert.True(t, overrides["AccountClaim/default/ac-2"].ForceSuccess)
+}
+
+func TestHandlers_ClearOverridesBulk(t *testing.T) {
+	handlers := createTestHandlers(t)
+
+	rec := doRequestWithBody(handlers, http.MethodPost, "/api/v1/overrides/bulk",
+		`[{"resourceType": "clusterdeployment", "namespace": "default", "name": "cd-1", "override": {"forceSuccess": true}}]`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = doRequestWithBody(handlers, http.MethodDelete, "/api/v1/overrides/bulk",
+		`["clusterdeployment/default/cd-1", "ClusterDeployment/default/cd-2", "cd-3"]`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var results []BulkOverrideResult
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &results))
+	require.Len(t, results, 3)
+	assert.Equal(t, BulkOverrideResult{Key: "ClusterDeployment/default/cd-1", Status: "cleared"}, results[0])
+	assert.Equal(t, BulkOverrideResult{Key: "ClusterDeployment/default/cd-2", Status: "not found"}, results[1])
+	assert.Equal(t, "invalid", results[2].Status)
+	assert.Empty(t, handlers.behaviorEngine.ListOverrides())
+}
+
+func TestHandlers_SetOverridesBulk_InvalidBody(t *testing.T) {
+	handlers := createTestHandlers(t)
+
+	rec := doRequestWithBody(handlers, http.MethodPost, "/api/v1/overrides/bulk", `{"resourceType": "clusterdeployment"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	kuberrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/clock"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gorilla/mux"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+
+	aaov1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/aws-account-operator/v1alpha1"
+	gcpv1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/gcp-project-operator/v1alpha1"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/state_machine"
+)
+
+// ResourceETA is the estimated time until a resource reaches its terminal state.
+// ETASeconds is nil when the resource is in a state that is not configured.
+type ResourceETA struct {
+	ResourceType string   `json:"resourceType"`
+	Namespace    string   `json:"namespace"`
+	Name         string   `json:"name"`
+	State        string   `json:"state"`
+	ETASeconds   *float64 `json:"etaSeconds"`
+}
+
+// resourceKinds maps the resource type path parameter to the kind used by the controllers
+var resourceKinds = map[string]string{
+	"clusterdeployment": "ClusterDeployment",
+	"accountclaim":      "AccountClaim",
+	"projectclaim":      "ProjectClaim",
+}
+
+// GetResourceETA estimates how long until a resource reaches its terminal state, based on
+// its current state, the time already spent in it and the remaining configured state durations
+func (h *Handlers) GetResourceETA(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	resourceType := vars["type"]
+	namespace := vars["namespace"]
+	name := vars["name"]
+
+	h.logger.Debug(ctx, "GET /api/v1/resources/%s/%s/%s/eta", resourceType, namespace, name)
+
+	kind, ok := resourceKinds[strings.ToLower(resourceType)]
+	if !ok {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Unknown resource type: %s", resourceType))
+		return
+	}
+
+	delay := func(resourceLabels map[string]string) func(time.Duration) time.Duration {
+		return func(duration time.Duration) time.Duration {
+			return h.behaviorEngine.GetTransitionDelay(ctx, kind, namespace, name, resourceLabels, duration)
+		}
+	}
+	key := client.ObjectKey{Namespace: namespace, Name: name}
+
+	var state string
+	var remaining time.Duration
+	var err, estimateErr error
+	switch kind {
+	case "ClusterDeployment":
+		cd := &hivev1.ClusterDeployment{}
+		if err = h.k8sClient.Get(ctx, key, cd); err == nil {
+			sm := state_machine.NewClusterDeploymentStateMachine(h.logger, h.behaviorEngine.GetClusterDeploymentConfig(), clock.RealClock{})
+			state, remaining, estimateErr = sm.EstimateRemaining(cd, delay(cd.Labels))
+		}
+	case "AccountClaim":
+		ac := &aaov1alpha1.AccountClaim{}
+		if err = h.k8sClient.Get(ctx, key, ac); err == nil {
+			sm := state_machine.NewAccountClaimStateMachine(h.logger, h.behaviorEngine.GetAccountClaimConfig(), clock.RealClock{})
+			state, remaining, estimateErr = sm.EstimateRemaining(ac, delay(ac.Labels))
+		}
+	case "ProjectClaim":
+		pc := &gcpv1alpha1.ProjectClaim{}
+		if err = h.k8sClient.Get(ctx, key, pc); err == nil {
+			sm := state_machine.NewProjectClaimStateMachine(h.logger, h.behaviorEngine.GetProjectClaimConfig(), clock.RealClock{})
+			state, remaining, estimateErr = sm.EstimateRemaining(pc, delay(pc.Labels))
+		}
+	}
+	if kuberrors.IsNotFound(err) {
+		h.writeError(w, http.StatusNotFound, fmt.Sprintf("%s %s/%s not found", kind, namespace, name))
+		return
+	}
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get %s %s/%s: %v", kind, namespace, name, err))
+		return
+	}
+
+	eta := ResourceETA{
+		ResourceType: kind,
+		Namespace:    namespace,
+		Name:         name,
+		State:        state,
+	}
+	if estimateErr != nil {
+		h.logger.Warn(ctx, "Cannot estimate ETA of %s %s/%s: %v", kind, namespace, name, estimateErr)
+	} else {
+		seconds := remaining.Seconds()
+		eta.ETASeconds = &seconds
+	}
+	h.writeJSON(w, http.StatusOK, eta)
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	aaov1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/aws-account-operator/v1alpha1"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+)
+
+func TestHandlers_GetResourceETA_ClusterDeployment(t *testing.T) {
+	// Partway through: Provisioning (2s) and Installing (1s) remain before Running
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "cd-1", Namespace: "default"},
+		Status: hivev1.ClusterDeploymentStatus{
+			ProvisionRef: &corev1.LocalObjectReference{Name: "cd-1-provision"},
+		},
+	}
+	handlers := createTestHandlers(t, cd)
+
+	rec := doRequest(handlers, http.MethodGet, "/api/v1/resources/clusterdeployment/default/cd-1/eta")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var eta ResourceETA
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &eta))
+	assert.Equal(t, "ClusterDeployment", eta.ResourceType)
+	assert.Equal(t, "Provisioning", eta.State)
+	require.NotNil(t, eta.ETASeconds)
+	assert.Equal(t, float64(3), *eta.ETASeconds)
+
+	// A delay override replaces each remaining state duration
+	delay := 10
+	handlers.behaviorEngine.SetResourceOverride(context.Background(), "ClusterDeployment", "default", "cd-1",
+		&config.ResourceOverride{DelaySeconds: &delay})
+
+	rec = doRequest(handlers, http.MethodGet, "/api/v1/resources/clusterdeployment/default/cd-1/eta")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &eta))
+	require.NotNil(t, eta.ETASeconds)
+	assert.Equal(t, float64(20), *eta.ETASeconds)
+}
+
+func TestHandlers_GetResourceETA_TerminalAccountClaim(t *testing.T) {
+	ac := &aaov1alpha1.AccountClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "ac-1", Namespace: "default"},
+		Status:     aaov1alpha1.AccountClaimStatus{State: aaov1alpha1.ClaimStatusReady},
+	}
+	handlers := createTestHandlers(t, ac)
+
+	rec := doRequest(handlers, http.MethodGet, "/api/v1/resources/accountclaim/default/ac-1/eta")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var eta ResourceETA
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &eta))
+	assert.Equal(t, "Ready", eta.State)
+	require.NotNil(t, eta.ETASeconds)
+	assert.Zero(t, *eta.ETASeconds)
+}
+
+func TestHandlers_GetResourceETA_SubtractsTimeInState(t *testing.T) {
+	// Provisioning (2s) was entered 1.5s ago, Installing (1s) still follows
+	entered := metav1.NewTime(time.Now().Add(-1500 * time.Millisecond))
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "cd-1", Namespace: "default"},
+		Status: hivev1.ClusterDeploymentStatus{
+			ProvisionRef: &corev1.LocalObjectReference{Name: "cd-1-provision"},
+			Conditions: []hivev1.ClusterDeploymentCondition{
+				{Type: "DeprovisionLaunchError", Status: corev1.ConditionFalse, LastTransitionTime: entered},
+			},
+		},
+	}
+	handlers := createTestHandlers(t, cd)
+
+	rec := doRequest(handlers, http.MethodGet, "/api/v1/resources/clusterdeployment/default/cd-1/eta")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var eta ResourceETA
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &eta))
+	require.NotNil(t, eta.ETASeconds)
+	assert.InDelta(t, 1.5, *eta.ETASeconds, 0.5)
+}
+
+func TestHandlers_GetResourceETA_UnknownState(t *testing.T) {
+	ac := &aaov1alpha1.AccountClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "ac-1", Namespace: "default"},
+		Status:     aaov1alpha1.AccountClaimStatus{State: "Reclaiming"},
+	}
+	handlers := createTestHandlers(t, ac)
+
+	rec := doRequest(handlers, http.MethodGet, "/api/v1/resources/accountclaim/default/ac-1/eta")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var eta ResourceETA
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &eta))
+	assert.Equal(t, "Reclaiming", eta.State)
+	assert.Nil(t, eta.ETASeconds)
+}
+
+func TestHandlers_GetResourceETA_Errors(t *testing.T) {
+	handlers := createTestHandlers(t)
+
+	rec := doRequest(handlers, http.MethodGet, "/api/v1/resources/clusterdeployment/default/missing/eta")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = doRequest(handlers, http.MethodGet, "/api/v1/resources/machinepool/default/mp-1/eta")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
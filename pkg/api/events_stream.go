@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// StreamEvents streams every state transition as Server-Sent Events until the client
+// disconnects, optionally filtered to one resource type with ?resourceType=
+func (h *Handlers) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.logger.Debug(ctx, "GET /api/v1/events/stream")
+
+	kind := ""
+	if resourceType := r.URL.Query().Get("resourceType"); resourceType != "" {
+		var ok bool
+		kind, ok = resourceKinds[strings.ToLower(resourceType)]
+		if !ok {
+			h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Unknown resource type: %s", resourceType))
+			return
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		h.writeError(w, http.StatusInternalServerError, "Streaming is not supported")
+		return
+	}
+
+	transitions, unsubscribe := h.behaviorEngine.SubscribeTransitions()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-ctx.Done():
+			h.logger.Debug(ctx, "Event stream client disconnected")
+			return
+		case transition, ok := <-transitions:
+			if !ok {
+				return
+			}
+			if kind != "" && transition.ResourceType != kind {
+				continue
+			}
+			data, err := json.Marshal(transition)
+			if err != nil {
+				h.logger.Error(ctx, "Failed to encode transition: %v", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/behavior"
+)
+
+// readTransition reads the next SSE data line of the stream as a transition
+func readTransition(t *testing.T, reader *bufio.Reader) behavior.Transition {
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		if data, ok := strings.CutPrefix(line, "data: "); ok {
+			var transition behavior.Transition
+			require.NoError(t, json.Unmarshal([]byte(data), &transition))
+			return transition
+		}
+	}
+}
+
+func TestHandlers_StreamEvents(t *testing.T) {
+	handlers := createTestHandlers(t)
+	server := httptest.NewServer(SetupRoutes(handlers))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/v1/events/stream?resourceType=accountclaim", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// The ClusterDeployment transition is filtered out
+	handlers.behaviorEngine.PublishTransition("ClusterDeployment", "default", "cd-1", "Provisioning")
+	handlers.behaviorEngine.PublishTransition("AccountClaim", "default", "ac-1", "Ready")
+
+	transition := readTransition(t, bufio.NewReader(resp.Body))
+	assert.Equal(t, "AccountClaim", transition.ResourceType)
+	assert.Equal(t, "ac-1", transition.Name)
+	assert.Equal(t, "Ready", transition.State)
+}
+
+func TestHandlers_StreamEvents_UnknownResourceType(t *testing.T) {
+	handlers := createTestHandlers(t)
+
+	rec := doRequest(handlers, http.MethodGet, "/api/v1/events/stream?resourceType=machinepool")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestHandlers_StreamEvents_ClientDisconnect(t *testing.T) {
+	handlers := createTestHandlers(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequestWithContext(ctx, http.MethodGet, "/api/v1/events/stream", nil)
+	rec := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handlers.StreamEvents(rec, req)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream did not end after the client disconnected")
+	}
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/behavior"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+)
+
+// SimulatorState is the configuration and the per-resource overrides of the simulator, as
+// exported to reproduce a run elsewhere
+type SimulatorState struct {
+	Config    *config.Config                      `json:"config"`
+	Overrides map[string]*config.ResourceOverride `json:"overrides"`
+}
+
+// ExportState returns the current configuration and active per-resource overrides
+func (h *Handlers) ExportState(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.logger.Debug(ctx, "GET /api/v1/export")
+
+	h.writeJSON(w, http.StatusOK, SimulatorState{
+		Config:    h.behaviorEngine.GetConfig(),
+		Overrides: h.behaviorEngine.ListOverrides(),
+	})
+}
+
+// ImportState replaces the configuration and the per-resource overrides with exported ones.
+// Nothing changes unless the whole state is valid.
+func (h *Handlers) ImportState(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.logger.Debug(ctx, "POST /api/v1/import")
+
+	var state SimulatorState
+	if err := json.NewDecoder(r.Body).Decode(&state); err != nil {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+	if state.Config == nil {
+		h.writeError(w, http.StatusBadRequest, "config is required")
+		return
+	}
+	if _, err := config.Validate(state.Config); err != nil {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid configuration: %v", err))
+		return
+	}
+	overrides := make(map[string]*config.ResourceOverride, len(state.Overrides))
+	for key, override := range state.Overrides {
+		segments := strings.Split(key, "/")
+		if len(segments) != 3 {
+			h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Override key %q must have the form type/namespace/name", key))
+			return
+		}
+		entry := behavior.ResourceOverrideEntry{
+			ResourceType: segments[0],
+			Namespace:    segments[1],
+			Name:         segments[2],
+			Override:     override,
+		}
+		if err := validateOverrideEntry(&entry); err != nil {
+			h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid override %s: %v", key, err))
+			return
+		}
+		overrides[fmt.Sprintf("%s/%s/%s", entry.ResourceType, entry.Namespace, entry.Name)] = entry.Override
+	}
+
+	h.behaviorEngine.ImportState(ctx, state.Config, overrides)
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":    "imported",
+		"overrides": len(overrides),
+	})
+}
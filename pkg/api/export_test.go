@@ -0,0 +1,61 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlers_ExportImportRoundTrip(t *testing.T) {
+	handlers := createTestHandlers(t)
+
+	rec := doRequestWithBody(handlers, http.MethodPost, "/api/v1/overrides/ClusterDeployment/default/cd-1/delay", `{"delaySeconds": 30}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = doRequestWithBody(handlers, http.MethodPost, "/api/v1/speed", `{"speedFactor": 4}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = doRequest(handlers, http.MethodGet, "/api/v1/export")
+	require.Equal(t, http.StatusOK, rec.Code)
+	exported := rec.Body.String()
+	assert.Contains(t, exported, "ClusterDeployment/default/cd-1")
+
+	// Reset the overrides and change the configuration
+	rec = doRequest(handlers, http.MethodPost, "/api/v1/reset")
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = doRequestWithBody(handlers, http.MethodPost, "/api/v1/speed", `{"speedFactor": 1}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = doRequest(handlers, http.MethodGet, "/api/v1/export")
+	require.NotEqual(t, exported, rec.Body.String())
+
+	rec = doRequestWithBody(handlers, http.MethodPost, "/api/v1/import", exported)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = doRequest(handlers, http.MethodGet, "/api/v1/export")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, exported, rec.Body.String())
+}
+
+func TestHandlers_ImportInvalidLeavesStateIntact(t *testing.T) {
+	handlers := createTestHandlers(t)
+
+	rec := doRequestWithBody(handlers, http.MethodPost, "/api/v1/overrides/ClusterDeployment/default/cd-1/delay", `{"delaySeconds": 30}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = doRequest(handlers, http.MethodGet, "/api/v1/export")
+	before := rec.Body.String()
+
+	for _, body := range []string{
+		`{"overrides": {}}`,
+		`{"config": {"clusterDeployment": {"defaultDelaySeconds": -1}}, "overrides": {}}`,
+		`{"config": {}, "overrides": {"cd-1": {"resourceName": "cd-1"}}}`,
+		`{"config": {}, "overrides": {"Widget/default/w-1": {"resourceName": "w-1"}}}`,
+		`{"config": {}, "overrides": {"ClusterDeployment/default/cd-2": {"delaySeconds": -5}}}`,
+	} {
+		rec = doRequestWithBody(handlers, http.MethodPost, "/api/v1/import", body)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+	}
+
+	rec = doRequest(handlers, http.MethodGet, "/api/v1/export")
+	assert.JSONEq(t, before, rec.Body.String())
+}
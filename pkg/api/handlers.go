@@ -5,27 +5,87 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
+	"strings"
 	"time"
 
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
 	"github.com/gorilla/mux"
 	"github.com/openshift-online/ocm-sdk-go/logging"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/prometheus/client_golang/prometheus"
 
+	aaov1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/aws-account-operator/v1alpha1"
+	gcpv1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/gcp-project-operator/v1alpha1"
 	"github.com/tzvatot/openshift-hive-simulator/pkg/behavior"
 	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/labels"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/notify"
 )
 
 // Handlers provides HTTP handlers for the simulator API
 type Handlers struct {
-	logger         logging.Logger
-	behaviorEngine *behavior.Engine
-	startTime      time.Time
+	logger          logging.Logger
+	behaviorEngine  *behavior.Engine
+	k8sClient       client.Client
+	startTime       time.Time
+	startedCheck    ReadinessCheck
+	readinessChecks []namedReadinessCheck
+	apiServerURL    string
+	controllers     []string
+	tlsEnabled      bool
+	responseHeaders map[string]string
+	imageSetBuilder ImageSetBuilder
+	notifier        *notify.Notifier
+	metricsGatherer prometheus.Gatherer
+
+	// apiToken, when set, is the bearer token required on mutating requests, and on reads too
+	// with requireTokenForReads
+	apiToken             string
+	requireTokenForReads bool
+}
+
+// ReadinessCheck returns an error while the component it checks is not ready
+type ReadinessCheck func(ctx context.Context) error
+
+// namedReadinessCheck is a readiness check with the name it is reported under
+type namedReadinessCheck struct {
+	name  string
+	check ReadinessCheck
+}
+
+// ResyncAnnotation is bumped on every simulated resource to force a reconcile
+const ResyncAnnotation = "hive-simulator.openshift.io/resync"
+
+// OverrideStatus describes an active per-resource override
+type OverrideStatus struct {
+	ResourceName string `json:"resourceName"`
+	// DelaySeconds replaces every state duration of the resource, nil when not overridden
+	DelaySeconds *int                    `json:"delaySeconds"`
+	ForceFail    bool                    `json:"forceFail"`
+	ForceSuccess bool                    `json:"forceSuccess"`
+	Failure      *config.FailureScenario `json:"failure,omitempty"`
+	ExpiresAt    *time.Time              `json:"expiresAt,omitempty"`
+	Paused       bool                    `json:"paused,omitempty"`
+	// AtState is the state the failure is forced in, from the override or else its failure,
+	// empty for any state
+	AtState string `json:"atState,omitempty"`
+}
+
+// NamespaceSummary describes the simulated resources found in a namespace
+type NamespaceSummary struct {
+	Namespace string         `json:"namespace"`
+	Total     int            `json:"total"`
+	Resources map[string]int `json:"resources"`
 }
 
 // NewHandlers creates new API handlers
-func NewHandlers(logger logging.Logger, behaviorEngine *behavior.Engine) *Handlers {
+func NewHandlers(logger logging.Logger, behaviorEngine *behavior.Engine, k8sClient client.Client) *Handlers {
 	return &Handlers{
 		logger:         logger,
 		behaviorEngine: behaviorEngine,
+		k8sClient:      k8sClient,
 		startTime:      time.Now().UTC(),
 	}
 }
@@ -36,7 +96,35 @@ func (h *Handlers) GetConfig(w http.ResponseWriter, r *http.Request) {
 	h.logger.Debug(ctx, "GET /api/v1/config")
 
 	cfg := h.behaviorEngine.GetConfig()
-	h.writeJSON(w, http.StatusOK, cfg)
+	warnings, _ := config.Validate(cfg.DeepCopy())
+	h.writeJSON(w, http.StatusOK, configResponse{Config: cfg, Warnings: warnings})
+}
+
+// configResponse is the current configuration, along with the warnings about its settings
+// that have no effect
+type configResponse struct {
+	*config.Config
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// GetResourceConfig returns a copy of the configuration of a single resource type
+func (h *Handlers) GetResourceConfig(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	resourceType := mux.Vars(r)["resourceType"]
+	h.logger.Debug(ctx, "GET /api/v1/config/%s", resourceType)
+
+	// Encoded from a copy, like the whole configuration, so as not to race with updates
+	cfg := h.behaviorEngine.GetConfig()
+	switch resourceKinds[strings.ToLower(resourceType)] {
+	case "ClusterDeployment":
+		h.writeJSON(w, http.StatusOK, cfg.ClusterDeployment)
+	case "AccountClaim":
+		h.writeJSON(w, http.StatusOK, cfg.AccountClaim)
+	case "ProjectClaim":
+		h.writeJSON(w, http.StatusOK, cfg.ProjectClaim)
+	default:
+		h.writeError(w, http.StatusNotFound, fmt.Sprintf("Unknown resource type: %s", resourceType))
+	}
 }
 
 // UpdateClusterDeploymentConfig updates ClusterDeployment configuration
@@ -45,8 +133,7 @@ func (h *Handlers) UpdateClusterDeploymentConfig(w http.ResponseWriter, r *http.
 	h.logger.Debug(ctx, "POST /api/v1/config/clusterdeployment")
 
 	var cfg config.ClusterDeploymentConfig
-	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
-		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+	if !h.decodeConfig(w, r, &cfg) {
 		return
 	}
 
@@ -60,8 +147,7 @@ func (h *Handlers) UpdateAccountClaimConfig(w http.ResponseWriter, r *http.Reque
 	h.logger.Debug(ctx, "POST /api/v1/config/accountclaim")
 
 	var cfg config.AccountClaimConfig
-	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
-		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+	if !h.decodeConfig(w, r, &cfg) {
 		return
 	}
 
@@ -75,8 +161,7 @@ func (h *Handlers) UpdateProjectClaimConfig(w http.ResponseWriter, r *http.Reque
 	h.logger.Debug(ctx, "POST /api/v1/config/projectclaim")
 
 	var cfg config.ProjectClaimConfig
-	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
-		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+	if !h.decodeConfig(w, r, &cfg) {
 		return
 	}
 
@@ -84,6 +169,59 @@ func (h *Handlers) UpdateProjectClaimConfig(w http.ResponseWriter, r *http.Reque
 	h.writeJSON(w, http.StatusOK, map[string]string{"status": "updated"})
 }
 
+// UpdateStateDuration changes the duration of a single configured state
+func (h *Handlers) UpdateStateDuration(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	resourceType := vars["resourceType"]
+	stateName := vars["stateName"]
+
+	h.logger.Debug(ctx, "PATCH /api/v1/config/%s/states/%s", resourceType, stateName)
+
+	kind, ok := resourceKinds[strings.ToLower(resourceType)]
+	if !ok {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Unknown resource type: %s", resourceType))
+		return
+	}
+
+	var req struct {
+		DurationSeconds *int `json:"durationSeconds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+	if req.DurationSeconds == nil || *req.DurationSeconds < 0 {
+		h.writeError(w, http.StatusBadRequest, "durationSeconds is required and must be >= 0")
+		return
+	}
+
+	if !h.behaviorEngine.SetStateDuration(ctx, kind, stateName, *req.DurationSeconds) {
+		h.writeError(w, http.StatusNotFound, fmt.Sprintf("%s state %s not found in configuration", kind, stateName))
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]string{"status": "updated"})
+}
+
+// SetSpeedFactor changes the factor every transition delay is divided by
+func (h *Handlers) SetSpeedFactor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.logger.Debug(ctx, "POST /api/v1/speed")
+
+	var req struct {
+		SpeedFactor float64 `json:"speedFactor"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+	if err := h.behaviorEngine.SetSpeedFactor(ctx, req.SpeedFactor); err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]float64{"speedFactor": req.SpeedFactor})
+}
+
 // SetResourceFailure forces a failure for a specific resource
 func (h *Handlers) SetResourceFailure(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -94,15 +232,28 @@ func (h *Handlers) SetResourceFailure(w http.ResponseWriter, r *http.Request) {
 
 	h.logger.Debug(ctx, "POST /api/v1/overrides/%s/%s/%s/failure", resourceType, namespace, name)
 
-	var failure config.FailureScenario
-	if err := json.NewDecoder(r.Body).Decode(&failure); err != nil {
+	resourceType, ok := h.overrideResourceKind(w, resourceType)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		config.FailureScenario
+		TTLSeconds int `json:"ttlSeconds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
 		return
 	}
+	expiresAt, ok := h.overrideExpiry(w, req.TTLSeconds)
+	if !ok {
+		return
+	}
 
 	override := &config.ResourceOverride{
 		ResourceName: name,
-		ForceFail:    &failure,
+		ForceFail:    &req.FailureScenario,
+		ExpiresAt:    expiresAt,
 	}
 
 	h.behaviorEngine.SetResourceOverride(ctx, resourceType, namespace, name, override)
@@ -119,23 +270,61 @@ func (h *Handlers) SetResourceDelay(w http.ResponseWriter, r *http.Request) {
 
 	h.logger.Debug(ctx, "POST /api/v1/overrides/%s/%s/%s/delay", resourceType, namespace, name)
 
+	resourceType, ok := h.overrideResourceKind(w, resourceType)
+	if !ok {
+		return
+	}
+
 	var req struct {
 		DelaySeconds int `json:"delaySeconds"`
+		TTLSeconds   int `json:"ttlSeconds"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
 		return
 	}
+	expiresAt, ok := h.overrideExpiry(w, req.TTLSeconds)
+	if !ok {
+		return
+	}
 
 	override := &config.ResourceOverride{
 		ResourceName: name,
 		DelaySeconds: &req.DelaySeconds,
+		ExpiresAt:    expiresAt,
 	}
 
 	h.behaviorEngine.SetResourceOverride(ctx, resourceType, namespace, name, override)
 	h.writeJSON(w, http.StatusOK, map[string]string{"status": "delay set"})
 }
 
+// overrideResourceKind returns the kind of the resource type of an override path, which is
+// matched case-insensitively. It writes an error response listing the valid types and
+// returns false if the type is unknown, as an override stored under it would never match.
+func (h *Handlers) overrideResourceKind(w http.ResponseWriter, resourceType string) (string, bool) {
+	kind, ok := resourceKinds[strings.ToLower(resourceType)]
+	if !ok {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf(
+			"Unknown resource type: %s, valid types are ClusterDeployment, AccountClaim and ProjectClaim", resourceType))
+		return "", false
+	}
+	return kind, true
+}
+
+// overrideExpiry returns when an override set with the given TTL expires, nil for no TTL.
+// It writes an error response and returns false if the TTL is invalid.
+func (h *Handlers) overrideExpiry(w http.ResponseWriter, ttlSeconds int) (*time.Time, bool) {
+	if ttlSeconds < 0 {
+		h.writeError(w, http.StatusBadRequest, "ttlSeconds must be >= 0")
+		return nil, false
+	}
+	if ttlSeconds == 0 {
+		return nil, true
+	}
+	expiresAt := time.Now().UTC().Add(time.Duration(ttlSeconds) * time.Second)
+	return &expiresAt, true
+}
+
 // SetResourceSuccess forces success for a specific resource
 func (h *Handlers) SetResourceSuccess(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -146,6 +335,11 @@ func (h *Handlers) SetResourceSuccess(w http.ResponseWriter, r *http.Request) {
 
 	h.logger.Debug(ctx, "POST /api/v1/overrides/%s/%s/%s/success", resourceType, namespace, name)
 
+	resourceType, ok := h.overrideResourceKind(w, resourceType)
+	if !ok {
+		return
+	}
+
 	override := &config.ResourceOverride{
 		ResourceName: name,
 		ForceSuccess: true,
@@ -155,6 +349,72 @@ func (h *Handlers) SetResourceSuccess(w http.ResponseWriter, r *http.Request) {
 	h.writeJSON(w, http.StatusOK, map[string]string{"status": "forced success set"})
 }
 
+// PauseResource holds a specific resource in its current state until it is resumed
+func (h *Handlers) PauseResource(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	resourceType := vars["resourceType"]
+	namespace := vars["namespace"]
+	name := vars["name"]
+
+	h.logger.Debug(ctx, "POST /api/v1/overrides/%s/%s/%s/pause", resourceType, namespace, name)
+
+	resourceType, ok := h.overrideResourceKind(w, resourceType)
+	if !ok {
+		return
+	}
+
+	h.behaviorEngine.SetPaused(ctx, resourceType, namespace, name, true)
+	h.writeJSON(w, http.StatusOK, map[string]string{"status": "paused"})
+}
+
+// ResumeResource lets a paused resource continue its progression
+func (h *Handlers) ResumeResource(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	resourceType := vars["resourceType"]
+	namespace := vars["namespace"]
+	name := vars["name"]
+
+	h.logger.Debug(ctx, "POST /api/v1/overrides/%s/%s/%s/resume", resourceType, namespace, name)
+
+	resourceType, ok := h.overrideResourceKind(w, resourceType)
+	if !ok {
+		return
+	}
+
+	if !h.behaviorEngine.SetPaused(ctx, resourceType, namespace, name, false) {
+		h.writeError(w, http.StatusNotFound, fmt.Sprintf("%s %s/%s is not paused", resourceType, namespace, name))
+		return
+	}
+	h.writeJSON(w, http.StatusOK, map[string]string{"status": "resumed"})
+}
+
+// ListOverrides returns the active per-resource overrides keyed by resourceType/namespace/name
+func (h *Handlers) ListOverrides(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.logger.Debug(ctx, "GET /api/v1/overrides")
+
+	overrides := make(map[string]OverrideStatus)
+	for key, override := range h.behaviorEngine.ListOverrides() {
+		atState := override.AtState
+		if atState == "" && override.ForceFail != nil {
+			atState = override.ForceFail.AtState
+		}
+		overrides[key] = OverrideStatus{
+			ResourceName: override.ResourceName,
+			DelaySeconds: override.DelaySeconds,
+			ForceFail:    override.ForceFail != nil,
+			ForceSuccess: override.ForceSuccess,
+			Failure:      override.ForceFail,
+			ExpiresAt:    override.ExpiresAt,
+			Paused:       override.Paused,
+			AtState:      atState,
+		}
+	}
+	h.writeJSON(w, http.StatusOK, overrides)
+}
+
 // ClearResourceOverride clears overrides for a specific resource
 func (h *Handlers) ClearResourceOverride(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -165,17 +425,237 @@ func (h *Handlers) ClearResourceOverride(w http.ResponseWriter, r *http.Request)
 
 	h.logger.Debug(ctx, "DELETE /api/v1/overrides/%s/%s/%s", resourceType, namespace, name)
 
+	resourceType, ok := h.overrideResourceKind(w, resourceType)
+	if !ok {
+		return
+	}
+
 	h.behaviorEngine.ClearResourceOverride(ctx, resourceType, namespace, name)
 	h.writeJSON(w, http.StatusOK, map[string]string{"status": "override cleared"})
 }
 
-// Reset resets all overrides
+// ClearOverridesMatching clears all overrides whose type/namespace/name key matches the pattern query parameter
+func (h *Handlers) ClearOverridesMatching(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	pattern := r.URL.Query().Get("pattern")
+
+	h.logger.Debug(ctx, "DELETE /api/v1/overrides?pattern=%s", pattern)
+
+	if pattern == "" {
+		h.writeError(w, http.StatusBadRequest, "Missing required query parameter: pattern")
+		return
+	}
+
+	cleared, err := h.behaviorEngine.ClearOverridesMatching(ctx, pattern)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":  "overrides cleared",
+		"cleared": cleared,
+	})
+}
+
+// AddSelectorOverride registers an override for all resources of a type matching a label selector
+func (h *Handlers) AddSelectorOverride(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.logger.Debug(ctx, "POST /api/v1/selector-overrides")
+
+	var override config.SelectorOverride
+	if err := json.NewDecoder(r.Body).Decode(&override); err != nil {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+
+	kind, ok := resourceKinds[strings.ToLower(override.ResourceType)]
+	if !ok {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Unknown resource type: %s", override.ResourceType))
+		return
+	}
+	override.ResourceType = kind
+
+	if err := h.behaviorEngine.AddSelectorOverride(ctx, &override); err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, override)
+}
+
+// ListSelectorOverrides returns the selector overrides in evaluation order
+func (h *Handlers) ListSelectorOverrides(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.logger.Debug(ctx, "GET /api/v1/selector-overrides")
+
+	h.writeJSON(w, http.StatusOK, h.behaviorEngine.ListSelectorOverrides())
+}
+
+// ClearSelectorOverrides clears all selector overrides
+func (h *Handlers) ClearSelectorOverrides(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.logger.Debug(ctx, "DELETE /api/v1/selector-overrides")
+
+	h.behaviorEngine.ClearSelectorOverrides(ctx)
+	h.writeJSON(w, http.StatusOK, map[string]string{"status": "selector overrides cleared"})
+}
+
+// Reset resets all overrides, or only those of resources labeled with the runID query parameter
 func (h *Handlers) Reset(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	h.logger.Debug(ctx, "POST /api/v1/reset")
+	runID := r.URL.Query().Get("runID")
+	h.logger.Debug(ctx, "POST /api/v1/reset?runID=%s", runID)
+
+	if runID == "" {
+		h.behaviorEngine.ClearAllOverrides(ctx)
+		h.writeJSON(w, http.StatusOK, map[string]string{"status": "all overrides cleared"})
+		return
+	}
+
+	opts := runIDListOptions(runID)
+
+	cdList := &hivev1.ClusterDeploymentList{}
+	if err := h.k8sClient.List(ctx, cdList, opts...); err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list ClusterDeployments: %v", err))
+		return
+	}
+	for i := range cdList.Items {
+		h.behaviorEngine.ClearResourceOverride(ctx, "ClusterDeployment", cdList.Items[i].Namespace, cdList.Items[i].Name)
+	}
+
+	acList := &aaov1alpha1.AccountClaimList{}
+	if err := h.k8sClient.List(ctx, acList, opts...); err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list AccountClaims: %v", err))
+		return
+	}
+	for i := range acList.Items {
+		h.behaviorEngine.ClearResourceOverride(ctx, "AccountClaim", acList.Items[i].Namespace, acList.Items[i].Name)
+	}
+
+	pcList := &gcpv1alpha1.ProjectClaimList{}
+	if err := h.k8sClient.List(ctx, pcList, opts...); err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list ProjectClaims: %v", err))
+		return
+	}
+	for i := range pcList.Items {
+		h.behaviorEngine.ClearResourceOverride(ctx, "ProjectClaim", pcList.Items[i].Namespace, pcList.Items[i].Name)
+	}
+
+	h.writeJSON(w, http.StatusOK, map[string]string{
+		"status": "overrides cleared",
+		"runID":  runID,
+	})
+}
+
+// AddReadinessCheck registers a check that must pass before the simulator reports ready
+func (h *Handlers) AddReadinessCheck(name string, check ReadinessCheck) {
+	h.readinessChecks = append(h.readinessChecks, namedReadinessCheck{name: name, check: check})
+}
+
+// SetStartedCheck sets the check that fails until the simulator has finished starting, after
+// envtest is up and the controller caches have synced. Both health endpoints report it.
+func (h *Handlers) SetStartedCheck(check ReadinessCheck) {
+	h.startedCheck = check
+}
+
+// SetAPIServerURL sets the URL of the Kubernetes API server reported by the status endpoint.
+// It changes on every restart, so automation can use it to rewrite downstream configs.
+func (h *Handlers) SetAPIServerURL(url string) {
+	h.apiServerURL = url
+}
+
+// SetEnabledControllers sets the names of the running reconcilers reported by the status endpoint
+func (h *Handlers) SetEnabledControllers(names []string) {
+	h.controllers = names
+}
+
+// SetTLSEnabled sets whether the API is served over HTTPS, as reported by the status endpoint
+func (h *Handlers) SetTLSEnabled(enabled bool) {
+	h.tlsEnabled = enabled
+}
+
+// SetResponseHeaders sets headers added to every API response
+func (h *Handlers) SetResponseHeaders(headers map[string]string) {
+	h.responseHeaders = headers
+}
+
+// addResponseHeaders is a middleware adding the configured headers to every response
+func (h *Handlers) addResponseHeaders(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for name, value := range h.responseHeaders {
+			w.Header().Set(name, value)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Healthz returns 503 until the simulator has finished starting
+func (h *Handlers) Healthz(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.logger.Debug(ctx, "GET /api/v1/healthz")
+
+	if h.startedCheck != nil {
+		if err := h.startedCheck(ctx); err != nil {
+			h.writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": err.Error()})
+			return
+		}
+	}
+	h.writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Readyz runs all readiness checks and returns 503 if any of them fails. The simulator has
+// to have finished starting as well, and the body includes the Kubernetes API server URL.
+func (h *Handlers) Readyz(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.logger.Debug(ctx, "GET /api/v1/readyz")
+
+	checks := make([]namedReadinessCheck, 0, len(h.readinessChecks)+1)
+	if h.startedCheck != nil {
+		checks = append(checks, namedReadinessCheck{name: "started", check: h.startedCheck})
+	}
+	checks = append(checks, h.readinessChecks...)
+
+	ready := true
+	results := make(map[string]string, len(checks))
+	for _, c := range checks {
+		if err := c.check(ctx); err != nil {
+			ready = false
+			results[c.name] = err.Error()
+			continue
+		}
+		results[c.name] = "ok"
+	}
+
+	status := http.StatusOK
+	if !ready {
+		status = http.StatusServiceUnavailable
+	}
+	body := map[string]interface{}{
+		"ready":  ready,
+		"checks": results,
+	}
+	if h.apiServerURL != "" {
+		body["apiServerURL"] = h.apiServerURL
+	}
+	h.writeJSON(w, status, body)
+}
+
+// PauseReconciliation stops every resource from progressing until reconciliation is resumed
+func (h *Handlers) PauseReconciliation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.logger.Debug(ctx, "POST /api/v1/pause")
 
-	h.behaviorEngine.ClearAllOverrides(ctx)
-	h.writeJSON(w, http.StatusOK, map[string]string{"status": "all overrides cleared"})
+	h.behaviorEngine.SetReconcilePaused(ctx, true)
+	h.writeJSON(w, http.StatusOK, map[string]string{"status": "paused"})
+}
+
+// ResumeReconciliation lets every resource continue from the state it was paused in
+func (h *Handlers) ResumeReconciliation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.logger.Debug(ctx, "POST /api/v1/resume")
+
+	h.behaviorEngine.SetReconcilePaused(ctx, false)
+	h.writeJSON(w, http.StatusOK, map[string]string{"status": "resumed"})
 }
 
 // GetStatus returns the simulator status
@@ -187,11 +667,161 @@ func (h *Handlers) GetStatus(w http.ResponseWriter, r *http.Request) {
 	status := map[string]interface{}{
 		"healthy": true,
 		"uptime":  uptime.String(),
+		"paused":  h.behaviorEngine.IsReconcilePaused(),
+		"tls":     h.tlsEnabled,
+	}
+	if h.apiServerURL != "" {
+		status["apiServerURL"] = h.apiServerURL
+	}
+	if h.controllers != nil {
+		status["controllers"] = h.controllers
 	}
 
 	h.writeJSON(w, http.StatusOK, status)
 }
 
+// GetRolls returns the most recent probabilistic failure rolls, to explain why a resource
+// did or did not fail
+func (h *Handlers) GetRolls(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.logger.Debug(ctx, "GET /api/v1/debug/rolls")
+
+	h.writeJSON(w, http.StatusOK, h.behaviorEngine.GetRolls())
+}
+
+// ListNamespaces returns the namespaces that contain simulated resources, optionally
+// counting only resources labeled with the runID query parameter
+func (h *Handlers) ListNamespaces(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	runID := r.URL.Query().Get("runID")
+	h.logger.Debug(ctx, "GET /api/v1/namespaces?runID=%s", runID)
+
+	opts := runIDListOptions(runID)
+
+	counts := make(map[string]map[string]int)
+	add := func(namespace, kind string) {
+		if counts[namespace] == nil {
+			counts[namespace] = make(map[string]int)
+		}
+		counts[namespace][kind]++
+	}
+
+	cdList := &hivev1.ClusterDeploymentList{}
+	if err := h.k8sClient.List(ctx, cdList, opts...); err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list ClusterDeployments: %v", err))
+		return
+	}
+	for i := range cdList.Items {
+		add(cdList.Items[i].Namespace, "ClusterDeployment")
+	}
+
+	acList := &aaov1alpha1.AccountClaimList{}
+	if err := h.k8sClient.List(ctx, acList, opts...); err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list AccountClaims: %v", err))
+		return
+	}
+	for i := range acList.Items {
+		add(acList.Items[i].Namespace, "AccountClaim")
+	}
+
+	pcList := &gcpv1alpha1.ProjectClaimList{}
+	if err := h.k8sClient.List(ctx, pcList, opts...); err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list ProjectClaims: %v", err))
+		return
+	}
+	for i := range pcList.Items {
+		add(pcList.Items[i].Namespace, "ProjectClaim")
+	}
+
+	summaries := make([]NamespaceSummary, 0, len(counts))
+	for namespace, resources := range counts {
+		total := 0
+		for _, count := range resources {
+			total += count
+		}
+		summaries = append(summaries, NamespaceSummary{
+			Namespace: namespace,
+			Total:     total,
+			Resources: resources,
+		})
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Namespace < summaries[j].Namespace
+	})
+
+	h.writeJSON(w, http.StatusOK, summaries)
+}
+
+// Resync touches every simulated resource so that it gets reconciled again
+func (h *Handlers) Resync(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.logger.Debug(ctx, "POST /api/v1/resync")
+
+	stamp := time.Now().UTC().Format(time.RFC3339Nano)
+	enqueued := 0
+
+	touch := func(obj client.Object) error {
+		annotations := obj.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[ResyncAnnotation] = stamp
+		obj.SetAnnotations(annotations)
+		if err := h.k8sClient.Update(ctx, obj); err != nil {
+			return err
+		}
+		enqueued++
+		return nil
+	}
+
+	cdList := &hivev1.ClusterDeploymentList{}
+	if err := h.k8sClient.List(ctx, cdList); err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list ClusterDeployments: %v", err))
+		return
+	}
+	for i := range cdList.Items {
+		if err := touch(&cdList.Items[i]); err != nil {
+			h.logger.Warn(ctx, "Failed to resync ClusterDeployment %s/%s: %v", cdList.Items[i].Namespace, cdList.Items[i].Name, err)
+		}
+	}
+
+	acList := &aaov1alpha1.AccountClaimList{}
+	if err := h.k8sClient.List(ctx, acList); err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list AccountClaims: %v", err))
+		return
+	}
+	for i := range acList.Items {
+		if err := touch(&acList.Items[i]); err != nil {
+			h.logger.Warn(ctx, "Failed to resync AccountClaim %s/%s: %v", acList.Items[i].Namespace, acList.Items[i].Name, err)
+		}
+	}
+
+	pcList := &gcpv1alpha1.ProjectClaimList{}
+	if err := h.k8sClient.List(ctx, pcList); err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list ProjectClaims: %v", err))
+		return
+	}
+	for i := range pcList.Items {
+		if err := touch(&pcList.Items[i]); err != nil {
+			h.logger.Warn(ctx, "Failed to resync ProjectClaim %s/%s: %v", pcList.Items[i].Namespace, pcList.Items[i].Name, err)
+		}
+	}
+
+	h.logger.Info(ctx, "Resync enqueued %d resources", enqueued)
+	h.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":   "resync triggered",
+		"enqueued": enqueued,
+	})
+}
+
+// runIDListOptions restricts a list to resources created under the given run ID, if any
+func runIDListOptions(runID string) []client.ListOption {
+	if runID == "" {
+		return nil
+	}
+	return []client.ListOption{client.MatchingLabels{labels.RunID: runID}}
+}
+
 // writeJSON writes a JSON response
 func (h *Handlers) writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
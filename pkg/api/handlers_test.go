@@ -0,0 +1,669 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/openshift-online/ocm-sdk-go/logging"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	aaov1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/aws-account-operator/v1alpha1"
+	gcpv1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/gcp-project-operator/v1alpha1"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/behavior"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/labels"
+)
+
+func createTestLogger() logging.Logger {
+	builder := logging.NewStdLoggerBuilder()
+	builder.Info(true)
+	logger, _ := builder.Build()
+	return logger
+}
+
+func createTestScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, hivev1.AddToScheme(scheme))
+	require.NoError(t, aaov1alpha1.AddToScheme(scheme))
+	require.NoError(t, gcpv1alpha1.AddToScheme(scheme))
+	return scheme
+}
+
+func createTestHandlers(t *testing.T, objects ...client.Object) *Handlers {
+	return createTestHandlersWithConfig(t, config.DefaultConfig(), objects...)
+}
+
+func createTestHandlersWithConfig(t *testing.T, cfg *config.Config, objects ...client.Object) *Handlers {
+	logger := createTestLogger()
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(createTestScheme(t)).
+		WithObjects(objects...).
+		WithStatusSubresource(
+			&hivev1.ClusterDeployment{},
+			&aaov1alpha1.AccountClaim{},
+			&gcpv1alpha1.ProjectClaim{},
+		).
+		Build()
+	engine := behavior.NewEngine(logger, cfg)
+	return NewHandlers(logger, engine, k8sClient)
+}
+
+func doRequest(handlers *Handlers, method, path string) *httptest.ResponseRecorder {
+	return doRequestWithBody(handlers, method, path, "")
+}
+
+func doRequestWithBody(handlers *Handlers, method, path, body string) *httptest.ResponseRecorder {
+	router := SetupRoutes(handlers)
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandlers_ListNamespaces(t *testing.T) {
+	handlers := createTestHandlers(t,
+		&hivev1.ClusterDeployment{ObjectMeta: metav1.ObjectMeta{Name: "cd-1", Namespace: "team-a"}},
+		&hivev1.ClusterDeployment{ObjectMeta: metav1.ObjectMeta{Name: "cd-2", Namespace: "team-a"}},
+		&aaov1alpha1.AccountClaim{ObjectMeta: metav1.ObjectMeta{Name: "ac-1", Namespace: "team-a"}},
+		&gcpv1alpha1.ProjectClaim{ObjectMeta: metav1.ObjectMeta{Name: "pc-1", Namespace: "team-b"}},
+	)
+
+	rec := doRequest(handlers, http.MethodGet, "/api/v1/namespaces")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var summaries []NamespaceSummary
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &summaries))
+	require.Len(t, summaries, 2)
+
+	assert.Equal(t, "team-a", summaries[0].Namespace)
+	assert.Equal(t, 3, summaries[0].Total)
+	assert.Equal(t, 2, summaries[0].Resources["ClusterDeployment"])
+	assert.Equal(t, 1, summaries[0].Resources["AccountClaim"])
+
+	assert.Equal(t, "team-b", summaries[1].Namespace)
+	assert.Equal(t, 1, summaries[1].Total)
+	assert.Equal(t, 1, summaries[1].Resources["ProjectClaim"])
+}
+
+func TestHandlers_ListNamespaces_Empty(t *testing.T) {
+	handlers := createTestHandlers(t)
+
+	rec := doRequest(handlers, http.MethodGet, "/api/v1/namespaces")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var summaries []NamespaceSummary
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &summaries))
+	assert.Empty(t, summaries)
+}
+
+func TestHandlers_Resync(t *testing.T) {
+	cd := &hivev1.ClusterDeployment{ObjectMeta: metav1.ObjectMeta{Name: "cd-1", Namespace: "default"}}
+	ac := &aaov1alpha1.AccountClaim{ObjectMeta: metav1.ObjectMeta{Name: "ac-1", Namespace: "default"}}
+	pc := &gcpv1alpha1.ProjectClaim{ObjectMeta: metav1.ObjectMeta{Name: "pc-1", Namespace: "other"}}
+	handlers := createTestHandlers(t, cd, ac, pc)
+
+	rec := doRequest(handlers, http.MethodPost, "/api/v1/resync")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, float64(3), resp["enqueued"])
+
+	ctx := context.Background()
+	updatedCD := &hivev1.ClusterDeployment{}
+	require.NoError(t, handlers.k8sClient.Get(ctx, client.ObjectKeyFromObject(cd), updatedCD))
+	assert.NotEmpty(t, updatedCD.Annotations[ResyncAnnotation])
+
+	updatedAC := &aaov1alpha1.AccountClaim{}
+	require.NoError(t, handlers.k8sClient.Get(ctx, client.ObjectKeyFromObject(ac), updatedAC))
+	assert.NotEmpty(t, updatedAC.Annotations[ResyncAnnotation])
+
+	updatedPC := &gcpv1alpha1.ProjectClaim{}
+	require.NoError(t, handlers.k8sClient.Get(ctx, client.ObjectKeyFromObject(pc), updatedPC))
+	assert.NotEmpty(t, updatedPC.Annotations[ResyncAnnotation])
+}
+
+func TestHandlers_RunIDFiltering(t *testing.T) {
+	runLabels := map[string]string{labels.RunID: "ci-1"}
+	handlers := createTestHandlers(t,
+		&hivev1.ClusterDeployment{ObjectMeta: metav1.ObjectMeta{Name: "cd-1", Namespace: "team-a", Labels: runLabels}},
+		&hivev1.ClusterDeployment{ObjectMeta: metav1.ObjectMeta{Name: "cd-2", Namespace: "team-a"}},
+		&aaov1alpha1.AccountClaim{ObjectMeta: metav1.ObjectMeta{Name: "ac-1", Namespace: "team-b"}},
+	)
+	ctx := context.Background()
+
+	rec := doRequest(handlers, http.MethodGet, "/api/v1/namespaces?runID=ci-1")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var summaries []NamespaceSummary
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &summaries))
+	require.Len(t, summaries, 1)
+	assert.Equal(t, "team-a", summaries[0].Namespace)
+	assert.Equal(t, 1, summaries[0].Total)
+
+	// Reset with a run ID only clears overrides of that run's resources
+	delay := 10
+	for _, name := range []string{"cd-1", "cd-2"} {
+		handlers.behaviorEngine.SetResourceOverride(ctx, "ClusterDeployment", "team-a", name,
+			&config.ResourceOverride{DelaySeconds: &delay})
+	}
+
+	rec = doRequest(handlers, http.MethodPost, "/api/v1/reset?runID=ci-1")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	assert.Equal(t, 5*time.Second, handlers.behaviorEngine.GetTransitionDelay(ctx, "ClusterDeployment", "team-a", "cd-1", nil, 5*time.Second))
+	assert.Equal(t, 10*time.Second, handlers.behaviorEngine.GetTransitionDelay(ctx, "ClusterDeployment", "team-a", "cd-2", nil, 5*time.Second))
+}
+
+func TestHandlers_Readyz(t *testing.T) {
+	handlers := createTestHandlers(t)
+
+	var checkErr error
+	handlers.AddReadinessCheck("clusterImageSets", func(ctx context.Context) error {
+		return checkErr
+	})
+
+	checkErr = fmt.Errorf("ClusterImageSets missing: openshift-v4.17.0")
+	rec := doRequest(handlers, http.MethodGet, "/api/v1/readyz")
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	var resp struct {
+		Ready  bool              `json:"ready"`
+		Checks map[string]string `json:"checks"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.False(t, resp.Ready)
+	assert.Equal(t, "ClusterImageSets missing: openshift-v4.17.0", resp.Checks["clusterImageSets"])
+
+	checkErr = nil
+	rec = doRequest(handlers, http.MethodGet, "/api/v1/readyz")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.True(t, resp.Ready)
+}
+
+func TestHandlers_Healthz(t *testing.T) {
+	handlers := createTestHandlers(t)
+	handlers.SetAPIServerURL("https://127.0.0.1:43567")
+
+	var started atomic.Bool
+	handlers.SetStartedCheck(func(ctx context.Context) error {
+		if !started.Load() {
+			return fmt.Errorf("simulator is not started")
+		}
+		return nil
+	})
+
+	rec := doRequest(handlers, http.MethodGet, "/api/v1/healthz")
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	rec = doRequest(handlers, http.MethodGet, "/api/v1/readyz")
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	var resp struct {
+		Ready        bool              `json:"ready"`
+		Checks       map[string]string `json:"checks"`
+		APIServerURL string            `json:"apiServerURL"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.False(t, resp.Ready)
+	assert.Equal(t, "simulator is not started", resp.Checks["started"])
+	assert.Equal(t, "https://127.0.0.1:43567", resp.APIServerURL)
+
+	started.Store(true)
+	rec = doRequest(handlers, http.MethodGet, "/api/v1/healthz")
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = doRequest(handlers, http.MethodGet, "/api/v1/readyz")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.True(t, resp.Ready)
+	assert.Equal(t, "ok", resp.Checks["started"])
+}
+
+func TestHandlers_GetStatus_APIServerURL(t *testing.T) {
+	handlers := createTestHandlers(t)
+
+	var status map[string]interface{}
+	rec := doRequest(handlers, http.MethodGet, "/api/v1/status")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	assert.NotContains(t, status, "apiServerURL")
+
+	handlers.SetAPIServerURL("https://127.0.0.1:43567")
+
+	status = nil
+	rec = doRequest(handlers, http.MethodGet, "/api/v1/status")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	assert.Equal(t, "https://127.0.0.1:43567", status["apiServerURL"])
+}
+
+func TestHandlers_GetStatus_Controllers(t *testing.T) {
+	handlers := createTestHandlers(t)
+	handlers.SetEnabledControllers([]string{"clusterdeployment"})
+
+	var status map[string]interface{}
+	rec := doRequest(handlers, http.MethodGet, "/api/v1/status")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	assert.Equal(t, []interface{}{"clusterdeployment"}, status["controllers"])
+}
+
+func TestHandlers_GetStatus_TLS(t *testing.T) {
+	handlers := createTestHandlers(t)
+
+	var status map[string]interface{}
+	rec := doRequest(handlers, http.MethodGet, "/api/v1/status")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	assert.Equal(t, false, status["tls"])
+
+	handlers.SetTLSEnabled(true)
+
+	status = nil
+	rec = doRequest(handlers, http.MethodGet, "/api/v1/status")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	assert.Equal(t, true, status["tls"])
+}
+
+func TestHandlers_GetConfig_Warnings(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.ClusterDeployment.DefaultDelaySeconds = 0
+	cfg.AccountClaim.DefaultDelaySeconds = 0
+	cfg.ProjectClaim.DefaultDelaySeconds = 0
+	handlers := createTestHandlersWithConfig(t, cfg)
+
+	var resp map[string]interface{}
+	rec := doRequest(handlers, http.MethodGet, "/api/v1/config")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Contains(t, resp, "clusterDeployment")
+	assert.NotContains(t, resp, "warnings")
+
+	acCfg := handlers.behaviorEngine.GetConfig().AccountClaim
+	acCfg.DefaultDelaySeconds = 30
+	handlers.behaviorEngine.UpdateAccountClaimConfig(context.Background(), acCfg)
+
+	resp = nil
+	rec = doRequest(handlers, http.MethodGet, "/api/v1/config")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Contains(t, resp, "accountClaim")
+	require.Contains(t, resp, "warnings")
+	assert.Len(t, resp["warnings"], 1)
+	assert.Contains(t, resp["warnings"].([]interface{})[0], "AccountClaim defaultDelaySeconds has no effect")
+}
+
+func TestHandlers_GetResourceConfig(t *testing.T) {
+	handlers := createTestHandlers(t)
+
+	var cdCfg config.ClusterDeploymentConfig
+	rec := doRequest(handlers, http.MethodGet, "/api/v1/config/clusterdeployment")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &cdCfg))
+	assert.Equal(t, handlers.behaviorEngine.GetClusterDeploymentConfig().States, cdCfg.States)
+
+	var acCfg config.AccountClaimConfig
+	rec = doRequest(handlers, http.MethodGet, "/api/v1/config/accountclaim")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &acCfg))
+	assert.Equal(t, handlers.behaviorEngine.GetAccountClaimConfig().States, acCfg.States)
+
+	var pcCfg config.ProjectClaimConfig
+	rec = doRequest(handlers, http.MethodGet, "/api/v1/config/projectclaim")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &pcCfg))
+	assert.Equal(t, handlers.behaviorEngine.GetProjectClaimConfig().States, pcCfg.States)
+
+	rec = doRequest(handlers, http.MethodGet, "/api/v1/config/machinepool")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestHandlers_ResponseHeaders(t *testing.T) {
+	handlers := createTestHandlers(t)
+	handlers.SetResponseHeaders(map[string]string{"X-Hive-Sim-Instance": "sim-1"})
+
+	rec := doRequest(handlers, http.MethodGet, "/api/v1/status")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "sim-1", rec.Header().Get("X-Hive-Sim-Instance"))
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+}
+
+func TestHandlers_UpdateStateDuration(t *testing.T) {
+	handlers := createTestHandlers(t)
+	before := handlers.behaviorEngine.GetClusterDeploymentConfig().States
+	durations := make(map[string]int, len(before))
+	for _, state := range before {
+		durations[state.Name] = state.DurationSeconds
+	}
+	require.Contains(t, durations, "Installing")
+
+	rec := doRequestWithBody(handlers, http.MethodPatch, "/api/v1/config/clusterdeployment/states/Installing", `{"durationSeconds": 120}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	for _, state := range handlers.behaviorEngine.GetClusterDeploymentConfig().States {
+		if state.Name == "Installing" {
+			assert.Equal(t, 120, state.DurationSeconds)
+			continue
+		}
+		assert.Equal(t, durations[state.Name], state.DurationSeconds, state.Name)
+	}
+	for _, state := range handlers.behaviorEngine.GetAccountClaimConfig().States {
+		assert.NotEqual(t, 120, state.DurationSeconds, state.Name)
+	}
+}
+
+func TestHandlers_UpdateStateDuration_Errors(t *testing.T) {
+	handlers := createTestHandlers(t)
+
+	rec := doRequestWithBody(handlers, http.MethodPatch, "/api/v1/config/clusterdeployment/states/Missing", `{"durationSeconds": 10}`)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = doRequestWithBody(handlers, http.MethodPatch, "/api/v1/config/clusterdeployment/states/Installing", `{"durationSeconds": -1}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequestWithBody(handlers, http.MethodPatch, "/api/v1/config/clusterdeployment/states/Installing", `{}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequestWithBody(handlers, http.MethodPatch, "/api/v1/config/machinepool/states/Installing", `{"durationSeconds": 10}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestHandlers_SetSpeedFactor(t *testing.T) {
+	handlers := createTestHandlers(t)
+
+	rec := doRequestWithBody(handlers, http.MethodPost, "/api/v1/speed", `{"speedFactor": 10}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, float64(10), handlers.behaviorEngine.GetSpeedFactor())
+
+	for _, body := range []string{`{"speedFactor": 0}`, `{"speedFactor": -1}`, `{}`, `{`} {
+		rec = doRequestWithBody(handlers, http.MethodPost, "/api/v1/speed", body)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+	}
+	assert.Equal(t, float64(10), handlers.behaviorEngine.GetSpeedFactor())
+}
+
+func TestHandlers_WildcardOverrides(t *testing.T) {
+	ctx := context.Background()
+	handlers := createTestHandlers(t)
+
+	rec := doRequestWithBody(handlers, http.MethodPost, "/api/v1/overrides/ClusterDeployment/ci/*/failure",
+		`{"condition": "ProvisionFailed", "reason": "InsufficientCapacity"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = doRequestWithBody(handlers, http.MethodPost, "/api/v1/overrides/ClusterDeployment/*/*/delay", `{"delaySeconds": 30}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	for i := 0; i < 3; i++ {
+		shouldFail, _ := handlers.behaviorEngine.ShouldFail(ctx, "ClusterDeployment", "ci", fmt.Sprintf("cd-%d", i), "", nil)
+		assert.True(t, shouldFail)
+	}
+	shouldFail, _ := handlers.behaviorEngine.ShouldFail(ctx, "ClusterDeployment", "other", "cd-0", "", nil)
+	assert.False(t, shouldFail)
+	assert.Equal(t, 30*time.Second, handlers.behaviorEngine.GetTransitionDelay(ctx, "ClusterDeployment", "other", "cd-0", nil, time.Second))
+
+	rec = doRequest(handlers, http.MethodDelete, "/api/v1/overrides/ClusterDeployment/ci/*")
+	require.Equal(t, http.StatusOK, rec.Code)
+	shouldFail, _ = handlers.behaviorEngine.ShouldFail(ctx, "ClusterDeployment", "ci", "cd-0", "", nil)
+	assert.False(t, shouldFail)
+	assert.Len(t, handlers.behaviorEngine.ListOverrides(), 1)
+}
+
+func TestHandlers_OverrideTTL(t *testing.T) {
+	handlers := createTestHandlers(t)
+
+	rec := doRequestWithBody(handlers, http.MethodPost, "/api/v1/overrides/ClusterDeployment/default/cd-1/failure",
+		`{"condition": "ProvisionFailed", "reason": "InsufficientCapacity", "ttlSeconds": 60}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = doRequestWithBody(handlers, http.MethodPost, "/api/v1/overrides/ClusterDeployment/default/cd-2/delay",
+		`{"delaySeconds": 30, "ttlSeconds": 120}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = doRequestWithBody(handlers, http.MethodPost, "/api/v1/overrides/ClusterDeployment/default/cd-3/delay", `{"delaySeconds": 30}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = doRequest(handlers, http.MethodGet, "/api/v1/overrides")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var overrides map[string]OverrideStatus
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &overrides))
+	require.Len(t, overrides, 3)
+
+	failure := overrides["ClusterDeployment/default/cd-1"]
+	require.NotNil(t, failure.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), *failure.ExpiresAt, 5*time.Second)
+	assert.Equal(t, "InsufficientCapacity", failure.Failure.Reason)
+	delay := overrides["ClusterDeployment/default/cd-2"]
+	require.NotNil(t, delay.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(2*time.Minute), *delay.ExpiresAt, 5*time.Second)
+	assert.Nil(t, overrides["ClusterDeployment/default/cd-3"].ExpiresAt)
+
+	rec = doRequestWithBody(handlers, http.MethodPost, "/api/v1/overrides/ClusterDeployment/default/cd-4/delay",
+		`{"delaySeconds": 30, "ttlSeconds": -1}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = doRequestWithBody(handlers, http.MethodPost, "/api/v1/overrides/ClusterDeployment/default/cd-4/failure",
+		`{"condition": "ProvisionFailed", "ttlSeconds": -1}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestHandlers_PauseResume(t *testing.T) {
+	ctx := context.Background()
+	handlers := createTestHandlers(t)
+
+	rec := doRequestWithBody(handlers, http.MethodPost, "/api/v1/overrides/ClusterDeployment/default/cd-1/delay", `{"delaySeconds": 30}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = doRequest(handlers, http.MethodPost, "/api/v1/overrides/ClusterDeployment/default/cd-1/pause")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, handlers.behaviorEngine.IsPaused("ClusterDeployment", "default", "cd-1"))
+
+	rec = doRequest(handlers, http.MethodGet, "/api/v1/overrides")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var overrides map[string]OverrideStatus
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &overrides))
+	assert.True(t, overrides["ClusterDeployment/default/cd-1"].Paused)
+
+	// Resuming keeps the rest of the override
+	rec = doRequest(handlers, http.MethodPost, "/api/v1/overrides/ClusterDeployment/default/cd-1/resume")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.False(t, handlers.behaviorEngine.IsPaused("ClusterDeployment", "default", "cd-1"))
+	assert.Equal(t, 30*time.Second, handlers.behaviorEngine.GetTransitionDelay(ctx, "ClusterDeployment", "default", "cd-1", nil, time.Second))
+
+	rec = doRequest(handlers, http.MethodPost, "/api/v1/overrides/ClusterDeployment/default/cd-1/resume")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestHandlers_PauseResumeReconciliation(t *testing.T) {
+	handlers := createTestHandlers(t)
+
+	rec := doRequest(handlers, http.MethodPost, "/api/v1/pause")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, handlers.behaviorEngine.IsReconcilePaused())
+
+	rec = doRequest(handlers, http.MethodGet, "/api/v1/status")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var status map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	assert.Equal(t, true, status["paused"])
+
+	// Resuming is idempotent
+	for i := 0; i < 2; i++ {
+		rec = doRequest(handlers, http.MethodPost, "/api/v1/resume")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.False(t, handlers.behaviorEngine.IsReconcilePaused())
+	}
+}
+
+func TestHandlers_SelectorOverrides(t *testing.T) {
+	ctx := context.Background()
+	handlers := createTestHandlers(t)
+
+	rec := doRequestWithBody(handlers, http.MethodPost, "/api/v1/selector-overrides",
+		`{"resourceType": "clusterdeployment", "labelSelector": "tier=canary", "delaySeconds": 60, "forceFail": {"condition": "ProvisionFailed", "reason": "Canary"}}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	rec = doRequestWithBody(handlers, http.MethodPost, "/api/v1/overrides/ClusterDeployment/default/cd-1/success", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	canary := map[string]string{"tier": "canary"}
+	shouldFail, failure := handlers.behaviorEngine.ShouldFail(ctx, "ClusterDeployment", "default", "cd-2", "", canary)
+	require.True(t, shouldFail)
+	assert.Equal(t, "Canary", failure.Reason)
+	assert.Equal(t, 60*time.Second, handlers.behaviorEngine.GetTransitionDelay(ctx, "ClusterDeployment", "default", "cd-2", canary, time.Second))
+
+	// The exact-name override wins over the selector
+	shouldFail, _ = handlers.behaviorEngine.ShouldFail(ctx, "ClusterDeployment", "default", "cd-1", "", canary)
+	assert.False(t, shouldFail)
+
+	rec = doRequest(handlers, http.MethodGet, "/api/v1/selector-overrides")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var overrides []config.SelectorOverride
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &overrides))
+	require.Len(t, overrides, 1)
+	assert.Equal(t, "ClusterDeployment", overrides[0].ResourceType)
+
+	rec = doRequest(handlers, http.MethodDelete, "/api/v1/selector-overrides")
+	require.Equal(t, http.StatusOK, rec.Code)
+	shouldFail, _ = handlers.behaviorEngine.ShouldFail(ctx, "ClusterDeployment", "default", "cd-2", "", canary)
+	assert.False(t, shouldFail)
+}
+
+func TestHandlers_AddSelectorOverride_Invalid(t *testing.T) {
+	handlers := createTestHandlers(t)
+
+	for _, body := range []string{
+		`not json`,
+		`{"resourceType": "Unknown", "labelSelector": "tier=canary"}`,
+		`{"resourceType": "ClusterDeployment", "labelSelector": "tier in canary"}`,
+		`{"resourceType": "ClusterDeployment", "labelSelector": "tier=canary", "probability": 2}`,
+	} {
+		rec := doRequestWithBody(handlers, http.MethodPost, "/api/v1/selector-overrides", body)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+	}
+	assert.Empty(t, handlers.behaviorEngine.ListSelectorOverrides())
+}
+
+func TestHandlers_Metrics(t *testing.T) {
+	handlers := createTestHandlers(t)
+
+	rec := doRequest(handlers, http.MethodGet, "/metrics")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	registry := prometheus.NewRegistry()
+	require.NoError(t, handlers.behaviorEngine.RegisterMetrics(registry))
+	handlers.SetMetricsGatherer(registry)
+
+	rec = doRequestWithBody(handlers, http.MethodPost, "/api/v1/overrides/ClusterDeployment/default/cd-1/success", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = doRequest(handlers, http.MethodGet, "/metrics")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `hivesim_overrides_set_total{resource_type="ClusterDeployment"} 1`)
+	assert.Contains(t, rec.Body.String(), `hivesim_overrides_active{resource_type="ClusterDeployment"} 1`)
+}
+
+func TestHandlers_GetRolls(t *testing.T) {
+	handlers := createTestHandlers(t)
+	ctx := context.Background()
+	cfg := handlers.behaviorEngine.GetConfig().ClusterDeployment
+	cfg.FailureScenarios = []config.FailureScenario{
+		{Probability: 1, Condition: "ProvisionFailed", Reason: "InsufficientCapacity"},
+	}
+	handlers.behaviorEngine.UpdateClusterDeploymentConfig(ctx, cfg)
+	handlers.behaviorEngine.ShouldFail(ctx, "ClusterDeployment", "default", "cd-1", "", nil)
+
+	rec := doRequest(handlers, http.MethodGet, "/api/v1/debug/rolls")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var rolls []behavior.Roll
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &rolls))
+	require.Len(t, rolls, 1)
+	assert.Equal(t, "ClusterDeployment/default/cd-1", rolls[0].Resource)
+	assert.Equal(t, "InsufficientCapacity", rolls[0].Scenario)
+	assert.Equal(t, float64(1), rolls[0].Threshold)
+	assert.True(t, rolls[0].Failed)
+}
+
+func TestHandlers_OverrideResourceType(t *testing.T) {
+	handlers := createTestHandlers(t)
+
+	requests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodPost, "/api/v1/overrides/Clusterdeploymnet/default/cd-1/failure", `{"condition": "ProvisionFailed"}`},
+		{http.MethodPost, "/api/v1/overrides/Clusterdeploymnet/default/cd-1/delay", `{"delaySeconds": 30}`},
+		{http.MethodPost, "/api/v1/overrides/Clusterdeploymnet/default/cd-1/success", ""},
+		{http.MethodPost, "/api/v1/overrides/Clusterdeploymnet/default/cd-1/pause", ""},
+		{http.MethodPost, "/api/v1/overrides/Clusterdeploymnet/default/cd-1/resume", ""},
+		{http.MethodDelete, "/api/v1/overrides/Clusterdeploymnet/default/cd-1", ""},
+	}
+	for _, req := range requests {
+		rec := doRequestWithBody(handlers, req.method, req.path, req.body)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, "%s %s", req.method, req.path)
+		assert.Contains(t, rec.Body.String(), "ClusterDeployment, AccountClaim and ProjectClaim")
+	}
+	assert.Empty(t, handlers.behaviorEngine.ListOverrides())
+
+	// The type is matched case-insensitively and stored under its kind
+	rec := doRequestWithBody(handlers, http.MethodPost, "/api/v1/overrides/clusterdeployment/default/cd-1/delay", `{"delaySeconds": 30}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, handlers.behaviorEngine.ListOverrides(), "ClusterDeployment/default/cd-1")
+}
+
+func TestHandlers_ListOverrides(t *testing.T) {
+	handlers := createTestHandlers(t)
+
+	rec := doRequestWithBody(handlers, http.MethodPost, "/api/v1/overrides/ClusterDeployment/default/cd-1/delay", `{"delaySeconds": 30}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = doRequestWithBody(handlers, http.MethodPost, "/api/v1/overrides/ClusterDeployment/default/cd-2/failure",
+		`{"condition": "ProvisionFailed", "reason": "InsufficientCapacity", "atState": "Installing"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = doRequest(handlers, http.MethodPost, "/api/v1/overrides/AccountClaim/default/ac-1/success")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = doRequest(handlers, http.MethodGet, "/api/v1/overrides")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var overrides map[string]OverrideStatus
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &overrides))
+	require.Len(t, overrides, 3)
+
+	delay := overrides["ClusterDeployment/default/cd-1"]
+	require.NotNil(t, delay.DelaySeconds)
+	assert.Equal(t, 30, *delay.DelaySeconds)
+	assert.False(t, delay.ForceFail)
+	assert.False(t, delay.ForceSuccess)
+	assert.Empty(t, delay.AtState)
+
+	failure := overrides["ClusterDeployment/default/cd-2"]
+	assert.Nil(t, failure.DelaySeconds)
+	assert.True(t, failure.ForceFail)
+	require.NotNil(t, failure.Failure)
+	assert.Equal(t, "InsufficientCapacity", failure.Failure.Reason)
+	assert.Equal(t, "Installing", failure.AtState)
+
+	assert.True(t, overrides["AccountClaim/default/ac-1"].ForceSuccess)
+
+	// Cleared overrides disappear from the list
+	rec = doRequest(handlers, http.MethodDelete, "/api/v1/overrides/ClusterDeployment/default/cd-1")
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = doRequest(handlers, http.MethodGet, "/api/v1/overrides")
+	overrides = nil
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &overrides))
+	assert.NotContains(t, overrides, "ClusterDeployment/default/cd-1")
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	kuberrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gorilla/mux"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+)
+
+// ImageSetBuilder builds a ClusterImageSet, with the labels and annotations clusters-service
+// expects, from its configuration
+type ImageSetBuilder func(cisConfig config.ClusterImageSetConfig) *hivev1.ClusterImageSet
+
+// SetImageSetBuilder sets how ClusterImageSets created through the API are built
+func (h *Handlers) SetImageSetBuilder(builder ImageSetBuilder) {
+	h.imageSetBuilder = builder
+}
+
+// CreateImageSet creates a ClusterImageSet, e.g. to simulate a new release going GA.
+// Image sets are visible unless the body sets visible to false.
+func (h *Handlers) CreateImageSet(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.logger.Debug(ctx, "POST /api/v1/imagesets")
+
+	if h.imageSetBuilder == nil {
+		h.writeError(w, http.StatusServiceUnavailable, "ClusterImageSet management is not available")
+		return
+	}
+
+	cisConfig := config.ClusterImageSetConfig{Visible: true}
+	if err := json.NewDecoder(r.Body).Decode(&cisConfig); err != nil {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+	if msgs := validation.IsDNS1123Subdomain(cisConfig.Name); len(msgs) > 0 {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid ClusterImageSet name %q: %s", cisConfig.Name, strings.Join(msgs, ", ")))
+		return
+	}
+
+	cis := h.imageSetBuilder(cisConfig)
+	if err := h.k8sClient.Create(ctx, cis); err != nil {
+		if kuberrors.IsAlreadyExists(err) {
+			h.writeError(w, http.StatusConflict, fmt.Sprintf("ClusterImageSet %s already exists", cisConfig.Name))
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create ClusterImageSet %s: %v", cisConfig.Name, err))
+		return
+	}
+
+	h.logger.Info(ctx, "Created ClusterImageSet %s", cisConfig.Name)
+	h.writeJSON(w, http.StatusCreated, cis)
+}
+
+// DeleteImageSet deletes a ClusterImageSet
+func (h *Handlers) DeleteImageSet(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	name := mux.Vars(r)["name"]
+	h.logger.Debug(ctx, "DELETE /api/v1/imagesets/%s", name)
+
+	cis := &hivev1.ClusterImageSet{}
+	if err := h.k8sClient.Get(ctx, client.ObjectKey{Name: name}, cis); err != nil {
+		if kuberrors.IsNotFound(err) {
+			h.writeError(w, http.StatusNotFound, fmt.Sprintf("ClusterImageSet %s not found", name))
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get ClusterImageSet %s: %v", name, err))
+		return
+	}
+
+	if err := h.k8sClient.Delete(ctx, cis); err != nil && !kuberrors.IsNotFound(err) {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete ClusterImageSet %s: %v", name, err))
+		return
+	}
+
+	h.logger.Info(ctx, "Deleted ClusterImageSet %s", name)
+	h.writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
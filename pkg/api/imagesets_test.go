@@ -0,0 +1,79 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	kuberrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+)
+
+func testImageSetBuilder(cisConfig config.ClusterImageSetConfig) *hivev1.ClusterImageSet {
+	cis := &hivev1.ClusterImageSet{}
+	cis.Name = cisConfig.Name
+	cis.Labels = map[string]string{"visible": strconv.FormatBool(cisConfig.Visible)}
+	return cis
+}
+
+func TestHandlers_CreateAndDeleteImageSet(t *testing.T) {
+	ctx := context.Background()
+	handlers := createTestHandlers(t)
+	handlers.SetImageSetBuilder(testImageSetBuilder)
+
+	rec := doRequestWithBody(handlers, http.MethodPost, "/api/v1/imagesets", `{"name": "openshift-v4.18.0"}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	var created hivev1.ClusterImageSet
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.Equal(t, "openshift-v4.18.0", created.Name)
+
+	// Image sets are visible unless requested otherwise
+	cis := &hivev1.ClusterImageSet{}
+	require.NoError(t, handlers.k8sClient.Get(ctx, client.ObjectKey{Name: "openshift-v4.18.0"}, cis))
+	assert.Equal(t, "true", cis.Labels["visible"])
+
+	rec = doRequestWithBody(handlers, http.MethodPost, "/api/v1/imagesets", `{"name": "openshift-v4.18.0"}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = doRequestWithBody(handlers, http.MethodPost, "/api/v1/imagesets", `{"name": "openshift-v4.19.0", "visible": false}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	require.NoError(t, handlers.k8sClient.Get(ctx, client.ObjectKey{Name: "openshift-v4.19.0"}, cis))
+	assert.Equal(t, "false", cis.Labels["visible"])
+
+	rec = doRequest(handlers, http.MethodDelete, "/api/v1/imagesets/openshift-v4.18.0")
+	require.Equal(t, http.StatusOK, rec.Code)
+	err := handlers.k8sClient.Get(ctx, client.ObjectKey{Name: "openshift-v4.18.0"}, cis)
+	assert.True(t, kuberrors.IsNotFound(err))
+
+	rec = doRequest(handlers, http.MethodDelete, "/api/v1/imagesets/openshift-v4.18.0")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestHandlers_CreateImageSet_Errors(t *testing.T) {
+	handlers := createTestHandlers(t)
+
+	// Not available until a builder is set
+	rec := doRequestWithBody(handlers, http.MethodPost, "/api/v1/imagesets", `{"name": "openshift-v4.18.0"}`)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	handlers.SetImageSetBuilder(testImageSetBuilder)
+
+	rec = doRequestWithBody(handlers, http.MethodPost, "/api/v1/imagesets", `not json`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequestWithBody(handlers, http.MethodPost, "/api/v1/imagesets", `{"name": ""}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequestWithBody(handlers, http.MethodPost, "/api/v1/imagesets", `{"name": "Not_Valid"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
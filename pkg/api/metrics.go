@@ -0,0 +1,25 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// SetMetricsGatherer sets the registry whose metrics are exposed on the metrics endpoint
+func (h *Handlers) SetMetricsGatherer(gatherer prometheus.Gatherer) {
+	h.metricsGatherer = gatherer
+}
+
+// Metrics exposes the simulator metrics in the Prometheus text format
+func (h *Handlers) Metrics(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.logger.Debug(ctx, "GET /metrics")
+
+	if h.metricsGatherer == nil {
+		h.writeError(w, http.StatusServiceUnavailable, "Metrics are not available")
+		return
+	}
+	promhttp.HandlerFor(h.metricsGatherer, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+}
@@ -0,0 +1,19 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/api/openapi"
+)
+
+// GetOpenAPISpec returns the OpenAPI document describing the API
+func (h *Handlers) GetOpenAPISpec(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.logger.Debug(ctx, "GET /api/v1/openapi.json")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(openapi.Spec); err != nil {
+		h.logger.Error(ctx, "Failed to write OpenAPI document: %v", err)
+	}
+}
@@ -0,0 +1,13 @@
+// Package openapi holds the OpenAPI 3.0 document describing the simulator's HTTP API
+package openapi
+
+import (
+	_ "embed"
+)
+
+// Spec is the OpenAPI 3.0 document of every route registered by api.SetupRoutes, with the
+// request and response schemas derived from the config structs. It is maintained by hand
+// alongside the routes and the structs it describes.
+//
+//go:embed openapi.json
+var Spec []byte
@@ -0,0 +1,78 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+)
+
+type document struct {
+	OpenAPI    string                                `json:"openapi"`
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"schemas"`
+	} `json:"components"`
+}
+
+func TestSpec_Valid(t *testing.T) {
+	var doc document
+	require.NoError(t, json.Unmarshal(Spec, &doc))
+	assert.True(t, strings.HasPrefix(doc.OpenAPI, "3.0"))
+	assert.NotEmpty(t, doc.Paths)
+}
+
+func TestSpec_ConfigSchemas(t *testing.T) {
+	var doc document
+	require.NoError(t, json.Unmarshal(Spec, &doc))
+
+	// Every field of the config structs is described, so a field added without updating the
+	// document is caught here
+	for _, value := range []interface{}{
+		config.Config{},
+		config.ClusterDeploymentConfig{},
+		config.AccountClaimConfig{},
+		config.ProjectClaimConfig{},
+		config.StateConfig{},
+		config.TransitionConfig{},
+		config.ConditionConfig{},
+		config.FailureScenario{},
+		config.ResourceOverride{},
+		config.SelectorOverride{},
+	} {
+		typ := reflect.TypeOf(value)
+		schema, ok := doc.Components.Schemas[typ.Name()]
+		if !assert.True(t, ok, "schema %s missing", typ.Name()) {
+			continue
+		}
+		for _, name := range jsonFields(typ) {
+			assert.Contains(t, schema.Properties, name, "schema %s is missing property %s", typ.Name(), name)
+		}
+	}
+}
+
+// jsonFields returns the JSON names of the fields of a struct type, including those of
+// embedded structs
+func jsonFields(typ reflect.Type) []string {
+	var names []string
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			names = append(names, jsonFields(field.Type)...)
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if !field.IsExported() || name == "" || name == "-" {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
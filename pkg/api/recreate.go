@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	kuberrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gorilla/mux"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+
+	aaov1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/aws-account-operator/v1alpha1"
+	gcpv1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/gcp-project-operator/v1alpha1"
+)
+
+// recreateTimeout bounds how long a recreate waits for the old object to be deleted
+const recreateTimeout = 2 * time.Minute
+
+// simulatorAnnotationPrefix prefixes the annotations the simulator uses to track the
+// lifecycle of a resource
+const simulatorAnnotationPrefix = "hive-simulator.openshift.io/"
+
+// RecreateResponse describes a freshly recreated resource
+type RecreateResponse struct {
+	ResourceType string `json:"resourceType"`
+	Namespace    string `json:"namespace"`
+	Name         string `json:"name"`
+	UID          string `json:"uid"`
+}
+
+// RecreateResource deletes a resource, waits for the deletion to complete and creates an
+// equivalent fresh object with the same metadata and spec but no status
+func (h *Handlers) RecreateResource(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	resourceType := vars["type"]
+	namespace := vars["namespace"]
+	name := vars["name"]
+
+	h.logger.Debug(ctx, "POST /api/v1/resources/%s/%s/%s/recreate", resourceType, namespace, name)
+
+	kind, ok := resourceKinds[strings.ToLower(resourceType)]
+	if !ok {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Unknown resource type: %s", resourceType))
+		return
+	}
+
+	key := client.ObjectKey{Namespace: namespace, Name: name}
+	var existing, fresh client.Object
+	switch kind {
+	case "ClusterDeployment":
+		existing = &hivev1.ClusterDeployment{}
+	case "AccountClaim":
+		existing = &aaov1alpha1.AccountClaim{}
+	case "ProjectClaim":
+		existing = &gcpv1alpha1.ProjectClaim{}
+	}
+
+	if err := h.k8sClient.Get(ctx, key, existing); err != nil {
+		if kuberrors.IsNotFound(err) {
+			h.writeError(w, http.StatusNotFound, fmt.Sprintf("%s %s/%s not found", kind, namespace, name))
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get %s %s/%s: %v", kind, namespace, name, err))
+		return
+	}
+
+	switch obj := existing.(type) {
+	case *hivev1.ClusterDeployment:
+		spec := obj.Spec.DeepCopy()
+		// Installation results belong to the old cluster
+		spec.Installed = false
+		spec.ClusterMetadata = nil
+		fresh = &hivev1.ClusterDeployment{ObjectMeta: freshObjectMeta(obj), Spec: *spec}
+	case *aaov1alpha1.AccountClaim:
+		fresh = &aaov1alpha1.AccountClaim{ObjectMeta: freshObjectMeta(obj), Spec: *obj.Spec.DeepCopy()}
+	case *gcpv1alpha1.ProjectClaim:
+		fresh = &gcpv1alpha1.ProjectClaim{ObjectMeta: freshObjectMeta(obj), Spec: *obj.Spec.DeepCopy()}
+	}
+
+	if err := h.k8sClient.Delete(ctx, existing); err != nil && !kuberrors.IsNotFound(err) {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete %s %s/%s: %v", kind, namespace, name, err))
+		return
+	}
+
+	// Wait for finalizers to run and the object to disappear before reusing its name
+	if err := h.waitForDeletion(ctx, key, existing); err != nil {
+		h.writeError(w, http.StatusGatewayTimeout, fmt.Sprintf("Timed out waiting for %s %s/%s to be deleted: %v", kind, namespace, name, err))
+		return
+	}
+
+	if err := h.k8sClient.Create(ctx, fresh); err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to recreate %s %s/%s: %v", kind, namespace, name, err))
+		return
+	}
+
+	h.logger.Info(ctx, "Recreated %s %s/%s", kind, namespace, name)
+	h.writeJSON(w, http.StatusCreated, RecreateResponse{
+		ResourceType: kind,
+		Namespace:    namespace,
+		Name:         name,
+		UID:          string(fresh.GetUID()),
+	})
+}
+
+// waitForDeletion polls until the object with the given key no longer exists
+func (h *Handlers) waitForDeletion(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	return wait.PollUntilContextTimeout(ctx, 100*time.Millisecond, recreateTimeout, true, func(ctx context.Context) (bool, error) {
+		err := h.k8sClient.Get(ctx, key, obj)
+		if kuberrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	})
+}
+
+// freshObjectMeta keeps the identifying metadata of an object, dropping server-populated
+// fields and the simulator's own annotations so the lifecycle of the new object starts over
+func freshObjectMeta(obj client.Object) metav1.ObjectMeta {
+	var annotations map[string]string
+	for key, value := range obj.GetAnnotations() {
+		if strings.HasPrefix(key, simulatorAnnotationPrefix) {
+			continue
+		}
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[key] = value
+	}
+	return metav1.ObjectMeta{
+		Name:        obj.GetName(),
+		Namespace:   obj.GetNamespace(),
+		Labels:      obj.GetLabels(),
+		Annotations: annotations,
+	}
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/state_machine"
+)
+
+func TestHandlers_RecreateResource_ClusterDeployment(t *testing.T) {
+	now := metav1.Now()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cd-1",
+			Namespace: "default",
+			Labels:    map[string]string{"app": "test"},
+		},
+		Spec: hivev1.ClusterDeploymentSpec{
+			ClusterName:     "cd-1",
+			BaseDomain:      "example.com",
+			Installed:       true,
+			ClusterMetadata: &hivev1.ClusterMetadata{InfraID: "cd-1-infra"},
+		},
+		Status: hivev1.ClusterDeploymentStatus{
+			ProvisionRef:       &corev1.LocalObjectReference{Name: "cd-1-provision"},
+			InstalledTimestamp: &now,
+		},
+	}
+	handlers := createTestHandlers(t, cd)
+
+	rec := doRequest(handlers, http.MethodPost, "/api/v1/resources/clusterdeployment/default/cd-1/recreate")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var resp RecreateResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "ClusterDeployment", resp.ResourceType)
+	assert.Equal(t, "cd-1", resp.Name)
+
+	recreated := &hivev1.ClusterDeployment{}
+	require.NoError(t, handlers.k8sClient.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "cd-1"}, recreated))
+	assert.Equal(t, "test", recreated.Labels["app"])
+	assert.Equal(t, "example.com", recreated.Spec.BaseDomain)
+	assert.False(t, recreated.Spec.Installed)
+	assert.Nil(t, recreated.Spec.ClusterMetadata)
+	assert.Nil(t, recreated.Status.ProvisionRef)
+	assert.Nil(t, recreated.Status.InstalledTimestamp)
+
+	// The fresh object starts its lifecycle over from Pending
+	rec = doRequest(handlers, http.MethodGet, "/api/v1/resources/clusterdeployment/default/cd-1/eta")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var eta ResourceETA
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &eta))
+	assert.Equal(t, "Pending", eta.State)
+}
+
+func TestHandlers_RecreateResource_DropsSimulatorAnnotations(t *testing.T) {
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cd-1",
+			Namespace: "default",
+			Annotations: map[string]string{
+				"example.com/owner":                             "team-a",
+				state_machine.StuckUntilAnnotation:              "never",
+				state_machine.DeprovisionStateAnnotation:        "Deprovisioning",
+				"hive-simulator.openshift.io/upgrade-available": "4.17.1",
+			},
+		},
+		Spec: hivev1.ClusterDeploymentSpec{ClusterName: "cd-1", BaseDomain: "example.com"},
+	}
+	handlers := createTestHandlers(t, cd)
+
+	rec := doRequest(handlers, http.MethodPost, "/api/v1/resources/clusterdeployment/default/cd-1/recreate")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	recreated := &hivev1.ClusterDeployment{}
+	require.NoError(t, handlers.k8sClient.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "cd-1"}, recreated))
+	assert.Equal(t, map[string]string{"example.com/owner": "team-a"}, recreated.Annotations)
+}
+
+func TestHandlers_RecreateResource_WaitsForFinalizer(t *testing.T) {
+	ctx := context.Background()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "cd-1",
+			Namespace:  "default",
+			Finalizers: []string{hivev1.FinalizerDeprovision},
+		},
+		Spec: hivev1.ClusterDeploymentSpec{ClusterName: "cd-1", BaseDomain: "example.com"},
+	}
+	handlers := createTestHandlers(t, cd)
+	key := client.ObjectKey{Namespace: "default", Name: "cd-1"}
+
+	// Stand in for the controller: remove the finalizer once the deletion is seen
+	deprovisioned := make(chan error, 1)
+	go func() {
+		deprovisioned <- wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 5*time.Second, true, func(ctx context.Context) (bool, error) {
+			deleting := &hivev1.ClusterDeployment{}
+			if err := handlers.k8sClient.Get(ctx, key, deleting); err != nil {
+				return false, err
+			}
+			if deleting.DeletionTimestamp == nil {
+				return false, nil
+			}
+			deleting.Finalizers = nil
+			return true, handlers.k8sClient.Update(ctx, deleting)
+		})
+	}()
+
+	rec := doRequest(handlers, http.MethodPost, "/api/v1/resources/clusterdeployment/default/cd-1/recreate")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	require.NoError(t, <-deprovisioned)
+
+	recreated := &hivev1.ClusterDeployment{}
+	require.NoError(t, handlers.k8sClient.Get(ctx, key, recreated))
+	assert.Nil(t, recreated.DeletionTimestamp)
+	assert.Empty(t, recreated.Finalizers)
+}
+
+func TestHandlers_RecreateResource_Errors(t *testing.T) {
+	handlers := createTestHandlers(t)
+
+	rec := doRequest(handlers, http.MethodPost, "/api/v1/resources/clusterdeployment/default/missing/recreate")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = doRequest(handlers, http.MethodPost, "/api/v1/resources/machinepool/default/mp-1/recreate")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/openshift-online/ocm-sdk-go/logging"
+	errors "github.com/zgalor/weberr"
+)
+
+// RecordedRequest is a single API request of a recorded session
+type RecordedRequest struct {
+	Method string          `json:"method"`
+	Path   string          `json:"path"`
+	Body   json.RawMessage `json:"body,omitempty"`
+
+	// DelayMs is how long to wait before sending the request
+	DelayMs int `json:"delayMs,omitempty"`
+}
+
+// LoadRecordedRequests reads a recorded API session from a JSONL file, one request per line.
+// Blank lines are skipped.
+func LoadRecordedRequests(path string) ([]RecordedRequest, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open recorded session %s", path)
+	}
+	defer file.Close()
+
+	var requests []RecordedRequest
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var request RecordedRequest
+		if err := json.Unmarshal([]byte(text), &request); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse line %d of recorded session %s", line, path)
+		}
+		if request.Method == "" || !strings.HasPrefix(request.Path, "/") {
+			return nil, errors.Errorf("line %d of recorded session %s needs a method and an absolute path", line, path)
+		}
+		if request.DelayMs < 0 {
+			return nil, errors.Errorf("line %d of recorded session %s has a negative delayMs", line, path)
+		}
+		requests = append(requests, request)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to read recorded session %s", path)
+	}
+	return requests, nil
+}
+
+// Replay sends the recorded requests to the API handler in order, waiting for each request's
+// delay first. Requests that fail are logged and do not stop the replay. It returns early
+// when the context is cancelled.
+func Replay(ctx context.Context, logger logging.Logger, handler http.Handler, requests []RecordedRequest) error {
+	for i, request := range requests {
+		if request.DelayMs > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Duration(request.DelayMs) * time.Millisecond):
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, request.Method, request.Path, bytes.NewReader(request.Body))
+		if err != nil {
+			logger.Warn(ctx, "Failed to build replayed request %d (%s %s): %v", i+1, request.Method, request.Path, err)
+			continue
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code >= http.StatusBadRequest {
+			logger.Warn(ctx, "Replayed request %d (%s %s) returned %d: %s",
+				i+1, request.Method, request.Path, rec.Code, strings.TrimSpace(rec.Body.String()))
+			continue
+		}
+		logger.Debug(ctx, "Replayed request %d (%s %s) returned %d", i+1, request.Method, request.Path, rec.Code)
+	}
+	return nil
+}
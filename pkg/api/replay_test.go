@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeRecordedSession(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestReplay_RecordedSession(t *testing.T) {
+	path := writeRecordedSession(t, `
+{"method": "POST", "path": "/api/v1/overrides/ClusterDeployment/default/cd-1/delay", "body": {"delaySeconds": 30}}
+{"method": "POST", "path": "/api/v1/overrides/ClusterDeployment/default/cd-2/failure", "body": {"condition": "ProvisionFailed", "reason": "InsufficientCapacity"}, "delayMs": 10}
+{"method": "POST", "path": "/api/v1/overrides/AccountClaim/default/ac-1/success"}
+
+{"method": "DELETE", "path": "/api/v1/overrides/ClusterDeployment/default/cd-1"}
+{"method": "POST", "path": "/api/v1/overrides/ClusterDeployment/default/cd-3/failure", "body": "not a failure"}
+`)
+	requests, err := LoadRecordedRequests(path)
+	require.NoError(t, err)
+	require.Len(t, requests, 5)
+	assert.Equal(t, 10, requests[1].DelayMs)
+
+	handlers := createTestHandlers(t)
+	start := time.Now()
+	require.NoError(t, Replay(context.Background(), handlers.logger, SetupRoutes(handlers), requests))
+	assert.GreaterOrEqual(t, time.Since(start), 10*time.Millisecond)
+
+	// The later delete cleared cd-1, and the invalid cd-3 request was skipped
+	overrides := handlers.behaviorEngine.ListOverrides()
+	require.Len(t, overrides, 2)
+	failure := overrides["ClusterDeployment/default/cd-2"]
+	require.NotNil(t, failure)
+	require.NotNil(t, failure.ForceFail)
+	assert.Equal(t, "InsufficientCapacity", failure.ForceFail.Reason)
+	success := overrides["AccountClaim/default/ac-1"]
+	require.NotNil(t, success)
+	assert.True(t, success.ForceSuccess)
+}
+
+func TestReplay_StopsOnCancel(t *testing.T) {
+	handlers := createTestHandlers(t)
+	requests := []RecordedRequest{
+		{Method: "POST", Path: "/api/v1/overrides/AccountClaim/default/ac-1/success", DelayMs: 60000},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, Replay(ctx, handlers.logger, SetupRoutes(handlers), requests), context.Canceled)
+	assert.Empty(t, handlers.behaviorEngine.ListOverrides())
+}
+
+func TestLoadRecordedRequests_Invalid(t *testing.T) {
+	_, err := LoadRecordedRequests(filepath.Join(t.TempDir(), "missing.jsonl"))
+	assert.Error(t, err)
+
+	_, err = LoadRecordedRequests(writeRecordedSession(t, `{"method": "GET", "path": "/api/v1/status"}
+not json
+`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 2")
+
+	_, err = LoadRecordedRequests(writeRecordedSession(t, `{"path": "/api/v1/status"}`))
+	assert.Error(t, err)
+
+	_, err = LoadRecordedRequests(writeRecordedSession(t, `{"method": "GET", "path": "/api/v1/status", "delayMs": -1}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	kuberrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gorilla/mux"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+
+	aaov1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/aws-account-operator/v1alpha1"
+	gcpv1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/gcp-project-operator/v1alpha1"
+)
+
+// ResetResource sends a resource back to its initial state in place, so that it can be reused
+// across tests without recreating it. Its status is cleared, along with the installation
+// result of a ClusterDeployment and the simulator's own annotations. The write enqueues the
+// resource, which then starts over from Pending.
+func (h *Handlers) ResetResource(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	resourceType := vars["type"]
+	namespace := vars["namespace"]
+	name := vars["name"]
+
+	h.logger.Debug(ctx, "POST /api/v1/resources/%s/%s/%s/reset", resourceType, namespace, name)
+
+	kind, ok := resourceKinds[strings.ToLower(resourceType)]
+	if !ok {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Unknown resource type: %s", resourceType))
+		return
+	}
+
+	key := client.ObjectKey{Namespace: namespace, Name: name}
+	var obj client.Object
+	switch kind {
+	case "ClusterDeployment":
+		obj = &hivev1.ClusterDeployment{}
+	case "AccountClaim":
+		obj = &aaov1alpha1.AccountClaim{}
+	case "ProjectClaim":
+		obj = &gcpv1alpha1.ProjectClaim{}
+	}
+	if err := h.k8sClient.Get(ctx, key, obj); err != nil {
+		if kuberrors.IsNotFound(err) {
+			h.writeError(w, http.StatusNotFound, fmt.Sprintf("%s %s/%s not found", kind, namespace, name))
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get %s %s/%s: %v", kind, namespace, name, err))
+		return
+	}
+	previousState := "Pending"
+	switch obj := obj.(type) {
+	case *hivev1.ClusterDeployment:
+		previousState = clusterDeploymentState(obj)
+		// Installation results belong to the previous run
+		obj.Spec.Installed = false
+		obj.Spec.ClusterMetadata = nil
+	case *aaov1alpha1.AccountClaim:
+		if obj.Status.State != "" {
+			previousState = string(obj.Status.State)
+		}
+	case *gcpv1alpha1.ProjectClaim:
+		if obj.Status.State != "" {
+			previousState = string(obj.Status.State)
+		}
+	}
+	dropSimulatorAnnotations(obj)
+
+	// The status is a subresource, written after the spec and metadata
+	if err := h.k8sClient.Update(ctx, obj); err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to reset %s %s/%s: %v", kind, namespace, name, err))
+		return
+	}
+	switch obj := obj.(type) {
+	case *hivev1.ClusterDeployment:
+		obj.Status = hivev1.ClusterDeploymentStatus{}
+	case *aaov1alpha1.AccountClaim:
+		obj.Status = aaov1alpha1.AccountClaimStatus{}
+	case *gcpv1alpha1.ProjectClaim:
+		obj.Status = gcpv1alpha1.ProjectClaimStatus{}
+	}
+	if err := h.k8sClient.Status().Update(ctx, obj); err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to reset %s %s/%s status: %v", kind, namespace, name, err))
+		return
+	}
+
+	h.logger.Info(ctx, "Reset %s %s/%s from state %s", kind, namespace, name, previousState)
+	h.writeJSON(w, http.StatusOK, SetStateResponse{
+		ResourceType:  kind,
+		Namespace:     namespace,
+		Name:          name,
+		PreviousState: previousState,
+		State:         "Pending",
+	})
+}
+
+// dropSimulatorAnnotations removes the annotations the simulator uses to track the lifecycle
+// of the object
+func dropSimulatorAnnotations(obj client.Object) {
+	annotations := obj.GetAnnotations()
+	for key := range annotations {
+		if strings.HasPrefix(key, simulatorAnnotationPrefix) {
+			delete(annotations, key)
+		}
+	}
+	obj.SetAnnotations(annotations)
+}
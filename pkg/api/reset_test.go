@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	gcpv1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/gcp-project-operator/v1alpha1"
+)
+
+func TestHandlers_ResetResource_ClusterDeployment(t *testing.T) {
+	now := metav1.Now()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cd-1",
+			Namespace: "default",
+			Labels:    map[string]string{"team": "a"},
+			Annotations: map[string]string{
+				"hive-simulator.openshift.io/stuck-until": "never",
+				"example.com/keep":                        "yes",
+			},
+		},
+		Spec: hivev1.ClusterDeploymentSpec{
+			Installed:       true,
+			ClusterMetadata: &hivev1.ClusterMetadata{ClusterID: "cluster-id"},
+		},
+		Status: hivev1.ClusterDeploymentStatus{
+			ProvisionRef:       &corev1.LocalObjectReference{Name: "cd-1-provision"},
+			InstalledTimestamp: &now,
+			Conditions: []hivev1.ClusterDeploymentCondition{
+				{Type: "ClusterDeploymentCompleted", Status: corev1.ConditionTrue},
+			},
+		},
+	}
+	handlers := createTestHandlers(t, cd)
+
+	rec := doRequest(handlers, http.MethodPost, "/api/v1/resources/clusterdeployment/default/cd-1/reset")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var response SetStateResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, "Running", response.PreviousState)
+	assert.Equal(t, "Pending", response.State)
+
+	reset := &hivev1.ClusterDeployment{}
+	require.NoError(t, handlers.k8sClient.Get(context.Background(), client.ObjectKeyFromObject(cd), reset))
+	assert.False(t, reset.Spec.Installed)
+	assert.Nil(t, reset.Spec.ClusterMetadata)
+	assert.Nil(t, reset.Status.ProvisionRef)
+	assert.Nil(t, reset.Status.InstalledTimestamp)
+	assert.Empty(t, reset.Status.Conditions)
+	assert.Equal(t, map[string]string{"team": "a"}, reset.Labels)
+	assert.Equal(t, map[string]string{"example.com/keep": "yes"}, reset.Annotations)
+	assert.Equal(t, "Pending", clusterDeploymentState(reset))
+}
+
+func TestHandlers_ResetResource_ProjectClaim(t *testing.T) {
+	pc := &gcpv1alpha1.ProjectClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "pc-1", Namespace: "default"},
+		Status:     gcpv1alpha1.ProjectClaimStatus{State: gcpv1alpha1.ClaimStatusError},
+	}
+	handlers := createTestHandlers(t, pc)
+
+	rec := doRequest(handlers, http.MethodPost, "/api/v1/resources/projectclaim/default/pc-1/reset")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	reset := &gcpv1alpha1.ProjectClaim{}
+	require.NoError(t, handlers.k8sClient.Get(context.Background(), client.ObjectKeyFromObject(pc), reset))
+	assert.Empty(t, reset.Status.State)
+}
+
+func TestHandlers_ResetResource_Errors(t *testing.T) {
+	handlers := createTestHandlers(t)
+
+	rec := doRequest(handlers, http.MethodPost, "/api/v1/resources/machine/default/cd-1/reset")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequest(handlers, http.MethodPost, "/api/v1/resources/clusterdeployment/default/missing/reset")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+
+	aaov1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/aws-account-operator/v1alpha1"
+	gcpv1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/gcp-project-operator/v1alpha1"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/state_machine"
+)
+
+// SimulatedResource describes a resource driven by the simulator and its current state
+type SimulatedResource struct {
+	Kind       string  `json:"kind"`
+	Namespace  string  `json:"namespace"`
+	Name       string  `json:"name"`
+	State      string  `json:"state"`
+	AgeSeconds float64 `json:"ageSeconds"`
+}
+
+// ListResources returns every ClusterDeployment, AccountClaim and ProjectClaim with its
+// current state and age, optionally only those of the kind given with ?kind=
+func (h *Handlers) ListResources(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	kindFilter := r.URL.Query().Get("kind")
+	h.logger.Debug(ctx, "GET /api/v1/resources?kind=%s", kindFilter)
+
+	if kindFilter != "" {
+		kind, ok := resourceKinds[strings.ToLower(kindFilter)]
+		if !ok {
+			h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Unknown resource kind: %s", kindFilter))
+			return
+		}
+		kindFilter = kind
+	}
+	included := func(kind string) bool {
+		return kindFilter == "" || kindFilter == kind
+	}
+
+	now := time.Now()
+	resources := []SimulatedResource{}
+	add := func(kind string, meta metav1.ObjectMeta, state string) {
+		resources = append(resources, SimulatedResource{
+			Kind:       kind,
+			Namespace:  meta.Namespace,
+			Name:       meta.Name,
+			State:      state,
+			AgeSeconds: now.Sub(meta.CreationTimestamp.Time).Seconds(),
+		})
+	}
+
+	if included("ClusterDeployment") {
+		cdList := &hivev1.ClusterDeploymentList{}
+		if err := h.k8sClient.List(ctx, cdList); err != nil {
+			h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list ClusterDeployments: %v", err))
+			return
+		}
+		for i := range cdList.Items {
+			add("ClusterDeployment", cdList.Items[i].ObjectMeta, clusterDeploymentState(&cdList.Items[i]))
+		}
+	}
+
+	if included("AccountClaim") {
+		acList := &aaov1alpha1.AccountClaimList{}
+		if err := h.k8sClient.List(ctx, acList); err != nil {
+			h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list AccountClaims: %v", err))
+			return
+		}
+		acStateMachine := state_machine.NewAccountClaimStateMachine(h.logger, h.behaviorEngine.GetAccountClaimConfig(), clock.RealClock{})
+		for i := range acList.Items {
+			add("AccountClaim", acList.Items[i].ObjectMeta, acStateMachine.CurrentState(&acList.Items[i]))
+		}
+	}
+
+	if included("ProjectClaim") {
+		pcList := &gcpv1alpha1.ProjectClaimList{}
+		if err := h.k8sClient.List(ctx, pcList); err != nil {
+			h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list ProjectClaims: %v", err))
+			return
+		}
+		pcStateMachine := state_machine.NewProjectClaimStateMachine(h.logger, h.behaviorEngine.GetProjectClaimConfig(), clock.RealClock{})
+		for i := range pcList.Items {
+			add("ProjectClaim", pcList.Items[i].ObjectMeta, pcStateMachine.CurrentState(&pcList.Items[i]))
+		}
+	}
+
+	sort.Slice(resources, func(i, j int) bool {
+		if resources[i].Kind != resources[j].Kind {
+			return resources[i].Kind < resources[j].Kind
+		}
+		if resources[i].Namespace != resources[j].Namespace {
+			return resources[i].Namespace < resources[j].Namespace
+		}
+		return resources[i].Name < resources[j].Name
+	})
+	h.writeJSON(w, http.StatusOK, resources)
+}
+
+// clusterDeploymentState returns the state of the ClusterDeployment as the controller
+// derives it, or Failed once it has failed
+func clusterDeploymentState(cd *hivev1.ClusterDeployment) string {
+	if state_machine.IsFailed(cd) {
+		return "Failed"
+	}
+	return state_machine.ClusterDeploymentState(cd)
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	aaov1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/aws-account-operator/v1alpha1"
+	gcpv1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/gcp-project-operator/v1alpha1"
+)
+
+func TestHandlers_ListResources(t *testing.T) {
+	handlers := createTestHandlers(t,
+		&hivev1.ClusterDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "running", Namespace: "team-a"},
+			Spec:       hivev1.ClusterDeploymentSpec{Installed: true},
+		},
+		&hivev1.ClusterDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "failed", Namespace: "team-a"},
+			Status: hivev1.ClusterDeploymentStatus{
+				ProvisionRef: &corev1.LocalObjectReference{Name: "failed-provision-failed"},
+			},
+		},
+		&aaov1alpha1.AccountClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "ready", Namespace: "team-a"},
+			Status:     aaov1alpha1.AccountClaimStatus{State: aaov1alpha1.ClaimStatusReady},
+		},
+		&gcpv1alpha1.ProjectClaim{ObjectMeta: metav1.ObjectMeta{Name: "new", Namespace: "team-b"}},
+	)
+
+	rec := doRequest(handlers, http.MethodGet, "/api/v1/resources")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resources []SimulatedResource
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resources))
+	require.Len(t, resources, 4)
+	states := make([]string, 0, len(resources))
+	for _, resource := range resources {
+		states = append(states, resource.Kind+"/"+resource.Namespace+"/"+resource.Name+"="+resource.State)
+	}
+	assert.Equal(t, []string{
+		"AccountClaim/team-a/ready=Ready",
+		"ClusterDeployment/team-a/failed=Failed",
+		"ClusterDeployment/team-a/running=Running",
+		"ProjectClaim/team-b/new=Pending",
+	}, states)
+
+	rec = doRequest(handlers, http.MethodGet, "/api/v1/resources?kind=ClusterDeployment")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resources))
+	require.Len(t, resources, 2)
+	for _, resource := range resources {
+		assert.Equal(t, "ClusterDeployment", resource.Kind)
+	}
+}
+
+func TestHandlers_ListResources_UnknownKind(t *testing.T) {
+	handlers := createTestHandlers(t)
+
+	rec := doRequest(handlers, http.MethodGet, "/api/v1/resources?kind=Machine")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequest(handlers, http.MethodGet, "/api/v1/resources")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, "[]", rec.Body.String())
+}
@@ -7,22 +7,78 @@ import (
 // SetupRoutes sets up the API routes
 func SetupRoutes(handlers *Handlers) *mux.Router {
 	router := mux.NewRouter()
+	router.Use(handlers.addResponseHeaders)
+	router.Use(handlers.requireAPIToken)
 
 	// Configuration endpoints
 	router.HandleFunc("/api/v1/config", handlers.GetConfig).Methods("GET")
+	router.HandleFunc("/api/v1/config/{resourceType}", handlers.GetResourceConfig).Methods("GET")
 	router.HandleFunc("/api/v1/config/clusterdeployment", handlers.UpdateClusterDeploymentConfig).Methods("POST")
 	router.HandleFunc("/api/v1/config/accountclaim", handlers.UpdateAccountClaimConfig).Methods("POST")
 	router.HandleFunc("/api/v1/config/projectclaim", handlers.UpdateProjectClaimConfig).Methods("POST")
+	router.HandleFunc("/api/v1/config/{resourceType}/states/{stateName}", handlers.UpdateStateDuration).Methods("PATCH")
+	router.HandleFunc("/api/v1/speed", handlers.SetSpeedFactor).Methods("POST")
 
 	// Per-resource override endpoints
+	router.HandleFunc("/api/v1/overrides/bulk", handlers.SetOverridesBulk).Methods("POST")
+	router.HandleFunc("/api/v1/overrides/bulk", handlers.ClearOverridesBulk).Methods("DELETE")
 	router.HandleFunc("/api/v1/overrides/{resourceType}/{namespace}/{name}/failure", handlers.SetResourceFailure).Methods("POST")
 	router.HandleFunc("/api/v1/overrides/{resourceType}/{namespace}/{name}/delay", handlers.SetResourceDelay).Methods("POST")
 	router.HandleFunc("/api/v1/overrides/{resourceType}/{namespace}/{name}/success", handlers.SetResourceSuccess).Methods("POST")
+	router.HandleFunc("/api/v1/overrides/{resourceType}/{namespace}/{name}/pause", handlers.PauseResource).Methods("POST")
+	router.HandleFunc("/api/v1/overrides/{resourceType}/{namespace}/{name}/resume", handlers.ResumeResource).Methods("POST")
 	router.HandleFunc("/api/v1/overrides/{resourceType}/{namespace}/{name}", handlers.ClearResourceOverride).Methods("DELETE")
+	router.HandleFunc("/api/v1/overrides", handlers.ListOverrides).Methods("GET")
+	router.HandleFunc("/api/v1/overrides", handlers.ClearOverridesMatching).Methods("DELETE")
+
+	// Label selector override endpoints
+	router.HandleFunc("/api/v1/selector-overrides", handlers.AddSelectorOverride).Methods("POST")
+	router.HandleFunc("/api/v1/selector-overrides", handlers.ListSelectorOverrides).Methods("GET")
+	router.HandleFunc("/api/v1/selector-overrides", handlers.ClearSelectorOverrides).Methods("DELETE")
+
+	// Event stream endpoint
+	router.HandleFunc("/api/v1/events/stream", handlers.StreamEvents).Methods("GET")
+
+	// Webhook endpoints
+	router.HandleFunc("/api/v1/webhooks", handlers.RegisterWebhook).Methods("POST")
+	router.HandleFunc("/api/v1/webhooks", handlers.ListWebhooks).Methods("GET")
+	router.HandleFunc("/api/v1/webhooks", handlers.ClearWebhooks).Methods("DELETE")
 
 	// State management endpoints
 	router.HandleFunc("/api/v1/reset", handlers.Reset).Methods("POST")
+	router.HandleFunc("/api/v1/resync", handlers.Resync).Methods("POST")
+	router.HandleFunc("/api/v1/pause", handlers.PauseReconciliation).Methods("POST")
+	router.HandleFunc("/api/v1/resume", handlers.ResumeReconciliation).Methods("POST")
 	router.HandleFunc("/api/v1/status", handlers.GetStatus).Methods("GET")
+	router.HandleFunc("/api/v1/export", handlers.ExportState).Methods("GET")
+	router.HandleFunc("/api/v1/import", handlers.ImportState).Methods("POST")
+	router.HandleFunc("/api/v1/version", handlers.GetVersion).Methods("GET")
+	router.HandleFunc("/api/v1/healthz", handlers.Healthz).Methods("GET")
+	router.HandleFunc("/api/v1/readyz", handlers.Readyz).Methods("GET")
+	router.HandleFunc("/api/v1/openapi.json", handlers.GetOpenAPISpec).Methods("GET")
+
+	// Metrics endpoint
+	router.HandleFunc("/metrics", handlers.Metrics).Methods("GET")
+
+	// Debug endpoints
+	router.HandleFunc("/api/v1/debug/rolls", handlers.GetRolls).Methods("GET")
+
+	// Scenario endpoints
+	router.HandleFunc("/api/v1/scenarios/osd", handlers.CreateOSDScenario).Methods("POST")
+	router.HandleFunc("/api/v1/scenarios/status", handlers.GetScenarioStatus).Methods("GET")
+
+	// ClusterImageSet endpoints
+	router.HandleFunc("/api/v1/imagesets", handlers.CreateImageSet).Methods("POST")
+	router.HandleFunc("/api/v1/imagesets/{name}", handlers.DeleteImageSet).Methods("DELETE")
+
+	// Resource inspection endpoints
+	router.HandleFunc("/api/v1/namespaces", handlers.ListNamespaces).Methods("GET")
+	router.HandleFunc("/api/v1/resources", handlers.ListResources).Methods("GET")
+	router.HandleFunc("/api/v1/resources/validate", handlers.ValidateResource).Methods("POST")
+	router.HandleFunc("/api/v1/resources/{type}/{namespace}/{name}/eta", handlers.GetResourceETA).Methods("GET")
+	router.HandleFunc("/api/v1/resources/{type}/{namespace}/{name}/recreate", handlers.RecreateResource).Methods("POST")
+	router.HandleFunc("/api/v1/resources/{type}/{namespace}/{name}/state", handlers.SetResourceState).Methods("POST")
+	router.HandleFunc("/api/v1/resources/{type}/{namespace}/{name}/reset", handlers.ResetResource).Methods("POST")
 
 	return router
 }
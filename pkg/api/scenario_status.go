@@ -0,0 +1,86 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"k8s.io/utils/clock"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+
+	aaov1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/aws-account-operator/v1alpha1"
+	gcpv1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/gcp-project-operator/v1alpha1"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/state_machine"
+)
+
+// ScenarioStatus aggregates the outcome of the resources of a scenario run
+type ScenarioStatus struct {
+	Total     int `json:"total"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	Pending   int `json:"pending"`
+}
+
+// add counts a resource by its current state. A resource has succeeded once it reaches
+// the final configured state of its lifecycle.
+func (s *ScenarioStatus) add(state string, failed bool, states []config.StateConfig) {
+	s.Total++
+	switch {
+	case failed:
+		s.Failed++
+	case len(states) > 0 && state == states[len(states)-1].Name:
+		s.Succeeded++
+	default:
+		s.Pending++
+	}
+}
+
+// GetScenarioStatus reports how many ClusterDeployments, AccountClaims and ProjectClaims
+// labeled with the given run ID have succeeded, failed or are still progressing
+func (h *Handlers) GetScenarioStatus(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	runID := r.URL.Query().Get("runID")
+	h.logger.Debug(ctx, "GET /api/v1/scenarios/status?runID=%s", runID)
+
+	listOpts := runIDListOptions(runID)
+	var status ScenarioStatus
+
+	cdList := &hivev1.ClusterDeploymentList{}
+	if err := h.k8sClient.List(ctx, cdList, listOpts...); err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list ClusterDeployments: %v", err))
+		return
+	}
+	cdConfig := h.behaviorEngine.GetClusterDeploymentConfig()
+	cdStateMachine := state_machine.NewClusterDeploymentStateMachine(h.logger, cdConfig, clock.RealClock{})
+	for i := range cdList.Items {
+		state, _, _ := cdStateMachine.EstimateRemaining(&cdList.Items[i], nil)
+		status.add(state, state == "Failed", cdConfig.States)
+	}
+
+	acList := &aaov1alpha1.AccountClaimList{}
+	if err := h.k8sClient.List(ctx, acList, listOpts...); err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list AccountClaims: %v", err))
+		return
+	}
+	acConfig := h.behaviorEngine.GetAccountClaimConfig()
+	acStateMachine := state_machine.NewAccountClaimStateMachine(h.logger, acConfig, clock.RealClock{})
+	for i := range acList.Items {
+		state, _, _ := acStateMachine.EstimateRemaining(&acList.Items[i], nil)
+		status.add(state, state == string(aaov1alpha1.ClaimStatusError), acConfig.States)
+	}
+
+	pcList := &gcpv1alpha1.ProjectClaimList{}
+	if err := h.k8sClient.List(ctx, pcList, listOpts...); err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to list ProjectClaims: %v", err))
+		return
+	}
+	pcConfig := h.behaviorEngine.GetProjectClaimConfig()
+	pcStateMachine := state_machine.NewProjectClaimStateMachine(h.logger, pcConfig, clock.RealClock{})
+	for i := range pcList.Items {
+		state, _, _ := pcStateMachine.EstimateRemaining(&pcList.Items[i], nil)
+		status.add(state, state == string(gcpv1alpha1.ClaimStatusError), pcConfig.States)
+	}
+
+	h.writeJSON(w, http.StatusOK, status)
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	aaov1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/aws-account-operator/v1alpha1"
+	gcpv1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/gcp-project-operator/v1alpha1"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/labels"
+)
+
+func TestHandlers_GetScenarioStatus_MixedOutcomes(t *testing.T) {
+	runLabels := map[string]string{labels.RunID: "run-1"}
+	running := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "running", Namespace: "default", Labels: runLabels},
+		Spec:       hivev1.ClusterDeploymentSpec{Installed: true},
+	}
+	provisioning := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "provisioning", Namespace: "default", Labels: runLabels},
+		Status: hivev1.ClusterDeploymentStatus{
+			ProvisionRef: &corev1.LocalObjectReference{Name: "provisioning-provision"},
+		},
+	}
+	failedCD := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "failed", Namespace: "default", Labels: runLabels},
+		Status: hivev1.ClusterDeploymentStatus{
+			ProvisionRef: &corev1.LocalObjectReference{Name: "failed-provision-failed"},
+		},
+	}
+	readyAC := &aaov1alpha1.AccountClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "ready", Namespace: "default", Labels: runLabels},
+		Status:     aaov1alpha1.AccountClaimStatus{State: aaov1alpha1.ClaimStatusReady},
+	}
+	erroredPC := &gcpv1alpha1.ProjectClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "errored", Namespace: "default", Labels: runLabels},
+		Status:     gcpv1alpha1.ProjectClaimStatus{State: gcpv1alpha1.ClaimStatusError},
+	}
+	newPC := &gcpv1alpha1.ProjectClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "new", Namespace: "default", Labels: runLabels},
+	}
+	// Resources of another run are not counted
+	otherRun := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default", Labels: map[string]string{labels.RunID: "run-2"}},
+		Spec:       hivev1.ClusterDeploymentSpec{Installed: true},
+	}
+	handlers := createTestHandlers(t, running, provisioning, failedCD, readyAC, erroredPC, newPC, otherRun)
+
+	rec := doRequest(handlers, http.MethodGet, "/api/v1/scenarios/status?runID=run-1")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var status ScenarioStatus
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	assert.Equal(t, ScenarioStatus{Total: 6, Succeeded: 2, Failed: 2, Pending: 2}, status)
+
+	rec = doRequest(handlers, http.MethodGet, "/api/v1/scenarios/status?runID=unknown")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	assert.Equal(t, ScenarioStatus{}, status)
+}
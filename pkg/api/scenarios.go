@@ -0,0 +1,134 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	corev1 "k8s.io/api/core/v1"
+	kuberrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	hivev1aws "github.com/openshift/hive/apis/hive/v1/aws"
+
+	aaov1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/aws-account-operator/v1alpha1"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/labels"
+)
+
+// OSDScenarioRequest is the request body for creating an OSD (AWS) provisioning scenario
+type OSDScenarioRequest struct {
+	// Namespace to create the resources in (defaults to the configured default namespace)
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name of the ClusterDeployment (generated if empty)
+	Name string `json:"name,omitempty"`
+
+	// Region is the AWS region (defaults to us-east-1)
+	Region string `json:"region,omitempty"`
+}
+
+// OSDScenarioResponse describes the resources created for an OSD scenario
+type OSDScenarioResponse struct {
+	Namespace         string `json:"namespace"`
+	ClusterID         string `json:"clusterID"`
+	AccountClaim      string `json:"accountClaim"`
+	ClusterDeployment string `json:"clusterDeployment"`
+}
+
+// CreateOSDScenario creates an AccountClaim and a dependent ClusterDeployment linked by the cluster ID label
+func (h *Handlers) CreateOSDScenario(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.logger.Debug(ctx, "POST /api/v1/scenarios/osd")
+
+	var req OSDScenarioRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+			return
+		}
+	}
+
+	namespace := h.behaviorEngine.ResolveNamespace(req.Namespace)
+	name := req.Name
+	if name == "" {
+		name = "osd-" + utilrand.String(8)
+	}
+	region := req.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	clusterID := name
+	credentialsSecret := name + "-aws-creds"
+
+	ac := &aaov1alpha1.AccountClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name + "-account",
+			Namespace: namespace,
+			Labels:    map[string]string{labels.ID: clusterID},
+		},
+		Spec: aaov1alpha1.AccountClaimSpec{
+			LegalEntity: aaov1alpha1.LegalEntity{
+				Name: "simulated-legal-entity",
+				ID:   "simulated-legal-entity-id",
+			},
+			AwsCredentialSecret: aaov1alpha1.SecretRef{
+				Name:      credentialsSecret,
+				Namespace: namespace,
+			},
+			Aws: aaov1alpha1.Aws{
+				Regions: []aaov1alpha1.AwsRegions{{Name: region}},
+			},
+		},
+	}
+
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				labels.ID:        clusterID,
+				"cloud-provider": "aws",
+			},
+		},
+		Spec: hivev1.ClusterDeploymentSpec{
+			ClusterName: name,
+			BaseDomain:  "example.com",
+			Platform: hivev1.Platform{
+				AWS: &hivev1aws.Platform{
+					Region:               region,
+					CredentialsSecretRef: corev1.LocalObjectReference{Name: credentialsSecret},
+				},
+			},
+		},
+	}
+
+	runID := h.behaviorEngine.GetRunID()
+	labels.StampRunID(ac, runID)
+	labels.StampRunID(cd, runID)
+
+	// The AccountClaim is created first; the ClusterDeployment waits for it via the dependency check
+	if err := h.k8sClient.Create(ctx, ac); err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create AccountClaim: %v", err))
+		return
+	}
+	if err := h.k8sClient.Create(ctx, cd); err != nil {
+		// Do not leave the AccountClaim of a scenario that was not created behind
+		if deleteErr := h.k8sClient.Delete(ctx, ac); deleteErr != nil && !kuberrors.IsNotFound(deleteErr) {
+			h.writeError(w, http.StatusInternalServerError, fmt.Sprintf(
+				"Failed to create ClusterDeployment: %v, and to delete AccountClaim %s/%s: %v", err, namespace, ac.Name, deleteErr))
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create ClusterDeployment: %v", err))
+		return
+	}
+
+	h.logger.Info(ctx, "Created OSD scenario in %s: AccountClaim %s, ClusterDeployment %s", namespace, ac.Name, cd.Name)
+	h.writeJSON(w, http.StatusCreated, OSDScenarioResponse{
+		Namespace:         namespace,
+		ClusterID:         clusterID,
+		AccountClaim:      ac.Name,
+		ClusterDeployment: cd.Name,
+	})
+}
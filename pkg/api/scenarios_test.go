@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	kuberrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	aaov1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/aws-account-operator/v1alpha1"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/controllers"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/labels"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/state_machine"
+)
+
+func TestHandlers_CreateOSDScenario(t *testing.T) {
+	ctx := context.Background()
+	handlers := createTestHandlers(t)
+
+	rec := doRequestWithBody(handlers, http.MethodPost, "/api/v1/scenarios/osd", `{"namespace": "osd-test", "name": "my-cluster"}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	var resp OSDScenarioResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "osd-test", resp.Namespace)
+	assert.Equal(t, "my-cluster", resp.ClusterDeployment)
+
+	acKey := client.ObjectKey{Namespace: resp.Namespace, Name: resp.AccountClaim}
+	cdKey := client.ObjectKey{Namespace: resp.Namespace, Name: resp.ClusterDeployment}
+
+	ac := &aaov1alpha1.AccountClaim{}
+	require.NoError(t, handlers.k8sClient.Get(ctx, acKey, ac))
+	cd := &hivev1.ClusterDeployment{}
+	require.NoError(t, handlers.k8sClient.Get(ctx, cdKey, cd))
+	assert.Equal(t, ac.Labels[labels.ID], cd.Labels[labels.ID])
+
+	// Drive the reconcilers by hand: the CD must not install before the AccountClaim is Ready
+	logger := createTestLogger()
+	cfg := handlers.behaviorEngine.GetConfig()
+	acReconciler := controllers.NewAccountClaimReconciler(handlers.k8sClient, logger,
+		state_machine.NewAccountClaimStateMachine(logger, cfg.AccountClaim, clock.RealClock{}), handlers.behaviorEngine)
+	cdReconciler := controllers.NewClusterDeploymentReconciler(handlers.k8sClient, logger,
+		state_machine.NewClusterDeploymentStateMachine(logger, cfg.ClusterDeployment, clock.RealClock{}), handlers.behaviorEngine)
+
+	for i := 0; i < 5; i++ {
+		_, err := cdReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: cdKey})
+		require.NoError(t, err)
+	}
+	require.NoError(t, handlers.k8sClient.Get(ctx, cdKey, cd))
+	assert.Nil(t, cd.Status.ProvisionRef, "ClusterDeployment must wait for the AccountClaim")
+	assert.Nil(t, cd.Status.InstalledTimestamp)
+
+	for i := 0; i < 3; i++ {
+		_, err := acReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: acKey})
+		require.NoError(t, err)
+	}
+	require.NoError(t, handlers.k8sClient.Get(ctx, acKey, ac))
+	require.Equal(t, aaov1alpha1.ClaimStatusReady, ac.Status.State)
+
+	for i := 0; i < 5; i++ {
+		_, err := cdReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: cdKey})
+		require.NoError(t, err)
+	}
+	require.NoError(t, handlers.k8sClient.Get(ctx, cdKey, cd))
+	assert.NotNil(t, cd.Status.InstalledTimestamp, "ClusterDeployment should reach Running after the AccountClaim is Ready")
+}
+
+func TestHandlers_CreateOSDScenario_ClusterDeploymentFails(t *testing.T) {
+	ctx := context.Background()
+	existing := &hivev1.ClusterDeployment{ObjectMeta: metav1.ObjectMeta{Name: "my-cluster", Namespace: "osd-test"}}
+	handlers := createTestHandlers(t, existing)
+
+	rec := doRequestWithBody(handlers, http.MethodPost, "/api/v1/scenarios/osd", `{"namespace": "osd-test", "name": "my-cluster"}`)
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Failed to create ClusterDeployment")
+
+	// The AccountClaim created first is removed again
+	err := handlers.k8sClient.Get(ctx, client.ObjectKey{Namespace: "osd-test", Name: "my-cluster-account"}, &aaov1alpha1.AccountClaim{})
+	assert.True(t, kuberrors.IsNotFound(err))
+}
+
+func TestHandlers_CreateOSDScenario_DefaultNamespace(t *testing.T) {
+	cfg := config.DefaultConfig()
+	require.NoError(t, cfg.SetRunID("ci-7"))
+	handlers := createTestHandlersWithConfig(t, cfg)
+
+	rec := doRequest(handlers, http.MethodPost, "/api/v1/scenarios/osd")
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	var resp OSDScenarioResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "default", resp.Namespace)
+	assert.NotEmpty(t, resp.ClusterDeployment)
+
+	// Created resources carry the run ID
+	cd := &hivev1.ClusterDeployment{}
+	require.NoError(t, handlers.k8sClient.Get(context.Background(),
+		client.ObjectKey{Namespace: resp.Namespace, Name: resp.ClusterDeployment}, cd))
+	assert.Equal(t, "ci-7", cd.Labels[labels.RunID])
+}
+
+func TestHandlers_CreateOSDScenario_ConfiguredDefaultNamespace(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.DefaultNamespace = "sim-workloads"
+	handlers := createTestHandlersWithConfig(t, cfg)
+
+	rec := doRequestWithBody(handlers, http.MethodPost, "/api/v1/scenarios/osd", `{"name": "osd-1"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var resp OSDScenarioResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "sim-workloads", resp.Namespace)
+
+	// Both resources land in the configured namespace, including the credentials reference
+	ctx := context.Background()
+	ac := &aaov1alpha1.AccountClaim{}
+	require.NoError(t, handlers.k8sClient.Get(ctx, client.ObjectKey{Namespace: "sim-workloads", Name: resp.AccountClaim}, ac))
+	assert.Equal(t, "sim-workloads", ac.Spec.AwsCredentialSecret.Namespace)
+	cd := &hivev1.ClusterDeployment{}
+	require.NoError(t, handlers.k8sClient.Get(ctx, client.ObjectKey{Namespace: "sim-workloads", Name: "osd-1"}, cd))
+}
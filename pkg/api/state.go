@@ -0,0 +1,174 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	kuberrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/utils/clock"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gorilla/mux"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+
+	aaov1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/aws-account-operator/v1alpha1"
+	gcpv1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/gcp-project-operator/v1alpha1"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/state_machine"
+)
+
+// SetStateRequest moves a resource to a configured state
+type SetStateRequest struct {
+	State string `json:"state"`
+
+	// Force allows moving a resource out of its terminal state, e.g. an installed
+	// ClusterDeployment back to Installing
+	Force bool `json:"force,omitempty"`
+}
+
+// SetStateResponse describes the state a resource was moved from and to
+type SetStateResponse struct {
+	ResourceType  string `json:"resourceType"`
+	Namespace     string `json:"namespace"`
+	Name          string `json:"name"`
+	PreviousState string `json:"previousState"`
+	State         string `json:"state"`
+}
+
+// SetResourceState immediately applies a configured state to a resource instead of waiting
+// for its transition. A resource in its terminal state is only moved with force.
+func (h *Handlers) SetResourceState(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	vars := mux.Vars(r)
+	resourceType := vars["type"]
+	namespace := vars["namespace"]
+	name := vars["name"]
+
+	h.logger.Debug(ctx, "POST /api/v1/resources/%s/%s/%s/state", resourceType, namespace, name)
+
+	kind, ok := resourceKinds[strings.ToLower(resourceType)]
+	if !ok {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Unknown resource type: %s", resourceType))
+		return
+	}
+
+	var req SetStateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+	if req.State == "" {
+		h.writeError(w, http.StatusBadRequest, "state is required")
+		return
+	}
+
+	key := client.ObjectKey{Namespace: namespace, Name: name}
+	var obj client.Object
+	switch kind {
+	case "ClusterDeployment":
+		obj = &hivev1.ClusterDeployment{}
+	case "AccountClaim":
+		obj = &aaov1alpha1.AccountClaim{}
+	case "ProjectClaim":
+		obj = &gcpv1alpha1.ProjectClaim{}
+	}
+	if err := h.k8sClient.Get(ctx, key, obj); err != nil {
+		if kuberrors.IsNotFound(err) {
+			h.writeError(w, http.StatusNotFound, fmt.Sprintf("%s %s/%s not found", kind, namespace, name))
+			return
+		}
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get %s %s/%s: %v", kind, namespace, name, err))
+		return
+	}
+
+	// Each case validates the state, guards terminal resources and applies the state
+	var previousState string
+	var known, terminal bool
+	var apply func() error
+	switch obj := obj.(type) {
+	case *hivev1.ClusterDeployment:
+		sm := state_machine.NewClusterDeploymentStateMachine(h.logger, h.behaviorEngine.GetClusterDeploymentConfig(), clock.RealClock{})
+		previousState = clusterDeploymentState(obj)
+		known = sm.HasState(obj, req.State)
+		terminal = sm.IsTerminal(obj)
+		apply = func() error {
+			if terminal {
+				sm.Rewind(obj)
+			}
+			if err := sm.ApplyState(ctx, obj, req.State); err != nil {
+				return err
+			}
+			// The installed flag and the state annotation are not part of the status
+			status := obj.Status.DeepCopy()
+			if err := h.k8sClient.Update(ctx, obj); err != nil {
+				return err
+			}
+			obj.Status = *status
+			return h.k8sClient.Status().Update(ctx, obj)
+		}
+	case *aaov1alpha1.AccountClaim:
+		sm := state_machine.NewAccountClaimStateMachine(h.logger, h.behaviorEngine.GetAccountClaimConfig(), clock.RealClock{})
+		previousState = sm.CurrentState(obj)
+		known = sm.HasState(req.State)
+		terminal = sm.IsTerminal(obj)
+		apply = func() error {
+			specChanged, err := sm.ApplyState(ctx, obj, aaov1alpha1.ClaimStatus(req.State))
+			if err != nil {
+				return err
+			}
+			if specChanged {
+				status := obj.Status.DeepCopy()
+				if err := h.k8sClient.Update(ctx, obj); err != nil {
+					return err
+				}
+				obj.Status = *status
+			}
+			return h.k8sClient.Status().Update(ctx, obj)
+		}
+	case *gcpv1alpha1.ProjectClaim:
+		sm := state_machine.NewProjectClaimStateMachine(h.logger, h.behaviorEngine.GetProjectClaimConfig(), clock.RealClock{})
+		previousState = sm.CurrentState(obj)
+		known = sm.HasState(req.State)
+		terminal = sm.IsTerminal(obj)
+		apply = func() error {
+			specChanged, err := sm.ApplyState(ctx, obj, gcpv1alpha1.ClaimStatus(req.State))
+			if err != nil {
+				return err
+			}
+			if specChanged {
+				status := obj.Status.DeepCopy()
+				if err := h.k8sClient.Update(ctx, obj); err != nil {
+					return err
+				}
+				obj.Status = *status
+			}
+			return h.k8sClient.Status().Update(ctx, obj)
+		}
+	}
+
+	if !known {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("%s state %s not found in configuration", kind, req.State))
+		return
+	}
+	if terminal && req.State != previousState && !req.Force {
+		h.writeError(w, http.StatusConflict, fmt.Sprintf("%s %s/%s is in terminal state %s, set force to move it to %s",
+			kind, namespace, name, previousState, req.State))
+		return
+	}
+	if err := apply(); err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to apply state %s to %s %s/%s: %v",
+			req.State, kind, namespace, name, err))
+		return
+	}
+
+	h.logger.Info(ctx, "Moved %s %s/%s from state %s to %s", kind, namespace, name, previousState, req.State)
+	h.writeJSON(w, http.StatusOK, SetStateResponse{
+		ResourceType:  kind,
+		Namespace:     namespace,
+		Name:          name,
+		PreviousState: previousState,
+		State:         req.State,
+	})
+}
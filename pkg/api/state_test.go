@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	aaov1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/aws-account-operator/v1alpha1"
+)
+
+func TestHandlers_SetResourceState_ClusterDeployment(t *testing.T) {
+	cd := &hivev1.ClusterDeployment{ObjectMeta: metav1.ObjectMeta{Name: "cd-1", Namespace: "default"}}
+	handlers := createTestHandlers(t, cd)
+	key := client.ObjectKey{Namespace: "default", Name: "cd-1"}
+
+	rec := doRequestWithBody(handlers, http.MethodPost, "/api/v1/resources/clusterdeployment/default/cd-1/state", `{"state": "Running"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var response SetStateResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, SetStateResponse{
+		ResourceType:  "ClusterDeployment",
+		Namespace:     "default",
+		Name:          "cd-1",
+		PreviousState: "Pending",
+		State:         "Running",
+	}, response)
+
+	updated := &hivev1.ClusterDeployment{}
+	require.NoError(t, handlers.k8sClient.Get(context.Background(), key, updated))
+	assert.True(t, updated.Spec.Installed)
+	assert.NotNil(t, updated.Status.InstalledTimestamp)
+
+	// An installed cluster only goes back with force
+	rec = doRequestWithBody(handlers, http.MethodPost, "/api/v1/resources/clusterdeployment/default/cd-1/state", `{"state": "Installing"}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = doRequestWithBody(handlers, http.MethodPost, "/api/v1/resources/clusterdeployment/default/cd-1/state", `{"state": "Installing", "force": true}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, handlers.k8sClient.Get(context.Background(), key, updated))
+	assert.False(t, updated.Spec.Installed)
+	assert.Equal(t, "Installing", clusterDeploymentState(updated))
+}
+
+func TestHandlers_SetResourceState_AccountClaim(t *testing.T) {
+	ac := &aaov1alpha1.AccountClaim{ObjectMeta: metav1.ObjectMeta{Name: "ac-1", Namespace: "default"}}
+	handlers := createTestHandlers(t, ac)
+
+	rec := doRequestWithBody(handlers, http.MethodPost, "/api/v1/resources/accountclaim/default/ac-1/state", `{"state": "Ready"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	updated := &aaov1alpha1.AccountClaim{}
+	require.NoError(t, handlers.k8sClient.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "ac-1"}, updated))
+	assert.Equal(t, aaov1alpha1.ClaimStatusReady, updated.Status.State)
+	assert.NotEmpty(t, updated.Spec.BYOCAWSAccountID)
+
+	// Reapplying the terminal state is not a move backward
+	rec = doRequestWithBody(handlers, http.MethodPost, "/api/v1/resources/accountclaim/default/ac-1/state", `{"state": "Ready"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = doRequestWithBody(handlers, http.MethodPost, "/api/v1/resources/accountclaim/default/ac-1/state", `{"state": "Pending"}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestHandlers_SetResourceState_Errors(t *testing.T) {
+	cd := &hivev1.ClusterDeployment{ObjectMeta: metav1.ObjectMeta{Name: "cd-1", Namespace: "default"}}
+	handlers := createTestHandlers(t, cd)
+
+	tests := []struct {
+		name string
+		path string
+		body string
+		code int
+	}{
+		{"unknown type", "/api/v1/resources/machine/default/cd-1/state", `{"state": "Running"}`, http.StatusBadRequest},
+		{"invalid body", "/api/v1/resources/clusterdeployment/default/cd-1/state", `{`, http.StatusBadRequest},
+		{"missing state", "/api/v1/resources/clusterdeployment/default/cd-1/state", `{}`, http.StatusBadRequest},
+		{"unknown state", "/api/v1/resources/clusterdeployment/default/cd-1/state", `{"state": "Exploded"}`, http.StatusBadRequest},
+		{"not found", "/api/v1/resources/clusterdeployment/default/missing/state", `{"state": "Running"}`, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := doRequestWithBody(handlers, http.MethodPost, tt.path, tt.body)
+			assert.Equal(t, tt.code, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	kuberrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+
+	aaov1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/aws-account-operator/v1alpha1"
+	gcpv1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/gcp-project-operator/v1alpha1"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/labels"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/state_machine"
+)
+
+// ValidationIssue is a reason the simulator would not handle a resource as expected
+type ValidationIssue struct {
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationResult lists the issues found when validating a resource. The resource is
+// valid when there are none.
+type ValidationResult struct {
+	ResourceType string            `json:"resourceType"`
+	Namespace    string            `json:"namespace"`
+	Name         string            `json:"name"`
+	Valid        bool              `json:"valid"`
+	Issues       []ValidationIssue `json:"issues"`
+}
+
+// ValidateResource runs the prerequisite checks the reconcilers would on a resource given
+// in the request body, without creating it. Resources without a namespace are checked in
+// the default namespace.
+func (h *Handlers) ValidateResource(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.logger.Debug(ctx, "POST /api/v1/resources/validate")
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Failed to read request body: %v", err))
+		return
+	}
+	var typeMeta metav1.TypeMeta
+	if err := json.Unmarshal(body, &typeMeta); err != nil {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+	kind, ok := resourceKinds[strings.ToLower(typeMeta.Kind)]
+	if !ok {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Unknown resource kind: %q", typeMeta.Kind))
+		return
+	}
+
+	var obj client.Object
+	switch kind {
+	case "ClusterDeployment":
+		obj = &hivev1.ClusterDeployment{}
+	case "AccountClaim":
+		obj = &aaov1alpha1.AccountClaim{}
+	case "ProjectClaim":
+		obj = &gcpv1alpha1.ProjectClaim{}
+	}
+	if err := json.Unmarshal(body, obj); err != nil {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid %s: %v", kind, err))
+		return
+	}
+	obj.SetNamespace(h.behaviorEngine.ResolveNamespace(obj.GetNamespace()))
+
+	issues, err := h.validateCommon(ctx, kind, obj)
+	if err == nil {
+		var kindIssues []ValidationIssue
+		switch resource := obj.(type) {
+		case *hivev1.ClusterDeployment:
+			kindIssues, err = h.validateClusterDeployment(ctx, resource)
+		case *aaov1alpha1.AccountClaim:
+			kindIssues = validateAccountClaim(resource)
+		case *gcpv1alpha1.ProjectClaim:
+			kindIssues = validateProjectClaim(resource)
+		}
+		issues = append(issues, kindIssues...)
+	}
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to validate %s: %v", kind, err))
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, ValidationResult{
+		ResourceType: kind,
+		Namespace:    obj.GetNamespace(),
+		Name:         obj.GetName(),
+		Valid:        len(issues) == 0,
+		Issues:       issues,
+	})
+}
+
+// validateCommon checks the name and namespace of a resource of any kind
+func (h *Handlers) validateCommon(ctx context.Context, kind string, obj client.Object) ([]ValidationIssue, error) {
+	issues := []ValidationIssue{}
+
+	ns := &corev1.Namespace{}
+	if err := h.k8sClient.Get(ctx, client.ObjectKey{Name: obj.GetNamespace()}, ns); err != nil {
+		if !kuberrors.IsNotFound(err) {
+			return nil, err
+		}
+		issues = append(issues, ValidationIssue{
+			Field:   "metadata.namespace",
+			Message: fmt.Sprintf("Namespace %s does not exist", obj.GetNamespace()),
+		})
+	} else if ns.Status.Phase == corev1.NamespaceTerminating {
+		issues = append(issues, ValidationIssue{
+			Field:   "metadata.namespace",
+			Message: fmt.Sprintf("Namespace %s is terminating, its resources are not reconciled", obj.GetNamespace()),
+		})
+	}
+
+	if obj.GetName() == "" {
+		issues = append(issues, ValidationIssue{Field: "metadata.name", Message: "Name is required"})
+		return issues, nil
+	}
+
+	existing := obj.DeepCopyObject().(client.Object)
+	if err := h.k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), existing); err == nil {
+		issues = append(issues, ValidationIssue{
+			Field:   "metadata.name",
+			Message: fmt.Sprintf("%s %s/%s already exists", kind, obj.GetNamespace(), obj.GetName()),
+		})
+	} else if !kuberrors.IsNotFound(err) {
+		return nil, err
+	}
+	return issues, nil
+}
+
+// validateClusterDeployment checks the image set and the dependencies the ClusterDeployment
+// reconciler waits for
+func (h *Handlers) validateClusterDeployment(ctx context.Context, cd *hivev1.ClusterDeployment) ([]ValidationIssue, error) {
+	var issues []ValidationIssue
+
+	// Agent and bare metal clusters reference their image set from the cluster install
+	// and have no cloud account dependency
+	if state_machine.IsAgentPlatform(cd) {
+		return issues, nil
+	}
+
+	if cd.Spec.Provisioning == nil || cd.Spec.Provisioning.ImageSetRef == nil || cd.Spec.Provisioning.ImageSetRef.Name == "" {
+		issues = append(issues, ValidationIssue{
+			Field:   "spec.provisioning.imageSetRef",
+			Message: "No ClusterImageSet is referenced, upgrade availability is not reported",
+		})
+	} else {
+		name := cd.Spec.Provisioning.ImageSetRef.Name
+		if err := h.k8sClient.Get(ctx, client.ObjectKey{Name: name}, &hivev1.ClusterImageSet{}); err != nil {
+			if !kuberrors.IsNotFound(err) {
+				return nil, err
+			}
+			issues = append(issues, ValidationIssue{
+				Field:   "spec.provisioning.imageSetRef",
+				Message: fmt.Sprintf("ClusterImageSet %s does not exist", name),
+			})
+		}
+	}
+
+	// The same dependency selection as the reconciler, based on the cloud-provider label
+	cfg := h.behaviorEngine.GetClusterDeploymentConfig()
+	cloudProvider := cd.Labels["cloud-provider"]
+	var dependency string
+	switch {
+	case cfg.DependsOnAccountClaim && (cloudProvider == "aws" || cloudProvider == ""):
+		dependency = "AccountClaim"
+	case cfg.DependsOnProjectClaim && cloudProvider == "gcp":
+		dependency = "ProjectClaim"
+	default:
+		return issues, nil
+	}
+
+	clusterID, hasLabel := cd.Labels[labels.ID]
+	if !hasLabel {
+		issues = append(issues, ValidationIssue{
+			Field:   "metadata.labels",
+			Message: fmt.Sprintf("No %s label, the %s dependency is not checked", labels.ID, dependency),
+		})
+		return issues, nil
+	}
+
+	found, err := h.hasClaimWithClusterID(ctx, dependency, cd.Namespace, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		issues = append(issues, ValidationIssue{
+			Field: "metadata.labels",
+			Message: fmt.Sprintf("No %s labeled %s=%s in namespace %s, provisioning waits until one is Ready",
+				dependency, labels.ID, clusterID, cd.Namespace),
+		})
+	}
+	return issues, nil
+}
+
+// hasClaimWithClusterID returns true if a claim of the kind with the cluster ID label exists in the namespace
+func (h *Handlers) hasClaimWithClusterID(ctx context.Context, kind, namespace, clusterID string) (bool, error) {
+	opts := []client.ListOption{client.InNamespace(namespace), client.MatchingLabels{labels.ID: clusterID}}
+	if kind == "AccountClaim" {
+		acList := &aaov1alpha1.AccountClaimList{}
+		if err := h.k8sClient.List(ctx, acList, opts...); err != nil {
+			return false, err
+		}
+		return len(acList.Items) > 0, nil
+	}
+	pcList := &gcpv1alpha1.ProjectClaimList{}
+	if err := h.k8sClient.List(ctx, pcList, opts...); err != nil {
+		return false, err
+	}
+	return len(pcList.Items) > 0, nil
+}
+
+// validateAccountClaim checks the credentials secret the AccountClaim reconciler creates once Ready
+func validateAccountClaim(ac *aaov1alpha1.AccountClaim) []ValidationIssue {
+	var issues []ValidationIssue
+	if ac.Spec.AwsCredentialSecret.Name == "" {
+		issues = append(issues, ValidationIssue{
+			Field:   "spec.awsCredentialSecret.name",
+			Message: "No AWS credentials secret is set, none is created once Ready",
+		})
+	} else if ac.Spec.AwsCredentialSecret.Namespace == "" {
+		issues = append(issues, ValidationIssue{
+			Field:   "spec.awsCredentialSecret.namespace",
+			Message: "The AWS credentials secret has no namespace",
+		})
+	}
+	return issues
+}
+
+// validateProjectClaim checks the credentials secret the ProjectClaim reconciler creates once Ready
+func validateProjectClaim(pc *gcpv1alpha1.ProjectClaim) []ValidationIssue {
+	var issues []ValidationIssue
+	if pc.Spec.GCPCredentialSecret.Name == "" {
+		issues = append(issues, ValidationIssue{
+			Field:   "spec.gcpCredentialSecret.name",
+			Message: "No GCP credentials secret is set, none is created once Ready",
+		})
+	} else if pc.Spec.GCPCredentialSecret.Namespace == "" {
+		issues = append(issues, ValidationIssue{
+			Field:   "spec.gcpCredentialSecret.namespace",
+			Message: "The GCP credentials secret has no namespace",
+		})
+	}
+	return issues
+}
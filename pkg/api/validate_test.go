@@ -0,0 +1,102 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	aaov1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/aws-account-operator/v1alpha1"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/labels"
+)
+
+func validateResource(t *testing.T, handlers *Handlers, body string) ValidationResult {
+	rec := doRequestWithBody(handlers, http.MethodPost, "/api/v1/resources/validate", body)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var result ValidationResult
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+	return result
+}
+
+func TestHandlers_ValidateResource_ClusterDeployment(t *testing.T) {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}
+	cis := &hivev1.ClusterImageSet{ObjectMeta: metav1.ObjectMeta{Name: "openshift-v4.18.0"}}
+	ac := &aaov1alpha1.AccountClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "ac-1", Namespace: "default", Labels: map[string]string{labels.ID: "cluster-1"}},
+	}
+	existing := &hivev1.ClusterDeployment{ObjectMeta: metav1.ObjectMeta{Name: "cd-existing", Namespace: "default"}}
+	handlers := createTestHandlers(t, ns, cis, ac, existing)
+
+	result := validateResource(t, handlers, `{
+		"kind": "ClusterDeployment",
+		"metadata": {"name": "cd-1", "namespace": "default", "labels": {"`+labels.ID+`": "cluster-1"}},
+		"spec": {"provisioning": {"imageSetRef": {"name": "openshift-v4.18.0"}}}
+	}`)
+	assert.True(t, result.Valid, result.Issues)
+	assert.Empty(t, result.Issues)
+	assert.Equal(t, "ClusterDeployment", result.ResourceType)
+
+	// A missing image set and a missing AccountClaim are both reported
+	result = validateResource(t, handlers, `{
+		"kind": "ClusterDeployment",
+		"metadata": {"name": "cd-2", "labels": {"`+labels.ID+`": "cluster-2"}},
+		"spec": {"provisioning": {"imageSetRef": {"name": "openshift-v4.99.0"}}}
+	}`)
+	assert.False(t, result.Valid)
+	assert.Equal(t, "default", result.Namespace)
+	require.Len(t, result.Issues, 2)
+	assert.Equal(t, "spec.provisioning.imageSetRef", result.Issues[0].Field)
+	assert.Contains(t, result.Issues[0].Message, "openshift-v4.99.0 does not exist")
+	assert.Contains(t, result.Issues[1].Message, "No AccountClaim")
+
+	result = validateResource(t, handlers, `{"kind": "ClusterDeployment", "metadata": {"name": "cd-existing", "namespace": "missing"}}`)
+	assert.False(t, result.Valid)
+	fields := make([]string, 0, len(result.Issues))
+	for _, issue := range result.Issues {
+		fields = append(fields, issue.Field)
+	}
+	assert.Equal(t, []string{"metadata.namespace", "spec.provisioning.imageSetRef", "metadata.labels"}, fields)
+
+	// Nothing is created
+	cdList := &hivev1.ClusterDeploymentList{}
+	require.NoError(t, handlers.k8sClient.List(context.Background(), cdList))
+	assert.Len(t, cdList.Items, 1)
+}
+
+func TestHandlers_ValidateResource_Claims(t *testing.T) {
+	handlers := createTestHandlers(t, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}})
+
+	result := validateResource(t, handlers, `{
+		"kind": "AccountClaim",
+		"metadata": {"name": "ac-1", "namespace": "default"},
+		"spec": {"awsCredentialSecret": {"name": "aws-creds", "namespace": "default"}}
+	}`)
+	assert.True(t, result.Valid, result.Issues)
+
+	result = validateResource(t, handlers, `{"kind": "ProjectClaim", "metadata": {"namespace": "default"}}`)
+	assert.False(t, result.Valid)
+	require.Len(t, result.Issues, 2)
+	assert.Equal(t, "metadata.name", result.Issues[0].Field)
+	assert.Equal(t, "spec.gcpCredentialSecret.name", result.Issues[1].Field)
+}
+
+func TestHandlers_ValidateResource_Invalid(t *testing.T) {
+	handlers := createTestHandlers(t)
+
+	rec := doRequestWithBody(handlers, http.MethodPost, "/api/v1/resources/validate", `not json`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequestWithBody(handlers, http.MethodPost, "/api/v1/resources/validate", `{"kind": "Pod"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequestWithBody(handlers, http.MethodPost, "/api/v1/resources/validate", `{"kind": "ClusterDeployment", "spec": "invalid"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
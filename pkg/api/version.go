@@ -0,0 +1,74 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// configSchemaVersion is the version of the configuration format the simulator expects. It is
+// bumped whenever a change to the format would make an older configuration be misinterpreted.
+const configSchemaVersion = "v1"
+
+// VersionInfo describes the versions of the running simulator
+type VersionInfo struct {
+	ConfigSchemaVersion string `json:"configSchemaVersion"`
+}
+
+// configVersion holds the schema version a posted configuration declares, under either field
+type configVersion struct {
+	APIVersion    string `json:"apiVersion"`
+	SchemaVersion string `json:"schemaVersion"`
+}
+
+// GetVersion returns the configuration schema version the simulator expects
+func (h *Handlers) GetVersion(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.logger.Debug(ctx, "GET /api/v1/version")
+
+	h.writeJSON(w, http.StatusOK, VersionInfo{ConfigSchemaVersion: configSchemaVersion})
+}
+
+// decodeConfig decodes a posted configuration into cfg after checking the schema version it
+// declares in apiVersion or schemaVersion. A mismatched version is rejected with a 400, a
+// configuration without a version is accepted with a warning. Returns false if an error
+// response was written.
+func (h *Handlers) decodeConfig(w http.ResponseWriter, r *http.Request, cfg interface{}) bool {
+	ctx := r.Context()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Failed to read request body: %v", err))
+		return false
+	}
+
+	var declared configVersion
+	if err := json.Unmarshal(body, &declared); err != nil {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return false
+	}
+	fields := []struct{ name, version string }{
+		{"apiVersion", declared.APIVersion},
+		{"schemaVersion", declared.SchemaVersion},
+	}
+	for _, field := range fields {
+		if field.version != "" && field.version != configSchemaVersion {
+			h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Unsupported configuration %s %q, expected %q",
+				field.name, field.version, configSchemaVersion))
+			return false
+		}
+	}
+	if declared.APIVersion == "" && declared.SchemaVersion == "" {
+		message := fmt.Sprintf("configuration has no apiVersion or schemaVersion, assuming %s", configSchemaVersion)
+		h.logger.Warn(ctx, "POST %s: %s", r.URL.Path, message)
+		w.Header().Add("Warning", fmt.Sprintf("299 - %q", message))
+	}
+
+	if err := json.NewDecoder(bytes.NewReader(body)).Decode(cfg); err != nil {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return false
+	}
+	return true
+}
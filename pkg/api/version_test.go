@@ -0,0 +1,61 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlers_GetVersion(t *testing.T) {
+	handlers := createTestHandlers(t)
+
+	rec := doRequest(handlers, http.MethodGet, "/api/v1/version")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var info VersionInfo
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &info))
+	assert.Equal(t, configSchemaVersion, info.ConfigSchemaVersion)
+}
+
+func TestHandlers_UpdateConfig_MatchingVersion(t *testing.T) {
+	handlers := createTestHandlers(t)
+
+	rec := doRequestWithBody(handlers, http.MethodPost, "/api/v1/config/clusterdeployment",
+		`{"apiVersion": "v1", "defaultDelaySeconds": 42, "states": [{"name": "Pending", "durationSeconds": 1}]}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("Warning"))
+	assert.Equal(t, 42, handlers.behaviorEngine.GetClusterDeploymentConfig().DefaultDelaySeconds)
+
+	rec = doRequestWithBody(handlers, http.MethodPost, "/api/v1/config/accountclaim",
+		`{"schemaVersion": "v1", "defaultDelaySeconds": 7}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 7, handlers.behaviorEngine.GetAccountClaimConfig().DefaultDelaySeconds)
+}
+
+func TestHandlers_UpdateConfig_MismatchedVersion(t *testing.T) {
+	handlers := createTestHandlers(t)
+	before := handlers.behaviorEngine.GetProjectClaimConfig().DefaultDelaySeconds
+
+	rec := doRequestWithBody(handlers, http.MethodPost, "/api/v1/config/projectclaim",
+		`{"schemaVersion": "v2", "defaultDelaySeconds": 99}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), `Unsupported configuration schemaVersion \"v2\", expected \"v1\"`)
+	assert.Equal(t, before, handlers.behaviorEngine.GetProjectClaimConfig().DefaultDelaySeconds)
+
+	rec = doRequestWithBody(handlers, http.MethodPost, "/api/v1/config/clusterdeployment",
+		`{"apiVersion": "v0", "defaultDelaySeconds": 99}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "apiVersion")
+}
+
+func TestHandlers_UpdateConfig_AbsentVersion(t *testing.T) {
+	handlers := createTestHandlers(t)
+
+	rec := doRequestWithBody(handlers, http.MethodPost, "/api/v1/config/accountclaim", `{"defaultDelaySeconds": 5}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Warning"), "configuration has no apiVersion or schemaVersion")
+	assert.Equal(t, 5, handlers.behaviorEngine.GetAccountClaimConfig().DefaultDelaySeconds)
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/notify"
+)
+
+// SetNotifier sets the notifier the webhooks registered through the API are added to
+func (h *Handlers) SetNotifier(notifier *notify.Notifier) {
+	h.notifier = notifier
+}
+
+// RegisterWebhook registers a callback URL that is posted an event whenever a resource of
+// the type enters the terminal state
+func (h *Handlers) RegisterWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.logger.Debug(ctx, "POST /api/v1/webhooks")
+
+	if h.notifier == nil {
+		h.writeError(w, http.StatusServiceUnavailable, "Webhooks are not available")
+		return
+	}
+
+	var webhook notify.Webhook
+	if err := json.NewDecoder(r.Body).Decode(&webhook); err != nil {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+		return
+	}
+	callback, err := url.Parse(webhook.URL)
+	if err != nil || (callback.Scheme != "http" && callback.Scheme != "https") || callback.Host == "" {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid webhook URL %q: must be an absolute http or https URL", webhook.URL))
+		return
+	}
+	if _, ok := resourceKinds[strings.ToLower(webhook.ResourceType)]; !ok {
+		h.writeError(w, http.StatusBadRequest, fmt.Sprintf("Unknown resource type: %s", webhook.ResourceType))
+		return
+	}
+	if webhook.TerminalState == "" {
+		h.writeError(w, http.StatusBadRequest, "terminalState is required")
+		return
+	}
+	webhook.ResourceType = strings.ToLower(webhook.ResourceType)
+
+	h.notifier.Register(webhook)
+	h.logger.Info(ctx, "Registered webhook %s for %s in state %s", webhook.URL, webhook.ResourceType, webhook.TerminalState)
+	h.writeJSON(w, http.StatusCreated, webhook)
+}
+
+// ListWebhooks returns the registered webhooks in the order they were registered
+func (h *Handlers) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.logger.Debug(ctx, "GET /api/v1/webhooks")
+
+	webhooks := []notify.Webhook{}
+	if h.notifier != nil {
+		webhooks = append(webhooks, h.notifier.Webhooks()...)
+	}
+	h.writeJSON(w, http.StatusOK, webhooks)
+}
+
+// ClearWebhooks removes every registered webhook
+func (h *Handlers) ClearWebhooks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	h.logger.Debug(ctx, "DELETE /api/v1/webhooks")
+
+	if h.notifier != nil {
+		h.notifier.Clear()
+	}
+	h.writeJSON(w, http.StatusOK, map[string]string{"status": "webhooks cleared"})
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/notify"
+)
+
+func TestHandlers_RegisterAndClearWebhooks(t *testing.T) {
+	handlers := createTestHandlers(t)
+	handlers.SetNotifier(notify.NewNotifier(createTestLogger()))
+
+	rec := doRequestWithBody(handlers, http.MethodPost, "/api/v1/webhooks",
+		`{"url": "http://localhost:9000/hook", "resourceType": "ClusterDeployment", "terminalState": "Running"}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	rec = doRequest(handlers, http.MethodGet, "/api/v1/webhooks")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var webhooks []notify.Webhook
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &webhooks))
+	assert.Equal(t, []notify.Webhook{{URL: "http://localhost:9000/hook", ResourceType: "clusterdeployment", TerminalState: "Running"}}, webhooks)
+
+	rec = doRequest(handlers, http.MethodDelete, "/api/v1/webhooks")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = doRequest(handlers, http.MethodGet, "/api/v1/webhooks")
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &webhooks))
+	assert.Empty(t, webhooks)
+}
+
+func TestHandlers_RegisterWebhook_Invalid(t *testing.T) {
+	handlers := createTestHandlers(t)
+	handlers.SetNotifier(notify.NewNotifier(createTestLogger()))
+
+	for _, body := range []string{
+		`{"url": "localhost/hook", "resourceType": "clusterdeployment", "terminalState": "Running"}`,
+		`{"url": "http://localhost/hook", "resourceType": "machinepool", "terminalState": "Running"}`,
+		`{"url": "http://localhost/hook", "resourceType": "clusterdeployment"}`,
+	} {
+		rec := doRequestWithBody(handlers, http.MethodPost, "/api/v1/webhooks", body)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+	}
+}
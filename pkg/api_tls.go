@@ -0,0 +1,112 @@
+package hive_simulator
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	errors "github.com/zgalor/weberr"
+)
+
+// Names of the self-signed certificate and key files, written next to the kubeconfig
+const (
+	selfSignedCertFile = "hive-simulator-api.crt"
+	selfSignedKeyFile  = "hive-simulator-api.key"
+)
+
+// selfSignedCertValidity is how long a generated self-signed certificate is valid
+const selfSignedCertValidity = 365 * 24 * time.Hour
+
+// ValidateAPITLS returns an error unless the configuration API TLS settings are consistent:
+// the certificate and key are given together, and not along with a self-signed certificate
+func ValidateAPITLS(certFile, keyFile string, selfSigned bool) error {
+	if (certFile == "") != (keyFile == "") {
+		return errors.Errorf("the TLS certificate and key must be set together, got certificate %q and key %q", certFile, keyFile)
+	}
+	if selfSigned && certFile != "" {
+		return errors.Errorf("a self-signed certificate cannot be used along with the TLS certificate %q", certFile)
+	}
+	return nil
+}
+
+// apiTLSEnabled returns true if the configuration API is served over HTTPS
+func (s *Server) apiTLSEnabled() bool {
+	return s.apiTLSCertFile != "" || s.apiTLSSelfSigned
+}
+
+// apiTLSFiles returns the certificate and key files the configuration API is served with,
+// generating a self-signed pair next to the kubeconfig when requested. Both are empty when
+// the API is served in plaintext.
+func (s *Server) apiTLSFiles(ctx context.Context) (string, string, error) {
+	if err := ValidateAPITLS(s.apiTLSCertFile, s.apiTLSKeyFile, s.apiTLSSelfSigned); err != nil {
+		return "", "", err
+	}
+	if !s.apiTLSSelfSigned {
+		return s.apiTLSCertFile, s.apiTLSKeyFile, nil
+	}
+
+	dir := filepath.Dir(s.kubeconfigPath)
+	certFile := filepath.Join(dir, selfSignedCertFile)
+	keyFile := filepath.Join(dir, selfSignedKeyFile)
+	if err := writeSelfSignedCert(certFile, keyFile, time.Now()); err != nil {
+		return "", "", errors.Wrapf(err, "failed to generate self-signed certificate")
+	}
+	s.logger.Info(ctx, "Generated self-signed certificate for the configuration API at %s", certFile)
+	return certFile, keyFile, nil
+}
+
+// writeSelfSignedCert writes a self-signed certificate for localhost and its private key,
+// in PEM format, to the given files
+func writeSelfSignedCert(certFile, keyFile string, now time.Time) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return errors.Wrapf(err, "failed to generate private key")
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return errors.Wrapf(err, "failed to generate serial number")
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "hive-simulator"},
+		NotBefore:             now.Add(-time.Minute),
+		NotAfter:              now.Add(selfSignedCertValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create certificate")
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal private key")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(certFile), 0o755); err != nil {
+		return errors.Wrapf(err, "failed to create certificate directory")
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if err := os.WriteFile(certFile, certPEM, 0o644); err != nil {
+		return errors.Wrapf(err, "failed to write certificate")
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+		return errors.Wrapf(err, "failed to write private key")
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package hive_simulator
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+)
+
+func TestValidateAPITLS(t *testing.T) {
+	require.NoError(t, ValidateAPITLS("", "", false))
+	require.NoError(t, ValidateAPITLS("tls.crt", "tls.key", false))
+	require.NoError(t, ValidateAPITLS("", "", true))
+
+	err := ValidateAPITLS("tls.crt", "", false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "must be set together")
+	require.Error(t, ValidateAPITLS("", "tls.key", false))
+	require.Error(t, ValidateAPITLS("tls.crt", "tls.key", true))
+}
+
+func TestServer_APITLSFiles_SelfSigned(t *testing.T) {
+	dir := t.TempDir()
+	server := NewServer(createTestLogger(), config.DefaultConfig(), ServerOptions{
+		KubeconfigPath:   filepath.Join(dir, "kubeconfig.yaml"),
+		APITLSSelfSigned: true,
+	})
+	assert.True(t, server.apiTLSEnabled())
+
+	certFile, keyFile, err := server.apiTLSFiles(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, selfSignedCertFile), certFile)
+	assert.Equal(t, filepath.Join(dir, selfSignedKeyFile), keyFile)
+
+	// The pair loads and the certificate is valid for localhost
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	require.NoError(t, err)
+	require.NoError(t, cert.VerifyHostname("localhost"))
+	require.NoError(t, cert.VerifyHostname("127.0.0.1"))
+	assert.True(t, cert.NotAfter.After(time.Now().Add(300*24*time.Hour)))
+}
+
+func TestServer_APITLSFiles_Plaintext(t *testing.T) {
+	server := NewServer(createTestLogger(), config.DefaultConfig(), ServerOptions{})
+	assert.False(t, server.apiTLSEnabled())
+
+	certFile, keyFile, err := server.apiTLSFiles(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, certFile)
+	assert.Empty(t, keyFile)
+
+	server = NewServer(createTestLogger(), config.DefaultConfig(), ServerOptions{APITLSKeyFile: "tls.key"})
+	_, _, err = server.apiTLSFiles(context.Background())
+	require.Error(t, err)
+}
@@ -0,0 +1,36 @@
+package behavior
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+)
+
+// accountLinkSuffixLength is the number of hex characters appended to the account name prefix
+const accountLinkSuffixLength = 8
+
+// AccountLink returns the name of the simulated account an AccountClaim links to in
+// spec.accountLink once Ready. With a random seed configured the name is derived from the seed
+// and the AccountClaim, so the same configuration yields the same names, otherwise it is random.
+func (e *Engine) AccountLink(namespace, name string) string {
+	e.mu.RLock()
+	seed := e.config.RandomSeed
+	var seedValue int64
+	if seed != nil {
+		seedValue = *seed
+	}
+	var account *config.AccountConfig
+	if e.config.AccountClaim != nil {
+		account = e.config.AccountClaim.Account
+	}
+	e.mu.RUnlock()
+
+	id := uuid.New()
+	if seed != nil {
+		id = uuid.NewSHA1(uuid.NameSpaceOID, []byte(fmt.Sprintf("%d/AccountClaim/%s/%s", seedValue, namespace, name)))
+	}
+	return account.GetAccountLinkPrefix() + strings.ReplaceAll(id.String(), "-", "")[:accountLinkSuffixLength]
+}
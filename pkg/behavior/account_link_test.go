@@ -0,0 +1,40 @@
+package behavior
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+)
+
+func TestEngine_AccountLink(t *testing.T) {
+	seed := int64(42)
+	cfg := createTestConfig()
+	cfg.RandomSeed = &seed
+	engine := NewEngine(createTestLogger(), cfg)
+	defer engine.Close()
+
+	// Seeded names only depend on the seed and the AccountClaim
+	link := engine.AccountLink("default", "test-claim")
+	assert.True(t, strings.HasPrefix(link, config.DefaultAccountLinkPrefix), link)
+	assert.Len(t, link, len(config.DefaultAccountLinkPrefix)+accountLinkSuffixLength)
+
+	other := NewEngine(createTestLogger(), cfg)
+	defer other.Close()
+	assert.Equal(t, link, other.AccountLink("default", "test-claim"))
+	assert.NotEqual(t, link, engine.AccountLink("default", "other-claim"))
+
+	// The prefix is configurable
+	cfg.AccountClaim.Account = &config.AccountConfig{AccountLinkPrefix: "sim-account-"}
+	prefixed := NewEngine(createTestLogger(), cfg)
+	defer prefixed.Close()
+	assert.True(t, strings.HasPrefix(prefixed.AccountLink("default", "test-claim"), "sim-account-"))
+
+	// Without a seed every name is random
+	cfg.RandomSeed = nil
+	unseeded := NewEngine(createTestLogger(), cfg)
+	defer unseeded.Close()
+	assert.NotEqual(t, unseeded.AccountLink("default", "test-claim"), unseeded.AccountLink("default", "test-claim"))
+}
@@ -0,0 +1,25 @@
+package behavior
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// ClusterID returns the ID a ClusterDeployment reports in spec.clusterMetadata once
+// installed. With a random seed configured the ID is derived from the seed and the
+// ClusterDeployment, so the same configuration yields the same IDs, otherwise it is random.
+func (e *Engine) ClusterID(namespace, name string) string {
+	e.mu.RLock()
+	seed := e.config.RandomSeed
+	var seedValue int64
+	if seed != nil {
+		seedValue = *seed
+	}
+	e.mu.RUnlock()
+
+	if seed == nil {
+		return uuid.NewString()
+	}
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(fmt.Sprintf("%d/%s/%s", seedValue, namespace, name))).String()
+}
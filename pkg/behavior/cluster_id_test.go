@@ -0,0 +1,41 @@
+package behavior
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_ClusterID(t *testing.T) {
+	seed := int64(42)
+	cfg := createTestConfig()
+	cfg.RandomSeed = &seed
+	engine := NewEngine(createTestLogger(), cfg)
+	defer engine.Close()
+
+	// Seeded IDs are valid UUIDs that only depend on the seed and the ClusterDeployment
+	id := engine.ClusterID("default", "test-cluster")
+	_, err := uuid.Parse(id)
+	require.NoError(t, err)
+
+	other := NewEngine(createTestLogger(), cfg)
+	defer other.Close()
+	assert.Equal(t, id, other.ClusterID("default", "test-cluster"))
+	assert.NotEqual(t, id, engine.ClusterID("default", "other-cluster"))
+
+	otherSeed := int64(7)
+	cfg.RandomSeed = &otherSeed
+	reseeded := NewEngine(createTestLogger(), cfg)
+	defer reseeded.Close()
+	assert.NotEqual(t, id, reseeded.ClusterID("default", "test-cluster"))
+
+	// Without a seed every ID is random
+	cfg.RandomSeed = nil
+	unseeded := NewEngine(createTestLogger(), cfg)
+	defer unseeded.Close()
+	_, err = uuid.Parse(unseeded.ClusterID("default", "test-cluster"))
+	require.NoError(t, err)
+	assert.NotEqual(t, unseeded.ClusterID("default", "test-cluster"), unseeded.ClusterID("default", "test-cluster"))
+}
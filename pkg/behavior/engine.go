@@ -4,40 +4,197 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"path"
+	"reflect"
+	"strings"
 	"sync"
 	"time"
 
+	k8slabels "k8s.io/apimachinery/pkg/labels"
+
 	"github.com/openshift-online/ocm-sdk-go/logging"
+	errors "github.com/zgalor/weberr"
 
 	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
 )
 
+// Wildcard matches any namespace or name in a resource override
+const Wildcard = "*"
+
+// overrideSweepInterval is how often expired overrides are removed
+const overrideSweepInterval = 5 * time.Second
+
+// selectorOverride is a SelectorOverride with its parsed label selector
+type selectorOverride struct {
+	override *config.SelectorOverride
+	selector k8slabels.Selector
+}
+
 // Engine manages behavior configuration and per-resource overrides
 type Engine struct {
-	logger    logging.Logger
-	config    *config.Config
-	overrides map[string]*config.ResourceOverride
-	mu        sync.RWMutex
-	rng       *rand.Rand
+	logger      logging.Logger
+	config      *config.Config
+	overrides   map[string]*config.ResourceOverride
+	selectors   []*selectorOverride
+	metrics     *engineMetrics
+	mu          sync.RWMutex
+	rngMu       sync.Mutex
+	rng         *rand.Rand
+	rolls       rollLog
+	retries     retryCounts
+	transitions transitionBroadcaster
+	paused      bool
+	done        chan struct{}
+	closeOnce   sync.Once
 }
 
-// NewEngine creates a new behavior engine
+// NewEngine creates a new behavior engine. Failure rolls are seeded with the configured
+// random seed when set, so that the same configuration yields the same rolls. Expired
+// overrides are swept in the background until the engine is closed.
 func NewEngine(logger logging.Logger, cfg *config.Config) *Engine {
-	return &Engine{
+	seed := time.Now().UTC().UnixNano()
+	if cfg.RandomSeed != nil {
+		seed = *cfg.RandomSeed
+	}
+	e := &Engine{
 		logger:    logger,
 		config:    cfg,
 		overrides: make(map[string]*config.ResourceOverride),
-		rng:       rand.New(rand.NewSource(time.Now().UTC().UnixNano())),
+		metrics:   newEngineMetrics(),
+		rng:       rand.New(rand.NewSource(seed)),
+		done:      make(chan struct{}),
+	}
+	go e.sweepExpiredOverrides()
+	return e
+}
+
+// Close stops the background sweep of expired overrides
+func (e *Engine) Close() {
+	e.closeOnce.Do(func() {
+		close(e.done)
+	})
+}
+
+// sweepExpiredOverrides periodically removes expired overrides until the engine is closed
+func (e *Engine) sweepExpiredOverrides() {
+	ticker := time.NewTicker(overrideSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-e.done:
+			return
+		case now := <-ticker.C:
+			e.RemoveExpiredOverrides(context.Background(), now)
+		}
+	}
+}
+
+// RemoveExpiredOverrides removes every override that has expired by now, returning how many were removed
+func (e *Engine) RemoveExpiredOverrides(ctx context.Context, now time.Time) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	removed := 0
+	for key, override := range e.overrides {
+		if override.Expired(now) {
+			e.removeExpired(ctx, key)
+			removed++
+		}
 	}
+	return removed
 }
 
-// GetConfig returns the current configuration (thread-safe copy)
+// removeExpiredKeys removes the overrides of the given keys that have expired by now. It
+// only takes the write lock when there is an expired override to remove.
+func (e *Engine) removeExpiredKeys(ctx context.Context, keys []string, now time.Time) {
+	e.mu.RLock()
+	expired := false
+	for _, key := range keys {
+		if override, exists := e.overrides[key]; exists && override.Expired(now) {
+			expired = true
+			break
+		}
+	}
+	e.mu.RUnlock()
+	if !expired {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, key := range keys {
+		// Checked again, the override may have been replaced since
+		if override, exists := e.overrides[key]; exists && override.Expired(now) {
+			e.removeExpired(ctx, key)
+		}
+	}
+}
+
+// removeExpired removes an expired override and forgets the transient failures of the
+// resources it matched, the caller must hold the write lock
+func (e *Engine) removeExpired(ctx context.Context, key string) {
+	e.logger.Info(ctx, "Override %s expired", key)
+	delete(e.overrides, key)
+	e.retries.clearMatching(strings.Split(key, "/"))
+	e.metrics.overrideCleared(keyResourceType(key))
+}
+
+// GetConfig returns a deep copy of the current configuration, so that callers can neither
+// modify it nor race with updates
 func (e *Engine) GetConfig() *config.Config {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
 
-	// Return a copy to prevent external modifications
-	return e.config
+	return e.config.DeepCopy()
+}
+
+// UpdateConfig replaces the configuration of the simulated resources with the one of cfg,
+// keeping the overrides: the clusterDeployment, accountClaim and projectClaim sections, and
+// the syncSet, dnsZone, clusterPool and fleetRamp sections. The latter enable controllers
+// that are set up at startup, so they can be changed but not added or removed, the current
+// section is kept then. The other settings only take effect at startup and are kept as well.
+// It returns the names of the sections that changed.
+func (e *Engine) UpdateConfig(ctx context.Context, cfg *config.Config) []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var changed []string
+	update := func(name string, current, next interface{}, set func()) {
+		if reflect.DeepEqual(current, next) {
+			return
+		}
+		e.logger.Info(ctx, "Updating %s configuration", name)
+		set()
+		changed = append(changed, name)
+	}
+	update("clusterDeployment", e.config.ClusterDeployment, cfg.ClusterDeployment, func() { e.config.ClusterDeployment = cfg.ClusterDeployment })
+	update("accountClaim", e.config.AccountClaim, cfg.AccountClaim, func() { e.config.AccountClaim = cfg.AccountClaim })
+	update("projectClaim", e.config.ProjectClaim, cfg.ProjectClaim, func() { e.config.ProjectClaim = cfg.ProjectClaim })
+
+	next := *cfg
+	e.keepControllerSections(ctx, &next)
+	update("syncSet", e.config.SyncSet, next.SyncSet, func() { e.config.SyncSet = next.SyncSet })
+	update("dnsZone", e.config.DNSZone, next.DNSZone, func() { e.config.DNSZone = next.DNSZone })
+	update("clusterPool", e.config.ClusterPool, next.ClusterPool, func() { e.config.ClusterPool = next.ClusterPool })
+	update("fleetRamp", e.config.FleetRamp, next.FleetRamp, func() { e.config.FleetRamp = next.FleetRamp })
+	return changed
+}
+
+// keepControllerSections sets the syncSet, dnsZone, clusterPool and fleetRamp sections of cfg
+// back to the current ones where cfg would add or remove one, as their controllers are only
+// registered at startup. The caller must hold the write lock.
+func (e *Engine) keepControllerSections(ctx context.Context, cfg *config.Config) {
+	keep := func(name string, currentSet, nextSet bool, restore func()) {
+		if currentSet != nextSet {
+			e.logger.Warn(ctx, "Keeping the %s configuration, adding or removing it only takes effect on restart", name)
+			restore()
+		}
+	}
+	keep("syncSet", e.config.SyncSet != nil, cfg.SyncSet != nil, func() { cfg.SyncSet = e.config.SyncSet })
+	keep("dnsZone", e.config.DNSZone != nil, cfg.DNSZone != nil, func() { cfg.DNSZone = e.config.DNSZone })
+	keep("clusterPool", e.config.ClusterPool != nil, cfg.ClusterPool != nil, func() { cfg.ClusterPool = e.config.ClusterPool })
+	keep("fleetRamp", e.config.FleetRamp != nil, cfg.FleetRamp != nil, func() { cfg.FleetRamp = e.config.FleetRamp })
 }
 
 // UpdateClusterDeploymentConfig updates ClusterDeployment configuration
@@ -67,81 +224,470 @@ func (e *Engine) UpdateProjectClaimConfig(ctx context.Context, cfg *config.Proje
 	e.config.ProjectClaim = cfg
 }
 
-// SetResourceOverride sets an override for a specific resource
+// SetStateDuration sets the duration of a single configured state of a resource type,
+// leaving the rest of its configuration unchanged. ClusterDeployment states are also looked
+// up in the agent and deprovision states, and updated in every list naming them. The
+// configuration is copied and the copy swapped in, as the state machines read the current
+// one without holding the lock. It returns false if the state is not configured.
+func (e *Engine) SetStateDuration(ctx context.Context, resourceType, stateName string, durationSeconds int) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var stateLists [][]config.StateConfig
+	var swap func()
+	switch resourceType {
+	case "ClusterDeployment":
+		if e.config.ClusterDeployment != nil {
+			cfg := e.config.ClusterDeployment.DeepCopy()
+			stateLists = [][]config.StateConfig{cfg.States, cfg.AgentStates, cfg.DeprovisionStates}
+			swap = func() { e.config.ClusterDeployment = cfg }
+		}
+	case "AccountClaim":
+		if e.config.AccountClaim != nil {
+			cfg := e.config.AccountClaim.DeepCopy()
+			stateLists = [][]config.StateConfig{cfg.States}
+			swap = func() { e.config.AccountClaim = cfg }
+		}
+	case "ProjectClaim":
+		if e.config.ProjectClaim != nil {
+			cfg := e.config.ProjectClaim.DeepCopy()
+			stateLists = [][]config.StateConfig{cfg.States}
+			swap = func() { e.config.ProjectClaim = cfg }
+		}
+	}
+
+	found := false
+	for _, states := range stateLists {
+		for i := range states {
+			if states[i].Name == stateName {
+				e.logger.Info(ctx, "Updating %s state %s duration: %ds -> %ds",
+					resourceType, stateName, states[i].DurationSeconds, durationSeconds)
+				states[i].DurationSeconds = durationSeconds
+				found = true
+			}
+		}
+	}
+	if found {
+		swap()
+	}
+	return found
+}
+
+// SetResourceOverride sets an override for a specific resource. A name of Wildcard applies
+// the override to all resources of the type in the namespace, and a namespace of Wildcard
+// to all namespaces. More specific overrides take precedence.
 func (e *Engine) SetResourceOverride(ctx context.Context, resourceType, namespace, name string, override *config.ResourceOverride) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
+	e.setOverride(ctx, resourceType, namespace, name, override)
+}
+
+// ResourceOverrideEntry is the override of one resource in a batch
+type ResourceOverrideEntry struct {
+	ResourceType string                   `json:"resourceType"`
+	Namespace    string                   `json:"namespace"`
+	Name         string                   `json:"name"`
+	Override     *config.ResourceOverride `json:"override"`
+}
+
+// SetResourceOverrides sets the overrides of a batch of resources at once, no reconcile sees
+// only part of the batch applied
+func (e *Engine) SetResourceOverrides(ctx context.Context, entries []ResourceOverrideEntry) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, entry := range entries {
+		e.setOverride(ctx, entry.ResourceType, entry.Namespace, entry.Name, entry.Override)
+	}
+}
+
+// setOverride sets the override of a resource, the caller must hold the write lock
+func (e *Engine) setOverride(ctx context.Context, resourceType, namespace, name string, override *config.ResourceOverride) {
 	key := e.makeKey(resourceType, namespace, name)
 	e.logger.Info(ctx, "Setting override for %s: %s", resourceType, key)
+	_, replaced := e.overrides[key]
 	e.overrides[key] = override
+	e.metrics.overrideSet(resourceType, replaced)
 }
 
-// ClearResourceOverride clears an override for a specific resource
-func (e *Engine) ClearResourceOverride(ctx context.Context, resourceType, namespace, name string) {
+// AddSelectorOverride registers an override for all resources of a type whose labels match
+// the selector. Selector overrides are evaluated in registration order, after the overrides
+// of a specific resource and before wildcard overrides.
+func (e *Engine) AddSelectorOverride(ctx context.Context, override *config.SelectorOverride) error {
+	selector, err := k8slabels.Parse(override.LabelSelector)
+	if err != nil {
+		return errors.Wrapf(err, "invalid label selector %q", override.LabelSelector)
+	}
+	if override.Probability < 0 || override.Probability > 1 {
+		return errors.Errorf("probability must be between 0 and 1, got %v", override.Probability)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.logger.Info(ctx, "Adding selector override for %s: %s", override.ResourceType, selector)
+	e.selectors = append(e.selectors, &selectorOverride{override: override, selector: selector})
+	return nil
+}
+
+// ListSelectorOverrides returns a copy of the registered selector overrides, in evaluation order
+func (e *Engine) ListSelectorOverrides() []config.SelectorOverride {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	overrides := make([]config.SelectorOverride, 0, len(e.selectors))
+	for _, selector := range e.selectors {
+		copied := *selector.override
+		if copied.DelaySeconds != nil {
+			delay := *copied.DelaySeconds
+			copied.DelaySeconds = &delay
+		}
+		if copied.ForceFail != nil {
+			failure := *copied.ForceFail
+			copied.ForceFail = &failure
+		}
+		overrides = append(overrides, copied)
+	}
+	return overrides
+}
+
+// ClearSelectorOverrides clears all selector overrides
+func (e *Engine) ClearSelectorOverrides(ctx context.Context) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.logger.Info(ctx, "Clearing all selector overrides (%d total)", len(e.selectors))
+	e.selectors = nil
+}
+
+// ListOverrides returns a deep copy of the active overrides, keyed by resourceType/namespace/name.
+// Expired overrides not swept yet are left out.
+func (e *Engine) ListOverrides() map[string]*config.ResourceOverride {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	now := time.Now()
+	overrides := make(map[string]*config.ResourceOverride, len(e.overrides))
+	for key, override := range e.overrides {
+		if override.Expired(now) {
+			continue
+		}
+		copied := *override
+		if override.DelaySeconds != nil {
+			delay := *override.DelaySeconds
+			copied.DelaySeconds = &delay
+		}
+		if override.ForceFail != nil {
+			failure := *override.ForceFail
+			copied.ForceFail = &failure
+		}
+		if override.ExpiresAt != nil {
+			expiresAt := *override.ExpiresAt
+			copied.ExpiresAt = &expiresAt
+		}
+		overrides[key] = &copied
+	}
+	return overrides
+}
+
+// SetPaused pauses or resumes the progression of a specific resource, keeping the rest of its
+// override. Resuming removes an override that only paused the resource. It returns false when
+// resuming a resource that is not paused.
+func (e *Engine) SetPaused(ctx context.Context, resourceType, namespace, name string, paused bool) bool {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
 	key := e.makeKey(resourceType, namespace, name)
+	existing, exists := e.overrides[key]
+	if exists && existing.Expired(time.Now()) {
+		e.removeExpired(ctx, key)
+		exists = false
+	}
+
+	if !paused {
+		if !exists || !existing.Paused {
+			return false
+		}
+		if existing.DelaySeconds == nil && existing.ForceFail == nil && !existing.ForceSuccess {
+			e.logger.Info(ctx, "Resuming %s", key)
+			delete(e.overrides, key)
+			e.metrics.overrideCleared(resourceType)
+			return true
+		}
+	}
+
+	override := &config.ResourceOverride{ResourceName: name}
+	if exists {
+		copied := *existing
+		override = &copied
+	}
+	override.Paused = paused
+	if paused {
+		e.logger.Info(ctx, "Pausing %s", key)
+	} else {
+		e.logger.Info(ctx, "Resuming %s", key)
+	}
+	e.overrides[key] = override
+	e.metrics.overrideSet(resourceType, exists)
+	return true
+}
+
+// IsPaused returns true if the progression of a resource is paused by its own override or a
+// wildcard one
+func (e *Engine) IsPaused(resourceType, namespace, name string) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	now := time.Now()
+	for _, overrideKey := range e.overrideKeys(resourceType, namespace, name) {
+		if override, exists := e.overrides[overrideKey]; exists && override.Paused && !override.Expired(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetReconcilePaused pauses or resumes the progression of every resource, e.g. to create a
+// batch of resources that then progress together. It returns false if nothing changed.
+func (e *Engine) SetReconcilePaused(ctx context.Context, paused bool) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.paused == paused {
+		return false
+	}
+	if paused {
+		e.logger.Info(ctx, "Pausing reconciliation of all resources")
+	} else {
+		e.logger.Info(ctx, "Resuming reconciliation of all resources")
+	}
+	e.paused = paused
+	return true
+}
+
+// IsReconcilePaused returns true if the progression of every resource is paused
+func (e *Engine) IsReconcilePaused() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.paused
+}
+
+// ClearResourceOverride clears an override for a specific resource, and forgets the
+// transient failures it had
+func (e *Engine) ClearResourceOverride(ctx context.Context, resourceType, namespace, name string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.clearOverride(ctx, e.makeKey(resourceType, namespace, name))
+}
+
+// ClearResourceOverrides clears the overrides of a batch of type/namespace/name keys at once,
+// and forgets the transient failures of the resources. It returns whether each key had an
+// override, in the order of the keys.
+func (e *Engine) ClearResourceOverrides(ctx context.Context, keys []string) []bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	cleared := make([]bool, len(keys))
+	for i, key := range keys {
+		cleared[i] = e.clearOverride(ctx, key)
+	}
+	return cleared
+}
+
+// clearOverride clears the override of a key and forgets the transient failures of the
+// resource, returning false if it had no override. The caller must hold the write lock.
+func (e *Engine) clearOverride(ctx context.Context, key string) bool {
+	resourceType := keyResourceType(key)
 	e.logger.Info(ctx, "Clearing override for %s: %s", resourceType, key)
+	e.retries.clear(key)
+	if _, exists := e.overrides[key]; !exists {
+		return false
+	}
 	delete(e.overrides, key)
+	e.metrics.overrideCleared(resourceType)
+	return true
 }
 
-// ClearAllOverrides clears all resource overrides
+// ClearAllOverrides clears all resource and selector overrides, and forgets the transient
+// failures of every resource
 func (e *Engine) ClearAllOverrides(ctx context.Context) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
-	e.logger.Info(ctx, "Clearing all resource overrides (%d total)", len(e.overrides))
+	e.logger.Info(ctx, "Clearing all resource overrides (%d total) and selector overrides (%d total)",
+		len(e.overrides), len(e.selectors))
+	for key := range e.overrides {
+		e.metrics.overrideCleared(keyResourceType(key))
+	}
 	e.overrides = make(map[string]*config.ResourceOverride)
+	e.selectors = nil
+	e.retries.clearAll()
 }
 
-// ShouldFail determines if a resource should fail based on configuration and overrides
-func (e *Engine) ShouldFail(ctx context.Context, resourceType, namespace, name string) (bool, *config.FailureScenario) {
-	e.mu.RLock()
-	defer e.mu.RUnlock()
+// ImportState replaces the configuration and the per-resource overrides, keyed by
+// resourceType/namespace/name, in one step. Transient failures already counted are forgotten,
+// selector overrides are kept. A syncSet, dnsZone, clusterPool or fleetRamp section cannot be
+// added or removed, like with UpdateConfig.
+func (e *Engine) ImportState(ctx context.Context, cfg *config.Config, overrides map[string]*config.ResourceOverride) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
 
-	key := e.makeKey(resourceType, namespace, name)
+	e.logger.Info(ctx, "Importing configuration and %d overrides, replacing %d overrides",
+		len(overrides), len(e.overrides))
+	for key := range e.overrides {
+		e.metrics.overrideCleared(keyResourceType(key))
+	}
+	e.keepControllerSections(ctx, cfg)
+	e.config = cfg
+	e.overrides = make(map[string]*config.ResourceOverride, len(overrides))
+	for key, override := range overrides {
+		e.overrides[key] = override
+		e.metrics.overrideSet(keyResourceType(key), false)
+	}
+	e.retries.clearAll()
+}
 
-	// Check for resource-specific override
-	if override, exists := e.overrides[key]; exists {
-		// If ForceSuccess is set, never fail
-		if override.ForceSuccess {
-			e.logger.Debug(ctx, "Resource %s has ForceSuccess=true, skipping failure", key)
-			return false, nil
+// ClearOverridesMatching clears every override whose type/namespace/name key matches the
+// given glob pattern, e.g. "ClusterDeployment/ci-*/*". Each segment is matched with path.Match.
+// Returns the number of overrides cleared.
+func (e *Engine) ClearOverridesMatching(ctx context.Context, pattern string) (int, error) {
+	patternSegments := strings.Split(pattern, "/")
+	if len(patternSegments) != 3 {
+		return 0, errors.Errorf("pattern %q must have the form type/namespace/name", pattern)
+	}
+	// Validate each segment up front so a bad pattern fails even with no overrides set
+	for _, segment := range patternSegments {
+		if _, err := path.Match(segment, ""); err != nil {
+			return 0, errors.Wrapf(err, "invalid pattern %q", pattern)
 		}
+	}
 
-		// If ForceFail is set, always fail
-		if override.ForceFail != nil {
-			e.logger.Info(ctx, "Resource %s has forced failure: %s", key, override.ForceFail.Message)
-			return true, override.ForceFail
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	cleared := 0
+	for key := range e.overrides {
+		if matchKey(patternSegments, key) {
+			delete(e.overrides, key)
+			e.metrics.overrideCleared(keyResourceType(key))
+			cleared++
 		}
 	}
 
-	// Check probabilistic failures from configuration
+	e.retries.clearMatching(patternSegments)
+
+	e.logger.Info(ctx, "Cleared %d overrides matching %s", cleared, pattern)
+	return cleared, nil
+}
+
+// matchKey returns true if every segment of the override key matches the corresponding pattern segment
+func matchKey(patternSegments []string, key string) bool {
+	keySegments := strings.SplitN(key, "/", 3)
+	if len(keySegments) != len(patternSegments) {
+		return false
+	}
+	for i := range patternSegments {
+		if matched, _ := path.Match(patternSegments[i], keySegments[i]); !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// ShouldFail determines if a resource should fail based on configuration and overrides.
+// The labels of the resource are matched against the selector overrides, and failures
+// restricted to a state only trigger while the resource is in that state. Without an
+// override that forces an outcome, the default failure of the resource type is rolled
+// before its failure scenarios.
+func (e *Engine) ShouldFail(ctx context.Context, resourceType, namespace, name, state string, resourceLabels map[string]string) (bool, *config.FailureScenario) {
+	now := time.Now()
+	key := e.makeKey(resourceType, namespace, name)
+	overrideKeys := e.overrideKeys(resourceType, namespace, name)
+
+	// Expired overrides are ignored, and removed once the read lock is released
+	defer e.removeExpiredKeys(ctx, overrideKeys, now)
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	// Check for resource-specific, selector or wildcard override, the most specific one that forces an outcome wins
+	if failed, failure, forced := e.forcedOutcome(ctx, key, state, overrideKeys[:1], now); forced {
+		if failed {
+			e.metrics.failureForced(resourceType)
+		}
+		return failed, failure
+	}
+	for _, selector := range e.matchingSelectors(resourceType, resourceLabels) {
+		failure := selector.override.ForceFail
+		if failure == nil || !failure.AppliesAt(state) || e.retriesExhausted(key, failure) {
+			continue
+		}
+		if probability := selector.override.Probability; probability > 0 {
+			roll := e.nextRoll()
+			e.rolls.add(Roll{
+				Time:      time.Now().UTC(),
+				Resource:  key,
+				Scenario:  failure.Reason,
+				Value:     roll,
+				Threshold: probability,
+				Failed:    roll < probability,
+			})
+			if roll >= probability {
+				e.logger.Debug(ctx, "Resource %s passed selector failure check (%s, %.2f >= %.2f)",
+					key, selector.selector, roll, probability)
+				continue
+			}
+		}
+		e.logger.Info(ctx, "Resource %s has selector failure (%s): %s", key, selector.selector, failure.Message)
+		e.metrics.failureForced(resourceType)
+		e.recordRetry(ctx, key, failure)
+		return true, failure
+	}
+	if failed, failure, forced := e.forcedOutcome(ctx, key, state, overrideKeys[1:], now); forced {
+		if failed {
+			e.metrics.failureForced(resourceType)
+		}
+		return failed, failure
+	}
+
+	// Check probabilistic failures from configuration, the default failure of the resource type first
 	var scenarios []config.FailureScenario
+	if defaults := e.defaults(resourceType); defaults != nil && defaults.Failure != nil {
+		scenarios = append(scenarios, *defaults.Failure)
+	}
 	switch resourceType {
 	case "ClusterDeployment":
 		if e.config.ClusterDeployment != nil {
-			scenarios = e.config.ClusterDeployment.FailureScenarios
+			scenarios = append(scenarios, e.config.ClusterDeployment.FailureScenarios...)
 		}
 	case "AccountClaim":
 		if e.config.AccountClaim != nil {
-			scenarios = e.config.AccountClaim.FailureScenarios
+			scenarios = append(scenarios, e.config.AccountClaim.FailureScenarios...)
 		}
 	case "ProjectClaim":
 		if e.config.ProjectClaim != nil {
-			scenarios = e.config.ProjectClaim.FailureScenarios
+			scenarios = append(scenarios, e.config.ProjectClaim.FailureScenarios...)
 		}
 	}
 
 	for i := range scenarios {
 		scenario := &scenarios[i]
-		if scenario.Probability > 0 {
-			roll := e.rng.Float64()
+		if scenario.Probability > 0 && scenario.AppliesAt(state) && !e.retriesExhausted(key, scenario) {
+			roll := e.nextRoll()
+			e.rolls.add(Roll{
+				Time:      time.Now().UTC(),
+				Resource:  key,
+				Scenario:  scenario.Reason,
+				Value:     roll,
+				Threshold: scenario.Probability,
+				Failed:    roll < scenario.Probability,
+			})
 			if roll < scenario.Probability {
 				e.logger.Info(ctx, "Resource %s failed probabilistic check (%.2f < %.2f): %s",
 					key, roll, scenario.Probability, scenario.Message)
+				e.recordRetry(ctx, key, scenario)
 				return true, scenario
 			}
 		}
@@ -150,23 +696,191 @@ func (e *Engine) ShouldFail(ctx context.Context, resourceType, namespace, name s
 	return false, nil
 }
 
-// GetTransitionDelay gets the transition delay for a resource
-func (e *Engine) GetTransitionDelay(ctx context.Context, resourceType, namespace, name string, defaultDuration time.Duration) time.Duration {
+// forcedOutcome returns the outcome forced by the first of the given overrides that forces
+// one in the state, and whether any does
+func (e *Engine) forcedOutcome(ctx context.Context, key, state string, overrideKeys []string, now time.Time) (bool, *config.FailureScenario, bool) {
+	for _, overrideKey := range overrideKeys {
+		override, exists := e.overrides[overrideKey]
+		if !exists || override.Expired(now) {
+			continue
+		}
+
+		// If ForceSuccess is set, never fail
+		if override.ForceSuccess {
+			e.logger.Debug(ctx, "Resource %s has ForceSuccess=true (%s), skipping failure", key, overrideKey)
+			return false, nil, true
+		}
+
+		// If ForceFail is set, fail once in the state it applies at
+		if override.ForceFail != nil && !override.FailsAt(state) {
+			e.logger.Debug(ctx, "Resource %s forced failure (%s) does not apply in state %s", key, overrideKey, state)
+			continue
+		}
+		if override.ForceFail != nil && e.retriesExhausted(key, override.ForceFail) {
+			e.logger.Debug(ctx, "Resource %s forced failure (%s) has no retries left", key, overrideKey)
+			continue
+		}
+		if override.ForceFail != nil {
+			e.logger.Info(ctx, "Resource %s has forced failure (%s): %s", key, overrideKey, override.ForceFail.Message)
+			e.recordRetry(ctx, key, override.ForceFail)
+			return true, override.ForceFail, true
+		}
+	}
+	return false, nil, false
+}
+
+// retriesExhausted returns true if the failure is transient and has already fired for the
+// resource as many times as it retries before success
+func (e *Engine) retriesExhausted(key string, failure *config.FailureScenario) bool {
+	return failure.RetriesBeforeSuccess > 0 && e.retries.exhausted(key, failure.RetriesBeforeSuccess)
+}
+
+// recordRetry counts a firing of the failure for the resource, if it is transient
+func (e *Engine) recordRetry(ctx context.Context, key string, failure *config.FailureScenario) {
+	if failure.RetriesBeforeSuccess <= 0 {
+		return
+	}
+	count := e.retries.record(key)
+	e.logger.Info(ctx, "Resource %s transient failure %d of %d", key, count, failure.RetriesBeforeSuccess)
+}
+
+// nextRoll returns the next value of the seeded random sequence, shared by failure rolls and
+// delay jitter. The random source is not safe for concurrent use, and ShouldFail and
+// GetTransitionDelay only hold the read lock.
+func (e *Engine) nextRoll() float64 {
+	e.rngMu.Lock()
+	defer e.rngMu.Unlock()
+	return e.rng.Float64()
+}
+
+// Roll returns the next value in [0, 1) of the seeded random sequence, for the probabilities
+// rolled outside the engine, such as those of state transitions
+func (e *Engine) Roll() float64 {
+	return e.nextRoll()
+}
+
+// Normal returns the next standard normally distributed value of the seeded random sequence,
+// for sampling state durations with a normal distribution
+func (e *Engine) Normal() float64 {
+	e.rngMu.Lock()
+	defer e.rngMu.Unlock()
+	return e.rng.NormFloat64()
+}
+
+// GetTransitionDelay gets the transition delay for a resource. The labels of the resource are
+// matched against the selector overrides. Without an override the configured delay is
+// multiplied by the default delay multiplier of the resource type and jittered, and the
+// delay is divided by the speed factor.
+func (e *Engine) GetTransitionDelay(ctx context.Context, resourceType, namespace, name string, resourceLabels map[string]string, defaultDuration time.Duration) time.Duration {
+	now := time.Now()
+	key := e.makeKey(resourceType, namespace, name)
+	overrideKeys := e.overrideKeys(resourceType, namespace, name)
+
+	// Expired overrides are ignored, and removed once the read lock is released
+	defer e.removeExpiredKeys(ctx, overrideKeys, now)
+
 	e.mu.RLock()
 	defer e.mu.RUnlock()
 
-	key := e.makeKey(resourceType, namespace, name)
+	// Check for resource-specific, selector or wildcard override, the most specific delay wins
+	if duration, ok := e.delayOverride(ctx, key, overrideKeys[:1], now); ok {
+		return e.accelerate(duration)
+	}
+	for _, selector := range e.matchingSelectors(resourceType, resourceLabels) {
+		if selector.override.DelaySeconds != nil {
+			duration := time.Duration(*selector.override.DelaySeconds) * time.Second
+			e.logger.Debug(ctx, "Resource %s has selector delay override (%s): %v", key, selector.selector, duration)
+			return e.accelerate(duration)
+		}
+	}
+	if duration, ok := e.delayOverride(ctx, key, overrideKeys[1:], now); ok {
+		return e.accelerate(duration)
+	}
 
-	// Check for resource-specific override
-	if override, exists := e.overrides[key]; exists {
-		if override.DelaySeconds != nil {
+	duration := time.Duration(float64(defaultDuration) * e.defaults(resourceType).GetDelayMultiplier())
+	return e.accelerate(e.jitter(resourceType, duration))
+}
+
+// defaults returns the defaults of the resource type, nil when none are configured. The
+// caller must hold the lock.
+func (e *Engine) defaults(resourceType string) *config.ResourceDefaults {
+	switch resourceType {
+	case "ClusterDeployment":
+		if e.config.ClusterDeployment != nil {
+			return e.config.ClusterDeployment.Defaults
+		}
+	case "AccountClaim":
+		if e.config.AccountClaim != nil {
+			return e.config.AccountClaim.Defaults
+		}
+	case "ProjectClaim":
+		if e.config.ProjectClaim != nil {
+			return e.config.ProjectClaim.Defaults
+		}
+	}
+	return nil
+}
+
+// jitter perturbs a configured delay by up to the jitter percentage of the resource type
+// either way. Override delays are explicit and never jittered. The caller must hold the lock.
+func (e *Engine) jitter(resourceType string, duration time.Duration) time.Duration {
+	var percent int
+	switch resourceType {
+	case "ClusterDeployment":
+		if e.config.ClusterDeployment != nil {
+			percent = e.config.ClusterDeployment.JitterPercent
+		}
+	case "AccountClaim":
+		if e.config.AccountClaim != nil {
+			percent = e.config.AccountClaim.JitterPercent
+		}
+	case "ProjectClaim":
+		if e.config.ProjectClaim != nil {
+			percent = e.config.ProjectClaim.JitterPercent
+		}
+	}
+	if percent <= 0 || duration <= 0 {
+		return duration
+	}
+	factor := 1 + float64(percent)/100*(2*e.nextRoll()-1)
+	return time.Duration(float64(duration) * factor)
+}
+
+// Accelerate divides a delay by the configured speed factor, for the delays that are timed
+// outside GetTransitionDelay such as power state changes, stuck recoveries and DNSZones
+func (e *Engine) Accelerate(duration time.Duration) time.Duration {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.accelerate(duration)
+}
+
+// accelerate divides the delay by the configured speed factor. The caller must hold the lock.
+func (e *Engine) accelerate(duration time.Duration) time.Duration {
+	return time.Duration(float64(duration) / e.config.GetSpeedFactor())
+}
+
+// delayOverride returns the delay of the first of the given overrides that sets one
+func (e *Engine) delayOverride(ctx context.Context, key string, overrideKeys []string, now time.Time) (time.Duration, bool) {
+	for _, overrideKey := range overrideKeys {
+		if override, exists := e.overrides[overrideKey]; exists && override.DelaySeconds != nil && !override.Expired(now) {
 			duration := time.Duration(*override.DelaySeconds) * time.Second
-			e.logger.Debug(ctx, "Resource %s has delay override: %v", key, duration)
-			return duration
+			e.logger.Debug(ctx, "Resource %s has delay override (%s): %v", key, overrideKey, duration)
+			return duration, true
 		}
 	}
+	return 0, false
+}
 
-	return defaultDuration
+// matchingSelectors returns the selector overrides of the resource type whose selector
+// matches the labels, in registration order
+func (e *Engine) matchingSelectors(resourceType string, resourceLabels map[string]string) []*selectorOverride {
+	var matching []*selectorOverride
+	for _, selector := range e.selectors {
+		if selector.override.ResourceType == resourceType && selector.selector.Matches(k8slabels.Set(resourceLabels)) {
+			matching = append(matching, selector)
+		}
+	}
+	return matching
 }
 
 // GetClusterDeploymentConfig returns the ClusterDeployment configuration
@@ -190,6 +904,34 @@ func (e *Engine) GetProjectClaimConfig() *config.ProjectClaimConfig {
 	return e.config.ProjectClaim
 }
 
+// GetSyncSetConfig returns the SyncSet simulation configuration, nil when it is disabled
+func (e *Engine) GetSyncSetConfig() *config.SyncSetConfig {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.config.SyncSet
+}
+
+// GetDNSZoneConfig returns the DNSZone simulation configuration, nil when it is disabled
+func (e *Engine) GetDNSZoneConfig() *config.DNSZoneConfig {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.config.DNSZone
+}
+
+// GetClusterPoolConfig returns the ClusterPool simulation configuration, nil when it is disabled
+func (e *Engine) GetClusterPoolConfig() *config.ClusterPoolConfig {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.config.ClusterPool
+}
+
+// GetFleetRampConfig returns the fleet ramp configuration, nil when it is disabled
+func (e *Engine) GetFleetRampConfig() *config.FleetRampConfig {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.config.FleetRamp
+}
+
 // GetClusterImageSetsConfig returns the ClusterImageSets configuration
 func (e *Engine) GetClusterImageSetsConfig() []config.ClusterImageSetConfig {
 	e.mu.RLock()
@@ -197,7 +939,77 @@ func (e *Engine) GetClusterImageSetsConfig() []config.ClusterImageSetConfig {
 	return e.config.ClusterImageSets
 }
 
+// GetEventVerbosity returns the configured event verbosity
+func (e *Engine) GetEventVerbosity() string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.config.GetEventVerbosity()
+}
+
+// GetSpeedFactor returns the factor transition delays are divided by
+func (e *Engine) GetSpeedFactor() float64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.config.GetSpeedFactor()
+}
+
+// SetSpeedFactor changes the factor transition delays are divided by, which must be > 0
+func (e *Engine) SetSpeedFactor(ctx context.Context, factor float64) error {
+	if factor <= 0 {
+		return errors.Errorf("speed factor must be > 0, got %v", factor)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.logger.Info(ctx, "Updating speed factor: %v -> %v", e.config.GetSpeedFactor(), factor)
+	e.config.SpeedFactor = factor
+	return nil
+}
+
+// GetRunID returns the run ID stamped on resources created by the simulator
+func (e *Engine) GetRunID() string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.config.RunID
+}
+
+// GetRolls returns the most recent probabilistic failure rolls, oldest first
+func (e *Engine) GetRolls() []Roll {
+	return e.rolls.list()
+}
+
+// ResolveNamespace returns the given namespace, or the configured default namespace if empty.
+// Endpoints that create resources, such as the scenario endpoints, use it for requests that
+// do not name a namespace.
+func (e *Engine) ResolveNamespace(namespace string) string {
+	if namespace != "" {
+		return namespace
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.config.GetDefaultNamespace()
+}
+
 // makeKey creates a unique key for a resource
 func (e *Engine) makeKey(resourceType, namespace, name string) string {
 	return fmt.Sprintf("%s/%s/%s", resourceType, namespace, name)
 }
+
+// keyResourceType returns the resource type of an override key
+func keyResourceType(key string) string {
+	resourceType, _, _ := strings.Cut(key, "/")
+	return resourceType
+}
+
+// overrideKeys returns the override keys that apply to a resource, most specific first: the
+// resource itself, all resources in its namespace, its name in any namespace and all resources
+// of its type
+func (e *Engine) overrideKeys(resourceType, namespace, name string) []string {
+	return []string{
+		e.makeKey(resourceType, namespace, name),
+		e.makeKey(resourceType, namespace, Wildcard),
+		e.makeKey(resourceType, Wildcard, name),
+		e.makeKey(resourceType, Wildcard, Wildcard),
+	}
+}
@@ -2,6 +2,8 @@ package behavior
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"testing"
 	"time"
 
@@ -65,6 +67,128 @@ func TestEngine_GetConfig(t *testing.T) {
 	assert.Equal(t, cfg.ClusterDeployment.DefaultDelaySeconds, retrievedConfig.ClusterDeployment.DefaultDelaySeconds)
 }
 
+func TestEngine_GetConfig_ReturnsCopy(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.ClusterDeployment.States = []config.StateConfig{{Name: "Running", DurationSeconds: 1}}
+	engine := NewEngine(createTestLogger(), cfg)
+
+	retrieved := engine.GetConfig()
+	retrieved.RunID = "changed"
+	retrieved.ClusterDeployment.DefaultDelaySeconds = 999
+	retrieved.ClusterDeployment.States[0].DurationSeconds = 999
+	retrieved.ClusterDeployment.FailureScenarios[0].Probability = 1
+	retrieved.AccountClaim = nil
+
+	internal := engine.GetConfig()
+	assert.Empty(t, internal.RunID)
+	assert.Equal(t, 5, internal.ClusterDeployment.DefaultDelaySeconds)
+	assert.Equal(t, 1, internal.ClusterDeployment.States[0].DurationSeconds)
+	assert.Equal(t, 0.5, internal.ClusterDeployment.FailureScenarios[0].Probability)
+	assert.NotNil(t, internal.AccountClaim)
+}
+
+func TestEngine_GetConfig_ConcurrentUpdates(t *testing.T) {
+	engine := NewEngine(createTestLogger(), createTestConfig())
+	ctx := context.Background()
+
+	// Meant to be run with -race: encoding the returned config must not race with updates
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			engine.UpdateClusterDeploymentConfig(ctx, &config.ClusterDeploymentConfig{
+				DefaultDelaySeconds: i,
+				States:              []config.StateConfig{{Name: "Running", DurationSeconds: i}},
+			})
+			engine.SetStateDuration(ctx, "ClusterDeployment", "Running", i+1)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		_, err := json.Marshal(engine.GetConfig())
+		require.NoError(t, err)
+	}
+	<-done
+}
+
+func TestEngine_SetStateDuration_SwapsCopy(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.ClusterDeployment.States = []config.StateConfig{{Name: "Pending", DurationSeconds: 1}, {Name: "Running"}}
+	engine := NewEngine(createTestLogger(), cfg)
+	defer engine.Close()
+
+	// A configuration read before the change is left as it was
+	before := engine.GetClusterDeploymentConfig()
+	require.True(t, engine.SetStateDuration(context.Background(), "ClusterDeployment", "Pending", 20))
+	assert.Equal(t, 1, before.States[0].DurationSeconds)
+	assert.Equal(t, 20, engine.GetClusterDeploymentConfig().States[0].DurationSeconds)
+
+	assert.False(t, engine.SetStateDuration(context.Background(), "ClusterDeployment", "Missing", 20))
+	assert.False(t, engine.SetStateDuration(context.Background(), "MachinePool", "Pending", 20))
+}
+
+func TestEngine_SetStateDuration_AgentAndDeprovisionStates(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.ClusterDeployment.AgentStates = []config.StateConfig{{Name: "AgentsDiscovering", DurationSeconds: 1}}
+	cfg.ClusterDeployment.DeprovisionStates = []config.StateConfig{{Name: "Deprovisioning", DurationSeconds: 2}}
+	engine := NewEngine(createTestLogger(), cfg)
+	defer engine.Close()
+	ctx := context.Background()
+
+	before := engine.GetClusterDeploymentConfig()
+	require.True(t, engine.SetStateDuration(ctx, "ClusterDeployment", "AgentsDiscovering", 30))
+	require.True(t, engine.SetStateDuration(ctx, "ClusterDeployment", "Deprovisioning", 40))
+	updated := engine.GetClusterDeploymentConfig()
+	assert.Equal(t, 30, updated.AgentStates[0].DurationSeconds)
+	assert.Equal(t, 40, updated.DeprovisionStates[0].DurationSeconds)
+	assert.Equal(t, 1, before.AgentStates[0].DurationSeconds)
+}
+
+func TestEngine_SetPaused(t *testing.T) {
+	engine := NewEngine(createTestLogger(), createTestConfig())
+	ctx := context.Background()
+
+	assert.False(t, engine.SetPaused(ctx, "ClusterDeployment", "ns1", "cluster1", false), "resuming a resource that is not paused")
+
+	// Pausing on its own adds an override that resuming removes
+	assert.True(t, engine.SetPaused(ctx, "ClusterDeployment", "ns1", "cluster1", true))
+	assert.True(t, engine.IsPaused("ClusterDeployment", "ns1", "cluster1"))
+	assert.False(t, engine.IsPaused("ClusterDeployment", "ns1", "cluster2"))
+	assert.True(t, engine.SetPaused(ctx, "ClusterDeployment", "ns1", "cluster1", false))
+	assert.False(t, engine.IsPaused("ClusterDeployment", "ns1", "cluster1"))
+	assert.Empty(t, engine.ListOverrides())
+
+	// Pausing keeps an existing override, and resuming leaves it in place
+	engine.SetResourceOverride(ctx, "ClusterDeployment", "ns1", "cluster1", &config.ResourceOverride{DelaySeconds: intPtr(10)})
+	assert.True(t, engine.SetPaused(ctx, "ClusterDeployment", "ns1", "cluster1", true))
+	assert.Equal(t, 10, *engine.ListOverrides()["ClusterDeployment/ns1/cluster1"].DelaySeconds)
+	assert.True(t, engine.SetPaused(ctx, "ClusterDeployment", "ns1", "cluster1", false))
+	override := engine.ListOverrides()["ClusterDeployment/ns1/cluster1"]
+	require.NotNil(t, override)
+	assert.False(t, override.Paused)
+	assert.Equal(t, 10, *override.DelaySeconds)
+
+	// Wildcard pauses apply to every matching resource
+	assert.True(t, engine.SetPaused(ctx, "ClusterDeployment", "ns2", Wildcard, true))
+	assert.True(t, engine.IsPaused("ClusterDeployment", "ns2", "cluster3"))
+	assert.False(t, engine.IsPaused("AccountClaim", "ns2", "cluster3"))
+}
+
+func TestEngine_SetReconcilePaused(t *testing.T) {
+	engine := NewEngine(createTestLogger(), createTestConfig())
+	ctx := context.Background()
+
+	assert.False(t, engine.IsReconcilePaused())
+	assert.True(t, engine.SetReconcilePaused(ctx, true))
+	assert.True(t, engine.IsReconcilePaused())
+	assert.False(t, engine.SetReconcilePaused(ctx, true))
+
+	// Pausing reconciliation does not pause resources individually
+	assert.False(t, engine.IsPaused("ClusterDeployment", "ns1", "cluster1"))
+
+	assert.True(t, engine.SetReconcilePaused(ctx, false))
+	assert.False(t, engine.IsReconcilePaused())
+}
+
 func TestEngine_UpdateConfigs(t *testing.T) {
 	logger := createTestLogger()
 	cfg := createTestConfig()
@@ -93,6 +217,49 @@ func TestEngine_UpdateConfigs(t *testing.T) {
 	assert.Equal(t, 9, engine.GetProjectClaimConfig().DefaultDelaySeconds)
 }
 
+func TestEngine_UpdateConfig(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.DNSZone = &config.DNSZoneConfig{DelaySeconds: 5}
+	cfg.DefaultNamespace = "startup"
+	engine := NewEngine(createTestLogger(), cfg)
+	defer engine.Close()
+	ctx := context.Background()
+
+	next := createTestConfig()
+	next.AccountClaim.DefaultDelaySeconds = 30
+	next.DNSZone = &config.DNSZoneConfig{DelaySeconds: 10}
+	next.SyncSet = &config.SyncSetConfig{DelaySeconds: 1}
+	next.DefaultNamespace = "reloaded"
+
+	changed := engine.UpdateConfig(ctx, next)
+	assert.Equal(t, []string{"accountClaim", "dnsZone"}, changed)
+	assert.Equal(t, 30, engine.GetAccountClaimConfig().DefaultDelaySeconds)
+	assert.Equal(t, 10, engine.GetDNSZoneConfig().DelaySeconds)
+
+	// Sections enabling controllers are neither added nor removed, startup settings are kept
+	assert.Nil(t, engine.GetSyncSetConfig())
+	assert.Equal(t, "startup", engine.GetConfig().DefaultNamespace)
+	next.DNSZone = nil
+	assert.Empty(t, engine.UpdateConfig(ctx, next))
+	assert.NotNil(t, engine.GetDNSZoneConfig())
+}
+
+func TestEngine_ImportState_KeepsControllerSections(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.ClusterPool = &config.ClusterPoolConfig{}
+	engine := NewEngine(createTestLogger(), cfg)
+	defer engine.Close()
+
+	imported := createTestConfig()
+	imported.ClusterDeployment.DefaultDelaySeconds = 42
+	imported.FleetRamp = &config.FleetRampConfig{}
+	engine.ImportState(context.Background(), imported, nil)
+
+	assert.Equal(t, 42, engine.GetClusterDeploymentConfig().DefaultDelaySeconds)
+	assert.NotNil(t, engine.GetClusterPoolConfig())
+	assert.Nil(t, engine.GetFleetRampConfig())
+}
+
 func TestEngine_ResourceOverrides(t *testing.T) {
 	logger := createTestLogger()
 	cfg := createTestConfig()
@@ -111,17 +278,56 @@ func TestEngine_ResourceOverrides(t *testing.T) {
 	engine.SetResourceOverride(ctx, resourceType, namespace, name, override)
 
 	// Verify override exists
-	delay := engine.GetTransitionDelay(ctx, resourceType, namespace, name, 5*time.Second)
+	delay := engine.GetTransitionDelay(ctx, resourceType, namespace, name, nil, 5*time.Second)
 	assert.Equal(t, 30*time.Second, delay)
 
 	// Clear override
 	engine.ClearResourceOverride(ctx, resourceType, namespace, name)
 
 	// Verify override cleared
-	delay = engine.GetTransitionDelay(ctx, resourceType, namespace, name, 5*time.Second)
+	delay = engine.GetTransitionDelay(ctx, resourceType, namespace, name, nil, 5*time.Second)
 	assert.Equal(t, 5*time.Second, delay)
 }
 
+func TestEngine_SetResourceOverrides_Atomic(t *testing.T) {
+	engine := NewEngine(createTestLogger(), createTestConfig())
+	ctx := context.Background()
+
+	const batchSize = 50
+	entries := make([]ResourceOverrideEntry, 0, batchSize)
+	for i := 0; i < batchSize; i++ {
+		name := fmt.Sprintf("cluster-%d", i)
+		entries = append(entries, ResourceOverrideEntry{
+			ResourceType: "ClusterDeployment",
+			Namespace:    "default",
+			Name:         name,
+			Override:     &config.ResourceOverride{ResourceName: name, DelaySeconds: intPtr(i)},
+		})
+	}
+
+	// Readers see either none or all of the batch, never part of it
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		engine.SetResourceOverrides(ctx, entries)
+	}()
+	for {
+		count := len(engine.ListOverrides())
+		require.True(t, count == 0 || count == batchSize, "saw %d of %d overrides", count, batchSize)
+		if count == batchSize {
+			break
+		}
+	}
+	<-done
+
+	delay := engine.GetTransitionDelay(ctx, "ClusterDeployment", "default", "cluster-7", nil, 5*time.Second)
+	assert.Equal(t, 7*time.Second, delay)
+
+	cleared := engine.ClearResourceOverrides(ctx, []string{"ClusterDeployment/default/cluster-7", "ClusterDeployment/default/missing"})
+	assert.Equal(t, []bool{true, false}, cleared)
+	assert.Len(t, engine.ListOverrides(), batchSize-1)
+}
+
 func TestEngine_ShouldFail_ForceSuccess(t *testing.T) {
 	logger := createTestLogger()
 	cfg := createTestConfig()
@@ -140,7 +346,7 @@ func TestEngine_ShouldFail_ForceSuccess(t *testing.T) {
 	engine.SetResourceOverride(ctx, resourceType, namespace, name, override)
 
 	// Should never fail
-	shouldFail, failure := engine.ShouldFail(ctx, resourceType, namespace, name)
+	shouldFail, failure := engine.ShouldFail(ctx, resourceType, namespace, name, "", nil)
 	assert.False(t, shouldFail)
 	assert.Nil(t, failure)
 }
@@ -168,13 +374,276 @@ func TestEngine_ShouldFail_ForceFail(t *testing.T) {
 	engine.SetResourceOverride(ctx, resourceType, namespace, name, override)
 
 	// Should always fail
-	shouldFail, failure := engine.ShouldFail(ctx, resourceType, namespace, name)
+	shouldFail, failure := engine.ShouldFail(ctx, resourceType, namespace, name, "", nil)
 	assert.True(t, shouldFail)
 	require.NotNil(t, failure)
 	assert.Equal(t, "ForcedFailure", failure.Condition)
 	assert.Equal(t, "This is a forced failure", failure.Message)
 }
 
+func TestEngine_ShouldFail_AtState(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.ClusterDeployment.FailureScenarios = []config.FailureScenario{
+		{Probability: 1, Condition: "ProvisionFailed", Reason: "InstallerCrashed", AtState: "Installing"},
+	}
+	engine := NewEngine(createTestLogger(), cfg)
+	ctx := context.Background()
+
+	// A configured failure only rolls in its state
+	shouldFail, _ := engine.ShouldFail(ctx, "ClusterDeployment", "default", "cluster1", "Provisioning", nil)
+	assert.False(t, shouldFail)
+	assert.Empty(t, engine.GetRolls())
+	shouldFail, failure := engine.ShouldFail(ctx, "ClusterDeployment", "default", "cluster1", "Installing", nil)
+	assert.True(t, shouldFail)
+	assert.Equal(t, "InstallerCrashed", failure.Reason)
+
+	// The state of an override takes precedence over the state of its failure, and an override
+	// that does not apply yet leaves the outcome to the less specific ones
+	engine.SetResourceOverride(ctx, "ClusterDeployment", "default", "cluster2", &config.ResourceOverride{
+		ForceFail: &config.FailureScenario{Condition: "ProvisionFailed", Reason: "Exact", AtState: "Installing"},
+		AtState:   "Provisioning",
+	})
+	engine.SetResourceOverride(ctx, "ClusterDeployment", "default", Wildcard, &config.ResourceOverride{
+		ForceFail: &config.FailureScenario{Condition: "ProvisionFailed", Reason: "Namespace", AtState: "Pending"},
+	})
+	reason := func(state string) string {
+		shouldFail, scenario := engine.ShouldFail(ctx, "ClusterDeployment", "default", "cluster2", state, nil)
+		if !shouldFail {
+			return ""
+		}
+		return scenario.Reason
+	}
+	assert.Equal(t, "Namespace", reason("Pending"))
+	assert.Equal(t, "Exact", reason("Provisioning"))
+	assert.Equal(t, "InstallerCrashed", reason("Installing"))
+	assert.Equal(t, "", reason("Running"))
+}
+
+func TestEngine_ShouldFail_RetriesBeforeSuccess(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.ClusterDeployment.FailureScenarios = nil
+	engine := NewEngine(createTestLogger(), cfg)
+	ctx := context.Background()
+
+	override := &config.ResourceOverride{
+		ForceFail: &config.FailureScenario{
+			Condition:            "ProvisionFailed",
+			Reason:               "Transient",
+			RetriesBeforeSuccess: 2,
+		},
+	}
+	engine.SetResourceOverride(ctx, "ClusterDeployment", "default", "test-cluster", override)
+
+	// The failure fires as many times as it retries and then lets the resource proceed
+	for i := 0; i < 2; i++ {
+		shouldFail, failure := engine.ShouldFail(ctx, "ClusterDeployment", "default", "test-cluster", "", nil)
+		assert.True(t, shouldFail, "retry %d", i)
+		require.NotNil(t, failure)
+		assert.Equal(t, "Transient", failure.Reason)
+	}
+	shouldFail, _ := engine.ShouldFail(ctx, "ClusterDeployment", "default", "test-cluster", "", nil)
+	assert.False(t, shouldFail)
+
+	// Clearing the override forgets the failures, so a new one retries from the start
+	engine.ClearResourceOverride(ctx, "ClusterDeployment", "default", "test-cluster")
+	engine.SetResourceOverride(ctx, "ClusterDeployment", "default", "test-cluster", override)
+	shouldFail, _ = engine.ShouldFail(ctx, "ClusterDeployment", "default", "test-cluster", "", nil)
+	assert.True(t, shouldFail)
+
+	engine.ClearAllOverrides(ctx)
+	engine.SetResourceOverride(ctx, "ClusterDeployment", "default", "test-cluster", override)
+	for i := 0; i < 2; i++ {
+		shouldFail, _ = engine.ShouldFail(ctx, "ClusterDeployment", "default", "test-cluster", "", nil)
+		assert.True(t, shouldFail, "retry %d after clearing all", i)
+	}
+	shouldFail, _ = engine.ShouldFail(ctx, "ClusterDeployment", "default", "test-cluster", "", nil)
+	assert.False(t, shouldFail)
+}
+
+func TestEngine_ExpiredOverrides_ForgetRetries(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.ClusterDeployment.FailureScenarios = nil
+	engine := NewEngine(createTestLogger(), cfg)
+	defer engine.Close()
+	ctx := context.Background()
+
+	expiresAt := time.Now().Add(time.Hour)
+	transient := func() *config.ResourceOverride {
+		return &config.ResourceOverride{
+			ForceFail: &config.FailureScenario{Condition: "ProvisionFailed", Reason: "Transient", RetriesBeforeSuccess: 1},
+			ExpiresAt: &expiresAt,
+		}
+	}
+	engine.SetResourceOverride(ctx, "ClusterDeployment", "default", "cluster1", transient())
+	engine.SetResourceOverride(ctx, "ClusterDeployment", "team-a", Wildcard, transient())
+	for _, resource := range [][2]string{{"default", "cluster1"}, {"team-a", "cluster2"}} {
+		shouldFail, _ := engine.ShouldFail(ctx, "ClusterDeployment", resource[0], resource[1], "", nil)
+		require.True(t, shouldFail)
+		shouldFail, _ = engine.ShouldFail(ctx, "ClusterDeployment", resource[0], resource[1], "", nil)
+		require.False(t, shouldFail)
+	}
+
+	// Once the overrides expire, new ones retry from the start
+	assert.Equal(t, 2, engine.RemoveExpiredOverrides(ctx, expiresAt.Add(time.Second)))
+	expiresAt = time.Now().Add(2 * time.Hour)
+	engine.SetResourceOverride(ctx, "ClusterDeployment", "default", "cluster1", transient())
+	engine.SetResourceOverride(ctx, "ClusterDeployment", "team-a", Wildcard, transient())
+	for _, resource := range [][2]string{{"default", "cluster1"}, {"team-a", "cluster2"}} {
+		shouldFail, _ := engine.ShouldFail(ctx, "ClusterDeployment", resource[0], resource[1], "", nil)
+		assert.True(t, shouldFail, "%s/%s", resource[0], resource[1])
+	}
+}
+
+func TestEngine_ShouldFail_RecordsRolls(t *testing.T) {
+	engine := NewEngine(createTestLogger(), createTestConfig())
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		shouldFail, _ := engine.ShouldFail(ctx, "ClusterDeployment", "default", "test-cluster", "", nil)
+
+		rolls := engine.GetRolls()
+		require.Len(t, rolls, i+1)
+		roll := rolls[i]
+		assert.Equal(t, "ClusterDeployment/default/test-cluster", roll.Resource)
+		assert.Equal(t, "TestReason", roll.Scenario)
+		assert.Equal(t, 0.5, roll.Threshold)
+		assert.GreaterOrEqual(t, roll.Value, 0.0)
+		assert.Less(t, roll.Value, 1.0)
+		assert.Equal(t, roll.Value < roll.Threshold, roll.Failed)
+		assert.Equal(t, shouldFail, roll.Failed)
+	}
+
+	// Resources without probabilistic scenarios do not roll
+	engine.ShouldFail(ctx, "AccountClaim", "default", "test-claim", "", nil)
+	assert.Len(t, engine.GetRolls(), 5)
+}
+
+func TestEngine_RollsAreBounded(t *testing.T) {
+	engine := NewEngine(createTestLogger(), createTestConfig())
+	ctx := context.Background()
+
+	for i := 0; i < maxRolls+10; i++ {
+		engine.ShouldFail(ctx, "ClusterDeployment", "default", fmt.Sprintf("cd-%d", i), "", nil)
+	}
+
+	rolls := engine.GetRolls()
+	require.Len(t, rolls, maxRolls)
+	assert.Equal(t, "ClusterDeployment/default/cd-10", rolls[0].Resource)
+	assert.Equal(t, fmt.Sprintf("ClusterDeployment/default/cd-%d", maxRolls+9), rolls[maxRolls-1].Resource)
+}
+
+func TestEngine_ShouldFail_RandomSeed(t *testing.T) {
+	ctx := context.Background()
+	outcomes := func(seed int64) []bool {
+		cfg := createTestConfig()
+		cfg.RandomSeed = &seed
+		engine := NewEngine(createTestLogger(), cfg)
+
+		var failed []bool
+		for i := 0; i < 50; i++ {
+			shouldFail, _ := engine.ShouldFail(ctx, "ClusterDeployment", "default", fmt.Sprintf("cd-%d", i), "", nil)
+			failed = append(failed, shouldFail)
+		}
+		return failed
+	}
+
+	first := outcomes(42)
+	assert.Equal(t, first, outcomes(42))
+	assert.Contains(t, first, true)
+	assert.Contains(t, first, false)
+	assert.NotEqual(t, first, outcomes(43))
+}
+
+func TestEngine_Normal_RandomSeed(t *testing.T) {
+	samples := func(seed int64) []float64 {
+		cfg := createTestConfig()
+		cfg.RandomSeed = &seed
+		engine := NewEngine(createTestLogger(), cfg)
+		defer engine.Close()
+
+		var values []float64
+		for i := 0; i < 10; i++ {
+			values = append(values, engine.Normal())
+		}
+		return values
+	}
+
+	assert.Equal(t, samples(42), samples(42))
+	assert.NotEqual(t, samples(42), samples(43))
+}
+
+func TestEngine_GetTransitionDelay_Jitter(t *testing.T) {
+	ctx := context.Background()
+	delays := func(seed int64, jitterPercent int) []time.Duration {
+		cfg := createTestConfig()
+		cfg.RandomSeed = &seed
+		cfg.ClusterDeployment.JitterPercent = jitterPercent
+		engine := NewEngine(createTestLogger(), cfg)
+
+		var durations []time.Duration
+		for i := 0; i < 50; i++ {
+			durations = append(durations, engine.GetTransitionDelay(ctx, "ClusterDeployment", "default",
+				fmt.Sprintf("cd-%d", i), nil, 100*time.Second))
+		}
+		return durations
+	}
+
+	// Jittered delays stay within the band and are reproducible with a fixed seed
+	first := delays(42, 20)
+	for _, delay := range first {
+		assert.GreaterOrEqual(t, delay, 80*time.Second)
+		assert.LessOrEqual(t, delay, 120*time.Second)
+	}
+	assert.Equal(t, first, delays(42, 20))
+	assert.NotEqual(t, first, delays(43, 20))
+	assert.NotEqual(t, first[0], first[1])
+
+	// Without jitter the delays are exact
+	for _, delay := range delays(42, 0) {
+		assert.Equal(t, 100*time.Second, delay)
+	}
+}
+
+func TestEngine_Defaults(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.ClusterDeployment.Defaults = &config.ResourceDefaults{
+		DelayMultiplier: 2,
+		Failure: &config.FailureScenario{
+			Probability: 1,
+			Condition:   "DefaultFailure",
+			Message:     "Every ClusterDeployment fails",
+			Reason:      "DefaultReason",
+		},
+	}
+	engine := NewEngine(createTestLogger(), cfg)
+	ctx := context.Background()
+
+	// The defaults apply below the built-in configuration
+	delay := engine.GetTransitionDelay(ctx, "ClusterDeployment", "default", "test-cluster", nil, 5*time.Second)
+	assert.Equal(t, 10*time.Second, delay)
+	shouldFail, failure := engine.ShouldFail(ctx, "ClusterDeployment", "default", "test-cluster", "", nil)
+	assert.True(t, shouldFail)
+	require.NotNil(t, failure)
+	assert.Equal(t, "DefaultReason", failure.Reason)
+
+	// Other resource types keep their own behavior
+	delay = engine.GetTransitionDelay(ctx, "AccountClaim", "default", "test-claim", nil, 5*time.Second)
+	assert.Equal(t, 5*time.Second, delay)
+	shouldFail, _ = engine.ShouldFail(ctx, "AccountClaim", "default", "test-claim", "", nil)
+	assert.False(t, shouldFail)
+
+	// API overrides take precedence over the defaults, wildcards included
+	engine.SetResourceOverride(ctx, "ClusterDeployment", "default", Wildcard, &config.ResourceOverride{
+		ResourceName: Wildcard,
+		DelaySeconds: intPtr(3),
+		ForceSuccess: true,
+	})
+	delay = engine.GetTransitionDelay(ctx, "ClusterDeployment", "default", "test-cluster", nil, 5*time.Second)
+	assert.Equal(t, 3*time.Second, delay)
+	shouldFail, _ = engine.ShouldFail(ctx, "ClusterDeployment", "default", "test-cluster", "", nil)
+	assert.False(t, shouldFail)
+}
+
 func TestEngine_GetTransitionDelay_WithOverride(t *testing.T) {
 	logger := createTestLogger()
 	cfg := createTestConfig()
@@ -186,7 +655,7 @@ func TestEngine_GetTransitionDelay_WithOverride(t *testing.T) {
 	name := "test-cluster"
 
 	// Without override
-	delay := engine.GetTransitionDelay(ctx, resourceType, namespace, name, 5*time.Second)
+	delay := engine.GetTransitionDelay(ctx, resourceType, namespace, name, nil, 5*time.Second)
 	assert.Equal(t, 5*time.Second, delay)
 
 	// With override
@@ -196,10 +665,224 @@ func TestEngine_GetTransitionDelay_WithOverride(t *testing.T) {
 	}
 	engine.SetResourceOverride(ctx, resourceType, namespace, name, override)
 
-	delay = engine.GetTransitionDelay(ctx, resourceType, namespace, name, 5*time.Second)
+	delay = engine.GetTransitionDelay(ctx, resourceType, namespace, name, nil, 5*time.Second)
 	assert.Equal(t, 20*time.Second, delay)
 }
 
+func TestEngine_GetTransitionDelay_SpeedFactor(t *testing.T) {
+	engine := NewEngine(createTestLogger(), createTestConfig())
+	ctx := context.Background()
+
+	assert.Equal(t, float64(1), engine.GetSpeedFactor())
+	require.NoError(t, engine.SetSpeedFactor(ctx, 10))
+	assert.Equal(t, 3*time.Second, engine.GetTransitionDelay(ctx, "ClusterDeployment", "default", "test-cluster", nil, 30*time.Second))
+
+	// Delay overrides are accelerated as well
+	engine.SetResourceOverride(ctx, "ClusterDeployment", "default", "test-cluster", &config.ResourceOverride{DelaySeconds: intPtr(20)})
+	assert.Equal(t, 2*time.Second, engine.GetTransitionDelay(ctx, "ClusterDeployment", "default", "test-cluster", nil, 30*time.Second))
+
+	require.NoError(t, engine.SetSpeedFactor(ctx, 0.5))
+	assert.Equal(t, 40*time.Second, engine.GetTransitionDelay(ctx, "ClusterDeployment", "default", "test-cluster", nil, 30*time.Second))
+
+	assert.Error(t, engine.SetSpeedFactor(ctx, 0))
+	assert.Error(t, engine.SetSpeedFactor(ctx, -2))
+	assert.Equal(t, 0.5, engine.GetSpeedFactor())
+}
+
+func TestEngine_Accelerate(t *testing.T) {
+	engine := NewEngine(createTestLogger(), createTestConfig())
+	ctx := context.Background()
+
+	assert.Equal(t, 30*time.Second, engine.Accelerate(30*time.Second))
+	require.NoError(t, engine.SetSpeedFactor(ctx, 10))
+	assert.Equal(t, 3*time.Second, engine.Accelerate(30*time.Second))
+}
+
+func TestEngine_WildcardOverrides(t *testing.T) {
+	// Without probabilistic failures, only overrides decide the outcome
+	cfg := createTestConfig()
+	cfg.ClusterDeployment.FailureScenarios = nil
+	engine := NewEngine(createTestLogger(), cfg)
+	ctx := context.Background()
+
+	failure := func(reason string) *config.ResourceOverride {
+		return &config.ResourceOverride{ForceFail: &config.FailureScenario{Condition: "ProvisionFailed", Reason: reason}}
+	}
+	engine.SetResourceOverride(ctx, "ClusterDeployment", Wildcard, Wildcard, failure("Global"))
+	engine.SetResourceOverride(ctx, "ClusterDeployment", "ns1", Wildcard, failure("Namespace"))
+	engine.SetResourceOverride(ctx, "ClusterDeployment", "ns1", "cluster1", failure("Exact"))
+	engine.SetResourceOverride(ctx, "ClusterDeployment", "ns1", "cluster2", &config.ResourceOverride{ForceSuccess: true})
+
+	reason := func(namespace, name string) string {
+		shouldFail, scenario := engine.ShouldFail(ctx, "ClusterDeployment", namespace, name, "", nil)
+		if !shouldFail {
+			return ""
+		}
+		return scenario.Reason
+	}
+	assert.Equal(t, "Exact", reason("ns1", "cluster1"))
+	assert.Equal(t, "", reason("ns1", "cluster2"))
+	assert.Equal(t, "Namespace", reason("ns1", "cluster3"))
+	assert.Equal(t, "Global", reason("ns2", "cluster1"))
+
+	// Wildcards only match resources of the same type
+	shouldFail, _ := engine.ShouldFail(ctx, "AccountClaim", "ns1", "claim1", "", nil)
+	assert.False(t, shouldFail)
+
+	// An exact override without a delay falls back to the namespace-wide delay
+	engine.SetResourceOverride(ctx, "ClusterDeployment", "ns1", Wildcard, &config.ResourceOverride{DelaySeconds: intPtr(30)})
+	assert.Equal(t, 30*time.Second, engine.GetTransitionDelay(ctx, "ClusterDeployment", "ns1", "cluster1", nil, 5*time.Second))
+	assert.Equal(t, 5*time.Second, engine.GetTransitionDelay(ctx, "ClusterDeployment", "ns2", "cluster1", nil, 5*time.Second))
+
+	engine.ClearAllOverrides(ctx)
+	assert.Equal(t, "", reason("ns1", "cluster1"))
+	assert.Equal(t, "", reason("ns2", "cluster1"))
+	assert.Empty(t, engine.ListOverrides())
+}
+
+func TestEngine_SelectorOverrides(t *testing.T) {
+	// Without probabilistic failures, only overrides decide the outcome
+	cfg := createTestConfig()
+	cfg.ClusterDeployment.FailureScenarios = nil
+	seed := int64(42)
+	cfg.RandomSeed = &seed
+	engine := NewEngine(createTestLogger(), cfg)
+	ctx := context.Background()
+
+	canary := map[string]string{"tier": "canary"}
+	require.NoError(t, engine.AddSelectorOverride(ctx, &config.SelectorOverride{
+		ResourceType:  "ClusterDeployment",
+		LabelSelector: "tier=canary",
+		DelaySeconds:  intPtr(60),
+		ForceFail:     &config.FailureScenario{Condition: "ProvisionFailed", Reason: "Canary"},
+	}))
+	engine.SetResourceOverride(ctx, "ClusterDeployment", Wildcard, Wildcard, &config.ResourceOverride{
+		DelaySeconds: intPtr(10),
+		ForceFail:    &config.FailureScenario{Condition: "ProvisionFailed", Reason: "Global"},
+	})
+	engine.SetResourceOverride(ctx, "ClusterDeployment", "ns1", "cluster1", &config.ResourceOverride{
+		DelaySeconds: intPtr(5),
+		ForceSuccess: true,
+	})
+
+	// Exact-name overrides take precedence over selector overrides
+	shouldFail, _ := engine.ShouldFail(ctx, "ClusterDeployment", "ns1", "cluster1", "", canary)
+	assert.False(t, shouldFail)
+	assert.Equal(t, 5*time.Second, engine.GetTransitionDelay(ctx, "ClusterDeployment", "ns1", "cluster1", canary, time.Second))
+
+	// Selector overrides take precedence over wildcard overrides
+	shouldFail, scenario := engine.ShouldFail(ctx, "ClusterDeployment", "ns1", "cluster2", "", canary)
+	require.True(t, shouldFail)
+	assert.Equal(t, "Canary", scenario.Reason)
+	assert.Equal(t, 60*time.Second, engine.GetTransitionDelay(ctx, "ClusterDeployment", "ns1", "cluster2", canary, time.Second))
+
+	// Resources not matching the selector fall through to the wildcard override
+	shouldFail, scenario = engine.ShouldFail(ctx, "ClusterDeployment", "ns1", "cluster3", "", map[string]string{"tier": "stable"})
+	require.True(t, shouldFail)
+	assert.Equal(t, "Global", scenario.Reason)
+	assert.Equal(t, 10*time.Second, engine.GetTransitionDelay(ctx, "ClusterDeployment", "ns1", "cluster3", nil, time.Second))
+
+	// Selector overrides only match resources of the same type
+	shouldFail, _ = engine.ShouldFail(ctx, "AccountClaim", "ns1", "claim1", "", canary)
+	assert.False(t, shouldFail)
+
+	// A probabilistic selector failure fails only part of the matching resources
+	require.NoError(t, engine.AddSelectorOverride(ctx, &config.SelectorOverride{
+		ResourceType:  "AccountClaim",
+		LabelSelector: "tier in (canary)",
+		ForceFail:     &config.FailureScenario{Condition: "Error", Reason: "CanaryClaim"},
+		Probability:   0.2,
+	}))
+	failures := 0
+	for i := 0; i < 200; i++ {
+		if shouldFail, _ := engine.ShouldFail(ctx, "AccountClaim", "ns1", fmt.Sprintf("claim-%d", i), "", canary); shouldFail {
+			failures++
+		}
+	}
+	assert.Greater(t, failures, 0)
+	assert.Less(t, failures, 100)
+
+	assert.Len(t, engine.ListSelectorOverrides(), 2)
+	engine.ClearAllOverrides(ctx)
+	assert.Empty(t, engine.ListSelectorOverrides())
+}
+
+func TestEngine_SelectorOverride_LostRollFallsThrough(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.ClusterDeployment.FailureScenarios = nil
+	engine := NewEngine(createTestLogger(), cfg)
+	defer engine.Close()
+	ctx := context.Background()
+
+	// A lost roll only skips its own selector override, the wildcard override still applies
+	require.NoError(t, engine.AddSelectorOverride(ctx, &config.SelectorOverride{
+		ResourceType:  "ClusterDeployment",
+		LabelSelector: "tier=canary",
+		ForceFail:     &config.FailureScenario{Condition: "ProvisionFailed", Reason: "Canary"},
+		Probability:   1e-12,
+	}))
+	engine.SetResourceOverride(ctx, "ClusterDeployment", "ns1", Wildcard, &config.ResourceOverride{
+		ForceFail: &config.FailureScenario{Condition: "ProvisionFailed", Reason: "Global"},
+	})
+
+	shouldFail, scenario := engine.ShouldFail(ctx, "ClusterDeployment", "ns1", "cluster1", "", map[string]string{"tier": "canary"})
+	require.True(t, shouldFail)
+	assert.Equal(t, "Global", scenario.Reason)
+}
+
+func TestEngine_AddSelectorOverride_Invalid(t *testing.T) {
+	engine := NewEngine(createTestLogger(), createTestConfig())
+	ctx := context.Background()
+
+	err := engine.AddSelectorOverride(ctx, &config.SelectorOverride{ResourceType: "ClusterDeployment", LabelSelector: "tier in canary"})
+	assert.Error(t, err)
+
+	err = engine.AddSelectorOverride(ctx, &config.SelectorOverride{ResourceType: "ClusterDeployment", Probability: 1.5})
+	assert.Error(t, err)
+	assert.Empty(t, engine.ListSelectorOverrides())
+}
+
+func TestEngine_ExpiredOverrides(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.ClusterDeployment.FailureScenarios = nil
+	engine := NewEngine(createTestLogger(), cfg)
+	defer engine.Close()
+	ctx := context.Background()
+
+	past := time.Now().Add(-time.Second)
+	future := time.Now().Add(time.Hour)
+	engine.SetResourceOverride(ctx, "ClusterDeployment", "ns1", "cluster1", &config.ResourceOverride{
+		ForceFail: &config.FailureScenario{Condition: "ProvisionFailed", Reason: "Expired"},
+		ExpiresAt: &past,
+	})
+	engine.SetResourceOverride(ctx, "ClusterDeployment", "ns1", Wildcard, &config.ResourceOverride{
+		DelaySeconds: intPtr(30),
+		ExpiresAt:    &future,
+	})
+	engine.SetResourceOverride(ctx, "ClusterDeployment", "ns1", "cluster2", &config.ResourceOverride{
+		DelaySeconds: intPtr(10),
+		ExpiresAt:    &past,
+	})
+
+	// Expired overrides are ignored, not listed and removed on use
+	assert.Len(t, engine.ListOverrides(), 1)
+	shouldFail, _ := engine.ShouldFail(ctx, "ClusterDeployment", "ns1", "cluster1", "", nil)
+	assert.False(t, shouldFail)
+	assert.Equal(t, 30*time.Second, engine.GetTransitionDelay(ctx, "ClusterDeployment", "ns1", "cluster1", nil, time.Second))
+	engine.mu.RLock()
+	assert.Len(t, engine.overrides, 2)
+	engine.mu.RUnlock()
+
+	// The sweep removes the remaining expired overrides, and the rest once they expire
+	assert.Equal(t, 1, engine.RemoveExpiredOverrides(ctx, time.Now()))
+	assert.Equal(t, 30*time.Second, engine.GetTransitionDelay(ctx, "ClusterDeployment", "ns1", "cluster2", nil, time.Second))
+	assert.Equal(t, 1, engine.RemoveExpiredOverrides(ctx, future))
+	assert.Empty(t, engine.ListOverrides())
+
+	// Closing twice is safe
+	engine.Close()
+}
+
 func TestEngine_ClearAllOverrides(t *testing.T) {
 	logger := createTestLogger()
 	cfg := createTestConfig()
@@ -217,15 +900,15 @@ func TestEngine_ClearAllOverrides(t *testing.T) {
 	})
 
 	// Verify overrides exist
-	delay1 := engine.GetTransitionDelay(ctx, "ClusterDeployment", "ns1", "cluster1", 5*time.Second)
+	delay1 := engine.GetTransitionDelay(ctx, "ClusterDeployment", "ns1", "cluster1", nil, 5*time.Second)
 	assert.Equal(t, 10*time.Second, delay1)
 
 	// Clear all
 	engine.ClearAllOverrides(ctx)
 
 	// Verify all cleared
-	delay1 = engine.GetTransitionDelay(ctx, "ClusterDeployment", "ns1", "cluster1", 5*time.Second)
-	delay2 := engine.GetTransitionDelay(ctx, "AccountClaim", "ns2", "account1", 5*time.Second)
+	delay1 = engine.GetTransitionDelay(ctx, "ClusterDeployment", "ns1", "cluster1", nil, 5*time.Second)
+	delay2 := engine.GetTransitionDelay(ctx, "AccountClaim", "ns2", "account1", nil, 5*time.Second)
 	assert.Equal(t, 5*time.Second, delay1)
 	assert.Equal(t, 5*time.Second, delay2)
 }
@@ -252,3 +935,75 @@ func TestEngine_GetClusterImageSetsConfig(t *testing.T) {
 func intPtr(i int) *int {
 	return &i
 }
+
+func TestEngine_ResolveNamespace(t *testing.T) {
+	logger := createTestLogger()
+	cfg := createTestConfig()
+	cfg.DefaultNamespace = "sim-workloads"
+	engine := NewEngine(logger, cfg)
+
+	assert.Equal(t, "sim-workloads", engine.ResolveNamespace(""))
+	assert.Equal(t, "explicit", engine.ResolveNamespace("explicit"))
+}
+
+func TestEngine_ClearOverridesMatching(t *testing.T) {
+	engine := NewEngine(createTestLogger(), createTestConfig())
+	ctx := context.Background()
+
+	for _, key := range [][3]string{
+		{"ClusterDeployment", "ci-123", "cluster1"},
+		{"ClusterDeployment", "ci-456", "cluster2"},
+		{"ClusterDeployment", "dev", "cluster3"},
+		{"AccountClaim", "ci-123", "account1"},
+	} {
+		engine.SetResourceOverride(ctx, key[0], key[1], key[2], &config.ResourceOverride{DelaySeconds: intPtr(10)})
+	}
+
+	cleared, err := engine.ClearOverridesMatching(ctx, "ClusterDeployment/ci-*/*")
+	require.NoError(t, err)
+	assert.Equal(t, 2, cleared)
+
+	assert.Equal(t, 5*time.Second, engine.GetTransitionDelay(ctx, "ClusterDeployment", "ci-123", "cluster1", nil, 5*time.Second))
+	assert.Equal(t, 5*time.Second, engine.GetTransitionDelay(ctx, "ClusterDeployment", "ci-456", "cluster2", nil, 5*time.Second))
+	assert.Equal(t, 10*time.Second, engine.GetTransitionDelay(ctx, "ClusterDeployment", "dev", "cluster3", nil, 5*time.Second))
+	assert.Equal(t, 10*time.Second, engine.GetTransitionDelay(ctx, "AccountClaim", "ci-123", "account1", nil, 5*time.Second))
+
+	// A wildcard segment never spans a '/'
+	cleared, err = engine.ClearOverridesMatching(ctx, "*/ci-123/*")
+	require.NoError(t, err)
+	assert.Equal(t, 1, cleared)
+}
+
+func TestEngine_ClearOverridesMatching_InvalidPattern(t *testing.T) {
+	engine := NewEngine(createTestLogger(), createTestConfig())
+	ctx := context.Background()
+
+	_, err := engine.ClearOverridesMatching(ctx, "ClusterDeployment/*")
+	assert.Error(t, err)
+
+	_, err = engine.ClearOverridesMatching(ctx, "ClusterDeployment/[/*")
+	assert.Error(t, err)
+}
+
+func TestEngine_ListOverrides(t *testing.T) {
+	engine := NewEngine(createTestLogger(), createTestConfig())
+	ctx := context.Background()
+
+	delay := 30
+	engine.SetResourceOverride(ctx, "ClusterDeployment", "default", "cd-1",
+		&config.ResourceOverride{ResourceName: "cd-1", DelaySeconds: &delay})
+	engine.SetResourceOverride(ctx, "AccountClaim", "default", "ac-1",
+		&config.ResourceOverride{ResourceName: "ac-1", ForceFail: &config.FailureScenario{Reason: "TestReason"}})
+
+	overrides := engine.ListOverrides()
+	require.Len(t, overrides, 2)
+	require.NotNil(t, overrides["ClusterDeployment/default/cd-1"].DelaySeconds)
+	assert.Equal(t, 30, *overrides["ClusterDeployment/default/cd-1"].DelaySeconds)
+	assert.Equal(t, "TestReason", overrides["AccountClaim/default/ac-1"].ForceFail.Reason)
+
+	// The returned overrides are copies
+	*overrides["ClusterDeployment/default/cd-1"].DelaySeconds = 1
+	overrides["AccountClaim/default/ac-1"].ForceFail.Reason = "Changed"
+	assert.Equal(t, 30*time.Second, engine.GetTransitionDelay(ctx, "ClusterDeployment", "default", "cd-1", nil, time.Second))
+	assert.Equal(t, "TestReason", engine.ListOverrides()["AccountClaim/default/ac-1"].ForceFail.Reason)
+}
@@ -0,0 +1,65 @@
+package behavior
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// engineMetrics tracks how resource overrides are used and the failures they force,
+// labeled by resource type
+type engineMetrics struct {
+	set            *prometheus.CounterVec
+	cleared        *prometheus.CounterVec
+	active         *prometheus.GaugeVec
+	forcedFailures *prometheus.GaugeVec
+}
+
+func newEngineMetrics() *engineMetrics {
+	return &engineMetrics{
+		set: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "hivesim_overrides_set_total",
+			Help: "Number of resource overrides set, including replaced ones",
+		}, []string{"resource_type"}),
+		cleared: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "hivesim_overrides_cleared_total",
+			Help: "Number of resource overrides cleared",
+		}, []string{"resource_type"}),
+		active: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "hivesim_overrides_active",
+			Help: "Number of resource overrides currently in effect",
+		}, []string{"resource_type"}),
+		forcedFailures: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "hivesim_forced_failures",
+			Help: "Number of failures forced by resource or selector overrides",
+		}, []string{"resource"}),
+	}
+}
+
+// RegisterMetrics registers the override usage and forced failure metrics of the engine with the registerer
+func (e *Engine) RegisterMetrics(registerer prometheus.Registerer) error {
+	collectors := []prometheus.Collector{e.metrics.set, e.metrics.cleared, e.metrics.active, e.metrics.forcedFailures}
+	for _, collector := range collectors {
+		if err := registerer.Register(collector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// overrideSet records a set override, replaced is true if it replaced an existing one
+func (m *engineMetrics) overrideSet(resourceType string, replaced bool) {
+	m.set.WithLabelValues(resourceType).Inc()
+	if !replaced {
+		m.active.WithLabelValues(resourceType).Inc()
+	}
+}
+
+// overrideCleared records a cleared override
+func (m *engineMetrics) overrideCleared(resourceType string) {
+	m.cleared.WithLabelValues(resourceType).Inc()
+	m.active.WithLabelValues(resourceType).Dec()
+}
+
+// failureForced records a failure forced by an override
+func (m *engineMetrics) failureForced(resourceType string) {
+	m.forcedFailures.WithLabelValues(resourceType).Inc()
+}
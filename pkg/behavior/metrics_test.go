@@ -0,0 +1,93 @@
+package behavior
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+)
+
+func TestEngine_OverrideMetrics(t *testing.T) {
+	engine := NewEngine(createTestLogger(), createTestConfig())
+	require.NoError(t, engine.RegisterMetrics(prometheus.NewRegistry()))
+	ctx := context.Background()
+
+	set := func(resourceType string) float64 {
+		return testutil.ToFloat64(engine.metrics.set.WithLabelValues(resourceType))
+	}
+	cleared := func(resourceType string) float64 {
+		return testutil.ToFloat64(engine.metrics.cleared.WithLabelValues(resourceType))
+	}
+	active := func(resourceType string) float64 {
+		return testutil.ToFloat64(engine.metrics.active.WithLabelValues(resourceType))
+	}
+
+	engine.SetResourceOverride(ctx, "ClusterDeployment", "ns1", "cluster1", &config.ResourceOverride{DelaySeconds: intPtr(10)})
+	engine.SetResourceOverride(ctx, "ClusterDeployment", "ns1", "cluster2", &config.ResourceOverride{ForceSuccess: true})
+	engine.SetResourceOverride(ctx, "AccountClaim", "ns1", "claim1", &config.ResourceOverride{ForceSuccess: true})
+	assert.Equal(t, float64(2), set("ClusterDeployment"))
+	assert.Equal(t, float64(2), active("ClusterDeployment"))
+	assert.Equal(t, float64(1), active("AccountClaim"))
+
+	// Replacing an override is counted as set but does not add an active one
+	engine.SetResourceOverride(ctx, "ClusterDeployment", "ns1", "cluster1", &config.ResourceOverride{DelaySeconds: intPtr(20)})
+	assert.Equal(t, float64(3), set("ClusterDeployment"))
+	assert.Equal(t, float64(2), active("ClusterDeployment"))
+
+	// Clearing an override that is not set changes nothing
+	engine.ClearResourceOverride(ctx, "ClusterDeployment", "ns1", "cluster1")
+	engine.ClearResourceOverride(ctx, "ClusterDeployment", "ns1", "cluster1")
+	assert.Equal(t, float64(1), cleared("ClusterDeployment"))
+	assert.Equal(t, float64(1), active("ClusterDeployment"))
+
+	_, err := engine.ClearOverridesMatching(ctx, "AccountClaim/*/*")
+	require.NoError(t, err)
+	assert.Equal(t, float64(1), cleared("AccountClaim"))
+	assert.Zero(t, active("AccountClaim"))
+
+	engine.ClearAllOverrides(ctx)
+	assert.Equal(t, float64(2), cleared("ClusterDeployment"))
+	assert.Zero(t, active("ClusterDeployment"))
+}
+
+func TestEngine_RegisterMetrics_Twice(t *testing.T) {
+	engine := NewEngine(createTestLogger(), createTestConfig())
+	registry := prometheus.NewRegistry()
+	require.NoError(t, engine.RegisterMetrics(registry))
+	assert.Error(t, engine.RegisterMetrics(registry))
+}
+
+func TestEngine_ForcedFailureMetrics(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.ClusterDeployment.FailureScenarios = nil
+	engine := NewEngine(createTestLogger(), cfg)
+	ctx := context.Background()
+
+	forced := func(resourceType string) float64 {
+		return testutil.ToFloat64(engine.metrics.forcedFailures.WithLabelValues(resourceType))
+	}
+
+	// Failures that are not forced by an override are not counted
+	engine.ShouldFail(ctx, "ClusterDeployment", "default", "cluster1", "", nil)
+	assert.Zero(t, forced("ClusterDeployment"))
+
+	engine.SetResourceOverride(ctx, "ClusterDeployment", "default", "cluster1", &config.ResourceOverride{
+		ForceFail: &config.FailureScenario{Condition: "ProvisionFailed", Reason: "Forced"},
+	})
+	require.NoError(t, engine.AddSelectorOverride(ctx, &config.SelectorOverride{
+		ResourceType:  "ClusterDeployment",
+		LabelSelector: "canary=true",
+		ForceFail:     &config.FailureScenario{Condition: "ProvisionFailed", Reason: "Canary"},
+	}))
+
+	shouldFail, _ := engine.ShouldFail(ctx, "ClusterDeployment", "default", "cluster1", "", nil)
+	assert.True(t, shouldFail)
+	shouldFail, _ = engine.ShouldFail(ctx, "ClusterDeployment", "default", "cluster2", "", map[string]string{"canary": "true"})
+	assert.True(t, shouldFail)
+	assert.Equal(t, float64(2), forced("ClusterDeployment"))
+}
@@ -0,0 +1,62 @@
+package behavior
+
+import (
+	"sync"
+)
+
+// retryCounts is a thread-safe count of the transient failures that fired per resource,
+// keyed like the overrides
+type retryCounts struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// exhausted returns true if the transient failures of the resource have fired as many
+// times as they retry before success
+func (c *retryCounts) exhausted(key string, retriesBeforeSuccess int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.counts[key] >= retriesBeforeSuccess
+}
+
+// record counts a transient failure of the resource, returning how many have fired
+func (c *retryCounts) record(key string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.counts == nil {
+		c.counts = make(map[string]int)
+	}
+	c.counts[key]++
+	return c.counts[key]
+}
+
+// clear forgets the transient failures of the resource
+func (c *retryCounts) clear(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.counts, key)
+}
+
+// clearMatching forgets the transient failures of every resource whose key matches the
+// pattern segments
+func (c *retryCounts) clearMatching(patternSegments []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.counts {
+		if matchKey(patternSegments, key) {
+			delete(c.counts, key)
+		}
+	}
+}
+
+// clearAll forgets the transient failures of every resource
+func (c *retryCounts) clearAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.counts = nil
+}
@@ -0,0 +1,46 @@
+package behavior
+
+import (
+	"sync"
+	"time"
+)
+
+// maxRolls bounds the number of failure rolls kept for debugging, oldest are dropped first
+const maxRolls = 1000
+
+// Roll records a single probabilistic failure evaluation
+type Roll struct {
+	Time      time.Time `json:"time"`
+	Resource  string    `json:"resource"`
+	Scenario  string    `json:"scenario"`
+	Value     float64   `json:"value"`
+	Threshold float64   `json:"threshold"`
+	Failed    bool      `json:"failed"`
+}
+
+// rollLog is a bounded, thread-safe log of failure rolls
+type rollLog struct {
+	mu    sync.Mutex
+	rolls []Roll
+}
+
+// add appends a roll, dropping the oldest one once the log is full
+func (l *rollLog) add(roll Roll) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.rolls) >= maxRolls {
+		l.rolls = l.rolls[len(l.rolls)-maxRolls+1:]
+	}
+	l.rolls = append(l.rolls, roll)
+}
+
+// list returns a copy of the logged rolls, oldest first
+func (l *rollLog) list() []Roll {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	rolls := make([]Roll, len(l.rolls))
+	copy(rolls, l.rolls)
+	return rolls
+}
@@ -0,0 +1,81 @@
+package behavior
+
+import (
+	"sync"
+	"time"
+)
+
+// transitionBufferSize bounds how many transitions a subscriber can fall behind, further
+// transitions are dropped for it until it catches up
+const transitionBufferSize = 100
+
+// Transition is a state change of a simulated resource
+type Transition struct {
+	// ResourceType is the kind of the resource, e.g. ClusterDeployment
+	ResourceType string    `json:"resourceType"`
+	Namespace    string    `json:"namespace"`
+	Name         string    `json:"name"`
+	State        string    `json:"state"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// transitionBroadcaster is a thread-safe fan-out of transitions to subscribers. Publishing
+// never blocks, so a slow subscriber cannot hold up the reconcilers.
+type transitionBroadcaster struct {
+	mu          sync.Mutex
+	subscribers map[chan Transition]struct{}
+}
+
+// subscribe returns a channel receiving every transition published from now on, and a
+// function that unsubscribes and closes the channel
+func (b *transitionBroadcaster) subscribe() (<-chan Transition, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.subscribers == nil {
+		b.subscribers = make(map[chan Transition]struct{})
+	}
+	ch := make(chan Transition, transitionBufferSize)
+	b.subscribers[ch] = struct{}{}
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			delete(b.subscribers, ch)
+			close(ch)
+		})
+	}
+}
+
+// publish sends the transition to every subscriber with room left in its buffer
+func (b *transitionBroadcaster) publish(transition Transition) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- transition:
+		default:
+		}
+	}
+}
+
+// PublishTransition sends a state change of a resource to the transition subscribers, the
+// timestamp defaults to now
+func (e *Engine) PublishTransition(resourceType, namespace, name, state string) {
+	e.transitions.publish(Transition{
+		ResourceType: resourceType,
+		Namespace:    namespace,
+		Name:         name,
+		State:        state,
+		Timestamp:    time.Now().UTC(),
+	})
+}
+
+// SubscribeTransitions returns a channel receiving every state change published from now on,
+// and a function to call once done that unsubscribes and closes the channel
+func (e *Engine) SubscribeTransitions() (<-chan Transition, func()) {
+	return e.transitions.subscribe()
+}
@@ -0,0 +1,51 @@
+package behavior
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngine_SubscribeTransitions(t *testing.T) {
+	engine := NewEngine(createTestLogger(), createTestConfig())
+	defer engine.Close()
+
+	first, unsubscribeFirst := engine.SubscribeTransitions()
+	second, unsubscribeSecond := engine.SubscribeTransitions()
+	defer unsubscribeSecond()
+
+	engine.PublishTransition("ClusterDeployment", "default", "cd-1", "Provisioning")
+	for _, ch := range []<-chan Transition{first, second} {
+		transition := <-ch
+		assert.Equal(t, "ClusterDeployment", transition.ResourceType)
+		assert.Equal(t, "default", transition.Namespace)
+		assert.Equal(t, "cd-1", transition.Name)
+		assert.Equal(t, "Provisioning", transition.State)
+		assert.False(t, transition.Timestamp.IsZero())
+	}
+
+	// Unsubscribing closes the channel and stops delivery to it
+	unsubscribeFirst()
+	unsubscribeFirst()
+	_, ok := <-first
+	assert.False(t, ok)
+
+	engine.PublishTransition("AccountClaim", "default", "ac-1", "Ready")
+	transition := <-second
+	assert.Equal(t, "AccountClaim", transition.ResourceType)
+}
+
+func TestEngine_PublishTransitionDoesNotBlock(t *testing.T) {
+	engine := NewEngine(createTestLogger(), createTestConfig())
+	defer engine.Close()
+
+	transitions, unsubscribe := engine.SubscribeTransitions()
+	defer unsubscribe()
+
+	// Transitions beyond the buffer of a subscriber that does not read are dropped
+	for i := 0; i < transitionBufferSize+10; i++ {
+		engine.PublishTransition("ClusterDeployment", "default", "cd-1", "Provisioning")
+	}
+	require.Len(t, transitions, transitionBufferSize)
+}
@@ -0,0 +1,87 @@
+package hive_simulator
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	hiveintv1alpha1 "github.com/openshift/hive/apis/hiveinternal/v1alpha1"
+	errors "github.com/zgalor/weberr"
+
+	aaov1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/aws-account-operator/v1alpha1"
+	gcpv1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/gcp-project-operator/v1alpha1"
+)
+
+// defaultCacheSyncTimeout bounds the wait for the controller caches when no timeout is set
+const defaultCacheSyncTimeout = 2 * time.Minute
+
+// watchedObjects returns an object of each kind the controllers watch
+func (s *Server) watchedObjects() []client.Object {
+	objs := []client.Object{
+		&hivev1.ClusterDeployment{},
+		&hivev1.ClusterImageSet{},
+		&aaov1alpha1.AccountClaim{},
+		&gcpv1alpha1.ProjectClaim{},
+	}
+	if s.config.SyncSet != nil {
+		objs = append(objs, &hiveintv1alpha1.ClusterSync{}, &hivev1.SyncSet{})
+		if s.config.SyncSet.SelectorSyncSets {
+			objs = append(objs, &hivev1.SelectorSyncSet{})
+		}
+	}
+	return objs
+}
+
+// waitForCacheSync waits for the informers of the controllers to sync, up to the cache sync
+// timeout. On timeout, the error names the kinds whose informers did not sync, which usually
+// means their CRD is missing or does not match the types registered in the scheme.
+func (s *Server) waitForCacheSync(ctx context.Context, informers cache.Cache) error {
+	timeout := s.cacheSyncTimeout
+	if timeout <= 0 {
+		timeout = defaultCacheSyncTimeout
+	}
+
+	syncCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if informers.WaitForCacheSync(syncCtx) {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return errors.Wrapf(ctx.Err(), "interrupted while waiting for cache sync")
+	}
+
+	unsynced := s.unsyncedKinds(ctx, informers)
+	if len(unsynced) == 0 {
+		return errors.Errorf("caches did not sync within %v", timeout)
+	}
+	s.logger.Error(ctx, "Informers not synced after %v: %s", timeout, strings.Join(unsynced, ", "))
+	return errors.Errorf("caches did not sync within %v, informers not synced: %s. "+
+		"Check that the CRDs of these kinds are installed and match the types registered in the scheme",
+		timeout, strings.Join(unsynced, ", "))
+}
+
+// unsyncedKinds returns the watched kinds whose informers have not synced, as group/version/kind
+func (s *Server) unsyncedKinds(ctx context.Context, informers cache.Cache) []string {
+	var unsynced []string
+	for _, obj := range s.watchedObjects() {
+		gvk, err := apiutil.GVKForObject(obj, s.k8sClient.Scheme())
+		if err != nil {
+			s.logger.Warn(ctx, "Failed to get the kind of %T: %v", obj, err)
+			continue
+		}
+		informer, err := informers.GetInformer(ctx, obj, cache.BlockUntilSynced(false))
+		if err != nil {
+			unsynced = append(unsynced, gvk.String()+" ("+err.Error()+")")
+			continue
+		}
+		if !informer.HasSynced() {
+			unsynced = append(unsynced, gvk.String())
+		}
+	}
+	return unsynced
+}
@@ -0,0 +1,59 @@
+package hive_simulator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	toolscache "k8s.io/client-go/tools/cache"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllertest"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+)
+
+// neverSyncingCache is a cache whose informers never all sync
+type neverSyncingCache struct {
+	*informertest.FakeInformers
+}
+
+func (c neverSyncingCache) WaitForCacheSync(ctx context.Context) bool {
+	<-ctx.Done()
+	return false
+}
+
+func TestServer_WaitForCacheSync_Timeout(t *testing.T) {
+	server := NewServer(createTestLogger(), config.DefaultConfig(), ServerOptions{CacheSyncTimeout: 10 * time.Millisecond})
+	scheme, err := server.newScheme()
+	require.NoError(t, err)
+	server.k8sClient = fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	// Only the ClusterDeployment informer syncs
+	informers := neverSyncingCache{&informertest.FakeInformers{
+		Scheme: scheme,
+		InformersByGVK: map[schema.GroupVersionKind]toolscache.SharedIndexInformer{
+			hivev1.SchemeGroupVersion.WithKind("ClusterDeployment"): &controllertest.FakeInformer{Synced: true},
+		},
+	}}
+
+	err = server.waitForCacheSync(context.Background(), informers)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "caches did not sync within 10ms")
+	assert.Contains(t, err.Error(), "aws.managed.openshift.io/v1alpha1, Kind=AccountClaim")
+	assert.Contains(t, err.Error(), "gcp.managed.openshift.io/v1alpha1, Kind=ProjectClaim")
+	assert.NotContains(t, err.Error(), "Kind=ClusterDeployment")
+	assert.Contains(t, err.Error(), "Check that the CRDs of these kinds are installed")
+}
+
+func TestServer_WaitForCacheSync_Synced(t *testing.T) {
+	server := NewServer(createTestLogger(), config.DefaultConfig(), ServerOptions{})
+	synced := true
+	require.NoError(t, server.waitForCacheSync(context.Background(), &informertest.FakeInformers{Synced: &synced}))
+}
@@ -1,7 +1,12 @@
 package config
 
 import (
+	"strings"
 	"time"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	errors "github.com/zgalor/weberr"
 )
 
 // Config is the main configuration for the hive simulator
@@ -10,8 +15,79 @@ type Config struct {
 	AccountClaim      *AccountClaimConfig      `yaml:"accountClaim" json:"accountClaim"`
 	ProjectClaim      *ProjectClaimConfig      `yaml:"projectClaim" json:"projectClaim"`
 	ClusterImageSets  []ClusterImageSetConfig  `yaml:"clusterImageSets" json:"clusterImageSets"`
+
+	// ProbeTimeRefreshSeconds is how often LastProbeTime is refreshed on the conditions
+	// of resources in a terminal state (0 disables the refresher)
+	ProbeTimeRefreshSeconds int `yaml:"probeTimeRefreshSeconds,omitempty" json:"probeTimeRefreshSeconds,omitempty"`
+
+	// TerminalResourceTTLSeconds is how long resources stay in a terminal state before they are
+	// deleted automatically (0 disables the sweeper)
+	TerminalResourceTTLSeconds int `yaml:"terminalResourceTTLSeconds,omitempty" json:"terminalResourceTTLSeconds,omitempty"`
+
+	// FailedResourceTTLSeconds is how long failed resources stay around before they are deleted
+	// automatically, so that they can be kept longer than succeeded ones (defaults to
+	// TerminalResourceTTLSeconds when 0)
+	FailedResourceTTLSeconds int `yaml:"failedResourceTTLSeconds,omitempty" json:"failedResourceTTLSeconds,omitempty"`
+
+	// DefaultNamespace is used for simulator-created resources when a request omits the namespace
+	DefaultNamespace string `yaml:"defaultNamespace,omitempty" json:"defaultNamespace,omitempty"`
+
+	// FleetRamp automatically generates ClusterDeployments over time (disabled when nil)
+	FleetRamp *FleetRampConfig `yaml:"fleetRamp,omitempty" json:"fleetRamp,omitempty"`
+
+	// RunID is stamped as a label on every resource the simulator creates, so that
+	// concurrent users can tell their resources apart (usually set with --run-id)
+	RunID string `yaml:"runID,omitempty" json:"runID,omitempty"`
+
+	// RandomSeed seeds the failure rolls of probabilistic failure scenarios, making them
+	// reproducible (seeded from the clock when unset)
+	RandomSeed *int64 `yaml:"randomSeed,omitempty" json:"randomSeed,omitempty"`
+
+	// APIResponseHeaders are added to every response of the configuration API
+	APIResponseHeaders map[string]string `yaml:"apiResponseHeaders,omitempty" json:"apiResponseHeaders,omitempty"`
+
+	// EventVerbosity selects the state transitions that emit Kubernetes events: none, failures,
+	// terminal (failures and terminal states, the default) or all
+	EventVerbosity string `yaml:"eventVerbosity,omitempty" json:"eventVerbosity,omitempty"`
+
+	// SpeedFactor divides every transition delay, so that long lifecycles run faster, e.g. 10
+	// makes a 30s state take 3s (1 when unset)
+	SpeedFactor float64 `yaml:"speedFactor,omitempty" json:"speedFactor,omitempty"`
+
+	// SyncSet simulates applying SyncSets and SelectorSyncSets to installed ClusterDeployments,
+	// reporting the results in ClusterSync objects the way Hive does (disabled when nil)
+	SyncSet *SyncSetConfig `yaml:"syncSet,omitempty" json:"syncSet,omitempty"`
+
+	// DNSZone simulates Hive's DNSZone controller, reporting DNSZones available with fake name
+	// servers (disabled when nil)
+	DNSZone *DNSZoneConfig `yaml:"dnsZone,omitempty" json:"dnsZone,omitempty"`
+
+	// ClusterPool simulates Hive's ClusterPool and ClusterClaim controllers, filling pools with
+	// ClusterDeployments and binding claims to the installed ones (disabled when nil)
+	ClusterPool *ClusterPoolConfig `yaml:"clusterPool,omitempty" json:"clusterPool,omitempty"`
 }
 
+const (
+	// EventVerbosityNone emits no events
+	EventVerbosityNone = "none"
+
+	// EventVerbosityFailures emits events for failures only
+	EventVerbosityFailures = "failures"
+
+	// EventVerbosityTerminal emits events for failures and terminal states
+	EventVerbosityTerminal = "terminal"
+
+	// EventVerbosityAll emits events for every state transition
+	EventVerbosityAll = "all"
+)
+
+// DefaultNamespaceName is the namespace used when no defaultNamespace is configured
+const DefaultNamespaceName = "default"
+
+// ClusterDeploymentTerminalState is the state in which a ClusterDeployment is installed, which
+// every ClusterDeployment state progression ends in
+const ClusterDeploymentTerminalState = "Running"
+
 // ClusterDeploymentConfig configures ClusterDeployment simulation behavior
 type ClusterDeploymentConfig struct {
 	// DefaultDelaySeconds is the total time from creation to ready state
@@ -20,14 +96,107 @@ type ClusterDeploymentConfig struct {
 	// States defines the progression and timing for each state
 	States []StateConfig `yaml:"states" json:"states"`
 
+	// DelayDistribution varies state durations between resources (fixed when unset).
+	// A state's own distribution takes precedence.
+	DelayDistribution *DelayDistribution `yaml:"delayDistribution,omitempty" json:"delayDistribution,omitempty"`
+
+	// StartJitterSeconds spreads the first transition of new resources over this window,
+	// using a per-resource offset derived from the name (0 disables)
+	StartJitterSeconds int `yaml:"startJitterSeconds,omitempty" json:"startJitterSeconds,omitempty"`
+
+	// JitterPercent perturbs every configured transition delay by a random amount of up to
+	// this percentage either way, drawn from the seeded random source (0 keeps exact timings)
+	JitterPercent int `yaml:"jitterPercent,omitempty" json:"jitterPercent,omitempty"`
+
+	// AgentStates defines the progression and timing of agent and bare metal ClusterDeployments,
+	// which have no cloud account dependency. The regular States are used when empty.
+	AgentStates []StateConfig `yaml:"agentStates,omitempty" json:"agentStates,omitempty"`
+
+	// DeprovisionStates defines the progression and timing after a ClusterDeployment is deleted.
+	// The deprovision finalizer is only added when states are configured, and is removed once
+	// the last state is reached. States without their own distribution use DelayDistribution.
+	DeprovisionStates []StateConfig `yaml:"deprovisionStates,omitempty" json:"deprovisionStates,omitempty"`
+
 	// FailureScenarios defines potential failure modes
 	FailureScenarios []FailureScenario `yaml:"failureScenarios" json:"failureScenarios"`
 
+	// Defaults apply to every resource of the type, below the API overrides of a resource
+	Defaults *ResourceDefaults `yaml:"defaults,omitempty" json:"defaults,omitempty"`
+
+	// Hibernation configures the power state transitions of installed ClusterDeployments
+	// (transitions are immediate when unset)
+	Hibernation *HibernationConfig `yaml:"hibernation,omitempty" json:"hibernation,omitempty"`
+
+	// InstallLogs sets an InstallLogsGathered condition on installed and failed
+	// ClusterDeployments, the way Hive reports log gathering (disabled when unset)
+	InstallLogs *InstallLogsConfig `yaml:"installLogs,omitempty" json:"installLogs,omitempty"`
+
+	// VersionSkew has installed ClusterDeployments report an older version than their image
+	// set and converge on it one minor version at a time, modeling a multi-hop upgrade
+	// (no cluster version is reported when unset)
+	VersionSkew *VersionSkewConfig `yaml:"versionSkew,omitempty" json:"versionSkew,omitempty"`
+
+	// AdminKubeconfig creates a <name>-admin-kubeconfig secret holding a fake kubeconfig and a
+	// <name>-admin-password secret holding the kubeadmin credentials when a ClusterDeployment
+	// reaches Running, and references them the way Hive does
+	AdminKubeconfig bool `yaml:"adminKubeconfig,omitempty" json:"adminKubeconfig,omitempty"`
+
+	// InstallPhases maps state names to the install phase a ClusterDeployment reports in the
+	// hive.openshift.io/install-phase annotation while in that state, e.g. "bootstrap". The
+	// annotation is removed in states without a phase.
+	InstallPhases map[string]string `yaml:"installPhases,omitempty" json:"installPhases,omitempty"`
+
 	// DependsOnAccountClaim if true, waits for AccountClaim to be Ready before progressing
 	DependsOnAccountClaim bool `yaml:"dependsOnAccountClaim" json:"dependsOnAccountClaim"`
 
 	// DependsOnProjectClaim if true, waits for ProjectClaim to be Ready before progressing
 	DependsOnProjectClaim bool `yaml:"dependsOnProjectClaim" json:"dependsOnProjectClaim"`
+
+	// DependsOnDNSZone if true, creates a <name>-zone DNSZone for the ClusterDeployment the way
+	// Hive does, and waits for it to be available before Installing
+	DependsOnDNSZone bool `yaml:"dependsOnDNSZone,omitempty" json:"dependsOnDNSZone,omitempty"`
+
+	// DependencyMatch is how claims are correlated with a ClusterDeployment: "label" (default)
+	// by the cluster ID label only, "owner" also by owner references when no claim is labeled
+	DependencyMatch string `yaml:"dependencyMatch,omitempty" json:"dependencyMatch,omitempty"`
+}
+
+const (
+	// DependencyMatchLabel correlates claims by the cluster ID label
+	DependencyMatchLabel = "label"
+
+	// DependencyMatchOwner also correlates claims owned by the ClusterDeployment, or owning it
+	DependencyMatchOwner = "owner"
+)
+
+// HibernationConfig configures how long hibernating and resuming a ClusterDeployment take
+type HibernationConfig struct {
+	// StoppingSeconds is how long a cluster stays Stopping before it is Hibernating
+	StoppingSeconds int `yaml:"stoppingSeconds" json:"stoppingSeconds"`
+
+	// ResumingSeconds is how long a cluster stays Resuming before it is Running
+	ResumingSeconds int `yaml:"resumingSeconds" json:"resumingSeconds"`
+}
+
+// VersionSkewConfig configures the version installed ClusterDeployments start from
+type VersionSkewConfig struct {
+	// InitialVersion is the version a ClusterDeployment reports once installed, e.g. "4.12.0".
+	// Clusters whose image set is not newer report the image set version.
+	InitialVersion string `yaml:"initialVersion" json:"initialVersion"`
+
+	// StepIntervalSeconds is how long a cluster stays on each minor version before moving
+	// to the next one
+	StepIntervalSeconds int `yaml:"stepIntervalSeconds" json:"stepIntervalSeconds"`
+}
+
+// InstallLogsConfig configures the simulated gathering of install logs
+type InstallLogsConfig struct {
+	// DelaySeconds is how long after install or failure the logs are gathered
+	DelaySeconds int `yaml:"delaySeconds" json:"delaySeconds"`
+
+	// Location is a fake logs location referenced by the condition, the namespace and
+	// name of the ClusterDeployment are appended to it (optional)
+	Location string `yaml:"location,omitempty" json:"location,omitempty"`
 }
 
 // AccountClaimConfig configures AccountClaim simulation behavior
@@ -38,8 +207,55 @@ type AccountClaimConfig struct {
 	// States defines the progression and timing for each state
 	States []StateConfig `yaml:"states" json:"states"`
 
+	// DelayDistribution varies state durations between resources (fixed when unset).
+	// A state's own distribution takes precedence.
+	DelayDistribution *DelayDistribution `yaml:"delayDistribution,omitempty" json:"delayDistribution,omitempty"`
+
+	// StartJitterSeconds spreads the first transition of new resources over this window,
+	// using a per-resource offset derived from the name (0 disables)
+	StartJitterSeconds int `yaml:"startJitterSeconds,omitempty" json:"startJitterSeconds,omitempty"`
+
+	// JitterPercent perturbs every configured transition delay by a random amount of up to
+	// this percentage either way, drawn from the seeded random source (0 keeps exact timings)
+	JitterPercent int `yaml:"jitterPercent,omitempty" json:"jitterPercent,omitempty"`
+
 	// FailureScenarios defines potential failure modes
 	FailureScenarios []FailureScenario `yaml:"failureScenarios" json:"failureScenarios"`
+
+	// Defaults apply to every resource of the type, below the API overrides of a resource
+	Defaults *ResourceDefaults `yaml:"defaults,omitempty" json:"defaults,omitempty"`
+
+	// CredentialSecret sets labels and annotations on the credentials secret created once Ready
+	CredentialSecret *CredentialSecretConfig `yaml:"credentialSecret,omitempty" json:"credentialSecret,omitempty"`
+
+	// Account configures the simulated AWS account linked to AccountClaims once Ready
+	Account *AccountConfig `yaml:"account,omitempty" json:"account,omitempty"`
+}
+
+// DefaultAccountLinkPrefix prefixes the names of simulated accounts, like the accounts of the
+// aws-account-operator
+const DefaultAccountLinkPrefix = "osd-creds-mgmt-"
+
+// AccountConfig configures the AWS account the simulated aws-account-operator links to Ready
+// AccountClaims
+type AccountConfig struct {
+	// AccountLinkPrefix prefixes the generated account name set in spec.accountLink
+	// (DefaultAccountLinkPrefix when unset)
+	AccountLinkPrefix string `yaml:"accountLinkPrefix,omitempty" json:"accountLinkPrefix,omitempty"`
+
+	// LegalEntityID and LegalEntityName are set in spec.legalEntity of claims that leave it
+	// empty (left empty when unset)
+	LegalEntityID   string `yaml:"legalEntityID,omitempty" json:"legalEntityID,omitempty"`
+	LegalEntityName string `yaml:"legalEntityName,omitempty" json:"legalEntityName,omitempty"`
+}
+
+// GetAccountLinkPrefix returns the configured account name prefix, falling back to
+// DefaultAccountLinkPrefix
+func (c *AccountConfig) GetAccountLinkPrefix() string {
+	if c == nil || c.AccountLinkPrefix == "" {
+		return DefaultAccountLinkPrefix
+	}
+	return c.AccountLinkPrefix
 }
 
 // ProjectClaimConfig configures ProjectClaim simulation behavior
@@ -50,8 +266,42 @@ type ProjectClaimConfig struct {
 	// States defines the progression and timing for each state
 	States []StateConfig `yaml:"states" json:"states"`
 
+	// DelayDistribution varies state durations between resources (fixed when unset).
+	// A state's own distribution takes precedence.
+	DelayDistribution *DelayDistribution `yaml:"delayDistribution,omitempty" json:"delayDistribution,omitempty"`
+
+	// StartJitterSeconds spreads the first transition of new resources over this window,
+	// using a per-resource offset derived from the name (0 disables)
+	StartJitterSeconds int `yaml:"startJitterSeconds,omitempty" json:"startJitterSeconds,omitempty"`
+
+	// JitterPercent perturbs every configured transition delay by a random amount of up to
+	// this percentage either way, drawn from the seeded random source (0 keeps exact timings)
+	JitterPercent int `yaml:"jitterPercent,omitempty" json:"jitterPercent,omitempty"`
+
 	// FailureScenarios defines potential failure modes
 	FailureScenarios []FailureScenario `yaml:"failureScenarios" json:"failureScenarios"`
+
+	// Defaults apply to every resource of the type, below the API overrides of a resource
+	Defaults *ResourceDefaults `yaml:"defaults,omitempty" json:"defaults,omitempty"`
+
+	// CredentialSecret sets labels and annotations on the credentials secret created once Ready
+	CredentialSecret *CredentialSecretConfig `yaml:"credentialSecret,omitempty" json:"credentialSecret,omitempty"`
+}
+
+// ResourceDefaults is the behavior every resource of a type defaults to. The API overrides of
+// a resource, by name, selector or wildcard, take precedence over it, and it takes precedence
+// over the rest of the configuration of the type.
+type ResourceDefaults struct {
+	// DelayMultiplier multiplies every configured transition delay, before jitter and the speed
+	// factor, e.g. 2 makes every state take twice as long (1 when unset)
+	DelayMultiplier float64 `yaml:"delayMultiplier,omitempty" json:"delayMultiplier,omitempty"`
+
+	// Failure is rolled with its probability before the failure scenarios
+	Failure *FailureScenario `yaml:"failure,omitempty" json:"failure,omitempty"`
+
+	// Conditions are set in every state that configures no conditions of its own, instead of
+	// the built-in conditions of the state
+	Conditions []ConditionConfig `yaml:"conditions,omitempty" json:"conditions,omitempty"`
 }
 
 // StateConfig defines a state and its duration
@@ -62,8 +312,45 @@ type StateConfig struct {
 	// DurationSeconds is how long to stay in this state
 	DurationSeconds int `yaml:"durationSeconds" json:"durationSeconds"`
 
+	// Distribution varies the duration of this state, overriding the resource-level delayDistribution
+	Distribution *DelayDistribution `yaml:"distribution,omitempty" json:"distribution,omitempty"`
+
 	// Conditions are additional conditions to set for this state
 	Conditions []ConditionConfig `yaml:"conditions,omitempty" json:"conditions,omitempty"`
+
+	// Next are the transitions out of this state, the first one that applies is taken. When
+	// none applies, the resource moves on to the following state in the list.
+	Next []TransitionConfig `yaml:"next,omitempty" json:"next,omitempty"`
+}
+
+// TransitionConfig is a conditional transition from one state to another of the same progression
+type TransitionConfig struct {
+	// To is the name of the target state
+	To string `yaml:"to" json:"to"`
+
+	// Labels restricts the transition to resources carrying all of these labels
+	Labels map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`
+
+	// Probability is the chance (0.0-1.0) of taking the transition, 0 always takes it
+	Probability float64 `yaml:"probability,omitempty" json:"probability,omitempty"`
+}
+
+// Delay distribution types
+const (
+	// DistributionFixed always uses the configured duration
+	DistributionFixed = "fixed"
+
+	// DistributionNormal samples a normal distribution centered on the configured duration
+	DistributionNormal = "normal"
+)
+
+// DelayDistribution describes how a configured duration varies between resources
+type DelayDistribution struct {
+	// Type is "fixed" (default) or "normal"
+	Type string `yaml:"type" json:"type"`
+
+	// StdDevSeconds is the standard deviation of a normal distribution
+	StdDevSeconds float64 `yaml:"stdDevSeconds,omitempty" json:"stdDevSeconds,omitempty"`
 }
 
 // ConditionConfig defines a condition to set on a resource
@@ -87,12 +374,104 @@ type FailureScenario struct {
 
 	// Reason is the failure reason
 	Reason string `yaml:"reason,omitempty" json:"reason,omitempty"`
+
+	// FailureScenarioRef names a built-in failure scenario (e.g. InstallAttemptsLimitReached)
+	// whose condition, reason and message are used for any field left empty
+	FailureScenarioRef string `yaml:"failureScenarioRef,omitempty" json:"failureScenarioRef,omitempty"`
+
+	// Stuck keeps a ClusterDeployment in Provisioning with the failure condition set,
+	// instead of failing it terminally
+	Stuck bool `yaml:"stuck,omitempty" json:"stuck,omitempty"`
+
+	// RecoverAfterSeconds clears a stuck failure after this delay, letting provisioning
+	// continue (0 stays stuck)
+	RecoverAfterSeconds int `yaml:"recoverAfterSeconds,omitempty" json:"recoverAfterSeconds,omitempty"`
+
+	// AtState only triggers the failure while the resource is in this state, e.g.
+	// "Installing" (any state when empty)
+	AtState string `yaml:"atState,omitempty" json:"atState,omitempty"`
+
+	// RetriesBeforeSuccess makes the failure transient: the resource gets the failure
+	// condition this many times, retrying in between, and then proceeds normally (0 fails
+	// it terminally)
+	RetriesBeforeSuccess int `yaml:"retriesBeforeSuccess,omitempty" json:"retriesBeforeSuccess,omitempty"`
+}
+
+// AppliesAt returns true if the failure can trigger while the resource is in the state
+func (f *FailureScenario) AppliesAt(state string) bool {
+	return f.AtState == "" || f.AtState == state
 }
 
 // ClusterImageSetConfig defines a ClusterImageSet to pre-populate
 type ClusterImageSetConfig struct {
 	Name    string `yaml:"name" json:"name"`
 	Visible bool   `yaml:"visible" json:"visible"`
+
+	// Labels are extra labels merged onto the created ClusterImageSet
+	Labels map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`
+
+	// Annotations are extra annotations merged onto the created ClusterImageSet
+	Annotations map[string]string `yaml:"annotations,omitempty" json:"annotations,omitempty"`
+}
+
+// FleetRampConfig configures automatic generation of ClusterDeployments at a fixed rate
+type FleetRampConfig struct {
+	// ClustersPerMinute is the rate at which ClusterDeployments are created
+	ClustersPerMinute float64 `yaml:"clustersPerMinute" json:"clustersPerMinute"`
+
+	// TargetCount is the number of ClusterDeployments after which the ramp stops
+	TargetCount int `yaml:"targetCount" json:"targetCount"`
+
+	// Namespace to create ClusterDeployments in (defaults to defaultNamespace)
+	Namespace string `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+
+	// NamePrefix is prepended to generated ClusterDeployment names (defaults to "fleet")
+	NamePrefix string `yaml:"namePrefix,omitempty" json:"namePrefix,omitempty"`
+}
+
+// SyncSetConfig configures the simulated application of SyncSets and SelectorSyncSets
+type SyncSetConfig struct {
+	// DelaySeconds is how long after a SyncSet is created or changed it is reported applied
+	DelaySeconds int `yaml:"delaySeconds" json:"delaySeconds"`
+
+	// SelectorSyncSets also reports SelectorSyncSets as applied to the ClusterDeployments they select
+	SelectorSyncSets bool `yaml:"selectorSyncSets,omitempty" json:"selectorSyncSets,omitempty"`
+
+	// Failures are resources that fail to apply, failing the SyncSets that contain them
+	Failures []SyncSetFailure `yaml:"failures,omitempty" json:"failures,omitempty"`
+}
+
+// SyncSetFailure is a resource of a SyncSet or SelectorSyncSet that fails to apply
+type SyncSetFailure struct {
+	// SyncSet is the name of the SyncSet or SelectorSyncSet
+	SyncSet string `yaml:"syncSet" json:"syncSet"`
+
+	// Resource is the failing resource as <kind>/<name>, e.g. "ConfigMap/cluster-settings"
+	Resource string `yaml:"resource" json:"resource"`
+
+	// Message is the reported failure message (optional)
+	Message string `yaml:"message,omitempty" json:"message,omitempty"`
+}
+
+// DNSZoneConfig configures the simulated DNSZone controller
+type DNSZoneConfig struct {
+	// DelaySeconds is how long after a DNSZone is created it is reported available
+	DelaySeconds int `yaml:"delaySeconds" json:"delaySeconds"`
+
+	// NameServers are reported in the status of available DNSZones (four fake name servers
+	// under the zone when empty)
+	NameServers []string `yaml:"nameServers,omitempty" json:"nameServers,omitempty"`
+}
+
+// ClusterPoolConfig configures the simulated ClusterPool and ClusterClaim controllers
+type ClusterPoolConfig struct {
+	// FillIntervalSeconds is how long a pool below its size waits after adding a
+	// ClusterDeployment before it adds the next one (0 adds all missing ones at once)
+	FillIntervalSeconds int `yaml:"fillIntervalSeconds" json:"fillIntervalSeconds"`
+
+	// ClaimDelaySeconds is how long after a ClusterClaim is created it is bound to an
+	// installed ClusterDeployment of its pool
+	ClaimDelaySeconds int `yaml:"claimDelaySeconds" json:"claimDelaySeconds"`
 }
 
 // ResourceOverride allows per-resource behavior overrides
@@ -108,6 +487,123 @@ type ResourceOverride struct {
 
 	// ForceSuccess forces this resource to succeed (overrides probability-based failures)
 	ForceSuccess bool `json:"forceSuccess,omitempty"`
+
+	// ExpiresAt is when the override stops applying (never when unset)
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+
+	// Paused holds this resource in its current state until it is resumed
+	Paused bool `json:"paused,omitempty"`
+
+	// AtState only forces the failure while the resource is in this state, taking precedence
+	// over the AtState of ForceFail (any state when both are empty)
+	AtState string `json:"atState,omitempty"`
+}
+
+// FailsAt returns true if the override forces a failure while the resource is in the state
+func (o *ResourceOverride) FailsAt(state string) bool {
+	if o.ForceFail == nil {
+		return false
+	}
+	if o.AtState != "" {
+		return o.AtState == state
+	}
+	return o.ForceFail.AppliesAt(state)
+}
+
+// Expired returns true if the override has an expiry time that has passed
+func (o *ResourceOverride) Expired(now time.Time) bool {
+	return o.ExpiresAt != nil && !now.Before(*o.ExpiresAt)
+}
+
+// SelectorOverride defines behavior overrides for all resources of a type matching a label selector
+type SelectorOverride struct {
+	// ResourceType is the kind of resources the override applies to, e.g. "ClusterDeployment"
+	ResourceType string `json:"resourceType"`
+
+	// LabelSelector selects the resources, e.g. "tier=canary" (an empty selector matches all)
+	LabelSelector string `json:"labelSelector"`
+
+	// DelaySeconds overrides the default delay
+	DelaySeconds *int `json:"delaySeconds,omitempty"`
+
+	// ForceFail fails the matching resources
+	ForceFail *FailureScenario `json:"forceFail,omitempty"`
+
+	// Probability of applying ForceFail to a matching resource (0 always applies it)
+	Probability float64 `json:"probability,omitempty"`
+}
+
+// GetDefaultNamespace returns the configured default namespace, falling back to "default"
+func (c *Config) GetDefaultNamespace() string {
+	if c.DefaultNamespace == "" {
+		return DefaultNamespaceName
+	}
+	return c.DefaultNamespace
+}
+
+// GetEventVerbosity returns the configured event verbosity, falling back to terminal
+func (c *Config) GetEventVerbosity() string {
+	if c.EventVerbosity == "" {
+		return EventVerbosityTerminal
+	}
+	return c.EventVerbosity
+}
+
+// GetSpeedFactor returns the configured speed factor, falling back to 1
+func (c *Config) GetSpeedFactor() float64 {
+	if c.SpeedFactor == 0 {
+		return 1
+	}
+	return c.SpeedFactor
+}
+
+// GetDelayMultiplier returns the configured delay multiplier, falling back to 1
+func (d *ResourceDefaults) GetDelayMultiplier() float64 {
+	if d == nil || d.DelayMultiplier == 0 {
+		return 1
+	}
+	return d.DelayMultiplier
+}
+
+// GetConditions returns the configured default conditions, nil without defaults
+func (d *ResourceDefaults) GetConditions() []ConditionConfig {
+	if d == nil {
+		return nil
+	}
+	return d.Conditions
+}
+
+// SetRunID validates and sets the run ID stamped on created resources
+func (c *Config) SetRunID(runID string) error {
+	if msgs := validation.IsValidLabelValue(runID); len(msgs) > 0 {
+		return errors.Errorf("run ID %q is invalid: %s", runID, strings.Join(msgs, ", "))
+	}
+	c.RunID = runID
+	return nil
+}
+
+// SetAPIResponseHeaders validates and adds API response headers given as Name=Value pairs,
+// replacing configured headers of the same name
+func (c *Config) SetAPIResponseHeaders(headers []string) error {
+	parsed := make(map[string]string, len(headers))
+	for _, header := range headers {
+		name, value, found := strings.Cut(header, "=")
+		if !found {
+			return errors.Errorf("API response header %q must be in Name=Value form", header)
+		}
+		if msgs := validation.IsHTTPHeaderName(name); len(msgs) > 0 {
+			return errors.Errorf("API response header name %q is invalid: %s", name, strings.Join(msgs, ", "))
+		}
+		parsed[name] = value
+	}
+
+	if c.APIResponseHeaders == nil {
+		c.APIResponseHeaders = make(map[string]string, len(parsed))
+	}
+	for name, value := range parsed {
+		c.APIResponseHeaders[name] = value
+	}
+	return nil
 }
 
 // GetTotalDuration returns the total duration for all states
@@ -195,6 +691,52 @@ func DefaultConfig() *Config {
 					},
 				},
 			},
+			AgentStates: []StateConfig{
+				{
+					Name:            "Pending",
+					DurationSeconds: 1,
+				},
+				{
+					Name:            "AgentWaiting",
+					DurationSeconds: 5,
+					Conditions: []ConditionConfig{
+						{
+							Type:    "ClusterInstallRequirementsMet",
+							Status:  "False",
+							Reason:  "AgentsNotReady",
+							Message: "Waiting for agents to register and be approved",
+						},
+					},
+				},
+				{
+					Name:            "Installing",
+					DurationSeconds: 2,
+					Conditions: []ConditionConfig{
+						{
+							Type:    "DNSNotReady",
+							Status:  "False",
+							Reason:  "DNSReady",
+							Message: "DNS is ready",
+						},
+					},
+				},
+				{
+					Name:            "Running",
+					DurationSeconds: 1,
+					Conditions: []ConditionConfig{
+						{
+							Type:    "ClusterDeploymentCompleted",
+							Status:  "True",
+							Reason:  "ClusterDeploymentCompleted",
+							Message: "Cluster deployment is complete",
+						},
+					},
+				},
+			},
+			Hibernation: &HibernationConfig{
+				StoppingSeconds: 2,
+				ResumingSeconds: 3,
+			},
 		},
 		AccountClaim: &AccountClaimConfig{
 			DefaultDelaySeconds: 3,
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"strings"
+	"text/template"
+
+	errors "github.com/zgalor/weberr"
+)
+
+// CredentialSecretConfig defines labels and annotations stamped onto the credentials secret of
+// a claim when it is created. Values are Go templates of the claim, see CredentialSecretData.
+type CredentialSecretConfig struct {
+	Labels      map[string]string `yaml:"labels,omitempty" json:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty" json:"annotations,omitempty"`
+}
+
+// CredentialSecretData is the claim data available to credentials secret templates, e.g.
+// "{{ .Name }}" for the name of the claim
+type CredentialSecretData struct {
+	Name      string
+	Namespace string
+
+	// ClusterID is the value of the api.openshift.com/id label of the claim
+	ClusterID string
+}
+
+// Render returns the labels and annotations with their values rendered for the claim
+func (c *CredentialSecretConfig) Render(data CredentialSecretData) (labels, annotations map[string]string, err error) {
+	labels, err = renderValues(c.Labels, data)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to render credentials secret labels")
+	}
+	annotations, err = renderValues(c.Annotations, data)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to render credentials secret annotations")
+	}
+	return labels, annotations, nil
+}
+
+// renderValues executes each value as a template of the data
+func renderValues(values map[string]string, data CredentialSecretData) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	rendered := make(map[string]string, len(values))
+	for key, value := range values {
+		tmpl, err := template.New(key).Option("missingkey=error").Parse(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid template for %s", key)
+		}
+		var out strings.Builder
+		if err := tmpl.Execute(&out, data); err != nil {
+			return nil, errors.Wrapf(err, "invalid template for %s", key)
+		}
+		rendered[key] = out.String()
+	}
+	return rendered, nil
+}
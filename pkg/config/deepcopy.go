@@ -0,0 +1,162 @@
+package config
+
+import (
+	"maps"
+	"slices"
+)
+
+// DeepCopy returns a copy of the configuration sharing no mutable state with it
+func (c *Config) DeepCopy() *Config {
+	if c == nil {
+		return nil
+	}
+	out := *c
+	out.ClusterDeployment = c.ClusterDeployment.DeepCopy()
+	out.AccountClaim = c.AccountClaim.DeepCopy()
+	out.ProjectClaim = c.ProjectClaim.DeepCopy()
+	if c.ClusterImageSets != nil {
+		out.ClusterImageSets = make([]ClusterImageSetConfig, len(c.ClusterImageSets))
+		for i, cis := range c.ClusterImageSets {
+			cis.Labels = maps.Clone(cis.Labels)
+			cis.Annotations = maps.Clone(cis.Annotations)
+			out.ClusterImageSets[i] = cis
+		}
+	}
+	if c.FleetRamp != nil {
+		fleetRamp := *c.FleetRamp
+		out.FleetRamp = &fleetRamp
+	}
+	if c.RandomSeed != nil {
+		seed := *c.RandomSeed
+		out.RandomSeed = &seed
+	}
+	out.APIResponseHeaders = maps.Clone(c.APIResponseHeaders)
+	if c.SyncSet != nil {
+		syncSet := *c.SyncSet
+		syncSet.Failures = slices.Clone(c.SyncSet.Failures)
+		out.SyncSet = &syncSet
+	}
+	if c.DNSZone != nil {
+		dnsZone := *c.DNSZone
+		dnsZone.NameServers = slices.Clone(c.DNSZone.NameServers)
+		out.DNSZone = &dnsZone
+	}
+	if c.ClusterPool != nil {
+		clusterPool := *c.ClusterPool
+		out.ClusterPool = &clusterPool
+	}
+	return &out
+}
+
+// DeepCopy returns a copy of the ClusterDeployment configuration sharing no mutable state with it
+func (c *ClusterDeploymentConfig) DeepCopy() *ClusterDeploymentConfig {
+	if c == nil {
+		return nil
+	}
+	out := *c
+	out.States = copyStates(c.States)
+	out.DelayDistribution = copyDistribution(c.DelayDistribution)
+	out.AgentStates = copyStates(c.AgentStates)
+	out.DeprovisionStates = copyStates(c.DeprovisionStates)
+	out.FailureScenarios = slices.Clone(c.FailureScenarios)
+	out.Defaults = c.Defaults.DeepCopy()
+	out.InstallPhases = maps.Clone(c.InstallPhases)
+	if c.Hibernation != nil {
+		hibernation := *c.Hibernation
+		out.Hibernation = &hibernation
+	}
+	if c.InstallLogs != nil {
+		installLogs := *c.InstallLogs
+		out.InstallLogs = &installLogs
+	}
+	if c.VersionSkew != nil {
+		versionSkew := *c.VersionSkew
+		out.VersionSkew = &versionSkew
+	}
+	return &out
+}
+
+// DeepCopy returns a copy of the AccountClaim configuration sharing no mutable state with it
+func (c *AccountClaimConfig) DeepCopy() *AccountClaimConfig {
+	if c == nil {
+		return nil
+	}
+	out := *c
+	out.States = copyStates(c.States)
+	out.DelayDistribution = copyDistribution(c.DelayDistribution)
+	out.FailureScenarios = slices.Clone(c.FailureScenarios)
+	out.Defaults = c.Defaults.DeepCopy()
+	out.CredentialSecret = c.CredentialSecret.DeepCopy()
+	if c.Account != nil {
+		account := *c.Account
+		out.Account = &account
+	}
+	return &out
+}
+
+// DeepCopy returns a copy of the ProjectClaim configuration sharing no mutable state with it
+func (c *ProjectClaimConfig) DeepCopy() *ProjectClaimConfig {
+	if c == nil {
+		return nil
+	}
+	out := *c
+	out.States = copyStates(c.States)
+	out.DelayDistribution = copyDistribution(c.DelayDistribution)
+	out.FailureScenarios = slices.Clone(c.FailureScenarios)
+	out.Defaults = c.Defaults.DeepCopy()
+	out.CredentialSecret = c.CredentialSecret.DeepCopy()
+	return &out
+}
+
+// DeepCopy returns a copy of the resource defaults sharing no mutable state with them
+func (d *ResourceDefaults) DeepCopy() *ResourceDefaults {
+	if d == nil {
+		return nil
+	}
+	out := *d
+	if d.Failure != nil {
+		failure := *d.Failure
+		out.Failure = &failure
+	}
+	out.Conditions = slices.Clone(d.Conditions)
+	return &out
+}
+
+// DeepCopy returns a copy of the credentials secret configuration sharing no mutable state with it
+func (c *CredentialSecretConfig) DeepCopy() *CredentialSecretConfig {
+	if c == nil {
+		return nil
+	}
+	return &CredentialSecretConfig{
+		Labels:      maps.Clone(c.Labels),
+		Annotations: maps.Clone(c.Annotations),
+	}
+}
+
+func copyStates(states []StateConfig) []StateConfig {
+	if states == nil {
+		return nil
+	}
+	out := make([]StateConfig, len(states))
+	for i, state := range states {
+		state.Distribution = copyDistribution(state.Distribution)
+		state.Conditions = slices.Clone(state.Conditions)
+		if state.Next != nil {
+			state.Next = make([]TransitionConfig, len(states[i].Next))
+			for j, transition := range states[i].Next {
+				transition.Labels = maps.Clone(transition.Labels)
+				state.Next[j] = transition
+			}
+		}
+		out[i] = state
+	}
+	return out
+}
+
+func copyDistribution(dist *DelayDistribution) *DelayDistribution {
+	if dist == nil {
+		return nil
+	}
+	out := *dist
+	return &out
+}
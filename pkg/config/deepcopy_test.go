@@ -0,0 +1,62 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_DeepCopy(t *testing.T) {
+	seed := int64(42)
+	cfg := DefaultConfig()
+	cfg.RandomSeed = &seed
+	cfg.FleetRamp = &FleetRampConfig{ClustersPerMinute: 1, TargetCount: 10}
+	cfg.APIResponseHeaders = map[string]string{"X-Hive-Sim-Instance": "sim-1"}
+	cfg.ClusterImageSets[0].Labels = map[string]string{"team": "qe"}
+	cfg.ClusterDeployment.Hibernation = &HibernationConfig{StoppingSeconds: 5}
+	cfg.SyncSet = &SyncSetConfig{Failures: []SyncSetFailure{{SyncSet: "ss", Resource: "ConfigMap/cm"}}}
+	cfg.DNSZone = &DNSZoneConfig{NameServers: []string{"ns1.example.com"}}
+	cfg.ClusterPool = &ClusterPoolConfig{FillIntervalSeconds: 10}
+	cfg.ClusterDeployment.States[0].Distribution = &DelayDistribution{Type: DistributionNormal, StdDevSeconds: 1}
+	cfg.ClusterDeployment.States[1].Next = []TransitionConfig{{To: "Running", Labels: map[string]string{"scenario": "fast"}}}
+	cfg.ProjectClaim.Defaults = &ResourceDefaults{
+		Failure:    &FailureScenario{Probability: 0.1, Message: "fails"},
+		Conditions: []ConditionConfig{{Type: "Ready", Status: "False"}},
+	}
+
+	copied := cfg.DeepCopy()
+	assert.Equal(t, cfg, copied)
+
+	// Changing the copy leaves the original untouched
+	*copied.RandomSeed = 7
+	copied.FleetRamp.TargetCount = 20
+	copied.APIResponseHeaders["X-Hive-Sim-Instance"] = "sim-2"
+	copied.ClusterImageSets[0].Labels["team"] = "dev"
+	copied.ClusterDeployment.Hibernation.StoppingSeconds = 10
+	copied.SyncSet.Failures[0].Resource = "Secret/s"
+	copied.DNSZone.NameServers[0] = "ns2.example.com"
+	copied.ClusterPool.FillIntervalSeconds = 20
+	copied.ClusterDeployment.States[0].Distribution.StdDevSeconds = 2
+	copied.ClusterDeployment.States[1].Conditions[0].Status = "True"
+	copied.ClusterDeployment.States[1].Next[0].Labels["scenario"] = "slow"
+	copied.AccountClaim.States[0].DurationSeconds = 100
+	copied.ProjectClaim.Defaults.Failure.Probability = 1
+	copied.ProjectClaim.Defaults.Conditions[0].Status = "True"
+	copied.ProjectClaim.FailureScenarios = append(copied.ProjectClaim.FailureScenarios, FailureScenario{Probability: 1})
+
+	assert.Equal(t, int64(42), *cfg.RandomSeed)
+	assert.Equal(t, 10, cfg.FleetRamp.TargetCount)
+	assert.Equal(t, "sim-1", cfg.APIResponseHeaders["X-Hive-Sim-Instance"])
+	assert.Equal(t, "qe", cfg.ClusterImageSets[0].Labels["team"])
+	assert.Equal(t, 5, cfg.ClusterDeployment.Hibernation.StoppingSeconds)
+	assert.Equal(t, "ConfigMap/cm", cfg.SyncSet.Failures[0].Resource)
+	assert.Equal(t, "ns1.example.com", cfg.DNSZone.NameServers[0])
+	assert.Equal(t, 10, cfg.ClusterPool.FillIntervalSeconds)
+	assert.Equal(t, float64(1), cfg.ClusterDeployment.States[0].Distribution.StdDevSeconds)
+	assert.Equal(t, "False", cfg.ClusterDeployment.States[1].Conditions[0].Status)
+	assert.Equal(t, "fast", cfg.ClusterDeployment.States[1].Next[0].Labels["scenario"])
+	assert.NotEqual(t, 100, cfg.AccountClaim.States[0].DurationSeconds)
+	assert.Equal(t, 0.1, cfg.ProjectClaim.Defaults.Failure.Probability)
+	assert.Equal(t, "False", cfg.ProjectClaim.Defaults.Conditions[0].Status)
+	assert.Len(t, cfg.ProjectClaim.FailureScenarios, len(DefaultConfig().ProjectClaim.FailureScenarios))
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+// Environment variables overriding configuration values, for deployments where setting the
+// environment is easier than mounting a configuration file
+const (
+	EnvClusterDeploymentDefaultDelaySeconds = "HIVESIM_CLUSTERDEPLOYMENT_DEFAULT_DELAY_SECONDS"
+	EnvAccountClaimDefaultDelaySeconds      = "HIVESIM_ACCOUNTCLAIM_DEFAULT_DELAY_SECONDS"
+	EnvProjectClaimDefaultDelaySeconds      = "HIVESIM_PROJECTCLAIM_DEFAULT_DELAY_SECONDS"
+	EnvDependsOnAccountClaim                = "HIVESIM_CLUSTERDEPLOYMENT_DEPENDS_ON_ACCOUNTCLAIM"
+	EnvDependsOnProjectClaim                = "HIVESIM_CLUSTERDEPLOYMENT_DEPENDS_ON_PROJECTCLAIM"
+	EnvRandomSeed                           = "HIVESIM_RANDOM_SEED"
+)
+
+// applyEnvOverrides sets the configuration values given in environment variables, which take
+// precedence over the file. Empty variables are ignored, invalid ones are all reported at once.
+func applyEnvOverrides(cfg *Config) error {
+	if cfg.ClusterDeployment == nil {
+		cfg.ClusterDeployment = DefaultConfig().ClusterDeployment
+	}
+	if cfg.AccountClaim == nil {
+		cfg.AccountClaim = DefaultConfig().AccountClaim
+	}
+	if cfg.ProjectClaim == nil {
+		cfg.ProjectClaim = DefaultConfig().ProjectClaim
+	}
+
+	errs := &ValidationErrors{}
+	envInt(errs, EnvClusterDeploymentDefaultDelaySeconds, &cfg.ClusterDeployment.DefaultDelaySeconds)
+	envInt(errs, EnvAccountClaimDefaultDelaySeconds, &cfg.AccountClaim.DefaultDelaySeconds)
+	envInt(errs, EnvProjectClaimDefaultDelaySeconds, &cfg.ProjectClaim.DefaultDelaySeconds)
+	envBool(errs, EnvDependsOnAccountClaim, &cfg.ClusterDeployment.DependsOnAccountClaim)
+	envBool(errs, EnvDependsOnProjectClaim, &cfg.ClusterDeployment.DependsOnProjectClaim)
+
+	if value := os.Getenv(EnvRandomSeed); value != "" {
+		seed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			errs.add("%s=%q must be an integer", EnvRandomSeed, value)
+		} else {
+			cfg.RandomSeed = &seed
+		}
+	}
+
+	if len(errs.Errors) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// envInt sets target from an integer environment variable
+func envInt(errs *ValidationErrors, name string, target *int) {
+	value := os.Getenv(name)
+	if value == "" {
+		return
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		errs.add("%s=%q must be an integer", name, value)
+		return
+	}
+	*target = parsed
+}
+
+// envBool sets target from a boolean environment variable
+func envBool(errs *ValidationErrors, name string, target *bool) {
+	value := os.Getenv(name)
+	if value == "" {
+		return
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		errs.add("%s=%q must be true or false", name, value)
+		return
+	}
+	*target = parsed
+}
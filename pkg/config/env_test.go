@@ -0,0 +1,70 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFromFile_EnvOverrides(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+clusterDeployment:
+  defaultDelaySeconds: 10
+  dependsOnAccountClaim: false
+  dependsOnProjectClaim: true
+accountClaim:
+  defaultDelaySeconds: 5
+randomSeed: 1
+`), 0644))
+
+	t.Setenv(EnvClusterDeploymentDefaultDelaySeconds, "30")
+	t.Setenv(EnvAccountClaimDefaultDelaySeconds, "")
+	t.Setenv(EnvProjectClaimDefaultDelaySeconds, "7")
+	t.Setenv(EnvDependsOnAccountClaim, "true")
+	t.Setenv(EnvDependsOnProjectClaim, "false")
+	t.Setenv(EnvRandomSeed, "42")
+
+	cfg, _, err := LoadFromFile(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, 30, cfg.ClusterDeployment.DefaultDelaySeconds)
+	assert.Equal(t, 5, cfg.AccountClaim.DefaultDelaySeconds, "empty variables are ignored")
+	assert.Equal(t, 7, cfg.ProjectClaim.DefaultDelaySeconds)
+	assert.True(t, cfg.ClusterDeployment.DependsOnAccountClaim)
+	assert.False(t, cfg.ClusterDeployment.DependsOnProjectClaim)
+	require.NotNil(t, cfg.RandomSeed)
+	assert.Equal(t, int64(42), *cfg.RandomSeed)
+}
+
+func TestLoadFromFile_EnvOverridesWithoutFile(t *testing.T) {
+	t.Setenv(EnvClusterDeploymentDefaultDelaySeconds, "30")
+
+	cfg, _, err := LoadFromFile("")
+	require.NoError(t, err)
+	assert.Equal(t, 30, cfg.ClusterDeployment.DefaultDelaySeconds)
+}
+
+func TestLoadFromFile_InvalidEnvOverrides(t *testing.T) {
+	t.Setenv(EnvClusterDeploymentDefaultDelaySeconds, "thirty")
+	t.Setenv(EnvDependsOnAccountClaim, "maybe")
+	t.Setenv(EnvRandomSeed, "1.5")
+
+	_, _, err := LoadFromFile("")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `HIVESIM_CLUSTERDEPLOYMENT_DEFAULT_DELAY_SECONDS="thirty" must be an integer`)
+	assert.Contains(t, err.Error(), `HIVESIM_CLUSTERDEPLOYMENT_DEPENDS_ON_ACCOUNTCLAIM="maybe" must be true or false`)
+	assert.Contains(t, err.Error(), `HIVESIM_RANDOM_SEED="1.5" must be an integer`)
+}
+
+func TestLoadFromFile_EnvOverridesAreValidated(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("clusterDeployment:\n  defaultDelaySeconds: 10\n"), 0644))
+	t.Setenv(EnvClusterDeploymentDefaultDelaySeconds, "-1")
+
+	_, _, err := LoadFromFile(configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ClusterDeployment defaultDelaySeconds must be >= 0")
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"sort"
+)
+
+// builtinFailureScenarios is a library of realistic failure modes keyed by name,
+// using the condition types and reasons reported by a real Hive installation
+var builtinFailureScenarios = map[string]FailureScenario{
+	"InstallAttemptsLimitReached": {
+		Condition: "ProvisionStopped",
+		Reason:    "InstallAttemptsLimitReached",
+		Message:   "Install attempts limit reached",
+	},
+	"KubeconfigSecretMissing": {
+		Condition: "Unreachable",
+		Reason:    "KubeconfigSecretMissing",
+		Message:   "Admin kubeconfig secret is missing",
+	},
+	"AuthenticationFailed": {
+		Condition: "AuthenticationFailure",
+		Reason:    "AuthenticationFailed",
+		Message:   "Credentials are invalid",
+	},
+	"DNSNotReadyTimedOut": {
+		Condition: "DNSNotReady",
+		Reason:    "DNSNotReadyTimedOut",
+		Message:   "DNS setup did not complete within the timeout",
+	},
+	"ClusterImageSetNotFound": {
+		Condition: "RequirementsMet",
+		Reason:    "ClusterImageSetNotFound",
+		Message:   "ClusterImageSet was not found",
+	},
+	"InstallImagesNotResolved": {
+		Condition: "InstallImagesNotResolved",
+		Reason:    "JobToResolveImagesFailed",
+		Message:   "Failed to resolve the installer images",
+	},
+	"InsufficientCapacity": {
+		Condition: "ProvisionFailed",
+		Reason:    "InsufficientCapacity",
+		Message:   "Insufficient capacity in the requested availability zone",
+	},
+	"QuotaExceeded": {
+		Condition: "ProvisionFailed",
+		Reason:    "QuotaExceeded",
+		Message:   "Cloud provider quota exceeded, waiting for a quota increase",
+		Stuck:     true,
+	},
+	"AccountLimitExceeded": {
+		Condition: "ProvisionFailed",
+		Reason:    "AccountLimitExceeded",
+		Message:   "The AWS organization account limit has been reached",
+	},
+}
+
+// LookupFailureScenario returns the built-in failure scenario registered under name
+func LookupFailureScenario(name string) (FailureScenario, bool) {
+	scenario, ok := builtinFailureScenarios[name]
+	return scenario, ok
+}
+
+// BuiltinFailureScenarioNames returns the sorted names of all built-in failure scenarios
+func BuiltinFailureScenarioNames() []string {
+	names := make([]string, 0, len(builtinFailureScenarios))
+	for name := range builtinFailureScenarios {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolveFailureScenarioRef fills in the condition, reason and message of a scenario
+// from the built-in library. Fields set explicitly on the scenario take precedence, and
+// scenarios referencing a stuck built-in are stuck as well.
+// Returns false if the scenario references an unknown name.
+func resolveFailureScenarioRef(scenario *FailureScenario) bool {
+	if scenario.FailureScenarioRef == "" {
+		return true
+	}
+	builtin, ok := LookupFailureScenario(scenario.FailureScenarioRef)
+	if !ok {
+		return false
+	}
+	if scenario.Condition == "" {
+		scenario.Condition = builtin.Condition
+	}
+	if scenario.Reason == "" {
+		scenario.Reason = builtin.Reason
+	}
+	if scenario.Message == "" {
+		scenario.Message = builtin.Message
+	}
+	if builtin.Stuck {
+		scenario.Stuck = true
+	}
+	return true
+}
@@ -1,42 +1,125 @@
 package config
 
 import (
+	"bytes"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/version"
+	"sigs.k8s.io/json"
 
 	errors "github.com/zgalor/weberr"
 )
 
-// LoadFromFile loads configuration from a YAML file
-func LoadFromFile(path string) (*Config, error) {
+// LoadFromFile loads configuration from a YAML or JSON file, with values overridden by the
+// HIVESIM_* environment variables. Fields the configuration does not have are rejected.
+// Settings that are valid but have no effect are returned as warnings.
+func LoadFromFile(path string) (*Config, []string, error) {
 	// If no path provided, return default config
 	if path == "" {
-		return DefaultConfig(), nil
+		cfg := DefaultConfig()
+		if err := applyEnvOverrides(cfg); err != nil {
+			return nil, nil, errors.Wrapf(err, "invalid environment overrides")
+		}
+		warnings, err := validate(cfg)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "invalid configuration")
+		}
+		return cfg, warnings, nil
 	}
 
 	// Read file
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to read config file %s", path)
+		return nil, nil, errors.Wrapf(err, "failed to read config file %s", path)
 	}
 
-	// Parse YAML
+	// Parse YAML or JSON
 	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return nil, errors.Wrapf(err, "failed to parse config file %s", path)
+	if err := decode(path, data, &cfg); err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to parse config file %s", path)
+	}
+
+	// Apply environment overrides before validating the result
+	if err := applyEnvOverrides(&cfg); err != nil {
+		return nil, nil, errors.Wrapf(err, "invalid environment overrides")
 	}
 
 	// Validate configuration
-	if err := validate(&cfg); err != nil {
-		return nil, errors.Wrapf(err, "invalid configuration")
+	warnings, err := validate(&cfg)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "invalid configuration")
+	}
+
+	return &cfg, warnings, nil
+}
+
+// decode parses a configuration file, as JSON when it has the .json extension and as YAML
+// otherwise. Unknown fields are an error naming the field, so that a misspelled setting is
+// not silently ignored. Field names are matched case-sensitively in both formats, unlike
+// encoding/json, which would accept a field whose case is wrong.
+func decode(path string, data []byte, cfg *Config) error {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		strictErrs, err := json.UnmarshalStrict(data, cfg)
+		if err != nil {
+			return err
+		}
+		if len(strictErrs) > 0 {
+			messages := make([]string, len(strictErrs))
+			for i, strictErr := range strictErrs {
+				messages[i] = strictErr.Error()
+			}
+			return errors.Errorf("%s", strings.Join(messages, "; "))
+		}
+		return nil
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && err != io.EOF {
+		return err
 	}
+	return nil
+}
+
+// ValidationErrors aggregates every problem found while validating a configuration, and the
+// warnings about settings that are valid but have no effect
+type ValidationErrors struct {
+	Errors   []string
+	warnings []string
+}
+
+// Error implements the error interface
+func (v *ValidationErrors) Error() string {
+	return strings.Join(v.Errors, "; ")
+}
+
+// add records a validation problem
+func (v *ValidationErrors) add(format string, args ...interface{}) {
+	v.Errors = append(v.Errors, fmt.Sprintf(format, args...))
+}
+
+// warn records a setting that is valid but has no effect
+func (v *ValidationErrors) warn(format string, args ...interface{}) {
+	v.warnings = append(v.warnings, fmt.Sprintf(format, args...))
+}
 
-	return &cfg, nil
+// Validate validates a configuration that was not loaded with LoadFromFile, e.g. one received
+// through the API. Like LoadFromFile, it fills in the defaults of missing sections and returns
+// the warnings about settings that have no effect.
+func Validate(cfg *Config) ([]string, error) {
+	return validate(cfg)
 }
 
-// validate validates the configuration
-func validate(cfg *Config) error {
+// validate validates the configuration, reporting all problems at once. Warnings are returned
+// whether or not the configuration is valid.
+func validate(cfg *Config) ([]string, error) {
 	// Ensure we have ClusterDeployment config
 	if cfg.ClusterDeployment == nil {
 		cfg.ClusterDeployment = DefaultConfig().ClusterDeployment
@@ -57,50 +140,438 @@ func validate(cfg *Config) error {
 		cfg.ClusterImageSets = DefaultConfig().ClusterImageSets
 	}
 
+	errs := &ValidationErrors{}
+
 	// Validate delay values are positive
 	if cfg.ClusterDeployment.DefaultDelaySeconds < 0 {
-		return errors.Errorf("ClusterDeployment defaultDelaySeconds must be >= 0")
+		errs.add("ClusterDeployment defaultDelaySeconds must be >= 0")
 	}
 	if cfg.AccountClaim.DefaultDelaySeconds < 0 {
-		return errors.Errorf("AccountClaim defaultDelaySeconds must be >= 0")
+		errs.add("AccountClaim defaultDelaySeconds must be >= 0")
 	}
 	if cfg.ProjectClaim.DefaultDelaySeconds < 0 {
-		return errors.Errorf("ProjectClaim defaultDelaySeconds must be >= 0")
+		errs.add("ProjectClaim defaultDelaySeconds must be >= 0")
+	}
+
+	if cfg.ClusterDeployment.StartJitterSeconds < 0 {
+		errs.add("ClusterDeployment startJitterSeconds must be >= 0")
+	}
+	if cfg.AccountClaim.StartJitterSeconds < 0 {
+		errs.add("AccountClaim startJitterSeconds must be >= 0")
+	}
+	if cfg.ProjectClaim.StartJitterSeconds < 0 {
+		errs.add("ProjectClaim startJitterSeconds must be >= 0")
+	}
+
+	if cfg.ClusterDeployment.JitterPercent < 0 || cfg.ClusterDeployment.JitterPercent > 100 {
+		errs.add("ClusterDeployment jitterPercent must be between 0 and 100")
+	}
+	if cfg.AccountClaim.JitterPercent < 0 || cfg.AccountClaim.JitterPercent > 100 {
+		errs.add("AccountClaim jitterPercent must be between 0 and 100")
+	}
+	if cfg.ProjectClaim.JitterPercent < 0 || cfg.ProjectClaim.JitterPercent > 100 {
+		errs.add("ProjectClaim jitterPercent must be between 0 and 100")
+	}
+
+	if cfg.ClusterDeployment.Hibernation != nil {
+		if cfg.ClusterDeployment.Hibernation.StoppingSeconds < 0 {
+			errs.add("ClusterDeployment hibernation stoppingSeconds must be >= 0")
+		}
+		if cfg.ClusterDeployment.Hibernation.ResumingSeconds < 0 {
+			errs.add("ClusterDeployment hibernation resumingSeconds must be >= 0")
+		}
+	}
+
+	if cfg.ClusterDeployment.InstallLogs != nil && cfg.ClusterDeployment.InstallLogs.DelaySeconds < 0 {
+		errs.add("ClusterDeployment installLogs delaySeconds must be >= 0")
+	}
+
+	if skew := cfg.ClusterDeployment.VersionSkew; skew != nil {
+		if _, err := version.ParseGeneric(skew.InitialVersion); err != nil {
+			errs.add("ClusterDeployment versionSkew initialVersion %q is invalid: %v", skew.InitialVersion, err)
+		}
+		if skew.StepIntervalSeconds < 0 {
+			errs.add("ClusterDeployment versionSkew stepIntervalSeconds must be >= 0")
+		}
+	}
+
+	if cfg.ProbeTimeRefreshSeconds < 0 {
+		errs.add("probeTimeRefreshSeconds must be >= 0")
+	}
+
+	if cfg.TerminalResourceTTLSeconds < 0 {
+		errs.add("terminalResourceTTLSeconds must be >= 0")
+	}
+
+	if cfg.FailedResourceTTLSeconds < 0 {
+		errs.add("failedResourceTTLSeconds must be >= 0")
+	}
+
+	if cfg.DefaultNamespace != "" {
+		if msgs := validation.IsDNS1123Label(cfg.DefaultNamespace); len(msgs) > 0 {
+			errs.add("defaultNamespace %q is invalid: %s", cfg.DefaultNamespace, strings.Join(msgs, ", "))
+		}
+	}
+
+	for name := range cfg.APIResponseHeaders {
+		if msgs := validation.IsHTTPHeaderName(name); len(msgs) > 0 {
+			errs.add("apiResponseHeaders name %q is invalid: %s", name, strings.Join(msgs, ", "))
+		}
+	}
+
+	switch cfg.EventVerbosity {
+	case "", EventVerbosityNone, EventVerbosityFailures, EventVerbosityTerminal, EventVerbosityAll:
+	default:
+		errs.add("eventVerbosity: unknown value %q (expected %s, %s, %s or %s)", cfg.EventVerbosity,
+			EventVerbosityNone, EventVerbosityFailures, EventVerbosityTerminal, EventVerbosityAll)
+	}
+
+	if cfg.SpeedFactor < 0 {
+		errs.add("speedFactor must be > 0")
+	}
+
+	if cfg.FleetRamp != nil {
+		if cfg.FleetRamp.ClustersPerMinute <= 0 {
+			errs.add("fleetRamp clustersPerMinute must be > 0")
+		}
+		if cfg.FleetRamp.TargetCount < 0 {
+			errs.add("fleetRamp targetCount must be >= 0")
+		}
+		if cfg.FleetRamp.Namespace != "" {
+			if msgs := validation.IsDNS1123Label(cfg.FleetRamp.Namespace); len(msgs) > 0 {
+				errs.add("fleetRamp namespace %q is invalid: %s", cfg.FleetRamp.Namespace, strings.Join(msgs, ", "))
+			}
+		}
+	}
+
+	if cfg.SyncSet != nil {
+		if cfg.SyncSet.DelaySeconds < 0 {
+			errs.add("syncSet delaySeconds must be >= 0")
+		}
+		for i, failure := range cfg.SyncSet.Failures {
+			if failure.SyncSet == "" {
+				errs.add("syncSet failure %d: syncSet is required", i)
+			}
+			if kind, name, found := strings.Cut(failure.Resource, "/"); !found || kind == "" || name == "" {
+				errs.add("syncSet failure %d: resource %q must be in <kind>/<name> form", i, failure.Resource)
+			}
+		}
+	}
+
+	if cfg.DNSZone != nil {
+		if cfg.DNSZone.DelaySeconds < 0 {
+			errs.add("dnsZone delaySeconds must be >= 0")
+		}
+		for i, nameServer := range cfg.DNSZone.NameServers {
+			if msgs := validation.IsDNS1123Subdomain(nameServer); len(msgs) > 0 {
+				errs.add("dnsZone nameServer %d %q is invalid: %s", i, nameServer, strings.Join(msgs, ", "))
+			}
+		}
+	}
+
+	if cfg.ClusterPool != nil {
+		if cfg.ClusterPool.FillIntervalSeconds < 0 {
+			errs.add("clusterPool fillIntervalSeconds must be >= 0")
+		}
+		if cfg.ClusterPool.ClaimDelaySeconds < 0 {
+			errs.add("clusterPool claimDelaySeconds must be >= 0")
+		}
+	}
+
+	if cfg.RunID != "" {
+		if msgs := validation.IsValidLabelValue(cfg.RunID); len(msgs) > 0 {
+			errs.add("runID %q is invalid: %s", cfg.RunID, strings.Join(msgs, ", "))
+		}
+	}
+
+	switch cfg.ClusterDeployment.DependencyMatch {
+	case "", DependencyMatchLabel, DependencyMatchOwner:
+	default:
+		errs.add("ClusterDeployment dependencyMatch: unknown value %q (expected %s or %s)",
+			cfg.ClusterDeployment.DependencyMatch, DependencyMatchLabel, DependencyMatchOwner)
+	}
+	if cfg.ClusterDeployment.DependsOnDNSZone && cfg.DNSZone == nil {
+		errs.add("ClusterDeployment dependsOnDNSZone requires dnsZone to be configured, or DNSZones never become available")
+	}
+
+	// Validate the state progressions, a ClusterDeployment has to end up installed
+	validateStateProgression(errs, cfg.ClusterDeployment.States, cfg.ClusterDeployment.DefaultDelaySeconds, "ClusterDeployment states")
+	validateTerminalState(errs, cfg.ClusterDeployment.States, "ClusterDeployment states")
+	if len(cfg.ClusterDeployment.AgentStates) > 0 {
+		validateStateProgression(errs, cfg.ClusterDeployment.AgentStates, 0, "ClusterDeployment agentStates")
+		validateTerminalState(errs, cfg.ClusterDeployment.AgentStates, "ClusterDeployment agentStates")
+	}
+	if len(cfg.ClusterDeployment.DeprovisionStates) > 0 {
+		validateStateProgression(errs, cfg.ClusterDeployment.DeprovisionStates, 0, "ClusterDeployment deprovisionStates")
 	}
+	validateStateProgression(errs, cfg.AccountClaim.States, cfg.AccountClaim.DefaultDelaySeconds, "AccountClaim states")
+	validateStateProgression(errs, cfg.ProjectClaim.States, cfg.ProjectClaim.DefaultDelaySeconds, "ProjectClaim states")
 
 	// Validate state durations
+	validateDelayDistribution(errs, cfg.ClusterDeployment.DelayDistribution, "ClusterDeployment delayDistribution")
 	for _, state := range cfg.ClusterDeployment.States {
 		if state.DurationSeconds < 0 {
-			return errors.Errorf("ClusterDeployment state %s duration must be >= 0", state.Name)
+			errs.add("ClusterDeployment state %s duration must be >= 0", state.Name)
+		}
+		validateDelayDistribution(errs, state.Distribution, fmt.Sprintf("ClusterDeployment state %s distribution", state.Name))
+	}
+	for _, state := range cfg.ClusterDeployment.AgentStates {
+		if state.DurationSeconds < 0 {
+			errs.add("ClusterDeployment agent state %s duration must be >= 0", state.Name)
+		}
+		validateDelayDistribution(errs, state.Distribution, fmt.Sprintf("ClusterDeployment agent state %s distribution", state.Name))
+	}
+	for _, state := range cfg.ClusterDeployment.DeprovisionStates {
+		if state.DurationSeconds < 0 {
+			errs.add("ClusterDeployment deprovision state %s duration must be >= 0", state.Name)
+		}
+		validateDelayDistribution(errs, state.Distribution, fmt.Sprintf("ClusterDeployment deprovision state %s distribution", state.Name))
+	}
+	for state := range cfg.ClusterDeployment.InstallPhases {
+		if !slices.ContainsFunc(cfg.ClusterDeployment.States, stateNamed(state)) &&
+			!slices.ContainsFunc(cfg.ClusterDeployment.AgentStates, stateNamed(state)) {
+			errs.add("ClusterDeployment installPhases references unknown state %s", state)
 		}
 	}
+	validateDelayDistribution(errs, cfg.AccountClaim.DelayDistribution, "AccountClaim delayDistribution")
 	for _, state := range cfg.AccountClaim.States {
 		if state.DurationSeconds < 0 {
-			return errors.Errorf("AccountClaim state %s duration must be >= 0", state.Name)
+			errs.add("AccountClaim state %s duration must be >= 0", state.Name)
+		}
+		validateDelayDistribution(errs, state.Distribution, fmt.Sprintf("AccountClaim state %s distribution", state.Name))
+	}
+	validateCredentialSecret(errs, cfg.AccountClaim.CredentialSecret, "AccountClaim credentialSecret")
+	if account := cfg.AccountClaim.Account; account != nil {
+		if msgs := validation.IsDNS1123Subdomain(account.GetAccountLinkPrefix() + "x"); len(msgs) > 0 {
+			errs.add("AccountClaim account accountLinkPrefix %q is invalid: %s", account.AccountLinkPrefix, strings.Join(msgs, ", "))
+		}
+		if account.LegalEntityName != "" && account.LegalEntityID == "" {
+			errs.add("AccountClaim account legalEntityName requires legalEntityID")
 		}
 	}
+	validateDelayDistribution(errs, cfg.ProjectClaim.DelayDistribution, "ProjectClaim delayDistribution")
 	for _, state := range cfg.ProjectClaim.States {
 		if state.DurationSeconds < 0 {
-			return errors.Errorf("ProjectClaim state %s duration must be >= 0", state.Name)
+			errs.add("ProjectClaim state %s duration must be >= 0", state.Name)
 		}
+		validateDelayDistribution(errs, state.Distribution, fmt.Sprintf("ProjectClaim state %s distribution", state.Name))
 	}
 
-	// Validate failure probabilities
-	for i, scenario := range cfg.ClusterDeployment.FailureScenarios {
+	validateCredentialSecret(errs, cfg.ProjectClaim.CredentialSecret, "ProjectClaim credentialSecret")
+
+	// Validate failure probabilities and resolve named scenarios
+	for i := range cfg.ClusterDeployment.FailureScenarios {
+		scenario := &cfg.ClusterDeployment.FailureScenarios[i]
 		if scenario.Probability < 0.0 || scenario.Probability > 1.0 {
-			return errors.Errorf("ClusterDeployment failure scenario %d probability must be 0.0-1.0", i)
+			errs.add("ClusterDeployment failure scenario %d probability must be 0.0-1.0", i)
+		}
+		if !resolveFailureScenarioRef(scenario) {
+			errs.add("ClusterDeployment failure scenario %d references unknown failureScenarioRef %q", i, scenario.FailureScenarioRef)
+		}
+		if scenario.RetriesBeforeSuccess < 0 {
+			errs.add("ClusterDeployment failure scenario %d retriesBeforeSuccess must be >= 0", i)
+		}
+		if scenario.RecoverAfterSeconds < 0 {
+			errs.add("ClusterDeployment failure scenario %d recoverAfterSeconds must be >= 0", i)
+		}
+		if scenario.RecoverAfterSeconds > 0 && !scenario.Stuck {
+			errs.add("ClusterDeployment failure scenario %d recoverAfterSeconds requires stuck", i)
+		}
+		if scenario.Stuck && scenario.RetriesBeforeSuccess > 0 {
+			errs.add("ClusterDeployment failure scenario %d cannot be both stuck and retried", i)
+		}
+		if scenario.AtState != "" && !slices.ContainsFunc(cfg.ClusterDeployment.States, stateNamed(scenario.AtState)) &&
+			!slices.ContainsFunc(cfg.ClusterDeployment.AgentStates, stateNamed(scenario.AtState)) {
+			errs.add("ClusterDeployment failure scenario %d atState references unknown state %s", i, scenario.AtState)
 		}
 	}
-	for i, scenario := range cfg.AccountClaim.FailureScenarios {
+	for i := range cfg.AccountClaim.FailureScenarios {
+		scenario := &cfg.AccountClaim.FailureScenarios[i]
 		if scenario.Probability < 0.0 || scenario.Probability > 1.0 {
-			return errors.Errorf("AccountClaim failure scenario %d probability must be 0.0-1.0", i)
+			errs.add("AccountClaim failure scenario %d probability must be 0.0-1.0", i)
+		}
+		if !resolveFailureScenarioRef(scenario) {
+			errs.add("AccountClaim failure scenario %d references unknown failureScenarioRef %q", i, scenario.FailureScenarioRef)
+		}
+		if scenario.RetriesBeforeSuccess < 0 {
+			errs.add("AccountClaim failure scenario %d retriesBeforeSuccess must be >= 0", i)
+		}
+		if scenario.Stuck || scenario.RecoverAfterSeconds != 0 {
+			errs.add("AccountClaim failure scenario %d: stuck failures are only supported for ClusterDeployments", i)
+		}
+		if scenario.AtState != "" && !slices.ContainsFunc(cfg.AccountClaim.States, stateNamed(scenario.AtState)) {
+			errs.add("AccountClaim failure scenario %d atState references unknown state %s", i, scenario.AtState)
 		}
 	}
-	for i, scenario := range cfg.ProjectClaim.FailureScenarios {
+	for i := range cfg.ProjectClaim.FailureScenarios {
+		scenario := &cfg.ProjectClaim.FailureScenarios[i]
 		if scenario.Probability < 0.0 || scenario.Probability > 1.0 {
-			return errors.Errorf("ProjectClaim failure scenario %d probability must be 0.0-1.0", i)
+			errs.add("ProjectClaim failure scenario %d probability must be 0.0-1.0", i)
+		}
+		if !resolveFailureScenarioRef(scenario) {
+			errs.add("ProjectClaim failure scenario %d references unknown failureScenarioRef %q", i, scenario.FailureScenarioRef)
+		}
+		if scenario.RetriesBeforeSuccess < 0 {
+			errs.add("ProjectClaim failure scenario %d retriesBeforeSuccess must be >= 0", i)
+		}
+		if scenario.Stuck || scenario.RecoverAfterSeconds != 0 {
+			errs.add("ProjectClaim failure scenario %d: stuck failures are only supported for ClusterDeployments", i)
+		}
+		if scenario.AtState != "" && !slices.ContainsFunc(cfg.ProjectClaim.States, stateNamed(scenario.AtState)) {
+			errs.add("ProjectClaim failure scenario %d atState references unknown state %s", i, scenario.AtState)
 		}
 	}
 
-	return nil
+	validateDefaults(errs, cfg.ClusterDeployment.Defaults, true, "ClusterDeployment defaults")
+	validateDefaults(errs, cfg.AccountClaim.Defaults, false, "AccountClaim defaults")
+	validateDefaults(errs, cfg.ProjectClaim.Defaults, false, "ProjectClaim defaults")
+
+	// Warn about settings that are accepted but have no effect
+	warnIgnoredDefaultDelay(errs, cfg.ClusterDeployment.States, cfg.ClusterDeployment.DefaultDelaySeconds, "ClusterDeployment")
+	warnIgnoredDefaultDelay(errs, cfg.AccountClaim.States, cfg.AccountClaim.DefaultDelaySeconds, "AccountClaim")
+	warnIgnoredDefaultDelay(errs, cfg.ProjectClaim.States, cfg.ProjectClaim.DefaultDelaySeconds, "ProjectClaim")
+	warnUnreachableFailures(errs, cfg.ClusterDeployment.FailureScenarios, "ClusterDeployment")
+	warnUnreachableFailures(errs, cfg.AccountClaim.FailureScenarios, "AccountClaim")
+	warnUnreachableFailures(errs, cfg.ProjectClaim.FailureScenarios, "ProjectClaim")
+
+	if len(errs.Errors) > 0 {
+		return errs.warnings, errs
+	}
+
+	return errs.warnings, nil
+}
+
+// warnIgnoredDefaultDelay warns when a default delay is set alongside states: the state
+// machine takes each step from the state durations and only falls back without states, so
+// the default delay has no effect
+func warnIgnoredDefaultDelay(errs *ValidationErrors, states []StateConfig, defaultDelaySeconds int, kind string) {
+	if defaultDelaySeconds > 0 && len(states) > 0 {
+		errs.warn("%s defaultDelaySeconds has no effect when states are configured, the durations of its %d states are used",
+			kind, len(states))
+	}
+}
+
+// warnUnreachableFailures warns about failure scenarios that can never fire
+func warnUnreachableFailures(errs *ValidationErrors, scenarios []FailureScenario, kind string) {
+	for i, scenario := range scenarios {
+		if scenario.Probability == 0 {
+			errs.warn("%s failure scenario %d has probability 0 and never fires", kind, i)
+		}
+	}
+}
+
+// stateNamed returns a predicate matching the state configuration with the name
+func stateNamed(name string) func(StateConfig) bool {
+	return func(s StateConfig) bool { return s.Name == name }
+}
+
+// validateStateProgression checks that the states of a progression are named uniquely, that
+// there are states at all unless a default delay drives the resource without them, and that
+// transitions between them target states of the same progression
+func validateStateProgression(errs *ValidationErrors, states []StateConfig, defaultDelaySeconds int, field string) {
+	if len(states) == 0 && defaultDelaySeconds == 0 {
+		errs.add("%s must not be empty when defaultDelaySeconds is 0", field)
+	}
+	seen := make(map[string]bool, len(states))
+	for i, state := range states {
+		if state.Name == "" {
+			errs.add("%s: state %d has no name", field, i)
+			continue
+		}
+		if seen[state.Name] {
+			errs.add("%s: duplicate state %s", field, state.Name)
+		}
+		seen[state.Name] = true
+	}
+	for _, state := range states {
+		for j, transition := range state.Next {
+			if !seen[transition.To] {
+				errs.add("%s: state %s transition %d targets unknown state %q", field, state.Name, j, transition.To)
+			}
+			if transition.Probability < 0 || transition.Probability > 1 {
+				errs.add("%s: state %s transition %d probability must be 0.0-1.0", field, state.Name, j)
+			}
+		}
+	}
+}
+
+// validateTerminalState checks that a ClusterDeployment progression ends in the state that
+// marks it installed
+func validateTerminalState(errs *ValidationErrors, states []StateConfig, field string) {
+	if len(states) == 0 {
+		return
+	}
+	if last := states[len(states)-1].Name; last != ClusterDeploymentTerminalState {
+		errs.add("%s must end in a terminal state (%s), got %s", field, ClusterDeploymentTerminalState, last)
+	}
+}
+
+// validateDelayDistribution checks the parameters of an optional delay distribution
+func validateDelayDistribution(errs *ValidationErrors, dist *DelayDistribution, field string) {
+	if dist == nil {
+		return
+	}
+	switch dist.Type {
+	case "", DistributionFixed:
+		if dist.StdDevSeconds != 0 {
+			errs.add("%s: stdDevSeconds is only valid for type %s", field, DistributionNormal)
+		}
+	case DistributionNormal:
+		if dist.StdDevSeconds <= 0 {
+			errs.add("%s: stdDevSeconds must be > 0 for type %s", field, DistributionNormal)
+		}
+	default:
+		errs.add("%s: unknown type %q (expected %s or %s)", field, dist.Type, DistributionFixed, DistributionNormal)
+	}
+}
+
+// validateDefaults checks the defaults of a resource type and resolves the named scenario of
+// their failure. Stuck failures are only supported for ClusterDeployments.
+func validateDefaults(errs *ValidationErrors, defaults *ResourceDefaults, stuckSupported bool, field string) {
+	if defaults == nil {
+		return
+	}
+	if defaults.DelayMultiplier < 0 {
+		errs.add("%s delayMultiplier must be >= 0", field)
+	}
+	failure := defaults.Failure
+	if failure == nil {
+		return
+	}
+	if failure.Probability < 0.0 || failure.Probability > 1.0 {
+		errs.add("%s failure probability must be 0.0-1.0", field)
+	}
+	if !resolveFailureScenarioRef(failure) {
+		errs.add("%s failure references unknown failureScenarioRef %q", field, failure.FailureScenarioRef)
+	}
+	if failure.RetriesBeforeSuccess < 0 {
+		errs.add("%s failure retriesBeforeSuccess must be >= 0", field)
+	}
+	if !stuckSupported && (failure.Stuck || failure.RecoverAfterSeconds != 0) {
+		errs.add("%s failure: stuck failures are only supported for ClusterDeployments", field)
+	}
+	if failure.Stuck && failure.RetriesBeforeSuccess > 0 {
+		errs.add("%s failure cannot be both stuck and retried", field)
+	}
+}
+
+// validateCredentialSecret checks the keys of optional credentials secret metadata, and that
+// the values are templates rendering the claim data
+func validateCredentialSecret(errs *ValidationErrors, secret *CredentialSecretConfig, field string) {
+	if secret == nil {
+		return
+	}
+	for _, metadata := range []struct {
+		name   string
+		values map[string]string
+	}{{"labels", secret.Labels}, {"annotations", secret.Annotations}} {
+		for key := range metadata.values {
+			if msgs := validation.IsQualifiedName(key); len(msgs) > 0 {
+				errs.add("%s %s key %q is invalid: %s", field, metadata.name, key, strings.Join(msgs, ", "))
+			}
+		}
+		if _, err := renderValues(metadata.values, CredentialSecretData{}); err != nil {
+			errs.add("%s %s: %v", field, metadata.name, err)
+		}
+	}
 }
@@ -10,7 +10,7 @@ import (
 )
 
 func TestLoadFromFile_EmptyPath(t *testing.T) {
-	cfg, err := LoadFromFile("")
+	cfg, _, err := LoadFromFile("")
 	require.NoError(t, err)
 	assert.NotNil(t, cfg)
 	assert.Equal(t, DefaultConfig().ClusterDeployment.DefaultDelaySeconds, cfg.ClusterDeployment.DefaultDelaySeconds)
@@ -57,7 +57,7 @@ clusterImageSets:
 	require.NoError(t, err)
 
 	// Load config
-	cfg, err := LoadFromFile(configPath)
+	cfg, _, err := LoadFromFile(configPath)
 	require.NoError(t, err)
 	assert.NotNil(t, cfg)
 
@@ -74,8 +74,52 @@ clusterImageSets:
 	assert.Equal(t, "test-image-v1.0.0", cfg.ClusterImageSets[0].Name)
 }
 
+func TestLoadFromFile_UnknownTopLevelField(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+clusterDeployment:
+  defaultDelaySeconds: 10
+clusterImageSet:
+  - name: openshift-v4.17.0
+`), 0644))
+
+	_, _, err := LoadFromFile(configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "field clusterImageSet not found")
+}
+
+func TestLoadFromFile_UnknownStateField(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+clusterDeployment:
+  dependsOnAccountclaim: true
+  states:
+    - name: Pending
+      durationSecs: 1
+`), 0644))
+
+	_, _, err := LoadFromFile(configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "field dependsOnAccountclaim not found")
+	assert.Contains(t, err.Error(), "field durationSecs not found")
+}
+
+func TestLoadFromFile_JSON(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"clusterDeployment": {"defaultDelaySeconds": 10, "states": [{"name": "Pending", "durationSeconds": 1}, {"name": "Running", "durationSeconds": 1}]}}`), 0644))
+
+	cfg, _, err := LoadFromFile(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, 10, cfg.ClusterDeployment.DefaultDelaySeconds)
+
+	require.NoError(t, os.WriteFile(configPath, []byte(`{"clusterDeployment": {"dependsOnAccountclaim": true}}`), 0644))
+	_, _, err = LoadFromFile(configPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown field "clusterDeployment.dependsOnAccountclaim"`)
+}
+
 func TestLoadFromFile_FileNotFound(t *testing.T) {
-	cfg, err := LoadFromFile("/nonexistent/path/config.yaml")
+	cfg, _, err := LoadFromFile("/nonexistent/path/config.yaml")
 	assert.Error(t, err)
 	assert.Nil(t, cfg)
 	assert.Contains(t, err.Error(), "failed to read config file")
@@ -88,7 +132,7 @@ func TestLoadFromFile_InvalidYAML(t *testing.T) {
 	err := os.WriteFile(configPath, []byte("invalid: yaml: content: ["), 0644)
 	require.NoError(t, err)
 
-	cfg, err := LoadFromFile(configPath)
+	cfg, _, err := LoadFromFile(configPath)
 	assert.Error(t, err)
 	assert.Nil(t, cfg)
 	assert.Contains(t, err.Error(), "failed to parse config file")
@@ -107,7 +151,7 @@ func TestValidate_NegativeDefaultDelay(t *testing.T) {
 		},
 	}
 
-	err := validate(cfg)
+	_, err := validate(cfg)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "ClusterDeployment defaultDelaySeconds must be >= 0")
 }
@@ -128,11 +172,84 @@ func TestValidate_NegativeStateDuration(t *testing.T) {
 		},
 	}
 
-	err := validate(cfg)
+	_, err := validate(cfg)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "ClusterDeployment state test duration must be >= 0")
 }
 
+func TestValidate_StateProgression(t *testing.T) {
+	cfg := DefaultConfig()
+	_, err := validate(cfg)
+	require.NoError(t, err)
+
+	cfg.ClusterDeployment.States = append(cfg.ClusterDeployment.States, StateConfig{Name: "Installing"})
+	cfg.AccountClaim.States = append(cfg.AccountClaim.States, StateConfig{Name: "Pending"}, StateConfig{})
+	_, err = validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ClusterDeployment states: duplicate state Installing")
+	assert.Contains(t, err.Error(), "ClusterDeployment states must end in a terminal state (Running), got Installing")
+	assert.Contains(t, err.Error(), "AccountClaim states: duplicate state Pending")
+	assert.Contains(t, err.Error(), "AccountClaim states: state 3 has no name")
+}
+
+func TestValidate_EmptyStates(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ClusterDeployment.States = nil
+	cfg.ClusterDeployment.DefaultDelaySeconds = 0
+	cfg.ProjectClaim.States = nil
+	cfg.ProjectClaim.DefaultDelaySeconds = 0
+	_, err := validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ClusterDeployment states must not be empty when defaultDelaySeconds is 0")
+	assert.Contains(t, err.Error(), "ProjectClaim states must not be empty when defaultDelaySeconds is 0")
+
+	// A default delay drives a resource without states
+	cfg.ClusterDeployment.DefaultDelaySeconds = 5
+	cfg.ProjectClaim.DefaultDelaySeconds = 5
+	_, err = validate(cfg)
+	require.NoError(t, err)
+}
+
+func TestValidate_DependencyMatch(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ClusterDeployment.DependencyMatch = DependencyMatchOwner
+	_, err := validate(cfg)
+	require.NoError(t, err)
+
+	cfg.ClusterDeployment.DependencyMatch = "annotation"
+	_, err = validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `ClusterDeployment dependencyMatch: unknown value "annotation"`)
+}
+
+func TestValidate_StateTransitions(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ClusterDeployment.States[1].Next = []TransitionConfig{
+		{To: "Installing", Labels: map[string]string{"scenario": "fast"}},
+		{To: "Running", Probability: 0.5},
+	}
+	_, err := validate(cfg)
+	require.NoError(t, err)
+
+	cfg.ClusterDeployment.States[1].Next = []TransitionConfig{{To: "Retrying"}}
+	cfg.ProjectClaim.States[0].Next = []TransitionConfig{{To: "Ready", Probability: 1.5}}
+	_, err = validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `ClusterDeployment states: state Provisioning transition 0 targets unknown state "Retrying"`)
+	assert.Contains(t, err.Error(), "ProjectClaim states: state Pending transition 0 probability must be 0.0-1.0")
+}
+
+func TestValidate_NegativeDeprovisionStateDuration(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ClusterDeployment.DeprovisionStates = []StateConfig{
+		{Name: "Deprovisioning", DurationSeconds: -1},
+	}
+
+	_, err := validate(cfg)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "ClusterDeployment deprovision state Deprovisioning duration must be >= 0")
+}
+
 func TestValidate_InvalidFailureProbability(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -167,7 +284,7 @@ func TestValidate_InvalidFailureProbability(t *testing.T) {
 				},
 			}
 
-			err := validate(cfg)
+			_, err := validate(cfg)
 			if tt.shouldError {
 				assert.Error(t, err)
 				assert.Contains(t, err.Error(), "probability must be 0.0-1.0")
@@ -181,7 +298,7 @@ func TestValidate_InvalidFailureProbability(t *testing.T) {
 func TestValidate_FillsDefaults(t *testing.T) {
 	cfg := &Config{}
 
-	err := validate(cfg)
+	_, err := validate(cfg)
 	assert.NoError(t, err)
 
 	// Should have filled in defaults
@@ -190,3 +307,473 @@ func TestValidate_FillsDefaults(t *testing.T) {
 	assert.NotNil(t, cfg.ProjectClaim)
 	assert.NotEmpty(t, cfg.ClusterImageSets)
 }
+
+func TestLoadFromFile_ReportsAllValidationErrors(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "multi-error.yaml")
+
+	configContent := `
+clusterDeployment:
+  defaultDelaySeconds: -1
+  states:
+    - name: Pending
+      durationSeconds: -2
+accountClaim:
+  defaultDelaySeconds: -3
+projectClaim:
+  defaultDelaySeconds: 1
+  failureScenarios:
+    - probability: 1.5
+      condition: TestFail
+      message: test
+`
+
+	err := os.WriteFile(configPath, []byte(configContent), 0644)
+	require.NoError(t, err)
+
+	cfg, _, err := LoadFromFile(configPath)
+	require.Error(t, err)
+	assert.Nil(t, cfg)
+
+	assert.Contains(t, err.Error(), "invalid configuration")
+	assert.Contains(t, err.Error(), "ClusterDeployment defaultDelaySeconds must be >= 0")
+	assert.Contains(t, err.Error(), "ClusterDeployment state Pending duration must be >= 0")
+	assert.Contains(t, err.Error(), "AccountClaim defaultDelaySeconds must be >= 0")
+	assert.Contains(t, err.Error(), "ProjectClaim failure scenario 0 probability must be 0.0-1.0")
+	assert.Contains(t, err.Error(), "ClusterDeployment states must end in a terminal state (Running), got Pending")
+
+	var validationErrs *ValidationErrors
+	require.ErrorAs(t, err, &validationErrs)
+	assert.Len(t, validationErrs.Errors, 5)
+}
+
+func TestValidate_DefaultNamespace(t *testing.T) {
+	cfg := &Config{DefaultNamespace: "Not_A_Valid_Namespace"}
+
+	_, err := validate(cfg)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "defaultNamespace \"Not_A_Valid_Namespace\" is invalid")
+
+	cfg = &Config{DefaultNamespace: "sim-workloads"}
+	_, err = validate(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "sim-workloads", cfg.GetDefaultNamespace())
+
+	cfg = &Config{}
+	_, err = validate(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultNamespaceName, cfg.GetDefaultNamespace())
+}
+
+func TestValidate_ResolvesFailureScenarioRef(t *testing.T) {
+	cfg := &Config{
+		ClusterDeployment: &ClusterDeploymentConfig{
+			DefaultDelaySeconds: 5,
+			FailureScenarios: []FailureScenario{
+				{Probability: 0.2, FailureScenarioRef: "InstallAttemptsLimitReached"},
+				{Probability: 0.1, FailureScenarioRef: "KubeconfigSecretMissing", Message: "custom message"},
+			},
+		},
+	}
+
+	_, err := validate(cfg)
+	require.NoError(t, err)
+
+	scenario := cfg.ClusterDeployment.FailureScenarios[0]
+	assert.Equal(t, 0.2, scenario.Probability)
+	assert.Equal(t, "ProvisionStopped", scenario.Condition)
+	assert.Equal(t, "InstallAttemptsLimitReached", scenario.Reason)
+	assert.Equal(t, "Install attempts limit reached", scenario.Message)
+
+	// Explicit fields win over the library definition
+	scenario = cfg.ClusterDeployment.FailureScenarios[1]
+	assert.Equal(t, "KubeconfigSecretMissing", scenario.Reason)
+	assert.Equal(t, "custom message", scenario.Message)
+}
+
+func TestValidate_StuckFailureScenarios(t *testing.T) {
+	cfg := &Config{
+		ClusterDeployment: &ClusterDeploymentConfig{
+			DefaultDelaySeconds: 5,
+			FailureScenarios: []FailureScenario{
+				{Probability: 0.1, FailureScenarioRef: "QuotaExceeded", RecoverAfterSeconds: 30},
+			},
+		},
+	}
+	_, err := validate(cfg)
+	require.NoError(t, err)
+	assert.True(t, cfg.ClusterDeployment.FailureScenarios[0].Stuck)
+	assert.Equal(t, "QuotaExceeded", cfg.ClusterDeployment.FailureScenarios[0].Reason)
+
+	cfg = &Config{
+		ClusterDeployment: &ClusterDeploymentConfig{
+			FailureScenarios: []FailureScenario{
+				{Probability: 0.1, Condition: "ProvisionFailed", RecoverAfterSeconds: 30},
+			},
+		},
+		AccountClaim: &AccountClaimConfig{
+			FailureScenarios: []FailureScenario{
+				{Probability: 0.1, FailureScenarioRef: "QuotaExceeded"},
+			},
+		},
+	}
+	_, err = validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ClusterDeployment failure scenario 0 recoverAfterSeconds requires stuck")
+	assert.Contains(t, err.Error(), "AccountClaim failure scenario 0: stuck failures are only supported for ClusterDeployments")
+}
+
+func TestValidate_UnknownFailureScenarioRef(t *testing.T) {
+	cfg := &Config{
+		AccountClaim: &AccountClaimConfig{
+			FailureScenarios: []FailureScenario{
+				{Probability: 0.5, FailureScenarioRef: "NoSuchScenario"},
+			},
+		},
+	}
+
+	_, err := validate(cfg)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "AccountClaim failure scenario 0 references unknown failureScenarioRef \"NoSuchScenario\"")
+}
+
+func TestLookupFailureScenario(t *testing.T) {
+	for _, name := range BuiltinFailureScenarioNames() {
+		scenario, ok := LookupFailureScenario(name)
+		require.True(t, ok, name)
+		assert.NotEmpty(t, scenario.Condition, name)
+		assert.NotEmpty(t, scenario.Reason, name)
+		assert.NotEmpty(t, scenario.Message, name)
+	}
+
+	scenario, ok := LookupFailureScenario("AccountLimitExceeded")
+	require.True(t, ok)
+	assert.Equal(t, "ProvisionFailed", scenario.Condition)
+	assert.Equal(t, "AccountLimitExceeded", scenario.Reason)
+
+	_, ok = LookupFailureScenario("NoSuchScenario")
+	assert.False(t, ok)
+}
+
+func TestValidate_FleetRamp(t *testing.T) {
+	cfg := &Config{FleetRamp: &FleetRampConfig{ClustersPerMinute: 0, TargetCount: -1, Namespace: "Bad_NS"}}
+
+	_, err := validate(cfg)
+	var validationErrs *ValidationErrors
+	require.ErrorAs(t, err, &validationErrs)
+	assert.Len(t, validationErrs.Errors, 3)
+
+	cfg = &Config{FleetRamp: &FleetRampConfig{ClustersPerMinute: 5, TargetCount: 100}}
+	_, err = validate(cfg)
+	require.NoError(t, err)
+}
+
+func TestConfig_SetRunID(t *testing.T) {
+	cfg := DefaultConfig()
+	require.NoError(t, cfg.SetRunID("ci-job-1234"))
+	assert.Equal(t, "ci-job-1234", cfg.RunID)
+
+	assert.Error(t, cfg.SetRunID("not a valid label value!"))
+	assert.Equal(t, "ci-job-1234", cfg.RunID)
+}
+
+func TestConfig_SetAPIResponseHeaders(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.APIResponseHeaders = map[string]string{"X-Hive-Sim-Instance": "from-file", "X-Team": "qe"}
+
+	require.NoError(t, cfg.SetAPIResponseHeaders([]string{"X-Hive-Sim-Instance=sim-1", "X-Empty="}))
+	assert.Equal(t, map[string]string{
+		"X-Hive-Sim-Instance": "sim-1",
+		"X-Team":              "qe",
+		"X-Empty":             "",
+	}, cfg.APIResponseHeaders)
+
+	assert.Error(t, cfg.SetAPIResponseHeaders([]string{"X-Missing-Value"}))
+	assert.Error(t, cfg.SetAPIResponseHeaders([]string{"Bad Name=value"}))
+	assert.Equal(t, "sim-1", cfg.APIResponseHeaders["X-Hive-Sim-Instance"])
+}
+
+func TestValidate_APIResponseHeaders(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.APIResponseHeaders = map[string]string{"X-Hive-Sim-Instance": "sim-1"}
+	_, err := validate(cfg)
+	require.NoError(t, err)
+
+	cfg.APIResponseHeaders["Bad Name"] = "value"
+	_, err = validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "apiResponseHeaders")
+}
+
+func TestValidate_EventVerbosity(t *testing.T) {
+	cfg := DefaultConfig()
+	assert.Equal(t, EventVerbosityTerminal, cfg.GetEventVerbosity())
+
+	cfg.EventVerbosity = EventVerbosityAll
+	_, err := validate(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, EventVerbosityAll, cfg.GetEventVerbosity())
+
+	cfg.EventVerbosity = "verbose"
+	_, err = validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "eventVerbosity")
+}
+
+func TestValidate_SpeedFactor(t *testing.T) {
+	cfg := DefaultConfig()
+	_, err := validate(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, float64(1), cfg.GetSpeedFactor())
+
+	cfg.SpeedFactor = 60
+	_, err = validate(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, float64(60), cfg.GetSpeedFactor())
+
+	cfg.SpeedFactor = -1
+	_, err = validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "speedFactor")
+}
+
+func TestValidate_InstallPhases(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ClusterDeployment.InstallPhases = map[string]string{"Provisioning": "infrastructure", "AgentWaiting": "discovery"}
+	_, err := validate(cfg)
+	require.NoError(t, err)
+
+	cfg.ClusterDeployment.InstallPhases["Bootstrapping"] = "bootstrap"
+	_, err = validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "installPhases references unknown state Bootstrapping")
+}
+
+func TestValidate_SyncSet(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SyncSet = &SyncSetConfig{
+		DelaySeconds: 5,
+		Failures:     []SyncSetFailure{{SyncSet: "cluster-config", Resource: "ConfigMap/settings"}},
+	}
+	_, err := validate(cfg)
+	require.NoError(t, err)
+
+	cfg.SyncSet.DelaySeconds = -1
+	cfg.SyncSet.Failures = append(cfg.SyncSet.Failures, SyncSetFailure{Resource: "settings"})
+	_, err = validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "syncSet delaySeconds must be >= 0")
+	assert.Contains(t, err.Error(), "syncSet failure 1: syncSet is required")
+	assert.Contains(t, err.Error(), `syncSet failure 1: resource "settings" must be in <kind>/<name> form`)
+}
+
+func TestValidate_DNSZone(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ClusterDeployment.DependsOnDNSZone = true
+	_, err := validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ClusterDeployment dependsOnDNSZone requires dnsZone to be configured")
+
+	cfg.DNSZone = &DNSZoneConfig{DelaySeconds: 5, NameServers: []string{"ns1.example.com"}}
+	_, err = validate(cfg)
+	require.NoError(t, err)
+
+	cfg.DNSZone.DelaySeconds = -1
+	cfg.DNSZone.NameServers = append(cfg.DNSZone.NameServers, "not a name server")
+	_, err = validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "dnsZone delaySeconds must be >= 0")
+	assert.Contains(t, err.Error(), `dnsZone nameServer 1 "not a name server" is invalid`)
+}
+
+func TestValidate_ClusterPool(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ClusterPool = &ClusterPoolConfig{FillIntervalSeconds: 10, ClaimDelaySeconds: 2}
+	_, err := validate(cfg)
+	require.NoError(t, err)
+
+	cfg.ClusterPool = &ClusterPoolConfig{FillIntervalSeconds: -1, ClaimDelaySeconds: -1}
+	_, err = validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "clusterPool fillIntervalSeconds must be >= 0")
+	assert.Contains(t, err.Error(), "clusterPool claimDelaySeconds must be >= 0")
+}
+
+func TestValidate_JitterPercent(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ClusterDeployment.JitterPercent = 100
+	cfg.AccountClaim.JitterPercent = 10
+	_, err := validate(cfg)
+	require.NoError(t, err)
+
+	cfg.ClusterDeployment.JitterPercent = 101
+	cfg.ProjectClaim.JitterPercent = -1
+	_, err = validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ClusterDeployment jitterPercent must be between 0 and 100")
+	assert.Contains(t, err.Error(), "ProjectClaim jitterPercent must be between 0 and 100")
+}
+
+func TestValidate_Defaults(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ProjectClaim.Defaults = &ResourceDefaults{
+		DelayMultiplier: 1.5,
+		Failure:         &FailureScenario{Probability: 0.1, FailureScenarioRef: "InsufficientCapacity"},
+		Conditions:      []ConditionConfig{{Type: "Ready", Status: "False"}},
+	}
+	_, err := validate(cfg)
+	require.NoError(t, err)
+	assert.NotEmpty(t, cfg.ProjectClaim.Defaults.Failure.Message, "the named scenario should be resolved")
+
+	cfg.ClusterDeployment.Defaults = &ResourceDefaults{DelayMultiplier: -1}
+	cfg.AccountClaim.Defaults = &ResourceDefaults{Failure: &FailureScenario{Probability: 2, Stuck: true}}
+	_, err = validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ClusterDeployment defaults delayMultiplier must be >= 0")
+	assert.Contains(t, err.Error(), "AccountClaim defaults failure probability must be 0.0-1.0")
+	assert.Contains(t, err.Error(), "AccountClaim defaults failure: stuck failures are only supported for ClusterDeployments")
+}
+
+func TestValidate_Account(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AccountClaim.Account = &AccountConfig{AccountLinkPrefix: "sim-", LegalEntityID: "id"}
+	_, err := validate(cfg)
+	require.NoError(t, err)
+
+	cfg.AccountClaim.Account = &AccountConfig{AccountLinkPrefix: "Sim_", LegalEntityName: "Org"}
+	_, err = validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `AccountClaim account accountLinkPrefix "Sim_" is invalid`)
+	assert.Contains(t, err.Error(), "AccountClaim account legalEntityName requires legalEntityID")
+}
+
+func TestValidate_Warnings(t *testing.T) {
+	// The defaults keep defaultDelaySeconds along with their states
+	warnings, err := validate(DefaultConfig())
+	require.NoError(t, err)
+	assert.Len(t, warnings, 3)
+
+	cfg := DefaultConfig()
+	cfg.ClusterDeployment.DefaultDelaySeconds = 60
+	cfg.AccountClaim.DefaultDelaySeconds = 0
+	cfg.ProjectClaim.DefaultDelaySeconds = 0
+	cfg.AccountClaim.FailureScenarios = []FailureScenario{
+		{Probability: 0.1, Condition: "Failed", Message: "fails"},
+		{Probability: 0, Condition: "Failed", Message: "never fails"},
+	}
+	warnings, err = validate(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"ClusterDeployment defaultDelaySeconds has no effect when states are configured, the durations of its 4 states are used",
+		"AccountClaim failure scenario 1 has probability 0 and never fires",
+	}, warnings)
+
+	// Warnings are returned along with errors
+	cfg.ProjectClaim.DefaultDelaySeconds = -1
+	warnings, err = validate(cfg)
+	require.Error(t, err)
+	assert.Len(t, warnings, 2)
+}
+
+func TestValidate_CredentialSecret(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AccountClaim.CredentialSecret = &CredentialSecretConfig{
+		Labels:      map[string]string{"api.openshift.com/id": "{{ .ClusterID }}"},
+		Annotations: map[string]string{"example.com/claim": "{{ .Namespace }}/{{ .Name }}"},
+	}
+	_, err := validate(cfg)
+	require.NoError(t, err)
+
+	cfg.ProjectClaim.CredentialSecret = &CredentialSecretConfig{
+		Labels:      map[string]string{"not a key": "value"},
+		Annotations: map[string]string{"example.com/claim": "{{ .Cluster }}"},
+	}
+	_, err = validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `ProjectClaim credentialSecret labels key "not a key" is invalid`)
+	assert.Contains(t, err.Error(), "ProjectClaim credentialSecret annotations: invalid template for example.com/claim")
+}
+
+func TestValidate_FailureScenarioAtState(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ClusterDeployment.FailureScenarios = []FailureScenario{{Probability: 0.5, Condition: "ProvisionFailed", AtState: "Installing"}}
+	cfg.AccountClaim.FailureScenarios = []FailureScenario{{Probability: 0.5, Condition: "ClaimFailed", AtState: "Pending"}}
+	_, err := validate(cfg)
+	require.NoError(t, err)
+
+	cfg.ClusterDeployment.FailureScenarios[0].AtState = "Bootstrapping"
+	cfg.ProjectClaim.FailureScenarios = []FailureScenario{{Probability: 0.5, Condition: "ClaimFailed", AtState: "Installing"}}
+	_, err = validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ClusterDeployment failure scenario 0 atState references unknown state Bootstrapping")
+	assert.Contains(t, err.Error(), "ProjectClaim failure scenario 0 atState references unknown state Installing")
+}
+
+func TestValidate_VersionSkew(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ClusterDeployment.VersionSkew = &VersionSkewConfig{InitialVersion: "4.12", StepIntervalSeconds: 60}
+	_, err := validate(cfg)
+	require.NoError(t, err)
+
+	cfg.ClusterDeployment.VersionSkew = &VersionSkewConfig{InitialVersion: "four", StepIntervalSeconds: -1}
+	_, err = validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `versionSkew initialVersion "four" is invalid`)
+	assert.Contains(t, err.Error(), "versionSkew stepIntervalSeconds must be >= 0")
+}
+
+func TestValidate_NegativeFailedResourceTTL(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.FailedResourceTTLSeconds = -1
+
+	_, err := validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failedResourceTTLSeconds")
+}
+
+func TestValidate_NegativeInstallLogsDelay(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ClusterDeployment.InstallLogs = &InstallLogsConfig{DelaySeconds: -1}
+
+	_, err := validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "installLogs delaySeconds")
+}
+
+func TestValidate_DelayDistributions(t *testing.T) {
+	cfg := &Config{
+		ClusterDeployment: &ClusterDeploymentConfig{
+			DelayDistribution: &DelayDistribution{Type: DistributionNormal, StdDevSeconds: 5},
+			States: []StateConfig{
+				{Name: "Pending", DurationSeconds: 1, Distribution: &DelayDistribution{Type: DistributionFixed}},
+				{Name: "Installing", DurationSeconds: 60, Distribution: &DelayDistribution{Type: DistributionNormal, StdDevSeconds: 20}},
+				{Name: "Running"},
+			},
+		},
+	}
+	_, err := validate(cfg)
+	require.NoError(t, err)
+
+	cfg.ClusterDeployment.States[0].Distribution = &DelayDistribution{Type: "uniform"}
+	cfg.ClusterDeployment.States[1].Distribution = &DelayDistribution{Type: DistributionNormal}
+
+	_, err = validate(cfg)
+	var validationErrs *ValidationErrors
+	require.ErrorAs(t, err, &validationErrs)
+	require.Len(t, validationErrs.Errors, 2)
+	assert.Contains(t, validationErrs.Errors[0], "ClusterDeployment state Pending distribution: unknown type \"uniform\"")
+	assert.Contains(t, validationErrs.Errors[1], "ClusterDeployment state Installing distribution: stdDevSeconds must be > 0")
+}
+
+func TestValidate_NegativeHibernationDurations(t *testing.T) {
+	cfg := &Config{
+		ClusterDeployment: &ClusterDeploymentConfig{
+			Hibernation: &HibernationConfig{StoppingSeconds: -1, ResumingSeconds: -5},
+		},
+	}
+
+	_, err := validate(cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "ClusterDeployment hibernation stoppingSeconds must be >= 0")
+	assert.Contains(t, err.Error(), "ClusterDeployment hibernation resumingSeconds must be >= 0")
+}
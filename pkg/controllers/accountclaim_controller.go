@@ -2,11 +2,13 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	"os"
 
 	corev1 "k8s.io/api/core/v1"
 	kuberrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -16,15 +18,26 @@ import (
 	aaov1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/aws-account-operator/v1alpha1"
 	"github.com/tzvatot/openshift-hive-simulator/pkg/behavior"
 	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/labels"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/logutil"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/notify"
 	"github.com/tzvatot/openshift-hive-simulator/pkg/state_machine"
 )
 
+// SecretReadyCondition is set on an AccountClaim once its AWS credentials secret exists,
+// which happens after the claim itself becomes Ready
+const SecretReadyCondition aaov1alpha1.AccountClaimConditionType = "SecretReady"
+
 // AccountClaimReconciler reconciles AccountClaim objects
 type AccountClaimReconciler struct {
 	client         client.Client
 	logger         logging.Logger
 	stateMachine   *state_machine.AccountClaimStateMachine
 	behaviorEngine *behavior.Engine
+	namespaces     *namespaceGuard
+	events         *eventEmitter
+	notifier       *notify.Notifier
+	dryRun         bool
 }
 
 // NewAccountClaimReconciler creates a new AccountClaim reconciler
@@ -39,13 +52,45 @@ func NewAccountClaimReconciler(
 		logger:         logger,
 		stateMachine:   stateMachine,
 		behaviorEngine: behaviorEngine,
+		namespaces:     newNamespaceGuard(client, logger),
+		events:         newEventEmitter(behaviorEngine),
 	}
 }
 
+// SetEventRecorder sets the recorder of the events emitted for AccountClaim transitions, no events
+// are emitted without one
+func (r *AccountClaimReconciler) SetEventRecorder(recorder record.EventRecorder) {
+	r.events.recorder = recorder
+}
+
+// SetNotifier sets the notifier of the webhooks registered for terminal states, no webhooks are
+// notified without one
+func (r *AccountClaimReconciler) SetNotifier(notifier *notify.Notifier) {
+	r.notifier = notifier
+}
+
+// SetDryRun sets whether AccountClaim transitions are only logged, without writing anything to the
+// API server
+func (r *AccountClaimReconciler) SetDryRun(dryRun bool) {
+	r.dryRun = dryRun
+}
+
 // Reconcile reconciles an AccountClaim
-func (r *AccountClaimReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+func (r *AccountClaimReconciler) Reconcile(ctx context.Context, req reconcile.Request) (result reconcile.Result, err error) {
+	defer func() {
+		result, err = requeueOnConflict(result, err)
+		recordReconcile("AccountClaim", result, err)
+	}()
+
+	ctx = logutil.WithReconcileID(ctx, "ac", req.Namespace, req.Name)
 	r.logger.Debug(ctx, "Reconciling AccountClaim %s/%s", req.Namespace, req.Name)
 
+	// Leave every resource as it is while reconciliation is paused
+	if r.behaviorEngine.IsReconcilePaused() {
+		r.logger.Debug(ctx, "Reconciliation is paused, requeuing AccountClaim %s/%s", req.Namespace, req.Name)
+		return reconcile.Result{RequeueAfter: reconcilePausedRequeueInterval}, nil
+	}
+
 	ac := &aaov1alpha1.AccountClaim{}
 	if err := r.client.Get(ctx, req.NamespacedName, ac); err != nil {
 		if kuberrors.IsNotFound(err) {
@@ -56,20 +101,57 @@ func (r *AccountClaimReconciler) Reconcile(ctx context.Context, req reconcile.Re
 		return reconcile.Result{}, err
 	}
 
+	// Only log the transition that would be applied in dry run
+	if r.dryRun {
+		return r.planTransition(ctx, ac), nil
+	}
+
 	// Skip if being deleted
 	if !ac.DeletionTimestamp.IsZero() {
 		r.logger.Debug(ctx, "AccountClaim %s/%s is being deleted, skipping", req.Namespace, req.Name)
 		return reconcile.Result{}, nil
 	}
 
-	// Skip if already in final state
+	// Skip if the namespace is being deleted, writes to it would be rejected
+	terminating, err := r.namespaces.isTerminating(ctx, "AccountClaim", req.Namespace)
+	if err != nil {
+		r.logger.Error(ctx, "Failed to get namespace %s: %v", req.Namespace, err)
+		return reconcile.Result{}, err
+	}
+	if terminating {
+		return reconcile.Result{}, nil
+	}
+
+	// Skip if already in final state, retrying the credentials secret if it is still missing
 	if ac.Status.State == aaov1alpha1.ClaimStatusReady || ac.Status.State == aaov1alpha1.ClaimStatusError {
 		r.logger.Debug(ctx, "AccountClaim %s/%s is in final state: %s, skipping", req.Namespace, req.Name, ac.Status.State)
+		if ac.Status.State == aaov1alpha1.ClaimStatusReady {
+			if err := r.ensureCredentialsSecret(ctx, ac); err != nil {
+				r.logger.Error(ctx, "Failed to create AWS credentials secret for AccountClaim %s/%s: %v",
+					ac.Namespace, ac.Name, err)
+				return reconcile.Result{}, err
+			}
+		}
 		return reconcile.Result{}, nil
 	}
 
+	// Hold a paused AccountClaim in its current state
+	if r.behaviorEngine.IsPaused("AccountClaim", ac.Namespace, ac.Name) {
+		r.logger.Debug(ctx, "AccountClaim %s/%s is paused", req.Namespace, req.Name)
+		return reconcile.Result{RequeueAfter: pausedRequeueInterval}, nil
+	}
+
+	// Spread out the first transition of resources created together
+	if delay := r.stateMachine.StartDelay(ac); delay > 0 {
+		r.logger.Debug(ctx, "Delaying first transition of AccountClaim %s/%s by %v", ac.Namespace, ac.Name, delay)
+		return reconcile.Result{RequeueAfter: delay}, nil
+	}
+
 	// Check for forced failure
-	shouldFail, failure := r.behaviorEngine.ShouldFail(ctx, "AccountClaim", ac.Namespace, ac.Name)
+	shouldFail, failure := r.behaviorEngine.ShouldFail(ctx, "AccountClaim", ac.Namespace, ac.Name, r.stateMachine.CurrentState(ac), ac.Labels)
+	if shouldFail && failure.RetriesBeforeSuccess > 0 {
+		return r.applyTransientFailure(ctx, ac, failure)
+	}
 	if shouldFail {
 		return r.applyFailure(ctx, ac, failure)
 	}
@@ -78,41 +160,57 @@ func (r *AccountClaimReconciler) Reconcile(ctx context.Context, req reconcile.Re
 	nextState, duration := r.stateMachine.GetNextState(ctx, ac)
 
 	// Apply the state
-	if err := r.stateMachine.ApplyState(ctx, ac, nextState); err != nil {
+	specChanged, err := r.stateMachine.ApplyState(ctx, ac, nextState)
+	if err != nil {
 		r.logger.Error(ctx, "Failed to apply state %s to AccountClaim %s/%s: %v",
 			nextState, ac.Namespace, ac.Name, err)
 		return reconcile.Result{}, err
 	}
+	if nextState == aaov1alpha1.ClaimStatusReady && r.linkAccount(ac) {
+		specChanged = true
+	}
 
-	// Update the AccountClaim
+	// Update the spec first if fields were set. Each update replaces the AccountClaim with the
+	// stored one, so the status is restored before it is written.
+	if specChanged {
+		status := ac.Status.DeepCopy()
+		if err := r.client.Update(ctx, ac); err != nil {
+			logWriteError(ctx, r.logger, err, "Failed to update AccountClaim %s/%s spec: %v",
+				ac.Namespace, ac.Name, err)
+			return reconcile.Result{}, err
+		}
+		ac.Status = *status
+	}
+
+	// Update the AccountClaim status
 	if err := r.client.Status().Update(ctx, ac); err != nil {
-		r.logger.Error(ctx, "Failed to update AccountClaim %s/%s status: %v",
+		logWriteError(ctx, r.logger, err, "Failed to update AccountClaim %s/%s status: %v",
 			ac.Namespace, ac.Name, err)
 		return reconcile.Result{}, err
 	}
 
-	// Also update spec if fields were set
-	if err := r.client.Update(ctx, ac); err != nil {
-		r.logger.Error(ctx, "Failed to update AccountClaim %s/%s spec: %v",
-			ac.Namespace, ac.Name, err)
-		return reconcile.Result{}, err
+	r.logger.Info(ctx, "AccountClaim %s/%s transitioned to state: %s", ac.Namespace, ac.Name, nextState)
+	recordStateTransition("AccountClaim", string(nextState))
+	r.behaviorEngine.PublishTransition("AccountClaim", ac.Namespace, ac.Name, string(nextState))
+	r.events.transitioned(ac, string(nextState), isClaimTerminal(string(nextState)))
+	if isClaimTerminal(string(nextState)) {
+		notifyTerminal(ctx, r.notifier, "AccountClaim", ac, string(nextState))
 	}
 
-	// Create AWS credentials secret when transitioning to Ready
-	if nextState == aaov1alpha1.ClaimStatusReady && ac.Spec.AwsCredentialSecret.Name != "" {
-		if err := r.createAWSCredentialsSecret(ctx, ac); err != nil {
+	// Create AWS credentials secret when transitioning to Ready. The transition is reported
+	// first: a failed secret is retried from the final state, which reports nothing.
+	if nextState == aaov1alpha1.ClaimStatusReady {
+		if err := r.ensureCredentialsSecret(ctx, ac); err != nil {
 			r.logger.Error(ctx, "Failed to create AWS credentials secret for AccountClaim %s/%s: %v",
 				ac.Namespace, ac.Name, err)
 			return reconcile.Result{}, err
 		}
 	}
 
-	r.logger.Info(ctx, "AccountClaim %s/%s transitioned to state: %s", ac.Namespace, ac.Name, nextState)
-
 	// Requeue after duration for next state transition
 	if duration > 0 {
 		// Check for delay override
-		duration = r.behaviorEngine.GetTransitionDelay(ctx, "AccountClaim", ac.Namespace, ac.Name, duration)
+		duration = r.behaviorEngine.GetTransitionDelay(ctx, "AccountClaim", ac.Namespace, ac.Name, ac.Labels, duration)
 		r.logger.Debug(ctx, "Requeuing AccountClaim %s/%s after %v", ac.Namespace, ac.Name, duration)
 		return reconcile.Result{RequeueAfter: duration}, nil
 	}
@@ -120,6 +218,51 @@ func (r *AccountClaimReconciler) Reconcile(ctx context.Context, req reconcile.Re
 	return reconcile.Result{}, nil
 }
 
+// linkAccount links the AccountClaim to its simulated account, and sets the configured legal
+// entity if the claim has none, the way the aws-account-operator does once the claim is Ready.
+// It returns true if the spec changed.
+func (r *AccountClaimReconciler) linkAccount(ac *aaov1alpha1.AccountClaim) bool {
+	changed := false
+	if ac.Spec.AccountLink == "" {
+		ac.Spec.AccountLink = r.behaviorEngine.AccountLink(ac.Namespace, ac.Name)
+		changed = true
+	}
+	account := r.behaviorEngine.GetAccountClaimConfig().Account
+	if account != nil && account.LegalEntityID != "" && ac.Spec.LegalEntity.ID == "" {
+		ac.Spec.LegalEntity = aaov1alpha1.LegalEntity{ID: account.LegalEntityID, Name: account.LegalEntityName}
+		changed = true
+	}
+	return changed
+}
+
+// planTransition logs the next transition of an AccountClaim without applying it, requeuing it
+// for when the transition would be due
+func (r *AccountClaimReconciler) planTransition(ctx context.Context, ac *aaov1alpha1.AccountClaim) reconcile.Result {
+	currentState := r.stateMachine.CurrentState(ac)
+	nextState, duration := r.stateMachine.GetNextState(ctx, ac)
+	if string(nextState) == currentState && duration == 0 {
+		r.logger.Info(ctx, "Dry run: AccountClaim %s/%s is in final state %s", ac.Namespace, ac.Name, currentState)
+		return reconcile.Result{}
+	}
+	duration = r.behaviorEngine.GetTransitionDelay(ctx, "AccountClaim", ac.Namespace, ac.Name, ac.Labels, duration)
+	r.logger.Info(ctx, "Dry run: would transition AccountClaim %s/%s from %s to %s after %v",
+		ac.Namespace, ac.Name, currentState, nextState, duration)
+	return reconcile.Result{RequeueAfter: duration}
+}
+
+// applyTransientFailure sets the failure condition on the AccountClaim and requeues it to retry
+// its transition
+func (r *AccountClaimReconciler) applyTransientFailure(ctx context.Context, ac *aaov1alpha1.AccountClaim, failure *config.FailureScenario) (reconcile.Result, error) {
+	r.stateMachine.ApplyTransientFailure(ctx, ac, failure)
+	if err := r.client.Status().Update(ctx, ac); err != nil {
+		logWriteError(ctx, r.logger, err, "Failed to update retrying AccountClaim %s/%s status: %v",
+			ac.Namespace, ac.Name, err)
+		return reconcile.Result{}, err
+	}
+	r.events.failed(ac, failure)
+	return reconcile.Result{RequeueAfter: transientRetryInterval}, nil
+}
+
 // applyFailure applies a failure state to the AccountClaim
 func (r *AccountClaimReconciler) applyFailure(ctx context.Context, ac *aaov1alpha1.AccountClaim, failure *config.FailureScenario) (reconcile.Result, error) {
 	if err := r.stateMachine.ApplyFailure(ctx, ac, failure); err != nil {
@@ -129,15 +272,47 @@ func (r *AccountClaimReconciler) applyFailure(ctx context.Context, ac *aaov1alph
 	}
 
 	if err := r.client.Status().Update(ctx, ac); err != nil {
-		r.logger.Error(ctx, "Failed to update failed AccountClaim %s/%s status: %v",
+		logWriteError(ctx, r.logger, err, "Failed to update failed AccountClaim %s/%s status: %v",
 			ac.Namespace, ac.Name, err)
 		return reconcile.Result{}, err
 	}
 
 	r.logger.Info(ctx, "AccountClaim %s/%s failed: %s", ac.Namespace, ac.Name, failure.Message)
+	recordStateTransition("AccountClaim", string(aaov1alpha1.ClaimStatusError))
+	r.behaviorEngine.PublishTransition("AccountClaim", ac.Namespace, ac.Name, string(aaov1alpha1.ClaimStatusError))
+	r.events.failed(ac, failure)
+	notifyTerminal(ctx, r.notifier, "AccountClaim", ac, string(aaov1alpha1.ClaimStatusError))
 	return reconcile.Result{}, nil
 }
 
+// ensureCredentialsSecret creates the AWS credentials secret of a Ready AccountClaim and
+// then marks the claim with the SecretReady condition
+func (r *AccountClaimReconciler) ensureCredentialsSecret(ctx context.Context, ac *aaov1alpha1.AccountClaim) error {
+	if ac.Spec.AwsCredentialSecret.Name == "" {
+		return nil
+	}
+	for _, condition := range ac.Status.Conditions {
+		if condition.Type == SecretReadyCondition && condition.Status == corev1.ConditionTrue {
+			return nil
+		}
+	}
+
+	if err := r.createAWSCredentialsSecret(ctx, ac); err != nil {
+		return err
+	}
+
+	now := metav1.NewTime(r.stateMachine.Now())
+	ac.Status.Conditions = append(ac.Status.Conditions, aaov1alpha1.AccountClaimCondition{
+		Type:               SecretReadyCondition,
+		Status:             corev1.ConditionTrue,
+		Reason:             "SecretCreated",
+		Message:            fmt.Sprintf("AWS credentials secret %s is ready", ac.Spec.AwsCredentialSecret.Name),
+		LastTransitionTime: now,
+		LastProbeTime:      now,
+	})
+	return r.client.Status().Update(ctx, ac)
+}
+
 // createAWSCredentialsSecret creates the AWS credentials secret for the AccountClaim
 func (r *AccountClaimReconciler) createAWSCredentialsSecret(ctx context.Context, ac *aaov1alpha1.AccountClaim) error {
 	// Check if secret already exists
@@ -186,6 +361,11 @@ func (r *AccountClaimReconciler) createAWSCredentialsSecret(ctx context.Context,
 		},
 	}
 
+	if err := stampCredentialSecretMetadata(secret, r.behaviorEngine.GetAccountClaimConfig().CredentialSecret, ac); err != nil {
+		return err
+	}
+	labels.StampRunID(secret, r.behaviorEngine.GetRunID())
+
 	if err := r.client.Create(ctx, secret); err != nil {
 		return err
 	}
@@ -0,0 +1,250 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	aaov1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/aws-account-operator/v1alpha1"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/behavior"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/labels"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/state_machine"
+)
+
+func findACCondition(ac *aaov1alpha1.AccountClaim, conditionType aaov1alpha1.AccountClaimConditionType) *aaov1alpha1.AccountClaimCondition {
+	for i := range ac.Status.Conditions {
+		if ac.Status.Conditions[i].Type == conditionType {
+			return &ac.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+func TestAccountClaimReconciler_SecretReadyAfterSecretCreated(t *testing.T) {
+	ctx := context.Background()
+	ac := &aaov1alpha1.AccountClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "ac-1", Namespace: "default"},
+		Spec: aaov1alpha1.AccountClaimSpec{
+			AwsCredentialSecret: aaov1alpha1.SecretRef{Name: "ac-1-creds", Namespace: "default"},
+		},
+	}
+
+	// The first attempt to create the credentials secret fails
+	failSecret := true
+	k8sClient := createTestClientWithInterceptor(t, interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			if _, ok := obj.(*corev1.Secret); ok && failSecret {
+				failSecret = false
+				return fmt.Errorf("transient error")
+			}
+			return c.Create(ctx, obj, opts...)
+		},
+	}, ac)
+
+	logger := createTestLogger()
+	cfg := config.DefaultConfig()
+	fakeClock := clocktesting.NewFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	engine := behavior.NewEngine(logger, cfg)
+	transitions, unsubscribe := engine.SubscribeTransitions()
+	defer unsubscribe()
+	reconciler := NewAccountClaimReconciler(k8sClient, logger,
+		state_machine.NewAccountClaimStateMachine(logger, cfg.AccountClaim, fakeClock), engine)
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(ac)}
+
+	// The claim becomes Ready but the secret creation fails, the transition is still reported
+	_, err := reconciler.Reconcile(ctx, req)
+	require.Error(t, err)
+	select {
+	case transition := <-transitions:
+		assert.Equal(t, string(aaov1alpha1.ClaimStatusReady), transition.State)
+	default:
+		t.Fatal("the Ready transition was not published")
+	}
+
+	updated := &aaov1alpha1.AccountClaim{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	assert.Equal(t, aaov1alpha1.ClaimStatusReady, updated.Status.State)
+	assert.Nil(t, findACCondition(updated, SecretReadyCondition), "SecretReady must wait for the secret")
+
+	// The next reconcile retries the secret and only then reports SecretReady
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKey{Namespace: "default", Name: "ac-1-creds"}, &corev1.Secret{}))
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	condition := findACCondition(updated, SecretReadyCondition)
+	require.NotNil(t, condition)
+	assert.Equal(t, corev1.ConditionTrue, condition.Status)
+	assert.True(t, condition.LastTransitionTime.Time.Equal(fakeClock.Now()), "SecretReady is stamped with the state machine clock")
+	assert.NotNil(t, findACCondition(updated, aaov1alpha1.AccountClaimed))
+}
+
+func TestAccountClaimReconciler_CredentialSecretMetadata(t *testing.T) {
+	ctx := context.Background()
+	ac := &aaov1alpha1.AccountClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ac-1",
+			Namespace: "default",
+			Labels:    map[string]string{labels.ID: "cluster-123"},
+		},
+		Spec: aaov1alpha1.AccountClaimSpec{
+			AwsCredentialSecret: aaov1alpha1.SecretRef{Name: "ac-1-creds", Namespace: "default"},
+		},
+	}
+	k8sClient := createTestClient(t, ac)
+
+	logger := createTestLogger()
+	cfg := config.DefaultConfig()
+	cfg.RunID = "run-1"
+	cfg.AccountClaim.CredentialSecret = &config.CredentialSecretConfig{
+		Labels:      map[string]string{labels.ID: "{{ .ClusterID }}", "team": "qe"},
+		Annotations: map[string]string{"example.com/claim": "{{ .Namespace }}/{{ .Name }}"},
+	}
+	reconciler := NewAccountClaimReconciler(k8sClient, logger,
+		state_machine.NewAccountClaimStateMachine(logger, cfg.AccountClaim, clock.RealClock{}), behavior.NewEngine(logger, cfg))
+
+	_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(ac)})
+	require.NoError(t, err)
+
+	secret := &corev1.Secret{}
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKey{Namespace: "default", Name: "ac-1-creds"}, secret))
+	assert.Equal(t, map[string]string{labels.ID: "cluster-123", "team": "qe", labels.RunID: "run-1"}, secret.Labels)
+	assert.Equal(t, map[string]string{"example.com/claim": "default/ac-1"}, secret.Annotations)
+}
+
+func TestAccountClaimReconciler_DryRunSkipsWrites(t *testing.T) {
+	ctx := context.Background()
+	ac := &aaov1alpha1.AccountClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "ac-1", Namespace: "default"},
+		Spec: aaov1alpha1.AccountClaimSpec{
+			AwsCredentialSecret: aaov1alpha1.SecretRef{Name: "ac-1-creds", Namespace: "default"},
+		},
+	}
+	k8sClient := createTestClientWithInterceptor(t, interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			t.Errorf("unexpected create of %T %s in dry run", obj, obj.GetName())
+			return c.Create(ctx, obj, opts...)
+		},
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			t.Errorf("unexpected update of %T %s in dry run", obj, obj.GetName())
+			return c.Update(ctx, obj, opts...)
+		},
+		SubResourceUpdate: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+			t.Errorf("unexpected %s update of %T %s in dry run", subResourceName, obj, obj.GetName())
+			return c.SubResource(subResourceName).Update(ctx, obj, opts...)
+		},
+	}, ac)
+
+	logger := createTestLogger()
+	cfg := config.DefaultConfig()
+	reconciler := NewAccountClaimReconciler(k8sClient, logger,
+		state_machine.NewAccountClaimStateMachine(logger, cfg.AccountClaim, clock.RealClock{}), behavior.NewEngine(logger, cfg))
+	reconciler.SetDryRun(true)
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(ac)}
+
+	_, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	updated := &aaov1alpha1.AccountClaim{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	assert.Empty(t, updated.Status.State)
+	assert.Empty(t, updated.Status.Conditions)
+}
+
+func TestAccountClaimReconciler_UpdatesSpecOnlyWhenChanged(t *testing.T) {
+	ctx := context.Background()
+	ac := &aaov1alpha1.AccountClaim{ObjectMeta: metav1.ObjectMeta{Name: "ac-1", Namespace: "default"}}
+
+	specUpdates := 0
+	k8sClient := createTestClientWithInterceptor(t, interceptor.Funcs{
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			if _, ok := obj.(*aaov1alpha1.AccountClaim); ok {
+				specUpdates++
+			}
+			return c.Update(ctx, obj, opts...)
+		},
+	}, ac)
+
+	logger := createTestLogger()
+	cfg := config.DefaultConfig()
+	cfg.AccountClaim.States = []config.StateConfig{{Name: "Pending"}, {Name: "Verifying"}, {Name: "Ready"}}
+	cfg.AccountClaim.FailureScenarios = nil
+	reconciler := NewAccountClaimReconciler(k8sClient, logger,
+		state_machine.NewAccountClaimStateMachine(logger, cfg.AccountClaim, clock.RealClock{}), behavior.NewEngine(logger, cfg))
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(ac)}
+
+	// Verifying only touches the status
+	_, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, ac))
+	assert.Equal(t, aaov1alpha1.ClaimStatus("Verifying"), ac.Status.State)
+	assert.Equal(t, 0, specUpdates)
+
+	// Ready sets the account ID in the spec
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, ac))
+	assert.Equal(t, aaov1alpha1.ClaimStatusReady, ac.Status.State)
+	assert.NotEmpty(t, ac.Spec.BYOCAWSAccountID)
+	assert.Equal(t, 1, specUpdates)
+}
+
+func TestAccountClaimReconciler_LinksAccountWhenReady(t *testing.T) {
+	ctx := context.Background()
+	unset := &aaov1alpha1.AccountClaim{ObjectMeta: metav1.ObjectMeta{Name: "ac-1", Namespace: "default"}}
+	preset := &aaov1alpha1.AccountClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "ac-2", Namespace: "default"},
+		Spec:       aaov1alpha1.AccountClaimSpec{LegalEntity: aaov1alpha1.LegalEntity{ID: "own-id", Name: "Own Org"}},
+	}
+	k8sClient := createTestClient(t, unset, preset)
+
+	seed := int64(42)
+	logger := createTestLogger()
+	cfg := config.DefaultConfig()
+	cfg.RandomSeed = &seed
+	cfg.AccountClaim.States = []config.StateConfig{{Name: "Pending"}, {Name: "Verifying"}, {Name: "Ready"}}
+	cfg.AccountClaim.FailureScenarios = nil
+	cfg.AccountClaim.Account = &config.AccountConfig{LegalEntityID: "sim-id", LegalEntityName: "Simulated Org"}
+	engine := behavior.NewEngine(logger, cfg)
+	reconciler := NewAccountClaimReconciler(k8sClient, logger,
+		state_machine.NewAccountClaimStateMachine(logger, cfg.AccountClaim, clock.RealClock{}), engine)
+
+	for _, claim := range []*aaov1alpha1.AccountClaim{unset, preset} {
+		req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(claim)}
+		ac := &aaov1alpha1.AccountClaim{}
+
+		// The account is only linked once Ready
+		_, err := reconciler.Reconcile(ctx, req)
+		require.NoError(t, err)
+		require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, ac))
+		assert.Equal(t, aaov1alpha1.ClaimStatus("Verifying"), ac.Status.State)
+		assert.Empty(t, ac.Spec.AccountLink)
+
+		_, err = reconciler.Reconcile(ctx, req)
+		require.NoError(t, err)
+		require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, ac))
+		assert.Equal(t, aaov1alpha1.ClaimStatusReady, ac.Status.State)
+		assert.Equal(t, engine.AccountLink(claim.Namespace, claim.Name), ac.Spec.AccountLink)
+	}
+
+	// The configured legal entity only fills in claims without one
+	ac := &aaov1alpha1.AccountClaim{}
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(unset), ac))
+	assert.Equal(t, aaov1alpha1.LegalEntity{ID: "sim-id", Name: "Simulated Org"}, ac.Spec.LegalEntity)
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(preset), ac))
+	assert.Equal(t, aaov1alpha1.LegalEntity{ID: "own-id", Name: "Own Org"}, ac.Spec.LegalEntity)
+}
@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	kuberrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/labels"
+)
+
+// adminKubeconfigSecretKey is the key Hive stores the admin kubeconfig under
+const adminKubeconfigSecretKey = "kubeconfig"
+
+// AdminKubeconfigSecretName returns the name Hive gives the admin kubeconfig secret of a ClusterDeployment
+func AdminKubeconfigSecretName(cd *hivev1.ClusterDeployment) string {
+	return cd.Name + "-admin-kubeconfig"
+}
+
+// reconcileAdminKubeconfig creates the admin kubeconfig secret of a Running ClusterDeployment,
+// if missing, and references it from spec.clusterMetadata. The secret is owned by the
+// ClusterDeployment so it is removed along with it.
+func (r *ClusterDeploymentReconciler) reconcileAdminKubeconfig(ctx context.Context, cd *hivev1.ClusterDeployment) error {
+	secretName := client.ObjectKey{Namespace: cd.Namespace, Name: AdminKubeconfigSecretName(cd)}
+
+	err := r.client.Get(ctx, secretName, &corev1.Secret{})
+	if kuberrors.IsNotFound(err) {
+		err = r.createAdminKubeconfigSecret(ctx, cd, secretName)
+	}
+	if err != nil {
+		return err
+	}
+
+	if cd.Spec.ClusterMetadata == nil {
+		cd.Spec.ClusterMetadata = &hivev1.ClusterMetadata{}
+	}
+	cd.Spec.ClusterMetadata.AdminKubeconfigSecretRef = corev1.LocalObjectReference{Name: secretName.Name}
+	return nil
+}
+
+func (r *ClusterDeploymentReconciler) createAdminKubeconfigSecret(ctx context.Context, cd *hivev1.ClusterDeployment, secretName client.ObjectKey) error {
+	kubeconfig, err := clientcmd.Write(clientcmdapi.Config{
+		Clusters: map[string]*clientcmdapi.Cluster{
+			cd.Name: {Server: cd.Status.APIURL, InsecureSkipTLSVerify: true},
+		},
+		AuthInfos: map[string]*clientcmdapi.AuthInfo{
+			"admin": {Token: fmt.Sprintf("simulated-admin-token-%s", cd.UID)},
+		},
+		Contexts: map[string]*clientcmdapi.Context{
+			"admin": {Cluster: cd.Name, AuthInfo: "admin"},
+		},
+		CurrentContext: "admin",
+	})
+	if err != nil {
+		return err
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName.Name,
+			Namespace: secretName.Namespace,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			adminKubeconfigSecretKey: kubeconfig,
+		},
+	}
+	if err := controllerutil.SetOwnerReference(cd, secret, r.client.Scheme()); err != nil {
+		return err
+	}
+
+	labels.StampRunID(secret, r.behaviorEngine.GetRunID())
+
+	if err := r.client.Create(ctx, secret); err != nil {
+		return err
+	}
+
+	r.logger.Info(ctx, "Created admin kubeconfig secret %s/%s for ClusterDeployment %s/%s",
+		secretName.Namespace, secretName.Name, cd.Namespace, cd.Name)
+	return nil
+}
@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+
+	corev1 "k8s.io/api/core/v1"
+	kuberrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/labels"
+)
+
+// adminUsername is the user Hive stores in the admin password secret of installed clusters
+const adminUsername = "kubeadmin"
+
+// AdminPasswordSecretName returns the name of the admin password secret of a ClusterDeployment
+func AdminPasswordSecretName(cd *hivev1.ClusterDeployment) string {
+	return cd.Name + "-admin-password"
+}
+
+// reconcileAdminPassword creates the admin password secret of a Running ClusterDeployment,
+// if missing, and references it from spec.clusterMetadata. The secret is owned by the
+// ClusterDeployment so it is removed along with it.
+func (r *ClusterDeploymentReconciler) reconcileAdminPassword(ctx context.Context, cd *hivev1.ClusterDeployment) error {
+	secretName := client.ObjectKey{Namespace: cd.Namespace, Name: AdminPasswordSecretName(cd)}
+
+	err := r.client.Get(ctx, secretName, &corev1.Secret{})
+	if kuberrors.IsNotFound(err) {
+		err = r.createAdminPasswordSecret(ctx, cd, secretName)
+	}
+	if err != nil {
+		return err
+	}
+
+	if cd.Spec.ClusterMetadata == nil {
+		cd.Spec.ClusterMetadata = &hivev1.ClusterMetadata{}
+	}
+	cd.Spec.ClusterMetadata.AdminPasswordSecretRef = &corev1.LocalObjectReference{Name: secretName.Name}
+	return nil
+}
+
+func (r *ClusterDeploymentReconciler) createAdminPasswordSecret(ctx context.Context, cd *hivev1.ClusterDeployment, secretName client.ObjectKey) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName.Name,
+			Namespace: secretName.Namespace,
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			"username": []byte(adminUsername),
+			"password": []byte(rand.Text()),
+		},
+	}
+	if err := controllerutil.SetOwnerReference(cd, secret, r.client.Scheme()); err != nil {
+		return err
+	}
+
+	labels.StampRunID(secret, r.behaviorEngine.GetRunID())
+
+	if err := r.client.Create(ctx, secret); err != nil {
+		return err
+	}
+
+	r.logger.Info(ctx, "Created admin password secret %s/%s for ClusterDeployment %s/%s",
+		secretName.Namespace, secretName.Name, cd.Namespace, cd.Name)
+	return nil
+}
@@ -0,0 +1,139 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	kuberrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/labels"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/state_machine"
+)
+
+const (
+	// clusterDeploymentNameLabel is the label Hive puts on the ClusterProvision and DNSZone of a
+	// ClusterDeployment with its name
+	clusterDeploymentNameLabel = "hive.openshift.io/cluster-deployment-name"
+
+	// failedProvisionSuffix is appended to the ProvisionRef of a failed ClusterDeployment
+	failedProvisionSuffix = "-failed"
+)
+
+// reconcileClusterProvision creates the ClusterProvision the ClusterDeployment references, if
+// missing, and advances its stage with the ClusterDeployment: complete once installed, failed
+// once provisioning failed. A failed ClusterDeployment references a provision of its own, the
+// provision of the failed attempt is marked failed too. Provisions are owned by the
+// ClusterDeployment so they are removed along with it.
+func (r *ClusterDeploymentReconciler) reconcileClusterProvision(ctx context.Context, cd *hivev1.ClusterDeployment) error {
+	if cd.Status.ProvisionRef == nil {
+		return nil
+	}
+
+	stage := hivev1.ClusterProvisionStageProvisioning
+	switch {
+	case cd.Spec.Installed:
+		stage = hivev1.ClusterProvisionStageComplete
+	case state_machine.IsFailed(cd):
+		stage = hivev1.ClusterProvisionStageFailed
+		attempt := strings.TrimSuffix(cd.Status.ProvisionRef.Name, failedProvisionSuffix)
+		if err := r.setClusterProvisionStage(ctx, cd, attempt, stage, false); err != nil {
+			return err
+		}
+	}
+	return r.setClusterProvisionStage(ctx, cd, cd.Status.ProvisionRef.Name, stage, true)
+}
+
+// setClusterProvisionStage sets the stage of the named ClusterProvision of the ClusterDeployment,
+// creating the provision if missing and create is set
+func (r *ClusterDeploymentReconciler) setClusterProvisionStage(
+	ctx context.Context,
+	cd *hivev1.ClusterDeployment,
+	name string,
+	stage hivev1.ClusterProvisionStage,
+	create bool,
+) error {
+	provision := &hivev1.ClusterProvision{}
+	err := r.client.Get(ctx, client.ObjectKey{Namespace: cd.Namespace, Name: name}, provision)
+	if kuberrors.IsNotFound(err) {
+		if !create {
+			return nil
+		}
+		return r.createClusterProvision(ctx, cd, name, stage)
+	}
+	if err != nil {
+		return err
+	}
+
+	if provision.Spec.Stage == stage {
+		return nil
+	}
+	provision.Spec.Stage = stage
+	setClusterProvisionResults(provision, cd)
+	if err := r.client.Update(ctx, provision); err != nil {
+		return err
+	}
+
+	r.logger.Debug(ctx, "ClusterProvision %s/%s moved to stage %s", cd.Namespace, name, stage)
+	return nil
+}
+
+func (r *ClusterDeploymentReconciler) createClusterProvision(
+	ctx context.Context,
+	cd *hivev1.ClusterDeployment,
+	name string,
+	stage hivev1.ClusterProvisionStage,
+) error {
+	provision := &hivev1.ClusterProvision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: cd.Namespace,
+			Labels:    map[string]string{clusterDeploymentNameLabel: cd.Name},
+		},
+		Spec: hivev1.ClusterProvisionSpec{
+			ClusterDeploymentRef: corev1.LocalObjectReference{Name: cd.Name},
+			PodSpec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "installer", Image: "simulated-installer"}},
+			},
+			Stage: stage,
+		},
+	}
+	setClusterProvisionResults(provision, cd)
+	if err := controllerutil.SetControllerReference(cd, provision, r.client.Scheme()); err != nil {
+		return err
+	}
+
+	labels.StampRunID(provision, r.behaviorEngine.GetRunID())
+
+	if err := r.client.Create(ctx, provision); err != nil {
+		return err
+	}
+
+	r.logger.Info(ctx, "Created ClusterProvision %s/%s for ClusterDeployment %s/%s in stage %s",
+		cd.Namespace, name, cd.Namespace, cd.Name, stage)
+	return nil
+}
+
+// setClusterProvisionResults fills in the install log of the provision for its stage, and the
+// infrastructure ID once complete
+func setClusterProvisionResults(provision *hivev1.ClusterProvision, cd *hivev1.ClusterDeployment) {
+	log := fmt.Sprintf("level=info msg=\"Simulating installation of cluster %s\"\n", cd.Name)
+	switch provision.Spec.Stage {
+	case hivev1.ClusterProvisionStageComplete:
+		log += "level=info msg=\"Install complete!\"\n"
+		if cd.Spec.ClusterMetadata != nil && cd.Spec.ClusterMetadata.InfraID != "" {
+			infraID := cd.Spec.ClusterMetadata.InfraID
+			provision.Spec.InfraID = &infraID
+		}
+	case hivev1.ClusterProvisionStageFailed:
+		log += "level=error msg=\"Cluster installation failed\"\n"
+	}
+	provision.Spec.InstallLog = &log
+}
@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	kuberrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/openshift-online/ocm-sdk-go/logging"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/logutil"
+)
+
+// clusterClaimRequeueInterval is how often a pending ClusterClaim looks for a ready cluster in its pool
+const clusterClaimRequeueInterval = 5 * time.Second
+
+// ClusterClaimReconciler simulates Hive's ClusterClaim controller: once the configured delay has
+// passed since a ClusterClaim was created, it is bound to the oldest installed and unclaimed
+// ClusterDeployment of its pool. The claim then owns the ClusterDeployment, which leaves the pool.
+type ClusterClaimReconciler struct {
+	client       client.Client
+	logger       logging.Logger
+	configSource func() *config.ClusterPoolConfig
+	namespaces   *namespaceGuard
+	now          func() time.Time
+	accelerate   func(time.Duration) time.Duration
+}
+
+// NewClusterClaimReconciler creates a new ClusterClaim reconciler from the clusterPool section of
+// the configuration
+func NewClusterClaimReconciler(client client.Client, logger logging.Logger, cfg *config.Config) *ClusterClaimReconciler {
+	return &ClusterClaimReconciler{
+		client:       client,
+		logger:       logger,
+		configSource: func() *config.ClusterPoolConfig { return cfg.ClusterPool },
+		namespaces:   newNamespaceGuard(client, logger),
+		now:          time.Now,
+		accelerate:   unaccelerated,
+	}
+}
+
+// SetConfigSource makes the reconciler read the clusterPool section of the configuration
+// from source on every reconcile, so that a reloaded claim delay applies
+func (r *ClusterClaimReconciler) SetConfigSource(source func() *config.ClusterPoolConfig) {
+	r.configSource = source
+}
+
+// SetAccelerate makes the reconciler scale the delay before a ClusterClaim is bound with
+// accelerate
+func (r *ClusterClaimReconciler) SetAccelerate(accelerate func(time.Duration) time.Duration) {
+	r.accelerate = accelerate
+}
+
+// Reconcile binds a ClusterClaim to a ready ClusterDeployment of its pool
+func (r *ClusterClaimReconciler) Reconcile(ctx context.Context, req reconcile.Request) (result reconcile.Result, err error) {
+	defer func() {
+		result, err = requeueOnConflict(result, err)
+		recordReconcile("ClusterClaim", result, err)
+	}()
+
+	ctx = logutil.WithReconcileID(ctx, "clusterclaim", req.Namespace, req.Name)
+	r.logger.Debug(ctx, "Reconciling ClusterClaim %s/%s", req.Namespace, req.Name)
+
+	claim := &hivev1.ClusterClaim{}
+	if err := r.client.Get(ctx, req.NamespacedName, claim); err != nil {
+		if kuberrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		r.logger.Error(ctx, "Failed to get ClusterClaim %s/%s: %v", req.Namespace, req.Name, err)
+		return reconcile.Result{}, err
+	}
+
+	if !claim.DeletionTimestamp.IsZero() || IsClusterClaimBound(claim) {
+		return reconcile.Result{}, nil
+	}
+
+	terminating, err := r.namespaces.isTerminating(ctx, "ClusterClaim", req.Namespace)
+	if err != nil {
+		r.logger.Error(ctx, "Failed to get namespace %s: %v", req.Namespace, err)
+		return reconcile.Result{}, err
+	}
+	if terminating {
+		return reconcile.Result{}, nil
+	}
+
+	now := r.now()
+	delay := r.accelerate(time.Duration(r.configSource().ClaimDelaySeconds) * time.Second)
+	if remaining := claim.CreationTimestamp.Add(delay).Sub(now); remaining > 0 {
+		return reconcile.Result{RequeueAfter: remaining}, nil
+	}
+
+	cds, err := poolClusterDeployments(ctx, r.client, claim.Namespace, claim.Spec.ClusterPoolName)
+	if err != nil {
+		r.logger.Error(ctx, "Failed to list ClusterDeployments of ClusterPool %s/%s: %v",
+			claim.Namespace, claim.Spec.ClusterPoolName, err)
+		return reconcile.Result{}, err
+	}
+	cd := claimableClusterDeployment(cds, claim.Name)
+	if cd == nil {
+		r.logger.Debug(ctx, "ClusterClaim %s/%s is waiting for a ready cluster in pool %s, requeue after %v",
+			claim.Namespace, claim.Name, claim.Spec.ClusterPoolName, clusterClaimRequeueInterval)
+		changed := setClusterClaimCondition(claim, hivev1.ClusterClaimPendingCondition, corev1.ConditionTrue,
+			"NoClusters", fmt.Sprintf("No clusters in pool %s are ready to be claimed", claim.Spec.ClusterPoolName), now)
+		if changed {
+			if err := r.client.Status().Update(ctx, claim); err != nil {
+				logWriteError(ctx, r.logger, err, "Failed to update ClusterClaim %s/%s status: %v", claim.Namespace, claim.Name, err)
+				return reconcile.Result{}, err
+			}
+		}
+		return reconcile.Result{RequeueAfter: clusterClaimRequeueInterval}, nil
+	}
+
+	// Assign the ClusterDeployment first, a conflict means another claim got it and this one
+	// is retried. Ownership moves from the pool to the claim, deleting the claim deletes the cluster.
+	if cd.Spec.ClusterPoolRef.ClaimName == "" {
+		claimedTime := metav1.NewTime(now)
+		cd.Spec.ClusterPoolRef.ClaimName = claim.Name
+		cd.Spec.ClusterPoolRef.ClaimedTimestamp = &claimedTime
+		cd.OwnerReferences = slices.DeleteFunc(cd.OwnerReferences, func(ref metav1.OwnerReference) bool {
+			return ref.Controller != nil && *ref.Controller
+		})
+		if err := controllerutil.SetControllerReference(claim, cd, r.client.Scheme()); err != nil {
+			return reconcile.Result{}, err
+		}
+		if err := r.client.Update(ctx, cd); err != nil {
+			logWriteError(ctx, r.logger, err, "Failed to assign ClusterDeployment %s/%s to ClusterClaim %s: %v",
+				cd.Namespace, cd.Name, claim.Name, err)
+			return reconcile.Result{}, err
+		}
+	}
+
+	claim.Spec.Namespace = cd.Namespace
+	if err := r.client.Update(ctx, claim); err != nil {
+		logWriteError(ctx, r.logger, err, "Failed to update ClusterClaim %s/%s: %v", claim.Namespace, claim.Name, err)
+		return reconcile.Result{}, err
+	}
+
+	claim.Status.Lifetime = claim.Spec.Lifetime
+	setClusterClaimCondition(claim, hivev1.ClusterClaimPendingCondition, corev1.ConditionFalse,
+		"ClusterClaimed", "Cluster claimed", now)
+	setClusterClaimCondition(claim, hivev1.ClusterRunningCondition, corev1.ConditionTrue,
+		"Running", "Cluster is running", now)
+	if err := r.client.Status().Update(ctx, claim); err != nil {
+		logWriteError(ctx, r.logger, err, "Failed to update ClusterClaim %s/%s status: %v", claim.Namespace, claim.Name, err)
+		return reconcile.Result{}, err
+	}
+
+	r.logger.Info(ctx, "ClusterClaim %s/%s claimed ClusterDeployment %s from pool %s",
+		claim.Namespace, claim.Name, cd.Name, claim.Spec.ClusterPoolName)
+	return reconcile.Result{}, nil
+}
+
+// claimableClusterDeployment returns the ClusterDeployment already assigned to the claim, if a
+// previous reconcile got that far, or else the oldest installed and unclaimed one
+func claimableClusterDeployment(cds []hivev1.ClusterDeployment, claimName string) *hivev1.ClusterDeployment {
+	var oldest *hivev1.ClusterDeployment
+	for i := range cds {
+		cd := &cds[i]
+		if !cd.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if cd.Spec.ClusterPoolRef.ClaimName == claimName {
+			return cd
+		}
+		if cd.Spec.ClusterPoolRef.ClaimName != "" || !cd.Spec.Installed {
+			continue
+		}
+		if oldest == nil || cd.CreationTimestamp.Before(&oldest.CreationTimestamp) {
+			oldest = cd
+		}
+	}
+	return oldest
+}
+
+// setClusterClaimCondition sets a condition of the ClusterClaim, keeping its transition time
+// when the status is unchanged. It returns true if the condition changed.
+func setClusterClaimCondition(claim *hivev1.ClusterClaim, conditionType hivev1.ClusterClaimConditionType,
+	status corev1.ConditionStatus, reason, message string, now time.Time) bool {
+	condition := hivev1.ClusterClaimCondition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: metav1.NewTime(now),
+		LastProbeTime:      metav1.NewTime(now),
+	}
+	for i, existing := range claim.Status.Conditions {
+		if existing.Type != conditionType {
+			continue
+		}
+		if existing.Status == status && existing.Reason == reason && existing.Message == message {
+			return false
+		}
+		if existing.Status == status {
+			condition.LastTransitionTime = existing.LastTransitionTime
+		}
+		claim.Status.Conditions[i] = condition
+		return true
+	}
+	claim.Status.Conditions = append(claim.Status.Conditions, condition)
+	return true
+}
+
+// IsClusterClaimBound returns true if the ClusterClaim has been assigned a cluster, which is
+// then found in the namespace of its spec
+func IsClusterClaimBound(claim *hivev1.ClusterClaim) bool {
+	for _, condition := range claim.Status.Conditions {
+		if condition.Type == hivev1.ClusterRunningCondition {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+)
+
+func createTestClusterClaimReconciler(k8sClient client.Client, poolCfg *config.ClusterPoolConfig) *ClusterClaimReconciler {
+	cfg := config.DefaultConfig()
+	cfg.ClusterPool = poolCfg
+	return NewClusterClaimReconciler(k8sClient, createTestLogger(), cfg)
+}
+
+func testClusterClaim(name string, created time.Time) *hivev1.ClusterClaim {
+	return &hivev1.ClusterClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "default",
+			UID:               types.UID("uid-" + name),
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: hivev1.ClusterClaimSpec{ClusterPoolName: "test-pool"},
+	}
+}
+
+func findClusterClaimCondition(claim *hivev1.ClusterClaim, conditionType hivev1.ClusterClaimConditionType) *hivev1.ClusterClaimCondition {
+	for i := range claim.Status.Conditions {
+		if claim.Status.Conditions[i].Type == conditionType {
+			return &claim.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+func TestClusterClaimReconciler_BindsOldestReadyCluster(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	claim := testClusterClaim("test-claim", now)
+	k8sClient := createTestClient(t, claim,
+		testPoolClusterDeployment("test-pool-aaaaa", now.Add(-3*time.Hour), false, ""),
+		testPoolClusterDeployment("test-pool-bbbbb", now.Add(-time.Hour), true, ""),
+		testPoolClusterDeployment("test-pool-ccccc", now.Add(-2*time.Hour), true, ""),
+		testPoolClusterDeployment("test-pool-ddddd", now.Add(-4*time.Hour), true, "other-claim"),
+	)
+	reconciler := createTestClusterClaimReconciler(k8sClient, &config.ClusterPoolConfig{})
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(claim)}
+
+	result, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+
+	// The oldest installed cluster not claimed by anyone else is assigned to the claim
+	cd := &hivev1.ClusterDeployment{}
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKey{Namespace: "default", Name: "test-pool-ccccc"}, cd))
+	assert.Equal(t, "test-claim", cd.Spec.ClusterPoolRef.ClaimName)
+	assert.NotNil(t, cd.Spec.ClusterPoolRef.ClaimedTimestamp)
+	owner := metav1.GetControllerOf(cd)
+	require.NotNil(t, owner)
+	assert.Equal(t, "ClusterClaim", owner.Kind)
+	assert.Equal(t, "test-claim", owner.Name)
+
+	updated := &hivev1.ClusterClaim{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	assert.True(t, IsClusterClaimBound(updated))
+	assert.Equal(t, "default", updated.Spec.Namespace)
+	pending := findClusterClaimCondition(updated, hivev1.ClusterClaimPendingCondition)
+	require.NotNil(t, pending)
+	assert.Equal(t, corev1.ConditionFalse, pending.Status)
+	assert.Equal(t, "ClusterClaimed", pending.Reason)
+	running := findClusterClaimCondition(updated, hivev1.ClusterRunningCondition)
+	require.NotNil(t, running)
+	assert.Equal(t, corev1.ConditionTrue, running.Status)
+
+	// A bound claim is left alone
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	after := &hivev1.ClusterClaim{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, after))
+	assert.Equal(t, updated.ResourceVersion, after.ResourceVersion)
+}
+
+func TestClusterClaimReconciler_PendingWithoutReadyCluster(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	claim := testClusterClaim("test-claim", now)
+	k8sClient := createTestClient(t, claim, testPoolClusterDeployment("test-pool-aaaaa", now, false, ""))
+	reconciler := createTestClusterClaimReconciler(k8sClient, &config.ClusterPoolConfig{ClaimDelaySeconds: 10})
+	reconciler.now = func() time.Time { return now.Add(4 * time.Second) }
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(claim)}
+
+	// Within the delay the claim is not looked at
+	result, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, 6*time.Second, result.RequeueAfter)
+	updated := &hivev1.ClusterClaim{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	assert.Empty(t, updated.Status.Conditions)
+
+	// Then it is pending until a cluster of the pool is installed
+	reconciler.now = func() time.Time { return now.Add(10 * time.Second) }
+	result, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, clusterClaimRequeueInterval, result.RequeueAfter)
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	assert.False(t, IsClusterClaimBound(updated))
+	pending := findClusterClaimCondition(updated, hivev1.ClusterClaimPendingCondition)
+	require.NotNil(t, pending)
+	assert.Equal(t, corev1.ConditionTrue, pending.Status)
+	assert.Equal(t, "NoClusters", pending.Reason)
+
+	cd := &hivev1.ClusterDeployment{}
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKey{Namespace: "default", Name: "test-pool-aaaaa"}, cd))
+	cd.Spec.Installed = true
+	require.NoError(t, k8sClient.Update(ctx, cd))
+
+	result, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	assert.True(t, IsClusterClaimBound(updated))
+	pending = findClusterClaimCondition(updated, hivev1.ClusterClaimPendingCondition)
+	require.NotNil(t, pending)
+	assert.Equal(t, corev1.ConditionFalse, pending.Status)
+}
@@ -2,11 +2,16 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	kuberrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/openshift-online/ocm-sdk-go/logging"
@@ -18,14 +23,34 @@ import (
 	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
 	"github.com/tzvatot/openshift-hive-simulator/pkg/state_machine"
 	"github.com/tzvatot/openshift-hive-simulator/pkg/labels"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/logutil"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/notify"
 )
 
+// pausedRequeueInterval is how often a paused resource is checked for being resumed
+const pausedRequeueInterval = 5 * time.Second
+
+// reconcilePausedRequeueInterval is how often resources are checked for reconciliation being
+// resumed, short so that they all pick up soon after it is
+const reconcilePausedRequeueInterval = time.Second
+
+// transientRetryInterval is how long a resource waits after a transient failure before it
+// retries its transition
+const transientRetryInterval = 5 * time.Second
+
+// dnsZoneRequeueInterval is how often a ClusterDeployment waiting for its DNSZone checks it
+const dnsZoneRequeueInterval = 2 * time.Second
+
 // ClusterDeploymentReconciler reconciles ClusterDeployment objects
 type ClusterDeploymentReconciler struct {
 	client         client.Client
 	logger         logging.Logger
 	stateMachine   *state_machine.ClusterDeploymentStateMachine
 	behaviorEngine *behavior.Engine
+	namespaces     *namespaceGuard
+	events         *eventEmitter
+	notifier       *notify.Notifier
+	dryRun         bool
 }
 
 // NewClusterDeploymentReconciler creates a new ClusterDeployment reconciler
@@ -40,13 +65,45 @@ func NewClusterDeploymentReconciler(
 		logger:         logger,
 		stateMachine:   stateMachine,
 		behaviorEngine: behaviorEngine,
+		namespaces:     newNamespaceGuard(client, logger),
+		events:         newEventEmitter(behaviorEngine),
 	}
 }
 
+// SetEventRecorder sets the recorder of the events emitted for ClusterDeployment transitions, no events
+// are emitted without one
+func (r *ClusterDeploymentReconciler) SetEventRecorder(recorder record.EventRecorder) {
+	r.events.recorder = recorder
+}
+
+// SetNotifier sets the notifier of the webhooks registered for terminal states, no webhooks are
+// notified without one
+func (r *ClusterDeploymentReconciler) SetNotifier(notifier *notify.Notifier) {
+	r.notifier = notifier
+}
+
+// SetDryRun sets whether ClusterDeployment transitions are only logged, without writing anything to the
+// API server
+func (r *ClusterDeploymentReconciler) SetDryRun(dryRun bool) {
+	r.dryRun = dryRun
+}
+
 // Reconcile reconciles a ClusterDeployment
-func (r *ClusterDeploymentReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+func (r *ClusterDeploymentReconciler) Reconcile(ctx context.Context, req reconcile.Request) (result reconcile.Result, err error) {
+	defer func() {
+		result, err = requeueOnConflict(result, err)
+		recordReconcile("ClusterDeployment", result, err)
+	}()
+
+	ctx = logutil.WithReconcileID(ctx, "cd", req.Namespace, req.Name)
 	r.logger.Debug(ctx, "Reconciling ClusterDeployment %s/%s", req.Namespace, req.Name)
 
+	// Leave every resource as it is while reconciliation is paused
+	if r.behaviorEngine.IsReconcilePaused() {
+		r.logger.Debug(ctx, "Reconciliation is paused, requeuing ClusterDeployment %s/%s", req.Namespace, req.Name)
+		return reconcile.Result{RequeueAfter: reconcilePausedRequeueInterval}, nil
+	}
+
 	cd := &hivev1.ClusterDeployment{}
 	if err := r.client.Get(ctx, req.NamespacedName, cd); err != nil {
 		if kuberrors.IsNotFound(err) {
@@ -57,66 +114,237 @@ func (r *ClusterDeploymentReconciler) Reconcile(ctx context.Context, req reconci
 		return reconcile.Result{}, err
 	}
 
-	// Skip if being deleted
+	// Only log the transition that would be applied in dry run
+	if r.dryRun {
+		return r.planTransition(ctx, cd), nil
+	}
+
+	// Walk through the deprovision states if being deleted
 	if !cd.DeletionTimestamp.IsZero() {
-		r.logger.Debug(ctx, "ClusterDeployment %s/%s is being deleted, skipping", req.Namespace, req.Name)
+		return r.reconcileDeprovision(ctx, cd)
+	}
+
+	// Skip if the namespace is being deleted, writes to it would be rejected
+	terminating, err := r.namespaces.isTerminating(ctx, "ClusterDeployment", req.Namespace)
+	if err != nil {
+		r.logger.Error(ctx, "Failed to get namespace %s: %v", req.Namespace, err)
+		return reconcile.Result{}, err
+	}
+	if terminating {
 		return reconcile.Result{}, nil
 	}
 
-	// Skip if already installed
+	// Hold deletion until the deprovision flow has run, only when deprovision states are configured
+	deprovisionStates := r.behaviorEngine.GetClusterDeploymentConfig().DeprovisionStates
+	if len(deprovisionStates) > 0 && !controllerutil.ContainsFinalizer(cd, hivev1.FinalizerDeprovision) {
+		controllerutil.AddFinalizer(cd, hivev1.FinalizerDeprovision)
+		if err := r.client.Update(ctx, cd); err != nil {
+			logWriteError(ctx, r.logger, err, "Failed to add deprovision finalizer to ClusterDeployment %s/%s: %v",
+				cd.Namespace, cd.Name, err)
+			return reconcile.Result{}, err
+		}
+	}
+
+	// Hold a paused ClusterDeployment in its current state, including its power state
+	if r.behaviorEngine.IsPaused("ClusterDeployment", cd.Namespace, cd.Name) {
+		r.logger.Debug(ctx, "ClusterDeployment %s/%s is paused", req.Namespace, req.Name)
+		return reconcile.Result{RequeueAfter: pausedRequeueInterval}, nil
+	}
+
+	// Skip state transitions if already installed, only keep the upgrade signal and power state current
 	if cd.Spec.Installed {
 		r.logger.Debug(ctx, "ClusterDeployment %s/%s is already installed, skipping", req.Namespace, req.Name)
-		return reconcile.Result{}, nil
+		if err := r.reconcileUpgradeAvailable(ctx, cd); err != nil {
+			logWriteError(ctx, r.logger, err, "Failed to update upgrade availability of ClusterDeployment %s/%s: %v",
+				cd.Namespace, cd.Name, err)
+			return reconcile.Result{}, err
+		}
+		logsAfter, err := r.reconcileInstallLogs(ctx, cd)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		versionAfter, err := r.reconcileVersionSkew(ctx, cd)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		result, err := r.reconcilePowerState(ctx, cd)
+		requeueSooner(&result, logsAfter)
+		requeueSooner(&result, versionAfter)
+		return result, err
+	}
+
+	// Start over from Pending once spec.installed is set back to false, simulating a reinstall
+	if r.stateMachine.IsReinstallRequested(cd) {
+		r.stateMachine.RewindForReinstall(cd)
+		if err := r.updateWithStatus(ctx, cd); err != nil {
+			logWriteError(ctx, r.logger, err, "Failed to reset ClusterDeployment %s/%s for reinstall: %v",
+				cd.Namespace, cd.Name, err)
+			return reconcile.Result{}, err
+		}
+		r.logger.Info(ctx, "ClusterDeployment %s/%s is no longer installed, provisioning it again", cd.Namespace, cd.Name)
+	}
+
+	// A failed ClusterDeployment only has its install logs left to gather
+	if state_machine.IsFailed(cd) {
+		logsAfter, err := r.reconcileInstallLogs(ctx, cd)
+		return reconcile.Result{RequeueAfter: logsAfter}, err
 	}
 
-	// Check for forced failure
-	shouldFail, failure := r.behaviorEngine.ShouldFail(ctx, "ClusterDeployment", cd.Namespace, cd.Name)
-	if shouldFail {
+	// Spread out the first transition of resources created together
+	if delay := r.stateMachine.StartDelay(cd); delay > 0 {
+		r.logger.Debug(ctx, "Delaying first transition of ClusterDeployment %s/%s by %v", cd.Namespace, cd.Name, delay)
+		return reconcile.Result{RequeueAfter: delay}, nil
+	}
+
+	// Hold a stuck ClusterDeployment in Provisioning until it recovers
+	stuckStatus, recoverAfter := r.stateMachine.GetStuckStatus(cd, r.stateMachine.Now())
+	switch stuckStatus {
+	case state_machine.Stuck:
+		r.logger.Debug(ctx, "ClusterDeployment %s/%s is stuck, recovering after %v", cd.Namespace, cd.Name, recoverAfter)
+		return reconcile.Result{RequeueAfter: recoverAfter}, nil
+	case state_machine.StuckRecoveryDue:
+		r.stateMachine.RecoverStuck(ctx, cd)
+		if err := r.updateWithStatus(ctx, cd); err != nil {
+			logWriteError(ctx, r.logger, err, "Failed to recover stuck ClusterDeployment %s/%s: %v", cd.Namespace, cd.Name, err)
+			return reconcile.Result{}, err
+		}
+	}
+
+	// Check for forced failure, a stuck failure only applies until the ClusterDeployment has recovered
+	shouldFail, failure := r.behaviorEngine.ShouldFail(ctx, "ClusterDeployment", cd.Namespace, cd.Name, r.stateMachine.CurrentState(cd), cd.Labels)
+	if shouldFail && failure.RetriesBeforeSuccess > 0 {
+		return r.applyTransientFailure(ctx, cd, failure)
+	}
+	if shouldFail && !failure.Stuck {
 		return r.applyFailure(ctx, cd, failure)
 	}
+	if shouldFail && stuckStatus == state_machine.NotStuck {
+		return r.applyStuck(ctx, cd, failure)
+	}
 
 	// Check dependencies if configured
-	if r.stateMachine.ShouldWaitForDependencies() {
-		ready, requeueAfter := r.checkDependencies(ctx, cd)
+	if r.stateMachine.ShouldWaitForDependencies(cd) {
+		ready, requeueAfter, waitingFor := r.checkDependencies(ctx, cd)
 		if !ready {
 			r.logger.Debug(ctx, "ClusterDeployment %s/%s waiting for dependencies, requeue after %v",
 				cd.Namespace, cd.Name, requeueAfter)
+			if err := r.setWaitingCondition(ctx, cd, waitingFor); err != nil {
+				r.logger.Error(ctx, "Failed to set waiting condition on ClusterDeployment %s/%s: %v",
+					cd.Namespace, cd.Name, err)
+				return reconcile.Result{}, err
+			}
 			return reconcile.Result{RequeueAfter: requeueAfter}, nil
 		}
 	}
 
-	// Determine next state and apply it
+	// Determine next state, Installing waits for the DNSZone of the ClusterDeployment if configured
 	nextState, duration := r.stateMachine.GetNextState(ctx, cd)
+	if nextState == "Installing" && r.behaviorEngine.GetClusterDeploymentConfig().DependsOnDNSZone {
+		ready, err := r.checkDNSZone(ctx, cd)
+		if err != nil {
+			logWriteError(ctx, r.logger, err, "Failed to get DNSZone of ClusterDeployment %s/%s: %v",
+				cd.Namespace, cd.Name, err)
+			return reconcile.Result{}, err
+		}
+		if !ready {
+			r.logger.Debug(ctx, "ClusterDeployment %s/%s waiting for its DNSZone, requeue after %v",
+				cd.Namespace, cd.Name, dnsZoneRequeueInterval)
+			if err := r.setWaitingCondition(ctx, cd, "DNSZone"); err != nil {
+				r.logger.Error(ctx, "Failed to set waiting condition on ClusterDeployment %s/%s: %v",
+					cd.Namespace, cd.Name, err)
+				return reconcile.Result{}, err
+			}
+			return reconcile.Result{RequeueAfter: dnsZoneRequeueInterval}, nil
+		}
+	}
+	removeWaitingConditions(cd)
 
-	// Apply the state
+	// Apply the next state
+	installPhase := cd.Annotations[state_machine.InstallPhaseAnnotation]
+	recordedState := cd.Annotations[state_machine.StateAnnotation]
 	if err := r.stateMachine.ApplyState(ctx, cd, nextState); err != nil {
 		r.logger.Error(ctx, "Failed to apply state %s to ClusterDeployment %s/%s: %v",
 			nextState, cd.Namespace, cd.Name, err)
 		return reconcile.Result{}, err
 	}
 
-	// Update the ClusterDeployment status
-	if err := r.client.Status().Update(ctx, cd); err != nil {
-		r.logger.Error(ctx, "Failed to update ClusterDeployment %s/%s status: %v",
+	if nextState == "Running" {
+		if cd.Spec.ClusterMetadata.ClusterID == "" {
+			cd.Spec.ClusterMetadata.ClusterID = r.behaviorEngine.ClusterID(cd.Namespace, cd.Name)
+		}
+
+		// Create the admin credentials before the ClusterDeployment references them
+		if r.behaviorEngine.GetClusterDeploymentConfig().AdminKubeconfig {
+			if err := r.reconcileAdminKubeconfig(ctx, cd); err != nil {
+				r.logger.Error(ctx, "Failed to create admin kubeconfig of ClusterDeployment %s/%s: %v",
+					cd.Namespace, cd.Name, err)
+				return reconcile.Result{}, err
+			}
+			if err := r.reconcileAdminPassword(ctx, cd); err != nil {
+				r.logger.Error(ctx, "Failed to create admin password of ClusterDeployment %s/%s: %v",
+					cd.Namespace, cd.Name, err)
+				return reconcile.Result{}, err
+			}
+		}
+	}
+
+	// Update the ClusterDeployment, including the spec once Installed is set or the install
+	// phase or recorded state changed
+	if cd.Spec.Installed || cd.Annotations[state_machine.InstallPhaseAnnotation] != installPhase ||
+		cd.Annotations[state_machine.StateAnnotation] != recordedState {
+		if err := r.updateWithStatus(ctx, cd); err != nil {
+			logWriteError(ctx, r.logger, err, "Failed to update ClusterDeployment %s/%s: %v",
+				cd.Namespace, cd.Name, err)
+			return reconcile.Result{}, err
+		}
+	} else if err := r.client.Status().Update(ctx, cd); err != nil {
+		logWriteError(ctx, r.logger, err, "Failed to update ClusterDeployment %s/%s status: %v",
+			cd.Namespace, cd.Name, err)
+		return reconcile.Result{}, err
+	}
+
+	if err := r.reconcileClusterProvision(ctx, cd); err != nil {
+		logWriteError(ctx, r.logger, err, "Failed to update ClusterProvision of ClusterDeployment %s/%s: %v",
 			cd.Namespace, cd.Name, err)
 		return reconcile.Result{}, err
 	}
 
-	// Also update spec if Installed was set
+	r.logger.Info(ctx, "ClusterDeployment %s/%s transitioned to state: %s", cd.Namespace, cd.Name, nextState)
+	recordStateTransition("ClusterDeployment", nextState)
+	r.behaviorEngine.PublishTransition("ClusterDeployment", cd.Namespace, cd.Name, nextState)
+	r.events.transitioned(cd, nextState, cd.Spec.Installed)
 	if cd.Spec.Installed {
-		if err := r.client.Update(ctx, cd); err != nil {
-			r.logger.Error(ctx, "Failed to update ClusterDeployment %s/%s spec: %v",
+		notifyTerminal(ctx, r.notifier, "ClusterDeployment", cd, nextState)
+	}
+
+	if nextState == "Running" {
+		if err := r.reconcileUpgradeAvailable(ctx, cd); err != nil {
+			logWriteError(ctx, r.logger, err, "Failed to update upgrade availability of ClusterDeployment %s/%s: %v",
 				cd.Namespace, cd.Name, err)
 			return reconcile.Result{}, err
 		}
 	}
 
-	r.logger.Info(ctx, "ClusterDeployment %s/%s transitioned to state: %s", cd.Namespace, cd.Name, nextState)
+	if cd.Spec.Installed {
+		logsAfter, err := r.reconcileInstallLogs(ctx, cd)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		versionAfter, err := r.reconcileVersionSkew(ctx, cd)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if duration == 0 {
+			result := reconcile.Result{RequeueAfter: logsAfter}
+			requeueSooner(&result, versionAfter)
+			return result, nil
+		}
+	}
 
 	// Requeue after duration for next state transition
 	if duration > 0 {
 		// Check for delay override
-		duration = r.behaviorEngine.GetTransitionDelay(ctx, "ClusterDeployment", cd.Namespace, cd.Name, duration)
+		duration = r.behaviorEngine.GetTransitionDelay(ctx, "ClusterDeployment", cd.Namespace, cd.Name, cd.Labels, duration)
 		r.logger.Debug(ctx, "Requeuing ClusterDeployment %s/%s after %v", cd.Namespace, cd.Name, duration)
 		return reconcile.Result{RequeueAfter: duration}, nil
 	}
@@ -124,8 +352,120 @@ func (r *ClusterDeploymentReconciler) Reconcile(ctx context.Context, req reconci
 	return reconcile.Result{}, nil
 }
 
-// checkDependencies checks if AccountClaim or ProjectClaim dependencies are ready
-func (r *ClusterDeploymentReconciler) checkDependencies(ctx context.Context, cd *hivev1.ClusterDeployment) (bool, time.Duration) {
+// planTransition logs the next transition of a ClusterDeployment without applying it, requeuing it
+// for when the transition would be due
+func (r *ClusterDeploymentReconciler) planTransition(ctx context.Context, cd *hivev1.ClusterDeployment) reconcile.Result {
+	currentState := r.stateMachine.CurrentState(cd)
+	nextState, duration := r.stateMachine.GetNextState(ctx, cd)
+	if nextState == currentState && duration == 0 {
+		r.logger.Info(ctx, "Dry run: ClusterDeployment %s/%s is in final state %s", cd.Namespace, cd.Name, currentState)
+		return reconcile.Result{}
+	}
+	duration = r.behaviorEngine.GetTransitionDelay(ctx, "ClusterDeployment", cd.Namespace, cd.Name, cd.Labels, duration)
+	r.logger.Info(ctx, "Dry run: would transition ClusterDeployment %s/%s from %s to %s after %v",
+		cd.Namespace, cd.Name, currentState, nextState, duration)
+	return reconcile.Result{RequeueAfter: duration}
+}
+
+// reconcilePowerState moves an installed ClusterDeployment towards its requested
+// spec.powerState. A cluster already in the requested power state is left untouched.
+func (r *ClusterDeploymentReconciler) reconcilePowerState(ctx context.Context, cd *hivev1.ClusterDeployment) (reconcile.Result, error) {
+	now := r.stateMachine.Now()
+	nextState, remaining := r.stateMachine.GetNextPowerState(cd, now)
+	if nextState == "" {
+		return reconcile.Result{RequeueAfter: remaining}, nil
+	}
+
+	r.stateMachine.ApplyPowerState(ctx, cd, nextState, now)
+	if err := r.client.Status().Update(ctx, cd); err != nil {
+		logWriteError(ctx, r.logger, err, "Failed to update ClusterDeployment %s/%s power state: %v",
+			cd.Namespace, cd.Name, err)
+		return reconcile.Result{}, err
+	}
+
+	r.logger.Info(ctx, "ClusterDeployment %s/%s transitioned to power state: %s", cd.Namespace, cd.Name, nextState)
+	return reconcile.Result{RequeueAfter: r.stateMachine.PowerStateDuration(nextState)}, nil
+}
+
+// reconcileInstallLogs sets the InstallLogsGathered condition of an installed or failed
+// ClusterDeployment once its logs are due. It returns how long until they are due, if not yet.
+func (r *ClusterDeploymentReconciler) reconcileInstallLogs(ctx context.Context, cd *hivev1.ClusterDeployment) (time.Duration, error) {
+	now := r.stateMachine.Now()
+	due, remaining := r.stateMachine.GetInstallLogsDelay(cd, now)
+	if !due {
+		return remaining, nil
+	}
+
+	r.stateMachine.ApplyInstallLogsGathered(ctx, cd, now)
+	if err := r.client.Status().Update(ctx, cd); err != nil {
+		logWriteError(ctx, r.logger, err, "Failed to update ClusterDeployment %s/%s install logs condition: %v",
+			cd.Namespace, cd.Name, err)
+		return 0, err
+	}
+	return 0, nil
+}
+
+// reconcileDeprovision moves a deleted ClusterDeployment through the configured deprovision
+// states and removes the deprovision finalizer once the last state is reached
+func (r *ClusterDeploymentReconciler) reconcileDeprovision(ctx context.Context, cd *hivev1.ClusterDeployment) (reconcile.Result, error) {
+	if !controllerutil.ContainsFinalizer(cd, hivev1.FinalizerDeprovision) {
+		r.logger.Debug(ctx, "ClusterDeployment %s/%s is being deleted, skipping", cd.Namespace, cd.Name)
+		return reconcile.Result{}, nil
+	}
+
+	nextState, duration, done := r.stateMachine.GetDeprovisionState(ctx, cd)
+	if done {
+		if err := r.stateMachine.ApplyDeprovisionState(ctx, cd, ""); err != nil {
+			return reconcile.Result{}, err
+		}
+		controllerutil.RemoveFinalizer(cd, hivev1.FinalizerDeprovision)
+		if err := r.client.Update(ctx, cd); err != nil {
+			logWriteError(ctx, r.logger, err, "Failed to remove deprovision finalizer from ClusterDeployment %s/%s: %v",
+				cd.Namespace, cd.Name, err)
+			return reconcile.Result{}, err
+		}
+		r.logger.Info(ctx, "ClusterDeployment %s/%s deprovisioned", cd.Namespace, cd.Name)
+		return reconcile.Result{}, nil
+	}
+
+	if err := r.stateMachine.ApplyDeprovisionState(ctx, cd, nextState); err != nil {
+		r.logger.Error(ctx, "Failed to apply deprovision state %s to ClusterDeployment %s/%s: %v",
+			nextState, cd.Namespace, cd.Name, err)
+		return reconcile.Result{}, err
+	}
+
+	if err := r.updateWithStatus(ctx, cd); err != nil {
+		logWriteError(ctx, r.logger, err, "Failed to update ClusterDeployment %s/%s deprovision state: %v",
+			cd.Namespace, cd.Name, err)
+		return reconcile.Result{}, err
+	}
+
+	r.logger.Info(ctx, "ClusterDeployment %s/%s transitioned to deprovision state: %s", cd.Namespace, cd.Name, nextState)
+
+	// A zero duration relies on the update event to move on to the next state
+	if duration > 0 {
+		duration = r.behaviorEngine.GetTransitionDelay(ctx, "ClusterDeployment", cd.Namespace, cd.Name, cd.Labels, duration)
+		r.logger.Debug(ctx, "Requeuing ClusterDeployment %s/%s after %v", cd.Namespace, cd.Name, duration)
+		return reconcile.Result{RequeueAfter: duration}, nil
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// updateWithStatus writes both the metadata and the status of the ClusterDeployment. The
+// metadata update returns the stored status, so the new status is kept for its own update.
+func (r *ClusterDeploymentReconciler) updateWithStatus(ctx context.Context, cd *hivev1.ClusterDeployment) error {
+	status := cd.Status.DeepCopy()
+	if err := r.client.Update(ctx, cd); err != nil {
+		return err
+	}
+	cd.Status = *status
+	return r.client.Status().Update(ctx, cd)
+}
+
+// checkDependencies checks if AccountClaim or ProjectClaim dependencies are ready.
+// When not ready, it also returns the kind of the dependency being waited for.
+func (r *ClusterDeploymentReconciler) checkDependencies(ctx context.Context, cd *hivev1.ClusterDeployment) (bool, time.Duration, string) {
 	cfg := r.behaviorEngine.GetClusterDeploymentConfig()
 
 	// Determine which dependency to check based on labels
@@ -136,7 +476,7 @@ func (r *ClusterDeploymentReconciler) checkDependencies(ctx context.Context, cd
 	if cfg.DependsOnAccountClaim && (cloudProvider == "aws" || cloudProvider == "") {
 		ready, requeue := r.checkAccountClaim(ctx, cd)
 		if !ready {
-			return false, requeue
+			return false, requeue, "AccountClaim"
 		}
 	}
 
@@ -144,81 +484,248 @@ func (r *ClusterDeploymentReconciler) checkDependencies(ctx context.Context, cd
 	if cfg.DependsOnProjectClaim && cloudProvider == "gcp" {
 		ready, requeue := r.checkProjectClaim(ctx, cd)
 		if !ready {
-			return false, requeue
+			return false, requeue, "ProjectClaim"
 		}
 	}
 
-	return true, 0
+	return true, 0, ""
 }
 
-// checkAccountClaim checks if the AccountClaim is ready
-func (r *ClusterDeploymentReconciler) checkAccountClaim(ctx context.Context, cd *hivev1.ClusterDeployment) (bool, time.Duration) {
-	// Find AccountClaim with matching cluster label
-	clusterID, hasLabel := cd.Labels[labels.ID]
-	if !hasLabel {
-		r.logger.Debug(ctx, "ClusterDeployment %s/%s has no cluster ID label, assuming no AccountClaim needed",
-			cd.Namespace, cd.Name)
-		return true, 0
+// setWaitingCondition surfaces a WaitingFor<Kind> condition on the ClusterDeployment
+// so that dependency stalls are visible on the object itself
+func (r *ClusterDeploymentReconciler) setWaitingCondition(ctx context.Context, cd *hivev1.ClusterDeployment, kind string) error {
+	conditionType := hivev1.ClusterDeploymentConditionType("WaitingFor" + kind)
+	message := fmt.Sprintf("Waiting for %s to be Ready", kind)
+
+	for _, condition := range cd.Status.Conditions {
+		if condition.Type == conditionType && condition.Status == corev1.ConditionTrue {
+			// Already surfaced, avoid needless status updates
+			return nil
+		}
+	}
+
+	removeWaitingConditions(cd)
+	now := metav1.NewTime(r.stateMachine.Now())
+	cd.Status.Conditions = append(cd.Status.Conditions, hivev1.ClusterDeploymentCondition{
+		Type:               conditionType,
+		Status:             corev1.ConditionTrue,
+		Reason:             kind + "NotReady",
+		Message:            message,
+		LastTransitionTime: now,
+		LastProbeTime:      now,
+	})
+
+	return r.client.Status().Update(ctx, cd)
+}
+
+// removeWaitingConditions drops any WaitingFor<Kind> dependency conditions
+func removeWaitingConditions(cd *hivev1.ClusterDeployment) {
+	conditions := cd.Status.Conditions[:0]
+	for _, condition := range cd.Status.Conditions {
+		if condition.Type == "WaitingForAccountClaim" || condition.Type == "WaitingForProjectClaim" ||
+			condition.Type == "WaitingForDNSZone" {
+			continue
+		}
+		conditions = append(conditions, condition)
 	}
+	cd.Status.Conditions = conditions
+}
 
+// checkAccountClaim checks if the AccountClaim is ready
+func (r *ClusterDeploymentReconciler) checkAccountClaim(ctx context.Context, cd *hivev1.ClusterDeployment) (bool, time.Duration) {
 	acList := &aaov1alpha1.AccountClaimList{}
 	if err := r.client.List(ctx, acList, client.InNamespace(cd.Namespace)); err != nil {
 		r.logger.Error(ctx, "Failed to list AccountClaims in namespace %s: %v", cd.Namespace, err)
 		return false, 5 * time.Second
 	}
-
+	claims := make([]client.Object, len(acList.Items))
 	for i := range acList.Items {
-		ac := &acList.Items[i]
-		if ac.Labels[labels.ID] == clusterID {
-			if ac.Status.State == aaov1alpha1.ClaimStatusReady {
-				r.logger.Debug(ctx, "AccountClaim %s/%s is ready for ClusterDeployment %s/%s",
-					ac.Namespace, ac.Name, cd.Namespace, cd.Name)
-				return true, 0
-			}
-			r.logger.Debug(ctx, "AccountClaim %s/%s is not ready yet (state: %s) for ClusterDeployment %s/%s",
-				ac.Namespace, ac.Name, ac.Status.State, cd.Namespace, cd.Name)
-			return false, 2 * time.Second
-		}
+		claims[i] = &acList.Items[i]
 	}
 
-	r.logger.Debug(ctx, "No AccountClaim found for ClusterDeployment %s/%s (cluster ID: %s)",
-		cd.Namespace, cd.Name, clusterID)
+	claim, needed := r.findClaim(ctx, cd, "AccountClaim", claims)
+	if claim == nil {
+		if !needed {
+			return true, 0
+		}
+		return false, 2 * time.Second
+	}
+	ac := claim.(*aaov1alpha1.AccountClaim)
+	if ac.Status.State == aaov1alpha1.ClaimStatusReady {
+		r.logger.Debug(ctx, "AccountClaim %s/%s is ready for ClusterDeployment %s/%s",
+			ac.Namespace, ac.Name, cd.Namespace, cd.Name)
+		return true, 0
+	}
+	r.logger.Debug(ctx, "AccountClaim %s/%s is not ready yet (state: %s) for ClusterDeployment %s/%s",
+		ac.Namespace, ac.Name, ac.Status.State, cd.Namespace, cd.Name)
 	return false, 2 * time.Second
 }
 
 // checkProjectClaim checks if the ProjectClaim is ready
 func (r *ClusterDeploymentReconciler) checkProjectClaim(ctx context.Context, cd *hivev1.ClusterDeployment) (bool, time.Duration) {
-	// Find ProjectClaim with matching cluster label
-	clusterID, hasLabel := cd.Labels[labels.ID]
-	if !hasLabel {
-		r.logger.Debug(ctx, "ClusterDeployment %s/%s has no cluster ID label, assuming no ProjectClaim needed",
-			cd.Namespace, cd.Name)
-		return true, 0
-	}
-
 	pcList := &gcpv1alpha1.ProjectClaimList{}
 	if err := r.client.List(ctx, pcList, client.InNamespace(cd.Namespace)); err != nil {
 		r.logger.Error(ctx, "Failed to list ProjectClaims in namespace %s: %v", cd.Namespace, err)
 		return false, 5 * time.Second
 	}
-
+	claims := make([]client.Object, len(pcList.Items))
 	for i := range pcList.Items {
-		pc := &pcList.Items[i]
-		if pc.Labels[labels.ID] == clusterID {
-			if pc.Status.State == gcpv1alpha1.ClaimStatusReady {
-				r.logger.Debug(ctx, "ProjectClaim %s/%s is ready for ClusterDeployment %s/%s",
-					pc.Namespace, pc.Name, cd.Namespace, cd.Name)
-				return true, 0
+		claims[i] = &pcList.Items[i]
+	}
+
+	claim, needed := r.findClaim(ctx, cd, "ProjectClaim", claims)
+	if claim == nil {
+		if !needed {
+			return true, 0
+		}
+		return false, 2 * time.Second
+	}
+	pc := claim.(*gcpv1alpha1.ProjectClaim)
+	if pc.Status.State == gcpv1alpha1.ClaimStatusReady {
+		r.logger.Debug(ctx, "ProjectClaim %s/%s is ready for ClusterDeployment %s/%s",
+			pc.Namespace, pc.Name, cd.Namespace, cd.Name)
+		return true, 0
+	}
+	r.logger.Debug(ctx, "ProjectClaim %s/%s is not ready yet (state: %s) for ClusterDeployment %s/%s",
+		pc.Namespace, pc.Name, pc.Status.State, cd.Namespace, cd.Name)
+	return false, 2 * time.Second
+}
+
+// DNSZoneName returns the name Hive gives the DNSZone of a ClusterDeployment
+func DNSZoneName(cd *hivev1.ClusterDeployment) string {
+	return cd.Name + "-zone"
+}
+
+// checkDNSZone checks if the DNSZone of the ClusterDeployment is available, creating it the
+// way Hive does when missing. The DNSZone is owned by the ClusterDeployment so it is removed
+// along with it.
+func (r *ClusterDeploymentReconciler) checkDNSZone(ctx context.Context, cd *hivev1.ClusterDeployment) (bool, error) {
+	dnsZone := &hivev1.DNSZone{}
+	err := r.client.Get(ctx, client.ObjectKey{Namespace: cd.Namespace, Name: DNSZoneName(cd)}, dnsZone)
+	if err == nil {
+		return IsDNSZoneAvailable(dnsZone), nil
+	}
+	if !kuberrors.IsNotFound(err) {
+		return false, err
+	}
+
+	dnsZone = &hivev1.DNSZone{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      DNSZoneName(cd),
+			Namespace: cd.Namespace,
+			Labels: map[string]string{
+				clusterDeploymentNameLabel: cd.Name,
+				dnsZoneTypeLabel:           dnsZoneTypeChild,
+			},
+		},
+		Spec: hivev1.DNSZoneSpec{
+			Zone:               clusterDomain(cd),
+			LinkToParentDomain: true,
+		},
+	}
+	if err := controllerutil.SetControllerReference(cd, dnsZone, r.client.Scheme()); err != nil {
+		return false, err
+	}
+	labels.StampRunID(dnsZone, r.behaviorEngine.GetRunID())
+
+	if err := r.client.Create(ctx, dnsZone); err != nil {
+		return false, err
+	}
+	r.logger.Info(ctx, "Created DNSZone %s/%s for ClusterDeployment %s/%s",
+		dnsZone.Namespace, dnsZone.Name, cd.Namespace, cd.Name)
+	return false, nil
+}
+
+// clusterDomain returns the domain of the cluster, <cluster name>.<base domain>, falling back
+// to the example.com domain the simulated URLs use when the base domain is unset
+func clusterDomain(cd *hivev1.ClusterDeployment) string {
+	if cd.Spec.BaseDomain == "" {
+		return cd.Name + ".example.com"
+	}
+	clusterName := cd.Spec.ClusterName
+	if clusterName == "" {
+		clusterName = cd.Name
+	}
+	return clusterName + "." + cd.Spec.BaseDomain
+}
+
+// findClaim returns the claim of the given kind the ClusterDeployment depends on, matched by
+// its cluster ID label and, with owner matching, by owner reference when none is labeled. When
+// no claim is found, needed reports whether the ClusterDeployment still has to wait for one:
+// it does when it has a cluster ID label, otherwise no claim is needed.
+func (r *ClusterDeploymentReconciler) findClaim(ctx context.Context, cd *hivev1.ClusterDeployment, kind string, claims []client.Object) (client.Object, bool) {
+	clusterID, hasLabel := cd.Labels[labels.ID]
+	if hasLabel {
+		for _, claim := range claims {
+			if claim.GetLabels()[labels.ID] == clusterID {
+				return claim, true
 			}
-			r.logger.Debug(ctx, "ProjectClaim %s/%s is not ready yet (state: %s) for ClusterDeployment %s/%s",
-				pc.Namespace, pc.Name, pc.Status.State, cd.Namespace, cd.Name)
-			return false, 2 * time.Second
 		}
 	}
 
-	r.logger.Debug(ctx, "No ProjectClaim found for ClusterDeployment %s/%s (cluster ID: %s)",
-		cd.Namespace, cd.Name, clusterID)
-	return false, 2 * time.Second
+	if r.behaviorEngine.GetClusterDeploymentConfig().DependencyMatch == config.DependencyMatchOwner {
+		for _, claim := range claims {
+			if ownedBy(claim, "ClusterDeployment", cd.Name) || ownedBy(cd, kind, claim.GetName()) {
+				r.logger.Debug(ctx, "%s %s/%s matches ClusterDeployment %s/%s by owner reference",
+					kind, claim.GetNamespace(), claim.GetName(), cd.Namespace, cd.Name)
+				return claim, true
+			}
+		}
+	}
+
+	if !hasLabel {
+		r.logger.Debug(ctx, "ClusterDeployment %s/%s has no cluster ID label, assuming no %s needed",
+			cd.Namespace, cd.Name, kind)
+		return nil, false
+	}
+	r.logger.Debug(ctx, "No %s found for ClusterDeployment %s/%s (cluster ID: %s)",
+		kind, cd.Namespace, cd.Name, clusterID)
+	return nil, true
+}
+
+// ownedBy returns true if one of the owner references of the object names the given owner.
+// Owner references are namespaced, so the kind and name identify the owner.
+func ownedBy(obj metav1.Object, ownerKind, ownerName string) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.Kind == ownerKind && ref.Name == ownerName {
+			return true
+		}
+	}
+	return false
+}
+
+// applyStuck holds the ClusterDeployment in Provisioning with the failure condition set
+func (r *ClusterDeploymentReconciler) applyStuck(ctx context.Context, cd *hivev1.ClusterDeployment, failure *config.FailureScenario) (reconcile.Result, error) {
+	r.stateMachine.ApplyStuck(ctx, cd, failure, r.stateMachine.Now())
+	if err := r.updateWithStatus(ctx, cd); err != nil {
+		logWriteError(ctx, r.logger, err, "Failed to update stuck ClusterDeployment %s/%s: %v",
+			cd.Namespace, cd.Name, err)
+		return reconcile.Result{}, err
+	}
+	if err := r.reconcileClusterProvision(ctx, cd); err != nil {
+		logWriteError(ctx, r.logger, err, "Failed to update ClusterProvision of ClusterDeployment %s/%s: %v",
+			cd.Namespace, cd.Name, err)
+		return reconcile.Result{}, err
+	}
+	r.events.failed(cd, failure)
+
+	if failure.RecoverAfterSeconds > 0 {
+		return reconcile.Result{RequeueAfter: r.behaviorEngine.Accelerate(time.Duration(failure.RecoverAfterSeconds) * time.Second)}, nil
+	}
+	return reconcile.Result{}, nil
+}
+
+// applyTransientFailure sets the failure condition on the ClusterDeployment and requeues it
+// to retry its transition
+func (r *ClusterDeploymentReconciler) applyTransientFailure(ctx context.Context, cd *hivev1.ClusterDeployment, failure *config.FailureScenario) (reconcile.Result, error) {
+	r.stateMachine.ApplyTransientFailure(ctx, cd, failure)
+	if err := r.client.Status().Update(ctx, cd); err != nil {
+		logWriteError(ctx, r.logger, err, "Failed to update retrying ClusterDeployment %s/%s status: %v",
+			cd.Namespace, cd.Name, err)
+		return reconcile.Result{}, err
+	}
+	r.events.failed(cd, failure)
+	return reconcile.Result{RequeueAfter: transientRetryInterval}, nil
 }
 
 // applyFailure applies a failure state to the ClusterDeployment
@@ -230,11 +737,22 @@ func (r *ClusterDeploymentReconciler) applyFailure(ctx context.Context, cd *hive
 	}
 
 	if err := r.client.Status().Update(ctx, cd); err != nil {
-		r.logger.Error(ctx, "Failed to update failed ClusterDeployment %s/%s status: %v",
+		logWriteError(ctx, r.logger, err, "Failed to update failed ClusterDeployment %s/%s status: %v",
+			cd.Namespace, cd.Name, err)
+		return reconcile.Result{}, err
+	}
+	if err := r.reconcileClusterProvision(ctx, cd); err != nil {
+		logWriteError(ctx, r.logger, err, "Failed to update ClusterProvision of ClusterDeployment %s/%s: %v",
 			cd.Namespace, cd.Name, err)
 		return reconcile.Result{}, err
 	}
 
 	r.logger.Info(ctx, "ClusterDeployment %s/%s failed: %s", cd.Namespace, cd.Name, failure.Message)
-	return reconcile.Result{}, nil
+	recordStateTransition("ClusterDeployment", failedState)
+	r.behaviorEngine.PublishTransition("ClusterDeployment", cd.Namespace, cd.Name, failedState)
+	r.events.failed(cd, failure)
+	notifyTerminal(ctx, r.notifier, "ClusterDeployment", cd, failedState)
+
+	logsAfter, err := r.reconcileInstallLogs(ctx, cd)
+	return reconcile.Result{RequeueAfter: logsAfter}, err
 }
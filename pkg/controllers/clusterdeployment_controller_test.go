@@ -0,0 +1,1131 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	kuberrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	aaov1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/aws-account-operator/v1alpha1"
+	gcpv1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/gcp-project-operator/v1alpha1"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/behavior"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/labels"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/notify"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/state_machine"
+)
+
+func createTestClusterDeploymentReconciler(k8sClient client.Client, cfg *config.Config) *ClusterDeploymentReconciler {
+	logger := createTestLogger()
+	return NewClusterDeploymentReconciler(
+		k8sClient,
+		logger,
+		state_machine.NewClusterDeploymentStateMachine(logger, cfg.ClusterDeployment, clock.RealClock{}),
+		behavior.NewEngine(logger, cfg),
+	)
+}
+
+func findCDCondition(cd *hivev1.ClusterDeployment, conditionType string) *hivev1.ClusterDeploymentCondition {
+	for i := range cd.Status.Conditions {
+		if string(cd.Status.Conditions[i].Type) == conditionType {
+			return &cd.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+func TestClusterDeploymentReconciler_WaitingForAccountClaimCondition(t *testing.T) {
+	ctx := context.Background()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-cluster",
+			Namespace: "default",
+			Labels:    map[string]string{labels.ID: "cluster-123"},
+		},
+	}
+	ac := &aaov1alpha1.AccountClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-claim",
+			Namespace: "default",
+			Labels:    map[string]string{labels.ID: "cluster-123"},
+		},
+		Status: aaov1alpha1.AccountClaimStatus{State: aaov1alpha1.ClaimStatusPending},
+	}
+
+	k8sClient := createTestClient(t, cd, ac)
+	reconciler := createTestClusterDeploymentReconciler(k8sClient, config.DefaultConfig())
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cd)}
+
+	// AccountClaim is pending: the CD should surface why it's stuck
+	result, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Greater(t, result.RequeueAfter.Seconds(), 0.0)
+
+	updated := &hivev1.ClusterDeployment{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	condition := findCDCondition(updated, "WaitingForAccountClaim")
+	require.NotNil(t, condition)
+	assert.Equal(t, corev1.ConditionTrue, condition.Status)
+	assert.Equal(t, "AccountClaimNotReady", condition.Reason)
+
+	// AccountClaim becomes Ready: the condition is cleared as the CD progresses
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(ac), ac))
+	ac.Status.State = aaov1alpha1.ClaimStatusReady
+	require.NoError(t, k8sClient.Status().Update(ctx, ac))
+
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	assert.Nil(t, findCDCondition(updated, "WaitingForAccountClaim"))
+}
+
+func TestClusterDeploymentReconciler_WaitsForUnreconciledAccountClaim(t *testing.T) {
+	ctx := context.Background()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-cluster",
+			Namespace: "default",
+			Labels:    map[string]string{labels.ID: "cluster-123"},
+		},
+	}
+	// Without the AccountClaim controller the claim never gets a state
+	ac := &aaov1alpha1.AccountClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-claim",
+			Namespace: "default",
+			Labels:    map[string]string{labels.ID: "cluster-123"},
+		},
+	}
+
+	k8sClient := createTestClient(t, cd, ac)
+	reconciler := createTestClusterDeploymentReconciler(k8sClient, config.DefaultConfig())
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cd)}
+
+	for range 3 {
+		result, err := reconciler.Reconcile(ctx, req)
+		require.NoError(t, err)
+		assert.Greater(t, result.RequeueAfter.Seconds(), 0.0)
+	}
+
+	updated := &hivev1.ClusterDeployment{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	assert.NotNil(t, findCDCondition(updated, "WaitingForAccountClaim"))
+	assert.Equal(t, "Pending", state_machine.ClusterDeploymentState(updated))
+}
+
+func TestClusterDeploymentReconciler_DependencyMatchOwner(t *testing.T) {
+	ctx := context.Background()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-cluster",
+			Namespace: "default",
+		},
+	}
+	// The AccountClaim is linked by owner reference only
+	ac := &aaov1alpha1.AccountClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-claim",
+			Namespace: "default",
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: hivev1.SchemeGroupVersion.String(), Kind: "ClusterDeployment", Name: "test-cluster"},
+			},
+		},
+		Status: aaov1alpha1.AccountClaimStatus{State: aaov1alpha1.ClaimStatusPending},
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.ClusterDeployment.DependencyMatch = config.DependencyMatchOwner
+	cfg.ClusterDeployment.FailureScenarios = nil
+	k8sClient := createTestClient(t, cd, ac)
+	reconciler := createTestClusterDeploymentReconciler(k8sClient, cfg)
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cd)}
+
+	// The owned AccountClaim is pending, so the ClusterDeployment waits for it
+	_, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	updated := &hivev1.ClusterDeployment{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	assert.NotNil(t, findCDCondition(updated, "WaitingForAccountClaim"))
+
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(ac), ac))
+	ac.Status.State = aaov1alpha1.ClaimStatusReady
+	require.NoError(t, k8sClient.Status().Update(ctx, ac))
+
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	assert.Nil(t, findCDCondition(updated, "WaitingForAccountClaim"))
+	assert.Equal(t, "Provisioning", state_machine.ClusterDeploymentState(updated))
+}
+
+func TestClusterDeploymentReconciler_DependencyMatchOwner_OwnedByClaim(t *testing.T) {
+	ctx := context.Background()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-cluster",
+			Namespace: "default",
+			Labels:    map[string]string{"cloud-provider": "gcp"},
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "gcp.managed.openshift.io/v1alpha1", Kind: "ProjectClaim", Name: "test-claim"},
+			},
+		},
+	}
+	pc := &gcpv1alpha1.ProjectClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-claim", Namespace: "default"},
+		Status:     gcpv1alpha1.ProjectClaimStatus{State: gcpv1alpha1.ClaimStatusPending},
+	}
+	k8sClient := createTestClient(t, cd, pc)
+
+	// Matching by label, the unlabeled ClusterDeployment needs no ProjectClaim
+	reconciler := createTestClusterDeploymentReconciler(k8sClient, config.DefaultConfig())
+	ready, _, _ := reconciler.checkDependencies(ctx, cd)
+	assert.True(t, ready)
+
+	// Matching by owner, it waits for the ProjectClaim owning it
+	cfg := config.DefaultConfig()
+	cfg.ClusterDeployment.DependencyMatch = config.DependencyMatchOwner
+	reconciler = createTestClusterDeploymentReconciler(k8sClient, cfg)
+	ready, _, kind := reconciler.checkDependencies(ctx, cd)
+	assert.False(t, ready)
+	assert.Equal(t, "ProjectClaim", kind)
+
+	// Claims owned by another ClusterDeployment are not matched
+	cd.OwnerReferences[0].Name = "other-claim"
+	ready, _, _ = reconciler.checkDependencies(ctx, cd)
+	assert.True(t, ready)
+}
+
+func TestClusterDeploymentReconciler_AgentPlatformSkipsDependencies(t *testing.T) {
+	ctx := context.Background()
+	// A pending AccountClaim with the same cluster ID would hold back an AWS cluster
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-cluster",
+			Namespace: "default",
+			Labels:    map[string]string{labels.ID: "cluster-123", "cloud-provider": "agent"},
+		},
+	}
+	ac := &aaov1alpha1.AccountClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-claim",
+			Namespace: "default",
+			Labels:    map[string]string{labels.ID: "cluster-123"},
+		},
+		Status: aaov1alpha1.AccountClaimStatus{State: aaov1alpha1.ClaimStatusPending},
+	}
+
+	k8sClient := createTestClient(t, cd, ac)
+	reconciler := createTestClusterDeploymentReconciler(k8sClient, config.DefaultConfig())
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cd)}
+
+	result, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, result.RequeueAfter)
+
+	updated := &hivev1.ClusterDeployment{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	assert.Nil(t, findCDCondition(updated, "WaitingForAccountClaim"))
+	assert.Nil(t, findCDCondition(updated, "WaitingForProjectClaim"))
+	condition := findCDCondition(updated, "ClusterInstallRequirementsMet")
+	require.NotNil(t, condition)
+	assert.Equal(t, "AgentsNotReady", condition.Reason)
+	assert.Nil(t, updated.Status.ProvisionRef)
+}
+
+func TestClusterDeploymentReconciler_DeprovisionDuringProvisioning(t *testing.T) {
+	ctx := context.Background()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+	}
+	cfg := config.DefaultConfig()
+	cfg.ClusterDeployment.DeprovisionStates = []config.StateConfig{
+		{
+			Name:            "Deprovisioning",
+			DurationSeconds: 2,
+			Conditions: []config.ConditionConfig{
+				{Type: "Provisioned", Status: "False", Reason: "Deprovisioning"},
+				{Type: "DeprovisionLaunchError", Status: "False", Reason: "DeprovisionLaunched"},
+			},
+		},
+		{
+			Name: "Deprovisioned",
+			Conditions: []config.ConditionConfig{
+				{Type: "Provisioned", Status: "False", Reason: "Deprovisioned"},
+			},
+		},
+	}
+	k8sClient := createTestClient(t, cd)
+	reconciler := createTestClusterDeploymentReconciler(k8sClient, cfg)
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cd)}
+
+	// Move to Provisioning, which also adds the deprovision finalizer
+	for i := 0; i < 2; i++ {
+		_, err := reconciler.Reconcile(ctx, req)
+		require.NoError(t, err)
+	}
+	updated := &hivev1.ClusterDeployment{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	require.Equal(t, "test-cluster-provision", updated.Status.ProvisionRef.Name)
+	require.Contains(t, updated.Finalizers, hivev1.FinalizerDeprovision)
+
+	// Deleting holds the object until the deprovision states have been walked through
+	require.NoError(t, k8sClient.Delete(ctx, updated))
+
+	result, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Second, result.RequeueAfter)
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	assert.Equal(t, "Deprovisioning", updated.Annotations[state_machine.DeprovisionStateAnnotation])
+	assert.Equal(t, "test-cluster-deprovision", updated.Annotations[state_machine.DeprovisionRefAnnotation])
+	condition := findCDCondition(updated, "Provisioned")
+	require.NotNil(t, condition)
+	assert.Equal(t, "Deprovisioning", condition.Reason)
+	// Conditions of other types are kept
+	assert.NotNil(t, findCDCondition(updated, "DeprovisionLaunchError"))
+
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	assert.Equal(t, "Deprovisioned", updated.Annotations[state_machine.DeprovisionStateAnnotation])
+	assert.Equal(t, "Deprovisioned", findCDCondition(updated, "Provisioned").Reason)
+
+	// The last state removes the finalizer and the object goes away
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	err = k8sClient.Get(ctx, req.NamespacedName, updated)
+	assert.True(t, kuberrors.IsNotFound(err), "expected ClusterDeployment to be gone, got %v", err)
+}
+
+func TestClusterDeploymentReconciler_DeprovisionWithoutStates(t *testing.T) {
+	ctx := context.Background()
+	now := metav1.Now()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "test-cluster",
+			Namespace:         "default",
+			Finalizers:        []string{hivev1.FinalizerDeprovision},
+			DeletionTimestamp: &now,
+		},
+	}
+	cfg := config.DefaultConfig()
+	cfg.ClusterDeployment.DeprovisionStates = nil
+	k8sClient := createTestClient(t, cd)
+	reconciler := createTestClusterDeploymentReconciler(k8sClient, cfg)
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cd)}
+
+	result, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+
+	err = k8sClient.Get(ctx, req.NamespacedName, &hivev1.ClusterDeployment{})
+	assert.True(t, kuberrors.IsNotFound(err), "expected ClusterDeployment to be gone, got %v", err)
+}
+
+func TestClusterDeploymentReconciler_NoFinalizerWithoutDeprovisionStates(t *testing.T) {
+	ctx := context.Background()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+	}
+	k8sClient := createTestClient(t, cd)
+	reconciler := createTestClusterDeploymentReconciler(k8sClient, config.DefaultConfig())
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cd)}
+
+	_, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	updated := &hivev1.ClusterDeployment{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	assert.Empty(t, updated.Finalizers)
+
+	// Deletion is not held by the simulator
+	require.NoError(t, k8sClient.Delete(ctx, updated))
+	err = k8sClient.Get(ctx, req.NamespacedName, updated)
+	assert.True(t, kuberrors.IsNotFound(err), "expected ClusterDeployment to be gone, got %v", err)
+}
+
+func TestClusterDeploymentReconciler_DryRun(t *testing.T) {
+	ctx := context.Background()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+	}
+	k8sClient := createTestClient(t, cd)
+	cfg := config.DefaultConfig()
+	cfg.ClusterDeployment.DeprovisionStates = []config.StateConfig{{Name: "Deprovisioning", DurationSeconds: 5}}
+	reconciler := createTestClusterDeploymentReconciler(k8sClient, cfg)
+	reconciler.SetDryRun(true)
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cd)}
+
+	before := &hivev1.ClusterDeployment{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, before))
+
+	// The transition is only planned, and retried once it would be due
+	result, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Greater(t, result.RequeueAfter, time.Duration(0))
+
+	updated := &hivev1.ClusterDeployment{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	assert.Equal(t, before.ResourceVersion, updated.ResourceVersion)
+	assert.Empty(t, updated.Finalizers)
+	assert.Empty(t, updated.Status.Conditions)
+	assert.Empty(t, updated.Annotations[state_machine.StateAnnotation])
+}
+
+func TestClusterDeploymentReconciler_StartJitterStaggersFirstTransition(t *testing.T) {
+	ctx := context.Background()
+	created := metav1.Now()
+	var objects []client.Object
+	for i := 0; i < 5; i++ {
+		objects = append(objects, &hivev1.ClusterDeployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              fmt.Sprintf("cd-%d", i),
+				Namespace:         "default",
+				CreationTimestamp: created,
+			},
+		})
+	}
+	cfg := config.DefaultConfig()
+	cfg.ClusterDeployment.StartJitterSeconds = 60
+	k8sClient := createTestClient(t, objects...)
+	reconciler := createTestClusterDeploymentReconciler(k8sClient, cfg)
+
+	delays := map[time.Duration]bool{}
+	for _, obj := range objects {
+		req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(obj)}
+		result, err := reconciler.Reconcile(ctx, req)
+		require.NoError(t, err)
+		assert.Greater(t, result.RequeueAfter, time.Duration(0))
+		assert.LessOrEqual(t, result.RequeueAfter, 60*time.Second)
+		// Round away the time spent between reconciles, leaving the per-resource offset
+		delays[result.RequeueAfter.Round(100*time.Millisecond)] = true
+
+		// Nothing has transitioned yet
+		updated := &hivev1.ClusterDeployment{}
+		require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+		assert.Nil(t, updated.Status.ProvisionRef)
+		assert.Empty(t, updated.Status.Conditions)
+	}
+	assert.Len(t, delays, len(objects), "first transitions should be staggered")
+}
+
+func TestClusterDeploymentReconciler_StartJitterElapsed(t *testing.T) {
+	ctx := context.Background()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "test-cluster",
+			Namespace:         "default",
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-2 * time.Minute)),
+		},
+	}
+	cfg := config.DefaultConfig()
+	cfg.ClusterDeployment.StartJitterSeconds = 60
+	k8sClient := createTestClient(t, cd)
+	reconciler := createTestClusterDeploymentReconciler(k8sClient, cfg)
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cd)}
+
+	_, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	updated := &hivev1.ClusterDeployment{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	assert.NotEmpty(t, updated.Status.Conditions, "the jitter window has passed, so the first transition happens")
+}
+
+func TestClusterDeploymentReconciler_QuotaStuckThenRecovers(t *testing.T) {
+	ctx := context.Background()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+	}
+	quota, ok := config.LookupFailureScenario("QuotaExceeded")
+	require.True(t, ok)
+	quota.Probability = 1.0
+	quota.RecoverAfterSeconds = 60
+	cfg := config.DefaultConfig()
+	cfg.ClusterDeployment.FailureScenarios = []config.FailureScenario{quota}
+	k8sClient := createTestClient(t, cd)
+	reconciler := createTestClusterDeploymentReconciler(k8sClient, cfg)
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cd)}
+
+	// The quota failure holds the cluster in Provisioning instead of failing it
+	result, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, 60*time.Second, result.RequeueAfter)
+
+	updated := &hivev1.ClusterDeployment{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	assert.Equal(t, "test-cluster-provision", updated.Status.ProvisionRef.Name)
+	condition := findCDCondition(updated, "ProvisionFailed")
+	require.NotNil(t, condition)
+	assert.Equal(t, corev1.ConditionTrue, condition.Status)
+	assert.Equal(t, "QuotaExceeded", condition.Reason)
+
+	// Reconciling again before the recovery delay keeps it stuck
+	result, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Greater(t, result.RequeueAfter, time.Duration(0))
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	assert.NotNil(t, findCDCondition(updated, "ProvisionFailed"))
+	assert.Nil(t, findCDCondition(updated, "DNSNotReady"))
+
+	// Once the quota is raised the cluster continues provisioning and is not stuck again
+	updated.Annotations[state_machine.StuckUntilAnnotation] = time.Now().Add(-time.Second).UTC().Format(time.RFC3339)
+	require.NoError(t, k8sClient.Update(ctx, updated))
+
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	assert.Nil(t, findCDCondition(updated, "ProvisionFailed"))
+	assert.NotNil(t, findCDCondition(updated, "DNSNotReady"), "expected the cluster to move on to Installing")
+	assert.NotEqual(t, "test-cluster-provision-failed", updated.Status.ProvisionRef.Name)
+}
+
+func TestClusterDeploymentReconciler_HibernateAndResume(t *testing.T) {
+	ctx := context.Background()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+		Spec: hivev1.ClusterDeploymentSpec{Installed: true},
+	}
+	cfg := config.DefaultConfig()
+	cfg.ClusterDeployment.Hibernation = &config.HibernationConfig{}
+	k8sClient := createTestClient(t, cd)
+	reconciler := createTestClusterDeploymentReconciler(k8sClient, cfg)
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cd)}
+
+	reconcileToPowerState := func(expected hivev1.ClusterPowerState) *hivev1.ClusterDeployment {
+		_, err := reconciler.Reconcile(ctx, req)
+		require.NoError(t, err)
+		updated := &hivev1.ClusterDeployment{}
+		require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+		require.Equal(t, expected, updated.Status.PowerState)
+		return updated
+	}
+	requestPowerState := func(powerState hivev1.ClusterPowerState) {
+		updated := &hivev1.ClusterDeployment{}
+		require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+		updated.Spec.PowerState = powerState
+		require.NoError(t, k8sClient.Update(ctx, updated))
+	}
+
+	requestPowerState(hivev1.ClusterPowerStateHibernating)
+	reconcileToPowerState(hivev1.ClusterPowerStateStopping)
+	hibernating := reconcileToPowerState(hivev1.ClusterPowerStateHibernating)
+	assert.Equal(t, corev1.ConditionTrue, findCDCondition(hibernating, "Hibernating").Status)
+	assert.Equal(t, corev1.ConditionTrue, findCDCondition(hibernating, "Unreachable").Status)
+
+	// Reconciling a cluster already in the requested power state changes nothing
+	unchanged := reconcileToPowerState(hivev1.ClusterPowerStateHibernating)
+	assert.Equal(t, hibernating.ResourceVersion, unchanged.ResourceVersion)
+
+	requestPowerState(hivev1.ClusterPowerStateRunning)
+	reconcileToPowerState(state_machine.ClusterPowerStateResuming)
+	running := reconcileToPowerState(hivev1.ClusterPowerStateRunning)
+	assert.Equal(t, corev1.ConditionTrue, findCDCondition(running, "Ready").Status)
+	assert.Equal(t, corev1.ConditionFalse, findCDCondition(running, "Unreachable").Status)
+	assert.Equal(t, corev1.ConditionFalse, findCDCondition(running, "Hibernating").Status)
+}
+
+func TestClusterDeploymentReconciler_PowerStateFollowsClock(t *testing.T) {
+	ctx := context.Background()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+		Spec:       hivev1.ClusterDeploymentSpec{Installed: true, PowerState: hivev1.ClusterPowerStateHibernating},
+	}
+	cfg := config.DefaultConfig()
+	cfg.ClusterDeployment.Hibernation = &config.HibernationConfig{StoppingSeconds: 60}
+	k8sClient := createTestClient(t, cd)
+	logger := createTestLogger()
+	fakeClock := clocktesting.NewFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	reconciler := NewClusterDeploymentReconciler(k8sClient, logger,
+		state_machine.NewClusterDeploymentStateMachine(logger, cfg.ClusterDeployment, fakeClock), behavior.NewEngine(logger, cfg))
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cd)}
+
+	powerState := func() hivev1.ClusterPowerState {
+		_, err := reconciler.Reconcile(ctx, req)
+		require.NoError(t, err)
+		updated := &hivev1.ClusterDeployment{}
+		require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+		return updated.Status.PowerState
+	}
+
+	// The cluster stops for as long as the state machine clock says
+	assert.Equal(t, hivev1.ClusterPowerStateStopping, powerState())
+	assert.Equal(t, hivev1.ClusterPowerStateStopping, powerState())
+	fakeClock.Step(time.Minute)
+	assert.Equal(t, hivev1.ClusterPowerStateHibernating, powerState())
+}
+
+func TestClusterDeploymentReconciler_PowerStateAlreadyRunning(t *testing.T) {
+	ctx := context.Background()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+		Spec: hivev1.ClusterDeploymentSpec{
+			Installed:  true,
+			PowerState: hivev1.ClusterPowerStateRunning,
+		},
+	}
+	k8sClient := createTestClient(t, cd)
+	reconciler := createTestClusterDeploymentReconciler(k8sClient, config.DefaultConfig())
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cd)}
+
+	before := &hivev1.ClusterDeployment{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, before))
+
+	result, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+
+	after := &hivev1.ClusterDeployment{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, after))
+	assert.Equal(t, before.ResourceVersion, after.ResourceVersion)
+	assert.Empty(t, after.Status.PowerState)
+	assert.Empty(t, after.Status.Conditions)
+}
+
+func TestClusterDeploymentReconciler_InstallLogsGatheredAfterInstall(t *testing.T) {
+	ctx := context.Background()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+		Spec:       hivev1.ClusterDeploymentSpec{Installed: true},
+		Status: hivev1.ClusterDeploymentStatus{
+			InstalledTimestamp: &metav1.Time{Time: time.Now().Add(-10 * time.Second)},
+		},
+	}
+	cfg := config.DefaultConfig()
+	cfg.ClusterDeployment.InstallLogs = &config.InstallLogsConfig{DelaySeconds: 30, Location: "s3://install-logs"}
+	k8sClient := createTestClient(t, cd)
+	reconciler := createTestClusterDeploymentReconciler(k8sClient, cfg)
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cd)}
+
+	// Gathering completes a while after install
+	result, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.InDelta(t, 20*time.Second, result.RequeueAfter, float64(time.Second))
+
+	updated := &hivev1.ClusterDeployment{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	assert.Nil(t, findCDCondition(updated, "InstallLogsGathered"))
+
+	updated.Status.InstalledTimestamp = &metav1.Time{Time: time.Now().Add(-time.Minute)}
+	require.NoError(t, k8sClient.Status().Update(ctx, updated))
+
+	result, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	condition := findCDCondition(updated, "InstallLogsGathered")
+	require.NotNil(t, condition)
+	assert.Equal(t, corev1.ConditionTrue, condition.Status)
+	assert.Contains(t, condition.Message, "s3://install-logs/default/test-cluster")
+}
+
+func TestClusterDeploymentReconciler_InstallLogsGatheredAfterFailure(t *testing.T) {
+	ctx := context.Background()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+	}
+	cfg := config.DefaultConfig()
+	cfg.ClusterDeployment.InstallLogs = &config.InstallLogsConfig{}
+	k8sClient := createTestClient(t, cd)
+	reconciler := createTestClusterDeploymentReconciler(k8sClient, cfg)
+	reconciler.behaviorEngine.SetResourceOverride(ctx, "ClusterDeployment", "default", "test-cluster",
+		&config.ResourceOverride{ForceFail: &config.FailureScenario{Condition: "ProvisionFailed", Reason: "InsufficientCapacity"}})
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cd)}
+
+	_, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	updated := &hivev1.ClusterDeployment{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	require.NotNil(t, findCDCondition(updated, "ProvisionFailed"))
+	require.NotNil(t, findCDCondition(updated, "InstallLogsGathered"))
+
+	// A failed ClusterDeployment is not failed again
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	after := &hivev1.ClusterDeployment{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, after))
+	assert.Equal(t, updated.ResourceVersion, after.ResourceVersion)
+}
+
+func TestClusterDeploymentReconciler_AdminKubeconfigOnRunning(t *testing.T) {
+	ctx := context.Background()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+		Status: hivev1.ClusterDeploymentStatus{
+			ProvisionRef: &corev1.LocalObjectReference{Name: "test-cluster-provision"},
+			Conditions: []hivev1.ClusterDeploymentCondition{
+				{Type: "DNSNotReady", Status: corev1.ConditionFalse, LastTransitionTime: metav1.NewTime(time.Now().Add(-time.Hour))},
+			},
+		},
+	}
+	cfg := config.DefaultConfig()
+	cfg.ClusterDeployment.DependsOnAccountClaim = false
+	cfg.ClusterDeployment.DependsOnProjectClaim = false
+	cfg.ClusterDeployment.FailureScenarios = nil
+	cfg.ClusterDeployment.AdminKubeconfig = true
+	k8sClient := createTestClient(t, cd)
+	reconciler := createTestClusterDeploymentReconciler(k8sClient, cfg)
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cd)}
+
+	_, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	updated := &hivev1.ClusterDeployment{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	require.True(t, updated.Spec.Installed)
+	require.NotNil(t, updated.Spec.ClusterMetadata)
+	assert.Equal(t, "test-cluster-admin-kubeconfig", updated.Spec.ClusterMetadata.AdminKubeconfigSecretRef.Name)
+
+	secret := &corev1.Secret{}
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKey{Namespace: "default", Name: "test-cluster-admin-kubeconfig"}, secret))
+	assert.Contains(t, string(secret.Data["kubeconfig"]), "https://api.test-cluster.example.com:6443")
+	require.Len(t, secret.OwnerReferences, 1)
+	assert.Equal(t, "test-cluster", secret.OwnerReferences[0].Name)
+
+	require.NotNil(t, updated.Spec.ClusterMetadata.AdminPasswordSecretRef)
+	assert.Equal(t, "test-cluster-admin-password", updated.Spec.ClusterMetadata.AdminPasswordSecretRef.Name)
+	password := &corev1.Secret{}
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKey{Namespace: "default", Name: "test-cluster-admin-password"}, password))
+	assert.Equal(t, "kubeadmin", string(password.Data["username"]))
+	assert.NotEmpty(t, password.Data["password"])
+	require.Len(t, password.OwnerReferences, 1)
+	assert.Equal(t, "test-cluster", password.OwnerReferences[0].Name)
+}
+
+func TestClusterDeploymentReconciler_AdminKubeconfigDisabled(t *testing.T) {
+	ctx := context.Background()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+		Status: hivev1.ClusterDeploymentStatus{
+			ProvisionRef: &corev1.LocalObjectReference{Name: "test-cluster-provision"},
+			Conditions: []hivev1.ClusterDeploymentCondition{
+				{Type: "DNSNotReady", Status: corev1.ConditionFalse, LastTransitionTime: metav1.NewTime(time.Now().Add(-time.Hour))},
+			},
+		},
+	}
+	cfg := config.DefaultConfig()
+	cfg.ClusterDeployment.DependsOnAccountClaim = false
+	cfg.ClusterDeployment.DependsOnProjectClaim = false
+	cfg.ClusterDeployment.FailureScenarios = nil
+	k8sClient := createTestClient(t, cd)
+	reconciler := createTestClusterDeploymentReconciler(k8sClient, cfg)
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cd)}
+
+	_, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	updated := &hivev1.ClusterDeployment{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	require.True(t, updated.Spec.Installed)
+	assert.Empty(t, updated.Spec.ClusterMetadata.AdminKubeconfigSecretRef.Name)
+	assert.Nil(t, updated.Spec.ClusterMetadata.AdminPasswordSecretRef)
+
+	err = k8sClient.Get(ctx, client.ObjectKey{Namespace: "default", Name: "test-cluster-admin-kubeconfig"}, &corev1.Secret{})
+	assert.True(t, kuberrors.IsNotFound(err))
+	err = k8sClient.Get(ctx, client.ObjectKey{Namespace: "default", Name: "test-cluster-admin-password"}, &corev1.Secret{})
+	assert.True(t, kuberrors.IsNotFound(err))
+}
+
+func TestClusterDeploymentReconciler_ClusterIDOnRunning(t *testing.T) {
+	ctx := context.Background()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+		Status: hivev1.ClusterDeploymentStatus{
+			ProvisionRef: &corev1.LocalObjectReference{Name: "test-cluster-provision"},
+			Conditions: []hivev1.ClusterDeploymentCondition{
+				{Type: "DNSNotReady", Status: corev1.ConditionFalse, LastTransitionTime: metav1.NewTime(time.Now().Add(-time.Hour))},
+			},
+		},
+	}
+	seed := int64(42)
+	cfg := config.DefaultConfig()
+	cfg.RandomSeed = &seed
+	cfg.ClusterDeployment.DependsOnAccountClaim = false
+	cfg.ClusterDeployment.DependsOnProjectClaim = false
+	cfg.ClusterDeployment.FailureScenarios = nil
+	k8sClient := createTestClient(t, cd)
+	reconciler := createTestClusterDeploymentReconciler(k8sClient, cfg)
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cd)}
+
+	_, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	updated := &hivev1.ClusterDeployment{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	require.True(t, updated.Spec.Installed)
+	require.NotNil(t, updated.Spec.ClusterMetadata)
+	assert.Equal(t, "test-cluster-infra", updated.Spec.ClusterMetadata.InfraID)
+	assert.Equal(t, reconciler.behaviorEngine.ClusterID("default", "test-cluster"), updated.Spec.ClusterMetadata.ClusterID)
+}
+
+func TestClusterDeploymentReconciler_WaitsForDNSZone(t *testing.T) {
+	ctx := context.Background()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+		Spec:       hivev1.ClusterDeploymentSpec{ClusterName: "test", BaseDomain: "sim.example.com"},
+		Status: hivev1.ClusterDeploymentStatus{
+			ProvisionRef: &corev1.LocalObjectReference{Name: "test-cluster-provision"},
+		},
+	}
+	cfg := config.DefaultConfig()
+	cfg.ClusterDeployment.DependsOnAccountClaim = false
+	cfg.ClusterDeployment.DependsOnProjectClaim = false
+	cfg.ClusterDeployment.DependsOnDNSZone = true
+	cfg.ClusterDeployment.FailureScenarios = nil
+	cfg.DNSZone = &config.DNSZoneConfig{}
+	k8sClient := createTestClient(t, cd)
+	reconciler := createTestClusterDeploymentReconciler(k8sClient, cfg)
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cd)}
+
+	// Installing waits for the DNSZone, which is created the way Hive does
+	result, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, dnsZoneRequeueInterval, result.RequeueAfter)
+
+	dnsZone := &hivev1.DNSZone{}
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKey{Namespace: "default", Name: "test-cluster-zone"}, dnsZone))
+	assert.Equal(t, "test.sim.example.com", dnsZone.Spec.Zone)
+	assert.Equal(t, "test-cluster", dnsZone.Labels["hive.openshift.io/cluster-deployment-name"])
+	require.Len(t, dnsZone.OwnerReferences, 1)
+	assert.Equal(t, "test-cluster", dnsZone.OwnerReferences[0].Name)
+
+	updated := &hivev1.ClusterDeployment{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	assert.Equal(t, "Provisioning", state_machine.ClusterDeploymentState(updated))
+	waiting := findCDCondition(updated, "WaitingForDNSZone")
+	require.NotNil(t, waiting)
+	assert.Equal(t, corev1.ConditionTrue, waiting.Status)
+
+	// Once the DNSZone is available the ClusterDeployment moves on
+	dnsZoneReconciler := NewDNSZoneReconciler(k8sClient, createTestLogger(), cfg)
+	_, err = dnsZoneReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(dnsZone)})
+	require.NoError(t, err)
+
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	assert.Equal(t, "Installing", state_machine.ClusterDeploymentState(updated))
+	assert.Nil(t, findCDCondition(updated, "WaitingForDNSZone"))
+}
+
+func TestClusterDeploymentReconciler_PausedResourceDoesNotTransition(t *testing.T) {
+	ctx := context.Background()
+	paused := &hivev1.ClusterDeployment{ObjectMeta: metav1.ObjectMeta{Name: "paused", Namespace: "default"}}
+	running := &hivev1.ClusterDeployment{ObjectMeta: metav1.ObjectMeta{Name: "running", Namespace: "default"}}
+	cfg := config.DefaultConfig()
+	cfg.ClusterDeployment.DependsOnAccountClaim = false
+	cfg.ClusterDeployment.DependsOnProjectClaim = false
+	cfg.ClusterDeployment.FailureScenarios = nil
+	k8sClient := createTestClient(t, paused, running)
+	reconciler := createTestClusterDeploymentReconciler(k8sClient, cfg)
+	reconciler.behaviorEngine.SetPaused(ctx, "ClusterDeployment", "default", "paused", true)
+
+	for _, cd := range []*hivev1.ClusterDeployment{paused, running} {
+		result, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cd)})
+		require.NoError(t, err)
+		assert.Positive(t, result.RequeueAfter)
+	}
+
+	updated := &hivev1.ClusterDeployment{}
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(paused), updated))
+	assert.Nil(t, updated.Status.ProvisionRef, "a paused ClusterDeployment must not transition")
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(running), updated))
+	assert.NotNil(t, updated.Status.ProvisionRef)
+
+	// Once resumed it continues
+	reconciler.behaviorEngine.SetPaused(ctx, "ClusterDeployment", "default", "paused", false)
+	_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(paused)})
+	require.NoError(t, err)
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(paused), updated))
+	assert.NotNil(t, updated.Status.ProvisionRef)
+}
+
+func TestClusterDeploymentReconciler_ReconcilePaused(t *testing.T) {
+	ctx := context.Background()
+	cd := &hivev1.ClusterDeployment{ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"}}
+	cfg := config.DefaultConfig()
+	cfg.ClusterDeployment.DependsOnAccountClaim = false
+	cfg.ClusterDeployment.DependsOnProjectClaim = false
+	cfg.ClusterDeployment.FailureScenarios = nil
+	k8sClient := createTestClient(t, cd)
+	reconciler := createTestClusterDeploymentReconciler(k8sClient, cfg)
+	reconciler.behaviorEngine.SetReconcilePaused(ctx, true)
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cd)}
+
+	result, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, reconcilePausedRequeueInterval, result.RequeueAfter)
+
+	// Nothing is written while paused, not even the finalizer
+	updated := &hivev1.ClusterDeployment{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	assert.Empty(t, updated.Finalizers)
+	assert.Nil(t, updated.Status.ProvisionRef)
+
+	// Once resumed it continues from where it was
+	reconciler.behaviorEngine.SetReconcilePaused(ctx, false)
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	assert.NotNil(t, updated.Status.ProvisionRef)
+}
+
+func TestClusterDeploymentReconciler_InstallPhaseTracksState(t *testing.T) {
+	ctx := context.Background()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+	}
+	cfg := config.DefaultConfig()
+	cfg.ClusterDeployment.DependsOnAccountClaim = false
+	cfg.ClusterDeployment.DependsOnProjectClaim = false
+	cfg.ClusterDeployment.FailureScenarios = nil
+	cfg.ClusterDeployment.InstallPhases = map[string]string{
+		"Provisioning": "infrastructure",
+		"Installing":   "bootstrap",
+	}
+	k8sClient := createTestClient(t, cd)
+	reconciler := createTestClusterDeploymentReconciler(k8sClient, cfg)
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cd)}
+
+	// Running has no phase, so the annotation is removed once installed
+	for _, expected := range []string{"infrastructure", "bootstrap", ""} {
+		_, err := reconciler.Reconcile(ctx, req)
+		require.NoError(t, err)
+
+		updated := &hivev1.ClusterDeployment{}
+		require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+		phase, ok := updated.Annotations[state_machine.InstallPhaseAnnotation]
+		assert.Equal(t, expected != "", ok)
+		assert.Equal(t, expected, phase)
+	}
+}
+
+func TestClusterDeploymentReconciler_ClusterProvisionTracksState(t *testing.T) {
+	ctx := context.Background()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+	}
+	cfg := config.DefaultConfig()
+	cfg.ClusterDeployment.DependsOnAccountClaim = false
+	cfg.ClusterDeployment.DependsOnProjectClaim = false
+	cfg.ClusterDeployment.FailureScenarios = nil
+	k8sClient := createTestClient(t, cd)
+	reconciler := createTestClusterDeploymentReconciler(k8sClient, cfg)
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cd)}
+
+	// Provisioning, Installing, Running
+	for _, expected := range []hivev1.ClusterProvisionStage{
+		hivev1.ClusterProvisionStageProvisioning,
+		hivev1.ClusterProvisionStageProvisioning,
+		hivev1.ClusterProvisionStageComplete,
+	} {
+		_, err := reconciler.Reconcile(ctx, req)
+		require.NoError(t, err)
+
+		updated := &hivev1.ClusterDeployment{}
+		require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+		require.NotNil(t, updated.Status.ProvisionRef)
+
+		provision := &hivev1.ClusterProvision{}
+		require.NoError(t, k8sClient.Get(ctx, client.ObjectKey{Namespace: "default", Name: updated.Status.ProvisionRef.Name}, provision))
+		assert.Equal(t, expected, provision.Spec.Stage)
+		assert.Equal(t, "test-cluster", provision.Spec.ClusterDeploymentRef.Name)
+		require.NotNil(t, provision.Spec.InstallLog)
+	}
+
+	provision := &hivev1.ClusterProvision{}
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKey{Namespace: "default", Name: "test-cluster-provision"}, provision))
+	require.NotNil(t, provision.Spec.InfraID)
+	assert.Equal(t, "test-cluster-infra", *provision.Spec.InfraID)
+	assert.Contains(t, *provision.Spec.InstallLog, "Install complete!")
+}
+
+func TestClusterDeploymentReconciler_ReinstallAfterInstalledReset(t *testing.T) {
+	ctx := context.Background()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+	}
+	cfg := config.DefaultConfig()
+	cfg.ClusterDeployment.DependsOnAccountClaim = false
+	cfg.ClusterDeployment.DependsOnProjectClaim = false
+	cfg.ClusterDeployment.FailureScenarios = nil
+	k8sClient := createTestClient(t, cd)
+	reconciler := createTestClusterDeploymentReconciler(k8sClient, cfg)
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cd)}
+
+	// Provisioning, Installing, Running
+	for range 3 {
+		_, err := reconciler.Reconcile(ctx, req)
+		require.NoError(t, err)
+	}
+	updated := &hivev1.ClusterDeployment{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	require.True(t, updated.Spec.Installed)
+	require.NotNil(t, updated.Spec.ClusterMetadata)
+	require.NotEmpty(t, updated.Status.APIURL)
+	updated.Status.PowerState = hivev1.ClusterPowerStateRunning
+	require.NoError(t, k8sClient.Status().Update(ctx, updated))
+
+	// Setting installed back to false starts the progression over
+	updated.Spec.Installed = false
+	require.NoError(t, k8sClient.Update(ctx, updated))
+	_, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	assert.False(t, updated.Spec.Installed)
+	assert.Nil(t, updated.Status.InstalledTimestamp)
+	assert.Equal(t, "Provisioning", state_machine.ClusterDeploymentState(updated))
+	assert.Nil(t, updated.Spec.ClusterMetadata, "the metadata of the previous installation is dropped")
+	assert.Empty(t, updated.Status.APIURL)
+	assert.Empty(t, updated.Status.WebConsoleURL)
+	assert.Empty(t, updated.Status.PowerState)
+	require.NotNil(t, updated.Status.ProvisionRef)
+	provision := &hivev1.ClusterProvision{}
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKey{Namespace: "default", Name: updated.Status.ProvisionRef.Name}, provision))
+	assert.Equal(t, hivev1.ClusterProvisionStageProvisioning, provision.Spec.Stage)
+
+	// Installing, Running
+	for range 2 {
+		_, err := reconciler.Reconcile(ctx, req)
+		require.NoError(t, err)
+	}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	assert.True(t, updated.Spec.Installed)
+	assert.NotNil(t, updated.Status.InstalledTimestamp)
+	require.NotNil(t, updated.Spec.ClusterMetadata)
+	assert.NotEmpty(t, updated.Spec.ClusterMetadata.ClusterID)
+}
+
+func TestClusterDeploymentReconciler_ClusterProvisionFailed(t *testing.T) {
+	ctx := context.Background()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+	}
+	cfg := config.DefaultConfig()
+	cfg.ClusterDeployment.DependsOnAccountClaim = false
+	cfg.ClusterDeployment.DependsOnProjectClaim = false
+	cfg.ClusterDeployment.FailureScenarios = nil
+	k8sClient := createTestClient(t, cd)
+	reconciler := createTestClusterDeploymentReconciler(k8sClient, cfg)
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cd)}
+
+	// Provisioning starts, then the attempt fails
+	_, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	reconciler.behaviorEngine.SetResourceOverride(ctx, "ClusterDeployment", "default", "test-cluster",
+		&config.ResourceOverride{ForceFail: &config.FailureScenario{Condition: "ProvisionFailed", Reason: "InsufficientCapacity"}})
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	updated := &hivev1.ClusterDeployment{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	require.Equal(t, "test-cluster-provision-failed", updated.Status.ProvisionRef.Name)
+
+	for _, name := range []string{"test-cluster-provision", "test-cluster-provision-failed"} {
+		provision := &hivev1.ClusterProvision{}
+		require.NoError(t, k8sClient.Get(ctx, client.ObjectKey{Namespace: "default", Name: name}, provision))
+		assert.Equal(t, hivev1.ClusterProvisionStageFailed, provision.Spec.Stage, name)
+	}
+}
+
+func TestClusterDeploymentReconciler_FailsAtState(t *testing.T) {
+	ctx := context.Background()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+	}
+	cfg := config.DefaultConfig()
+	cfg.ClusterDeployment.DependsOnAccountClaim = false
+	cfg.ClusterDeployment.DependsOnProjectClaim = false
+	cfg.ClusterDeployment.FailureScenarios = nil
+	k8sClient := createTestClient(t, cd)
+	reconciler := createTestClusterDeploymentReconciler(k8sClient, cfg)
+	reconciler.behaviorEngine.SetResourceOverride(ctx, "ClusterDeployment", "default", "test-cluster",
+		&config.ResourceOverride{
+			ForceFail: &config.FailureScenario{Condition: "ProvisionFailed", Reason: "InstallerCrashed"},
+			AtState:   "Installing",
+		})
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cd)}
+
+	// Provisioning and Installing are reached before the failure applies
+	for _, expected := range []string{"Provisioning", "Installing"} {
+		_, err := reconciler.Reconcile(ctx, req)
+		require.NoError(t, err)
+
+		updated := &hivev1.ClusterDeployment{}
+		require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+		assert.Equal(t, expected, reconciler.stateMachine.CurrentState(updated))
+		assert.Nil(t, findCDCondition(updated, "ProvisionFailed"))
+	}
+
+	_, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	updated := &hivev1.ClusterDeployment{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	condition := findCDCondition(updated, "ProvisionFailed")
+	require.NotNil(t, condition)
+	assert.Equal(t, "InstallerCrashed", condition.Reason)
+	assert.True(t, state_machine.IsFailed(updated))
+	assert.False(t, updated.Spec.Installed)
+}
+
+func TestClusterDeploymentReconciler_NotifiesWebhookOnRunning(t *testing.T) {
+	ctx := context.Background()
+	received := make(chan notify.Event, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event notify.Event
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		received <- event
+	}))
+	defer server.Close()
+
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+	}
+	cfg := config.DefaultConfig()
+	cfg.ClusterDeployment.DependsOnAccountClaim = false
+	cfg.ClusterDeployment.DependsOnProjectClaim = false
+	cfg.ClusterDeployment.FailureScenarios = nil
+	k8sClient := createTestClient(t, cd)
+	reconciler := createTestClusterDeploymentReconciler(k8sClient, cfg)
+	notifier := notify.NewNotifier(createTestLogger())
+	notifier.Register(notify.Webhook{URL: server.URL, ResourceType: "clusterdeployment", TerminalState: "Running"})
+	reconciler.SetNotifier(notifier)
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cd)}
+
+	// Provisioning and Installing are not terminal, Running is
+	for i := 0; i < 3; i++ {
+		_, err := reconciler.Reconcile(ctx, req)
+		require.NoError(t, err)
+	}
+
+	select {
+	case event := <-received:
+		assert.Equal(t, "clusterdeployment", event.ResourceType)
+		assert.Equal(t, "default", event.Namespace)
+		assert.Equal(t, "test-cluster", event.Name)
+		assert.Equal(t, "Running", event.State)
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not notified")
+	}
+	assert.Empty(t, received)
+}
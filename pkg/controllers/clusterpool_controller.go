@@ -0,0 +1,206 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	kuberrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/openshift-online/ocm-sdk-go/logging"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/labels"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/logutil"
+)
+
+// ClusterPoolReconciler simulates Hive's ClusterPool controller: it keeps the configured number
+// of unclaimed ClusterDeployments in the namespace of each pool, which the ClusterDeployment
+// controller installs ahead of any claim, and reports how many of them are ready
+type ClusterPoolReconciler struct {
+	client       client.Client
+	logger       logging.Logger
+	configSource func() *config.ClusterPoolConfig
+	runID        string
+	namespaces   *namespaceGuard
+	now          func() time.Time
+}
+
+// NewClusterPoolReconciler creates a new ClusterPool reconciler from the clusterPool section of
+// the configuration
+func NewClusterPoolReconciler(client client.Client, logger logging.Logger, cfg *config.Config) *ClusterPoolReconciler {
+	return &ClusterPoolReconciler{
+		client:       client,
+		logger:       logger,
+		configSource: func() *config.ClusterPoolConfig { return cfg.ClusterPool },
+		runID:        cfg.RunID,
+		namespaces:   newNamespaceGuard(client, logger),
+		now:          time.Now,
+	}
+}
+
+// SetConfigSource makes the reconciler read the clusterPool section of the configuration
+// from source on every reconcile, so that a reloaded fill interval applies
+func (r *ClusterPoolReconciler) SetConfigSource(source func() *config.ClusterPoolConfig) {
+	r.configSource = source
+}
+
+// Reconcile adds ClusterDeployments to a pool below its size and updates its status
+func (r *ClusterPoolReconciler) Reconcile(ctx context.Context, req reconcile.Request) (result reconcile.Result, err error) {
+	defer func() {
+		result, err = requeueOnConflict(result, err)
+		recordReconcile("ClusterPool", result, err)
+	}()
+
+	ctx = logutil.WithReconcileID(ctx, "clusterpool", req.Namespace, req.Name)
+	r.logger.Debug(ctx, "Reconciling ClusterPool %s/%s", req.Namespace, req.Name)
+
+	pool := &hivev1.ClusterPool{}
+	if err := r.client.Get(ctx, req.NamespacedName, pool); err != nil {
+		if kuberrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		r.logger.Error(ctx, "Failed to get ClusterPool %s/%s: %v", req.Namespace, req.Name, err)
+		return reconcile.Result{}, err
+	}
+
+	if !pool.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+
+	terminating, err := r.namespaces.isTerminating(ctx, "ClusterPool", req.Namespace)
+	if err != nil {
+		r.logger.Error(ctx, "Failed to get namespace %s: %v", req.Namespace, err)
+		return reconcile.Result{}, err
+	}
+	if terminating {
+		return reconcile.Result{}, nil
+	}
+
+	cds, err := poolClusterDeployments(ctx, r.client, pool.Namespace, pool.Name)
+	if err != nil {
+		r.logger.Error(ctx, "Failed to list ClusterDeployments of ClusterPool %s/%s: %v", pool.Namespace, pool.Name, err)
+		return reconcile.Result{}, err
+	}
+
+	var unclaimed, ready int32
+	var lastCreated time.Time
+	for i := range cds {
+		cd := &cds[i]
+		if cd.CreationTimestamp.After(lastCreated) {
+			lastCreated = cd.CreationTimestamp.Time
+		}
+		if cd.Spec.ClusterPoolRef.ClaimName != "" || !cd.DeletionTimestamp.IsZero() {
+			continue
+		}
+		unclaimed++
+		if cd.Spec.Installed {
+			ready++
+		}
+	}
+
+	// Add the missing ClusterDeployments, one per fill interval when one is configured. Claimed
+	// ClusterDeployments still count towards the maximum size of the pool.
+	missing := pool.Spec.Size - unclaimed
+	if pool.Spec.MaxSize != nil {
+		missing = min(missing, *pool.Spec.MaxSize-int32(len(cds)))
+	}
+	interval := time.Duration(r.configSource().FillIntervalSeconds) * time.Second
+	if missing > 0 && interval > 0 {
+		if remaining := lastCreated.Add(interval).Sub(r.now()); remaining > 0 {
+			result.RequeueAfter = remaining
+			missing = 0
+		} else {
+			if missing > 1 {
+				result.RequeueAfter = interval
+			}
+			missing = 1
+		}
+	}
+	for i := int32(0); i < missing; i++ {
+		cd, err := r.buildClusterDeployment(pool)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if err := r.client.Create(ctx, cd); err != nil {
+			logWriteError(ctx, r.logger, err, "Failed to create ClusterDeployment for ClusterPool %s/%s: %v",
+				pool.Namespace, pool.Name, err)
+			return reconcile.Result{}, err
+		}
+		r.logger.Info(ctx, "ClusterPool %s/%s created ClusterDeployment %s", pool.Namespace, pool.Name, cd.Name)
+		unclaimed++
+	}
+
+	status := *pool.Status.DeepCopy()
+	status.Size = unclaimed
+	status.Ready = ready
+	status.Standby = unclaimed - ready
+	if equality.Semantic.DeepEqual(pool.Status, status) {
+		return result, nil
+	}
+	pool.Status = status
+	if err := r.client.Status().Update(ctx, pool); err != nil {
+		logWriteError(ctx, r.logger, err, "Failed to update ClusterPool %s/%s status: %v", pool.Namespace, pool.Name, err)
+		return reconcile.Result{}, err
+	}
+	r.logger.Debug(ctx, "ClusterPool %s/%s has %d unclaimed ClusterDeployments, %d ready",
+		pool.Namespace, pool.Name, status.Size, status.Ready)
+	return result, nil
+}
+
+// buildClusterDeployment builds a ClusterDeployment for the pool, named after it with a random
+// suffix like Hive names them. The pool owns it until it is claimed.
+func (r *ClusterPoolReconciler) buildClusterDeployment(pool *hivev1.ClusterPool) (*hivev1.ClusterDeployment, error) {
+	name := fmt.Sprintf("%s-%s", pool.Name, utilrand.String(5))
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   pool.Namespace,
+			Labels:      maps.Clone(pool.Spec.Labels),
+			Annotations: maps.Clone(pool.Spec.Annotations),
+		},
+		Spec: hivev1.ClusterDeploymentSpec{
+			ClusterName:   name,
+			BaseDomain:    pool.Spec.BaseDomain,
+			Platform:      *pool.Spec.Platform.DeepCopy(),
+			PullSecretRef: pool.Spec.PullSecretRef.DeepCopy(),
+			Provisioning: &hivev1.Provisioning{
+				ImageSetRef: pool.Spec.ImageSetRef.DeepCopy(),
+			},
+			ClusterPoolRef: &hivev1.ClusterPoolReference{
+				Namespace: pool.Namespace,
+				PoolName:  pool.Name,
+			},
+		},
+	}
+	if err := controllerutil.SetControllerReference(pool, cd, r.client.Scheme()); err != nil {
+		return nil, err
+	}
+	labels.StampRunID(cd, r.runID)
+	return cd, nil
+}
+
+// poolClusterDeployments returns the ClusterDeployments created for the named pool, claimed or not
+func poolClusterDeployments(ctx context.Context, c client.Client, namespace, poolName string) ([]hivev1.ClusterDeployment, error) {
+	cdList := &hivev1.ClusterDeploymentList{}
+	if err := c.List(ctx, cdList, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+	var cds []hivev1.ClusterDeployment
+	for _, cd := range cdList.Items {
+		poolRef := cd.Spec.ClusterPoolRef
+		if poolRef != nil && poolRef.Namespace == namespace && poolRef.PoolName == poolName {
+			cds = append(cds, cd)
+		}
+	}
+	return cds, nil
+}
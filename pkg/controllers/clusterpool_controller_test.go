@@ -0,0 +1,162 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	hivev1aws "github.com/openshift/hive/apis/hive/v1/aws"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+)
+
+func createTestClusterPoolReconciler(k8sClient client.Client, poolCfg *config.ClusterPoolConfig) *ClusterPoolReconciler {
+	cfg := config.DefaultConfig()
+	cfg.ClusterPool = poolCfg
+	return NewClusterPoolReconciler(k8sClient, createTestLogger(), cfg)
+}
+
+func testClusterPool(size int32) *hivev1.ClusterPool {
+	return &hivev1.ClusterPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-pool", Namespace: "default", UID: "pool-uid"},
+		Spec: hivev1.ClusterPoolSpec{
+			Size:        size,
+			BaseDomain:  "example.com",
+			ImageSetRef: hivev1.ClusterImageSetReference{Name: "openshift-v4.17.0"},
+			Platform:    hivev1.Platform{AWS: &hivev1aws.Platform{Region: "us-east-1"}},
+			Labels:      map[string]string{"team": "qe"},
+		},
+	}
+}
+
+// testPoolClusterDeployment returns a ClusterDeployment of the test pool
+func testPoolClusterDeployment(name string, created time.Time, installed bool, claimName string) *hivev1.ClusterDeployment {
+	return &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "default",
+			UID:               types.UID("uid-" + name),
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: hivev1.ClusterDeploymentSpec{
+			Installed: installed,
+			ClusterPoolRef: &hivev1.ClusterPoolReference{
+				Namespace: "default",
+				PoolName:  "test-pool",
+				ClaimName: claimName,
+			},
+		},
+	}
+}
+
+func listPoolClusterDeployments(t *testing.T, k8sClient client.Client) []hivev1.ClusterDeployment {
+	cds, err := poolClusterDeployments(context.Background(), k8sClient, "default", "test-pool")
+	require.NoError(t, err)
+	return cds
+}
+
+func TestClusterPoolReconciler_FillsPool(t *testing.T) {
+	ctx := context.Background()
+	pool := testClusterPool(3)
+	k8sClient := createTestClient(t, pool)
+	reconciler := createTestClusterPoolReconciler(k8sClient, &config.ClusterPoolConfig{})
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(pool)}
+
+	result, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+
+	cds := listPoolClusterDeployments(t, k8sClient)
+	require.Len(t, cds, 3)
+	for _, cd := range cds {
+		assert.Regexp(t, `^test-pool-[a-z0-9]{5}$`, cd.Name)
+		assert.Equal(t, cd.Name, cd.Spec.ClusterName)
+		assert.Equal(t, "example.com", cd.Spec.BaseDomain)
+		assert.Equal(t, "us-east-1", cd.Spec.Platform.AWS.Region)
+		assert.Equal(t, "openshift-v4.17.0", cd.Spec.Provisioning.ImageSetRef.Name)
+		assert.Equal(t, "qe", cd.Labels["team"])
+		assert.Empty(t, cd.Spec.ClusterPoolRef.ClaimName)
+		owner := metav1.GetControllerOf(&cd)
+		require.NotNil(t, owner)
+		assert.Equal(t, "ClusterPool", owner.Kind)
+		assert.Equal(t, "test-pool", owner.Name)
+	}
+
+	updated := &hivev1.ClusterPool{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	assert.Equal(t, int32(3), updated.Status.Size)
+	assert.Equal(t, int32(0), updated.Status.Ready)
+	assert.Equal(t, int32(3), updated.Status.Standby)
+
+	// An installed cluster is ready, a claimed one leaves the pool and is replaced
+	cds[0].Spec.Installed = true
+	require.NoError(t, k8sClient.Update(ctx, &cds[0]))
+	cds[1].Spec.ClusterPoolRef.ClaimName = "test-claim"
+	require.NoError(t, k8sClient.Update(ctx, &cds[1]))
+
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Len(t, listPoolClusterDeployments(t, k8sClient), 4)
+
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	assert.Equal(t, int32(3), updated.Status.Size)
+	assert.Equal(t, int32(1), updated.Status.Ready)
+	assert.Equal(t, int32(2), updated.Status.Standby)
+}
+
+func TestClusterPoolReconciler_MaxSize(t *testing.T) {
+	ctx := context.Background()
+	pool := testClusterPool(2)
+	maxSize := int32(2)
+	pool.Spec.MaxSize = &maxSize
+	now := time.Now()
+	k8sClient := createTestClient(t, pool,
+		testPoolClusterDeployment("test-pool-aaaaa", now, true, ""),
+		testPoolClusterDeployment("test-pool-bbbbb", now, true, "test-claim"),
+	)
+	reconciler := createTestClusterPoolReconciler(k8sClient, &config.ClusterPoolConfig{})
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(pool)}
+
+	// The claimed cluster counts towards the maximum size, so it is not replaced
+	_, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Len(t, listPoolClusterDeployments(t, k8sClient), 2)
+
+	updated := &hivev1.ClusterPool{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	assert.Equal(t, int32(1), updated.Status.Size)
+	assert.Equal(t, int32(1), updated.Status.Ready)
+	assert.Equal(t, int32(0), updated.Status.Standby)
+}
+
+func TestClusterPoolReconciler_FillInterval(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	pool := testClusterPool(3)
+	k8sClient := createTestClient(t, pool, testPoolClusterDeployment("test-pool-aaaaa", now, false, ""))
+	reconciler := createTestClusterPoolReconciler(k8sClient, &config.ClusterPoolConfig{FillIntervalSeconds: 10})
+	reconciler.now = func() time.Time { return now.Add(4 * time.Second) }
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(pool)}
+
+	// Within the interval of the last added cluster nothing is added
+	result, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, 6*time.Second, result.RequeueAfter)
+	assert.Len(t, listPoolClusterDeployments(t, k8sClient), 1)
+
+	// Then one cluster is added per interval
+	reconciler.now = func() time.Time { return now.Add(10 * time.Second) }
+	result, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Second, result.RequeueAfter)
+	assert.Len(t, listPoolClusterDeployments(t, k8sClient), 2)
+}
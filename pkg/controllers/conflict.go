@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	"context"
+
+	kuberrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/openshift-online/ocm-sdk-go/logging"
+)
+
+// requeueOnConflict turns a reconcile that failed on a write conflict into an immediate
+// requeue, another writer updated the resource first and the next reconcile works on its
+// latest version
+func requeueOnConflict(result reconcile.Result, err error) (reconcile.Result, error) {
+	if err != nil && kuberrors.IsConflict(err) {
+		return reconcile.Result{Requeue: true}, nil
+	}
+	return result, err
+}
+
+// logWriteError logs a failed write, at debug level when it failed on a conflict since the
+// reconcile is requeued for those
+func logWriteError(ctx context.Context, logger logging.Logger, err error, format string, args ...interface{}) {
+	if kuberrors.IsConflict(err) {
+		logger.Debug(ctx, format, args...)
+		return
+	}
+	logger.Error(ctx, format, args...)
+}
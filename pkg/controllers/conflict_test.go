@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	kuberrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/clock"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	aaov1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/aws-account-operator/v1alpha1"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/behavior"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/state_machine"
+)
+
+// conflictOnce returns interceptor funcs failing the first status update with a conflict
+func conflictOnce() interceptor.Funcs {
+	conflicted := false
+	return interceptor.Funcs{
+		SubResourceUpdate: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+			if !conflicted {
+				conflicted = true
+				return kuberrors.NewConflict(schema.GroupResource{}, obj.GetName(), errors.New("the object has been modified"))
+			}
+			return c.SubResource(subResourceName).Update(ctx, obj, opts...)
+		},
+	}
+}
+
+func TestRequeueOnConflict(t *testing.T) {
+	conflict := kuberrors.NewConflict(schema.GroupResource{}, "test", errors.New("the object has been modified"))
+	result, err := requeueOnConflict(reconcile.Result{}, conflict)
+	require.NoError(t, err)
+	assert.True(t, result.Requeue)
+
+	other := errors.New("boom")
+	result, err = requeueOnConflict(reconcile.Result{}, other)
+	assert.Equal(t, other, err)
+	assert.False(t, result.Requeue)
+}
+
+func TestClusterDeploymentReconciler_RequeuesOnConflict(t *testing.T) {
+	ctx := context.Background()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+	}
+	cfg := config.DefaultConfig()
+	cfg.ClusterDeployment.DependsOnAccountClaim = false
+	cfg.ClusterDeployment.DependsOnProjectClaim = false
+	cfg.ClusterDeployment.FailureScenarios = nil
+	k8sClient := createTestClientWithInterceptor(t, conflictOnce(), cd)
+	reconciler := createTestClusterDeploymentReconciler(k8sClient, cfg)
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cd)}
+
+	result, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.True(t, result.Requeue)
+
+	// The requeued reconcile applies the transition
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, cd))
+	assert.Equal(t, "Provisioning", state_machine.ClusterDeploymentState(cd))
+}
+
+func TestAccountClaimReconciler_RequeuesOnConflict(t *testing.T) {
+	ctx := context.Background()
+	ac := &aaov1alpha1.AccountClaim{ObjectMeta: metav1.ObjectMeta{Name: "ac-1", Namespace: "default"}}
+	k8sClient := createTestClientWithInterceptor(t, conflictOnce(), ac)
+
+	logger := createTestLogger()
+	cfg := config.DefaultConfig()
+	cfg.AccountClaim.FailureScenarios = nil
+	reconciler := NewAccountClaimReconciler(k8sClient, logger,
+		state_machine.NewAccountClaimStateMachine(logger, cfg.AccountClaim, clock.RealClock{}), behavior.NewEngine(logger, cfg))
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(ac)}
+
+	result, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.True(t, result.Requeue)
+
+	// The requeued reconcile applies the transition
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, ac))
+	assert.NotEmpty(t, ac.Status.State)
+}
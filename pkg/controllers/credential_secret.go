@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/labels"
+)
+
+// stampCredentialSecretMetadata merges the configured labels and annotations, rendered for the
+// claim, onto its credentials secret. It does nothing if no metadata is configured.
+func stampCredentialSecretMetadata(secret *corev1.Secret, cfg *config.CredentialSecretConfig, claim metav1.Object) error {
+	if cfg == nil {
+		return nil
+	}
+	secretLabels, annotations, err := cfg.Render(config.CredentialSecretData{
+		Name:      claim.GetName(),
+		Namespace: claim.GetNamespace(),
+		ClusterID: claim.GetLabels()[labels.ID],
+	})
+	if err != nil {
+		return err
+	}
+
+	for key, value := range secretLabels {
+		if secret.Labels == nil {
+			secret.Labels = make(map[string]string, len(secretLabels))
+		}
+		secret.Labels[key] = value
+	}
+	for key, value := range annotations {
+		if secret.Annotations == nil {
+			secret.Annotations = make(map[string]string, len(annotations))
+		}
+		secret.Annotations[key] = value
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	kuberrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/openshift-online/ocm-sdk-go/logging"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/logutil"
+)
+
+const (
+	// dnsZoneTypeLabel is the label Hive puts on a DNSZone with its type, dnsZoneTypeChild for
+	// the zone of a ClusterDeployment
+	dnsZoneTypeLabel = "hive.openshift.io/dnszone-type"
+	dnsZoneTypeChild = "child"
+
+	// defaultNameServerCount is how many fake name servers are reported when none are configured
+	defaultNameServerCount = 4
+)
+
+// DNSZoneReconciler simulates Hive's DNSZone controller: once the configured delay has passed
+// since a DNSZone was created, it is reported available with the configured name servers, the
+// way Hive reports a zone created in the cloud
+type DNSZoneReconciler struct {
+	client       client.Client
+	logger       logging.Logger
+	configSource func() *config.DNSZoneConfig
+	namespaces   *namespaceGuard
+	now          func() time.Time
+	accelerate   func(time.Duration) time.Duration
+}
+
+// NewDNSZoneReconciler creates a new DNSZone reconciler from the dnsZone section of the configuration
+func NewDNSZoneReconciler(client client.Client, logger logging.Logger, cfg *config.Config) *DNSZoneReconciler {
+	return &DNSZoneReconciler{
+		client:       client,
+		logger:       logger,
+		configSource: func() *config.DNSZoneConfig { return cfg.DNSZone },
+		namespaces:   newNamespaceGuard(client, logger),
+		now:          time.Now,
+		accelerate:   unaccelerated,
+	}
+}
+
+// SetConfigSource makes the reconciler read the dnsZone section of the configuration from
+// source on every reconcile, so that a reloaded delay and name servers apply
+func (r *DNSZoneReconciler) SetConfigSource(source func() *config.DNSZoneConfig) {
+	r.configSource = source
+}
+
+// SetAccelerate makes the reconciler scale the delay before a DNSZone is available with
+// accelerate, such as the engine's speed factor
+func (r *DNSZoneReconciler) SetAccelerate(accelerate func(time.Duration) time.Duration) {
+	r.accelerate = accelerate
+}
+
+// Reconcile reports a DNSZone available once its delay has passed
+func (r *DNSZoneReconciler) Reconcile(ctx context.Context, req reconcile.Request) (result reconcile.Result, err error) {
+	defer func() {
+		result, err = requeueOnConflict(result, err)
+		recordReconcile("DNSZone", result, err)
+	}()
+
+	ctx = logutil.WithReconcileID(ctx, "dnszone", req.Namespace, req.Name)
+	r.logger.Debug(ctx, "Reconciling DNSZone %s/%s", req.Namespace, req.Name)
+
+	dnsZone := &hivev1.DNSZone{}
+	if err := r.client.Get(ctx, req.NamespacedName, dnsZone); err != nil {
+		if kuberrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		r.logger.Error(ctx, "Failed to get DNSZone %s/%s: %v", req.Namespace, req.Name, err)
+		return reconcile.Result{}, err
+	}
+
+	if !dnsZone.DeletionTimestamp.IsZero() || IsDNSZoneAvailable(dnsZone) {
+		return reconcile.Result{}, nil
+	}
+
+	terminating, err := r.namespaces.isTerminating(ctx, "DNSZone", req.Namespace)
+	if err != nil {
+		r.logger.Error(ctx, "Failed to get namespace %s: %v", req.Namespace, err)
+		return reconcile.Result{}, err
+	}
+	if terminating {
+		return reconcile.Result{}, nil
+	}
+
+	now := r.now()
+	delay := r.accelerate(time.Duration(r.configSource().DelaySeconds) * time.Second)
+	if remaining := dnsZone.CreationTimestamp.Add(delay).Sub(now); remaining > 0 {
+		return reconcile.Result{RequeueAfter: remaining}, nil
+	}
+
+	syncTime := metav1.NewTime(now)
+	dnsZone.Status.NameServers = r.nameServers(dnsZone)
+	dnsZone.Status.LastSyncTimestamp = &syncTime
+	dnsZone.Status.LastSyncGeneration = dnsZone.Generation
+	setDNSZoneAvailableCondition(dnsZone, syncTime)
+	if err := r.client.Status().Update(ctx, dnsZone); err != nil {
+		logWriteError(ctx, r.logger, err, "Failed to update DNSZone %s/%s status: %v", dnsZone.Namespace, dnsZone.Name, err)
+		return reconcile.Result{}, err
+	}
+
+	r.logger.Info(ctx, "DNSZone %s/%s for zone %s is available", dnsZone.Namespace, dnsZone.Name, dnsZone.Spec.Zone)
+	return reconcile.Result{}, nil
+}
+
+// nameServers returns the configured name servers, or fake ones under the zone when none are
+func (r *DNSZoneReconciler) nameServers(dnsZone *hivev1.DNSZone) []string {
+	cfg := r.configSource()
+	if len(cfg.NameServers) > 0 {
+		return slices.Clone(cfg.NameServers)
+	}
+	nameServers := make([]string, 0, defaultNameServerCount)
+	for i := 1; i <= defaultNameServerCount; i++ {
+		nameServers = append(nameServers, fmt.Sprintf("ns%d.%s", i, dnsZone.Spec.Zone))
+	}
+	return nameServers
+}
+
+// setDNSZoneAvailableCondition sets the ZoneAvailable condition of the DNSZone to True
+func setDNSZoneAvailableCondition(dnsZone *hivev1.DNSZone, now metav1.Time) {
+	condition := hivev1.DNSZoneCondition{
+		Type:               hivev1.ZoneAvailableDNSZoneCondition,
+		Status:             corev1.ConditionTrue,
+		Reason:             "ZoneAvailable",
+		Message:            "DNS Zone available in cloud",
+		LastTransitionTime: now,
+		LastProbeTime:      now,
+	}
+	for i := range dnsZone.Status.Conditions {
+		if dnsZone.Status.Conditions[i].Type == condition.Type {
+			dnsZone.Status.Conditions[i] = condition
+			return
+		}
+	}
+	dnsZone.Status.Conditions = append(dnsZone.Status.Conditions, condition)
+}
+
+// IsDNSZoneAvailable returns true if the DNSZone reports its zone available, which is what Hive
+// waits for before installing a cluster
+func IsDNSZoneAvailable(dnsZone *hivev1.DNSZone) bool {
+	for _, condition := range dnsZone.Status.Conditions {
+		if condition.Type == hivev1.ZoneAvailableDNSZoneCondition {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+)
+
+func createTestDNSZoneReconciler(k8sClient client.Client, dnsZoneCfg *config.DNSZoneConfig) *DNSZoneReconciler {
+	cfg := config.DefaultConfig()
+	cfg.DNSZone = dnsZoneCfg
+	return NewDNSZoneReconciler(k8sClient, createTestLogger(), cfg)
+}
+
+func testDNSZone(name string, created time.Time) *hivev1.DNSZone {
+	return &hivev1.DNSZone{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "default",
+			Generation:        1,
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: hivev1.DNSZoneSpec{Zone: "test-cluster.example.com"},
+	}
+}
+
+func TestDNSZoneReconciler_AvailableAfterDelay(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	dnsZone := testDNSZone("test-cluster-zone", now)
+	k8sClient := createTestClient(t, dnsZone)
+	reconciler := createTestDNSZoneReconciler(k8sClient, &config.DNSZoneConfig{DelaySeconds: 30})
+	reconciler.now = func() time.Time { return now.Add(10 * time.Second) }
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(dnsZone)}
+
+	// Within the delay the zone is not available yet
+	result, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, 20*time.Second, result.RequeueAfter)
+	updated := &hivev1.DNSZone{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	assert.False(t, IsDNSZoneAvailable(updated))
+
+	reconciler.now = func() time.Time { return now.Add(30 * time.Second) }
+	result, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	assert.True(t, IsDNSZoneAvailable(updated))
+	require.Len(t, updated.Status.Conditions, 1)
+	assert.Equal(t, hivev1.ZoneAvailableDNSZoneCondition, updated.Status.Conditions[0].Type)
+	assert.Equal(t, corev1.ConditionTrue, updated.Status.Conditions[0].Status)
+	assert.Equal(t, []string{
+		"ns1.test-cluster.example.com",
+		"ns2.test-cluster.example.com",
+		"ns3.test-cluster.example.com",
+		"ns4.test-cluster.example.com",
+	}, updated.Status.NameServers)
+	require.NotNil(t, updated.Status.LastSyncTimestamp)
+	assert.True(t, now.Add(30*time.Second).Equal(updated.Status.LastSyncTimestamp.Time))
+	assert.Equal(t, int64(1), updated.Status.LastSyncGeneration)
+}
+
+func TestDNSZoneReconciler_Accelerated(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	dnsZone := testDNSZone("test-cluster-zone", now)
+	k8sClient := createTestClient(t, dnsZone)
+	reconciler := createTestDNSZoneReconciler(k8sClient, &config.DNSZoneConfig{DelaySeconds: 30})
+	reconciler.SetAccelerate(func(duration time.Duration) time.Duration { return duration / 10 })
+	reconciler.now = func() time.Time { return now.Add(time.Second) }
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(dnsZone)}
+
+	// The 30s delay takes 3s at a speed factor of 10
+	result, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Second, result.RequeueAfter)
+
+	reconciler.now = func() time.Time { return now.Add(3 * time.Second) }
+	result, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+	updated := &hivev1.DNSZone{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	assert.True(t, IsDNSZoneAvailable(updated))
+}
+
+func TestDNSZoneReconciler_ConfiguredNameServers(t *testing.T) {
+	ctx := context.Background()
+	dnsZone := testDNSZone("test-cluster-zone", time.Now().Add(-time.Minute))
+	k8sClient := createTestClient(t, dnsZone)
+	reconciler := createTestDNSZoneReconciler(k8sClient, &config.DNSZoneConfig{
+		NameServers: []string{"ns-1.awsdns-01.com", "ns-2.awsdns-02.net"},
+	})
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(dnsZone)}
+
+	_, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+
+	updated := &hivev1.DNSZone{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	assert.True(t, IsDNSZoneAvailable(updated))
+	assert.Equal(t, []string{"ns-1.awsdns-01.com", "ns-2.awsdns-02.net"}, updated.Status.NameServers)
+
+	// An available zone is left alone
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	after := &hivev1.DNSZone{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, after))
+	assert.Equal(t, updated.ResourceVersion, after.ResourceVersion)
+}
@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/behavior"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+)
+
+// EventReasonStateTransitioned is the reason of the events emitted for state transitions
+const EventReasonStateTransitioned = "StateTransitioned"
+
+// eventVerbosityLevels orders the event verbosities from the fewest to the most events
+var eventVerbosityLevels = map[string]int{
+	config.EventVerbosityNone:     0,
+	config.EventVerbosityFailures: 1,
+	config.EventVerbosityTerminal: 2,
+	config.EventVerbosityAll:      3,
+}
+
+// eventEmitter emits Kubernetes events for state transitions and failures, at the verbosity
+// currently configured in the behavior engine. Nothing is emitted until a recorder is set.
+type eventEmitter struct {
+	recorder       record.EventRecorder
+	behaviorEngine *behavior.Engine
+}
+
+func newEventEmitter(behaviorEngine *behavior.Engine) *eventEmitter {
+	return &eventEmitter{behaviorEngine: behaviorEngine}
+}
+
+// enabled returns true if events of the verbosity are emitted
+func (e *eventEmitter) enabled(verbosity string) bool {
+	if e.recorder == nil {
+		return false
+	}
+	configured := e.behaviorEngine.GetEventVerbosity()
+	return eventVerbosityLevels[configured] >= eventVerbosityLevels[verbosity]
+}
+
+// transitioned emits a Normal event for a transition to the state. Transitions to terminal
+// states are emitted at terminal verbosity, intermediate ones only at all.
+func (e *eventEmitter) transitioned(obj runtime.Object, state string, terminal bool) {
+	verbosity := config.EventVerbosityAll
+	if terminal {
+		verbosity = config.EventVerbosityTerminal
+	}
+	if !e.enabled(verbosity) {
+		return
+	}
+	e.recorder.Eventf(obj, corev1.EventTypeNormal, EventReasonStateTransitioned, "Transitioned to state %s", state)
+}
+
+// failed emits a Warning event for a failure, with the reason of the failure scenario or its
+// condition when it has none
+func (e *eventEmitter) failed(obj runtime.Object, failure *config.FailureScenario) {
+	if !e.enabled(config.EventVerbosityFailures) {
+		return
+	}
+	reason := failure.Reason
+	if reason == "" {
+		reason = failure.Condition
+	}
+	e.recorder.Event(obj, corev1.EventTypeWarning, reason, failure.Message)
+}
@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+)
+
+func TestClusterDeploymentReconciler_EventVerbosity(t *testing.T) {
+	ctx := context.Background()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+	}
+	k8sClient := createTestClient(t, cd)
+
+	cfg := config.DefaultConfig()
+	cfg.ClusterDeployment.DependsOnAccountClaim = false
+	cfg.ClusterDeployment.DependsOnProjectClaim = false
+	cfg.ClusterDeployment.FailureScenarios = nil
+	reconciler := createTestClusterDeploymentReconciler(k8sClient, cfg)
+	recorder := record.NewFakeRecorder(10)
+	reconciler.SetEventRecorder(recorder)
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cd)}
+
+	// Intermediate transitions emit no events at the default verbosity
+	_, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	updated := &hivev1.ClusterDeployment{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	require.False(t, updated.Spec.Installed)
+	assert.Empty(t, recorder.Events)
+
+	// Failures do
+	reconciler.behaviorEngine.SetResourceOverride(ctx, "ClusterDeployment", "default", "test-cluster",
+		&config.ResourceOverride{ForceFail: &config.FailureScenario{
+			Condition: "ProvisionFailed",
+			Reason:    "QuotaExceeded",
+			Message:   "Quota exceeded",
+		}})
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, recorder.Events, 1)
+	assert.Equal(t, "Warning QuotaExceeded Quota exceeded", <-recorder.Events)
+}
+
+func TestEventEmitter_Verbosity(t *testing.T) {
+	tests := []struct {
+		verbosity    string
+		intermediate bool
+		terminal     bool
+		failure      bool
+	}{
+		{verbosity: config.EventVerbosityNone},
+		{verbosity: config.EventVerbosityFailures, failure: true},
+		{verbosity: "", terminal: true, failure: true},
+		{verbosity: config.EventVerbosityTerminal, terminal: true, failure: true},
+		{verbosity: config.EventVerbosityAll, intermediate: true, terminal: true, failure: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.verbosity, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.EventVerbosity = tt.verbosity
+			reconciler := createTestClusterDeploymentReconciler(createTestClient(t), cfg)
+			recorder := record.NewFakeRecorder(10)
+			reconciler.SetEventRecorder(recorder)
+			cd := &hivev1.ClusterDeployment{ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"}}
+
+			reconciler.events.transitioned(cd, "Provisioning", false)
+			assert.Equal(t, tt.intermediate, len(recorder.Events) == 1, "intermediate transition")
+			drain(recorder)
+
+			reconciler.events.transitioned(cd, "Running", true)
+			assert.Equal(t, tt.terminal, len(recorder.Events) == 1, "terminal transition")
+			drain(recorder)
+
+			reconciler.events.failed(cd, &config.FailureScenario{Condition: "ProvisionFailed", Message: "failed"})
+			assert.Equal(t, tt.failure, len(recorder.Events) == 1, "failure")
+		})
+	}
+}
+
+func drain(recorder *record.FakeRecorder) {
+	for len(recorder.Events) > 0 {
+		<-recorder.Events
+	}
+}
@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	kuberrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift-online/ocm-sdk-go/logging"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	hivev1aws "github.com/openshift/hive/apis/hive/v1/aws"
+	errors "github.com/zgalor/weberr"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/labels"
+)
+
+// FleetRampLabel marks ClusterDeployments generated by the fleet ramp
+const FleetRampLabel = "hive-simulator.openshift.io/fleet-ramp"
+
+// FleetRamp creates ClusterDeployments at a fixed rate until a target count is reached
+type FleetRamp struct {
+	client       client.Client
+	logger       logging.Logger
+	configSource func() *config.FleetRampConfig
+	namespace    string
+	namePrefix   string
+	runID        string
+}
+
+// NewFleetRamp creates a new fleet ramp from the fleetRamp section of the configuration
+func NewFleetRamp(client client.Client, logger logging.Logger, cfg *config.Config) *FleetRamp {
+	rampCfg := cfg.FleetRamp
+	namespace := rampCfg.Namespace
+	if namespace == "" {
+		namespace = cfg.GetDefaultNamespace()
+	}
+	namePrefix := rampCfg.NamePrefix
+	if namePrefix == "" {
+		namePrefix = "fleet"
+	}
+
+	return &FleetRamp{
+		client:       client,
+		logger:       logger,
+		configSource: func() *config.FleetRampConfig { return rampCfg },
+		namespace:    namespace,
+		namePrefix:   namePrefix,
+		runID:        cfg.RunID,
+	}
+}
+
+// SetConfigSource makes the ramp read its rate and target from source before every creation,
+// so that reloaded ones apply to a ramp in progress. The namespace and name prefix are fixed
+// once the ramp is created.
+func (f *FleetRamp) SetConfigSource(source func() *config.FleetRampConfig) {
+	f.configSource = source
+}
+
+// rampInterval returns the time between two creations at the configured rate
+func rampInterval(cfg *config.FleetRampConfig) time.Duration {
+	return time.Duration(float64(time.Minute) / cfg.ClustersPerMinute)
+}
+
+// Start creates ClusterDeployments on schedule until the target count is reached
+// or the context is cancelled
+func (f *FleetRamp) Start(ctx context.Context) error {
+	if err := f.ensureNamespace(ctx); err != nil {
+		return errors.Wrapf(err, "failed to ensure fleet ramp namespace %s", f.namespace)
+	}
+
+	created, err := f.countExisting(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "failed to count existing fleet ramp ClusterDeployments")
+	}
+
+	cfg := f.configSource()
+	interval := rampInterval(cfg)
+	f.logger.Info(ctx, "Starting fleet ramp in namespace %s: %d/%d ClusterDeployments, one every %v",
+		f.namespace, created, cfg.TargetCount, interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for created < cfg.TargetCount {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			name := fmt.Sprintf("%s-%04d", f.namePrefix, created+1)
+			if err := f.client.Create(ctx, f.buildClusterDeployment(name)); err != nil && !kuberrors.IsAlreadyExists(err) {
+				f.logger.Warn(ctx, "Failed to create fleet ramp ClusterDeployment %s/%s: %v", f.namespace, name, err)
+				continue
+			}
+			created++
+			f.logger.Debug(ctx, "Fleet ramp created ClusterDeployment %s/%s (%d/%d)", f.namespace, name, created, cfg.TargetCount)
+		}
+
+		// Pick up a reloaded rate or target
+		cfg = f.configSource()
+		if next := rampInterval(cfg); next != interval {
+			f.logger.Info(ctx, "Fleet ramp now creates one ClusterDeployment every %v", next)
+			interval = next
+			ticker.Reset(interval)
+		}
+	}
+
+	f.logger.Info(ctx, "Fleet ramp reached its target of %d ClusterDeployments", cfg.TargetCount)
+	return nil
+}
+
+// countExisting returns how many fleet ramp ClusterDeployments already exist
+func (f *FleetRamp) countExisting(ctx context.Context) (int, error) {
+	cdList := &hivev1.ClusterDeploymentList{}
+	if err := f.client.List(ctx, cdList, client.InNamespace(f.namespace), client.HasLabels{FleetRampLabel}); err != nil {
+		return 0, err
+	}
+	return len(cdList.Items), nil
+}
+
+// ensureNamespace creates the ramp namespace if it doesn't exist
+func (f *FleetRamp) ensureNamespace(ctx context.Context) error {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: f.namespace}}
+	if err := f.client.Create(ctx, ns); err != nil && !kuberrors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+// buildClusterDeployment builds a minimal AWS ClusterDeployment with no claim dependencies
+func (f *FleetRamp) buildClusterDeployment(name string) *hivev1.ClusterDeployment {
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: f.namespace,
+			Labels: map[string]string{
+				FleetRampLabel:   "true",
+				"cloud-provider": "aws",
+			},
+		},
+		Spec: hivev1.ClusterDeploymentSpec{
+			ClusterName: name,
+			BaseDomain:  "example.com",
+			Platform: hivev1.Platform{
+				AWS: &hivev1aws.Platform{
+					Region: "us-east-1",
+				},
+			},
+		},
+	}
+	labels.StampRunID(cd, f.runID)
+	return cd
+}
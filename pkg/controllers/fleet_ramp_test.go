@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/labels"
+)
+
+func TestFleetRamp_CreatesTargetCount(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	k8sClient := createTestClient(t)
+	ramp := NewFleetRamp(k8sClient, createTestLogger(), &config.Config{
+		RunID: "ci-42",
+		FleetRamp: &config.FleetRampConfig{
+			ClustersPerMinute: 6000, // one every 10ms
+			TargetCount:       3,
+			Namespace:         "fleet",
+			NamePrefix:        "ramp",
+		},
+	})
+
+	require.NoError(t, ramp.Start(ctx))
+	require.NoError(t, ctx.Err(), "ramp should stop on its own once the target is reached")
+
+	cdList := &hivev1.ClusterDeploymentList{}
+	require.NoError(t, k8sClient.List(ctx, cdList, client.InNamespace("fleet")))
+	require.Len(t, cdList.Items, 3)
+	for _, cd := range cdList.Items {
+		assert.Equal(t, "true", cd.Labels[FleetRampLabel])
+		assert.Equal(t, "ci-42", cd.Labels[labels.RunID])
+	}
+	assert.Equal(t, "ramp-0001", cdList.Items[0].Name)
+}
+
+func TestFleetRamp_StopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	k8sClient := createTestClient(t)
+	ramp := NewFleetRamp(k8sClient, createTestLogger(), &config.Config{
+		FleetRamp: &config.FleetRampConfig{
+			ClustersPerMinute: 1,
+			TargetCount:       10,
+		},
+	})
+
+	require.NoError(t, ramp.Start(ctx))
+
+	cdList := &hivev1.ClusterDeploymentList{}
+	require.NoError(t, k8sClient.List(context.Background(), cdList))
+	assert.Empty(t, cdList.Items)
+}
@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/openshift-online/ocm-sdk-go/logging"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	hiveintv1alpha1 "github.com/openshift/hive/apis/hiveinternal/v1alpha1"
+	"github.com/stretchr/testify/require"
+
+	aaov1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/aws-account-operator/v1alpha1"
+	gcpv1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/gcp-project-operator/v1alpha1"
+)
+
+func createTestLogger() logging.Logger {
+	builder := logging.NewStdLoggerBuilder()
+	builder.Info(true)
+	logger, _ := builder.Build()
+	return logger
+}
+
+func createTestClient(t *testing.T, objects ...client.Object) client.Client {
+	return createTestClientWithInterceptor(t, interceptor.Funcs{}, objects...)
+}
+
+func createTestClientWithInterceptor(t *testing.T, funcs interceptor.Funcs, objects ...client.Object) client.Client {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, hivev1.AddToScheme(scheme))
+	require.NoError(t, hiveintv1alpha1.AddToScheme(scheme))
+	require.NoError(t, aaov1alpha1.AddToScheme(scheme))
+	require.NoError(t, gcpv1alpha1.AddToScheme(scheme))
+
+	return fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objects...).
+		WithInterceptorFuncs(funcs).
+		WithStatusSubresource(
+			&hivev1.ClusterDeployment{},
+			&hivev1.DNSZone{},
+			&hivev1.ClusterPool{},
+			&hivev1.ClusterClaim{},
+			&hiveintv1alpha1.ClusterSync{},
+			&aaov1alpha1.AccountClaim{},
+			&gcpv1alpha1.ProjectClaim{},
+		).
+		Build()
+}
@@ -0,0 +1,49 @@
+package controllers
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	reconcileTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "hivesim_reconcile_total",
+		Help: "Number of reconciles by resource and result (success, requeue or error)",
+	}, []string{"resource", "result"})
+
+	stateTransitionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "hivesim_state_transitions_total",
+		Help: "Number of state transitions by resource and the state transitioned to",
+	}, []string{"resource", "state"})
+)
+
+// failedState is the state failed ClusterDeployments are counted under in the transition metrics
+const failedState = "Failed"
+
+// RegisterMetrics registers the reconciler metrics with the registerer
+func RegisterMetrics(registerer prometheus.Registerer) error {
+	for _, collector := range []prometheus.Collector{reconcileTotal, stateTransitionsTotal} {
+		if err := registerer.Register(collector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordReconcile counts a reconcile of the resource by its outcome
+func recordReconcile(resource string, result reconcile.Result, err error) {
+	outcome := "success"
+	switch {
+	case err != nil:
+		outcome = "error"
+	case result.Requeue || result.RequeueAfter > 0:
+		outcome = "requeue"
+	}
+	reconcileTotal.WithLabelValues(resource, outcome).Inc()
+}
+
+// recordStateTransition counts a transition of the resource to the state
+func recordStateTransition(resource, state string) {
+	stateTransitionsTotal.WithLabelValues(resource, state).Inc()
+}
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	aaov1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/aws-account-operator/v1alpha1"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/behavior"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/state_machine"
+)
+
+func TestReconcileMetrics(t *testing.T) {
+	ctx := context.Background()
+	ac := &aaov1alpha1.AccountClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "ac-metrics", Namespace: "default"},
+	}
+	k8sClient := createTestClient(t, ac)
+
+	logger := createTestLogger()
+	cfg := config.DefaultConfig()
+	reconciler := NewAccountClaimReconciler(k8sClient, logger,
+		state_machine.NewAccountClaimStateMachine(logger, cfg.AccountClaim, clock.RealClock{}), behavior.NewEngine(logger, cfg))
+
+	// The counters are package level, so only their changes are checked
+	reconciles := func(result string) float64 {
+		return testutil.ToFloat64(reconcileTotal.WithLabelValues("AccountClaim", result))
+	}
+	transitions := func(state aaov1alpha1.ClaimStatus) float64 {
+		return testutil.ToFloat64(stateTransitionsTotal.WithLabelValues("AccountClaim", string(state)))
+	}
+	requeueBefore := reconciles("requeue")
+	readyBefore := transitions(aaov1alpha1.ClaimStatusReady)
+
+	result, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(ac)})
+	require.NoError(t, err)
+	require.Positive(t, result.RequeueAfter)
+
+	updated := &aaov1alpha1.AccountClaim{}
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(ac), updated))
+	require.Equal(t, aaov1alpha1.ClaimStatusReady, updated.Status.State)
+	assert.Equal(t, requeueBefore+1, reconciles("requeue"))
+	assert.Equal(t, readyBefore+1, transitions(aaov1alpha1.ClaimStatusReady))
+}
+
+func TestRegisterMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	require.NoError(t, RegisterMetrics(registry))
+	assert.Error(t, RegisterMetrics(registry))
+}
@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"context"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	kuberrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift-online/ocm-sdk-go/logging"
+)
+
+// namespaceGuard detects namespaces that are being terminated so reconcilers can skip
+// their resources instead of failing on writes the API server rejects
+type namespaceGuard struct {
+	client client.Client
+	logger logging.Logger
+
+	// logged holds the terminating namespaces already reported, so each is logged once
+	logged sync.Map
+}
+
+// newNamespaceGuard creates a new namespace guard
+func newNamespaceGuard(client client.Client, logger logging.Logger) *namespaceGuard {
+	return &namespaceGuard{
+		client: client,
+		logger: logger,
+	}
+}
+
+// isTerminating reports whether the namespace is in the Terminating phase. A namespace
+// that cannot be found is not considered terminating, so the caller proceeds as usual.
+func (g *namespaceGuard) isTerminating(ctx context.Context, kind string, namespace string) (bool, error) {
+	ns := &corev1.Namespace{}
+	if err := g.client.Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+		if kuberrors.IsNotFound(err) {
+			g.logged.Delete(namespace)
+			return false, nil
+		}
+		return false, err
+	}
+
+	if ns.Status.Phase != corev1.NamespaceTerminating {
+		// The name may be reused after the namespace is gone
+		g.logged.Delete(namespace)
+		return false, nil
+	}
+
+	if _, alreadyLogged := g.logged.LoadOrStore(namespace, struct{}{}); !alreadyLogged {
+		g.logger.Info(ctx, "Namespace %s is terminating, skipping %s resources in it", namespace, kind)
+	}
+	return true, nil
+}
@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/openshift-online/ocm-sdk-go/logging"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/behavior"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/state_machine"
+)
+
+func TestClusterDeploymentReconciler_SkipsTerminatingNamespace(t *testing.T) {
+	ctx := context.Background()
+	ns := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "doomed"},
+		Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceTerminating},
+	}
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "doomed"},
+	}
+	k8sClient := createTestClient(t, ns, cd)
+
+	var stdout, stderr bytes.Buffer
+	logger, err := logging.NewStdLoggerBuilder().Streams(&stdout, &stderr).Build()
+	require.NoError(t, err)
+	cfg := config.DefaultConfig()
+	reconciler := NewClusterDeploymentReconciler(k8sClient, logger,
+		state_machine.NewClusterDeploymentStateMachine(logger, cfg.ClusterDeployment, clock.RealClock{}), behavior.NewEngine(logger, cfg))
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cd)}
+
+	for i := 0; i < 3; i++ {
+		result, err := reconciler.Reconcile(ctx, req)
+		require.NoError(t, err)
+		assert.Zero(t, result.RequeueAfter, "a terminating namespace must not be requeued")
+	}
+
+	// The resource is left untouched and the namespace is reported only once
+	updated := &hivev1.ClusterDeployment{}
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+	assert.Nil(t, updated.Status.ProvisionRef)
+	assert.Empty(t, updated.Status.Conditions)
+	assert.Equal(t, 1, strings.Count(stdout.String(), "Namespace doomed is terminating"))
+}
+
+func TestNamespaceGuard_IsTerminating(t *testing.T) {
+	ctx := context.Background()
+	active := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "active"},
+		Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceActive},
+	}
+	guard := newNamespaceGuard(createTestClient(t, active), createTestLogger())
+
+	terminating, err := guard.isTerminating(ctx, "AccountClaim", "active")
+	require.NoError(t, err)
+	assert.False(t, terminating)
+
+	// A missing namespace is not treated as terminating
+	terminating, err = guard.isTerminating(ctx, "AccountClaim", "missing")
+	require.NoError(t, err)
+	assert.False(t, terminating)
+}
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift-online/ocm-sdk-go/logging"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+
+	aaov1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/aws-account-operator/v1alpha1"
+	gcpv1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/gcp-project-operator/v1alpha1"
+)
+
+// ProbeTimeRefresher periodically bumps LastProbeTime on the conditions of
+// resources that reached a terminal state, leaving LastTransitionTime untouched
+type ProbeTimeRefresher struct {
+	client   client.Client
+	logger   logging.Logger
+	interval time.Duration
+	now      func() metav1.Time
+}
+
+// NewProbeTimeRefresher creates a new probe time refresher
+func NewProbeTimeRefresher(client client.Client, logger logging.Logger, interval time.Duration) *ProbeTimeRefresher {
+	return &ProbeTimeRefresher{
+		client:   client,
+		logger:   logger,
+		interval: interval,
+		now:      metav1.Now,
+	}
+}
+
+// Start runs the refresher until the context is cancelled
+func (p *ProbeTimeRefresher) Start(ctx context.Context) error {
+	p.logger.Info(ctx, "Starting probe time refresher (interval: %v)", p.interval)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			p.refresh(ctx)
+		}
+	}
+}
+
+// refresh updates LastProbeTime on all terminal resources
+func (p *ProbeTimeRefresher) refresh(ctx context.Context) {
+	now := p.now()
+
+	cdList := &hivev1.ClusterDeploymentList{}
+	if err := p.client.List(ctx, cdList); err != nil {
+		p.logger.Error(ctx, "Failed to list ClusterDeployments for probe time refresh: %v", err)
+	} else {
+		for i := range cdList.Items {
+			cd := &cdList.Items[i]
+			if !isClusterDeploymentTerminal(cd) || len(cd.Status.Conditions) == 0 {
+				continue
+			}
+			for j := range cd.Status.Conditions {
+				cd.Status.Conditions[j].LastProbeTime = now
+			}
+			if err := p.client.Status().Update(ctx, cd); err != nil {
+				p.logger.Warn(ctx, "Failed to refresh probe time on ClusterDeployment %s/%s: %v", cd.Namespace, cd.Name, err)
+			}
+		}
+	}
+
+	acList := &aaov1alpha1.AccountClaimList{}
+	if err := p.client.List(ctx, acList); err != nil {
+		p.logger.Error(ctx, "Failed to list AccountClaims for probe time refresh: %v", err)
+	} else {
+		for i := range acList.Items {
+			ac := &acList.Items[i]
+			if !isClaimTerminal(string(ac.Status.State)) || len(ac.Status.Conditions) == 0 {
+				continue
+			}
+			for j := range ac.Status.Conditions {
+				ac.Status.Conditions[j].LastProbeTime = now
+			}
+			if err := p.client.Status().Update(ctx, ac); err != nil {
+				p.logger.Warn(ctx, "Failed to refresh probe time on AccountClaim %s/%s: %v", ac.Namespace, ac.Name, err)
+			}
+		}
+	}
+
+	pcList := &gcpv1alpha1.ProjectClaimList{}
+	if err := p.client.List(ctx, pcList); err != nil {
+		p.logger.Error(ctx, "Failed to list ProjectClaims for probe time refresh: %v", err)
+	} else {
+		for i := range pcList.Items {
+			pc := &pcList.Items[i]
+			if !isClaimTerminal(string(pc.Status.State)) || len(pc.Status.Conditions) == 0 {
+				continue
+			}
+			for j := range pc.Status.Conditions {
+				pc.Status.Conditions[j].LastProbeTime = now
+			}
+			if err := p.client.Status().Update(ctx, pc); err != nil {
+				p.logger.Warn(ctx, "Failed to refresh probe time on ProjectClaim %s/%s: %v", pc.Namespace, pc.Name, err)
+			}
+		}
+	}
+}
+
+// isClusterDeploymentTerminal returns true if the ClusterDeployment is installed or failed
+func isClusterDeploymentTerminal(cd *hivev1.ClusterDeployment) bool {
+	if cd.Spec.Installed {
+		return true
+	}
+	return cd.Status.ProvisionRef != nil && strings.HasSuffix(cd.Status.ProvisionRef.Name, "-provision-failed")
+}
+
+// isClaimTerminal returns true if an AccountClaim/ProjectClaim state is terminal
+func isClaimTerminal(state string) bool {
+	return state == string(aaov1alpha1.ClaimStatusReady) || state == string(aaov1alpha1.ClaimStatusError)
+}
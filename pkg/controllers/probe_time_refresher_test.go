@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	aaov1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/aws-account-operator/v1alpha1"
+)
+
+func TestProbeTimeRefresher_Refresh(t *testing.T) {
+	ctx := context.Background()
+	transitionTime := metav1.NewTime(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	ac := &aaov1alpha1.AccountClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "ready-claim", Namespace: "default"},
+		Status: aaov1alpha1.AccountClaimStatus{
+			State: aaov1alpha1.ClaimStatusReady,
+			Conditions: []aaov1alpha1.AccountClaimCondition{
+				{
+					Type:               aaov1alpha1.AccountClaimed,
+					Status:             corev1.ConditionTrue,
+					LastTransitionTime: transitionTime,
+					LastProbeTime:      transitionTime,
+				},
+			},
+		},
+	}
+	pending := &aaov1alpha1.AccountClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "pending-claim", Namespace: "default"},
+		Status: aaov1alpha1.AccountClaimStatus{
+			State: aaov1alpha1.ClaimStatusPending,
+			Conditions: []aaov1alpha1.AccountClaimCondition{
+				{
+					Type:               aaov1alpha1.AccountUnclaimed,
+					Status:             corev1.ConditionTrue,
+					LastTransitionTime: transitionTime,
+					LastProbeTime:      transitionTime,
+				},
+			},
+		},
+	}
+
+	k8sClient := createTestClient(t, ac, pending)
+	refresher := NewProbeTimeRefresher(k8sClient, createTestLogger(), time.Second)
+
+	clock := transitionTime.Time
+	refresher.now = func() metav1.Time {
+		clock = clock.Add(time.Minute)
+		return metav1.NewTime(clock)
+	}
+
+	var previousProbe time.Time
+	for cycle := 1; cycle <= 2; cycle++ {
+		refresher.refresh(ctx)
+
+		updated := &aaov1alpha1.AccountClaim{}
+		require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(ac), updated))
+		require.Len(t, updated.Status.Conditions, 1)
+
+		probe := updated.Status.Conditions[0].LastProbeTime.Time
+		assert.True(t, probe.After(transitionTime.Time), "cycle %d: probe time should advance", cycle)
+		assert.True(t, probe.After(previousProbe), "cycle %d: probe time should advance", cycle)
+		assert.True(t, updated.Status.Conditions[0].LastTransitionTime.Time.Equal(transitionTime.Time))
+		previousProbe = probe
+	}
+
+	// Non-terminal resources are left untouched
+	untouched := &aaov1alpha1.AccountClaim{}
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(pending), untouched))
+	assert.True(t, untouched.Status.Conditions[0].LastProbeTime.Time.Equal(transitionTime.Time))
+}
@@ -7,6 +7,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	kuberrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -16,6 +17,9 @@ import (
 	gcpv1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/gcp-project-operator/v1alpha1"
 	"github.com/tzvatot/openshift-hive-simulator/pkg/behavior"
 	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/labels"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/logutil"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/notify"
 	"github.com/tzvatot/openshift-hive-simulator/pkg/state_machine"
 )
 
@@ -25,6 +29,10 @@ type ProjectClaimReconciler struct {
 	logger         logging.Logger
 	stateMachine   *state_machine.ProjectClaimStateMachine
 	behaviorEngine *behavior.Engine
+	namespaces     *namespaceGuard
+	events         *eventEmitter
+	notifier       *notify.Notifier
+	dryRun         bool
 }
 
 // NewProjectClaimReconciler creates a new ProjectClaim reconciler
@@ -39,13 +47,45 @@ func NewProjectClaimReconciler(
 		logger:         logger,
 		stateMachine:   stateMachine,
 		behaviorEngine: behaviorEngine,
+		namespaces:     newNamespaceGuard(client, logger),
+		events:         newEventEmitter(behaviorEngine),
 	}
 }
 
+// SetEventRecorder sets the recorder of the events emitted for ProjectClaim transitions, no events
+// are emitted without one
+func (r *ProjectClaimReconciler) SetEventRecorder(recorder record.EventRecorder) {
+	r.events.recorder = recorder
+}
+
+// SetNotifier sets the notifier of the webhooks registered for terminal states, no webhooks are
+// notified without one
+func (r *ProjectClaimReconciler) SetNotifier(notifier *notify.Notifier) {
+	r.notifier = notifier
+}
+
+// SetDryRun sets whether ProjectClaim transitions are only logged, without writing anything to the
+// API server
+func (r *ProjectClaimReconciler) SetDryRun(dryRun bool) {
+	r.dryRun = dryRun
+}
+
 // Reconcile reconciles a ProjectClaim
-func (r *ProjectClaimReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+func (r *ProjectClaimReconciler) Reconcile(ctx context.Context, req reconcile.Request) (result reconcile.Result, err error) {
+	defer func() {
+		result, err = requeueOnConflict(result, err)
+		recordReconcile("ProjectClaim", result, err)
+	}()
+
+	ctx = logutil.WithReconcileID(ctx, "pc", req.Namespace, req.Name)
 	r.logger.Debug(ctx, "Reconciling ProjectClaim %s/%s", req.Namespace, req.Name)
 
+	// Leave every resource as it is while reconciliation is paused
+	if r.behaviorEngine.IsReconcilePaused() {
+		r.logger.Debug(ctx, "Reconciliation is paused, requeuing ProjectClaim %s/%s", req.Namespace, req.Name)
+		return reconcile.Result{RequeueAfter: reconcilePausedRequeueInterval}, nil
+	}
+
 	pc := &gcpv1alpha1.ProjectClaim{}
 	if err := r.client.Get(ctx, req.NamespacedName, pc); err != nil {
 		if kuberrors.IsNotFound(err) {
@@ -56,20 +96,50 @@ func (r *ProjectClaimReconciler) Reconcile(ctx context.Context, req reconcile.Re
 		return reconcile.Result{}, err
 	}
 
+	// Only log the transition that would be applied in dry run
+	if r.dryRun {
+		return r.planTransition(ctx, pc), nil
+	}
+
 	// Skip if being deleted
 	if !pc.DeletionTimestamp.IsZero() {
 		r.logger.Debug(ctx, "ProjectClaim %s/%s is being deleted, skipping", req.Namespace, req.Name)
 		return reconcile.Result{}, nil
 	}
 
+	// Skip if the namespace is being deleted, writes to it would be rejected
+	terminating, err := r.namespaces.isTerminating(ctx, "ProjectClaim", req.Namespace)
+	if err != nil {
+		r.logger.Error(ctx, "Failed to get namespace %s: %v", req.Namespace, err)
+		return reconcile.Result{}, err
+	}
+	if terminating {
+		return reconcile.Result{}, nil
+	}
+
 	// Skip if already in final state
 	if pc.Status.State == gcpv1alpha1.ClaimStatusReady || pc.Status.State == gcpv1alpha1.ClaimStatusError {
 		r.logger.Debug(ctx, "ProjectClaim %s/%s is in final state: %s, skipping", req.Namespace, req.Name, pc.Status.State)
 		return reconcile.Result{}, nil
 	}
 
+	// Hold a paused ProjectClaim in its current state
+	if r.behaviorEngine.IsPaused("ProjectClaim", pc.Namespace, pc.Name) {
+		r.logger.Debug(ctx, "ProjectClaim %s/%s is paused", req.Namespace, req.Name)
+		return reconcile.Result{RequeueAfter: pausedRequeueInterval}, nil
+	}
+
+	// Spread out the first transition of resources created together
+	if delay := r.stateMachine.StartDelay(pc); delay > 0 {
+		r.logger.Debug(ctx, "Delaying first transition of ProjectClaim %s/%s by %v", pc.Namespace, pc.Name, delay)
+		return reconcile.Result{RequeueAfter: delay}, nil
+	}
+
 	// Check for forced failure
-	shouldFail, failure := r.behaviorEngine.ShouldFail(ctx, "ProjectClaim", pc.Namespace, pc.Name)
+	shouldFail, failure := r.behaviorEngine.ShouldFail(ctx, "ProjectClaim", pc.Namespace, pc.Name, r.stateMachine.CurrentState(pc), pc.Labels)
+	if shouldFail && failure.RetriesBeforeSuccess > 0 {
+		return r.applyTransientFailure(ctx, pc, failure)
+	}
 	if shouldFail {
 		return r.applyFailure(ctx, pc, failure)
 	}
@@ -78,22 +148,28 @@ func (r *ProjectClaimReconciler) Reconcile(ctx context.Context, req reconcile.Re
 	nextState, duration := r.stateMachine.GetNextState(ctx, pc)
 
 	// Apply the state
-	if err := r.stateMachine.ApplyState(ctx, pc, nextState); err != nil {
+	specChanged, err := r.stateMachine.ApplyState(ctx, pc, nextState)
+	if err != nil {
 		r.logger.Error(ctx, "Failed to apply state %s to ProjectClaim %s/%s: %v",
 			nextState, pc.Namespace, pc.Name, err)
 		return reconcile.Result{}, err
 	}
 
-	// Update the ProjectClaim
-	if err := r.client.Status().Update(ctx, pc); err != nil {
-		r.logger.Error(ctx, "Failed to update ProjectClaim %s/%s status: %v",
-			pc.Namespace, pc.Name, err)
-		return reconcile.Result{}, err
+	// Update the spec first if fields were set. Each update replaces the ProjectClaim with the
+	// stored one, so the status is restored before it is written.
+	if specChanged {
+		status := pc.Status.DeepCopy()
+		if err := r.client.Update(ctx, pc); err != nil {
+			logWriteError(ctx, r.logger, err, "Failed to update ProjectClaim %s/%s spec: %v",
+				pc.Namespace, pc.Name, err)
+			return reconcile.Result{}, err
+		}
+		pc.Status = *status
 	}
 
-	// Also update spec if fields were set
-	if err := r.client.Update(ctx, pc); err != nil {
-		r.logger.Error(ctx, "Failed to update ProjectClaim %s/%s spec: %v",
+	// Update the ProjectClaim status
+	if err := r.client.Status().Update(ctx, pc); err != nil {
+		logWriteError(ctx, r.logger, err, "Failed to update ProjectClaim %s/%s status: %v",
 			pc.Namespace, pc.Name, err)
 		return reconcile.Result{}, err
 	}
@@ -108,11 +184,17 @@ func (r *ProjectClaimReconciler) Reconcile(ctx context.Context, req reconcile.Re
 	}
 
 	r.logger.Info(ctx, "ProjectClaim %s/%s transitioned to state: %s", pc.Namespace, pc.Name, nextState)
+	recordStateTransition("ProjectClaim", string(nextState))
+	r.behaviorEngine.PublishTransition("ProjectClaim", pc.Namespace, pc.Name, string(nextState))
+	r.events.transitioned(pc, string(nextState), isClaimTerminal(string(nextState)))
+	if isClaimTerminal(string(nextState)) {
+		notifyTerminal(ctx, r.notifier, "ProjectClaim", pc, string(nextState))
+	}
 
 	// Requeue after duration for next state transition
 	if duration > 0 {
 		// Check for delay override
-		duration = r.behaviorEngine.GetTransitionDelay(ctx, "ProjectClaim", pc.Namespace, pc.Name, duration)
+		duration = r.behaviorEngine.GetTransitionDelay(ctx, "ProjectClaim", pc.Namespace, pc.Name, pc.Labels, duration)
 		r.logger.Debug(ctx, "Requeuing ProjectClaim %s/%s after %v", pc.Namespace, pc.Name, duration)
 		return reconcile.Result{RequeueAfter: duration}, nil
 	}
@@ -120,6 +202,34 @@ func (r *ProjectClaimReconciler) Reconcile(ctx context.Context, req reconcile.Re
 	return reconcile.Result{}, nil
 }
 
+// planTransition logs the next transition of a ProjectClaim without applying it, requeuing it
+// for when the transition would be due
+func (r *ProjectClaimReconciler) planTransition(ctx context.Context, pc *gcpv1alpha1.ProjectClaim) reconcile.Result {
+	currentState := r.stateMachine.CurrentState(pc)
+	nextState, duration := r.stateMachine.GetNextState(ctx, pc)
+	if string(nextState) == currentState && duration == 0 {
+		r.logger.Info(ctx, "Dry run: ProjectClaim %s/%s is in final state %s", pc.Namespace, pc.Name, currentState)
+		return reconcile.Result{}
+	}
+	duration = r.behaviorEngine.GetTransitionDelay(ctx, "ProjectClaim", pc.Namespace, pc.Name, pc.Labels, duration)
+	r.logger.Info(ctx, "Dry run: would transition ProjectClaim %s/%s from %s to %s after %v",
+		pc.Namespace, pc.Name, currentState, nextState, duration)
+	return reconcile.Result{RequeueAfter: duration}
+}
+
+// applyTransientFailure sets the failure condition on the ProjectClaim and requeues it to retry
+// its transition
+func (r *ProjectClaimReconciler) applyTransientFailure(ctx context.Context, pc *gcpv1alpha1.ProjectClaim, failure *config.FailureScenario) (reconcile.Result, error) {
+	r.stateMachine.ApplyTransientFailure(ctx, pc, failure)
+	if err := r.client.Status().Update(ctx, pc); err != nil {
+		logWriteError(ctx, r.logger, err, "Failed to update retrying ProjectClaim %s/%s status: %v",
+			pc.Namespace, pc.Name, err)
+		return reconcile.Result{}, err
+	}
+	r.events.failed(pc, failure)
+	return reconcile.Result{RequeueAfter: transientRetryInterval}, nil
+}
+
 // applyFailure applies a failure state to the ProjectClaim
 func (r *ProjectClaimReconciler) applyFailure(ctx context.Context, pc *gcpv1alpha1.ProjectClaim, failure *config.FailureScenario) (reconcile.Result, error) {
 	if err := r.stateMachine.ApplyFailure(ctx, pc, failure); err != nil {
@@ -129,12 +239,16 @@ func (r *ProjectClaimReconciler) applyFailure(ctx context.Context, pc *gcpv1alph
 	}
 
 	if err := r.client.Status().Update(ctx, pc); err != nil {
-		r.logger.Error(ctx, "Failed to update failed ProjectClaim %s/%s status: %v",
+		logWriteError(ctx, r.logger, err, "Failed to update failed ProjectClaim %s/%s status: %v",
 			pc.Namespace, pc.Name, err)
 		return reconcile.Result{}, err
 	}
 
 	r.logger.Info(ctx, "ProjectClaim %s/%s failed: %s", pc.Namespace, pc.Name, failure.Message)
+	recordStateTransition("ProjectClaim", string(gcpv1alpha1.ClaimStatusError))
+	r.behaviorEngine.PublishTransition("ProjectClaim", pc.Namespace, pc.Name, string(gcpv1alpha1.ClaimStatusError))
+	r.events.failed(pc, failure)
+	notifyTerminal(ctx, r.notifier, "ProjectClaim", pc, string(gcpv1alpha1.ClaimStatusError))
 	return reconcile.Result{}, nil
 }
 
@@ -194,6 +308,11 @@ func (r *ProjectClaimReconciler) createGCPCredentialsSecret(ctx context.Context,
 		},
 	}
 
+	if err := stampCredentialSecretMetadata(secret, r.behaviorEngine.GetProjectClaimConfig().CredentialSecret, pc); err != nil {
+		return err
+	}
+	labels.StampRunID(secret, r.behaviorEngine.GetRunID())
+
 	if err := r.client.Create(ctx, secret); err != nil {
 		return err
 	}
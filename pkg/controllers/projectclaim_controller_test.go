@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	gcpv1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/gcp-project-operator/v1alpha1"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/behavior"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/state_machine"
+)
+
+func TestProjectClaimReconciler_UpdatesSpecOnlyWhenChanged(t *testing.T) {
+	ctx := context.Background()
+	pc := &gcpv1alpha1.ProjectClaim{ObjectMeta: metav1.ObjectMeta{Name: "pc-1", Namespace: "default"}}
+
+	specUpdates := 0
+	k8sClient := createTestClientWithInterceptor(t, interceptor.Funcs{
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			if _, ok := obj.(*gcpv1alpha1.ProjectClaim); ok {
+				specUpdates++
+			}
+			return c.Update(ctx, obj, opts...)
+		},
+	}, pc)
+
+	logger := createTestLogger()
+	cfg := config.DefaultConfig()
+	cfg.ProjectClaim.FailureScenarios = nil
+	reconciler := NewProjectClaimReconciler(k8sClient, logger,
+		state_machine.NewProjectClaimStateMachine(logger, cfg.ProjectClaim, clock.RealClock{}), behavior.NewEngine(logger, cfg))
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(pc)}
+
+	// PendingProject sets the project ID in the spec
+	_, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, pc))
+	assert.Equal(t, gcpv1alpha1.ClaimStatusPendingProject, pc.Status.State)
+	assert.NotEmpty(t, pc.Spec.GCPProjectID)
+	assert.Equal(t, 1, specUpdates)
+
+	// Ready keeps the project ID, so only the status is updated
+	_, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, pc))
+	assert.Equal(t, gcpv1alpha1.ClaimStatusReady, pc.Status.State)
+	assert.Equal(t, 1, specUpdates)
+}
@@ -0,0 +1,11 @@
+package controllers
+
+import (
+	"time"
+)
+
+// unaccelerated is the default acceleration of the reconcilers timing their own delays,
+// leaving them as configured
+func unaccelerated(duration time.Duration) time.Duration {
+	return duration
+}
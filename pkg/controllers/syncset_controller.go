@@ -0,0 +1,440 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kuberrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8slabels "k8s.io/apimachinery/pkg/labels"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/openshift-online/ocm-sdk-go/logging"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	hiveintv1alpha1 "github.com/openshift/hive/apis/hiveinternal/v1alpha1"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/labels"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/logutil"
+)
+
+const (
+	// syncSetKind and selectorSyncSetKind name the kinds of sync sets in ClusterSync messages
+	syncSetKind         = "SyncSet"
+	selectorSyncSetKind = "SelectorSyncSet"
+
+	// defaultSyncSetFailureMessage is reported for failing resources configured without a message
+	defaultSyncSetFailureMessage = "simulated apply failure"
+)
+
+// SyncSetReconciler simulates applying SyncSets, and optionally SelectorSyncSets, to installed
+// ClusterDeployments. As SyncSets have no status fields, the results are reported the way Hive
+// does: in a ClusterSync named after each ClusterDeployment. Requests are keyed by
+// ClusterDeployment, changes to sync sets are mapped to the ClusterDeployments they may apply to.
+type SyncSetReconciler struct {
+	client       client.Client
+	logger       logging.Logger
+	configSource func() *config.SyncSetConfig
+	runID        string
+	namespaces   *namespaceGuard
+	now          func() time.Time
+	accelerate   func(time.Duration) time.Duration
+
+	// observed holds when each sync set generation was first seen for a ClusterDeployment,
+	// the apply delay counts from then
+	mu       sync.Mutex
+	observed map[string]observedGeneration
+}
+
+type observedGeneration struct {
+	generation int64
+	at         time.Time
+}
+
+// NewSyncSetReconciler creates a new SyncSet reconciler from the syncSet section of the configuration
+func NewSyncSetReconciler(client client.Client, logger logging.Logger, cfg *config.Config) *SyncSetReconciler {
+	return &SyncSetReconciler{
+		client:       client,
+		logger:       logger,
+		configSource: func() *config.SyncSetConfig { return cfg.SyncSet },
+		runID:        cfg.RunID,
+		namespaces:   newNamespaceGuard(client, logger),
+		now:          time.Now,
+		accelerate:   unaccelerated,
+		observed:     make(map[string]observedGeneration),
+	}
+}
+
+// SetConfigSource makes the reconciler read the syncSet section of the configuration from
+// source on every reconcile, so that reloaded delays and failures apply
+func (r *SyncSetReconciler) SetConfigSource(source func() *config.SyncSetConfig) {
+	r.configSource = source
+}
+
+// SetAccelerate makes the reconciler scale how long a sync set takes to apply with accelerate
+func (r *SyncSetReconciler) SetAccelerate(accelerate func(time.Duration) time.Duration) {
+	r.accelerate = accelerate
+}
+
+// Reconcile updates the ClusterSync of a ClusterDeployment with the sync sets applying to it
+func (r *SyncSetReconciler) Reconcile(ctx context.Context, req reconcile.Request) (result reconcile.Result, err error) {
+	defer func() {
+		recordReconcile("SyncSet", result, err)
+	}()
+
+	ctx = logutil.WithReconcileID(ctx, "syncset", req.Namespace, req.Name)
+	r.logger.Debug(ctx, "Reconciling sync sets of ClusterDeployment %s/%s", req.Namespace, req.Name)
+
+	cd := &hivev1.ClusterDeployment{}
+	if err := r.client.Get(ctx, req.NamespacedName, cd); err != nil {
+		if kuberrors.IsNotFound(err) {
+			r.forget(req.Namespace, req.Name)
+			return reconcile.Result{}, nil
+		}
+		r.logger.Error(ctx, "Failed to get ClusterDeployment %s/%s: %v", req.Namespace, req.Name, err)
+		return reconcile.Result{}, err
+	}
+
+	// Like Hive, only sync to clusters that are installed and not going away
+	if !cd.DeletionTimestamp.IsZero() || !cd.Spec.Installed {
+		return reconcile.Result{}, nil
+	}
+
+	terminating, err := r.namespaces.isTerminating(ctx, "ClusterSync", req.Namespace)
+	if err != nil {
+		r.logger.Error(ctx, "Failed to get namespace %s: %v", req.Namespace, err)
+		return reconcile.Result{}, err
+	}
+	if terminating {
+		return reconcile.Result{}, nil
+	}
+
+	syncSets, err := r.syncSetsFor(ctx, cd)
+	if err != nil {
+		r.logger.Error(ctx, "Failed to list SyncSets for ClusterDeployment %s/%s: %v", cd.Namespace, cd.Name, err)
+		return reconcile.Result{}, err
+	}
+	selectorSyncSets, err := r.selectorSyncSetsFor(ctx, cd)
+	if err != nil {
+		r.logger.Error(ctx, "Failed to list SelectorSyncSets for ClusterDeployment %s/%s: %v", cd.Namespace, cd.Name, err)
+		return reconcile.Result{}, err
+	}
+
+	clusterSync, err := r.getOrCreateClusterSync(ctx, cd)
+	if err != nil {
+		r.logger.Error(ctx, "Failed to get ClusterSync %s/%s: %v", cd.Namespace, cd.Name, err)
+		return reconcile.Result{}, err
+	}
+
+	now := r.now()
+	status := clusterSync.Status.DeepCopy()
+	var requeueAfter time.Duration
+	status.SyncSets = r.syncStatuses(ctx, cd, syncSetKind, syncSets, clusterSync.Status.SyncSets, now, &requeueAfter)
+	status.SelectorSyncSets = r.syncStatuses(ctx, cd, selectorSyncSetKind, selectorSyncSets, clusterSync.Status.SelectorSyncSets, now, &requeueAfter)
+	setClusterSyncFailedCondition(status, now)
+	if requeueAfter == 0 && len(failedSyncs(status)) == 0 && status.FirstSuccessTime == nil {
+		firstSuccess := metav1.NewTime(now)
+		status.FirstSuccessTime = &firstSuccess
+	}
+
+	if !equality.Semantic.DeepEqual(&clusterSync.Status, status) {
+		clusterSync.Status = *status
+		if err := r.client.Status().Update(ctx, clusterSync); err != nil {
+			r.logger.Error(ctx, "Failed to update ClusterSync %s/%s status: %v", cd.Namespace, cd.Name, err)
+			return reconcile.Result{}, err
+		}
+	}
+
+	return reconcile.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// syncSetObject is a SyncSet or SelectorSyncSet, with the part of the spec both share
+type syncSetObject struct {
+	name       string
+	generation int64
+	spec       *hivev1.SyncSetCommonSpec
+}
+
+// syncSetsFor returns the SyncSets referencing the ClusterDeployment
+func (r *SyncSetReconciler) syncSetsFor(ctx context.Context, cd *hivev1.ClusterDeployment) ([]syncSetObject, error) {
+	ssList := &hivev1.SyncSetList{}
+	if err := r.client.List(ctx, ssList, client.InNamespace(cd.Namespace)); err != nil {
+		return nil, err
+	}
+
+	var syncSets []syncSetObject
+	for i := range ssList.Items {
+		ss := &ssList.Items[i]
+		for _, ref := range ss.Spec.ClusterDeploymentRefs {
+			if ref.Name == cd.Name {
+				syncSets = append(syncSets, syncSetObject{name: ss.Name, generation: ss.Generation, spec: &ss.Spec.SyncSetCommonSpec})
+				break
+			}
+		}
+	}
+	return syncSets, nil
+}
+
+// selectorSyncSetsFor returns the SelectorSyncSets selecting the ClusterDeployment, none unless
+// SelectorSyncSets are simulated
+func (r *SyncSetReconciler) selectorSyncSetsFor(ctx context.Context, cd *hivev1.ClusterDeployment) ([]syncSetObject, error) {
+	if !r.configSource().SelectorSyncSets {
+		return nil, nil
+	}
+
+	sssList := &hivev1.SelectorSyncSetList{}
+	if err := r.client.List(ctx, sssList); err != nil {
+		return nil, err
+	}
+
+	var selectorSyncSets []syncSetObject
+	for i := range sssList.Items {
+		sss := &sssList.Items[i]
+		selector, err := metav1.LabelSelectorAsSelector(&sss.Spec.ClusterDeploymentSelector)
+		if err != nil {
+			r.logger.Warn(ctx, "Ignoring SelectorSyncSet %s with invalid selector: %v", sss.Name, err)
+			continue
+		}
+		if selector.Matches(k8slabels.Set(cd.Labels)) {
+			selectorSyncSets = append(selectorSyncSets, syncSetObject{name: sss.Name, generation: sss.Generation, spec: &sss.Spec.SyncSetCommonSpec})
+		}
+	}
+	return selectorSyncSets, nil
+}
+
+// getOrCreateClusterSync returns the ClusterSync of the ClusterDeployment, creating it if missing.
+// It is owned by the ClusterDeployment so it is removed along with it.
+func (r *SyncSetReconciler) getOrCreateClusterSync(ctx context.Context, cd *hivev1.ClusterDeployment) (*hiveintv1alpha1.ClusterSync, error) {
+	clusterSync := &hiveintv1alpha1.ClusterSync{}
+	err := r.client.Get(ctx, client.ObjectKeyFromObject(cd), clusterSync)
+	if err == nil || !kuberrors.IsNotFound(err) {
+		return clusterSync, err
+	}
+
+	clusterSync = &hiveintv1alpha1.ClusterSync{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cd.Name,
+			Namespace: cd.Namespace,
+		},
+	}
+	if err := controllerutil.SetControllerReference(cd, clusterSync, r.client.Scheme()); err != nil {
+		return nil, err
+	}
+	labels.StampRunID(clusterSync, r.runID)
+
+	if err := r.client.Create(ctx, clusterSync); err != nil {
+		return nil, err
+	}
+	r.logger.Info(ctx, "Created ClusterSync %s/%s", cd.Namespace, cd.Name)
+	return clusterSync, nil
+}
+
+// syncStatuses returns the sync status of each sync set of the kind. Sync sets whose current
+// generation is still within the apply delay keep their previous status, if any, and lower
+// requeueAfter to when they are applied.
+func (r *SyncSetReconciler) syncStatuses(
+	ctx context.Context,
+	cd *hivev1.ClusterDeployment,
+	kind string,
+	syncSets []syncSetObject,
+	previous []hiveintv1alpha1.SyncStatus,
+	now time.Time,
+	requeueAfter *time.Duration,
+) []hiveintv1alpha1.SyncStatus {
+	previousByName := make(map[string]hiveintv1alpha1.SyncStatus, len(previous))
+	for _, status := range previous {
+		previousByName[status.Name] = status
+	}
+
+	var statuses []hiveintv1alpha1.SyncStatus
+	for _, ss := range syncSets {
+		prev, hasPrev := previousByName[ss.name]
+		if hasPrev && prev.ObservedGeneration == ss.generation {
+			statuses = append(statuses, prev)
+			continue
+		}
+
+		key := fmt.Sprintf("%s/%s/%s/%s", cd.Namespace, cd.Name, kind, ss.name)
+		if remaining := r.remainingDelay(key, ss.generation, now); remaining > 0 {
+			if *requeueAfter == 0 || remaining < *requeueAfter {
+				*requeueAfter = remaining
+			}
+			if hasPrev {
+				statuses = append(statuses, prev)
+			}
+			continue
+		}
+
+		status := hiveintv1alpha1.SyncStatus{
+			Name:               ss.name,
+			ObservedGeneration: ss.generation,
+			Result:             hiveintv1alpha1.SuccessSyncSetResult,
+			LastTransitionTime: metav1.NewTime(now),
+		}
+		if message := r.failureMessage(ss); message != "" {
+			status.Result = hiveintv1alpha1.FailureSyncSetResult
+			status.FailureMessage = message
+		}
+		if hasPrev {
+			status.FirstSuccessTime = prev.FirstSuccessTime
+			if prev.Result == status.Result && prev.FailureMessage == status.FailureMessage {
+				status.LastTransitionTime = prev.LastTransitionTime
+			}
+		}
+		if status.Result == hiveintv1alpha1.SuccessSyncSetResult {
+			if status.FirstSuccessTime == nil {
+				firstSuccess := metav1.NewTime(now)
+				status.FirstSuccessTime = &firstSuccess
+			}
+			r.logger.Info(ctx, "%s %s applied to ClusterDeployment %s/%s", kind, ss.name, cd.Namespace, cd.Name)
+		} else {
+			r.logger.Warn(ctx, "%s %s failed to apply to ClusterDeployment %s/%s: %s",
+				kind, ss.name, cd.Namespace, cd.Name, status.FailureMessage)
+		}
+		statuses = append(statuses, status)
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+// remainingDelay returns how long until the generation of a sync set is applied, counting
+// the delay from when the generation was first seen
+func (r *SyncSetReconciler) remainingDelay(key string, generation int64, now time.Time) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	observed, ok := r.observed[key]
+	if !ok || observed.generation != generation {
+		observed = observedGeneration{generation: generation, at: now}
+		r.observed[key] = observed
+	}
+	return observed.at.Add(r.accelerate(time.Duration(r.configSource().DelaySeconds) * time.Second)).Sub(now)
+}
+
+// forget drops the observed generations of a deleted ClusterDeployment
+func (r *SyncSetReconciler) forget(namespace, name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	prefix := fmt.Sprintf("%s/%s/", namespace, name)
+	for key := range r.observed {
+		if strings.HasPrefix(key, prefix) {
+			delete(r.observed, key)
+		}
+	}
+}
+
+// failureMessage returns the failure message of the first configured failing resource the sync
+// set contains, or an empty string if it applies successfully
+func (r *SyncSetReconciler) failureMessage(ss syncSetObject) string {
+	for _, failure := range r.configSource().Failures {
+		if failure.SyncSet != ss.name {
+			continue
+		}
+		for _, raw := range ss.spec.Resources {
+			resource := metav1.PartialObjectMetadata{}
+			if err := json.Unmarshal(raw.Raw, &resource); err != nil {
+				continue
+			}
+			if fmt.Sprintf("%s/%s", resource.Kind, resource.Name) != failure.Resource {
+				continue
+			}
+			message := failure.Message
+			if message == "" {
+				message = defaultSyncSetFailureMessage
+			}
+			return fmt.Sprintf("Failed to apply resource %s: %s", failure.Resource, message)
+		}
+	}
+	return ""
+}
+
+// failedSyncs describes the sync sets that failed to apply, in the format of Hive's Failed condition message
+func failedSyncs(status *hiveintv1alpha1.ClusterSyncStatus) []string {
+	var failed []string
+	for _, ss := range status.SyncSets {
+		if ss.Result == hiveintv1alpha1.FailureSyncSetResult {
+			failed = append(failed, fmt.Sprintf("%s %s is failing", syncSetKind, ss.Name))
+		}
+	}
+	for _, sss := range status.SelectorSyncSets {
+		if sss.Result == hiveintv1alpha1.FailureSyncSetResult {
+			failed = append(failed, fmt.Sprintf("%s %s is failing", selectorSyncSetKind, sss.Name))
+		}
+	}
+	return failed
+}
+
+// setClusterSyncFailedCondition sets the Failed condition of a ClusterSync from its sync statuses,
+// keeping the transition time unless the condition changed
+func setClusterSyncFailedCondition(status *hiveintv1alpha1.ClusterSyncStatus, now time.Time) {
+	condition := hiveintv1alpha1.ClusterSyncCondition{
+		Type:    hiveintv1alpha1.ClusterSyncFailed,
+		Status:  corev1.ConditionFalse,
+		Reason:  "Success",
+		Message: "All SyncSets and SelectorSyncSets have been applied to the cluster",
+	}
+	if failed := failedSyncs(status); len(failed) > 0 {
+		condition.Status = corev1.ConditionTrue
+		condition.Reason = "Failure"
+		condition.Message = strings.Join(failed, "\n")
+	}
+
+	for i, existing := range status.Conditions {
+		if existing.Type != hiveintv1alpha1.ClusterSyncFailed {
+			continue
+		}
+		if existing.Status == condition.Status && existing.Reason == condition.Reason && existing.Message == condition.Message {
+			return
+		}
+		condition.LastProbeTime = metav1.NewTime(now)
+		condition.LastTransitionTime = metav1.NewTime(now)
+		if existing.Status == condition.Status {
+			condition.LastTransitionTime = existing.LastTransitionTime
+		}
+		status.Conditions[i] = condition
+		return
+	}
+	condition.LastProbeTime = metav1.NewTime(now)
+	condition.LastTransitionTime = metav1.NewTime(now)
+	status.Conditions = append(status.Conditions, condition)
+}
+
+// MapSyncSetToClusterDeployments enqueues the ClusterDeployments in the namespace of a changed
+// SyncSet, including ones it no longer references so that their ClusterSync drops it
+func (r *SyncSetReconciler) MapSyncSetToClusterDeployments(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.mapToClusterDeployments(ctx, "SyncSet", client.InNamespace(obj.GetNamespace()))
+}
+
+// MapSelectorSyncSetToClusterDeployments enqueues every ClusterDeployment when a SelectorSyncSet
+// changes, as any of them may be selected or no longer be
+func (r *SyncSetReconciler) MapSelectorSyncSetToClusterDeployments(ctx context.Context, _ client.Object) []reconcile.Request {
+	return r.mapToClusterDeployments(ctx, "SelectorSyncSet")
+}
+
+func (r *SyncSetReconciler) mapToClusterDeployments(ctx context.Context, kind string, opts ...client.ListOption) []reconcile.Request {
+	cdList := &hivev1.ClusterDeploymentList{}
+	if err := r.client.List(ctx, cdList, opts...); err != nil {
+		r.logger.Error(ctx, "Failed to list ClusterDeployments for %s change: %v", kind, err)
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(cdList.Items))
+	for i := range cdList.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&cdList.Items[i])})
+	}
+	return requests
+}
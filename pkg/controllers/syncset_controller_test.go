@@ -0,0 +1,184 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	kuberrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	hiveintv1alpha1 "github.com/openshift/hive/apis/hiveinternal/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+)
+
+func createTestSyncSetReconciler(k8sClient client.Client, syncSetCfg *config.SyncSetConfig) *SyncSetReconciler {
+	cfg := config.DefaultConfig()
+	cfg.SyncSet = syncSetCfg
+	return NewSyncSetReconciler(k8sClient, createTestLogger(), cfg)
+}
+
+func installedClusterDeployment(name string, labels map[string]string) *hivev1.ClusterDeployment {
+	return &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels},
+		Spec:       hivev1.ClusterDeploymentSpec{Installed: true},
+	}
+}
+
+func testSyncSet(name string, clusters ...string) *hivev1.SyncSet {
+	ss := &hivev1.SyncSet{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Generation: 1},
+		Spec: hivev1.SyncSetSpec{
+			SyncSetCommonSpec: hivev1.SyncSetCommonSpec{
+				Resources: []runtime.RawExtension{
+					{Raw: []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"settings","namespace":"openshift-config"}}`)},
+				},
+			},
+		},
+	}
+	for _, cluster := range clusters {
+		ss.Spec.ClusterDeploymentRefs = append(ss.Spec.ClusterDeploymentRefs, corev1.LocalObjectReference{Name: cluster})
+	}
+	return ss
+}
+
+func getClusterSync(t *testing.T, k8sClient client.Client, name string) *hiveintv1alpha1.ClusterSync {
+	clusterSync := &hiveintv1alpha1.ClusterSync{}
+	require.NoError(t, k8sClient.Get(context.Background(), types.NamespacedName{Name: name, Namespace: "default"}, clusterSync))
+	return clusterSync
+}
+
+func TestSyncSetReconciler_ReportsApplied(t *testing.T) {
+	ctx := context.Background()
+	cd := installedClusterDeployment("test-cd", nil)
+	k8sClient := createTestClient(t, cd, testSyncSet("cluster-config", "test-cd"), testSyncSet("other", "other-cd"))
+	reconciler := createTestSyncSetReconciler(k8sClient, &config.SyncSetConfig{})
+
+	result, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "test-cd", Namespace: "default"}})
+	require.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+
+	clusterSync := getClusterSync(t, k8sClient, "test-cd")
+	require.Len(t, clusterSync.OwnerReferences, 1)
+	assert.Equal(t, "test-cd", clusterSync.OwnerReferences[0].Name)
+
+	require.Len(t, clusterSync.Status.SyncSets, 1)
+	status := clusterSync.Status.SyncSets[0]
+	assert.Equal(t, "cluster-config", status.Name)
+	assert.Equal(t, int64(1), status.ObservedGeneration)
+	assert.Equal(t, hiveintv1alpha1.SuccessSyncSetResult, status.Result)
+	assert.NotNil(t, status.FirstSuccessTime)
+	assert.NotNil(t, clusterSync.Status.FirstSuccessTime)
+
+	require.Len(t, clusterSync.Status.Conditions, 1)
+	assert.Equal(t, hiveintv1alpha1.ClusterSyncFailed, clusterSync.Status.Conditions[0].Type)
+	assert.Equal(t, corev1.ConditionFalse, clusterSync.Status.Conditions[0].Status)
+	assert.Equal(t, "Success", clusterSync.Status.Conditions[0].Reason)
+}
+
+func TestSyncSetReconciler_AppliesAfterDelay(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	k8sClient := createTestClient(t, installedClusterDeployment("test-cd", nil), testSyncSet("cluster-config", "test-cd"))
+	reconciler := createTestSyncSetReconciler(k8sClient, &config.SyncSetConfig{DelaySeconds: 30})
+	reconciler.now = func() time.Time { return now }
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "test-cd", Namespace: "default"}}
+
+	result, err := reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, result.RequeueAfter)
+	assert.Empty(t, getClusterSync(t, k8sClient, "test-cd").Status.SyncSets)
+
+	now = now.Add(30 * time.Second)
+	result, err = reconciler.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Zero(t, result.RequeueAfter)
+
+	clusterSync := getClusterSync(t, k8sClient, "test-cd")
+	require.Len(t, clusterSync.Status.SyncSets, 1)
+	assert.Equal(t, hiveintv1alpha1.SuccessSyncSetResult, clusterSync.Status.SyncSets[0].Result)
+}
+
+func TestSyncSetReconciler_FailingResource(t *testing.T) {
+	ctx := context.Background()
+	k8sClient := createTestClient(t, installedClusterDeployment("test-cd", nil),
+		testSyncSet("cluster-config", "test-cd"), testSyncSet("other-config", "test-cd"))
+	reconciler := createTestSyncSetReconciler(k8sClient, &config.SyncSetConfig{
+		Failures: []config.SyncSetFailure{
+			{SyncSet: "cluster-config", Resource: "ConfigMap/settings", Message: "admission webhook denied the request"},
+			{SyncSet: "other-config", Resource: "Secret/settings"},
+		},
+	})
+
+	_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "test-cd", Namespace: "default"}})
+	require.NoError(t, err)
+
+	clusterSync := getClusterSync(t, k8sClient, "test-cd")
+	require.Len(t, clusterSync.Status.SyncSets, 2)
+	failed := clusterSync.Status.SyncSets[0]
+	assert.Equal(t, "cluster-config", failed.Name)
+	assert.Equal(t, hiveintv1alpha1.FailureSyncSetResult, failed.Result)
+	assert.Equal(t, "Failed to apply resource ConfigMap/settings: admission webhook denied the request", failed.FailureMessage)
+	assert.Nil(t, failed.FirstSuccessTime)
+
+	// The other SyncSet has no Secret/settings resource, so it applies
+	assert.Equal(t, hiveintv1alpha1.SuccessSyncSetResult, clusterSync.Status.SyncSets[1].Result)
+
+	require.Len(t, clusterSync.Status.Conditions, 1)
+	assert.Equal(t, corev1.ConditionTrue, clusterSync.Status.Conditions[0].Status)
+	assert.Equal(t, "Failure", clusterSync.Status.Conditions[0].Reason)
+	assert.Equal(t, "SyncSet cluster-config is failing", clusterSync.Status.Conditions[0].Message)
+	assert.Nil(t, clusterSync.Status.FirstSuccessTime)
+}
+
+func TestSyncSetReconciler_SelectorSyncSets(t *testing.T) {
+	ctx := context.Background()
+	sss := &hivev1.SelectorSyncSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "fleet-config", Generation: 2},
+		Spec: hivev1.SelectorSyncSetSpec{
+			ClusterDeploymentSelector: metav1.LabelSelector{MatchLabels: map[string]string{"tier": "canary"}},
+		},
+	}
+	k8sClient := createTestClient(t,
+		installedClusterDeployment("canary-cd", map[string]string{"tier": "canary"}),
+		installedClusterDeployment("stable-cd", map[string]string{"tier": "stable"}),
+		sss)
+	reconciler := createTestSyncSetReconciler(k8sClient, &config.SyncSetConfig{SelectorSyncSets: true})
+
+	for _, name := range []string{"canary-cd", "stable-cd"} {
+		_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: "default"}})
+		require.NoError(t, err)
+	}
+
+	canary := getClusterSync(t, k8sClient, "canary-cd")
+	require.Len(t, canary.Status.SelectorSyncSets, 1)
+	assert.Equal(t, "fleet-config", canary.Status.SelectorSyncSets[0].Name)
+	assert.Equal(t, int64(2), canary.Status.SelectorSyncSets[0].ObservedGeneration)
+	assert.Equal(t, hiveintv1alpha1.SuccessSyncSetResult, canary.Status.SelectorSyncSets[0].Result)
+
+	assert.Empty(t, getClusterSync(t, k8sClient, "stable-cd").Status.SelectorSyncSets)
+}
+
+func TestSyncSetReconciler_SkipsUninstalled(t *testing.T) {
+	ctx := context.Background()
+	cd := installedClusterDeployment("test-cd", nil)
+	cd.Spec.Installed = false
+	k8sClient := createTestClient(t, cd, testSyncSet("cluster-config", "test-cd"))
+	reconciler := createTestSyncSetReconciler(k8sClient, &config.SyncSetConfig{})
+
+	_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: "test-cd", Namespace: "default"}})
+	require.NoError(t, err)
+
+	err = k8sClient.Get(ctx, types.NamespacedName{Name: "test-cd", Namespace: "default"}, &hiveintv1alpha1.ClusterSync{})
+	assert.True(t, kuberrors.IsNotFound(err), "no ClusterSync should be created before install")
+}
@@ -0,0 +1,21 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/notify"
+)
+
+// notifyTerminal notifies the webhooks registered for the kind of the resource that it entered
+// a terminal state. Nothing is notified without a notifier.
+func notifyTerminal(ctx context.Context, notifier *notify.Notifier, kind string, obj client.Object, state string) {
+	notifier.Notify(ctx, notify.Event{
+		ResourceType: strings.ToLower(kind),
+		Namespace:    obj.GetNamespace(),
+		Name:         obj.GetName(),
+		State:        state,
+	})
+}
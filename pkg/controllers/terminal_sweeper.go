@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	kuberrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift-online/ocm-sdk-go/logging"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+
+	aaov1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/aws-account-operator/v1alpha1"
+	gcpv1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/gcp-project-operator/v1alpha1"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/state_machine"
+)
+
+// maxSweepInterval bounds how often the terminal resource sweeper looks for expired resources
+const maxSweepInterval = 30 * time.Second
+
+// TerminalResourceSweeper periodically deletes resources that have been in a terminal state
+// for longer than the TTL. Failed resources use their own TTL, so that they can be kept around
+// longer. A zero TTL keeps the resources. Deletion goes through the usual finalizers, so
+// ClusterDeployments still walk through their deprovision states.
+type TerminalResourceSweeper struct {
+	client    client.Client
+	logger    logging.Logger
+	ttl       time.Duration
+	failedTTL time.Duration
+	interval  time.Duration
+	now       func() time.Time
+}
+
+// NewTerminalResourceSweeper creates a new terminal resource sweeper
+func NewTerminalResourceSweeper(client client.Client, logger logging.Logger, ttl, failedTTL time.Duration) *TerminalResourceSweeper {
+	interval := maxSweepInterval
+	for _, d := range []time.Duration{ttl, failedTTL} {
+		if d > 0 {
+			interval = min(interval, d)
+		}
+	}
+	return &TerminalResourceSweeper{
+		client:    client,
+		logger:    logger,
+		ttl:       ttl,
+		failedTTL: failedTTL,
+		interval:  interval,
+		now:       time.Now,
+	}
+}
+
+// Start runs the sweeper until the context is cancelled
+func (s *TerminalResourceSweeper) Start(ctx context.Context) error {
+	s.logger.Info(ctx, "Starting terminal resource sweeper (TTL: %v, failed TTL: %v)", s.ttl, s.failedTTL)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			s.sweep(ctx)
+		}
+	}
+}
+
+// sweep deletes all terminal resources whose TTL has expired
+func (s *TerminalResourceSweeper) sweep(ctx context.Context) {
+	cdList := &hivev1.ClusterDeploymentList{}
+	if err := s.client.List(ctx, cdList); err != nil {
+		s.logger.Error(ctx, "Failed to list ClusterDeployments for terminal resource sweep: %v", err)
+	} else {
+		for i := range cdList.Items {
+			cd := &cdList.Items[i]
+			if !isClusterDeploymentTerminal(cd) {
+				continue
+			}
+			// Installed clusters keep gaining conditions, e.g. for hibernation, so count from the install
+			var since time.Time
+			if cd.Status.InstalledTimestamp != nil {
+				since = cd.Status.InstalledTimestamp.Time
+			} else {
+				for _, condition := range cd.Status.Conditions {
+					since = later(since, condition.LastTransitionTime)
+				}
+			}
+			s.deleteIfExpired(ctx, "ClusterDeployment", cd, since, state_machine.IsFailed(cd))
+		}
+	}
+
+	acList := &aaov1alpha1.AccountClaimList{}
+	if err := s.client.List(ctx, acList); err != nil {
+		s.logger.Error(ctx, "Failed to list AccountClaims for terminal resource sweep: %v", err)
+	} else {
+		for i := range acList.Items {
+			ac := &acList.Items[i]
+			if !isClaimTerminal(string(ac.Status.State)) {
+				continue
+			}
+			var since time.Time
+			for _, condition := range ac.Status.Conditions {
+				since = later(since, condition.LastTransitionTime)
+			}
+			s.deleteIfExpired(ctx, "AccountClaim", ac, since, ac.Status.State == aaov1alpha1.ClaimStatusError)
+		}
+	}
+
+	pcList := &gcpv1alpha1.ProjectClaimList{}
+	if err := s.client.List(ctx, pcList); err != nil {
+		s.logger.Error(ctx, "Failed to list ProjectClaims for terminal resource sweep: %v", err)
+	} else {
+		for i := range pcList.Items {
+			pc := &pcList.Items[i]
+			if !isClaimTerminal(string(pc.Status.State)) {
+				continue
+			}
+			var since time.Time
+			for _, condition := range pc.Status.Conditions {
+				since = later(since, condition.LastTransitionTime)
+			}
+			s.deleteIfExpired(ctx, "ProjectClaim", pc, since, pc.Status.State == gcpv1alpha1.ClaimStatusError)
+		}
+	}
+}
+
+// deleteIfExpired deletes a resource that has been terminal since the given time, once the
+// TTL for succeeded or failed resources has passed. Resources with an unknown terminal time
+// or already being deleted are skipped.
+func (s *TerminalResourceSweeper) deleteIfExpired(ctx context.Context, kind string, obj client.Object, since time.Time, failed bool) {
+	ttl := s.ttl
+	if failed {
+		ttl = s.failedTTL
+	}
+	if ttl == 0 || since.IsZero() || !obj.GetDeletionTimestamp().IsZero() || s.now().Sub(since) < ttl {
+		return
+	}
+
+	s.logger.Info(ctx, "Deleting %s %s/%s, terminal for more than %v", kind, obj.GetNamespace(), obj.GetName(), ttl)
+	if err := s.client.Delete(ctx, obj); err != nil && !kuberrors.IsNotFound(err) {
+		s.logger.Warn(ctx, "Failed to delete terminal %s %s/%s: %v", kind, obj.GetNamespace(), obj.GetName(), err)
+	}
+}
+
+// later returns the later of a time and a condition timestamp
+func later(t time.Time, timestamp metav1.Time) time.Time {
+	if timestamp.After(t) {
+		return timestamp.Time
+	}
+	return t
+}
@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	kuberrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	aaov1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/aws-account-operator/v1alpha1"
+)
+
+func TestTerminalResourceSweeper_Sweep(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	expired := metav1.NewTime(now.Add(-2 * time.Minute))
+	recent := metav1.NewTime(now.Add(-10 * time.Second))
+
+	installed := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "installed", Namespace: "default"},
+		Spec:       hivev1.ClusterDeploymentSpec{Installed: true},
+		Status:     hivev1.ClusterDeploymentStatus{InstalledTimestamp: &expired},
+	}
+	justInstalled := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "just-installed", Namespace: "default"},
+		Spec:       hivev1.ClusterDeploymentSpec{Installed: true},
+		Status:     hivev1.ClusterDeploymentStatus{InstalledTimestamp: &recent},
+	}
+	provisioning := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "provisioning", Namespace: "default"},
+		Status: hivev1.ClusterDeploymentStatus{
+			ProvisionRef: &corev1.LocalObjectReference{Name: "provisioning-provision"},
+			Conditions: []hivev1.ClusterDeploymentCondition{
+				{Type: "DeprovisionLaunchError", Status: corev1.ConditionFalse, LastTransitionTime: expired},
+			},
+		},
+	}
+	failedClaim := &aaov1alpha1.AccountClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "failed-claim", Namespace: "default"},
+		Status: aaov1alpha1.AccountClaimStatus{
+			State: aaov1alpha1.ClaimStatusError,
+			Conditions: []aaov1alpha1.AccountClaimCondition{
+				{Type: aaov1alpha1.AccountClaimFailed, Status: corev1.ConditionTrue, LastTransitionTime: expired},
+			},
+		},
+	}
+	k8sClient := createTestClient(t, installed, justInstalled, provisioning, failedClaim)
+
+	sweeper := NewTerminalResourceSweeper(k8sClient, createTestLogger(), time.Minute, time.Minute)
+	sweeper.now = func() time.Time { return now }
+	sweeper.sweep(ctx)
+
+	err := k8sClient.Get(ctx, client.ObjectKeyFromObject(installed), &hivev1.ClusterDeployment{})
+	assert.True(t, kuberrors.IsNotFound(err), "expected expired ClusterDeployment to be deleted, got %v", err)
+	err = k8sClient.Get(ctx, client.ObjectKeyFromObject(failedClaim), &aaov1alpha1.AccountClaim{})
+	assert.True(t, kuberrors.IsNotFound(err), "expected expired AccountClaim to be deleted, got %v", err)
+
+	// Resources within the TTL or not terminal yet are kept
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(justInstalled), &hivev1.ClusterDeployment{}))
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(provisioning), &hivev1.ClusterDeployment{}))
+}
+
+func TestTerminalResourceSweeper_FailedTTL(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	terminalSince := metav1.NewTime(now.Add(-5 * time.Minute))
+
+	installed := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "installed", Namespace: "default"},
+		Spec:       hivev1.ClusterDeploymentSpec{Installed: true},
+		Status:     hivev1.ClusterDeploymentStatus{InstalledTimestamp: &terminalSince},
+	}
+	failed := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "failed", Namespace: "default"},
+		Status: hivev1.ClusterDeploymentStatus{
+			ProvisionRef: &corev1.LocalObjectReference{Name: "failed-provision-failed"},
+			Conditions: []hivev1.ClusterDeploymentCondition{
+				{Type: "ProvisionFailed", Status: corev1.ConditionTrue, LastTransitionTime: terminalSince},
+			},
+		},
+	}
+	readyClaim := &aaov1alpha1.AccountClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "ready-claim", Namespace: "default"},
+		Status: aaov1alpha1.AccountClaimStatus{
+			State: aaov1alpha1.ClaimStatusReady,
+			Conditions: []aaov1alpha1.AccountClaimCondition{
+				{Type: aaov1alpha1.AccountClaimed, Status: corev1.ConditionTrue, LastTransitionTime: terminalSince},
+			},
+		},
+	}
+	failedClaim := &aaov1alpha1.AccountClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "failed-claim", Namespace: "default"},
+		Status: aaov1alpha1.AccountClaimStatus{
+			State: aaov1alpha1.ClaimStatusError,
+			Conditions: []aaov1alpha1.AccountClaimCondition{
+				{Type: aaov1alpha1.AccountClaimFailed, Status: corev1.ConditionTrue, LastTransitionTime: terminalSince},
+			},
+		},
+	}
+	k8sClient := createTestClient(t, installed, failed, readyClaim, failedClaim)
+
+	// Succeeded resources expire after a minute, failed ones are kept for ten
+	sweeper := NewTerminalResourceSweeper(k8sClient, createTestLogger(), time.Minute, 10*time.Minute)
+	sweeper.now = func() time.Time { return now }
+	sweeper.sweep(ctx)
+
+	err := k8sClient.Get(ctx, client.ObjectKeyFromObject(installed), &hivev1.ClusterDeployment{})
+	assert.True(t, kuberrors.IsNotFound(err), "expected installed ClusterDeployment to be deleted, got %v", err)
+	err = k8sClient.Get(ctx, client.ObjectKeyFromObject(readyClaim), &aaov1alpha1.AccountClaim{})
+	assert.True(t, kuberrors.IsNotFound(err), "expected ready AccountClaim to be deleted, got %v", err)
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(failed), &hivev1.ClusterDeployment{}))
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(failedClaim), &aaov1alpha1.AccountClaim{}))
+
+	sweeper.now = func() time.Time { return now.Add(10 * time.Minute) }
+	sweeper.sweep(ctx)
+
+	err = k8sClient.Get(ctx, client.ObjectKeyFromObject(failed), &hivev1.ClusterDeployment{})
+	assert.True(t, kuberrors.IsNotFound(err), "expected failed ClusterDeployment to be deleted, got %v", err)
+	err = k8sClient.Get(ctx, client.ObjectKeyFromObject(failedClaim), &aaov1alpha1.AccountClaim{})
+	assert.True(t, kuberrors.IsNotFound(err), "expected failed AccountClaim to be deleted, got %v", err)
+}
+
+func TestTerminalResourceSweeper_ZeroTTLKeepsResources(t *testing.T) {
+	ctx := context.Background()
+	installedAt := metav1.NewTime(time.Now().Add(-time.Hour))
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "installed", Namespace: "default"},
+		Spec:       hivev1.ClusterDeploymentSpec{Installed: true},
+		Status:     hivev1.ClusterDeploymentStatus{InstalledTimestamp: &installedAt},
+	}
+	k8sClient := createTestClient(t, cd)
+
+	// Only failed resources are cleaned up
+	sweeper := NewTerminalResourceSweeper(k8sClient, createTestLogger(), 0, time.Minute)
+	assert.Equal(t, maxSweepInterval, sweeper.interval)
+	sweeper.sweep(ctx)
+
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(cd), &hivev1.ClusterDeployment{}))
+}
+
+func TestTerminalResourceSweeper_EventuallyDeletes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	installedAt := metav1.Now()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+		Spec:       hivev1.ClusterDeploymentSpec{Installed: true},
+		Status:     hivev1.ClusterDeploymentStatus{InstalledTimestamp: &installedAt},
+	}
+	k8sClient := createTestClient(t, cd)
+
+	sweeper := NewTerminalResourceSweeper(k8sClient, createTestLogger(), 50*time.Millisecond, 50*time.Millisecond)
+	go func() {
+		_ = sweeper.Start(ctx)
+	}()
+
+	require.Eventually(t, func() bool {
+		err := k8sClient.Get(ctx, client.ObjectKeyFromObject(cd), &hivev1.ClusterDeployment{})
+		return kuberrors.IsNotFound(err)
+	}, 5*time.Second, 20*time.Millisecond)
+}
@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/version"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+)
+
+const (
+	// UpgradeAvailableCondition is set on Running ClusterDeployments when a newer visible image set exists
+	UpgradeAvailableCondition hivev1.ClusterDeploymentConditionType = "UpgradeAvailable"
+
+	// UpgradeAvailableAnnotation holds the newest version a Running ClusterDeployment can upgrade to
+	UpgradeAvailableAnnotation = "hive-simulator.openshift.io/upgrade-available"
+
+	// ImageSetVisibleLabel marks whether a ClusterImageSet is offered to users; image sets without it are visible
+	ImageSetVisibleLabel = "hive-simulator.openshift.io/visible"
+
+	// ClustersServiceVisibleLabel is the label clusters-service hides ClusterImageSets with when
+	// it is "false"
+	ClustersServiceVisibleLabel = "api.openshift.com/visible"
+
+	// imageSetVersionAnnotation holds the OpenShift version of a ClusterImageSet
+	imageSetVersionAnnotation = "api.openshift.com/version"
+)
+
+// reconcileUpgradeAvailable signals on a Running ClusterDeployment whether a newer visible
+// ClusterImageSet than the one it was installed from exists
+func (r *ClusterDeploymentReconciler) reconcileUpgradeAvailable(ctx context.Context, cd *hivev1.ClusterDeployment) error {
+	current, err := r.clusterDeploymentVersion(ctx, cd)
+	if err != nil || current == nil {
+		// Nothing to compare against
+		return err
+	}
+
+	latest, err := r.latestVisibleVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	upgradeTo := ""
+	if latest != nil && latest.GreaterThan(current) {
+		upgradeTo = latest.String()
+	}
+
+	if setUpgradeAvailableCondition(cd, current.String(), upgradeTo, metav1.NewTime(r.stateMachine.Now())) {
+		if err := r.client.Status().Update(ctx, cd); err != nil {
+			return err
+		}
+	}
+
+	if cd.Annotations[UpgradeAvailableAnnotation] == upgradeTo {
+		return nil
+	}
+	if upgradeTo == "" {
+		delete(cd.Annotations, UpgradeAvailableAnnotation)
+	} else {
+		if cd.Annotations == nil {
+			cd.Annotations = make(map[string]string)
+		}
+		cd.Annotations[UpgradeAvailableAnnotation] = upgradeTo
+		r.logger.Info(ctx, "Upgrade to %s available for ClusterDeployment %s/%s (current: %s)",
+			upgradeTo, cd.Namespace, cd.Name, current)
+	}
+	return r.client.Update(ctx, cd)
+}
+
+// clusterDeploymentVersion returns the version of the image set the ClusterDeployment references,
+// or nil if it has none
+func (r *ClusterDeploymentReconciler) clusterDeploymentVersion(ctx context.Context, cd *hivev1.ClusterDeployment) (*version.Version, error) {
+	if cd.Spec.Provisioning == nil || cd.Spec.Provisioning.ImageSetRef == nil {
+		return nil, nil
+	}
+
+	cis := &hivev1.ClusterImageSet{}
+	if err := r.client.Get(ctx, client.ObjectKey{Name: cd.Spec.Provisioning.ImageSetRef.Name}, cis); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	return imageSetVersion(cis), nil
+}
+
+// latestVisibleVersion returns the highest version among the visible ClusterImageSets, those
+// hidden by neither the simulator nor the clusters-service visibility label
+func (r *ClusterDeploymentReconciler) latestVisibleVersion(ctx context.Context) (*version.Version, error) {
+	cisList := &hivev1.ClusterImageSetList{}
+	if err := r.client.List(ctx, cisList); err != nil {
+		return nil, err
+	}
+
+	var latest *version.Version
+	for i := range cisList.Items {
+		cis := &cisList.Items[i]
+		if cis.Labels[ImageSetVisibleLabel] == "false" || cis.Labels[ClustersServiceVisibleLabel] == "false" {
+			continue
+		}
+		v := imageSetVersion(cis)
+		if v != nil && (latest == nil || v.GreaterThan(latest)) {
+			latest = v
+		}
+	}
+	return latest, nil
+}
+
+// imageSetVersion parses the version annotation of a ClusterImageSet, returning nil if absent or invalid
+func imageSetVersion(cis *hivev1.ClusterImageSet) *version.Version {
+	v, err := version.ParseSemantic(cis.Annotations[imageSetVersionAnnotation])
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+// setUpgradeAvailableCondition sets the UpgradeAvailable condition stamped with now, returning
+// true if it changed
+func setUpgradeAvailableCondition(cd *hivev1.ClusterDeployment, current, upgradeTo string, now metav1.Time) bool {
+	status := corev1.ConditionFalse
+	reason := "UpToDate"
+	message := fmt.Sprintf("Version %s is the latest available", current)
+	if upgradeTo != "" {
+		status = corev1.ConditionTrue
+		reason = "NewerVersionAvailable"
+		message = fmt.Sprintf("Version %s is available (current: %s)", upgradeTo, current)
+	}
+
+	for i := range cd.Status.Conditions {
+		condition := &cd.Status.Conditions[i]
+		if condition.Type != UpgradeAvailableCondition {
+			continue
+		}
+		if condition.Status == status && condition.Message == message {
+			return false
+		}
+		condition.Status = status
+		condition.Reason = reason
+		condition.Message = message
+		condition.LastTransitionTime = now
+		condition.LastProbeTime = now
+		return true
+	}
+
+	cd.Status.Conditions = append(cd.Status.Conditions, hivev1.ClusterDeploymentCondition{
+		Type:               UpgradeAvailableCondition,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: now,
+		LastProbeTime:      now,
+	})
+	return true
+}
+
+// MapImageSetToClusterDeployments enqueues every ClusterDeployment when a ClusterImageSet changes,
+// so that Running clusters re-evaluate whether an upgrade is available
+func (r *ClusterDeploymentReconciler) MapImageSetToClusterDeployments(ctx context.Context, _ client.Object) []reconcile.Request {
+	cdList := &hivev1.ClusterDeploymentList{}
+	if err := r.client.List(ctx, cdList); err != nil {
+		r.logger.Error(ctx, "Failed to list ClusterDeployments for ClusterImageSet change: %v", err)
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(cdList.Items))
+	for i := range cdList.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&cdList.Items[i])})
+	}
+	return requests
+}
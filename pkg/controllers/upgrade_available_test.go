@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+)
+
+func buildTestImageSet(name, version string, visible bool) *hivev1.ClusterImageSet {
+	visibleLabel := "true"
+	if !visible {
+		visibleLabel = "false"
+	}
+	return &hivev1.ClusterImageSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Labels:      map[string]string{ImageSetVisibleLabel: visibleLabel},
+			Annotations: map[string]string{imageSetVersionAnnotation: version},
+		},
+	}
+}
+
+func TestClusterDeploymentReconciler_UpgradeAvailable(t *testing.T) {
+	ctx := context.Background()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "cd-1", Namespace: "default"},
+		Spec: hivev1.ClusterDeploymentSpec{
+			Installed: true,
+			Provisioning: &hivev1.Provisioning{
+				ImageSetRef: &hivev1.ClusterImageSetReference{Name: "openshift-v4.14.0"},
+			},
+		},
+	}
+	k8sClient := createTestClient(t,
+		cd,
+		buildTestImageSet("openshift-v4.14.0", "4.14.0", true),
+		buildTestImageSet("openshift-v4.15.0", "4.15.0", true),
+		// Hidden image sets are never offered
+		buildTestImageSet("openshift-v4.16.0", "4.16.0", false),
+		&hivev1.ClusterImageSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "openshift-v4.17.0",
+				Labels:      map[string]string{ClustersServiceVisibleLabel: "false"},
+				Annotations: map[string]string{imageSetVersionAnnotation: "4.17.0"},
+			},
+		},
+	)
+	reconciler := createTestClusterDeploymentReconciler(k8sClient, config.DefaultConfig())
+
+	_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cd)})
+	require.NoError(t, err)
+
+	updated := &hivev1.ClusterDeployment{}
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(cd), updated))
+	assert.Equal(t, "4.15.0", updated.Annotations[UpgradeAvailableAnnotation])
+
+	condition := findCDCondition(updated, string(UpgradeAvailableCondition))
+	require.NotNil(t, condition)
+	assert.Equal(t, "True", string(condition.Status))
+	assert.Equal(t, "NewerVersionAvailable", condition.Reason)
+}
+
+func TestClusterDeploymentReconciler_UpgradeAvailable_UpToDate(t *testing.T) {
+	ctx := context.Background()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "cd-1", Namespace: "default"},
+		Spec: hivev1.ClusterDeploymentSpec{
+			Installed: true,
+			Provisioning: &hivev1.Provisioning{
+				ImageSetRef: &hivev1.ClusterImageSetReference{Name: "openshift-v4.15.0"},
+			},
+		},
+	}
+	k8sClient := createTestClient(t,
+		cd,
+		buildTestImageSet("openshift-v4.14.0", "4.14.0", true),
+		buildTestImageSet("openshift-v4.15.0", "4.15.0", true),
+	)
+	reconciler := createTestClusterDeploymentReconciler(k8sClient, config.DefaultConfig())
+
+	_, err := reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cd)})
+	require.NoError(t, err)
+
+	updated := &hivev1.ClusterDeployment{}
+	require.NoError(t, k8sClient.Get(ctx, client.ObjectKeyFromObject(cd), updated))
+	assert.NotContains(t, updated.Annotations, UpgradeAvailableAnnotation)
+
+	condition := findCDCondition(updated, string(UpgradeAvailableCondition))
+	require.NotNil(t, condition)
+	assert.Equal(t, "False", string(condition.Status))
+}
+
+func TestClusterDeploymentReconciler_VersionSkew(t *testing.T) {
+	ctx := context.Background()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "cd-1", Namespace: "default"},
+		Spec: hivev1.ClusterDeploymentSpec{
+			Installed: true,
+			Provisioning: &hivev1.Provisioning{
+				ImageSetRef: &hivev1.ClusterImageSetReference{Name: "openshift-v4.15.0"},
+			},
+		},
+	}
+	k8sClient := createTestClient(t, cd, buildTestImageSet("openshift-v4.15.0", "4.15.0", true))
+	cfg := config.DefaultConfig()
+	cfg.ClusterDeployment.VersionSkew = &config.VersionSkewConfig{InitialVersion: "4.12.0", StepIntervalSeconds: 60}
+	reconciler := createTestClusterDeploymentReconciler(k8sClient, cfg)
+	req := reconcile.Request{NamespacedName: client.ObjectKeyFromObject(cd)}
+
+	for _, expected := range []string{"4.12.0", "4.13.0", "4.14.0", "4.15.0"} {
+		result, err := reconciler.Reconcile(ctx, req)
+		require.NoError(t, err)
+
+		updated := &hivev1.ClusterDeployment{}
+		require.NoError(t, k8sClient.Get(ctx, req.NamespacedName, updated))
+		require.NotNil(t, updated.Status.ClusterVersionStatus)
+		assert.Equal(t, expected, updated.Status.ClusterVersionStatus.Desired.Version)
+		assert.Equal(t, "4.12.0", *updated.Status.InstallVersion)
+		if expected == "4.15.0" {
+			assert.Zero(t, result.RequeueAfter)
+			break
+		}
+		assert.InDelta(t, time.Minute, result.RequeueAfter, float64(time.Second))
+
+		// Let the step interval pass
+		stepped := metav1.NewTime(updated.Status.ClusterVersionStatus.History[0].CompletionTime.Add(-time.Minute))
+		updated.Status.ClusterVersionStatus.History[0].CompletionTime = &stepped
+		require.NoError(t, k8sClient.Status().Update(ctx, updated))
+	}
+}
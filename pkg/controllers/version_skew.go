@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+)
+
+// reconcileVersionSkew moves the cluster version an installed ClusterDeployment reports
+// towards the version of its image set, returning how long until the next step is due
+func (r *ClusterDeploymentReconciler) reconcileVersionSkew(ctx context.Context, cd *hivev1.ClusterDeployment) (time.Duration, error) {
+	if r.behaviorEngine.GetClusterDeploymentConfig().VersionSkew == nil {
+		return 0, nil
+	}
+	target, err := r.clusterDeploymentVersion(ctx, cd)
+	if err != nil {
+		return 0, err
+	}
+
+	now := r.stateMachine.Now()
+	next, remaining := r.stateMachine.GetVersionStep(cd, target, now)
+	if next == nil {
+		return remaining, nil
+	}
+	for next != nil {
+		r.stateMachine.ApplyVersion(ctx, cd, next, now)
+		next, remaining = r.stateMachine.GetVersionStep(cd, target, now)
+	}
+
+	if err := r.client.Status().Update(ctx, cd); err != nil {
+		logWriteError(ctx, r.logger, err, "Failed to update ClusterDeployment %s/%s cluster version: %v",
+			cd.Namespace, cd.Name, err)
+		return 0, err
+	}
+	return remaining, nil
+}
+
+// requeueSooner shortens the requeue of the result to after, if after is sooner
+func requeueSooner(result *reconcile.Result, after time.Duration) {
+	if after > 0 && (result.RequeueAfter == 0 || after < result.RequeueAfter) {
+		result.RequeueAfter = after
+	}
+}
@@ -0,0 +1,71 @@
+package hive_simulator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	errors "github.com/zgalor/weberr"
+)
+
+// statusSubresourceKinds are the simulated kinds whose status the controllers write
+var statusSubresourceKinds = []schema.GroupKind{
+	{Group: "hive.openshift.io", Kind: "ClusterDeployment"},
+	{Group: "hive.openshift.io", Kind: "DNSZone"},
+	{Group: "hive.openshift.io", Kind: "ClusterPool"},
+	{Group: "hive.openshift.io", Kind: "ClusterClaim"},
+	{Group: "hiveinternal.openshift.io", Kind: "ClusterSync"},
+	{Group: "aws.managed.openshift.io", Kind: "AccountClaim"},
+	{Group: "gcp.managed.openshift.io", Kind: "ProjectClaim"},
+}
+
+// missingStatusSubresources returns the CRD versions of simulated kinds that are served
+// without a status subresource, formatted as "<crd name>/<version>"
+func missingStatusSubresources(crds []*apiextensionsv1.CustomResourceDefinition) []string {
+	var missing []string
+	for _, crd := range crds {
+		if !isStatusSubresourceKind(crd) {
+			continue
+		}
+		for _, version := range crd.Spec.Versions {
+			if !version.Served {
+				continue
+			}
+			if version.Subresources == nil || version.Subresources.Status == nil {
+				missing = append(missing, fmt.Sprintf("%s/%s", crd.Name, version.Name))
+			}
+		}
+	}
+	return missing
+}
+
+// isStatusSubresourceKind returns true if the CRD defines one of the simulated kinds
+func isStatusSubresourceKind(crd *apiextensionsv1.CustomResourceDefinition) bool {
+	for _, gk := range statusSubresourceKinds {
+		if crd.Spec.Group == gk.Group && crd.Spec.Names.Kind == gk.Kind {
+			return true
+		}
+	}
+	return false
+}
+
+// checkStatusSubresources verifies the installed CRDs enable the status subresource.
+// Without it Status().Update silently drops changes, so conditions are never set.
+func (s *Server) checkStatusSubresources(ctx context.Context, crds []*apiextensionsv1.CustomResourceDefinition) error {
+	missing := missingStatusSubresources(crds)
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if s.requireStatusSubresource {
+		return errors.Errorf("CRDs installed without the status subresource: %s", strings.Join(missing, ", "))
+	}
+
+	s.logger.Warn(ctx, "CRDs installed without the status subresource: %s. "+
+		"Status updates for these resources will be silently ignored and conditions will not be set.",
+		strings.Join(missing, ", "))
+	return nil
+}
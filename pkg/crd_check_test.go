@@ -0,0 +1,79 @@
+package hive_simulator
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift-online/ocm-sdk-go/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+)
+
+func buildTestCRD(group, kind, name string, withStatus bool) *apiextensionsv1.CustomResourceDefinition {
+	version := apiextensionsv1.CustomResourceDefinitionVersion{Name: "v1", Served: true, Storage: true}
+	if withStatus {
+		version.Subresources = &apiextensionsv1.CustomResourceSubresources{
+			Status: &apiextensionsv1.CustomResourceSubresourceStatus{},
+		}
+	}
+	return &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group:    group,
+			Names:    apiextensionsv1.CustomResourceDefinitionNames{Kind: kind},
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{version},
+		},
+	}
+}
+
+func TestMissingStatusSubresources(t *testing.T) {
+	crds := []*apiextensionsv1.CustomResourceDefinition{
+		buildTestCRD("hive.openshift.io", "ClusterDeployment", "clusterdeployments.hive.openshift.io", false),
+		buildTestCRD("aws.managed.openshift.io", "AccountClaim", "accountclaims.aws.managed.openshift.io", true),
+		buildTestCRD("hive.openshift.io", "DNSZone", "dnszones.hive.openshift.io", false),
+		buildTestCRD("hive.openshift.io", "ClusterPool", "clusterpools.hive.openshift.io", true),
+		buildTestCRD("hive.openshift.io", "ClusterClaim", "clusterclaims.hive.openshift.io", false),
+		// Unrelated kinds are not checked
+		buildTestCRD("hive.openshift.io", "ClusterImageSet", "clusterimagesets.hive.openshift.io", false),
+	}
+
+	assert.Equal(t, []string{
+		"clusterdeployments.hive.openshift.io/v1",
+		"dnszones.hive.openshift.io/v1",
+		"clusterclaims.hive.openshift.io/v1",
+	}, missingStatusSubresources(crds))
+}
+
+func TestServer_CheckStatusSubresources_Warns(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	logger, err := logging.NewStdLoggerBuilder().Streams(&stdout, &stderr).Build()
+	require.NoError(t, err)
+
+	server := NewServer(logger, config.DefaultConfig(), ServerOptions{})
+	crds := []*apiextensionsv1.CustomResourceDefinition{
+		buildTestCRD("gcp.managed.openshift.io", "ProjectClaim", "projectclaims.gcp.managed.openshift.io", false),
+	}
+
+	require.NoError(t, server.checkStatusSubresources(context.Background(), crds))
+	assert.Contains(t, stdout.String()+stderr.String(),
+		"CRDs installed without the status subresource: projectclaims.gcp.managed.openshift.io/v1")
+}
+
+func TestServer_CheckStatusSubresources_Required(t *testing.T) {
+	server := NewServer(createTestLogger(), config.DefaultConfig(), ServerOptions{RequireStatusSubresource: true})
+	crds := []*apiextensionsv1.CustomResourceDefinition{
+		buildTestCRD("hive.openshift.io", "ClusterDeployment", "clusterdeployments.hive.openshift.io", false),
+	}
+
+	err := server.checkStatusSubresources(context.Background(), crds)
+	assert.Error(t, err)
+
+	crds[0] = buildTestCRD("hive.openshift.io", "ClusterDeployment", "clusterdeployments.hive.openshift.io", true)
+	assert.NoError(t, server.checkStatusSubresources(context.Background(), crds))
+}
@@ -0,0 +1,98 @@
+package hive_simulator
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	errors "github.com/zgalor/weberr"
+)
+
+// crdDownloadTimeout bounds the download of each CRD URL
+const crdDownloadTimeout = 30 * time.Second
+
+// crdDirectoryPaths returns the directories envtest installs CRDs from: the configured CRD
+// directories, or the auto-detected directory of the simulator's CRDs when none are
+// configured, then any extra directories, then the directory the CRD URLs are downloaded to.
+// A configured directory that does not exist is an error.
+func (s *Server) crdDirectoryPaths(ctx context.Context) ([]string, error) {
+	for _, dir := range slices.Concat(s.crdDirs, s.extraCRDDirs) {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "CRD directory %s is not accessible", dir)
+		}
+		if !info.IsDir() {
+			return nil, errors.Errorf("CRD directory %s is not a directory", dir)
+		}
+	}
+
+	paths := slices.Clone(s.crdDirs)
+	if len(paths) == 0 {
+		paths = []string{detectCRDDir()}
+	}
+	paths = append(paths, s.extraCRDDirs...)
+
+	if len(s.crdURLs) > 0 {
+		dir, err := os.MkdirTemp("", "hive-simulator-crds-")
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create CRD download directory")
+		}
+		s.crdDownloadDir = dir
+		for i, url := range s.crdURLs {
+			s.logger.Info(ctx, "Downloading CRDs from: %s", url)
+			if err := downloadCRD(ctx, url, filepath.Join(dir, fmt.Sprintf("crd-%d.yaml", i))); err != nil {
+				return nil, err
+			}
+		}
+		paths = append(paths, dir)
+	}
+	return paths, nil
+}
+
+// detectCRDDir returns the directory of the simulator's own CRDs, trying the locations of a
+// built binary, the working directory and the monorepo layout in turn
+func detectCRDDir() string {
+	crdPath := filepath.Join(filepath.Dir(os.Args[0]), "..", "crds")
+	if _, err := os.Stat(crdPath); os.IsNotExist(err) {
+		// Try relative path from working directory
+		crdPath = "crds"
+		if _, err := os.Stat(crdPath); os.IsNotExist(err) {
+			// Try uhc-clusters-service monorepo structure
+			crdPath = "cmd/hive-simulator/crds"
+		}
+	}
+	return crdPath
+}
+
+// downloadCRD writes the CRD YAML served at the URL to the file
+func downloadCRD(ctx context.Context, url, file string) error {
+	ctx, cancel := context.WithTimeout(ctx, crdDownloadTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return errors.Wrapf(err, "invalid CRD URL %s", url)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to download CRDs from %s", url)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("failed to download CRDs from %s: %s", url, resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrapf(err, "failed to download CRDs from %s", url)
+	}
+	if err := os.WriteFile(file, data, 0o600); err != nil {
+		return errors.Wrapf(err, "failed to write CRDs downloaded from %s", url)
+	}
+	return nil
+}
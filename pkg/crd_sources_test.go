@@ -0,0 +1,71 @@
+package hive_simulator
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+)
+
+func TestServer_CRDDirectoryPaths_MissingDir(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	for _, opts := range []ServerOptions{
+		{CRDDirs: []string{missing}},
+		{ExtraCRDDirs: []string{missing}},
+	} {
+		server := NewServer(createTestLogger(), config.DefaultConfig(), opts)
+		_, err := server.crdDirectoryPaths(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), missing)
+	}
+}
+
+func TestServer_CRDDirectoryPaths_DownloadsURLs(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/widgets.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(widgetCRD))
+	}))
+	defer srv.Close()
+
+	crdDir := filepath.Join("..", "cmd", "crds")
+	server := NewServer(createTestLogger(), config.DefaultConfig(), ServerOptions{
+		CRDDirs: []string{crdDir},
+		CRDURLs: []string{srv.URL + "/widgets.yaml"},
+	})
+	paths, err := server.crdDirectoryPaths(context.Background())
+	require.NoError(t, err)
+	defer os.RemoveAll(server.crdDownloadDir)
+	require.Equal(t, []string{crdDir, server.crdDownloadDir}, paths)
+
+	// Read the CRDs the way envtest does before installing them
+	options := &envtest.CRDInstallOptions{Paths: paths, ErrorIfPathMissing: true}
+	require.NoError(t, envtest.ReadCRDFiles(options))
+	names := make(map[string]bool, len(options.CRDs))
+	for _, crd := range options.CRDs {
+		names[crd.Name] = true
+	}
+	assert.True(t, names["widgets.example.com"], "downloaded CRD should be installed")
+
+	// A URL that cannot be downloaded fails startup
+	server = NewServer(createTestLogger(), config.DefaultConfig(), ServerOptions{
+		CRDDirs: []string{crdDir},
+		CRDURLs: []string{srv.URL + "/missing.yaml"},
+	})
+	_, err = server.crdDirectoryPaths(context.Background())
+	defer os.RemoveAll(server.crdDownloadDir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "404")
+}
@@ -1,7 +1,30 @@
 package labels
 
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
 // Labels used by OCM clusters-service
 const (
 	// ID is the cluster ID label
 	ID = "api.openshift.com/id"
 )
+
+// Labels used by the simulator itself
+const (
+	// RunID identifies the simulator run that created a resource
+	RunID = "hive-sim/run-id"
+)
+
+// StampRunID labels the object with the given run ID. It does nothing if the run ID is empty.
+func StampRunID(obj metav1.Object, runID string) {
+	if runID == "" {
+		return
+	}
+	objLabels := obj.GetLabels()
+	if objLabels == nil {
+		objLabels = make(map[string]string)
+	}
+	objLabels[RunID] = runID
+	obj.SetLabels(objLabels)
+}
@@ -0,0 +1,116 @@
+package latency
+
+import (
+	"context"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Client wraps a client.Client and delays every operation to simulate a slow API server
+type Client struct {
+	client.Client
+	delay time.Duration
+}
+
+// NewClient creates a new latency-injecting client. A delay <= 0 returns the client unchanged.
+func NewClient(c client.Client, delay time.Duration) client.Client {
+	if delay <= 0 {
+		return c
+	}
+	return &Client{
+		Client: c,
+		delay:  delay,
+	}
+}
+
+// Get delays and then delegates to the wrapped client
+func (c *Client) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if err := wait(ctx, c.delay); err != nil {
+		return err
+	}
+	return c.Client.Get(ctx, key, obj, opts...)
+}
+
+// List delays and then delegates to the wrapped client
+func (c *Client) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if err := wait(ctx, c.delay); err != nil {
+		return err
+	}
+	return c.Client.List(ctx, list, opts...)
+}
+
+// Create delays and then delegates to the wrapped client
+func (c *Client) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if err := wait(ctx, c.delay); err != nil {
+		return err
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+// Update delays and then delegates to the wrapped client
+func (c *Client) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	if err := wait(ctx, c.delay); err != nil {
+		return err
+	}
+	return c.Client.Update(ctx, obj, opts...)
+}
+
+// Delete delays and then delegates to the wrapped client
+func (c *Client) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	if err := wait(ctx, c.delay); err != nil {
+		return err
+	}
+	return c.Client.Delete(ctx, obj, opts...)
+}
+
+// Patch delays and then delegates to the wrapped client
+func (c *Client) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := wait(ctx, c.delay); err != nil {
+		return err
+	}
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+// Status returns a status writer that is also delayed
+func (c *Client) Status() client.SubResourceWriter {
+	return &statusWriter{
+		SubResourceWriter: c.Client.Status(),
+		delay:             c.delay,
+	}
+}
+
+// statusWriter delays status subresource writes
+type statusWriter struct {
+	client.SubResourceWriter
+	delay time.Duration
+}
+
+// Update delays and then delegates to the wrapped status writer
+func (w *statusWriter) Update(ctx context.Context, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+	if err := wait(ctx, w.delay); err != nil {
+		return err
+	}
+	return w.SubResourceWriter.Update(ctx, obj, opts...)
+}
+
+// Patch delays and then delegates to the wrapped status writer
+func (w *statusWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+	if err := wait(ctx, w.delay); err != nil {
+		return err
+	}
+	return w.SubResourceWriter.Patch(ctx, obj, patch, opts...)
+}
+
+// wait sleeps for the given delay or until the context is cancelled
+func wait(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
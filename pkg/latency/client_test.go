@@ -0,0 +1,53 @@
+package latency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_DelaysOperations(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"}}
+	inner := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+
+	delay := 50 * time.Millisecond
+	c := NewClient(inner, delay)
+
+	start := time.Now()
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(secret), &corev1.Secret{}))
+	assert.GreaterOrEqual(t, time.Since(start), delay)
+
+	start = time.Now()
+	require.NoError(t, c.List(ctx, &corev1.SecretList{}))
+	assert.GreaterOrEqual(t, time.Since(start), delay)
+}
+
+func TestNewClient_NoDelayReturnsWrappedClient(t *testing.T) {
+	inner := fake.NewClientBuilder().Build()
+	assert.Same(t, inner, NewClient(inner, 0))
+}
+
+func TestClient_CancelledContext(t *testing.T) {
+	inner := fake.NewClientBuilder().Build()
+	c := NewClient(inner, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := c.Get(ctx, client.ObjectKey{Name: "missing"}, &corev1.Secret{})
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,80 @@
+package logutil
+
+import (
+	"context"
+	"fmt"
+
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+
+	"github.com/openshift-online/ocm-sdk-go/logging"
+)
+
+// reconcileIDLength is the length of the random part of a reconcile ID, enough to tell apart
+// the reconciles of one resource in a log
+const reconcileIDLength = 4
+
+// prefixKey is the context key of the log prefix of a reconcile
+type prefixKey struct{}
+
+// WithReconcileID returns a context carrying a new correlation ID for one reconcile of the
+// named resource. Messages logged with it by a Logger are prefixed with
+// "[<kind>/<namespace>/<name>#<id>]", so the reconcile can be followed with grep.
+func WithReconcileID(ctx context.Context, kind, namespace, name string) context.Context {
+	id := utilrand.String(reconcileIDLength)
+	return context.WithValue(ctx, prefixKey{}, fmt.Sprintf("[%s/%s/%s#%s] ", kind, namespace, name, id))
+}
+
+// Prefix returns the log prefix carried by the context, empty outside of a reconcile
+func Prefix(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	prefix, _ := ctx.Value(prefixKey{}).(string)
+	return prefix
+}
+
+// Logger wraps a logging.Logger, prefixing every message with the reconcile ID carried by
+// its context
+type Logger struct {
+	logging.Logger
+}
+
+// NewLogger creates a new reconcile ID aware logger. A logger that already is one is
+// returned unchanged.
+func NewLogger(logger logging.Logger) logging.Logger {
+	if _, ok := logger.(*Logger); ok {
+		return logger
+	}
+	return &Logger{Logger: logger}
+}
+
+// Debug prefixes the message and delegates to the wrapped logger
+func (l *Logger) Debug(ctx context.Context, format string, args ...interface{}) {
+	l.Logger.Debug(ctx, "%s"+format, prepend(ctx, args)...)
+}
+
+// Info prefixes the message and delegates to the wrapped logger
+func (l *Logger) Info(ctx context.Context, format string, args ...interface{}) {
+	l.Logger.Info(ctx, "%s"+format, prepend(ctx, args)...)
+}
+
+// Warn prefixes the message and delegates to the wrapped logger
+func (l *Logger) Warn(ctx context.Context, format string, args ...interface{}) {
+	l.Logger.Warn(ctx, "%s"+format, prepend(ctx, args)...)
+}
+
+// Error prefixes the message and delegates to the wrapped logger
+func (l *Logger) Error(ctx context.Context, format string, args ...interface{}) {
+	l.Logger.Error(ctx, "%s"+format, prepend(ctx, args)...)
+}
+
+// Fatal prefixes the message and delegates to the wrapped logger
+func (l *Logger) Fatal(ctx context.Context, format string, args ...interface{}) {
+	l.Logger.Fatal(ctx, "%s"+format, prepend(ctx, args)...)
+}
+
+// prepend returns the format arguments with the log prefix of the context first, as a
+// resource name is not escaped for use in a format string
+func prepend(ctx context.Context, args []interface{}) []interface{} {
+	return append([]interface{}{Prefix(ctx)}, args...)
+}
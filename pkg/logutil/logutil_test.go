@@ -0,0 +1,51 @@
+package logutil
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/openshift-online/ocm-sdk-go/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestLogger(t *testing.T, out *bytes.Buffer) logging.Logger {
+	logger, err := logging.NewStdLoggerBuilder().Streams(out, out).Debug(true).Build()
+	require.NoError(t, err)
+	return NewLogger(logger)
+}
+
+func TestLogger_PrefixesReconcileID(t *testing.T) {
+	var out bytes.Buffer
+	logger := createTestLogger(t, &out)
+
+	ctx := WithReconcileID(context.Background(), "cd", "default", "test-cluster")
+	logger.Debug(ctx, "Reconciling %s", "test-cluster")
+	logger.Info(ctx, "Applying state %s", "Provisioning")
+	logger.Warn(ctx, "Requeue after %v", "5s")
+
+	// Every line of one reconcile carries the same ID
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 3)
+	prefix := regexp.MustCompile(`\[cd/default/test-cluster#[a-z0-9]{4}\] `)
+	id := prefix.FindString(lines[0])
+	require.NotEmpty(t, id)
+	for _, line := range lines {
+		assert.Contains(t, line, id)
+	}
+	assert.Contains(t, lines[1], id+"Applying state Provisioning")
+	assert.Equal(t, id, Prefix(ctx))
+}
+
+func TestLogger_WithoutReconcileID(t *testing.T) {
+	var out bytes.Buffer
+	logger := createTestLogger(t, &out)
+
+	logger.Info(context.Background(), "Starting %d%%", 100)
+	assert.Contains(t, out.String(), "Starting 100%")
+	assert.NotContains(t, out.String(), "[")
+	assert.Same(t, logger, NewLogger(logger))
+}
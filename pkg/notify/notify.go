@@ -0,0 +1,160 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openshift-online/ocm-sdk-go/logging"
+	errors "github.com/zgalor/weberr"
+)
+
+// Delivery defaults of the callbacks
+const (
+	defaultAttempts = 5
+	defaultBackoff  = time.Second
+	requestTimeout  = 10 * time.Second
+)
+
+// Webhook is a callback URL notified when resources of a type enter a terminal state
+type Webhook struct {
+	URL string `json:"url"`
+	// ResourceType is clusterdeployment, accountclaim or projectclaim
+	ResourceType  string `json:"resourceType"`
+	TerminalState string `json:"terminalState"`
+}
+
+// Event is posted as JSON to the webhooks matching the resource type and state
+type Event struct {
+	ResourceType string    `json:"resourceType"`
+	Namespace    string    `json:"namespace"`
+	Name         string    `json:"name"`
+	State        string    `json:"state"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// Notifier posts events to the registered webhooks. Deliveries run in the background, so a
+// slow or failing callback never blocks the reconciler that fired the event, and are retried
+// with exponential backoff until they succeed or run out of attempts.
+type Notifier struct {
+	logger   logging.Logger
+	client   *http.Client
+	attempts int
+	backoff  time.Duration
+
+	mutex    sync.RWMutex
+	webhooks []Webhook
+}
+
+// NewNotifier creates a new notifier with no webhooks registered
+func NewNotifier(logger logging.Logger) *Notifier {
+	return &Notifier{
+		logger:   logger,
+		client:   &http.Client{Timeout: requestTimeout},
+		attempts: defaultAttempts,
+		backoff:  defaultBackoff,
+	}
+}
+
+// Register adds a webhook, its resource type is matched case-insensitively
+func (n *Notifier) Register(webhook Webhook) {
+	webhook.ResourceType = strings.ToLower(webhook.ResourceType)
+
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.webhooks = append(n.webhooks, webhook)
+}
+
+// Webhooks returns the registered webhooks in the order they were registered
+func (n *Notifier) Webhooks() []Webhook {
+	n.mutex.RLock()
+	defer n.mutex.RUnlock()
+	return append([]Webhook{}, n.webhooks...)
+}
+
+// Clear removes every webhook, deliveries already in progress still complete
+func (n *Notifier) Clear() {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.webhooks = nil
+}
+
+// Notify posts the event to every webhook registered for its resource type and state. It
+// returns without waiting for the deliveries. A nil notifier notifies nothing.
+func (n *Notifier) Notify(ctx context.Context, event Event) {
+	if n == nil {
+		return
+	}
+	event.ResourceType = strings.ToLower(event.ResourceType)
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+
+	var matching []Webhook
+	n.mutex.RLock()
+	for _, webhook := range n.webhooks {
+		if webhook.ResourceType == event.ResourceType && webhook.TerminalState == event.State {
+			matching = append(matching, webhook)
+		}
+	}
+	n.mutex.RUnlock()
+	if len(matching) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		n.logger.Error(ctx, "Failed to encode %s event of %s/%s: %v", event.State, event.Namespace, event.Name, err)
+		return
+	}
+
+	// The deliveries outlive the reconcile that fired them
+	ctx = context.WithoutCancel(ctx)
+	for _, webhook := range matching {
+		go n.deliver(ctx, webhook.URL, event, body)
+	}
+}
+
+// deliver posts the event to the URL, retrying with exponential backoff on failure
+func (n *Notifier) deliver(ctx context.Context, url string, event Event, body []byte) {
+	backoff := n.backoff
+	for attempt := 1; ; attempt++ {
+		err := n.post(ctx, url, body)
+		if err == nil {
+			n.logger.Debug(ctx, "Notified %s of %s %s/%s in state %s",
+				url, event.ResourceType, event.Namespace, event.Name, event.State)
+			return
+		}
+		if attempt >= n.attempts {
+			n.logger.Warn(ctx, "Giving up notifying %s of %s %s/%s in state %s after %d attempts: %v",
+				url, event.ResourceType, event.Namespace, event.Name, event.State, attempt, err)
+			return
+		}
+		n.logger.Debug(ctx, "Failed to notify %s (attempt %d), retrying in %v: %v", url, attempt, backoff, err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// post sends the body to the URL, any response other than 2xx is an error
+func (n *Notifier) post(ctx context.Context, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("callback returned status %d", resp.StatusCode)
+	}
+	return nil
+}
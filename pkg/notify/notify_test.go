@@ -0,0 +1,87 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openshift-online/ocm-sdk-go/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestNotifier(t *testing.T) *Notifier {
+	logger, err := logging.NewStdLoggerBuilder().Build()
+	require.NoError(t, err)
+	notifier := NewNotifier(logger)
+	notifier.backoff = time.Millisecond
+	return notifier
+}
+
+func TestNotifier_RetriesUntilDelivered(t *testing.T) {
+	var calls atomic.Int32
+	received := make(chan Event, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first attempt fails
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var event Event
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		received <- event
+	}))
+	defer server.Close()
+
+	notifier := createTestNotifier(t)
+	notifier.Register(Webhook{URL: server.URL, ResourceType: "ClusterDeployment", TerminalState: "Running"})
+	notifier.Notify(context.Background(), Event{ResourceType: "ClusterDeployment", Namespace: "default", Name: "cd-1", State: "Running"})
+
+	select {
+	case event := <-received:
+		assert.Equal(t, "clusterdeployment", event.ResourceType)
+		assert.Equal(t, "default", event.Namespace)
+		assert.Equal(t, "cd-1", event.Name)
+		assert.Equal(t, "Running", event.State)
+		assert.False(t, event.Timestamp.IsZero())
+	case <-time.After(5 * time.Second):
+		t.Fatal("event was not delivered")
+	}
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestNotifier_OnlyMatchingWebhooks(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer server.Close()
+
+	notifier := createTestNotifier(t)
+	notifier.Register(Webhook{URL: server.URL, ResourceType: "accountclaim", TerminalState: "Ready"})
+	notifier.Notify(context.Background(), Event{ResourceType: "accountclaim", Name: "ac-1", State: "Error"})
+	notifier.Notify(context.Background(), Event{ResourceType: "projectclaim", Name: "pc-1", State: "Ready"})
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Zero(t, calls.Load())
+}
+
+func TestNotifier_NilNotifiesNothing(t *testing.T) {
+	var notifier *Notifier
+	assert.NotPanics(t, func() {
+		notifier.Notify(context.Background(), Event{ResourceType: "clusterdeployment", State: "Running"})
+	})
+}
+
+func TestNotifier_Clear(t *testing.T) {
+	notifier := createTestNotifier(t)
+	notifier.Register(Webhook{URL: "http://localhost/hook", ResourceType: "clusterdeployment", TerminalState: "Running"})
+	assert.Len(t, notifier.Webhooks(), 1)
+
+	notifier.Clear()
+	assert.Empty(t, notifier.Webhooks())
+}
@@ -0,0 +1,45 @@
+package hive_simulator
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	hiveintv1alpha1 "github.com/openshift/hive/apis/hiveinternal/v1alpha1"
+	errors "github.com/zgalor/weberr"
+
+	aaov1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/aws-account-operator/v1alpha1"
+	gcpv1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/gcp-project-operator/v1alpha1"
+)
+
+// SchemeRegistration adds a group of API types to a runtime scheme. It lets extensions
+// layering their own CRDs on top of the simulator make those types known to its clients.
+type SchemeRegistration struct {
+	// Name describes the registered types in error messages
+	Name string
+
+	// AddToScheme registers the types, usually the generated AddToScheme of an API package
+	AddToScheme func(*runtime.Scheme) error
+}
+
+// builtinSchemes are the API types the simulator always serves
+var builtinSchemes = []SchemeRegistration{
+	// Core Kubernetes types (including Secret, ConfigMap, etc.)
+	{Name: "core Kubernetes types", AddToScheme: corev1.AddToScheme},
+	{Name: "Hive", AddToScheme: hivev1.AddToScheme},
+	{Name: "Hive internal", AddToScheme: hiveintv1alpha1.AddToScheme},
+	{Name: "AWS Account Operator", AddToScheme: aaov1alpha1.AddToScheme},
+	{Name: "GCP Project Operator", AddToScheme: gcpv1alpha1.AddToScheme},
+}
+
+// newScheme creates a scheme with the built-in types followed by the extra registrations
+func (s *Server) newScheme() (*runtime.Scheme, error) {
+	scheme := runtime.NewScheme()
+	registrations := append(append([]SchemeRegistration{}, builtinSchemes...), s.extraSchemes...)
+	for _, registration := range registrations {
+		if err := registration.AddToScheme(scheme); err != nil {
+			return nil, errors.Wrapf(err, "failed to add %s to scheme", registration.Name)
+		}
+	}
+	return scheme, nil
+}
@@ -0,0 +1,87 @@
+package hive_simulator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+)
+
+const widgetCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+    listKind: WidgetList
+    plural: widgets
+    singular: widget
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        x-kubernetes-preserve-unknown-fields: true
+`
+
+func TestServer_CRDDirectoryPaths_LoadsExtraCRDs(t *testing.T) {
+	extraDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(extraDir, "example.com_widgets.yaml"), []byte(widgetCRD), 0o600))
+
+	crdDir := filepath.Join("..", "cmd", "crds")
+	server := NewServer(createTestLogger(), config.DefaultConfig(), ServerOptions{
+		CRDDirs:      []string{crdDir},
+		ExtraCRDDirs: []string{extraDir},
+	})
+	paths, err := server.crdDirectoryPaths(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{crdDir, extraDir}, paths)
+
+	// Read the CRDs the way envtest does before installing them
+	options := &envtest.CRDInstallOptions{Paths: paths, ErrorIfPathMissing: true}
+	require.NoError(t, envtest.ReadCRDFiles(options))
+
+	names := make(map[string]bool, len(options.CRDs))
+	for _, crd := range options.CRDs {
+		names[crd.Name] = true
+	}
+	assert.True(t, names["widgets.example.com"], "extra CRD should be installed")
+	assert.True(t, names["clusterdeployments.hive.openshift.io"], "simulator CRDs should still be installed")
+}
+
+func TestServer_NewScheme_ExtraSchemes(t *testing.T) {
+	server := NewServer(createTestLogger(), config.DefaultConfig(), ServerOptions{
+		ExtraSchemes: []SchemeRegistration{{Name: "API extensions", AddToScheme: apiextensionsv1.AddToScheme}},
+	})
+
+	scheme, err := server.newScheme()
+	require.NoError(t, err)
+	assert.True(t, scheme.Recognizes(hivev1.SchemeGroupVersion.WithKind("ClusterDeployment")))
+	assert.True(t, scheme.Recognizes(apiextensionsv1.SchemeGroupVersion.WithKind("CustomResourceDefinition")))
+
+	server = NewServer(createTestLogger(), config.DefaultConfig(), ServerOptions{
+		ExtraSchemes: []SchemeRegistration{{Name: "broken plugin", AddToScheme: func(*runtime.Scheme) error {
+			return fmt.Errorf("boom")
+		}}},
+	})
+	_, err = server.newScheme()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "broken plugin")
+}
@@ -6,24 +6,32 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/runtime"
+	kuberrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/utils/clock"
 
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
 	"github.com/go-logr/logr"
 	"github.com/openshift-online/ocm-sdk-go/logging"
 	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	hiveintv1alpha1 "github.com/openshift/hive/apis/hiveinternal/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	errors "github.com/zgalor/weberr"
 
 	aaov1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/aws-account-operator/v1alpha1"
@@ -32,29 +40,200 @@ import (
 	"github.com/tzvatot/openshift-hive-simulator/pkg/behavior"
 	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
 	"github.com/tzvatot/openshift-hive-simulator/pkg/controllers"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/labels"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/latency"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/logutil"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/notify"
 	"github.com/tzvatot/openshift-hive-simulator/pkg/state_machine"
 )
 
+// ServerOptions holds command-line level settings for the simulator server
+type ServerOptions struct {
+	// APIPort is the port for the configuration API
+	APIPort int
+
+	// ClientLatency delays every controller client operation to simulate a slow API server
+	ClientLatency time.Duration
+
+	// RequireStatusSubresource fails startup if a simulated CRD lacks the status subresource
+	RequireStatusSubresource bool
+
+	// CRDDirs are the directories of the simulator's CRDs, auto-detected when empty
+	CRDDirs []string
+
+	// CRDURLs are downloaded to a temporary directory and installed along with the other CRDs
+	CRDURLs []string
+
+	// ExtraCRDDirs are additional directories of CRDs installed alongside the simulated ones
+	ExtraCRDDirs []string
+
+	// ExtraSchemes register the API types of the extra CRDs with the simulator's clients
+	ExtraSchemes []SchemeRegistration
+
+	// KubeconfigPath is where the kubeconfig for the envtest API server is written,
+	// defaults to hive-simulator-kubeconfig.yaml in the temp directory
+	KubeconfigPath string
+
+	// KeepKubeconfig leaves the kubeconfig file in place when the simulator stops
+	KeepKubeconfig bool
+
+	// ReplayRequests are sent to the API, in order, once it is up
+	ReplayRequests []api.RecordedRequest
+
+	// MetricsPort is the port of the Prometheus metrics endpoint (0 disables it, the metrics
+	// are still served by the configuration API)
+	MetricsPort int
+
+	// CacheSyncTimeout bounds the wait for the controller caches to sync at startup, after
+	// envtest is up (defaults to 2 minutes)
+	CacheSyncTimeout time.Duration
+
+	// EnabledControllers names the reconcilers to run, all of them when empty. Resources of
+	// a disabled controller are left as they are created.
+	EnabledControllers []string
+
+	// APIToken is the bearer token required on mutating configuration API requests, the API
+	// is open when empty
+	APIToken string
+
+	// APITokenForReads requires the API token on read-only requests too, except for the
+	// health probes
+	APITokenForReads bool
+
+	// APITLSCertFile and APITLSKeyFile serve the configuration API over HTTPS when set,
+	// together, instead of plaintext
+	APITLSCertFile string
+	APITLSKeyFile  string
+
+	// APITLSSelfSigned serves the configuration API over HTTPS with a self-signed certificate
+	// generated at startup, written next to the kubeconfig
+	APITLSSelfSigned bool
+
+	// DryRun makes the reconcilers only log the transitions they would apply, without writing
+	// anything to the API server
+	DryRun bool
+}
+
+// Names of the reconcilers that can be enabled one by one
+const (
+	ClusterDeploymentController = "clusterdeployment"
+	AccountClaimController      = "accountclaim"
+	ProjectClaimController      = "projectclaim"
+)
+
+// AllControllers lists every reconciler that can be enabled, in the order they are registered
+var AllControllers = []string{ClusterDeploymentController, AccountClaimController, ProjectClaimController}
+
+// ValidateControllers returns an error if any of the names is not a known controller
+func ValidateControllers(names []string) error {
+	for _, name := range names {
+		if !slices.Contains(AllControllers, name) {
+			return errors.Errorf("unknown controller %q, must be one of %s", name, strings.Join(AllControllers, ", "))
+		}
+	}
+	return nil
+}
+
+// managerStopTimeout bounds how long stop waits for the controller manager to exit before
+// stopping envtest anyway
+const managerStopTimeout = 10 * time.Second
+
 // Server is the main hive simulator server
 type Server struct {
-	logger         logging.Logger
-	config         *config.Config
-	apiPort        int
-	envTest        *envtest.Environment
-	k8sClient      client.Client
-	mgr            manager.Manager
-	behaviorEngine *behavior.Engine
-	apiServer      *http.Server
-	kubeconfigPath string
+	logger                   logging.Logger
+	config                   *config.Config
+	apiPort                  int
+	clientLatency            time.Duration
+	requireStatusSubresource bool
+	crdDirs                  []string
+	crdURLs                  []string
+	crdDownloadDir           string
+	extraCRDDirs             []string
+	extraSchemes             []SchemeRegistration
+	envTest                  *envtest.Environment
+	k8sClient                client.Client
+	mgr                      manager.Manager
+	stopManager              context.CancelFunc
+	mgrDone                  chan struct{}
+	behaviorEngine           *behavior.Engine
+	notifier                 *notify.Notifier
+	apiServer                *http.Server
+	metricsPort              int
+	metricsRegistry          *prometheus.Registry
+	metricsServer            *http.Server
+	kubeconfigPath           string
+	keepKubeconfig           bool
+	replayRequests           []api.RecordedRequest
+	cacheSyncTimeout         time.Duration
+	enabledControllers       []string
+	apiToken                 string
+	apiTokenForReads         bool
+	apiTLSCertFile           string
+	apiTLSKeyFile            string
+	apiTLSSelfSigned         bool
+	dryRun                   bool
+	imageSetsReady           atomic.Bool
+	ready                    atomic.Bool
 }
 
-// NewServer creates a new hive simulator server
-func NewServer(logger logging.Logger, cfg *config.Config, apiPort int) *Server {
+// NewServer creates a new hive simulator server. Messages logged during a reconcile are
+// prefixed with its correlation ID.
+func NewServer(logger logging.Logger, cfg *config.Config, opts ServerOptions) *Server {
+	logger = logutil.NewLogger(logger)
 	return &Server{
-		logger:  logger,
-		config:  cfg,
-		apiPort: apiPort,
+		logger:                   logger,
+		config:                   cfg,
+		apiPort:                  opts.APIPort,
+		clientLatency:            opts.ClientLatency,
+		requireStatusSubresource: opts.RequireStatusSubresource,
+		crdDirs:                  opts.CRDDirs,
+		crdURLs:                  opts.CRDURLs,
+		extraCRDDirs:             opts.ExtraCRDDirs,
+		extraSchemes:             opts.ExtraSchemes,
+		kubeconfigPath:           opts.KubeconfigPath,
+		keepKubeconfig:           opts.KeepKubeconfig,
+		replayRequests:           opts.ReplayRequests,
+		metricsPort:              opts.MetricsPort,
+		cacheSyncTimeout:         opts.CacheSyncTimeout,
+		enabledControllers:       enabledControllers(opts.EnabledControllers),
+		apiToken:                 opts.APIToken,
+		apiTokenForReads:         opts.APITokenForReads,
+		apiTLSCertFile:           opts.APITLSCertFile,
+		apiTLSKeyFile:            opts.APITLSKeyFile,
+		apiTLSSelfSigned:         opts.APITLSSelfSigned,
+		dryRun:                   opts.DryRun,
+		behaviorEngine:           behavior.NewEngine(logger, cfg),
+		notifier:                 notify.NewNotifier(logger),
+	}
+}
+
+// enabledControllers returns the controllers to run in registration order, every one of them
+// when none is named
+func enabledControllers(names []string) []string {
+	if len(names) == 0 {
+		return slices.Clone(AllControllers)
+	}
+	var enabled []string
+	for _, name := range AllControllers {
+		if slices.Contains(names, name) {
+			enabled = append(enabled, name)
+		}
 	}
+	return enabled
+}
+
+// ReloadConfig replaces the configuration of the simulated resources with the one from cfg,
+// see behavior.Engine.UpdateConfig for the sections it covers. The state machines and the
+// controllers read their configuration through the engine, so the changes apply to the
+// resources already in progress. Per-resource overrides set through the API are kept. Other
+// settings, such as ClusterImageSets, only take effect at startup.
+func (s *Server) ReloadConfig(ctx context.Context, cfg *config.Config) {
+	changed := s.behaviorEngine.UpdateConfig(ctx, cfg)
+	if len(changed) == 0 {
+		s.logger.Debug(ctx, "Reloaded configuration, nothing changed")
+		return
+	}
+	s.logger.Debug(ctx, "Reloaded configuration, changed: %s", strings.Join(changed, ", "))
 }
 
 // Start starts the simulator server
@@ -66,38 +245,52 @@ func (s *Server) Start(ctx context.Context) error {
 		return errors.Wrapf(err, "failed to setup envtest")
 	}
 
+	// Verify the installed CRDs let the controllers write status
+	if err := s.checkStatusSubresources(ctx, s.envTest.CRDs); err != nil {
+		return errors.Wrapf(err, "failed to verify CRDs")
+	}
+
 	// Create Kubernetes client
 	if err := s.setupK8sClient(ctx); err != nil {
 		return errors.Wrapf(err, "failed to setup kubernetes client")
 	}
 
+	// Ensure the default namespace exists
+	if err := s.ensureDefaultNamespace(ctx); err != nil {
+		return errors.Wrapf(err, "failed to ensure default namespace")
+	}
+
 	// Pre-populate ClusterImageSets
 	if err := s.prepopulateClusterImageSets(ctx); err != nil {
 		return errors.Wrapf(err, "failed to prepopulate ClusterImageSets")
 	}
 
-	// Set up behavior engine
-	s.behaviorEngine = behavior.NewEngine(s.logger, s.config)
-
 	// Set up controller manager
 	if err := s.setupControllerManager(ctx); err != nil {
 		return errors.Wrapf(err, "failed to setup controller manager")
 	}
 
-	// Start controller manager in background
-	mgrDone := make(chan struct{})
+	// Start controller manager in background, stop waits for it to exit
+	mgrCtx, stopManager := context.WithCancel(ctx)
+	s.stopManager = stopManager
+	s.mgrDone = make(chan struct{})
 	go func() {
-		defer close(mgrDone)
+		defer close(s.mgrDone)
 		s.logger.Info(ctx, "Starting controller manager")
-		if err := s.mgr.Start(ctx); err != nil {
+		if err := s.mgr.Start(mgrCtx); err != nil {
 			s.logger.Error(ctx, "Controller manager failed: %v", err)
 		}
 		s.logger.Info(ctx, "Controller manager stopped")
 	}()
 
 	// Wait for cache sync
-	if !s.mgr.GetCache().WaitForCacheSync(ctx) {
-		return errors.Errorf("failed to wait for cache sync")
+	if err := s.waitForCacheSync(ctx, s.mgr.GetCache()); err != nil {
+		return err
+	}
+
+	// Start metrics server
+	if err := s.startMetricsServer(ctx); err != nil {
+		return errors.Wrapf(err, "failed to start metrics server")
 	}
 
 	// Start API server
@@ -105,24 +298,24 @@ func (s *Server) Start(ctx context.Context) error {
 		return errors.Wrapf(err, "failed to start API server")
 	}
 
+	s.ready.Store(true)
 	s.logger.Info(ctx, "Hive Simulator started successfully")
 	s.logger.Info(ctx, "  Kubernetes API: Use kubeconfig at %s", s.kubeconfigPath)
-	s.logger.Info(ctx, "  Configuration API: http://localhost:%d", s.apiPort)
+	scheme := "http"
+	if s.apiTLSEnabled() {
+		scheme = "https"
+	}
+	s.logger.Info(ctx, "  Configuration API: %s://localhost:%d", scheme, s.apiPort)
+	if s.metricsPort > 0 {
+		s.logger.Info(ctx, "  Metrics: http://localhost:%d/metrics", s.metricsPort)
+	}
 
 	// Wait for context cancellation
 	<-ctx.Done()
 
+	s.ready.Store(false)
 	s.logger.Info(ctx, "Shutting down Hive Simulator")
 
-	// Wait for controller manager to stop (with timeout)
-	s.logger.Info(ctx, "Waiting for controller manager to stop...")
-	select {
-	case <-mgrDone:
-		s.logger.Info(ctx, "Controller manager stopped gracefully")
-	case <-time.After(10 * time.Second):
-		s.logger.Warn(ctx, "Controller manager did not stop within timeout")
-	}
-
 	return s.stop(context.Background())
 }
 
@@ -134,42 +327,24 @@ func (s *Server) setupEnvtest(ctx context.Context) error {
 	ctrl.SetLogger(logr.Discard())
 
 	// Create scheme with all our CRDs
-	runtimeScheme := runtime.NewScheme()
-
-	// Add core Kubernetes types (including Secret, ConfigMap, etc.)
-	if err := corev1.AddToScheme(runtimeScheme); err != nil {
-		return errors.Wrapf(err, "failed to add core Kubernetes types to scheme")
+	runtimeScheme, err := s.newScheme()
+	if err != nil {
+		return err
 	}
 
-	if err := hivev1.AddToScheme(runtimeScheme); err != nil {
-		return errors.Wrapf(err, "failed to add Hive to scheme")
-	}
-	if err := aaov1alpha1.AddToScheme(runtimeScheme); err != nil {
-		return errors.Wrapf(err, "failed to add AWS Account Operator to scheme")
-	}
-	if err := gcpv1alpha1.AddToScheme(runtimeScheme); err != nil {
-		return errors.Wrapf(err, "failed to add GCP Project Operator to scheme")
+	crdPaths, err := s.crdDirectoryPaths(ctx)
+	if err != nil {
+		return err
 	}
-
-	// Find the CRD directory - try multiple possible locations
-	crdPath := filepath.Join(filepath.Dir(os.Args[0]), "..", "crds")
-	if _, err := os.Stat(crdPath); os.IsNotExist(err) {
-		// Try relative path from working directory
-		crdPath = "crds"
-		if _, err := os.Stat(crdPath); os.IsNotExist(err) {
-			// Try uhc-clusters-service monorepo structure
-			crdPath = "cmd/hive-simulator/crds"
-		}
+	for _, dir := range crdPaths {
+		s.logger.Info(ctx, "Loading CRDs from: %s", dir)
 	}
-	s.logger.Info(ctx, "Loading CRDs from: %s", crdPath)
 
 	// Note: envtest uses dynamic ports which change on each restart
 	// Use restart-simulator.sh to automatically regenerate provision shard config after restart
 	s.envTest = &envtest.Environment{
-		Scheme: runtimeScheme,
-		CRDDirectoryPaths: []string{
-			crdPath,
-		},
+		Scheme:                   runtimeScheme,
+		CRDDirectoryPaths:        crdPaths,
 		ErrorIfCRDPathMissing:    true, // Fail if CRDs not found
 		ControlPlaneStartTimeout: time.Minute,
 		ControlPlaneStopTimeout:  time.Minute,
@@ -215,13 +390,15 @@ func (s *Server) createKubeconfig(cfg *rest.Config) error {
 		},
 	}
 
-	// Write to temp file
-	kubeconfigPath := filepath.Join(os.TempDir(), "hive-simulator-kubeconfig.yaml")
-	if err := clientcmd.WriteToFile(kubeconfig, kubeconfigPath); err != nil {
+	if s.kubeconfigPath == "" {
+		s.kubeconfigPath = filepath.Join(os.TempDir(), "hive-simulator-kubeconfig.yaml")
+	}
+	if err := os.MkdirAll(filepath.Dir(s.kubeconfigPath), 0o755); err != nil {
+		return errors.Wrapf(err, "failed to create kubeconfig directory")
+	}
+	if err := clientcmd.WriteToFile(kubeconfig, s.kubeconfigPath); err != nil {
 		return errors.Wrapf(err, "failed to write kubeconfig")
 	}
-
-	s.kubeconfigPath = kubeconfigPath
 	return nil
 }
 
@@ -229,26 +406,9 @@ func (s *Server) createKubeconfig(cfg *rest.Config) error {
 func (s *Server) setupK8sClient(ctx context.Context) error {
 	s.logger.Info(ctx, "Setting up Kubernetes client")
 
-	scheme := runtime.NewScheme()
-
-	// Add core Kubernetes types (including Secret, ConfigMap, etc.)
-	if err := corev1.AddToScheme(scheme); err != nil {
-		return errors.Wrapf(err, "failed to add core Kubernetes types to scheme")
-	}
-
-	// Add Hive types
-	if err := hivev1.AddToScheme(scheme); err != nil {
-		return errors.Wrapf(err, "failed to add Hive to scheme")
-	}
-
-	// Add AWS Account Operator types
-	if err := aaov1alpha1.AddToScheme(scheme); err != nil {
-		return errors.Wrapf(err, "failed to add AWS Account Operator to scheme")
-	}
-
-	// Add GCP Project Operator types
-	if err := gcpv1alpha1.AddToScheme(scheme); err != nil {
-		return errors.Wrapf(err, "failed to add GCP Project Operator to scheme")
+	scheme, err := s.newScheme()
+	if err != nil {
+		return err
 	}
 
 	// Create client
@@ -281,78 +441,240 @@ func (s *Server) setupControllerManager(ctx context.Context) error {
 		return errors.Wrapf(err, "failed to create manager")
 	}
 
+	// Optionally slow down the controllers' client to simulate a laggy control plane
+	mgrClient := mgr.GetClient()
+	if s.clientLatency > 0 {
+		s.logger.Info(ctx, "Injecting %v latency into controller client operations", s.clientLatency)
+		mgrClient = latency.NewClient(mgrClient, s.clientLatency)
+	}
+
 	// Create state machines
-	cdStateMachine := state_machine.NewClusterDeploymentStateMachine(s.logger, s.config.ClusterDeployment)
-	acStateMachine := state_machine.NewAccountClaimStateMachine(s.logger, s.config.AccountClaim)
-	pcStateMachine := state_machine.NewProjectClaimStateMachine(s.logger, s.config.ProjectClaim)
+	cdStateMachine := state_machine.NewClusterDeploymentStateMachine(s.logger, s.config.ClusterDeployment, clock.RealClock{})
+	acStateMachine := state_machine.NewAccountClaimStateMachine(s.logger, s.config.AccountClaim, clock.RealClock{})
+	pcStateMachine := state_machine.NewProjectClaimStateMachine(s.logger, s.config.ProjectClaim, clock.RealClock{})
+
+	// Read the configuration through the engine, so that reloads and API updates apply
+	cdStateMachine.SetConfigSource(s.behaviorEngine.GetClusterDeploymentConfig)
+	acStateMachine.SetConfigSource(s.behaviorEngine.GetAccountClaimConfig)
+	pcStateMachine.SetConfigSource(s.behaviorEngine.GetProjectClaimConfig)
+
+	// Roll transition probabilities and sample normally distributed state durations with the
+	// engine, so that branching and timing follow the random seed
+	cdStateMachine.SetRoll(s.behaviorEngine.Roll)
+	acStateMachine.SetRoll(s.behaviorEngine.Roll)
+	pcStateMachine.SetRoll(s.behaviorEngine.Roll)
+	cdStateMachine.SetNormal(s.behaviorEngine.Normal)
+	acStateMachine.SetNormal(s.behaviorEngine.Normal)
+	pcStateMachine.SetNormal(s.behaviorEngine.Normal)
+
+	// Scale the delays the state machines time themselves, such as power state changes and
+	// stuck recoveries, by the speed factor like the transition delays
+	cdStateMachine.SetAccelerate(s.behaviorEngine.Accelerate)
+	acStateMachine.SetAccelerate(s.behaviorEngine.Accelerate)
+	pcStateMachine.SetAccelerate(s.behaviorEngine.Accelerate)
 
 	// Create reconcilers
 	cdReconciler := controllers.NewClusterDeploymentReconciler(
-		mgr.GetClient(),
+		mgrClient,
 		s.logger,
 		cdStateMachine,
 		s.behaviorEngine,
 	)
 
 	acReconciler := controllers.NewAccountClaimReconciler(
-		mgr.GetClient(),
+		mgrClient,
 		s.logger,
 		acStateMachine,
 		s.behaviorEngine,
 	)
 
 	pcReconciler := controllers.NewProjectClaimReconciler(
-		mgr.GetClient(),
+		mgrClient,
 		s.logger,
 		pcStateMachine,
 		s.behaviorEngine,
 	)
 
-	// Register reconcilers with controller-runtime
-	if err := ctrl.NewControllerManagedBy(mgr).
-		For(&hivev1.ClusterDeployment{}).
-		Complete(cdReconciler); err != nil {
-		return errors.Wrapf(err, "failed to create ClusterDeployment controller")
+	// Emit Kubernetes events for transitions, filtered by the configured verbosity
+	recorder := mgr.GetEventRecorderFor("hive-simulator")
+	cdReconciler.SetEventRecorder(recorder)
+	acReconciler.SetEventRecorder(recorder)
+	pcReconciler.SetEventRecorder(recorder)
+
+	// Notify the webhooks registered through the API of terminal states
+	cdReconciler.SetNotifier(s.notifier)
+	acReconciler.SetNotifier(s.notifier)
+	pcReconciler.SetNotifier(s.notifier)
+
+	// Only log the planned transitions in dry run
+	cdReconciler.SetDryRun(s.dryRun)
+	acReconciler.SetDryRun(s.dryRun)
+	pcReconciler.SetDryRun(s.dryRun)
+
+	// Register the enabled reconcilers with controller-runtime
+	for _, name := range AllControllers {
+		if !slices.Contains(s.enabledControllers, name) {
+			s.logger.Info(ctx, "Controller %s is disabled", name)
+		}
+	}
+
+	if slices.Contains(s.enabledControllers, ClusterDeploymentController) {
+		if err := ctrl.NewControllerManagedBy(mgr).
+			For(&hivev1.ClusterDeployment{}).
+			Watches(&hivev1.ClusterImageSet{}, handler.EnqueueRequestsFromMapFunc(cdReconciler.MapImageSetToClusterDeployments)).
+			Complete(cdReconciler); err != nil {
+			return errors.Wrapf(err, "failed to create ClusterDeployment controller")
+		}
+	}
+
+	if slices.Contains(s.enabledControllers, AccountClaimController) {
+		if err := ctrl.NewControllerManagedBy(mgr).
+			For(&aaov1alpha1.AccountClaim{}).
+			Complete(acReconciler); err != nil {
+			return errors.Wrapf(err, "failed to create AccountClaim controller")
+		}
+	}
+
+	if slices.Contains(s.enabledControllers, ProjectClaimController) {
+		if err := ctrl.NewControllerManagedBy(mgr).
+			For(&gcpv1alpha1.ProjectClaim{}).
+			Complete(pcReconciler); err != nil {
+			return errors.Wrapf(err, "failed to create ProjectClaim controller")
+		}
+	}
+
+	// The remaining controllers and background tasks only write, leave them out in dry run
+	if s.dryRun {
+		s.logger.Info(ctx, "Dry run: SyncSet, DNSZone and ClusterPool simulation and background tasks are disabled")
+		s.mgr = mgr
+		return nil
+	}
+
+	// Register SyncSet simulation if configured, reporting to ClusterSyncs like Hive does
+	if s.config.SyncSet != nil {
+		ssReconciler := controllers.NewSyncSetReconciler(mgrClient, s.logger, s.config)
+		ssReconciler.SetConfigSource(s.behaviorEngine.GetSyncSetConfig)
+		ssReconciler.SetAccelerate(s.behaviorEngine.Accelerate)
+		ssBuilder := ctrl.NewControllerManagedBy(mgr).
+			Named("clustersync").
+			For(&hivev1.ClusterDeployment{}).
+			Owns(&hiveintv1alpha1.ClusterSync{}).
+			Watches(&hivev1.SyncSet{}, handler.EnqueueRequestsFromMapFunc(ssReconciler.MapSyncSetToClusterDeployments))
+		if s.config.SyncSet.SelectorSyncSets {
+			ssBuilder = ssBuilder.Watches(&hivev1.SelectorSyncSet{},
+				handler.EnqueueRequestsFromMapFunc(ssReconciler.MapSelectorSyncSetToClusterDeployments))
+		}
+		if err := ssBuilder.Complete(ssReconciler); err != nil {
+			return errors.Wrapf(err, "failed to create SyncSet controller")
+		}
+	}
+
+	// Register DNSZone simulation if configured, reporting zones available like Hive does
+	if s.config.DNSZone != nil {
+		dnsZoneReconciler := controllers.NewDNSZoneReconciler(mgrClient, s.logger, s.config)
+		dnsZoneReconciler.SetConfigSource(s.behaviorEngine.GetDNSZoneConfig)
+		dnsZoneReconciler.SetAccelerate(s.behaviorEngine.Accelerate)
+		if err := ctrl.NewControllerManagedBy(mgr).
+			For(&hivev1.DNSZone{}).
+			Complete(dnsZoneReconciler); err != nil {
+			return errors.Wrapf(err, "failed to create DNSZone controller")
+		}
+	}
+
+	// Register ClusterPool simulation if configured, filling pools and binding claims like Hive does
+	if s.config.ClusterPool != nil {
+		poolReconciler := controllers.NewClusterPoolReconciler(mgrClient, s.logger, s.config)
+		poolReconciler.SetConfigSource(s.behaviorEngine.GetClusterPoolConfig)
+		if err := ctrl.NewControllerManagedBy(mgr).
+			For(&hivev1.ClusterPool{}).
+			Owns(&hivev1.ClusterDeployment{}).
+			Complete(poolReconciler); err != nil {
+			return errors.Wrapf(err, "failed to create ClusterPool controller")
+		}
+
+		claimReconciler := controllers.NewClusterClaimReconciler(mgrClient, s.logger, s.config)
+		claimReconciler.SetConfigSource(s.behaviorEngine.GetClusterPoolConfig)
+		claimReconciler.SetAccelerate(s.behaviorEngine.Accelerate)
+		if err := ctrl.NewControllerManagedBy(mgr).
+			For(&hivev1.ClusterClaim{}).
+			Complete(claimReconciler); err != nil {
+			return errors.Wrapf(err, "failed to create ClusterClaim controller")
+		}
+	}
+
+	// Register probe time refresher if configured
+	if s.config.ProbeTimeRefreshSeconds > 0 {
+		refresher := controllers.NewProbeTimeRefresher(
+			mgrClient,
+			s.logger,
+			time.Duration(s.config.ProbeTimeRefreshSeconds)*time.Second,
+		)
+		if err := mgr.Add(refresher); err != nil {
+			return errors.Wrapf(err, "failed to add probe time refresher")
+		}
 	}
 
-	if err := ctrl.NewControllerManagedBy(mgr).
-		For(&aaov1alpha1.AccountClaim{}).
-		Complete(acReconciler); err != nil {
-		return errors.Wrapf(err, "failed to create AccountClaim controller")
+	// Register terminal resource sweeper if configured
+	if s.config.TerminalResourceTTLSeconds > 0 || s.config.FailedResourceTTLSeconds > 0 {
+		failedTTLSeconds := s.config.FailedResourceTTLSeconds
+		if failedTTLSeconds == 0 {
+			failedTTLSeconds = s.config.TerminalResourceTTLSeconds
+		}
+		sweeper := controllers.NewTerminalResourceSweeper(
+			mgrClient,
+			s.logger,
+			time.Duration(s.config.TerminalResourceTTLSeconds)*time.Second,
+			time.Duration(failedTTLSeconds)*time.Second,
+		)
+		if err := mgr.Add(sweeper); err != nil {
+			return errors.Wrapf(err, "failed to add terminal resource sweeper")
+		}
 	}
 
-	if err := ctrl.NewControllerManagedBy(mgr).
-		For(&gcpv1alpha1.ProjectClaim{}).
-		Complete(pcReconciler); err != nil {
-		return errors.Wrapf(err, "failed to create ProjectClaim controller")
+	// Register fleet ramp if configured
+	if s.config.FleetRamp != nil {
+		ramp := controllers.NewFleetRamp(mgrClient, s.logger, s.config)
+		ramp.SetConfigSource(s.behaviorEngine.GetFleetRampConfig)
+		if err := mgr.Add(ramp); err != nil {
+			return errors.Wrapf(err, "failed to add fleet ramp")
+		}
 	}
 
 	s.mgr = mgr
 	return nil
 }
 
+// ensureDefaultNamespace creates the configured default namespace if it doesn't exist
+func (s *Server) ensureDefaultNamespace(ctx context.Context) error {
+	name := s.config.GetDefaultNamespace()
+
+	ns := &corev1.Namespace{}
+	err := s.k8sClient.Get(ctx, client.ObjectKey{Name: name}, ns)
+	if err == nil {
+		return nil
+	}
+	if !kuberrors.IsNotFound(err) {
+		return err
+	}
+
+	ns.Name = name
+	if err := s.k8sClient.Create(ctx, ns); err != nil && !kuberrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	s.logger.Info(ctx, "Created default namespace: %s", name)
+	return nil
+}
+
 // prepopulateClusterImageSets pre-populates ClusterImageSets
 func (s *Server) prepopulateClusterImageSets(ctx context.Context) error {
 	s.logger.Info(ctx, "Pre-populating ClusterImageSets")
+	s.dedupClusterImageSets(ctx)
 
 	for _, cisConfig := range s.config.ClusterImageSets {
-		cis := &hivev1.ClusterImageSet{}
-		cis.Name = cisConfig.Name
-		cis.Spec.ReleaseImage = fmt.Sprintf("quay.io/openshift-release-dev/ocp-release:%s", cisConfig.Name)
-
-		// Add channel-group label expected by clusters-service
-		channelGroup := s.extractChannelGroup(cisConfig.Name)
-		if cis.Labels == nil {
-			cis.Labels = make(map[string]string)
-		}
-		cis.Labels["api.openshift.com/channel-group"] = channelGroup
-
-		// Add version annotation expected by clusters-service
-		version := s.extractVersion(cisConfig.Name)
-		if cis.Annotations == nil {
-			cis.Annotations = make(map[string]string)
-		}
-		cis.Annotations["api.openshift.com/version"] = version
+		cis := s.buildClusterImageSet(cisConfig)
+		channelGroup := cis.Labels["api.openshift.com/channel-group"]
+		version := cis.Annotations["api.openshift.com/version"]
 
 		if err := s.k8sClient.Create(ctx, cis); err != nil {
 			s.logger.Warn(ctx, "Failed to create ClusterImageSet %s (may already exist): %v", cisConfig.Name, err)
@@ -365,6 +687,107 @@ func (s *Server) prepopulateClusterImageSets(ctx context.Context) error {
 	return nil
 }
 
+// dedupClusterImageSets drops ClusterImageSets listed more than once in the configuration,
+// keeping the first entry of each name
+func (s *Server) dedupClusterImageSets(ctx context.Context) {
+	seen := make(map[string]bool, len(s.config.ClusterImageSets))
+	unique := make([]config.ClusterImageSetConfig, 0, len(s.config.ClusterImageSets))
+	for _, cisConfig := range s.config.ClusterImageSets {
+		if seen[cisConfig.Name] {
+			s.logger.Warn(ctx, "ClusterImageSet %s is listed more than once in the configuration, using the first entry", cisConfig.Name)
+			continue
+		}
+		seen[cisConfig.Name] = true
+		unique = append(unique, cisConfig)
+	}
+	s.config.ClusterImageSets = unique
+}
+
+// checkStarted fails until envtest, the Kubernetes client and the controller caches are all
+// up, and again once the simulator is shutting down
+func (s *Server) checkStarted(ctx context.Context) error {
+	if !s.ready.Load() {
+		return errors.Errorf("simulator is not started")
+	}
+	return nil
+}
+
+// checkClusterImageSets verifies that every configured ClusterImageSet exists, retrying
+// creation of any that are missing. Once all exist the result is remembered.
+func (s *Server) checkClusterImageSets(ctx context.Context) error {
+	if s.imageSetsReady.Load() {
+		return nil
+	}
+
+	var missing []string
+	for _, cisConfig := range s.config.ClusterImageSets {
+		err := s.k8sClient.Get(ctx, client.ObjectKey{Name: cisConfig.Name}, &hivev1.ClusterImageSet{})
+		if err == nil {
+			continue
+		}
+		if !kuberrors.IsNotFound(err) {
+			s.logger.Warn(ctx, "Failed to get ClusterImageSet %s: %v", cisConfig.Name, err)
+			missing = append(missing, cisConfig.Name)
+			continue
+		}
+
+		if err := s.k8sClient.Create(ctx, s.buildClusterImageSet(cisConfig)); err != nil && !kuberrors.IsAlreadyExists(err) {
+			s.logger.Warn(ctx, "Failed to create missing ClusterImageSet %s: %v", cisConfig.Name, err)
+			missing = append(missing, cisConfig.Name)
+			continue
+		}
+		s.logger.Info(ctx, "Created missing ClusterImageSet: %s", cisConfig.Name)
+	}
+
+	if len(missing) > 0 {
+		return errors.Errorf("ClusterImageSets missing: %s", strings.Join(missing, ", "))
+	}
+
+	s.imageSetsReady.Store(true)
+	return nil
+}
+
+// buildClusterImageSet builds a ClusterImageSet object from its configuration
+func (s *Server) buildClusterImageSet(cisConfig config.ClusterImageSetConfig) *hivev1.ClusterImageSet {
+	cis := &hivev1.ClusterImageSet{}
+	cis.Name = cisConfig.Name
+	cis.Spec.ReleaseImage = fmt.Sprintf("quay.io/openshift-release-dev/ocp-release:%s", cisConfig.Name)
+	cis.Labels = make(map[string]string)
+	cis.Annotations = make(map[string]string)
+
+	// Custom labels and annotations from config
+	for k, v := range cisConfig.Labels {
+		cis.Labels[k] = v
+	}
+	for k, v := range cisConfig.Annotations {
+		cis.Annotations[k] = v
+	}
+
+	// Add channel-group label expected by clusters-service
+	if _, ok := cis.Labels["api.openshift.com/channel-group"]; !ok {
+		cis.Labels["api.openshift.com/channel-group"] = s.extractChannelGroup(cisConfig.Name)
+	}
+
+	// Add version annotation expected by clusters-service
+	if _, ok := cis.Annotations["api.openshift.com/version"]; !ok {
+		cis.Annotations["api.openshift.com/version"] = s.extractVersion(cisConfig.Name)
+	}
+
+	labels.StampRunID(cis, s.config.RunID)
+
+	// Record visibility so that hidden image sets are not offered as upgrades
+	if _, ok := cis.Labels[controllers.ImageSetVisibleLabel]; !ok {
+		cis.Labels[controllers.ImageSetVisibleLabel] = strconv.FormatBool(cisConfig.Visible)
+	}
+
+	// Hide invisible image sets from clusters-service, which filters on this label
+	if _, ok := cis.Labels[controllers.ClustersServiceVisibleLabel]; !ok && !cisConfig.Visible {
+		cis.Labels[controllers.ClustersServiceVisibleLabel] = "false"
+	}
+
+	return cis
+}
+
 // extractChannelGroup extracts the channel group from the ClusterImageSet name
 func (s *Server) extractChannelGroup(name string) string {
 	// Infer channel from name patterns
@@ -401,7 +824,23 @@ func (s *Server) extractVersion(name string) string {
 func (s *Server) startAPIServer(ctx context.Context) error {
 	s.logger.Info(ctx, "Starting API server on port %d", s.apiPort)
 
-	handlers := api.NewHandlers(s.logger, s.behaviorEngine)
+	certFile, keyFile, err := s.apiTLSFiles(ctx)
+	if err != nil {
+		return err
+	}
+
+	handlers := api.NewHandlers(s.logger, s.behaviorEngine, s.k8sClient)
+	handlers.SetStartedCheck(s.checkStarted)
+	handlers.AddReadinessCheck("clusterImageSets", s.checkClusterImageSets)
+	handlers.SetAPIServerURL(s.envTest.Config.Host)
+	handlers.SetEnabledControllers(s.enabledControllers)
+	handlers.SetResponseHeaders(s.config.APIResponseHeaders)
+	handlers.SetImageSetBuilder(s.buildClusterImageSet)
+	handlers.SetMetricsGatherer(s.metricsRegistry)
+	handlers.SetNotifier(s.notifier)
+	handlers.SetAPIToken(s.apiToken)
+	handlers.SetRequireTokenForReads(s.apiTokenForReads)
+	handlers.SetTLSEnabled(certFile != "")
 	router := api.SetupRoutes(handlers)
 
 	s.apiServer = &http.Server{
@@ -411,11 +850,72 @@ func (s *Server) startAPIServer(ctx context.Context) error {
 	}
 
 	go func() {
-		if err := s.apiServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if certFile != "" {
+			err = s.apiServer.ListenAndServeTLS(certFile, keyFile)
+		} else {
+			err = s.apiServer.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			s.logger.Error(ctx, "API server failed: %v", err)
 		}
 	}()
 
+	if len(s.replayRequests) > 0 {
+		go func() {
+			s.logger.Info(ctx, "Replaying %d recorded API requests", len(s.replayRequests))
+			if err := api.Replay(ctx, s.logger, s.replayHandler(router), s.replayRequests); err != nil {
+				s.logger.Warn(ctx, "Replay of recorded API requests stopped: %v", err)
+				return
+			}
+			s.logger.Info(ctx, "Replay of recorded API requests finished")
+		}()
+	}
+
+	return nil
+}
+
+// replayHandler returns the handler recorded requests are replayed against: the API router,
+// with the API token added to every request since the recordings are trusted
+func (s *Server) replayHandler(router http.Handler) http.Handler {
+	if s.apiToken == "" {
+		return router
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Header.Set("Authorization", "Bearer "+s.apiToken)
+		router.ServeHTTP(w, r)
+	})
+}
+
+// startMetricsServer registers the reconciler and behavior engine metrics, and serves them
+// on the metrics port when one is set. The controller-runtime metrics server stays disabled.
+func (s *Server) startMetricsServer(ctx context.Context) error {
+	s.metricsRegistry = prometheus.NewRegistry()
+	if err := s.behaviorEngine.RegisterMetrics(s.metricsRegistry); err != nil {
+		return errors.Wrapf(err, "failed to register behavior engine metrics")
+	}
+	if err := controllers.RegisterMetrics(s.metricsRegistry); err != nil {
+		return errors.Wrapf(err, "failed to register reconciler metrics")
+	}
+
+	if s.metricsPort <= 0 {
+		return nil
+	}
+	s.logger.Info(ctx, "Starting metrics server on port %d", s.metricsPort)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(s.metricsRegistry, promhttp.HandlerOpts{}))
+	s.metricsServer = &http.Server{
+		Addr:              fmt.Sprintf(":%d", s.metricsPort),
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		if err := s.metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			s.logger.Error(ctx, "Metrics server failed: %v", err)
+		}
+	}()
 	return nil
 }
 
@@ -435,6 +935,29 @@ func (s *Server) stop(ctx context.Context) error {
 		}
 	}
 
+	// Stop metrics server
+	if s.metricsServer != nil {
+		shutdownCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		if err := s.metricsServer.Shutdown(shutdownCtx); err != nil {
+			s.logger.Error(ctx, "Failed to shutdown metrics server: %v", err)
+		}
+	}
+
+	// Let in-flight reconciles finish before the API server they write to goes away
+	if s.mgrDone != nil {
+		s.logger.Info(ctx, "Waiting for controller manager to stop...")
+		s.stopManager()
+		select {
+		case <-s.mgrDone:
+			s.logger.Info(ctx, "Controller manager stopped gracefully")
+		case <-time.After(managerStopTimeout):
+			s.logger.Warn(ctx, "Controller manager did not stop within %v", managerStopTimeout)
+		}
+	}
+
+	s.behaviorEngine.Close()
+
 	// Stop envtest (this stops etcd and kube-apiserver)
 	if s.envTest != nil {
 		s.logger.Info(ctx, "Stopping envtest environment (etcd and kube-apiserver)...")
@@ -445,8 +968,15 @@ func (s *Server) stop(ctx context.Context) error {
 		}
 	}
 
+	// Clean up downloaded CRDs
+	if s.crdDownloadDir != "" {
+		if err := os.RemoveAll(s.crdDownloadDir); err != nil {
+			s.logger.Warn(ctx, "Failed to remove downloaded CRDs: %v", err)
+		}
+	}
+
 	// Clean up kubeconfig
-	if s.kubeconfigPath != "" {
+	if s.kubeconfigPath != "" && !s.keepKubeconfig {
 		s.logger.Debug(ctx, "Removing kubeconfig file: %s", s.kubeconfigPath)
 		if err := os.Remove(s.kubeconfigPath); err != nil {
 			s.logger.Warn(ctx, "Failed to remove kubeconfig: %v", err)
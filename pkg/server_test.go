@@ -0,0 +1,235 @@
+package hive_simulator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/openshift-online/ocm-sdk-go/logging"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/controllers"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/labels"
+)
+
+func createTestLogger() logging.Logger {
+	builder := logging.NewStdLoggerBuilder()
+	builder.Info(true)
+	logger, _ := builder.Build()
+	return logger
+}
+
+func createTestServer(t *testing.T, cfg *config.Config, objects ...client.Object) *Server {
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+	require.NoError(t, hivev1.AddToScheme(scheme))
+
+	server := NewServer(createTestLogger(), cfg, ServerOptions{})
+	server.k8sClient = fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+	return server
+}
+
+func TestServer_PrepopulateClusterImageSets_CustomMetadata(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.DefaultConfig()
+	cfg.ClusterImageSets = []config.ClusterImageSetConfig{
+		{
+			Name:        "openshift-v4.17.0-ec.0-candidate",
+			Visible:     true,
+			Labels:      map[string]string{"team": "qe"},
+			Annotations: map[string]string{"example.com/notes": "custom"},
+		},
+	}
+	cfg.RunID = "ci-1"
+	server := createTestServer(t, cfg)
+
+	require.NoError(t, server.prepopulateClusterImageSets(ctx))
+
+	cis := &hivev1.ClusterImageSet{}
+	require.NoError(t, server.k8sClient.Get(ctx, client.ObjectKey{Name: "openshift-v4.17.0-ec.0-candidate"}, cis))
+
+	assert.Equal(t, "qe", cis.Labels["team"])
+	assert.Equal(t, "candidate", cis.Labels["api.openshift.com/channel-group"])
+	assert.Equal(t, "true", cis.Labels[controllers.ImageSetVisibleLabel])
+	assert.Equal(t, "ci-1", cis.Labels[labels.RunID])
+	assert.Equal(t, "custom", cis.Annotations["example.com/notes"])
+	assert.Equal(t, "4.17.0-ec.0", cis.Annotations["api.openshift.com/version"])
+}
+
+func TestServer_PrepopulateClusterImageSets_Visibility(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.DefaultConfig()
+	cfg.ClusterImageSets = []config.ClusterImageSetConfig{
+		{Name: "openshift-v4.16.0", Visible: true},
+		{Name: "openshift-v4.17.0", Visible: false},
+		{Name: "openshift-v4.18.0-ec.0-candidate", Visible: false},
+	}
+	server := createTestServer(t, cfg)
+
+	require.NoError(t, server.prepopulateClusterImageSets(ctx))
+
+	visible := &hivev1.ClusterImageSet{}
+	require.NoError(t, server.k8sClient.Get(ctx, client.ObjectKey{Name: "openshift-v4.16.0"}, visible))
+	assert.NotContains(t, visible.Labels, "api.openshift.com/visible")
+	assert.Equal(t, "true", visible.Labels[controllers.ImageSetVisibleLabel])
+
+	for _, name := range []string{"openshift-v4.17.0", "openshift-v4.18.0-ec.0-candidate"} {
+		invisible := &hivev1.ClusterImageSet{}
+		require.NoError(t, server.k8sClient.Get(ctx, client.ObjectKey{Name: name}, invisible))
+		assert.Equal(t, "false", invisible.Labels["api.openshift.com/visible"], name)
+		assert.Equal(t, "false", invisible.Labels[controllers.ImageSetVisibleLabel], name)
+	}
+}
+
+func TestServer_PrepopulateClusterImageSets_Duplicates(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.DefaultConfig()
+	cfg.ClusterImageSets = []config.ClusterImageSetConfig{
+		{Name: "openshift-v4.16.0", Visible: true},
+		{Name: "openshift-v4.17.0", Visible: true},
+		{Name: "openshift-v4.16.0", Visible: false},
+	}
+	server := createTestServer(t, cfg)
+	var logs bytes.Buffer
+	builder := logging.NewStdLoggerBuilder().Streams(&logs, &logs)
+	builder.Warn(true)
+	server.logger, _ = builder.Build()
+
+	require.NoError(t, server.prepopulateClusterImageSets(ctx))
+
+	// Only the first entry is created and kept in the configuration
+	cisList := &hivev1.ClusterImageSetList{}
+	require.NoError(t, server.k8sClient.List(ctx, cisList))
+	assert.Len(t, cisList.Items, 2)
+	cis := &hivev1.ClusterImageSet{}
+	require.NoError(t, server.k8sClient.Get(ctx, client.ObjectKey{Name: "openshift-v4.16.0"}, cis))
+	assert.Equal(t, "true", cis.Labels[controllers.ImageSetVisibleLabel])
+	assert.Len(t, cfg.ClusterImageSets, 2)
+
+	assert.Contains(t, logs.String(), "ClusterImageSet openshift-v4.16.0 is listed more than once")
+	assert.NotContains(t, logs.String(), "may already exist")
+}
+
+func TestServer_CheckClusterImageSets_RetriesFailedCreates(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.DefaultConfig()
+	cfg.ClusterImageSets = []config.ClusterImageSetConfig{
+		{Name: "openshift-v4.16.0", Visible: true},
+		{Name: "openshift-v4.17.0", Visible: true},
+	}
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, hivev1.AddToScheme(scheme))
+
+	// The first two attempts to create 4.17.0 fail transiently
+	failures := 2
+	server := NewServer(createTestLogger(), cfg, ServerOptions{})
+	server.k8sClient = fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				if obj.GetName() == "openshift-v4.17.0" && failures > 0 {
+					failures--
+					return fmt.Errorf("transient error")
+				}
+				return c.Create(ctx, obj, opts...)
+			},
+		}).
+		Build()
+
+	require.NoError(t, server.prepopulateClusterImageSets(ctx))
+
+	// Readiness retries the creation but the second attempt still fails
+	err := server.checkClusterImageSets(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "openshift-v4.17.0")
+
+	// The third attempt succeeds
+	require.NoError(t, server.checkClusterImageSets(ctx))
+	require.NoError(t, server.k8sClient.Get(ctx, client.ObjectKey{Name: "openshift-v4.17.0"}, &hivev1.ClusterImageSet{}))
+}
+
+func TestServer_ReloadConfigKeepsOverrides(t *testing.T) {
+	ctx := context.Background()
+	server := createTestServer(t, config.DefaultConfig())
+	delay := 30
+	server.behaviorEngine.SetResourceOverride(ctx, "ClusterDeployment", "default", "cd-1",
+		&config.ResourceOverride{DelaySeconds: &delay})
+
+	reloaded := config.DefaultConfig()
+	reloaded.AccountClaim.DefaultDelaySeconds = 7
+	server.ReloadConfig(ctx, reloaded)
+
+	assert.Equal(t, 7, server.behaviorEngine.GetAccountClaimConfig().DefaultDelaySeconds)
+	assert.Equal(t, reloaded.ClusterDeployment, server.behaviorEngine.GetClusterDeploymentConfig())
+	assert.Equal(t, 30*time.Second,
+		server.behaviorEngine.GetTransitionDelay(ctx, "ClusterDeployment", "default", "cd-1", nil, time.Second))
+}
+
+func TestServer_CreateKubeconfig_CustomPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "dir", "kubeconfig.yaml")
+	server := NewServer(createTestLogger(), config.DefaultConfig(), ServerOptions{
+		KubeconfigPath: path,
+		KeepKubeconfig: true,
+	})
+
+	require.NoError(t, server.createKubeconfig(&rest.Config{Host: "https://127.0.0.1:43567"}))
+
+	kubeconfig, err := clientcmd.LoadFromFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "https://127.0.0.1:43567", kubeconfig.Clusters["hive-simulator"].Server)
+
+	// The kubeconfig is kept on stop when requested
+	require.NoError(t, server.stop(context.Background()))
+	_, err = os.Stat(path)
+	assert.NoError(t, err)
+}
+
+func TestServer_StopWaitsForControllerManager(t *testing.T) {
+	server := NewServer(createTestLogger(), config.DefaultConfig(), ServerOptions{})
+
+	// The manager takes a moment to drain its in-flight reconciles once stopped
+	var drained bool
+	server.mgrDone = make(chan struct{})
+	server.stopManager = func() {
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+			drained = true
+			close(server.mgrDone)
+		}()
+	}
+
+	require.NoError(t, server.stop(context.Background()))
+	assert.True(t, drained)
+}
+
+func TestServer_EnabledControllers(t *testing.T) {
+	server := NewServer(createTestLogger(), config.DefaultConfig(), ServerOptions{})
+	assert.Equal(t, AllControllers, server.enabledControllers)
+
+	server = NewServer(createTestLogger(), config.DefaultConfig(), ServerOptions{
+		EnabledControllers: []string{ProjectClaimController, ClusterDeploymentController},
+	})
+	assert.Equal(t, []string{ClusterDeploymentController, ProjectClaimController}, server.enabledControllers)
+
+	require.NoError(t, ValidateControllers([]string{"clusterdeployment", "accountclaim"}))
+	err := ValidateControllers([]string{"clusterdeployment", "syncset"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown controller "syncset"`)
+}
@@ -3,10 +3,12 @@ package state_machine
 import (
 	"context"
 	"fmt"
+	"math/rand"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
 
 	"github.com/openshift-online/ocm-sdk-go/logging"
 
@@ -16,44 +18,85 @@ import (
 
 // AccountClaimStateMachine manages AccountClaim state transitions
 type AccountClaimStateMachine struct {
-	logger logging.Logger
-	config *config.AccountClaimConfig
+	logger       logging.Logger
+	configSource func() *config.AccountClaimConfig
+	clock        clock.Clock
+	roll         func() float64
+	normal       func() float64
+	accelerate   func(time.Duration) time.Duration
 }
 
-// NewAccountClaimStateMachine creates a new AccountClaim state machine
-func NewAccountClaimStateMachine(logger logging.Logger, cfg *config.AccountClaimConfig) *AccountClaimStateMachine {
+// NewAccountClaimStateMachine creates a new AccountClaim state machine. The clock stamps the
+// conditions it sets and the times it measures, the real clock is used when it is nil.
+func NewAccountClaimStateMachine(logger logging.Logger, cfg *config.AccountClaimConfig, clk clock.Clock) *AccountClaimStateMachine {
+	if clk == nil {
+		clk = clock.RealClock{}
+	}
 	return &AccountClaimStateMachine{
-		logger: logger,
-		config: cfg,
+		logger:       logger,
+		configSource: func() *config.AccountClaimConfig { return cfg },
+		clock:        clk,
+		roll:         rand.Float64,
+		normal:       rand.NormFloat64,
+		accelerate:   unaccelerated,
 	}
 }
 
+// SetRoll makes the state machine roll the probabilities of its transitions with roll instead
+// of the unseeded global source
+func (sm *AccountClaimStateMachine) SetRoll(roll func() float64) {
+	sm.roll = roll
+}
+
+// SetNormal makes the state machine sample normally distributed state durations with normal
+// instead of the unseeded global source
+func (sm *AccountClaimStateMachine) SetNormal(normal func() float64) {
+	sm.normal = normal
+}
+
+// SetAccelerate makes the state machine scale its start jitter with accelerate
+func (sm *AccountClaimStateMachine) SetAccelerate(accelerate func(time.Duration) time.Duration) {
+	sm.accelerate = accelerate
+}
+
+// SetConfigSource makes the state machine read its configuration from source on every use,
+// so that updated states and jitter apply to AccountClaims already in progress
+func (sm *AccountClaimStateMachine) SetConfigSource(source func() *config.AccountClaimConfig) {
+	sm.configSource = source
+}
+
+// config returns the current configuration
+func (sm *AccountClaimStateMachine) config() *config.AccountClaimConfig {
+	return sm.configSource()
+}
+
 // GetNextState determines the next state for an AccountClaim
 func (sm *AccountClaimStateMachine) GetNextState(ctx context.Context, ac *aaov1alpha1.AccountClaim) (aaov1alpha1.ClaimStatus, time.Duration) {
+	cfg := sm.config()
 	currentState := ac.Status.State
 	sm.logger.Debug(ctx, "Current AccountClaim state for %s/%s: %s", ac.Namespace, ac.Name, currentState)
 
 	// Find current state in config
-	for i, state := range sm.config.States {
+	for i, state := range cfg.States {
 		if string(currentState) == state.Name || (currentState == "" && state.Name == "Pending") {
-			// If this is the last state, stay here
-			if i >= len(sm.config.States)-1 {
+			// If this is the final state, stay here
+			nextState := followingState(cfg.States, i, ac.Labels, sm.roll)
+			if nextState == nil {
 				sm.logger.Debug(ctx, "AccountClaim %s/%s is in final state: %s", ac.Namespace, ac.Name, state.Name)
 				return aaov1alpha1.ClaimStatus(state.Name), 0
 			}
 
 			// Return next state and its duration
-			nextState := sm.config.States[i+1]
-			duration := time.Duration(nextState.DurationSeconds) * time.Second
+			duration := sampleDuration(*nextState, cfg.DelayDistribution, sm.normal)
 			sm.logger.Debug(ctx, "Next state for AccountClaim %s/%s: %s (duration: %v)", ac.Namespace, ac.Name, nextState.Name, duration)
 			return aaov1alpha1.ClaimStatus(nextState.Name), duration
 		}
 	}
 
 	// Default to first state
-	if len(sm.config.States) > 0 {
-		firstState := sm.config.States[0]
-		duration := time.Duration(firstState.DurationSeconds) * time.Second
+	if len(cfg.States) > 0 {
+		firstState := cfg.States[0]
+		duration := sampleDuration(firstState, cfg.DelayDistribution, sm.normal)
 		sm.logger.Debug(ctx, "AccountClaim %s/%s has no current state, starting with: %s", ac.Namespace, ac.Name, firstState.Name)
 		return aaov1alpha1.ClaimStatus(firstState.Name), duration
 	}
@@ -61,58 +104,151 @@ func (sm *AccountClaimStateMachine) GetNextState(ctx context.Context, ac *aaov1a
 	return aaov1alpha1.ClaimStatusPending, 3 * time.Second
 }
 
-// ApplyState applies a state to the AccountClaim
-func (sm *AccountClaimStateMachine) ApplyState(ctx context.Context, ac *aaov1alpha1.AccountClaim, state aaov1alpha1.ClaimStatus) error {
+// ApplyState applies a state to the AccountClaim. Conditions come from the state
+// configuration, then the default conditions of the resource type, then the built-in
+// conditions of the state. specChanged reports whether the spec was changed as well as the
+// status.
+func (sm *AccountClaimStateMachine) ApplyState(ctx context.Context, ac *aaov1alpha1.AccountClaim, state aaov1alpha1.ClaimStatus) (specChanged bool, err error) {
+	cfg := sm.config()
 	sm.logger.Info(ctx, "Applying state %s to AccountClaim %s/%s", state, ac.Namespace, ac.Name)
 
 	ac.Status.State = state
 
-	now := metav1.Now()
+	now := metav1.NewTime(sm.clock.Now())
 
 	// Update conditions based on state
+	previous := ac.Status.Conditions
+	stateConfig := findState(cfg.States, string(state))
+	if conditionConfigs := stateConditions(stateConfig, cfg.Defaults); len(conditionConfigs) > 0 {
+		ac.Status.Conditions = sm.buildConditions(conditionConfigs, now)
+	} else if conditions := defaultAccountClaimConditions(state, now); conditions != nil {
+		ac.Status.Conditions = conditions
+	}
+	keepAccountClaimTransitionTimes(previous, ac.Status.Conditions)
+
+	// Simulate AWS account ID
+	if state == aaov1alpha1.ClaimStatusReady && ac.Spec.BYOCAWSAccountID == "" {
+		ac.Spec.BYOCAWSAccountID = fmt.Sprintf("123456789%03d", sm.clock.Now().UTC().Unix()%1000)
+		specChanged = true
+	}
+
+	return specChanged, nil
+}
+
+// keepAccountClaimTransitionTimes keeps the LastTransitionTime of the conditions whose status is
+// the same as in the previous conditions, only their LastProbeTime moves
+func keepAccountClaimTransitionTimes(previous, conditions []aaov1alpha1.AccountClaimCondition) {
+	for i := range conditions {
+		for _, prev := range previous {
+			if prev.Type == conditions[i].Type && prev.Status == conditions[i].Status && !prev.LastTransitionTime.IsZero() {
+				conditions[i].LastTransitionTime = prev.LastTransitionTime
+			}
+		}
+	}
+}
+
+// buildConditions builds the conditions of a state from their configuration
+func (sm *AccountClaimStateMachine) buildConditions(conditionConfigs []config.ConditionConfig, now metav1.Time) []aaov1alpha1.AccountClaimCondition {
+	conditions := []aaov1alpha1.AccountClaimCondition{}
+
+	for _, condConfig := range conditionConfigs {
+		condition := aaov1alpha1.AccountClaimCondition{
+			Type:               aaov1alpha1.AccountClaimConditionType(condConfig.Type),
+			Status:             conditionStatus(condConfig.Status),
+			Reason:             condConfig.Reason,
+			Message:            condConfig.Message,
+			LastTransitionTime: now,
+			LastProbeTime:      now,
+		}
+		conditions = append(conditions, condition)
+	}
+
+	return conditions
+}
+
+// defaultAccountClaimConditions returns the conditions of a state that has none configured,
+// nil for states without defaults, which keep their current conditions
+func defaultAccountClaimConditions(state aaov1alpha1.ClaimStatus, now metav1.Time) []aaov1alpha1.AccountClaimCondition {
+	var condition aaov1alpha1.AccountClaimCondition
 	switch state {
 	case aaov1alpha1.ClaimStatusPending:
-		ac.Status.Conditions = []aaov1alpha1.AccountClaimCondition{
-			{
-				Type:               aaov1alpha1.AccountUnclaimed,
-				Status:             corev1.ConditionTrue,
-				Reason:             "AccountPending",
-				Message:            "Account claim is pending",
-				LastTransitionTime: now,
-				LastProbeTime:      now,
-			},
+		condition = aaov1alpha1.AccountClaimCondition{
+			Type:    aaov1alpha1.AccountUnclaimed,
+			Reason:  "AccountPending",
+			Message: "Account claim is pending",
 		}
 
 	case aaov1alpha1.ClaimStatusReady:
-		ac.Status.Conditions = []aaov1alpha1.AccountClaimCondition{
-			{
-				Type:               aaov1alpha1.AccountClaimed,
-				Status:             corev1.ConditionTrue,
-				Reason:             "AccountClaimed",
-				Message:            "Account has been claimed",
-				LastTransitionTime: now,
-				LastProbeTime:      now,
-			},
-		}
-		// Simulate AWS account ID
-		if ac.Spec.BYOCAWSAccountID == "" {
-			ac.Spec.BYOCAWSAccountID = fmt.Sprintf("123456789%03d", time.Now().UTC().Unix()%1000)
+		condition = aaov1alpha1.AccountClaimCondition{
+			Type:    aaov1alpha1.AccountClaimed,
+			Reason:  "AccountClaimed",
+			Message: "Account has been claimed",
 		}
 
 	case aaov1alpha1.ClaimStatusError:
-		ac.Status.Conditions = []aaov1alpha1.AccountClaimCondition{
-			{
-				Type:               aaov1alpha1.AccountClaimFailed,
-				Status:             corev1.ConditionTrue,
-				Reason:             "ClaimFailed",
-				Message:            "Account claim failed",
-				LastTransitionTime: now,
-				LastProbeTime:      now,
-			},
+		condition = aaov1alpha1.AccountClaimCondition{
+			Type:    aaov1alpha1.AccountClaimFailed,
+			Reason:  "ClaimFailed",
+			Message: "Account claim failed",
 		}
+
+	default:
+		return nil
 	}
 
-	return nil
+	condition.Status = corev1.ConditionTrue
+	condition.LastTransitionTime = now
+	condition.LastProbeTime = now
+	return []aaov1alpha1.AccountClaimCondition{condition}
+}
+
+// EstimateRemaining returns the current state of the AccountClaim and the estimated
+// time until it reaches its final state, including any start jitter still to wait out.
+// Errored AccountClaims have nothing remaining. An error is returned, along with the state,
+// when the current state is not configured.
+func (sm *AccountClaimStateMachine) EstimateRemaining(ac *aaov1alpha1.AccountClaim, delay DelayFunc) (string, time.Duration, error) {
+	currentState := ac.Status.State
+	if currentState == aaov1alpha1.ClaimStatusError {
+		return string(currentState), 0, nil
+	}
+	if currentState == "" {
+		currentState = aaov1alpha1.ClaimStatusPending
+	}
+
+	transitions := make([]metav1.Time, 0, 2*len(ac.Status.Conditions))
+	for _, condition := range ac.Status.Conditions {
+		transitions = append(transitions, condition.LastTransitionTime, condition.LastProbeTime)
+	}
+	elapsed := stateElapsed(sm.clock.Now(), ac.CreationTimestamp, transitions...)
+
+	remaining, err := remainingDuration(sm.config().States, string(currentState), elapsed, delay)
+	if err != nil {
+		return string(currentState), 0, err
+	}
+	return string(currentState), sm.StartDelay(ac) + remaining, nil
+}
+
+// CurrentState returns the state of the AccountClaim, Pending until a state has been applied
+func (sm *AccountClaimStateMachine) CurrentState(ac *aaov1alpha1.AccountClaim) string {
+	if ac.Status.State == "" {
+		return string(aaov1alpha1.ClaimStatusPending)
+	}
+	return string(ac.Status.State)
+}
+
+// StartDelay returns how long an AccountClaim that has no state yet should wait before its
+// first transition
+func (sm *AccountClaimStateMachine) StartDelay(ac *aaov1alpha1.AccountClaim) time.Duration {
+	if ac.Status.State != "" {
+		return 0
+	}
+	return startJitter(ac, sm.accelerate(time.Duration(sm.config().StartJitterSeconds)*time.Second), sm.clock.Now())
+}
+
+// Now returns the current time of the state machine's clock, for the conditions the
+// AccountClaim controller sets itself
+func (sm *AccountClaimStateMachine) Now() time.Time {
+	return sm.clock.Now()
 }
 
 // ApplyFailure applies a failure state to the AccountClaim
@@ -121,7 +257,7 @@ func (sm *AccountClaimStateMachine) ApplyFailure(ctx context.Context, ac *aaov1a
 
 	ac.Status.State = aaov1alpha1.ClaimStatusError
 
-	now := metav1.Now()
+	now := metav1.NewTime(sm.clock.Now())
 	condition := aaov1alpha1.AccountClaimCondition{
 		Type:               aaov1alpha1.AccountClaimConditionType(failure.Condition),
 		Status:             corev1.ConditionTrue,
@@ -0,0 +1,30 @@
+package state_machine
+
+import (
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+)
+
+// IsAgentPlatform returns true if the ClusterDeployment installs on agent or bare metal
+// hosts, either by its platform spec or by its cloud-provider label
+func IsAgentPlatform(cd *hivev1.ClusterDeployment) bool {
+	if cd.Spec.Platform.AgentBareMetal != nil || cd.Spec.Platform.BareMetal != nil {
+		return true
+	}
+	switch cd.Labels["cloud-provider"] {
+	case "agent", "baremetal":
+		return true
+	}
+	return false
+}
+
+// states returns the state progression of the ClusterDeployment, using the agent states
+// for agent and bare metal clusters when configured
+func (sm *ClusterDeploymentStateMachine) states(cd *hivev1.ClusterDeployment) []config.StateConfig {
+	cfg := sm.config()
+	if IsAgentPlatform(cd) && len(cfg.AgentStates) > 0 {
+		return cfg.AgentStates
+	}
+	return cfg.States
+}
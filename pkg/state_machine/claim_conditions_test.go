@@ -0,0 +1,215 @@
+package state_machine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+	aaov1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/aws-account-operator/v1alpha1"
+	gcpv1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/gcp-project-operator/v1alpha1"
+)
+
+func TestAccountClaimStateMachine_ApplyState_ConfiguredConditions(t *testing.T) {
+	ctx := context.Background()
+	sm := NewAccountClaimStateMachine(createTestLogger(), &config.AccountClaimConfig{
+		States: []config.StateConfig{
+			{Name: "Pending"},
+			{Name: "Ready", Conditions: []config.ConditionConfig{
+				{Type: "Claimed", Status: "True", Reason: "AccountReused", Message: "Reused a pooled account"},
+				{Type: "Unclaimed", Status: "False"},
+			}},
+		},
+	}, clock.RealClock{})
+	ac := &aaov1alpha1.AccountClaim{ObjectMeta: metav1.ObjectMeta{Name: "test-claim", Namespace: "default"}}
+
+	_, err := sm.ApplyState(ctx, ac, aaov1alpha1.ClaimStatusReady)
+	require.NoError(t, err)
+	assert.Equal(t, aaov1alpha1.ClaimStatusReady, ac.Status.State)
+	require.Len(t, ac.Status.Conditions, 2)
+	assert.Equal(t, aaov1alpha1.AccountClaimed, ac.Status.Conditions[0].Type)
+	assert.Equal(t, corev1.ConditionTrue, ac.Status.Conditions[0].Status)
+	assert.Equal(t, "AccountReused", ac.Status.Conditions[0].Reason)
+	assert.Equal(t, "Reused a pooled account", ac.Status.Conditions[0].Message)
+	assert.Equal(t, aaov1alpha1.AccountUnclaimed, ac.Status.Conditions[1].Type)
+	assert.Equal(t, corev1.ConditionFalse, ac.Status.Conditions[1].Status)
+	assert.NotEmpty(t, ac.Spec.BYOCAWSAccountID)
+}
+
+func TestAccountClaimStateMachine_ApplyState_DefaultConditions(t *testing.T) {
+	ctx := context.Background()
+	sm := NewAccountClaimStateMachine(createTestLogger(), &config.AccountClaimConfig{
+		States: []config.StateConfig{{Name: "Pending"}, {Name: "Ready"}},
+	}, clock.RealClock{})
+	ac := &aaov1alpha1.AccountClaim{ObjectMeta: metav1.ObjectMeta{Name: "test-claim", Namespace: "default"}}
+
+	specChanged, err := sm.ApplyState(ctx, ac, aaov1alpha1.ClaimStatusPending)
+	require.NoError(t, err)
+	assert.False(t, specChanged)
+	require.Len(t, ac.Status.Conditions, 1)
+	assert.Equal(t, aaov1alpha1.AccountUnclaimed, ac.Status.Conditions[0].Type)
+	assert.Empty(t, ac.Spec.BYOCAWSAccountID)
+
+	specChanged, err = sm.ApplyState(ctx, ac, aaov1alpha1.ClaimStatusReady)
+	require.NoError(t, err)
+	assert.True(t, specChanged)
+	require.Len(t, ac.Status.Conditions, 1)
+	assert.Equal(t, aaov1alpha1.AccountClaimed, ac.Status.Conditions[0].Type)
+	assert.Equal(t, corev1.ConditionTrue, ac.Status.Conditions[0].Status)
+	assert.Equal(t, "AccountClaimed", ac.Status.Conditions[0].Reason)
+	assert.NotEmpty(t, ac.Spec.BYOCAWSAccountID)
+}
+
+func TestProjectClaimStateMachine_ApplyState_ConfiguredConditions(t *testing.T) {
+	ctx := context.Background()
+	sm := NewProjectClaimStateMachine(createTestLogger(), &config.ProjectClaimConfig{
+		States: []config.StateConfig{
+			{Name: "Pending"},
+			{Name: "PendingProject", Conditions: []config.ConditionConfig{
+				{Type: "PendingProject", Status: "True", Reason: "QuotaCheck", Message: "Checking project quota"},
+			}},
+			{Name: "Ready"},
+		},
+	}, clock.RealClock{})
+	pc := &gcpv1alpha1.ProjectClaim{ObjectMeta: metav1.ObjectMeta{Name: "test-claim", Namespace: "default"}}
+
+	specChanged, err := sm.ApplyState(ctx, pc, gcpv1alpha1.ClaimStatusPendingProject)
+	require.NoError(t, err)
+	assert.True(t, specChanged)
+	require.Len(t, pc.Status.Conditions, 1)
+	assert.Equal(t, gcpv1alpha1.ConditionType("PendingProject"), pc.Status.Conditions[0].Type)
+	assert.Equal(t, "QuotaCheck", pc.Status.Conditions[0].Reason)
+	assert.Equal(t, "Checking project quota", pc.Status.Conditions[0].Message)
+	projectID := pc.Spec.GCPProjectID
+	assert.NotEmpty(t, projectID)
+
+	// Ready has no conditions configured, so it falls back to the defaults
+	specChanged, err = sm.ApplyState(ctx, pc, gcpv1alpha1.ClaimStatusReady)
+	require.NoError(t, err)
+	assert.False(t, specChanged)
+	require.Len(t, pc.Status.Conditions, 1)
+	assert.Equal(t, gcpv1alpha1.ConditionType("Ready"), pc.Status.Conditions[0].Type)
+	assert.Equal(t, "ProjectReady", pc.Status.Conditions[0].Reason)
+	assert.Equal(t, projectID, pc.Spec.GCPProjectID)
+}
+
+func TestProjectClaimStateMachine_ApplyState_ResourceDefaultConditions(t *testing.T) {
+	ctx := context.Background()
+	sm := NewProjectClaimStateMachine(createTestLogger(), &config.ProjectClaimConfig{
+		States: []config.StateConfig{
+			{Name: "Pending"},
+			{Name: "PendingProject", Conditions: []config.ConditionConfig{
+				{Type: "PendingProject", Status: "True", Reason: "QuotaCheck"},
+			}},
+			{Name: "Ready"},
+		},
+		Defaults: &config.ResourceDefaults{
+			Conditions: []config.ConditionConfig{{Type: "Simulated", Status: "True", Reason: "HiveSimulator"}},
+		},
+	}, clock.RealClock{})
+	pc := &gcpv1alpha1.ProjectClaim{ObjectMeta: metav1.ObjectMeta{Name: "test-claim", Namespace: "default"}}
+
+	// Conditions configured for the state take precedence over the defaults
+	_, err := sm.ApplyState(ctx, pc, gcpv1alpha1.ClaimStatusPendingProject)
+	require.NoError(t, err)
+	require.Len(t, pc.Status.Conditions, 1)
+	assert.Equal(t, "QuotaCheck", pc.Status.Conditions[0].Reason)
+
+	// The defaults take precedence over the built-in conditions of the state
+	_, err = sm.ApplyState(ctx, pc, gcpv1alpha1.ClaimStatusReady)
+	require.NoError(t, err)
+	require.Len(t, pc.Status.Conditions, 1)
+	assert.Equal(t, gcpv1alpha1.ConditionType("Simulated"), pc.Status.Conditions[0].Type)
+	assert.Equal(t, "HiveSimulator", pc.Status.Conditions[0].Reason)
+}
+
+func TestClaimStateMachines_ApplyState_KeepsTransitionTimes(t *testing.T) {
+	ctx := context.Background()
+	fakeClock := clocktesting.NewFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	entered := fakeClock.Now()
+
+	acSM := NewAccountClaimStateMachine(createTestLogger(), &config.AccountClaimConfig{
+		States: []config.StateConfig{{Name: "Pending"}, {Name: "Ready"}},
+	}, fakeClock)
+	ac := &aaov1alpha1.AccountClaim{ObjectMeta: metav1.ObjectMeta{Name: "test-claim", Namespace: "default"}}
+	_, err := acSM.ApplyState(ctx, ac, aaov1alpha1.ClaimStatusReady)
+	require.NoError(t, err)
+
+	pcSM := NewProjectClaimStateMachine(createTestLogger(), &config.ProjectClaimConfig{
+		States: []config.StateConfig{{Name: "Pending"}, {Name: "Ready", Conditions: []config.ConditionConfig{
+			{Type: "Ready", Status: "True"},
+		}}},
+	}, fakeClock)
+	pc := &gcpv1alpha1.ProjectClaim{ObjectMeta: metav1.ObjectMeta{Name: "test-claim", Namespace: "default"}}
+	_, err = pcSM.ApplyState(ctx, pc, gcpv1alpha1.ClaimStatusReady)
+	require.NoError(t, err)
+
+	// Reapplying a state with unchanged statuses only probes the conditions, with the
+	// built-in and the configured conditions alike
+	fakeClock.Step(time.Minute)
+	_, err = acSM.ApplyState(ctx, ac, aaov1alpha1.ClaimStatusReady)
+	require.NoError(t, err)
+	_, err = pcSM.ApplyState(ctx, pc, gcpv1alpha1.ClaimStatusReady)
+	require.NoError(t, err)
+
+	require.Len(t, ac.Status.Conditions, 1)
+	assert.Equal(t, entered, ac.Status.Conditions[0].LastTransitionTime.Time)
+	assert.Equal(t, fakeClock.Now(), ac.Status.Conditions[0].LastProbeTime.Time)
+	require.Len(t, pc.Status.Conditions, 1)
+	assert.Equal(t, entered, pc.Status.Conditions[0].LastTransitionTime.Time)
+	assert.Equal(t, fakeClock.Now(), pc.Status.Conditions[0].LastProbeTime.Time)
+
+	// A status change is a transition
+	_, err = acSM.ApplyState(ctx, ac, aaov1alpha1.ClaimStatusPending)
+	require.NoError(t, err)
+	require.Len(t, ac.Status.Conditions, 1)
+	assert.Equal(t, fakeClock.Now(), ac.Status.Conditions[0].LastTransitionTime.Time)
+}
+
+func TestClaimStateMachines_FakeClock(t *testing.T) {
+	ctx := context.Background()
+	fakeClock := clocktesting.NewFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	failure := &config.FailureScenario{Condition: "ClaimFailed", Reason: "Quota", Message: "Out of quota"}
+
+	acSM := NewAccountClaimStateMachine(createTestLogger(), &config.AccountClaimConfig{
+		States: []config.StateConfig{{Name: "Pending"}, {Name: "Ready"}},
+	}, fakeClock)
+	ac := &aaov1alpha1.AccountClaim{ObjectMeta: metav1.ObjectMeta{Name: "test-claim", Namespace: "default"}}
+	_, err := acSM.ApplyState(ctx, ac, aaov1alpha1.ClaimStatusPending)
+	require.NoError(t, err)
+	require.NotEmpty(t, ac.Status.Conditions)
+	assert.Equal(t, fakeClock.Now(), ac.Status.Conditions[0].LastTransitionTime.Time)
+
+	fakeClock.Step(time.Minute)
+	acSM.ApplyTransientFailure(ctx, ac, failure)
+	assert.Equal(t, fakeClock.Now(), ac.Status.Conditions[len(ac.Status.Conditions)-1].LastTransitionTime.Time)
+
+	fakeClock.Step(time.Minute)
+	require.NoError(t, acSM.ApplyFailure(ctx, ac, failure))
+	assert.Equal(t, fakeClock.Now(), ac.Status.Conditions[len(ac.Status.Conditions)-1].LastTransitionTime.Time)
+
+	pcSM := NewProjectClaimStateMachine(createTestLogger(), &config.ProjectClaimConfig{
+		States: []config.StateConfig{{Name: "Pending"}, {Name: "Ready"}},
+	}, fakeClock)
+	pc := &gcpv1alpha1.ProjectClaim{ObjectMeta: metav1.ObjectMeta{Name: "test-claim", Namespace: "default"}}
+	_, err = pcSM.ApplyState(ctx, pc, gcpv1alpha1.ClaimStatusPending)
+	require.NoError(t, err)
+	require.NotEmpty(t, pc.Status.Conditions)
+	assert.Equal(t, fakeClock.Now(), pc.Status.Conditions[0].LastTransitionTime.Time)
+
+	fakeClock.Step(time.Minute)
+	pcSM.ApplyTransientFailure(ctx, pc, failure)
+	assert.Equal(t, fakeClock.Now(), pc.Status.Conditions[len(pc.Status.Conditions)-1].LastTransitionTime.Time)
+
+	fakeClock.Step(time.Minute)
+	require.NoError(t, pcSM.ApplyFailure(ctx, pc, failure))
+	assert.Equal(t, fakeClock.Now(), pc.Status.Conditions[len(pc.Status.Conditions)-1].LastTransitionTime.Time)
+}
@@ -3,10 +3,12 @@ package state_machine
 import (
 	"context"
 	"fmt"
+	"math/rand"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
 
 	"github.com/openshift-online/ocm-sdk-go/logging"
 	hivev1 "github.com/openshift/hive/apis/hive/v1"
@@ -17,44 +19,94 @@ import (
 
 // ClusterDeploymentStateMachine manages ClusterDeployment state transitions
 type ClusterDeploymentStateMachine struct {
-	logger logging.Logger
-	config *config.ClusterDeploymentConfig
+	logger       logging.Logger
+	configSource func() *config.ClusterDeploymentConfig
+	clock        clock.Clock
+	roll         func() float64
+	normal       func() float64
+	accelerate   func(time.Duration) time.Duration
 }
 
-// NewClusterDeploymentStateMachine creates a new ClusterDeployment state machine
-func NewClusterDeploymentStateMachine(logger logging.Logger, cfg *config.ClusterDeploymentConfig) *ClusterDeploymentStateMachine {
+// NewClusterDeploymentStateMachine creates a new ClusterDeployment state machine. The clock stamps the
+// conditions it sets and the times it measures, the real clock is used when it is nil.
+func NewClusterDeploymentStateMachine(logger logging.Logger, cfg *config.ClusterDeploymentConfig, clk clock.Clock) *ClusterDeploymentStateMachine {
+	if clk == nil {
+		clk = clock.RealClock{}
+	}
 	return &ClusterDeploymentStateMachine{
-		logger: logger,
-		config: cfg,
+		logger:       logger,
+		configSource: func() *config.ClusterDeploymentConfig { return cfg },
+		clock:        clk,
+		roll:         rand.Float64,
+		normal:       rand.NormFloat64,
+		accelerate:   unaccelerated,
 	}
 }
 
+// SetRoll makes the state machine roll the probabilities of the transitions between
+// ClusterDeployment and deprovision states with roll instead of the unseeded global source
+func (sm *ClusterDeploymentStateMachine) SetRoll(roll func() float64) {
+	sm.roll = roll
+}
+
+// SetNormal makes the state machine sample the normally distributed durations of the
+// ClusterDeployment and deprovision states with normal instead of the unseeded global source
+func (sm *ClusterDeploymentStateMachine) SetNormal(normal func() float64) {
+	sm.normal = normal
+}
+
+// SetAccelerate makes the state machine scale the delays it measures itself, such as the start
+// jitter, power state changes, stuck recoveries, install logs and version steps, with accelerate
+func (sm *ClusterDeploymentStateMachine) SetAccelerate(accelerate func(time.Duration) time.Duration) {
+	sm.accelerate = accelerate
+}
+
+// Now returns the current time of the state machine's clock. The ClusterDeployment controller
+// measures and stamps with it whatever it times itself, so a fake clock drives those as well.
+func (sm *ClusterDeploymentStateMachine) Now() time.Time {
+	return sm.clock.Now()
+}
+
+// SetConfigSource makes the state machine read its configuration from source on every use
+// instead of the configuration it was created with, so that updated states, jitter,
+// dependencies and install phases apply to ClusterDeployments already in progress
+func (sm *ClusterDeploymentStateMachine) SetConfigSource(source func() *config.ClusterDeploymentConfig) {
+	sm.configSource = source
+}
+
+// config returns the current configuration
+func (sm *ClusterDeploymentStateMachine) config() *config.ClusterDeploymentConfig {
+	return sm.configSource()
+}
+
 // GetNextState determines the next state for a ClusterDeployment
 func (sm *ClusterDeploymentStateMachine) GetNextState(ctx context.Context, cd *hivev1.ClusterDeployment) (string, time.Duration) {
-	currentState := sm.getCurrentState(cd)
+	cfg := sm.config()
+	currentState := sm.CurrentState(cd)
 	sm.logger.Debug(ctx, "Current ClusterDeployment state for %s/%s: %s", cd.Namespace, cd.Name, currentState)
 
 	// Find current state in config
-	for i, state := range sm.config.States {
+	states := sm.states(cd)
+	for i, state := range states {
 		if state.Name == currentState {
-			// If this is the last state, stay here
-			if i >= len(sm.config.States)-1 {
+			// If this is the final state, stay here
+			nextState := followingState(states, i, cd.Labels, sm.roll)
+			if nextState == nil {
 				sm.logger.Debug(ctx, "ClusterDeployment %s/%s is in final state: %s", cd.Namespace, cd.Name, currentState)
 				return currentState, 0
 			}
 
 			// Return next state and its duration
-			nextState := sm.config.States[i+1]
-			duration := time.Duration(nextState.DurationSeconds) * time.Second
+			duration := sampleDuration(*nextState, cfg.DelayDistribution, sm.normal)
 			sm.logger.Debug(ctx, "Next state for ClusterDeployment %s/%s: %s (duration: %v)", cd.Namespace, cd.Name, nextState.Name, duration)
 			return nextState.Name, duration
 		}
 	}
 
 	// Default to first state if current state not found
-	if len(sm.config.States) > 0 {
-		firstState := sm.config.States[0]
-		duration := time.Duration(firstState.DurationSeconds) * time.Second
+	if len(states) > 0 {
+		firstState := states[0]
+		duration := sampleDuration(firstState, cfg.DelayDistribution, sm.normal)
 		sm.logger.Debug(ctx, "ClusterDeployment %s/%s has no current state, starting with: %s", cd.Namespace, cd.Name, firstState.Name)
 		return firstState.Name, duration
 	}
@@ -67,21 +119,17 @@ func (sm *ClusterDeploymentStateMachine) ApplyState(ctx context.Context, cd *hiv
 	sm.logger.Info(ctx, "Applying state %s to ClusterDeployment %s/%s", state, cd.Namespace, cd.Name)
 
 	// Find state config
-	var stateConfig *config.StateConfig
-	for i := range sm.config.States {
-		if sm.config.States[i].Name == state {
-			stateConfig = &sm.config.States[i]
-			break
-		}
-	}
-
+	stateConfig := findState(sm.states(cd), state)
 	if stateConfig == nil {
 		return errors.Errorf("state %s not found in configuration", state)
 	}
 
 	// Update conditions based on state
-	now := metav1.Now()
-	cd.Status.Conditions = sm.buildConditions(stateConfig, now)
+	now := metav1.NewTime(sm.clock.Now())
+	previous := cd.Status.Conditions
+	cd.Status.Conditions = sm.buildConditions(stateConditions(stateConfig, sm.config().Defaults), now)
+	keepTransitionTimes(previous, cd.Status.Conditions)
+	sm.applyInstallPhase(cd, state)
 
 	// Apply state-specific updates
 	switch state {
@@ -108,6 +156,7 @@ func (sm *ClusterDeploymentStateMachine) ApplyState(ctx context.Context, cd *hiv
 		cd.Status.WebConsoleURL = fmt.Sprintf("https://console-openshift-console.apps.%s.example.com", cd.Name)
 		cd.Status.APIURL = fmt.Sprintf("https://api.%s.example.com:6443", cd.Name)
 	}
+	recordState(cd, state)
 
 	return nil
 }
@@ -116,7 +165,7 @@ func (sm *ClusterDeploymentStateMachine) ApplyState(ctx context.Context, cd *hiv
 func (sm *ClusterDeploymentStateMachine) ApplyFailure(ctx context.Context, cd *hivev1.ClusterDeployment, failure *config.FailureScenario) error {
 	sm.logger.Warn(ctx, "Applying failure to ClusterDeployment %s/%s: %s - %s", cd.Namespace, cd.Name, failure.Reason, failure.Message)
 
-	now := metav1.Now()
+	now := metav1.NewTime(sm.clock.Now())
 
 	// Add failure condition
 	condition := hivev1.ClusterDeploymentCondition{
@@ -138,18 +187,67 @@ func (sm *ClusterDeploymentStateMachine) ApplyFailure(ctx context.Context, cd *h
 	return nil
 }
 
-// ShouldWaitForDependencies checks if ClusterDeployment should wait for dependencies
-func (sm *ClusterDeploymentStateMachine) ShouldWaitForDependencies() bool {
-	return sm.config.DependsOnAccountClaim || sm.config.DependsOnProjectClaim
+// EstimateRemaining returns the current state of the ClusterDeployment and the estimated
+// time until it reaches its final state, including any start jitter still to wait out.
+// Failed ClusterDeployments have nothing remaining. An error is returned, along with the
+// state, when the current state is not configured.
+func (sm *ClusterDeploymentStateMachine) EstimateRemaining(cd *hivev1.ClusterDeployment, delay DelayFunc) (string, time.Duration, error) {
+	if IsFailed(cd) {
+		return "Failed", 0, nil
+	}
+	currentState := sm.CurrentState(cd)
+
+	transitions := make([]metav1.Time, 0, 2*len(cd.Status.Conditions))
+	for _, condition := range cd.Status.Conditions {
+		transitions = append(transitions, condition.LastTransitionTime, condition.LastProbeTime)
+	}
+	elapsed := stateElapsed(sm.clock.Now(), cd.CreationTimestamp, transitions...)
+
+	remaining, err := remainingDuration(sm.states(cd), currentState, elapsed, delay)
+	if err != nil {
+		return currentState, 0, err
+	}
+	return currentState, sm.StartDelay(cd) + remaining, nil
+}
+
+// StartDelay returns how long a ClusterDeployment that has not started progressing yet
+// should wait before its first transition
+func (sm *ClusterDeploymentStateMachine) StartDelay(cd *hivev1.ClusterDeployment) time.Duration {
+	if cd.Spec.Installed || cd.Status.ProvisionRef != nil || len(cd.Status.Conditions) > 0 {
+		return 0
+	}
+	return startJitter(cd, sm.accelerate(time.Duration(sm.config().StartJitterSeconds)*time.Second), sm.clock.Now())
+}
+
+// ShouldWaitForDependencies checks if ClusterDeployment should wait for dependencies.
+// Agent and bare metal clusters have no cloud account or project to wait for, neither do the
+// clusters of a ClusterPool, which are provisioned before anyone claims them.
+func (sm *ClusterDeploymentStateMachine) ShouldWaitForDependencies(cd *hivev1.ClusterDeployment) bool {
+	cfg := sm.config()
+	if IsAgentPlatform(cd) || cd.Spec.ClusterPoolRef != nil {
+		return false
+	}
+	return cfg.DependsOnAccountClaim || cfg.DependsOnProjectClaim
 }
 
-// getCurrentState determines the current state from the ClusterDeployment
-func (sm *ClusterDeploymentStateMachine) getCurrentState(cd *hivev1.ClusterDeployment) string {
+// CurrentState determines the current state from the ClusterDeployment
+func (sm *ClusterDeploymentStateMachine) CurrentState(cd *hivev1.ClusterDeployment) string {
+	return ClusterDeploymentState(cd)
+}
+
+// ClusterDeploymentState determines the current state of a ClusterDeployment from its
+// installed flag, state annotation, conditions and provision reference
+func ClusterDeploymentState(cd *hivev1.ClusterDeployment) string {
 	// If installed, it's running
 	if cd.Spec.Installed {
 		return "Running"
 	}
 
+	// A state the conditions do not identify is recorded explicitly
+	if state := cd.Annotations[StateAnnotation]; state != "" {
+		return state
+	}
+
 	// Check conditions to determine state
 	for _, condition := range cd.Status.Conditions {
 		switch condition.Type {
@@ -161,6 +259,10 @@ func (sm *ClusterDeploymentStateMachine) getCurrentState(cd *hivev1.ClusterDeplo
 			if condition.Status == corev1.ConditionFalse {
 				return "Installing"
 			}
+		case "ClusterInstallRequirementsMet":
+			if condition.Status == corev1.ConditionFalse {
+				return "AgentWaiting"
+			}
 		case "DeprovisionLaunchError":
 			if condition.Status == corev1.ConditionFalse {
 				return "Provisioning"
@@ -177,22 +279,26 @@ func (sm *ClusterDeploymentStateMachine) getCurrentState(cd *hivev1.ClusterDeplo
 	return "Pending"
 }
 
-// buildConditions builds conditions for a given state
-func (sm *ClusterDeploymentStateMachine) buildConditions(stateConfig *config.StateConfig, now metav1.Time) []hivev1.ClusterDeploymentCondition {
-	conditions := []hivev1.ClusterDeploymentCondition{}
-
-	for _, condConfig := range stateConfig.Conditions {
-		status := corev1.ConditionUnknown
-		switch condConfig.Status {
-		case "True":
-			status = corev1.ConditionTrue
-		case "False":
-			status = corev1.ConditionFalse
+// keepTransitionTimes keeps the LastTransitionTime of the conditions whose status is the same
+// as in the previous conditions, only their LastProbeTime moves
+func keepTransitionTimes(previous, conditions []hivev1.ClusterDeploymentCondition) {
+	for i := range conditions {
+		for _, prev := range previous {
+			if prev.Type == conditions[i].Type && prev.Status == conditions[i].Status && !prev.LastTransitionTime.IsZero() {
+				conditions[i].LastTransitionTime = prev.LastTransitionTime
+			}
 		}
+	}
+}
+
+// buildConditions builds the conditions of a state from their configuration
+func (sm *ClusterDeploymentStateMachine) buildConditions(conditionConfigs []config.ConditionConfig, now metav1.Time) []hivev1.ClusterDeploymentCondition {
+	conditions := []hivev1.ClusterDeploymentCondition{}
 
+	for _, condConfig := range conditionConfigs {
 		condition := hivev1.ClusterDeploymentCondition{
 			Type:               hivev1.ClusterDeploymentConditionType(condConfig.Type),
-			Status:             status,
+			Status:             conditionStatus(condConfig.Status),
 			Reason:             condConfig.Reason,
 			Message:            condConfig.Message,
 			LastTransitionTime: now,
@@ -3,12 +3,16 @@ package state_machine
 import (
 	"context"
 	"testing"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
+	clocktesting "k8s.io/utils/clock/testing"
 
 	"github.com/openshift-online/ocm-sdk-go/logging"
 	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	hivev1agent "github.com/openshift/hive/apis/hive/v1/agent"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -40,17 +44,44 @@ func TestNewClusterDeploymentStateMachine(t *testing.T) {
 	logger := createTestLogger()
 	cfg := createTestClusterDeploymentConfig()
 
-	sm := NewClusterDeploymentStateMachine(logger, cfg)
+	sm := NewClusterDeploymentStateMachine(logger, cfg, clock.RealClock{})
 
 	assert.NotNil(t, sm)
 	assert.NotNil(t, sm.logger)
-	assert.NotNil(t, sm.config)
+	assert.NotNil(t, sm.config())
+
+	// Without a clock the real one is used
+	sm = NewClusterDeploymentStateMachine(logger, cfg, nil)
+	assert.Equal(t, clock.RealClock{}, sm.clock)
+}
+
+func TestClusterDeploymentStateMachine_SetConfigSource(t *testing.T) {
+	current := createTestClusterDeploymentConfig()
+	sm := NewClusterDeploymentStateMachine(createTestLogger(), createTestClusterDeploymentConfig(), clock.RealClock{})
+	sm.SetConfigSource(func() *config.ClusterDeploymentConfig { return current })
+	ctx := context.Background()
+	cd := &hivev1.ClusterDeployment{ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"}}
+	require.NoError(t, sm.ApplyState(ctx, cd, "Pending"))
+
+	state, duration := sm.GetNextState(ctx, cd)
+	assert.Equal(t, "Provisioning", state)
+	assert.Equal(t, 2*time.Second, duration)
+
+	// An updated configuration applies to a ClusterDeployment in progress
+	current = createTestClusterDeploymentConfig()
+	current.States[1].DurationSeconds = 30
+	current.DependsOnAccountClaim = false
+	current.DependsOnProjectClaim = false
+	state, duration = sm.GetNextState(ctx, cd)
+	assert.Equal(t, "Provisioning", state)
+	assert.Equal(t, 30*time.Second, duration)
+	assert.False(t, sm.ShouldWaitForDependencies(cd))
 }
 
 func TestClusterDeploymentStateMachine_GetNextState(t *testing.T) {
 	logger := createTestLogger()
 	cfg := createTestClusterDeploymentConfig()
-	sm := NewClusterDeploymentStateMachine(logger, cfg)
+	sm := NewClusterDeploymentStateMachine(logger, cfg, clock.RealClock{})
 	ctx := context.Background()
 
 	tests := []struct {
@@ -112,7 +143,7 @@ func TestClusterDeploymentStateMachine_ApplyState(t *testing.T) {
 			Message: "Cluster is provisioning",
 		},
 	}
-	sm := NewClusterDeploymentStateMachine(logger, cfg)
+	sm := NewClusterDeploymentStateMachine(logger, cfg, clock.RealClock{})
 	ctx := context.Background()
 
 	cd := &hivev1.ClusterDeployment{
@@ -146,7 +177,7 @@ func TestClusterDeploymentStateMachine_ApplyState(t *testing.T) {
 func TestClusterDeploymentStateMachine_ApplyState_InvalidState(t *testing.T) {
 	logger := createTestLogger()
 	cfg := createTestClusterDeploymentConfig()
-	sm := NewClusterDeploymentStateMachine(logger, cfg)
+	sm := NewClusterDeploymentStateMachine(logger, cfg, clock.RealClock{})
 	ctx := context.Background()
 
 	cd := &hivev1.ClusterDeployment{
@@ -164,7 +195,7 @@ func TestClusterDeploymentStateMachine_ApplyState_InvalidState(t *testing.T) {
 func TestClusterDeploymentStateMachine_ApplyFailure(t *testing.T) {
 	logger := createTestLogger()
 	cfg := createTestClusterDeploymentConfig()
-	sm := NewClusterDeploymentStateMachine(logger, cfg)
+	sm := NewClusterDeploymentStateMachine(logger, cfg, clock.RealClock{})
 	ctx := context.Background()
 
 	cd := &hivev1.ClusterDeployment{
@@ -191,6 +222,83 @@ func TestClusterDeploymentStateMachine_ApplyFailure(t *testing.T) {
 	assert.Equal(t, "Test failure message", cd.Status.Conditions[0].Message)
 }
 
+func TestClusterDeploymentStateMachine_FakeClock(t *testing.T) {
+	cfg := createTestClusterDeploymentConfig()
+	cfg.States[1].Conditions = []config.ConditionConfig{{Type: "DeprovisionLaunchError", Status: "False"}}
+	fakeClock := clocktesting.NewFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	sm := NewClusterDeploymentStateMachine(createTestLogger(), cfg, fakeClock)
+	ctx := context.Background()
+
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "test-cluster",
+			Namespace:         "default",
+			CreationTimestamp: metav1.NewTime(fakeClock.Now()),
+		},
+	}
+
+	// Conditions are stamped with the time of the injected clock
+	require.NoError(t, sm.ApplyState(ctx, cd, "Provisioning"))
+	require.Len(t, cd.Status.Conditions, 1)
+	assert.Equal(t, fakeClock.Now(), cd.Status.Conditions[0].LastTransitionTime.Time)
+	assert.Equal(t, fakeClock.Now(), cd.Status.Conditions[0].LastProbeTime.Time)
+
+	// The time spent in a state is measured with it too
+	fakeClock.Step(time.Second)
+	state, remaining, err := sm.EstimateRemaining(cd, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Provisioning", state)
+	assert.Equal(t, 2*time.Second, remaining)
+
+	fakeClock.Step(time.Minute)
+	require.NoError(t, sm.ApplyState(ctx, cd, "Running"))
+	assert.Equal(t, fakeClock.Now(), cd.Status.InstalledTimestamp.Time)
+
+	require.NoError(t, sm.ApplyFailure(ctx, cd, &config.FailureScenario{Condition: "ProvisionFailed"}))
+	assert.Equal(t, fakeClock.Now(), cd.Status.Conditions[len(cd.Status.Conditions)-1].LastTransitionTime.Time)
+}
+
+func TestClusterDeploymentStateMachine_ApplyState_KeepsTransitionTimes(t *testing.T) {
+	cfg := createTestClusterDeploymentConfig()
+	cfg.States[1].Conditions = []config.ConditionConfig{
+		{Type: "ProvisionStopped", Status: "False"},
+		{Type: "Provisioned", Status: "False"},
+	}
+	cfg.States[2].Conditions = []config.ConditionConfig{
+		{Type: "ProvisionStopped", Status: "False"},
+		{Type: "Provisioned", Status: "True"},
+	}
+	fakeClock := clocktesting.NewFakeClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	sm := NewClusterDeploymentStateMachine(createTestLogger(), cfg, fakeClock)
+	ctx := context.Background()
+	cd := &hivev1.ClusterDeployment{ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"}}
+
+	require.NoError(t, sm.ApplyState(ctx, cd, "Provisioning"))
+	entered := fakeClock.Now()
+
+	// Applying the same state again only probes the conditions
+	fakeClock.Step(time.Minute)
+	require.NoError(t, sm.ApplyState(ctx, cd, "Provisioning"))
+	for _, condition := range cd.Status.Conditions {
+		assert.Equal(t, entered, condition.LastTransitionTime.Time, condition.Type)
+		assert.Equal(t, fakeClock.Now(), condition.LastProbeTime.Time, condition.Type)
+	}
+
+	// Only the conditions whose status changes transition
+	fakeClock.Step(time.Minute)
+	require.NoError(t, sm.ApplyState(ctx, cd, "Installing"))
+	require.Len(t, cd.Status.Conditions, 2)
+	assert.Equal(t, entered, cd.Status.Conditions[0].LastTransitionTime.Time)
+	assert.Equal(t, fakeClock.Now(), cd.Status.Conditions[1].LastTransitionTime.Time)
+
+	// The time in the new state is still measured from when it was entered
+	fakeClock.Step(200 * time.Millisecond)
+	state, remaining, err := sm.EstimateRemaining(cd, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Installing", state)
+	assert.Equal(t, 800*time.Millisecond, remaining)
+}
+
 func TestClusterDeploymentStateMachine_ShouldWaitForDependencies(t *testing.T) {
 	logger := createTestLogger()
 
@@ -227,9 +335,60 @@ func TestClusterDeploymentStateMachine_ShouldWaitForDependencies(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			sm := NewClusterDeploymentStateMachine(logger, tt.config)
-			result := sm.ShouldWaitForDependencies()
+			sm := NewClusterDeploymentStateMachine(logger, tt.config, clock.RealClock{})
+			result := sm.ShouldWaitForDependencies(&hivev1.ClusterDeployment{})
 			assert.Equal(t, tt.expectedResult, result)
 		})
 	}
+
+	// Pool clusters are provisioned before anyone claims them, with no account or project to wait for
+	sm := NewClusterDeploymentStateMachine(logger, tests[0].config, clock.RealClock{})
+	pooled := &hivev1.ClusterDeployment{
+		Spec: hivev1.ClusterDeploymentSpec{
+			ClusterPoolRef: &hivev1.ClusterPoolReference{Namespace: "default", PoolName: "test-pool"},
+		},
+	}
+	assert.False(t, sm.ShouldWaitForDependencies(pooled))
+}
+
+func TestClusterDeploymentStateMachine_AgentPlatform(t *testing.T) {
+	sm := NewClusterDeploymentStateMachine(createTestLogger(), config.DefaultConfig().ClusterDeployment, clock.RealClock{})
+	ctx := context.Background()
+
+	agent := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "agent-cluster", Namespace: "default"},
+		Spec: hivev1.ClusterDeploymentSpec{
+			Platform: hivev1.Platform{AgentBareMetal: &hivev1agent.BareMetalPlatform{}},
+		},
+	}
+	baremetal := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "baremetal-cluster",
+			Namespace: "default",
+			Labels:    map[string]string{"cloud-provider": "baremetal"},
+		},
+	}
+	aws := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "aws-cluster", Namespace: "default"},
+	}
+
+	assert.True(t, IsAgentPlatform(agent))
+	assert.True(t, IsAgentPlatform(baremetal))
+	assert.False(t, IsAgentPlatform(aws))
+	assert.False(t, sm.ShouldWaitForDependencies(agent))
+	assert.True(t, sm.ShouldWaitForDependencies(aws))
+
+	// Agent clusters wait for their agents instead of provisioning cloud infrastructure
+	var progression []string
+	for {
+		nextState, _ := sm.GetNextState(ctx, agent)
+		if len(progression) > 0 && nextState == progression[len(progression)-1] {
+			break
+		}
+		require.NoError(t, sm.ApplyState(ctx, agent, nextState))
+		progression = append(progression, nextState)
+	}
+	assert.Equal(t, []string{"AgentWaiting", "Installing", "Running"}, progression)
+	assert.Nil(t, agent.Status.ProvisionRef)
+	assert.True(t, agent.Spec.Installed)
 }
@@ -0,0 +1,38 @@
+package state_machine
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+)
+
+// findState returns the configuration of the named state, or nil if it is not configured
+func findState(states []config.StateConfig, name string) *config.StateConfig {
+	for i := range states {
+		if states[i].Name == name {
+			return &states[i]
+		}
+	}
+	return nil
+}
+
+// stateConditions returns the conditions configured for a state, falling back to the default
+// conditions of the resource type when the state configures none
+func stateConditions(stateConfig *config.StateConfig, defaults *config.ResourceDefaults) []config.ConditionConfig {
+	if stateConfig != nil && len(stateConfig.Conditions) > 0 {
+		return stateConfig.Conditions
+	}
+	return defaults.GetConditions()
+}
+
+// conditionStatus converts a configured condition status, anything but "True" or "False" is
+// unknown
+func conditionStatus(status string) corev1.ConditionStatus {
+	switch status {
+	case "True":
+		return corev1.ConditionTrue
+	case "False":
+		return corev1.ConditionFalse
+	}
+	return corev1.ConditionUnknown
+}
@@ -0,0 +1,97 @@
+package state_machine
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	errors "github.com/zgalor/weberr"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+)
+
+const (
+	// DeprovisionStateAnnotation records the deprovision state a deleted ClusterDeployment is in
+	DeprovisionStateAnnotation = "hive-simulator.openshift.io/deprovision-state"
+
+	// DeprovisionRefAnnotation names the simulated ClusterDeprovision of a deleted ClusterDeployment,
+	// the deprovision counterpart of Status.ProvisionRef
+	DeprovisionRefAnnotation = "hive-simulator.openshift.io/deprovision-ref"
+)
+
+// GetDeprovisionState determines the next deprovision state for a ClusterDeployment that is
+// being deleted. It returns done once the last deprovision state has been reached, or right
+// away when no deprovision states are configured, meaning the finalizer can be removed.
+func (sm *ClusterDeploymentStateMachine) GetDeprovisionState(ctx context.Context, cd *hivev1.ClusterDeployment) (string, time.Duration, bool) {
+	cfg := sm.config()
+	states := cfg.DeprovisionStates
+	currentState := cd.Annotations[DeprovisionStateAnnotation]
+	sm.logger.Debug(ctx, "Current deprovision state for ClusterDeployment %s/%s: %q", cd.Namespace, cd.Name, currentState)
+
+	if currentState == "" {
+		if len(states) == 0 {
+			return "", 0, true
+		}
+		return states[0].Name, sm.accelerate(sampleDuration(states[0], cfg.DelayDistribution, sm.normal)), false
+	}
+
+	for i, state := range states {
+		if state.Name != currentState {
+			continue
+		}
+		if nextState := followingState(states, i, cd.Labels, sm.roll); nextState != nil {
+			return nextState.Name, sm.accelerate(sampleDuration(*nextState, cfg.DelayDistribution, sm.normal)), false
+		}
+		break
+	}
+
+	// In the final state, or in a state that is no longer configured
+	return currentState, 0, true
+}
+
+// ApplyDeprovisionState applies a deprovision state to the ClusterDeployment. The state's
+// conditions replace existing conditions of the same type. An empty state only sets the
+// deprovision reference, for when no deprovision states are configured.
+func (sm *ClusterDeploymentStateMachine) ApplyDeprovisionState(ctx context.Context, cd *hivev1.ClusterDeployment, state string) error {
+	cfg := sm.config()
+	if cd.Annotations == nil {
+		cd.Annotations = map[string]string{}
+	}
+	cd.Annotations[DeprovisionRefAnnotation] = cd.Name + "-deprovision"
+
+	if state == "" {
+		return nil
+	}
+	sm.logger.Info(ctx, "Applying deprovision state %s to ClusterDeployment %s/%s", state, cd.Namespace, cd.Name)
+
+	var stateConfig *config.StateConfig
+	for i := range cfg.DeprovisionStates {
+		if cfg.DeprovisionStates[i].Name == state {
+			stateConfig = &cfg.DeprovisionStates[i]
+			break
+		}
+	}
+	if stateConfig == nil {
+		return errors.Errorf("deprovision state %s not found in configuration", state)
+	}
+
+	cd.Annotations[DeprovisionStateAnnotation] = state
+	for _, condition := range sm.buildConditions(stateConfig.Conditions, metav1.NewTime(sm.clock.Now())) {
+		cd.Status.Conditions = setCondition(cd.Status.Conditions, condition)
+	}
+
+	return nil
+}
+
+// setCondition replaces the condition of the same type, or appends it
+func setCondition(conditions []hivev1.ClusterDeploymentCondition, condition hivev1.ClusterDeploymentCondition) []hivev1.ClusterDeploymentCondition {
+	for i := range conditions {
+		if conditions[i].Type == condition.Type {
+			conditions[i] = condition
+			return conditions
+		}
+	}
+	return append(conditions, condition)
+}
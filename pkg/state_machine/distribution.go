@@ -0,0 +1,28 @@
+package state_machine
+
+import (
+	"time"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+)
+
+// sampleDuration returns how long a resource stays in the given state. The state's own
+// distribution takes precedence over the resource-level one; without either the
+// configured duration is used as is. normal returns standard normally distributed values.
+func sampleDuration(state config.StateConfig, resourceDistribution *config.DelayDistribution, normal func() float64) time.Duration {
+	mean := time.Duration(state.DurationSeconds) * time.Second
+
+	dist := state.Distribution
+	if dist == nil {
+		dist = resourceDistribution
+	}
+	if dist == nil || dist.Type != config.DistributionNormal {
+		return mean
+	}
+
+	sample := mean + time.Duration(normal()*dist.StdDevSeconds*float64(time.Second))
+	if sample < 0 {
+		return 0
+	}
+	return sample
+}
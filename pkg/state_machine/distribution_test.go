@@ -0,0 +1,77 @@
+package state_machine
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"k8s.io/utils/clock"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+)
+
+func TestSampleDuration_PerStateDistribution(t *testing.T) {
+	resourceDistribution := &config.DelayDistribution{Type: config.DistributionNormal, StdDevSeconds: 2}
+	quick := config.StateConfig{
+		Name:            "Pending",
+		DurationSeconds: 1,
+		Distribution:    &config.DelayDistribution{Type: config.DistributionFixed},
+	}
+	variable := config.StateConfig{
+		Name:            "Installing",
+		DurationSeconds: 60,
+		Distribution:    &config.DelayDistribution{Type: config.DistributionNormal, StdDevSeconds: 20},
+	}
+	inherited := config.StateConfig{Name: "Provisioning", DurationSeconds: 30}
+
+	variableSamples := map[time.Duration]bool{}
+	inheritedSamples := map[time.Duration]bool{}
+	for i := 0; i < 100; i++ {
+		// A fixed per-state distribution overrides the resource-level normal one
+		assert.Equal(t, time.Second, sampleDuration(quick, resourceDistribution, rand.NormFloat64))
+
+		sample := sampleDuration(variable, resourceDistribution, rand.NormFloat64)
+		assert.GreaterOrEqual(t, sample, time.Duration(0))
+		variableSamples[sample] = true
+
+		inheritedSamples[sampleDuration(inherited, resourceDistribution, rand.NormFloat64)] = true
+	}
+
+	assert.Greater(t, len(variableSamples), 1, "per-state normal distribution should vary")
+	assert.Greater(t, len(inheritedSamples), 1, "states without a distribution should use the resource-level one")
+}
+
+func TestSampleDuration_DefaultsToFixed(t *testing.T) {
+	state := config.StateConfig{Name: "Pending", DurationSeconds: 3}
+	assert.Equal(t, 3*time.Second, sampleDuration(state, nil, rand.NormFloat64))
+}
+
+func TestClusterDeploymentStateMachine_GetNextState_SamplesPerState(t *testing.T) {
+	cfg := createTestClusterDeploymentConfig()
+	cfg.States[1].Distribution = &config.DelayDistribution{Type: config.DistributionNormal, StdDevSeconds: 10}
+	sm := NewClusterDeploymentStateMachine(createTestLogger(), cfg, clock.RealClock{})
+
+	// A new ClusterDeployment moves to Provisioning, whose duration is sampled
+	durations := map[time.Duration]bool{}
+	for i := 0; i < 50; i++ {
+		state, duration := sm.GetNextState(context.Background(), &hivev1.ClusterDeployment{})
+		assert.Equal(t, "Provisioning", state)
+		durations[duration] = true
+	}
+	assert.Greater(t, len(durations), 1)
+}
+
+func TestClusterDeploymentStateMachine_SetNormal(t *testing.T) {
+	cfg := createTestClusterDeploymentConfig()
+	cfg.States[1].Distribution = &config.DelayDistribution{Type: config.DistributionNormal, StdDevSeconds: 10}
+	sm := NewClusterDeploymentStateMachine(createTestLogger(), cfg, clock.RealClock{})
+	sm.SetNormal(func() float64 { return 1 })
+
+	// The sampled duration is one standard deviation above the configured one
+	_, duration := sm.GetNextState(context.Background(), &hivev1.ClusterDeployment{})
+	assert.Equal(t, time.Duration(cfg.States[1].DurationSeconds+10)*time.Second, duration)
+}
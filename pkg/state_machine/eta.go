@@ -0,0 +1,61 @@
+package state_machine
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	errors "github.com/zgalor/weberr"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+)
+
+// DelayFunc adjusts a configured state duration, e.g. to apply a per-resource delay override
+type DelayFunc func(time.Duration) time.Duration
+
+// remainingDuration sums the time a resource still has to spend in the current and
+// following states before it enters the final configured state, less the time already
+// spent in the current state. Configured durations are used as is, so with a normal
+// delay distribution the actual time can be longer or shorter.
+func remainingDuration(states []config.StateConfig, currentState string, elapsed time.Duration, delay DelayFunc) (time.Duration, error) {
+	start := -1
+	for i, state := range states {
+		if state.Name == currentState {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return 0, errors.Errorf("state %s not found in configuration", currentState)
+	}
+
+	var remaining time.Duration
+	for i := start; i < len(states)-1; i++ {
+		duration := time.Duration(states[i].DurationSeconds) * time.Second
+		if delay != nil {
+			duration = delay(duration)
+		}
+		if i == start {
+			duration = max(duration-elapsed, 0)
+		}
+		remaining += duration
+	}
+	return remaining, nil
+}
+
+// stateElapsed returns how long a resource has been in its current state at now, counted
+// from the latest condition transition or probe, or from its creation when no condition has
+// been set yet. Probes count as every condition is probed when a state is applied, including
+// the ones whose transition time is kept. It is zero when no timestamp is known.
+func stateElapsed(now time.Time, created metav1.Time, transitions ...metav1.Time) time.Duration {
+	entered := created.Time
+	for _, transition := range transitions {
+		if transition.After(entered) {
+			entered = transition.Time
+		}
+	}
+	if entered.IsZero() {
+		return 0
+	}
+	return now.Sub(entered)
+}
@@ -0,0 +1,38 @@
+package state_machine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+)
+
+func TestRemainingDuration(t *testing.T) {
+	states := []config.StateConfig{
+		{Name: "Pending", DurationSeconds: 1},
+		{Name: "Provisioning", DurationSeconds: 4},
+		{Name: "Running", DurationSeconds: 2},
+	}
+
+	remaining, err := remainingDuration(states, "Pending", 0, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, remaining)
+
+	// Time already spent in the current state is subtracted, never going below zero for it
+	remaining, err = remainingDuration(states, "Provisioning", 3*time.Second, nil)
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, remaining)
+	remaining, err = remainingDuration(states, "Pending", 10*time.Second, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 4*time.Second, remaining)
+
+	remaining, err = remainingDuration(states, "Running", 0, nil)
+	require.NoError(t, err)
+	assert.Zero(t, remaining)
+
+	_, err = remainingDuration(states, "Deleted", 0, nil)
+	assert.Error(t, err)
+}
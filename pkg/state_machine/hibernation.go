@@ -0,0 +1,137 @@
+package state_machine
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+)
+
+// ClusterPowerStateResuming is the power state of a ClusterDeployment coming out of
+// hibernation. Hive reports the individual resume steps, the simulator folds them into one.
+const ClusterPowerStateResuming hivev1.ClusterPowerState = "Resuming"
+
+// GetNextPowerState determines the next power state of an installed ClusterDeployment from
+// its requested spec.powerState. It returns an empty state when no transition is due, along
+// with how long until a Stopping or Resuming cluster completes its transition.
+func (sm *ClusterDeploymentStateMachine) GetNextPowerState(cd *hivev1.ClusterDeployment, now time.Time) (hivev1.ClusterPowerState, time.Duration) {
+	cfg := sm.config()
+	desired := cd.Spec.PowerState
+	if desired == "" {
+		desired = hivev1.ClusterPowerStateRunning
+	}
+	current := cd.Status.PowerState
+	if current == "" {
+		current = hivev1.ClusterPowerStateRunning
+	}
+
+	var stoppingDuration, resumingDuration time.Duration
+	if cfg.Hibernation != nil {
+		stoppingDuration = sm.accelerate(time.Duration(cfg.Hibernation.StoppingSeconds) * time.Second)
+		resumingDuration = sm.accelerate(time.Duration(cfg.Hibernation.ResumingSeconds) * time.Second)
+	}
+
+	switch current {
+	case hivev1.ClusterPowerStateRunning:
+		if desired == hivev1.ClusterPowerStateHibernating {
+			return hivev1.ClusterPowerStateStopping, 0
+		}
+	case hivev1.ClusterPowerStateStopping:
+		if desired == hivev1.ClusterPowerStateRunning {
+			return ClusterPowerStateResuming, 0
+		}
+		if remaining := stoppingDuration - powerStateAge(cd, now); remaining > 0 {
+			return "", remaining
+		}
+		return hivev1.ClusterPowerStateHibernating, 0
+	case hivev1.ClusterPowerStateHibernating:
+		if desired == hivev1.ClusterPowerStateRunning {
+			return ClusterPowerStateResuming, 0
+		}
+	case ClusterPowerStateResuming:
+		if desired == hivev1.ClusterPowerStateHibernating {
+			return hivev1.ClusterPowerStateStopping, 0
+		}
+		if remaining := resumingDuration - powerStateAge(cd, now); remaining > 0 {
+			return "", remaining
+		}
+		return hivev1.ClusterPowerStateRunning, 0
+	}
+
+	return "", 0
+}
+
+// PowerStateDuration returns how long the ClusterDeployment stays in a transitional power state
+func (sm *ClusterDeploymentStateMachine) PowerStateDuration(state hivev1.ClusterPowerState) time.Duration {
+	cfg := sm.config()
+	if cfg.Hibernation == nil {
+		return 0
+	}
+	switch state {
+	case hivev1.ClusterPowerStateStopping:
+		return sm.accelerate(time.Duration(cfg.Hibernation.StoppingSeconds) * time.Second)
+	case ClusterPowerStateResuming:
+		return sm.accelerate(time.Duration(cfg.Hibernation.ResumingSeconds) * time.Second)
+	}
+	return 0
+}
+
+// ApplyPowerState applies a power state to the ClusterDeployment, setting the Hibernating,
+// Ready and Unreachable conditions the way Hive reports them
+func (sm *ClusterDeploymentStateMachine) ApplyPowerState(ctx context.Context, cd *hivev1.ClusterDeployment, state hivev1.ClusterPowerState, now time.Time) {
+	sm.logger.Info(ctx, "Applying power state %s to ClusterDeployment %s/%s", state, cd.Namespace, cd.Name)
+
+	cd.Status.PowerState = state
+	transitionTime := metav1.NewTime(now)
+	setPowerCondition := func(conditionType hivev1.ClusterDeploymentConditionType, status corev1.ConditionStatus, reason, message string) {
+		cd.Status.Conditions = setCondition(cd.Status.Conditions, hivev1.ClusterDeploymentCondition{
+			Type:               conditionType,
+			Status:             status,
+			Reason:             reason,
+			Message:            message,
+			LastTransitionTime: transitionTime,
+			LastProbeTime:      transitionTime,
+		})
+	}
+
+	switch state {
+	case hivev1.ClusterPowerStateStopping:
+		setPowerCondition(hivev1.ClusterHibernatingCondition, corev1.ConditionFalse,
+			hivev1.HibernatingReasonStopping, "Cluster is stopping")
+		setPowerCondition(hivev1.ClusterReadyCondition, corev1.ConditionFalse,
+			hivev1.ReadyReasonStoppingOrHibernating, "Cluster is stopping")
+	case hivev1.ClusterPowerStateHibernating:
+		setPowerCondition(hivev1.ClusterHibernatingCondition, corev1.ConditionTrue,
+			hivev1.HibernatingReasonHibernating, "Cluster is hibernating")
+		setPowerCondition(hivev1.ClusterReadyCondition, corev1.ConditionFalse,
+			hivev1.ReadyReasonStoppingOrHibernating, "Cluster is hibernating")
+		setPowerCondition(hivev1.UnreachableCondition, corev1.ConditionTrue,
+			hivev1.HibernatingReasonHibernating, "Cluster is hibernating")
+	case ClusterPowerStateResuming:
+		setPowerCondition(hivev1.ClusterHibernatingCondition, corev1.ConditionFalse,
+			hivev1.HibernatingReasonResumingOrRunning, "Cluster is resuming")
+		setPowerCondition(hivev1.ClusterReadyCondition, corev1.ConditionFalse,
+			hivev1.ReadyReasonStartingMachines, "Cluster is resuming")
+	case hivev1.ClusterPowerStateRunning:
+		setPowerCondition(hivev1.ClusterHibernatingCondition, corev1.ConditionFalse,
+			hivev1.HibernatingReasonResumingOrRunning, "Cluster is running")
+		setPowerCondition(hivev1.ClusterReadyCondition, corev1.ConditionTrue,
+			hivev1.ReadyReasonRunning, "Cluster is running")
+		setPowerCondition(hivev1.UnreachableCondition, corev1.ConditionFalse,
+			"ClusterReachable", "Cluster is reachable")
+	}
+}
+
+// powerStateAge returns how long the ClusterDeployment has been in its current power state,
+// based on the Hibernating condition that every power state transition sets
+func powerStateAge(cd *hivev1.ClusterDeployment, now time.Time) time.Duration {
+	for _, condition := range cd.Status.Conditions {
+		if condition.Type == hivev1.ClusterHibernatingCondition {
+			return now.Sub(condition.LastTransitionTime.Time)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,133 @@
+package state_machine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+)
+
+func findCondition(cd *hivev1.ClusterDeployment, conditionType hivev1.ClusterDeploymentConditionType) *hivev1.ClusterDeploymentCondition {
+	for i := range cd.Status.Conditions {
+		if cd.Status.Conditions[i].Type == conditionType {
+			return &cd.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+func createTestHibernationStateMachine() *ClusterDeploymentStateMachine {
+	cfg := createTestClusterDeploymentConfig()
+	cfg.Hibernation = &config.HibernationConfig{StoppingSeconds: 10, ResumingSeconds: 20}
+	return NewClusterDeploymentStateMachine(createTestLogger(), cfg, clock.RealClock{})
+}
+
+func TestClusterDeploymentStateMachine_Hibernate(t *testing.T) {
+	ctx := context.Background()
+	sm := createTestHibernationStateMachine()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+		Spec: hivev1.ClusterDeploymentSpec{
+			Installed:  true,
+			PowerState: hivev1.ClusterPowerStateHibernating,
+		},
+	}
+	now := time.Now()
+
+	// Running -> Stopping
+	state, _ := sm.GetNextPowerState(cd, now)
+	require.Equal(t, hivev1.ClusterPowerStateStopping, state)
+	sm.ApplyPowerState(ctx, cd, state, now)
+	assert.Equal(t, hivev1.ClusterPowerStateStopping, cd.Status.PowerState)
+	assert.Equal(t, hivev1.HibernatingReasonStopping, findCondition(cd, hivev1.ClusterHibernatingCondition).Reason)
+	assert.Equal(t, corev1.ConditionFalse, findCondition(cd, hivev1.ClusterReadyCondition).Status)
+	assert.Equal(t, 10*time.Second, sm.PowerStateDuration(state))
+
+	// Stays Stopping until stoppingSeconds have passed
+	state, remaining := sm.GetNextPowerState(cd, now.Add(4*time.Second))
+	assert.Empty(t, state)
+	assert.Equal(t, 6*time.Second, remaining)
+
+	// Stopping -> Hibernating
+	later := now.Add(10 * time.Second)
+	state, _ = sm.GetNextPowerState(cd, later)
+	require.Equal(t, hivev1.ClusterPowerStateHibernating, state)
+	sm.ApplyPowerState(ctx, cd, state, later)
+	assert.Equal(t, hivev1.ClusterPowerStateHibernating, cd.Status.PowerState)
+	assert.Equal(t, corev1.ConditionTrue, findCondition(cd, hivev1.ClusterHibernatingCondition).Status)
+	assert.Equal(t, corev1.ConditionTrue, findCondition(cd, hivev1.UnreachableCondition).Status)
+}
+
+func TestClusterDeploymentStateMachine_Resume(t *testing.T) {
+	ctx := context.Background()
+	sm := createTestHibernationStateMachine()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+		Spec: hivev1.ClusterDeploymentSpec{
+			Installed:  true,
+			PowerState: hivev1.ClusterPowerStateRunning,
+		},
+		Status: hivev1.ClusterDeploymentStatus{PowerState: hivev1.ClusterPowerStateHibernating},
+	}
+	now := time.Now()
+
+	// Hibernating -> Resuming
+	state, _ := sm.GetNextPowerState(cd, now)
+	require.Equal(t, ClusterPowerStateResuming, state)
+	sm.ApplyPowerState(ctx, cd, state, now)
+	assert.Equal(t, hivev1.HibernatingReasonResumingOrRunning, findCondition(cd, hivev1.ClusterHibernatingCondition).Reason)
+	assert.Equal(t, corev1.ConditionFalse, findCondition(cd, hivev1.ClusterReadyCondition).Status)
+
+	state, remaining := sm.GetNextPowerState(cd, now.Add(5*time.Second))
+	assert.Empty(t, state)
+	assert.Equal(t, 15*time.Second, remaining)
+
+	// Resuming -> Running
+	later := now.Add(20 * time.Second)
+	state, _ = sm.GetNextPowerState(cd, later)
+	require.Equal(t, hivev1.ClusterPowerStateRunning, state)
+	sm.ApplyPowerState(ctx, cd, state, later)
+	assert.Equal(t, hivev1.ClusterPowerStateRunning, cd.Status.PowerState)
+	assert.Equal(t, corev1.ConditionTrue, findCondition(cd, hivev1.ClusterReadyCondition).Status)
+	assert.Equal(t, corev1.ConditionFalse, findCondition(cd, hivev1.UnreachableCondition).Status)
+}
+
+func TestClusterDeploymentStateMachine_PowerStateAlreadyReached(t *testing.T) {
+	sm := createTestHibernationStateMachine()
+	for _, tc := range []struct {
+		name    string
+		desired hivev1.ClusterPowerState
+		current hivev1.ClusterPowerState
+	}{
+		{name: "running by default", desired: "", current: ""},
+		{name: "running", desired: hivev1.ClusterPowerStateRunning, current: hivev1.ClusterPowerStateRunning},
+		{name: "hibernating", desired: hivev1.ClusterPowerStateHibernating, current: hivev1.ClusterPowerStateHibernating},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cd := &hivev1.ClusterDeployment{
+				Spec:   hivev1.ClusterDeploymentSpec{Installed: true, PowerState: tc.desired},
+				Status: hivev1.ClusterDeploymentStatus{PowerState: tc.current},
+			}
+			state, remaining := sm.GetNextPowerState(cd, time.Now())
+			assert.Empty(t, state)
+			assert.Zero(t, remaining)
+		})
+	}
+}
+
+func TestClusterDeploymentStateMachine_PowerStateAccelerated(t *testing.T) {
+	sm := createTestHibernationStateMachine()
+	sm.SetAccelerate(func(duration time.Duration) time.Duration { return duration / 10 })
+
+	assert.Equal(t, time.Second, sm.PowerStateDuration(hivev1.ClusterPowerStateStopping))
+	assert.Equal(t, 2*time.Second, sm.PowerStateDuration(ClusterPowerStateResuming))
+}
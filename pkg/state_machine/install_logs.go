@@ -0,0 +1,73 @@
+package state_machine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+)
+
+// InstallLogsGatheredCondition is set once the install logs of an installed or failed
+// ClusterDeployment have been gathered
+const InstallLogsGatheredCondition hivev1.ClusterDeploymentConditionType = "InstallLogsGathered"
+
+// IsFailed returns true if the ClusterDeployment has failed to provision
+func IsFailed(cd *hivev1.ClusterDeployment) bool {
+	return cd.Status.ProvisionRef != nil && strings.HasSuffix(cd.Status.ProvisionRef.Name, "-provision-failed")
+}
+
+// GetInstallLogsDelay returns whether the install logs of an installed or failed
+// ClusterDeployment are due to be gathered, or how long until they are. Nothing is due
+// when log gathering is not configured or the logs have already been gathered.
+func (sm *ClusterDeploymentStateMachine) GetInstallLogsDelay(cd *hivev1.ClusterDeployment, now time.Time) (bool, time.Duration) {
+	cfg := sm.config()
+	if cfg.InstallLogs == nil || (!cd.Spec.Installed && !IsFailed(cd)) {
+		return false, 0
+	}
+
+	// Installed clusters finished at install time, failed ones at their latest condition
+	var finished time.Time
+	for _, condition := range cd.Status.Conditions {
+		if condition.Type == InstallLogsGatheredCondition {
+			return false, 0
+		}
+		if condition.LastTransitionTime.After(finished) {
+			finished = condition.LastTransitionTime.Time
+		}
+	}
+	if cd.Status.InstalledTimestamp != nil {
+		finished = cd.Status.InstalledTimestamp.Time
+	}
+
+	delay := sm.accelerate(time.Duration(cfg.InstallLogs.DelaySeconds) * time.Second)
+	if remaining := delay - now.Sub(finished); remaining > 0 {
+		return false, remaining
+	}
+	return true, 0
+}
+
+// ApplyInstallLogsGathered sets the InstallLogsGathered condition, referencing the logs
+// location when one is configured
+func (sm *ClusterDeploymentStateMachine) ApplyInstallLogsGathered(ctx context.Context, cd *hivev1.ClusterDeployment, now time.Time) {
+	sm.logger.Info(ctx, "Gathered install logs of ClusterDeployment %s/%s", cd.Namespace, cd.Name)
+
+	message := "Install logs have been gathered"
+	if location := sm.config().InstallLogs.Location; location != "" {
+		message = fmt.Sprintf("Install logs have been gathered to %s/%s/%s", strings.TrimSuffix(location, "/"), cd.Namespace, cd.Name)
+	}
+
+	transitionTime := metav1.NewTime(now)
+	cd.Status.Conditions = setCondition(cd.Status.Conditions, hivev1.ClusterDeploymentCondition{
+		Type:               InstallLogsGatheredCondition,
+		Status:             corev1.ConditionTrue,
+		Reason:             "InstallLogsGathered",
+		Message:            message,
+		LastTransitionTime: transitionTime,
+		LastProbeTime:      transitionTime,
+	})
+}
@@ -0,0 +1,98 @@
+package state_machine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+)
+
+func TestClusterDeploymentStateMachine_InstallLogs(t *testing.T) {
+	ctx := context.Background()
+	cfg := createTestClusterDeploymentConfig()
+	cfg.InstallLogs = &config.InstallLogsConfig{DelaySeconds: 30, Location: "s3://install-logs/"}
+	sm := NewClusterDeploymentStateMachine(createTestLogger(), cfg, clock.RealClock{})
+
+	installedAt := time.Now()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+		Spec:       hivev1.ClusterDeploymentSpec{Installed: true},
+		Status: hivev1.ClusterDeploymentStatus{
+			InstalledTimestamp: &metav1.Time{Time: installedAt},
+		},
+	}
+
+	due, remaining := sm.GetInstallLogsDelay(cd, installedAt.Add(10*time.Second))
+	assert.False(t, due)
+	assert.Equal(t, 20*time.Second, remaining)
+
+	now := installedAt.Add(30 * time.Second)
+	due, _ = sm.GetInstallLogsDelay(cd, now)
+	require.True(t, due)
+
+	sm.ApplyInstallLogsGathered(ctx, cd, now)
+	condition := findCondition(cd, InstallLogsGatheredCondition)
+	require.NotNil(t, condition)
+	assert.Equal(t, corev1.ConditionTrue, condition.Status)
+	assert.Equal(t, "Install logs have been gathered to s3://install-logs/default/test-cluster", condition.Message)
+
+	// Logs are only gathered once
+	due, remaining = sm.GetInstallLogsDelay(cd, now.Add(time.Hour))
+	assert.False(t, due)
+	assert.Zero(t, remaining)
+}
+
+func TestClusterDeploymentStateMachine_InstallLogsAfterFailure(t *testing.T) {
+	cfg := createTestClusterDeploymentConfig()
+	cfg.InstallLogs = &config.InstallLogsConfig{DelaySeconds: 5}
+	sm := NewClusterDeploymentStateMachine(createTestLogger(), cfg, clock.RealClock{})
+
+	failedAt := time.Now()
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+		Status: hivev1.ClusterDeploymentStatus{
+			ProvisionRef: &corev1.LocalObjectReference{Name: "test-cluster-provision-failed"},
+			Conditions: []hivev1.ClusterDeploymentCondition{
+				{Type: "ProvisionFailed", Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(failedAt)},
+			},
+		},
+	}
+
+	due, remaining := sm.GetInstallLogsDelay(cd, failedAt.Add(2*time.Second))
+	assert.False(t, due)
+	assert.Equal(t, 3*time.Second, remaining)
+
+	due, _ = sm.GetInstallLogsDelay(cd, failedAt.Add(5*time.Second))
+	assert.True(t, due)
+}
+
+func TestClusterDeploymentStateMachine_InstallLogsNotDue(t *testing.T) {
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+		Spec:       hivev1.ClusterDeploymentSpec{Installed: true},
+	}
+
+	// Not configured
+	sm := NewClusterDeploymentStateMachine(createTestLogger(), createTestClusterDeploymentConfig(), clock.RealClock{})
+	due, remaining := sm.GetInstallLogsDelay(cd, time.Now())
+	assert.False(t, due)
+	assert.Zero(t, remaining)
+
+	// Still provisioning
+	cfg := createTestClusterDeploymentConfig()
+	cfg.InstallLogs = &config.InstallLogsConfig{}
+	sm = NewClusterDeploymentStateMachine(createTestLogger(), cfg, clock.RealClock{})
+	cd.Spec.Installed = false
+	due, remaining = sm.GetInstallLogsDelay(cd, time.Now())
+	assert.False(t, due)
+	assert.Zero(t, remaining)
+}
@@ -0,0 +1,23 @@
+package state_machine
+
+import (
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+)
+
+// InstallPhaseAnnotation reports the install phase of a ClusterDeployment the way Hive does,
+// giving finer-grained progress than the state name
+const InstallPhaseAnnotation = "hive.openshift.io/install-phase"
+
+// applyInstallPhase sets the install phase annotation to the phase configured for the state,
+// or removes it if the state has none
+func (sm *ClusterDeploymentStateMachine) applyInstallPhase(cd *hivev1.ClusterDeployment, state string) {
+	phase, ok := sm.config().InstallPhases[state]
+	if !ok {
+		delete(cd.Annotations, InstallPhaseAnnotation)
+		return
+	}
+	if cd.Annotations == nil {
+		cd.Annotations = map[string]string{}
+	}
+	cd.Annotations[InstallPhaseAnnotation] = phase
+}
@@ -0,0 +1,27 @@
+package state_machine
+
+import (
+	"hash/fnv"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// startJitter returns how much longer a new resource waits before its first transition.
+// Each resource gets a fixed offset in [0, window) derived from its name, counted from its
+// creation, so resources created together start progressing at different times.
+func startJitter(obj metav1.Object, window time.Duration, now time.Time) time.Duration {
+	if window <= 0 {
+		return 0
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(obj.GetNamespace() + "/" + obj.GetName()))
+	offset := time.Duration(hash.Sum32()) * time.Millisecond % window
+
+	remaining := obj.GetCreationTimestamp().Add(offset).Sub(now)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
@@ -0,0 +1,69 @@
+package state_machine
+
+import (
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+
+	aaov1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/aws-account-operator/v1alpha1"
+	gcpv1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/gcp-project-operator/v1alpha1"
+)
+
+// HasState returns true if the state is part of the configured progression of the
+// ClusterDeployment
+func (sm *ClusterDeploymentStateMachine) HasState(cd *hivev1.ClusterDeployment, state string) bool {
+	return findState(sm.states(cd), state) != nil
+}
+
+// IsTerminal returns true if the ClusterDeployment has finished installing or has failed
+func (sm *ClusterDeploymentStateMachine) IsTerminal(cd *hivev1.ClusterDeployment) bool {
+	return cd.Spec.Installed || IsFailed(cd)
+}
+
+// Rewind drops the installation result of the ClusterDeployment, so that an earlier state
+// applied afterwards is the one it is in
+func (sm *ClusterDeploymentStateMachine) Rewind(cd *hivev1.ClusterDeployment) {
+	cd.Spec.Installed = false
+	cd.Status.InstalledTimestamp = nil
+	cd.Status.ProvisionRef = nil
+	cd.Status.Conditions = nil
+	delete(cd.Annotations, StateAnnotation)
+}
+
+// RewindForReinstall rewinds the ClusterDeployment and also drops what the previous
+// installation left in its spec and status: the cluster metadata with its admin credential
+// references, the install phase, the console and API URLs and the power state. A reinstall
+// then starts from a ClusterDeployment that was never installed.
+func (sm *ClusterDeploymentStateMachine) RewindForReinstall(cd *hivev1.ClusterDeployment) {
+	sm.Rewind(cd)
+	cd.Spec.ClusterMetadata = nil
+	cd.Status.WebConsoleURL = ""
+	cd.Status.APIURL = ""
+	cd.Status.PowerState = ""
+	delete(cd.Annotations, InstallPhaseAnnotation)
+}
+
+// IsReinstallRequested returns true if spec.installed of an installed ClusterDeployment was set
+// back to false, asking for it to be provisioned again. Its installation result, a completed
+// condition or install time, is still there as nothing but the simulator removes it.
+func (sm *ClusterDeploymentStateMachine) IsReinstallRequested(cd *hivev1.ClusterDeployment) bool {
+	return !cd.Spec.Installed && (cd.Status.InstalledTimestamp != nil || ClusterDeploymentState(cd) == "Running")
+}
+
+// HasState returns true if the state is part of the configured progression of AccountClaims
+func (sm *AccountClaimStateMachine) HasState(state string) bool {
+	return findState(sm.config().States, state) != nil
+}
+
+// IsTerminal returns true if the AccountClaim is Ready or in Error
+func (sm *AccountClaimStateMachine) IsTerminal(ac *aaov1alpha1.AccountClaim) bool {
+	return ac.Status.State == aaov1alpha1.ClaimStatusReady || ac.Status.State == aaov1alpha1.ClaimStatusError
+}
+
+// HasState returns true if the state is part of the configured progression of ProjectClaims
+func (sm *ProjectClaimStateMachine) HasState(state string) bool {
+	return findState(sm.config().States, state) != nil
+}
+
+// IsTerminal returns true if the ProjectClaim is Ready or in Error
+func (sm *ProjectClaimStateMachine) IsTerminal(pc *gcpv1alpha1.ProjectClaim) bool {
+	return pc.Status.State == gcpv1alpha1.ClaimStatusReady || pc.Status.State == gcpv1alpha1.ClaimStatusError
+}
@@ -3,10 +3,12 @@ package state_machine
 import (
 	"context"
 	"fmt"
+	"math/rand"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
 
 	"github.com/openshift-online/ocm-sdk-go/logging"
 
@@ -16,44 +18,85 @@ import (
 
 // ProjectClaimStateMachine manages ProjectClaim state transitions
 type ProjectClaimStateMachine struct {
-	logger logging.Logger
-	config *config.ProjectClaimConfig
+	logger       logging.Logger
+	configSource func() *config.ProjectClaimConfig
+	clock        clock.Clock
+	roll         func() float64
+	normal       func() float64
+	accelerate   func(time.Duration) time.Duration
 }
 
-// NewProjectClaimStateMachine creates a new ProjectClaim state machine
-func NewProjectClaimStateMachine(logger logging.Logger, cfg *config.ProjectClaimConfig) *ProjectClaimStateMachine {
+// NewProjectClaimStateMachine creates a new ProjectClaim state machine. The clock stamps the
+// conditions it sets and the times it measures, the real clock is used when it is nil.
+func NewProjectClaimStateMachine(logger logging.Logger, cfg *config.ProjectClaimConfig, clk clock.Clock) *ProjectClaimStateMachine {
+	if clk == nil {
+		clk = clock.RealClock{}
+	}
 	return &ProjectClaimStateMachine{
-		logger: logger,
-		config: cfg,
+		logger:       logger,
+		configSource: func() *config.ProjectClaimConfig { return cfg },
+		clock:        clk,
+		roll:         rand.Float64,
+		normal:       rand.NormFloat64,
+		accelerate:   unaccelerated,
 	}
 }
 
+// SetRoll makes the state machine roll the probabilities of its transitions with roll instead
+// of the unseeded global source
+func (sm *ProjectClaimStateMachine) SetRoll(roll func() float64) {
+	sm.roll = roll
+}
+
+// SetNormal makes the state machine draw the normally distributed durations of its states
+// from normal instead of the unseeded global source
+func (sm *ProjectClaimStateMachine) SetNormal(normal func() float64) {
+	sm.normal = normal
+}
+
+// SetAccelerate makes the state machine scale the start jitter of ProjectClaims with accelerate
+func (sm *ProjectClaimStateMachine) SetAccelerate(accelerate func(time.Duration) time.Duration) {
+	sm.accelerate = accelerate
+}
+
+// SetConfigSource makes the state machine read its configuration from source on every use,
+// so that updated states and jitter apply to ProjectClaims already in progress
+func (sm *ProjectClaimStateMachine) SetConfigSource(source func() *config.ProjectClaimConfig) {
+	sm.configSource = source
+}
+
+// config returns the current configuration
+func (sm *ProjectClaimStateMachine) config() *config.ProjectClaimConfig {
+	return sm.configSource()
+}
+
 // GetNextState determines the next state for a ProjectClaim
 func (sm *ProjectClaimStateMachine) GetNextState(ctx context.Context, pc *gcpv1alpha1.ProjectClaim) (gcpv1alpha1.ClaimStatus, time.Duration) {
+	cfg := sm.config()
 	currentState := pc.Status.State
 	sm.logger.Debug(ctx, "Current ProjectClaim state for %s/%s: %s", pc.Namespace, pc.Name, currentState)
 
 	// Find current state in config
-	for i, state := range sm.config.States {
+	for i, state := range cfg.States {
 		if string(currentState) == state.Name || (currentState == "" && state.Name == "Pending") {
-			// If this is the last state, stay here
-			if i >= len(sm.config.States)-1 {
+			// If this is the final state, stay here
+			nextState := followingState(cfg.States, i, pc.Labels, sm.roll)
+			if nextState == nil {
 				sm.logger.Debug(ctx, "ProjectClaim %s/%s is in final state: %s", pc.Namespace, pc.Name, state.Name)
 				return gcpv1alpha1.ClaimStatus(state.Name), 0
 			}
 
 			// Return next state and its duration
-			nextState := sm.config.States[i+1]
-			duration := time.Duration(nextState.DurationSeconds) * time.Second
+			duration := sampleDuration(*nextState, cfg.DelayDistribution, sm.normal)
 			sm.logger.Debug(ctx, "Next state for ProjectClaim %s/%s: %s (duration: %v)", pc.Namespace, pc.Name, nextState.Name, duration)
 			return gcpv1alpha1.ClaimStatus(nextState.Name), duration
 		}
 	}
 
 	// Default to first state
-	if len(sm.config.States) > 0 {
-		firstState := sm.config.States[0]
-		duration := time.Duration(firstState.DurationSeconds) * time.Second
+	if len(cfg.States) > 0 {
+		firstState := cfg.States[0]
+		duration := sampleDuration(firstState, cfg.DelayDistribution, sm.normal)
 		sm.logger.Debug(ctx, "ProjectClaim %s/%s has no current state, starting with: %s", pc.Namespace, pc.Name, firstState.Name)
 		return gcpv1alpha1.ClaimStatus(firstState.Name), duration
 	}
@@ -61,74 +104,155 @@ func (sm *ProjectClaimStateMachine) GetNextState(ctx context.Context, pc *gcpv1a
 	return gcpv1alpha1.ClaimStatusPending, 4 * time.Second
 }
 
-// ApplyState applies a state to the ProjectClaim
-func (sm *ProjectClaimStateMachine) ApplyState(ctx context.Context, pc *gcpv1alpha1.ProjectClaim, state gcpv1alpha1.ClaimStatus) error {
+// ApplyState applies a state to the ProjectClaim. Conditions come from the state
+// configuration, then the default conditions of the resource type, then the built-in
+// conditions of the state. specChanged reports whether the spec was changed as well as the
+// status.
+func (sm *ProjectClaimStateMachine) ApplyState(ctx context.Context, pc *gcpv1alpha1.ProjectClaim, state gcpv1alpha1.ClaimStatus) (specChanged bool, err error) {
+	cfg := sm.config()
 	sm.logger.Info(ctx, "Applying state %s to ProjectClaim %s/%s", state, pc.Namespace, pc.Name)
 
 	pc.Status.State = state
 
-	now := metav1.Now()
+	now := metav1.NewTime(sm.clock.Now())
 
 	// Update conditions based on state
+	previous := pc.Status.Conditions
+	stateConfig := findState(cfg.States, string(state))
+	if conditionConfigs := stateConditions(stateConfig, cfg.Defaults); len(conditionConfigs) > 0 {
+		pc.Status.Conditions = sm.buildConditions(conditionConfigs, now)
+	} else if conditions := defaultProjectClaimConditions(state, now); conditions != nil {
+		pc.Status.Conditions = conditions
+	}
+	keepProjectClaimTransitionTimes(previous, pc.Status.Conditions)
+
+	// Simulate GCP project ID, set once the project is being created
+	switch state {
+	case gcpv1alpha1.ClaimStatusPendingProject, gcpv1alpha1.ClaimStatusReady:
+		if pc.Spec.GCPProjectID == "" {
+			pc.Spec.GCPProjectID = fmt.Sprintf("project-%s-%d", pc.Name, sm.clock.Now().UTC().Unix()%10000)
+			specChanged = true
+		}
+	}
+
+	return specChanged, nil
+}
+
+// keepProjectClaimTransitionTimes keeps the LastTransitionTime of the conditions whose status is
+// the same as in the previous conditions, only their LastProbeTime moves
+func keepProjectClaimTransitionTimes(previous, conditions []gcpv1alpha1.Condition) {
+	for i := range conditions {
+		for _, prev := range previous {
+			if prev.Type == conditions[i].Type && prev.Status == conditions[i].Status && !prev.LastTransitionTime.IsZero() {
+				conditions[i].LastTransitionTime = prev.LastTransitionTime
+			}
+		}
+	}
+}
+
+// buildConditions builds the conditions of a state from their configuration
+func (sm *ProjectClaimStateMachine) buildConditions(conditionConfigs []config.ConditionConfig, now metav1.Time) []gcpv1alpha1.Condition {
+	conditions := []gcpv1alpha1.Condition{}
+
+	for _, condConfig := range conditionConfigs {
+		condition := gcpv1alpha1.Condition{
+			Type:               gcpv1alpha1.ConditionType(condConfig.Type),
+			Status:             conditionStatus(condConfig.Status),
+			Reason:             condConfig.Reason,
+			Message:            condConfig.Message,
+			LastTransitionTime: now,
+			LastProbeTime:      now,
+		}
+		conditions = append(conditions, condition)
+	}
+
+	return conditions
+}
+
+// defaultProjectClaimConditions returns the conditions of a state that has none configured,
+// nil for states without defaults, which keep their current conditions
+func defaultProjectClaimConditions(state gcpv1alpha1.ClaimStatus, now metav1.Time) []gcpv1alpha1.Condition {
+	var condition gcpv1alpha1.Condition
 	switch state {
 	case gcpv1alpha1.ClaimStatusPending:
-		pc.Status.Conditions = []gcpv1alpha1.Condition{
-			{
-				Type:               gcpv1alpha1.ConditionType("Pending"),
-				Status:             corev1.ConditionTrue,
-				Reason:             "ProjectPending",
-				Message:            "Project claim is pending",
-				LastTransitionTime: now,
-				LastProbeTime:      now,
-			},
+		condition = gcpv1alpha1.Condition{
+			Type:    gcpv1alpha1.ConditionType("Pending"),
+			Reason:  "ProjectPending",
+			Message: "Project claim is pending",
 		}
 
 	case gcpv1alpha1.ClaimStatusPendingProject:
-		pc.Status.Conditions = []gcpv1alpha1.Condition{
-			{
-				Type:               gcpv1alpha1.ConditionType("PendingProject"),
-				Status:             corev1.ConditionTrue,
-				Reason:             "ProjectCreating",
-				Message:            "GCP project is being created",
-				LastTransitionTime: now,
-				LastProbeTime:      now,
-			},
-		}
-		// Simulate GCP project ID
-		if pc.Spec.GCPProjectID == "" {
-			pc.Spec.GCPProjectID = fmt.Sprintf("project-%s-%d", pc.Name, time.Now().UTC().Unix()%10000)
+		condition = gcpv1alpha1.Condition{
+			Type:    gcpv1alpha1.ConditionType("PendingProject"),
+			Reason:  "ProjectCreating",
+			Message: "GCP project is being created",
 		}
 
 	case gcpv1alpha1.ClaimStatusReady:
-		pc.Status.Conditions = []gcpv1alpha1.Condition{
-			{
-				Type:               gcpv1alpha1.ConditionType("Ready"),
-				Status:             corev1.ConditionTrue,
-				Reason:             "ProjectReady",
-				Message:            "GCP project is ready",
-				LastTransitionTime: now,
-				LastProbeTime:      now,
-			},
-		}
-		// Ensure GCP project ID is set
-		if pc.Spec.GCPProjectID == "" {
-			pc.Spec.GCPProjectID = fmt.Sprintf("project-%s-%d", pc.Name, time.Now().UTC().Unix()%10000)
+		condition = gcpv1alpha1.Condition{
+			Type:    gcpv1alpha1.ConditionType("Ready"),
+			Reason:  "ProjectReady",
+			Message: "GCP project is ready",
 		}
 
 	case gcpv1alpha1.ClaimStatusError:
-		pc.Status.Conditions = []gcpv1alpha1.Condition{
-			{
-				Type:               gcpv1alpha1.ConditionType("Error"),
-				Status:             corev1.ConditionTrue,
-				Reason:             "ClaimFailed",
-				Message:            "Project claim failed",
-				LastTransitionTime: now,
-				LastProbeTime:      now,
-			},
+		condition = gcpv1alpha1.Condition{
+			Type:    gcpv1alpha1.ConditionType("Error"),
+			Reason:  "ClaimFailed",
+			Message: "Project claim failed",
 		}
+
+	default:
+		return nil
 	}
 
-	return nil
+	condition.Status = corev1.ConditionTrue
+	condition.LastTransitionTime = now
+	condition.LastProbeTime = now
+	return []gcpv1alpha1.Condition{condition}
+}
+
+// EstimateRemaining returns the current state of the ProjectClaim and the estimated
+// time until it reaches its final state, including any start jitter still to wait out.
+// Errored ProjectClaims have nothing remaining. An error is returned, along with the state,
+// when the current state is not configured.
+func (sm *ProjectClaimStateMachine) EstimateRemaining(pc *gcpv1alpha1.ProjectClaim, delay DelayFunc) (string, time.Duration, error) {
+	currentState := pc.Status.State
+	if currentState == gcpv1alpha1.ClaimStatusError {
+		return string(currentState), 0, nil
+	}
+	if currentState == "" {
+		currentState = gcpv1alpha1.ClaimStatusPending
+	}
+
+	transitions := make([]metav1.Time, 0, 2*len(pc.Status.Conditions))
+	for _, condition := range pc.Status.Conditions {
+		transitions = append(transitions, condition.LastTransitionTime, condition.LastProbeTime)
+	}
+	elapsed := stateElapsed(sm.clock.Now(), pc.CreationTimestamp, transitions...)
+
+	remaining, err := remainingDuration(sm.config().States, string(currentState), elapsed, delay)
+	if err != nil {
+		return string(currentState), 0, err
+	}
+	return string(currentState), sm.StartDelay(pc) + remaining, nil
+}
+
+// CurrentState returns the state of the ProjectClaim, Pending until a state has been applied
+func (sm *ProjectClaimStateMachine) CurrentState(pc *gcpv1alpha1.ProjectClaim) string {
+	if pc.Status.State == "" {
+		return string(gcpv1alpha1.ClaimStatusPending)
+	}
+	return string(pc.Status.State)
+}
+
+// StartDelay returns how long a ProjectClaim that has no state yet should wait before its
+// first transition
+func (sm *ProjectClaimStateMachine) StartDelay(pc *gcpv1alpha1.ProjectClaim) time.Duration {
+	if pc.Status.State != "" {
+		return 0
+	}
+	return startJitter(pc, sm.accelerate(time.Duration(sm.config().StartJitterSeconds)*time.Second), sm.clock.Now())
 }
 
 // ApplyFailure applies a failure state to the ProjectClaim
@@ -137,7 +261,7 @@ func (sm *ProjectClaimStateMachine) ApplyFailure(ctx context.Context, pc *gcpv1a
 
 	pc.Status.State = gcpv1alpha1.ClaimStatusError
 
-	now := metav1.Now()
+	now := metav1.NewTime(sm.clock.Now())
 	condition := gcpv1alpha1.Condition{
 		Type:               gcpv1alpha1.ConditionType(failure.Condition),
 		Status:             corev1.ConditionTrue,
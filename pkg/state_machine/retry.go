@@ -0,0 +1,78 @@
+package state_machine
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+
+	aaov1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/aws-account-operator/v1alpha1"
+	gcpv1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/gcp-project-operator/v1alpha1"
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+)
+
+// ApplyTransientFailure sets the condition of a transient failure on the ClusterDeployment and
+// counts an install restart. The ClusterDeployment stays in its current state to retry, and
+// the condition is replaced by those of the next state it reaches.
+func (sm *ClusterDeploymentStateMachine) ApplyTransientFailure(ctx context.Context, cd *hivev1.ClusterDeployment, failure *config.FailureScenario) {
+	sm.logger.Warn(ctx, "Applying transient failure to ClusterDeployment %s/%s: %s - %s", cd.Namespace, cd.Name, failure.Reason, failure.Message)
+
+	transitionTime := metav1.NewTime(sm.clock.Now())
+	cd.Status.Conditions = setCondition(cd.Status.Conditions, hivev1.ClusterDeploymentCondition{
+		Type:               hivev1.ClusterDeploymentConditionType(failure.Condition),
+		Status:             corev1.ConditionTrue,
+		Reason:             failure.Reason,
+		Message:            failure.Message,
+		LastTransitionTime: transitionTime,
+		LastProbeTime:      transitionTime,
+	})
+	cd.Status.InstallRestarts++
+}
+
+// ApplyTransientFailure sets the condition of a transient failure on the AccountClaim, which
+// stays in its current state to retry
+func (sm *AccountClaimStateMachine) ApplyTransientFailure(ctx context.Context, ac *aaov1alpha1.AccountClaim, failure *config.FailureScenario) {
+	sm.logger.Warn(ctx, "Applying transient failure to AccountClaim %s/%s: %s - %s", ac.Namespace, ac.Name, failure.Reason, failure.Message)
+
+	transitionTime := metav1.NewTime(sm.clock.Now())
+	condition := aaov1alpha1.AccountClaimCondition{
+		Type:               aaov1alpha1.AccountClaimConditionType(failure.Condition),
+		Status:             corev1.ConditionTrue,
+		Reason:             failure.Reason,
+		Message:            failure.Message,
+		LastTransitionTime: transitionTime,
+		LastProbeTime:      transitionTime,
+	}
+	for i := range ac.Status.Conditions {
+		if ac.Status.Conditions[i].Type == condition.Type {
+			ac.Status.Conditions[i] = condition
+			return
+		}
+	}
+	ac.Status.Conditions = append(ac.Status.Conditions, condition)
+}
+
+// ApplyTransientFailure sets the condition of a transient failure on the ProjectClaim, which
+// stays in its current state to retry
+func (sm *ProjectClaimStateMachine) ApplyTransientFailure(ctx context.Context, pc *gcpv1alpha1.ProjectClaim, failure *config.FailureScenario) {
+	sm.logger.Warn(ctx, "Applying transient failure to ProjectClaim %s/%s: %s - %s", pc.Namespace, pc.Name, failure.Reason, failure.Message)
+
+	transitionTime := metav1.NewTime(sm.clock.Now())
+	condition := gcpv1alpha1.Condition{
+		Type:               gcpv1alpha1.ConditionType(failure.Condition),
+		Status:             corev1.ConditionTrue,
+		Reason:             failure.Reason,
+		Message:            failure.Message,
+		LastTransitionTime: transitionTime,
+		LastProbeTime:      transitionTime,
+	}
+	for i := range pc.Status.Conditions {
+		if pc.Status.Conditions[i].Type == condition.Type {
+			pc.Status.Conditions[i] = condition
+			return
+		}
+	}
+	pc.Status.Conditions = append(pc.Status.Conditions, condition)
+}
@@ -0,0 +1,11 @@
+package state_machine
+
+import (
+	"time"
+)
+
+// unaccelerated is the default acceleration of the state machines, leaving delays as
+// configured
+func unaccelerated(duration time.Duration) time.Duration {
+	return duration
+}
@@ -0,0 +1,112 @@
+package state_machine
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+)
+
+const (
+	// StuckUntilAnnotation records until when a ClusterDeployment is held in Provisioning by a
+	// stuck failure: an RFC 3339 time, "never", or "recovered" once the failure has cleared
+	StuckUntilAnnotation = "hive-simulator.openshift.io/stuck-until"
+
+	// StuckConditionAnnotation records the condition type set by the stuck failure
+	StuckConditionAnnotation = "hive-simulator.openshift.io/stuck-condition"
+
+	stuckNever     = "never"
+	stuckRecovered = "recovered"
+)
+
+// StuckStatus describes where a ClusterDeployment is in a stuck failure
+type StuckStatus int
+
+const (
+	// NotStuck means no stuck failure has been applied
+	NotStuck StuckStatus = iota
+
+	// Stuck means the ClusterDeployment must stay where it is
+	Stuck
+
+	// StuckRecoveryDue means the recovery delay has passed but the failure is still set
+	StuckRecoveryDue
+
+	// StuckRecovered means a stuck failure was applied and has cleared
+	StuckRecovered
+)
+
+// GetStuckStatus returns the stuck status of the ClusterDeployment and, while it is stuck
+// with a recovery delay, how long until it recovers
+func (sm *ClusterDeploymentStateMachine) GetStuckStatus(cd *hivev1.ClusterDeployment, now time.Time) (StuckStatus, time.Duration) {
+	value, ok := cd.Annotations[StuckUntilAnnotation]
+	switch {
+	case !ok:
+		return NotStuck, 0
+	case value == stuckRecovered:
+		return StuckRecovered, 0
+	case value == stuckNever:
+		return Stuck, 0
+	}
+
+	until, err := time.Parse(time.RFC3339, value)
+	if err != nil || !now.Before(until) {
+		return StuckRecoveryDue, 0
+	}
+	return Stuck, until.Sub(now)
+}
+
+// ApplyStuck holds the ClusterDeployment in Provisioning with the failure condition set,
+// recording when it recovers
+func (sm *ClusterDeploymentStateMachine) ApplyStuck(ctx context.Context, cd *hivev1.ClusterDeployment, failure *config.FailureScenario, now time.Time) {
+	sm.logger.Warn(ctx, "ClusterDeployment %s/%s is stuck in Provisioning: %s - %s", cd.Namespace, cd.Name, failure.Reason, failure.Message)
+
+	if cd.Status.ProvisionRef == nil {
+		cd.Status.ProvisionRef = &corev1.LocalObjectReference{
+			Name: cd.Name + "-provision",
+		}
+	}
+
+	transitionTime := metav1.NewTime(now)
+	cd.Status.Conditions = setCondition(cd.Status.Conditions, hivev1.ClusterDeploymentCondition{
+		Type:               hivev1.ClusterDeploymentConditionType(failure.Condition),
+		Status:             corev1.ConditionTrue,
+		Reason:             failure.Reason,
+		Message:            failure.Message,
+		LastTransitionTime: transitionTime,
+		LastProbeTime:      transitionTime,
+	})
+
+	until := stuckNever
+	if failure.RecoverAfterSeconds > 0 {
+		until = now.Add(sm.accelerate(time.Duration(failure.RecoverAfterSeconds) * time.Second)).UTC().Format(time.RFC3339)
+	}
+	if cd.Annotations == nil {
+		cd.Annotations = map[string]string{}
+	}
+	cd.Annotations[StuckUntilAnnotation] = until
+	cd.Annotations[StuckConditionAnnotation] = failure.Condition
+}
+
+// RecoverStuck clears the failure condition of a stuck ClusterDeployment so that it
+// continues provisioning, and marks it as recovered so the failure is not applied again
+func (sm *ClusterDeploymentStateMachine) RecoverStuck(ctx context.Context, cd *hivev1.ClusterDeployment) {
+	sm.logger.Info(ctx, "ClusterDeployment %s/%s recovered from stuck failure", cd.Namespace, cd.Name)
+
+	conditionType := hivev1.ClusterDeploymentConditionType(cd.Annotations[StuckConditionAnnotation])
+	conditions := cd.Status.Conditions[:0]
+	for _, condition := range cd.Status.Conditions {
+		if condition.Type != conditionType {
+			conditions = append(conditions, condition)
+		}
+	}
+	cd.Status.Conditions = conditions
+
+	cd.Annotations[StuckUntilAnnotation] = stuckRecovered
+	delete(cd.Annotations, StuckConditionAnnotation)
+}
@@ -0,0 +1,54 @@
+package state_machine
+
+import (
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+)
+
+// StateAnnotation records the state of a ClusterDeployment whose conditions do not identify
+// it, such as a state only reached through a transition
+const StateAnnotation = "hive-simulator.openshift.io/state"
+
+// followingState returns the state that follows states[current]: the target of the first of its
+// transitions that applies to a resource with the given labels, or the following state in the
+// list when none does. It returns nil when states[current] is the final state and none of its
+// transitions applies. Probabilities are rolled with roll, which returns values in [0, 1).
+func followingState(states []config.StateConfig, current int, labels map[string]string, roll func() float64) *config.StateConfig {
+	for _, transition := range states[current].Next {
+		if !transitionApplies(transition, labels, roll) {
+			continue
+		}
+		if target := findState(states, transition.To); target != nil {
+			return target
+		}
+	}
+	if current >= len(states)-1 {
+		return nil
+	}
+	return &states[current+1]
+}
+
+// transitionApplies returns true if the resource carries every label of the transition and
+// the transition wins its probability roll. A transition without conditions always applies.
+func transitionApplies(transition config.TransitionConfig, labels map[string]string, roll func() float64) bool {
+	for key, value := range transition.Labels {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return transition.Probability <= 0 || roll() < transition.Probability
+}
+
+// recordState sets the state annotation when the ClusterDeployment would not be found in the
+// applied state otherwise, and removes it when it would
+func recordState(cd *hivev1.ClusterDeployment, state string) {
+	delete(cd.Annotations, StateAnnotation)
+	if ClusterDeploymentState(cd) == state {
+		return
+	}
+	if cd.Annotations == nil {
+		cd.Annotations = map[string]string{}
+	}
+	cd.Annotations[StateAnnotation] = state
+}
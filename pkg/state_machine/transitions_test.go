@@ -0,0 +1,139 @@
+package state_machine
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+	aaov1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/aws-account-operator/v1alpha1"
+	gcpv1alpha1 "github.com/tzvatot/openshift-hive-simulator/pkg/externalapis/gcp-project-operator/v1alpha1"
+)
+
+func TestFollowingState(t *testing.T) {
+	states := []config.StateConfig{
+		{Name: "Pending"},
+		{Name: "Provisioning", Next: []config.TransitionConfig{
+			{To: "Retrying", Labels: map[string]string{"scenario": "retry"}},
+		}},
+		{Name: "Retrying", Next: []config.TransitionConfig{{To: "Installing"}}},
+		{Name: "Installing"},
+		{Name: "Running"},
+	}
+	roll := func() float64 { return 0.5 }
+
+	// Without a matching transition the next state in the list follows
+	assert.Equal(t, "Retrying", followingState(states, 1, map[string]string{"scenario": "other"}, roll).Name)
+	assert.Equal(t, "Running", followingState(states, 3, nil, roll).Name)
+
+	// A transition whose labels match wins, an unconditional one always applies
+	states[1].Next = append(states[1].Next, config.TransitionConfig{To: "Installing"})
+	assert.Equal(t, "Retrying", followingState(states, 1, map[string]string{"scenario": "retry"}, roll).Name)
+	assert.Equal(t, "Installing", followingState(states, 1, nil, roll).Name)
+	assert.Equal(t, "Installing", followingState(states, 2, nil, roll).Name)
+
+	// The final state has no following state
+	assert.Nil(t, followingState(states, 4, nil, roll))
+}
+
+func TestFollowingState_Probability(t *testing.T) {
+	states := []config.StateConfig{
+		{Name: "Pending", Next: []config.TransitionConfig{{To: "Error", Probability: 1}}},
+		{Name: "Ready"},
+		{Name: "Error"},
+	}
+	roll := func() float64 { return 0.5 }
+	assert.Equal(t, "Error", followingState(states, 0, nil, roll).Name)
+
+	// The transition applies when the roll is below its probability
+	states[0].Next[0].Probability = 0.6
+	assert.Equal(t, "Error", followingState(states, 0, nil, roll).Name)
+	states[0].Next[0].Probability = 0.4
+	assert.Equal(t, "Ready", followingState(states, 0, nil, roll).Name)
+}
+
+func TestAccountClaimStateMachine_SetRoll(t *testing.T) {
+	ctx := context.Background()
+	sm := NewAccountClaimStateMachine(createTestLogger(), &config.AccountClaimConfig{States: []config.StateConfig{
+		{Name: "Pending", Next: []config.TransitionConfig{{To: "Error", Probability: 0.5}}},
+		{Name: "Ready"},
+		{Name: "Error"},
+	}}, clock.RealClock{})
+	ac := &aaov1alpha1.AccountClaim{ObjectMeta: metav1.ObjectMeta{Name: "test-claim", Namespace: "default"}}
+
+	sm.SetRoll(func() float64 { return 0.9 })
+	state, _ := sm.GetNextState(ctx, ac)
+	assert.Equal(t, aaov1alpha1.ClaimStatusReady, state)
+
+	sm.SetRoll(func() float64 { return 0.1 })
+	state, _ = sm.GetNextState(ctx, ac)
+	assert.Equal(t, aaov1alpha1.ClaimStatusError, state)
+}
+
+func TestClusterDeploymentStateMachine_GetNextState_Branch(t *testing.T) {
+	ctx := context.Background()
+	cfg := createTestClusterDeploymentConfig()
+	cfg.States = []config.StateConfig{
+		{Name: "Pending", DurationSeconds: 1},
+		{Name: "Provisioning", DurationSeconds: 2, Next: []config.TransitionConfig{
+			{To: "Retrying", Labels: map[string]string{"scenario": "retry"}},
+			{To: "Installing"},
+		}},
+		{Name: "Retrying", DurationSeconds: 3},
+		{Name: "Installing", DurationSeconds: 1},
+		{Name: "Running", DurationSeconds: 1},
+	}
+	sm := NewClusterDeploymentStateMachine(createTestLogger(), cfg, clock.RealClock{})
+	cd := &hivev1.ClusterDeployment{ObjectMeta: metav1.ObjectMeta{
+		Name:      "test-cluster",
+		Namespace: "default",
+		Labels:    map[string]string{"scenario": "retry"},
+	}}
+
+	require.NoError(t, sm.ApplyState(ctx, cd, "Provisioning"))
+	assert.NotContains(t, cd.Annotations, StateAnnotation)
+	nextState, _ := sm.GetNextState(ctx, cd)
+	require.Equal(t, "Retrying", nextState)
+
+	// The conditions do not identify the branch target, so it is recorded
+	require.NoError(t, sm.ApplyState(ctx, cd, nextState))
+	assert.Equal(t, "Retrying", cd.Annotations[StateAnnotation])
+	assert.Equal(t, "Retrying", sm.CurrentState(cd))
+	nextState, _ = sm.GetNextState(ctx, cd)
+	require.Equal(t, "Installing", nextState)
+
+	require.NoError(t, sm.ApplyState(ctx, cd, "Running"))
+	assert.NotContains(t, cd.Annotations, StateAnnotation)
+	assert.Equal(t, "Running", sm.CurrentState(cd))
+}
+
+func TestClaimStateMachines_GetNextState_Branch(t *testing.T) {
+	ctx := context.Background()
+	states := []config.StateConfig{
+		{Name: "Pending", Next: []config.TransitionConfig{
+			{To: "Error", Labels: map[string]string{"scenario": "error"}},
+		}},
+		{Name: "Ready"},
+		{Name: "Error"},
+	}
+	labels := map[string]string{"scenario": "error"}
+
+	acSM := NewAccountClaimStateMachine(createTestLogger(), &config.AccountClaimConfig{States: states}, clock.RealClock{})
+	ac := &aaov1alpha1.AccountClaim{ObjectMeta: metav1.ObjectMeta{Name: "test-claim", Namespace: "default"}}
+	acState, _ := acSM.GetNextState(ctx, ac)
+	assert.Equal(t, aaov1alpha1.ClaimStatusReady, acState)
+	ac.Labels = labels
+	acState, _ = acSM.GetNextState(ctx, ac)
+	assert.Equal(t, aaov1alpha1.ClaimStatusError, acState)
+
+	pcSM := NewProjectClaimStateMachine(createTestLogger(), &config.ProjectClaimConfig{States: states}, clock.RealClock{})
+	pc := &gcpv1alpha1.ProjectClaim{ObjectMeta: metav1.ObjectMeta{Name: "test-claim", Namespace: "default", Labels: labels}}
+	pcState, _ := pcSM.GetNextState(ctx, pc)
+	assert.Equal(t, gcpv1alpha1.ClaimStatusError, pcState)
+}
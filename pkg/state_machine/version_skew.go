@@ -0,0 +1,99 @@
+package state_machine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/version"
+
+	configv1 "github.com/openshift/api/config/v1"
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+)
+
+// GetVersionStep returns the version an installed ClusterDeployment reports next on its way
+// to the target version of its image set, or how long until the next step is due. A cluster
+// that reports no version yet starts from the configured initial version, and then moves up
+// one minor version per step interval until it reaches the target. Nothing is due when
+// version skew is not configured or the cluster already reports the target.
+func (sm *ClusterDeploymentStateMachine) GetVersionStep(cd *hivev1.ClusterDeployment, target *version.Version, now time.Time) (*version.Version, time.Duration) {
+	skew := sm.config().VersionSkew
+	if skew == nil || target == nil || !cd.Spec.Installed {
+		return nil, 0
+	}
+
+	current, since := reportedVersion(cd)
+	if current == nil {
+		initial, err := version.ParseGeneric(skew.InitialVersion)
+		if err != nil || !initial.LessThan(target) {
+			return target, 0
+		}
+		return minorVersion(initial.Major(), initial.Minor(), initial.Patch()), 0
+	}
+	if !current.LessThan(target) {
+		return nil, 0
+	}
+
+	interval := sm.accelerate(time.Duration(skew.StepIntervalSeconds) * time.Second)
+	if remaining := interval - now.Sub(since); remaining > 0 {
+		return nil, remaining
+	}
+	if current.Major() != target.Major() || current.Minor()+1 >= target.Minor() {
+		return target, 0
+	}
+	return minorVersion(current.Major(), current.Minor()+1, 0), 0
+}
+
+// ApplyVersion has the ClusterDeployment report the version as its current cluster version,
+// recording the step in the update history. The first version reported is the install version.
+func (sm *ClusterDeploymentStateMachine) ApplyVersion(ctx context.Context, cd *hivev1.ClusterDeployment, v *version.Version, now time.Time) {
+	if cd.Status.ClusterVersionStatus == nil {
+		sm.logger.Info(ctx, "ClusterDeployment %s/%s installed with version %s", cd.Namespace, cd.Name, v)
+		installVersion := v.String()
+		cd.Status.InstallVersion = &installVersion
+		cd.Status.ClusterVersionStatus = &configv1.ClusterVersionStatus{}
+	} else {
+		sm.logger.Info(ctx, "ClusterDeployment %s/%s updated to version %s", cd.Namespace, cd.Name, v)
+	}
+
+	release := configv1.Release{
+		Version: v.String(),
+		Image:   fmt.Sprintf("quay.io/openshift-release-dev/ocp-release:%s-x86_64", v),
+	}
+	completed := metav1.NewTime(now)
+	status := cd.Status.ClusterVersionStatus
+	status.Desired = release
+	status.History = append([]configv1.UpdateHistory{{
+		State:          configv1.CompletedUpdate,
+		StartedTime:    completed,
+		CompletionTime: &completed,
+		Version:        release.Version,
+		Image:          release.Image,
+	}}, status.History...)
+}
+
+// reportedVersion returns the cluster version the ClusterDeployment reports and when it was
+// reached, or nil if it reports none
+func reportedVersion(cd *hivev1.ClusterDeployment) (*version.Version, time.Time) {
+	status := cd.Status.ClusterVersionStatus
+	if status == nil {
+		return nil, time.Time{}
+	}
+	v, err := version.ParseGeneric(status.Desired.Version)
+	if err != nil {
+		return nil, time.Time{}
+	}
+
+	// The history is ordered newest first
+	var since time.Time
+	if len(status.History) > 0 && status.History[0].CompletionTime != nil {
+		since = status.History[0].CompletionTime.Time
+	}
+	return v, since
+}
+
+// minorVersion builds a version from its components
+func minorVersion(major, minor, patch uint) *version.Version {
+	return version.MustParseSemantic(fmt.Sprintf("%d.%d.%d", major, minor, patch))
+}
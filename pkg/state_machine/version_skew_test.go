@@ -0,0 +1,83 @@
+package state_machine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/utils/clock"
+
+	hivev1 "github.com/openshift/hive/apis/hive/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tzvatot/openshift-hive-simulator/pkg/config"
+)
+
+func createTestVersionSkewStateMachine(initialVersion string) *ClusterDeploymentStateMachine {
+	cfg := createTestClusterDeploymentConfig()
+	cfg.VersionSkew = &config.VersionSkewConfig{InitialVersion: initialVersion, StepIntervalSeconds: 60}
+	return NewClusterDeploymentStateMachine(createTestLogger(), cfg, clock.RealClock{})
+}
+
+func TestClusterDeploymentStateMachine_VersionSkewConverges(t *testing.T) {
+	ctx := context.Background()
+	sm := createTestVersionSkewStateMachine("4.12")
+	cd := &hivev1.ClusterDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cluster", Namespace: "default"},
+		Spec:       hivev1.ClusterDeploymentSpec{Installed: true},
+	}
+	target := version.MustParseSemantic("4.15.2")
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Installed at the initial version
+	next, remaining := sm.GetVersionStep(cd, target, now)
+	require.NotNil(t, next)
+	assert.Zero(t, remaining)
+	assert.Equal(t, "4.12.0", next.String())
+	sm.ApplyVersion(ctx, cd, next, now)
+	require.NotNil(t, cd.Status.InstallVersion)
+	assert.Equal(t, "4.12.0", *cd.Status.InstallVersion)
+
+	// Each step waits out the interval, then moves up one minor version
+	for _, expected := range []string{"4.13.0", "4.14.0", "4.15.2"} {
+		next, remaining = sm.GetVersionStep(cd, target, now.Add(20*time.Second))
+		assert.Nil(t, next)
+		assert.Equal(t, 40*time.Second, remaining)
+
+		now = now.Add(time.Minute)
+		next, remaining = sm.GetVersionStep(cd, target, now)
+		require.NotNil(t, next)
+		assert.Zero(t, remaining)
+		assert.Equal(t, expected, next.String())
+		sm.ApplyVersion(ctx, cd, next, now)
+		assert.Equal(t, expected, cd.Status.ClusterVersionStatus.Desired.Version)
+	}
+
+	// Converged
+	next, remaining = sm.GetVersionStep(cd, target, now.Add(time.Hour))
+	assert.Nil(t, next)
+	assert.Zero(t, remaining)
+
+	history := cd.Status.ClusterVersionStatus.History
+	require.Len(t, history, 4)
+	assert.Equal(t, "4.15.2", history[0].Version)
+	assert.Equal(t, "4.12.0", history[3].Version)
+	assert.Equal(t, "4.12.0", *cd.Status.InstallVersion)
+}
+
+func TestClusterDeploymentStateMachine_VersionSkewNotOlder(t *testing.T) {
+	sm := createTestVersionSkewStateMachine("4.16.0")
+	cd := &hivev1.ClusterDeployment{Spec: hivev1.ClusterDeploymentSpec{Installed: true}}
+
+	// An image set that is not newer than the initial version is reported as is
+	next, _ := sm.GetVersionStep(cd, version.MustParseSemantic("4.15.0"), time.Now())
+	require.NotNil(t, next)
+	assert.Equal(t, "4.15.0", next.String())
+
+	// Without version skew nothing is reported
+	next, _ = NewClusterDeploymentStateMachine(createTestLogger(), createTestClusterDeploymentConfig(), clock.RealClock{}).GetVersionStep(cd, version.MustParseSemantic("4.15.0"), time.Now())
+	assert.Nil(t, next)
+}